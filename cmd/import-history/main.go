@@ -0,0 +1,453 @@
+// Command import-history loads daily price history into the candles
+// collection, so backtests, paper runs and statistics work from real prices
+// without calling a provider while the server runs.
+//
+//	go run ./cmd/import-history -symbols AAPL,MSFT -full
+//	go run ./cmd/import-history -csv ./history -from 2024-01-01
+//	go run ./cmd/import-history -csv prices.csv -symbols AAPL -dry-run
+//
+// Without -csv the history is fetched from Alpha Vantage's
+// TIME_SERIES_DAILY with ALPHA_VANTAGE_API_KEY, at most
+// ALPHA_VANTAGE_CALLS_PER_MINUTE requests a minute (default 5). -csv reads a
+// file, or a directory of SYMBOL.csv files, with a header row naming date
+// (or timestamp), open, high, low, close, volume and, for files holding
+// several symbols, symbol; Alpha Vantage's CSV downloads read as they are.
+//
+// Rows with an unparseable date, non-positive prices, a high or low that
+// does not bound the open and close, negative volume or a date in the future
+// are skipped and reported (-strict stops at the first), as are repeated
+// days. Days already stored are kept unless -replace is set, so running an
+// import again only adds what is new.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+)
+
+// Invalid rows logged per symbol before the rest are only counted
+const maxReportedRows = 5
+
+type options struct {
+	symbols []string
+	csvPath string
+	full    bool
+	from    time.Time
+	to      time.Time
+	replace bool
+	strict  bool
+	dryRun  bool
+}
+
+// result counts what happened to one symbol's rows
+type result struct {
+	rows      int
+	invalid   int
+	duplicate int
+	outside   int
+	added     int64
+	replaced  int64
+}
+
+func main() {
+	var opts options
+	var symbols, from, to string
+	flag.StringVar(&symbols, "symbols", "", "comma separated symbols to import; with -csv, defaults to every symbol found")
+	flag.StringVar(&opts.csvPath, "csv", "", "CSV file or directory of SYMBOL.csv files to read instead of Alpha Vantage")
+	flag.BoolVar(&opts.full, "full", false, "fetch the full Alpha Vantage history instead of the last 100 days")
+	flag.StringVar(&from, "from", "", "first day to import, YYYY-MM-DD")
+	flag.StringVar(&to, "to", "", "last day to import, YYYY-MM-DD")
+	flag.BoolVar(&opts.replace, "replace", false, "overwrite candles already stored for the same days")
+	flag.BoolVar(&opts.strict, "strict", false, "stop at the first invalid row")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "validate the history without writing it")
+	flag.Parse()
+
+	for _, symbol := range strings.Split(symbols, ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			opts.symbols = append(opts.symbols, symbol)
+		}
+	}
+	var err error
+	if opts.from, err = parseFlagDay(from); err != nil {
+		log.Fatalf("Invalid -from: %v", err)
+	}
+	if opts.to, err = parseFlagDay(to); err != nil {
+		log.Fatalf("Invalid -to: %v", err)
+	}
+	if opts.csvPath == "" && len(opts.symbols) == 0 {
+		log.Fatal("Pass -symbols to fetch from Alpha Vantage, or -csv to read files")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ No .env file, using the process environment")
+	}
+
+	var history map[string][]models.Candle
+	var results map[string]*result
+	if opts.csvPath != "" {
+		history, results, err = readCSVHistory(opts)
+	} else {
+		history, results, err = fetchHistory(opts)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var candleService *services.CandleService
+	ctx := context.Background()
+	if !opts.dryRun {
+		config.ConnectDB()
+		defer config.DisconnectDB()
+		candleService = services.NewCandleService()
+		if err := candleService.EnsureIndexes(ctx); err != nil {
+			log.Printf("⚠️ Error creating candle indexes, existing duplicates are left in place: %v", err)
+		}
+	}
+
+	imported := 0
+	for _, symbol := range sortedSymbols(history) {
+		candles := history[symbol]
+		r := results[symbol]
+		if candleService != nil && len(candles) > 0 {
+			r.added, r.replaced, err = candleService.ImportCandles(ctx, candles, opts.replace)
+			if err != nil {
+				log.Fatalf("Failed to save candles for %s: %v", symbol, err)
+			}
+		}
+		imported += len(candles)
+		summary := fmt.Sprintf("%s: %d rows, %d valid, %d invalid, %d repeated, %d outside the range",
+			symbol, r.rows, len(candles), r.invalid, r.duplicate, r.outside)
+		if candleService != nil {
+			summary += fmt.Sprintf("; %d added, %d replaced", r.added, r.replaced)
+		}
+		log.Printf("📈 %s", summary)
+	}
+
+	if opts.dryRun {
+		fmt.Printf("🔎 Dry run: %d candles for %d symbols are valid, nothing was written\n", imported, len(history))
+		return
+	}
+	fmt.Printf("✅ Imported %d candles for %d symbols\n", imported, len(history))
+}
+
+// readCSVHistory reads the history of every requested symbol from -csv
+func readCSVHistory(opts options) (map[string][]models.Candle, map[string]*result, error) {
+	info, err := os.Stat(opts.csvPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make(map[string]string) // Symbol to file, for a directory
+	if info.IsDir() {
+		symbols := opts.symbols
+		if len(symbols) == 0 {
+			paths, err := filepath.Glob(filepath.Join(opts.csvPath, "*.csv"))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, path := range paths {
+				symbols = append(symbols, strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))))
+			}
+			if len(symbols) == 0 {
+				return nil, nil, fmt.Errorf("no .csv files in %s", opts.csvPath)
+			}
+		}
+		for _, symbol := range symbols {
+			files[symbol] = filepath.Join(opts.csvPath, symbol+".csv")
+			if _, err := os.Stat(files[symbol]); errors.Is(err, os.ErrNotExist) {
+				files[symbol] = filepath.Join(opts.csvPath, strings.ToLower(symbol)+".csv")
+			}
+		}
+	}
+
+	history := make(map[string][]models.Candle)
+	results := make(map[string]*result)
+	read := func(path, symbol string) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return parseHistory(file, symbol, opts, history, results)
+	}
+
+	if !info.IsDir() {
+		symbol := ""
+		if len(opts.symbols) == 1 {
+			symbol = opts.symbols[0]
+		}
+		if err := read(opts.csvPath, symbol); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", opts.csvPath, err)
+		}
+		// A file holding several symbols is narrowed to those asked for
+		if len(opts.symbols) > 0 {
+			wanted := make(map[string]bool)
+			for _, symbol := range opts.symbols {
+				wanted[symbol] = true
+			}
+			for symbol := range history {
+				if !wanted[symbol] {
+					delete(history, symbol)
+				}
+			}
+		}
+		return history, results, nil
+	}
+
+	for symbol, path := range files {
+		if err := read(path, symbol); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return history, results, nil
+}
+
+// fetchHistory downloads each symbol's daily history from Alpha Vantage as
+// CSV, spacing the requests to stay within the rate limit
+func fetchHistory(opts options) (map[string][]models.Candle, map[string]*result, error) {
+	apiKey := os.Getenv("ALPHA_VANTAGE_API_KEY")
+	if apiKey == "" {
+		return nil, nil, errors.New("ALPHA_VANTAGE_API_KEY is required to fetch from Alpha Vantage; pass -csv to read files instead")
+	}
+	spacing := time.Minute / time.Duration(max(config.GetEnvInt("ALPHA_VANTAGE_CALLS_PER_MINUTE", 5), 1))
+	outputSize := "compact"
+	if opts.full {
+		outputSize = "full"
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	history := make(map[string][]models.Candle)
+	results := make(map[string]*result)
+	for i, symbol := range opts.symbols {
+		if i > 0 {
+			time.Sleep(spacing)
+		}
+		endpoint := fmt.Sprintf("https://www.alphavantage.co/query?function=TIME_SERIES_DAILY&symbol=%s&outputsize=%s&datatype=csv&apikey=%s",
+			url.QueryEscape(symbol), outputSize, url.QueryEscape(apiKey))
+		body, err := download(client, endpoint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching %s: %v", symbol, err)
+		}
+		if err := parseHistory(bytes.NewReader(body), symbol, opts, history, results); err != nil {
+			return nil, nil, fmt.Errorf("fetching %s: %v", symbol, err)
+		}
+		log.Printf("⬇️ Fetched %s from Alpha Vantage", symbol)
+	}
+	return history, results, nil
+}
+
+// download fetches a CSV response. Alpha Vantage answers errors and rate
+// limiting with a JSON object instead.
+func download(client *http.Client, endpoint string) ([]byte, error) {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var apiError map[string]interface{}
+		if err := json.Unmarshal(trimmed, &apiError); err == nil {
+			for _, key := range []string{"Error Message", "Information", "Note"} {
+				if message, ok := apiError[key].(string); ok {
+					return nil, fmt.Errorf("API error: %s", message)
+				}
+			}
+		}
+		return nil, fmt.Errorf("unexpected response: %s", trimmed)
+	}
+	return body, nil
+}
+
+// parseHistory reads CSV rows into history, validating each row and
+// dropping days already read. Rows are for symbol unless the file has a
+// symbol column.
+func parseHistory(r io.Reader, symbol string, opts options, history map[string][]models.Candle, results map[string]*result) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "timestamp", "time":
+			name = "date"
+		case "ticker":
+			name = "symbol"
+		}
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	for _, name := range []string{"date", "open", "high", "low", "close", "volume"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("header has no %s column", name)
+		}
+	}
+	symbolColumn, hasSymbol := columns["symbol"]
+	if !hasSymbol && symbol == "" {
+		return errors.New("the file has no symbol column; pass exactly one symbol with -symbols")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	seen := make(map[string]map[time.Time]bool)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rowSymbol := symbol
+		if hasSymbol && symbolColumn < len(record) && strings.TrimSpace(record[symbolColumn]) != "" {
+			rowSymbol = strings.ToUpper(strings.TrimSpace(record[symbolColumn]))
+		}
+		if rowSymbol == "" {
+			return fmt.Errorf("line %d: no symbol", line)
+		}
+		res := results[rowSymbol]
+		if res == nil {
+			res = &result{}
+			results[rowSymbol] = res
+			history[rowSymbol] = []models.Candle{}
+		}
+		if seen[rowSymbol] == nil {
+			// Days read from an earlier file count as seen
+			seen[rowSymbol] = make(map[time.Time]bool)
+			for _, candle := range history[rowSymbol] {
+				seen[rowSymbol][candle.Time] = true
+			}
+		}
+		res.rows++
+
+		candle, err := parseCandle(rowSymbol, record, columns, today)
+		if err != nil {
+			if opts.strict {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+			if res.invalid < maxReportedRows {
+				log.Printf("⚠️ %s line %d skipped: %v", rowSymbol, line, err)
+			}
+			res.invalid++
+			continue
+		}
+		if (!opts.from.IsZero() && candle.Time.Before(opts.from)) || (!opts.to.IsZero() && candle.Time.After(opts.to)) {
+			res.outside++
+			continue
+		}
+		if seen[rowSymbol][candle.Time] {
+			res.duplicate++
+			continue
+		}
+		seen[rowSymbol][candle.Time] = true
+		history[rowSymbol] = append(history[rowSymbol], candle)
+	}
+	return nil
+}
+
+// parseCandle validates one row as a daily candle starting at UTC midnight
+func parseCandle(symbol string, record []string, columns map[string]int, today time.Time) (models.Candle, error) {
+	field := func(name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	day, err := parseDay(field("date"))
+	if err != nil {
+		return models.Candle{}, err
+	}
+	if day.After(today) {
+		return models.Candle{}, fmt.Errorf("date %s is in the future", day.Format("2006-01-02"))
+	}
+
+	prices := make(map[string]float64, 4)
+	for _, name := range []string{"open", "high", "low", "close"} {
+		value, err := strconv.ParseFloat(field(name), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+			return models.Candle{}, fmt.Errorf("%s %q is not a positive price", name, field(name))
+		}
+		prices[name] = value
+	}
+	openPrice, high, low, closePrice := prices["open"], prices["high"], prices["low"], prices["close"]
+	if high < math.Max(openPrice, closePrice) || low > math.Min(openPrice, closePrice) {
+		return models.Candle{}, fmt.Errorf("high %.4f and low %.4f do not bound open %.4f and close %.4f", high, low, openPrice, closePrice)
+	}
+
+	volume, err := strconv.ParseFloat(field("volume"), 64)
+	if err != nil || math.IsNaN(volume) || volume < 0 {
+		return models.Candle{}, fmt.Errorf("volume %q is not a non-negative number", field("volume"))
+	}
+
+	return models.Candle{
+		Symbol: symbol,
+		Time:   day,
+		Open:   openPrice,
+		High:   high,
+		Low:    low,
+		Close:  closePrice,
+		Volume: int64(volume),
+	}, nil
+}
+
+// parseDay reads a date, or a timestamp whose day is taken
+func parseDay(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "01/02/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Truncate(24 * time.Hour), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q is not YYYY-MM-DD", value)
+}
+
+func parseFlagDay(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func sortedSymbols(history map[string][]models.Candle) []string {
+	symbols := make([]string, 0, len(history))
+	for symbol := range history {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
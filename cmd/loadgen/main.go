@@ -0,0 +1,299 @@
+// Command loadgen simulates concurrent traders and WebSocket subscribers
+// against a running server and reports order latency and tick throughput.
+//
+//	go run ./cmd/loadgen -url http://localhost:8080 -users 50 -subscribers 200 -duration 1m
+//
+// With -admin-token the report is posted to /api/admin/metrics/load-tests so
+// runs can be compared on the metrics endpoint.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"trading-simulator/internal/models"
+)
+
+type options struct {
+	baseURL     string
+	users       int
+	subscribers int
+	duration    time.Duration
+	interval    time.Duration
+	symbols     []string
+	adminToken  string
+	label       string
+}
+
+// stats collects results from every simulated user and subscriber
+type stats struct {
+	ordersPlaced    atomic.Int64
+	ordersFailed    atomic.Int64
+	ticksReceived   atomic.Int64
+	subscriberDrops atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (s *stats) recordOrder(latency time.Duration, ok bool) {
+	if !ok {
+		s.ordersFailed.Add(1)
+		return
+	}
+	s.ordersPlaced.Add(1)
+	s.mu.Lock()
+	s.latencies = append(s.latencies, latency)
+	s.mu.Unlock()
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func main() {
+	var opts options
+	var symbols string
+	flag.StringVar(&opts.baseURL, "url", "http://localhost:8080", "server base URL")
+	flag.IntVar(&opts.users, "users", 10, "concurrent users placing orders")
+	flag.IntVar(&opts.subscribers, "subscribers", 50, "concurrent WebSocket subscribers")
+	flag.DurationVar(&opts.duration, "duration", 30*time.Second, "how long to generate load")
+	flag.DurationVar(&opts.interval, "interval", time.Second, "pause between orders of one user")
+	flag.StringVar(&symbols, "symbols", "AAPL,GOOGL,MSFT,TSLA,AMZN", "comma separated symbols to trade")
+	flag.StringVar(&opts.adminToken, "admin-token", "", "admin JWT used to submit the report to the metrics endpoint")
+	flag.StringVar(&opts.label, "label", "", "tag stored with the report, such as a commit hash")
+	flag.Parse()
+	opts.baseURL = strings.TrimRight(opts.baseURL, "/")
+	opts.symbols = strings.Split(symbols, ",")
+
+	report, err := run(opts)
+	if err != nil {
+		log.Fatalf("Load test failed: %v", err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+
+	if opts.adminToken != "" {
+		if err := submit(opts, report); err != nil {
+			log.Fatalf("Failed to submit report: %v", err)
+		}
+		log.Println("Report submitted to the metrics endpoint")
+	}
+}
+
+func run(opts options) (*models.LoadTestReport, error) {
+	prices, err := fetchPrices(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	runID := randomID()
+	tokens := make([]string, opts.users)
+	for i := range tokens {
+		if tokens[i], err = register(opts, fmt.Sprintf("lg%s_%d", runID, i)); err != nil {
+			return nil, fmt.Errorf("registering user %d: %w", i, err)
+		}
+	}
+	log.Printf("Registered %d users, starting %d subscribers", opts.users, opts.subscribers)
+
+	var s stats
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(opts.duration)
+	start := time.Now()
+
+	for i := 0; i < opts.subscribers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			subscribe(opts, fmt.Sprintf("lgsub%s_%d", runID, i), deadline, &s)
+		}(i)
+	}
+	for i, token := range tokens {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			trade(opts, token, i, prices, deadline, &s)
+		}(i, token)
+	}
+	wg.Wait()
+	elapsed := time.Since(start).Seconds()
+
+	return &models.LoadTestReport{
+		Users:           opts.users,
+		Subscribers:     opts.subscribers,
+		Duration:        elapsed,
+		OrdersPlaced:    s.ordersPlaced.Load(),
+		OrdersFailed:    s.ordersFailed.Load(),
+		OrdersPerSecond: float64(s.ordersPlaced.Load()) / elapsed,
+		OrderLatency:    percentiles(s.latencies),
+		TicksReceived:   s.ticksReceived.Load(),
+		TicksPerSecond:  float64(s.ticksReceived.Load()) / elapsed,
+		SubscriberDrops: s.subscriberDrops.Load(),
+		Label:           opts.label,
+	}, nil
+}
+
+// trade alternates buying and selling one share, rotating symbols so the
+// per-symbol order interval is not what limits throughput
+func trade(opts options, token string, offset int, prices map[string]float64, deadline time.Time, s *stats) {
+	held := make(map[string]bool)
+	for i := offset; time.Now().Before(deadline); i++ {
+		symbol := opts.symbols[i%len(opts.symbols)]
+		side := "buy"
+		if held[symbol] {
+			side = "sell"
+		}
+
+		began := time.Now()
+		err := post(opts.baseURL+"/api/orders/place", token, map[string]interface{}{
+			"symbol":    symbol,
+			"type":      side,
+			"orderType": "market",
+			"quantity":  1,
+			"price":     prices[symbol],
+		}, nil)
+		s.recordOrder(time.Since(began), err == nil)
+		if err == nil {
+			held[symbol] = side == "buy"
+		}
+
+		time.Sleep(opts.interval)
+	}
+}
+
+// subscribe counts messages received on the WebSocket feed until the deadline
+func subscribe(opts options, username string, deadline time.Time, s *stats) {
+	u, _ := url.Parse(opts.baseURL)
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = "/ws"
+	u.RawQuery = url.Values{"username": {username}}.Encode()
+
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		s.subscriberDrops.Add(1)
+		return
+	}
+	defer conn.Close()
+
+	// Keep the anonymous socket from being dropped as idle on long runs
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ping := time.NewTicker(time.Minute)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ping.C:
+				conn.WriteJSON(map[string]string{"action": "ping"})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(deadline)
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if time.Now().Before(deadline) {
+				s.subscriberDrops.Add(1)
+			}
+			return
+		}
+		s.ticksReceived.Add(1)
+	}
+}
+
+func fetchPrices(opts options) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	for _, symbol := range opts.symbols {
+		resp, err := client.Get(opts.baseURL + "/api/stocks/" + symbol)
+		if err != nil {
+			return nil, err
+		}
+		var stock models.Stock
+		err = json.NewDecoder(resp.Body).Decode(&stock)
+		resp.Body.Close()
+		if err != nil || stock.Price <= 0 {
+			return nil, fmt.Errorf("no price for %s", symbol)
+		}
+		prices[symbol] = stock.Price
+	}
+	return prices, nil
+}
+
+func register(opts options, username string) (string, error) {
+	var auth struct {
+		Token string `json:"token"`
+	}
+	err := post(opts.baseURL+"/api/auth/register", "", map[string]string{
+		"username": username,
+		"email":    username + "@loadgen.local",
+		"password": "loadgen-" + username,
+	}, &auth)
+	return auth.Token, err
+}
+
+func submit(opts options, report *models.LoadTestReport) error {
+	return post(opts.baseURL+"/api/admin/metrics/load-tests", opts.adminToken, report, nil)
+}
+
+// post sends a JSON body and decodes the response into out when it is not nil
+func post(endpoint, token string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func percentiles(latencies []time.Duration) models.LatencyReport {
+	if len(latencies) == 0 {
+		return models.LatencyReport{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	at := func(p float64) float64 {
+		return float64(latencies[int(p*float64(len(latencies)-1))].Microseconds()) / 1000
+	}
+	return models.LatencyReport{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: at(1)}
+}
+
+func randomID() string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate run ID: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
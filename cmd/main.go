@@ -1,23 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	"trading-simulator/config"
+	"trading-simulator/internal/chaos"
 	"trading-simulator/internal/handlers"
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/secrets"
 	"trading-simulator/internal/services"
+	"trading-simulator/web"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Negotiate permessage-deflate; clients opt in with ?compress=true
+	EnableCompression: true,
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins in development
 	},
@@ -30,24 +38,284 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Fault injection is for development only; it must be on before the
+	// Mongo client is created
+	if config.GetEnv("CHAOS_ENABLED", "") == "true" {
+		chaos.Enable()
+		log.Println("⚠️ CHAOS_ENABLED is set: faults can be injected through /api/admin/chaos. Never set it in production")
+	}
+
 	// Initialize MongoDB
 	config.ConnectDB()
 
 	// Initialize services
 	marketService := services.NewMarketDataService()
+	symbolService := services.NewSymbolService()
+	screenerService := services.NewScreenerService(symbolService, marketService)
+	fundamentalsService := services.NewFundamentalsService(
+		services.NewAlphaVantageFundamentalsProvider(os.Getenv("ALPHA_VANTAGE_API_KEY")),
+		symbolService,
+	)
+	orderGuard := services.NewOrderGuardService()
+	tierService := services.NewTierService()
+	featureFlagService := services.NewFeatureFlagService(tierService)
+	competitionService := services.NewCompetitionService(marketService, featureFlagService)
 	wsHub := services.NewWebSocketHub()
-	orderService := services.NewOrderService(marketService)
-	advancedOrderService := services.NewAdvancedOrderService(marketService)
-	authService := services.NewAuthService()
+	eventBus := services.NewEventBus()
+	outboxService := services.NewOutboxService(eventBus)
+	dataModeService := services.NewDataModeService()
+	orderService := services.NewOrderService(marketService, symbolService, orderGuard, competitionService, eventBus, outboxService, dataModeService, featureFlagService)
+	tenantService := services.NewTenantService()
+	marketClock := services.NewMarketClock(symbolService)
+	classroomService := services.NewClassroomService(orderService, tenantService)
+	trainingService := services.NewTrainingService()
+	orderEngine := services.NewOrderEngine(orderService, tenantService, classroomService, marketClock, trainingService)
+	advancedOrderService := services.NewAdvancedOrderService(marketService, orderEngine, wsHub)
+	basketService := services.NewBasketService(orderEngine)
+	ledgerService := services.NewLedgerService(orderService)
+	achievementService := services.NewAchievementService(orderService, tenantService, ledgerService, wsHub, eventBus)
+	profileService := services.NewProfileService(orderService, achievementService, tenantService, ledgerService)
+	etfService := services.NewETFService(symbolService, marketService)
+	journalService := services.NewJournalService(orderService)
+	orderHistoryService := services.NewOrderHistoryService()
+	corporateActionService := services.NewCorporateActionService(orderService, symbolService, marketService, etfService, ledgerService)
+	costBasisService := services.NewCostBasisService(orderService)
+	invariantService := services.NewInvariantService(costBasisService, tenantService)
+	referralService := services.NewReferralService()
+	riskService := services.NewRiskService(orderService, marketService, symbolService, featureFlagService)
+	statsService := services.NewStatsService(wsHub)
+	maintenanceService := services.NewMaintenanceService(wsHub, eventBus)
+	simulationService := services.NewSimulationService()
+	mailer := services.NewMailer()
+	authService := services.NewAuthService(referralService, tenantService, tierService, eventBus, mailer)
+	auditService := services.NewAuditService(eventBus)
+	accessPolicyService := services.NewAccessPolicyService(auditService, mailer)
+	tickLogService := services.NewTickLogService()
+	performanceService := services.NewPerformanceService(wsHub, tickLogService)
+	candleService := services.NewCandleService()
+	symbolStatsService := services.NewSymbolStatsService(candleService)
+	customSymbolService := services.NewCustomSymbolService(symbolService, marketService)
+	customSymbolService.Load(context.Background())
+	etfService.Load(context.Background())
+	delayedQuoteService := services.NewDelayedQuoteService(marketService, symbolService, dataModeService)
+	delayedQuoteService.Load(context.Background())
+	sessionService := services.NewSessionService()
+	sessionService.Load(context.Background())
+	interestService := services.NewInterestService(ledgerService)
+	borrowService := services.NewBorrowService(symbolService, marketService, ledgerService)
+	borrowService.Load(context.Background())
+	accountService := services.NewAccountService(orderService, marketService, sessionService, featureFlagService, wsHub)
+	playbackService := services.NewPlaybackService()
+	strategyService := services.NewStrategyService(symbolService, candleService, orderService)
+	strategyRunner := services.NewStrategyRunner(strategyService, orderEngine, marketService, maintenanceService)
+	strategyRunner.Load(context.Background())
+	competitionAnnouncer := services.NewCompetitionAnnouncer(competitionService, eventBus)
+	keyring, err := secrets.NewKeyring(config.GetEnv("SECRETS_KEYS", ""))
+	if err != nil {
+		log.Fatalf("Invalid SECRETS_KEYS: %v", err)
+	}
+	if !keyring.Enabled() {
+		log.Println("⚠️ SECRETS_KEYS not set, secrets are stored unencrypted")
+	}
+	integrationService := services.NewIntegrationService(eventBus, keyring)
+	notificationService := services.NewNotificationService(services.NewPushSenders(), wsHub, eventBus)
+	statementService := services.NewStatementService(orderService, marketService, candleService)
+	digestService := services.NewDigestService(accountService, symbolService, mailer)
+
+	// Authenticated sockets can place and cancel orders
+	wsHub.SetTrading(services.NewSocketTrading(orderEngine, advancedOrderService, maintenanceService))
+	// Messages for users who are offline wait in their inbox
+	inboxService := services.NewInboxService()
+	wsHub.SetInbox(inboxService)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := inboxService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating inbox indexes: %v", err)
+		}
+	}()
+	// Large deposits and withdrawals wait for an admin's or teacher's review
+	cashRequestService := services.NewCashRequestService(ledgerService, wsHub)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := cashRequestService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating cash request indexes: %v", err)
+		}
+	}()
+	// Running competitions' standings are pushed over the leaderboard channel
+	leaderboardFeed := services.NewLeaderboardFeed(competitionService, marketService, wsHub, eventBus)
 
 	// Start WebSocket hub in goroutine
 	go wsHub.Run()
+	go leaderboardFeed.Run()
+
+	// Price ticks feed the risk history and the WebSocket broadcast
+	eventBus.Subscribe(services.EventPriceTick, func(event services.Event) {
+		stock := event.Payload.(models.Stock)
+		marketService.RecordTick(stock)
+		symbolStatsService.RecordTick(stock)
+		sessionService.RecordTick(stock)
+		playbackService.RecordTick(stock)
+		tickLogService.RecordTick(stock)
+		strategyRunner.OnTick(stock)
+		wsHub.BroadcastStock(stock)
+	})
+
+	// Seed symbol statistics from stored candles
+	go func() {
+		var symbols []string
+		for _, info := range symbolService.ListSymbols() {
+			symbols = append(symbols, info.Symbol)
+		}
+		symbolStatsService.LoadHistory(context.Background(), symbols)
+	}()
+
+	// An optional real-time provider prices its symbols in place of the
+	// simulator once it connects
+	var marketStream *services.MarketStream
+	if name := config.GetEnv("MARKET_STREAM_PROVIDER", ""); name != "" {
+		provider, err := services.NewQuoteStreamProvider(name, os.Getenv("MARKET_STREAM_API_KEY"))
+		if err != nil {
+			log.Printf("⚠️ Market data streaming disabled: %v", err)
+		} else {
+			symbols := strings.Split(config.GetEnv("MARKET_STREAM_SYMBOLS", "AAPL,GOOGL,MSFT,TSLA,AMZN"), ",")
+			marketStream = services.NewMarketStream(provider, marketService, eventBus, symbols)
+			go marketStream.Run()
+		}
+	}
+
+	// Balances can be shown in other currencies, at simulated exchange rates
+	// unless FX_PROVIDER names a provider
+	var fxProvider services.FXRateProvider
+	if name := config.GetEnv("FX_PROVIDER", ""); name != "" {
+		provider, err := services.NewFXRateProvider(name, os.Getenv("ALPHA_VANTAGE_API_KEY"))
+		if err != nil {
+			log.Printf("⚠️ Simulating exchange rates: %v", err)
+		} else {
+			fxProvider = provider
+		}
+	}
+	fxService := services.NewFXService(fxProvider)
+	go fxService.Run()
 
 	// Start market data simulator
-	go simulateMarketData(wsHub, marketService)
+	go simulateMarketData(eventBus, marketService, simulationService, dataModeService, marketStream)
+
+	// Optional Kafka/NATS streaming of ticks and fills
+	streamService, err := services.NewStreamService(eventBus)
+	if err != nil {
+		log.Printf("⚠️ Event streaming disabled: %v", err)
+	} else if streamService != nil {
+		go streamService.Run()
+	}
+
+	// Start outbox delivery
+	go dispatchOutbox(outboxService)
 
 	// Start stop order monitoring
-	go monitorStopOrders(advancedOrderService)
+	go monitorStopOrders(advancedOrderService, maintenanceService)
+
+	// Start cancelling expired and orphaned stop orders
+	go sweepStaleOrders(advancedOrderService)
+
+	// Start putting scheduled orders into effect
+	go activateScheduledOrders(advancedOrderService, maintenanceService)
+
+	// Start periodic achievement snapshots
+	go monitorAchievements(achievementService)
+
+	// Start corporate action generation and processing
+	go processCorporateActions(corporateActionService)
+
+	// Start purging data of deleted accounts
+	go purgeDeletedAccounts(accountService)
+
+	// Accrue interest and borrow fees once a day
+	go accrueDailyCharges(interestService, borrowService)
+
+	// Check every account adds up to its history once a day
+	go checkInvariants(invariantService)
+
+	// Carry existing balances into the journal, then keep stored cash in
+	// step with it
+	go materializeBalances(journalService)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := journalService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating journal indexes: %v", err)
+		}
+	}()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := orderHistoryService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating order event indexes: %v", err)
+		}
+	}()
+
+	// Start writing playback samples; the TTL index expires old ones
+	go playbackService.Run()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := playbackService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating tick history indexes: %v", err)
+		}
+	}()
+
+	// Start writing the raw tick log; the TTL index expires old ticks
+	go tickLogService.Run()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := tickLogService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating tick log indexes: %v", err)
+		}
+	}()
+
+	// Imported and generated history keeps one candle per symbol and day
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := candleService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating candle indexes: %v", err)
+		}
+	}()
+
+	// Enforce unique usernames and emails
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := authService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating user indexes, usernames may not be unique: %v", err)
+		}
+	}()
+
+	// Start fetching real quotes for delayed mode
+	go delayedQuoteService.Run()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := delayedQuoteService.EnsureIndexes(ctx); err != nil {
+			log.Printf("Error creating delayed quote indexes: %v", err)
+		}
+	}()
+
+	// Start competition announcements and webhook delivery
+	go announceCompetitions(competitionAnnouncer)
+	go deliverWebhooks(integrationService)
+
+	// Start generating monthly statements
+	go generateStatements(statementService)
+
+	// Start emailing daily and weekly digests
+	go sendDigests(digestService)
+
+	// Push day P&L to connected traders
+	go pushDayChanges(accountService)
+
 
 	// Create Gin router
 	router := gin.Default()
@@ -56,7 +324,7 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -66,53 +334,299 @@ func main() {
 		c.Next()
 	})
 
+	// Security headers, body size and JSON limits
+	router.Use(handlers.SecurityHeaders())
+	router.Use(handlers.LimitRequestBody(
+		int64(config.GetEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		config.GetEnvInt("MAX_JSON_DEPTH", 32),
+	))
+
 	// Initialize handlers
-	marketHandler := handlers.NewMarketHandler(marketService)
-	orderHandler := handlers.NewOrderHandler(orderService)
+	marketHandler := handlers.NewMarketHandler(marketService, symbolService, screenerService, fundamentalsService, candleService, symbolStatsService, maintenanceService, simulationService, wsHub, dataModeService)
+	orderHandler := handlers.NewOrderHandler(orderService, orderEngine, advancedOrderService, accountService, fxService)
 	advancedOrderHandler := handlers.NewAdvancedOrderHandler(advancedOrderService)
-	authHandler := handlers.NewAuthHandler(authService)
+	accessHandler := handlers.NewAccessHandler(accessPolicyService)
+	authHandler := handlers.NewAuthHandler(authService, auditService, accessHandler)
+	adminHandler := handlers.NewAdminHandler(orderGuard, statsService)
+	featureFlagHandler := handlers.NewFeatureFlagHandler(featureFlagService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
+	simulationHandler := handlers.NewSimulationHandler(simulationService)
+	trainingHandler := handlers.NewTrainingHandler(trainingService)
+	dataModeHandler := handlers.NewDataModeHandler(dataModeService, authService)
+	connectionHandler := handlers.NewConnectionHandler(wsHub)
+	competitionHandler := handlers.NewCompetitionHandler(competitionService)
+	classroomHandler := handlers.NewClassroomHandler(classroomService)
+	referralHandler := handlers.NewReferralHandler(referralService)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	tierHandler := handlers.NewTierHandler(tierService, authService)
+	corporateActionHandler := handlers.NewCorporateActionHandler(corporateActionService)
+	riskHandler := handlers.NewRiskHandler(riskService, fxService)
+	accountHandler := handlers.NewAccountHandler(accountService, ledgerService, digestService, fxService)
+	metricsHandler := handlers.NewMetricsHandler(performanceService)
+	marketStreamHandler := handlers.NewMarketStreamHandler(marketStream)
+	fxHandler := handlers.NewFXHandler(fxService)
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+	customSymbolHandler := handlers.NewCustomSymbolHandler(customSymbolService, authService)
+	etfHandler := handlers.NewETFHandler(etfService)
+	symbolAdminHandler := handlers.NewSymbolAdminHandler(symbolService, borrowService, orderService)
+	marketClockHandler := handlers.NewMarketClockHandler(marketClock)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	costBasisHandler := handlers.NewCostBasisHandler(costBasisService)
+	invariantHandler := handlers.NewInvariantHandler(invariantService)
+	journalHandler := handlers.NewJournalHandler(journalService)
+	orderHistoryHandler := handlers.NewOrderHistoryHandler(orderHistoryService)
+	playbackHandler := handlers.NewPlaybackHandler(playbackService)
+	tickLogHandler := handlers.NewTickLogHandler(tickLogService)
+	strategyHandler := handlers.NewStrategyHandler(strategyService, strategyRunner)
+	integrationHandler := handlers.NewIntegrationHandler(integrationService)
+	chaosHandler := handlers.NewChaosHandler()
+	deviceHandler := handlers.NewDeviceHandler(notificationService)
+	inboxHandler := handlers.NewInboxHandler(inboxService)
+	cashRequestHandler := handlers.NewCashRequestHandler(cashRequestService, classroomService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	basketHandler := handlers.NewBasketHandler(basketService)
 
 	// Auth middleware helper
 	authMiddleware := authHandler.AuthMiddleware()
+	adminMiddleware := authHandler.AdminMiddleware()
+	platformAdmin := authHandler.PlatformAdminMiddleware()
+	resolveTenant := tenantHandler.ResolveTenant()
+	userPrefs := authHandler.Preferences()
+	optionalAuth := authHandler.OptionalAuth()
+	competitionsEnabled := featureFlagHandler.Require(services.FeatureCompetitions)
+	tradingOpen := maintenanceHandler.BlockDuringMaintenance()
+	tradeAccess := accessHandler.RequireAccess(services.AccessTrade)
+	etag := handlers.ETag()
+	requestTimeout := handlers.Timeout(time.Duration(config.GetEnvInt("REQUEST_TIMEOUT_SECONDS", 10)) * time.Second)
 
 	// Routes
-	router.GET("/", func(c *gin.Context) {
+	apiInfo := func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"status":  "OK",
-			"message": "Trading Simulator API",
-			"version": "1.0.0",
+			"status":      "OK",
+			"message":     "Trading Simulator API",
+			"version":     "1.0.0",
+			"apiVersions": []string{"v1", "v2"},
+			"docs":        "/api/docs",
 			"endpoints": []string{
 				"GET /health",
+				"GET /api/docs",
+				"GET /api/docs/openapi.json",
 				"GET /api/stocks/:symbol",
+				"GET /api/stocks/:symbol/fundamentals",
+				"GET /api/stocks/:symbol/candles",
+				"GET /api/stocks/:symbol/stats",
+				"GET /api/stocks/:symbol/my-activity",
+				"GET /api/stocks/:symbol/playback",
+				"GET /api/stocks/:symbol/ticks",
+				"GET /api/fx/rates",
+				"GET /api/symbols",
+				"GET /api/symbols/custom",
+				"POST /api/symbols/custom",
+				"DELETE /api/symbols/custom/:symbol",
+				"GET /api/etf",
+				"GET /api/etf/:symbol/holdings",
+				"GET /api/market/snapshot",
+				"GET /api/market/clock",
+				"GET /api/screener",
 				"GET /ws",
 				"POST /api/orders/place",
+				"POST /api/orders/basket",
+				"GET /api/orders/basket/:id",
 				"GET /api/portfolio", 
+				"PUT /api/portfolio/:symbol/drip",
+				"POST /api/portfolio/:symbol/close",
+				"PUT /api/portfolio/cost-basis",
+				"GET /api/portfolio/risk",
+				"POST /api/portfolio/stress-test",
 				"GET /api/orders",
+				"GET /api/orders/stats",
+				"GET /api/orders/:id/events",
+				"GET /api/orders/:id/as-of",
+				"GET /api/executions",
 				"POST /api/advanced-orders/stop",
 				"GET /api/advanced-orders/active",
+				"GET /api/advanced-orders/history",
 				"POST /api/advanced-orders/cancel/:id",
 				"POST /api/auth/register",
 				"POST /api/auth/login",
 				"GET /api/auth/me",
+				"PUT /api/auth/preferences",
+				"PUT /api/auth/email",
+				"POST /api/auth/email/verify",
+				"PUT /api/auth/username",
+				"GET /api/tiers",
+				"GET /api/devices",
+				"POST /api/devices",
+				"PUT /api/devices/:id/preferences",
+				"DELETE /api/devices/:id",
+				"GET /api/inbox",
+				"POST /api/inbox/read",
+				"GET /api/account/summary",
+				"GET /api/account/ledger",
+				"GET /api/account/journal",
+				"GET /api/account/journal/balances",
+				"GET /api/account/digest",
+				"POST /api/account/cash-requests",
+				"GET /api/account/cash-requests",
+				"GET /api/statements",
+				"GET /api/statements/:year/:month",
+				"GET /api/account/export",
+				"DELETE /api/account",
+				"GET /api/account/trusted-ips",
+				"PUT /api/account/trusted-ips",
+				"GET /api/account/permissions",
+				"GET /api/admin/violations",
+				"GET /api/admin/cash-requests",
+				"POST /api/admin/cash-requests/:id/approve",
+				"POST /api/admin/cash-requests/:id/deny",
+				"GET /api/admin/audit-log",
+				"POST /api/admin/impersonate/:userID",
+				"GET /api/admin/stats",
+				"GET /api/admin/metrics",
+				"GET /api/admin/market-stream",
+				"POST /api/admin/metrics/load-tests",
+				"GET /api/admin/ws/connections",
+				"POST /api/admin/ws/connections/:id/disconnect",
+				"GET /api/tenant",
+				"GET /api/admin/tenants",
+				"PUT /api/admin/tenants/:id",
+				"GET /api/admin/feature-flags",
+				"PUT /api/admin/feature-flags/:name",
+				"PUT /api/admin/tiers/:name",
+				"PUT /api/admin/users/:id/tier",
+				"GET /api/admin/maintenance",
+				"PUT /api/admin/maintenance",
+				"GET /api/admin/simulation",
+				"PUT /api/admin/simulation",
+				"GET /api/admin/training",
+				"PUT /api/admin/training",
+				"GET /api/admin/data-mode",
+				"PUT /api/admin/data-mode",
+				"PUT /api/admin/users/:id/data-mode",
+				"GET /api/admin/symbols",
+				"PUT /api/admin/symbols/:symbol/borrow",
+				"PUT /api/admin/symbols/:symbol/halt",
+				"DELETE /api/admin/symbols/:symbol/halt",
+				"POST /api/admin/etfs",
+				"GET /api/admin/integrations/webhooks",
+				"POST /api/admin/integrations/webhooks",
+				"PUT /api/admin/integrations/webhooks/:id",
+				"DELETE /api/admin/integrations/webhooks/:id",
+				"POST /api/admin/integrations/webhooks/:id/test",
+				"POST /api/admin/secrets/rotate",
+				"GET /api/admin/chaos",
+				"PUT /api/admin/chaos/:target",
+				"DELETE /api/admin/chaos/:target",
+				"DELETE /api/admin/chaos",
+				"GET /api/admin/integrations/deliveries",
+				"GET /api/features",
+				"GET /api/strategies",
+				"POST /api/strategies",
+				"GET /api/strategies/marketplace",
+				"GET /api/strategies/pnl",
+				"GET /api/strategies/:id",
+				"PUT /api/strategies/:id",
+				"DELETE /api/strategies/:id",
+				"POST /api/strategies/:id/publish",
+				"POST /api/strategies/:id/clone",
+				"POST /api/strategies/:id/paper-run",
+				"GET /api/strategies/:id/runs",
+				"GET /api/strategies/:id/runs/:runId/logs",
+				"POST /api/strategies/:id/start",
+				"POST /api/strategies/:id/stop",
+				"POST /api/competitions",
+				"GET /api/competitions",
+				"GET /api/competitions/:id",
+				"PUT /api/competitions/:id/rules",
+				"POST /api/competitions/:id/join",
+				"GET /api/competitions/:id/portfolio",
+				"POST /api/classrooms",
+				"GET /api/classrooms",
+				"POST /api/classrooms/join",
+				"GET /api/classrooms/:id/dashboard",
+				"POST /api/classrooms/:id/students/:studentId/reset",
+				"PUT /api/classrooms/:id/restrictions",
+				"GET /api/classrooms/:id/cash-requests",
+				"POST /api/classrooms/:id/cash-requests/:requestId/approve",
+				"POST /api/classrooms/:id/cash-requests/:requestId/deny",
+				"GET /api/referrals",
+				"GET /api/achievements",
+				"GET /api/users/search",
+				"GET /api/users/:username/profile",
+				"GET /api/corporate-actions",
+				"POST /api/admin/corporate-actions",
+				"POST /api/admin/cost-basis/recompute",
+				"GET /api/admin/invariants",
+				"POST /api/admin/journal/materialize",
+				"GET /api/admin/orders/:id/events",
 			},
 		})
-	})
+	}
+
+	// With SERVE_FRONTEND=true the embedded SPA owns "/" and the API summary
+	// moves to /api
+	if config.GetEnv("SERVE_FRONTEND", "false") == "true" {
+		router.GET("/api", apiInfo)
+		router.NoRoute(handlers.SPA(web.Dist()))
+	} else {
+		router.GET("/", apiInfo)
+	}
 
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"status":  "OK",
-			"message": "Trading Simulator API is running",
+			"status":      "OK",
+			"message":     "Trading Simulator API is running",
+			"maintenance": maintenanceService.GetState(),
 		})
 	})
 
-	// Market data routes
-	router.GET("/api/stocks/:symbol", marketHandler.GetStockPrice)
-
 	// WebSocket endpoint
 	router.GET("/ws", func(c *gin.Context) {
-		username := c.Query("username")
-		if username == "" {
-			username = "Anonymous"
+		opts := services.ClientOptions{
+			Username: c.Query("username"),
+			Binary:   c.Query("encoding") == "binary", // Optional feed encoding: ?encoding=binary sends price ticks as binary frames
+		}
+		if opts.Username == "" {
+			opts.Username = "Anonymous"
+		}
+		// Optional ?symbols=AAPL,MSFT limits the snapshot and ticks to those symbols
+		if symbols := c.Query("symbols"); symbols != "" {
+			opts.Symbols = strings.Split(symbols, ",")
+			if limit := wsHub.Limits().MaxSubscriptions; len(opts.Symbols) > limit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d symbols per connection", limit)})
+				return
+			}
+		}
+		// With ?token= the socket is authenticated: the snapshot includes open
+		// orders and the client may place and cancel orders over the socket
+		if token := c.Query("token"); token != "" {
+			userID, username, tenantID, err := authHandler.AuthenticateToken(c.Request.Context(), token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			// Authenticated sockets trade, so they follow the trade access policy
+			if err := accessHandler.Check(c, userID, services.AccessTrade); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			orders, err := advancedOrderService.GetActiveStopOrders(c.Request.Context(), userID)
+			if err != nil {
+				log.Printf("Error loading open orders for WebSocket snapshot: %v", err)
+			}
+			if user, err := authService.GetUserByID(c.Request.Context(), userID); err == nil {
+				opts.HidePresence = user.HidePresence
+			}
+			opts.Username = username
+			opts.UserID = userID
+			opts.TenantID = tenantID
+			opts.Orders = append([]models.Order{}, orders...)
+			// Messages kept while the user was offline follow the snapshot, and
+			// are marked delivered once the upgraded socket has queued them
+			if opts.Inbox, err = inboxService.Undelivered(c.Request.Context(), userID); err != nil {
+				log.Printf("Error loading inbox for WebSocket connect: %v", err)
+			}
 		}
 
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -122,28 +636,211 @@ func main() {
 			return
 		}
 
-		client := wsHub.RegisterClient(conn, username)
-		log.Printf("WebSocket connection established for user: %s", username)
+		conn.EnableWriteCompression(c.Query("compress") == "true")
+		client := wsHub.RegisterClient(conn, opts)
+		log.Printf("WebSocket connection established for user: %s", opts.Username)
 
 		// Start client pumps
 		go client.WritePump()
 		go client.ReadPump()
 	})
 
-	// Protected order routes - require authentication
-	router.POST("/api/orders/place", authMiddleware, orderHandler.PlaceOrder)
-	router.GET("/api/portfolio", authMiddleware, orderHandler.GetPortfolio)
-	router.GET("/api/orders", authMiddleware, orderHandler.GetOrders)
+	// API routes. Each version gets the same handlers; breaking changes to
+	// request or response shapes are switched on handlers.APIVersion so /api/v1
+	// keeps working for existing frontends.
+	registerAPI := func(api *gin.RouterGroup) {
+		// Market data routes
+		api.GET("/stocks/:symbol", optionalAuth, etag, marketHandler.GetStockPrice)
+		api.GET("/stocks/:symbol/fundamentals", marketHandler.GetFundamentals)
+		api.GET("/stocks/:symbol/candles", marketHandler.GetCandles)
+		api.GET("/stocks/:symbol/stats", marketHandler.GetStats)
+		api.GET("/stocks/:symbol/my-activity", authMiddleware, userPrefs, orderHandler.GetMyActivity)
+		api.GET("/stocks/:symbol/playback", handlers.Timeout(time.Hour), authMiddleware, playbackHandler.StreamPlayback)
+		api.GET("/stocks/:symbol/ticks", authMiddleware, tickLogHandler.GetTicks)
+		api.GET("/fx/rates", fxHandler.GetRates)
+		api.GET("/symbols", marketHandler.GetSymbols)
+		api.GET("/symbols/custom", customSymbolHandler.ListCustomSymbols)
+		api.POST("/symbols/custom", authMiddleware, customSymbolHandler.CreateCustomSymbol)
+		api.DELETE("/symbols/custom/:symbol", authMiddleware, customSymbolHandler.DeleteCustomSymbol)
+		api.GET("/etf", etfHandler.ListETFs)
+		api.GET("/etf/:symbol/holdings", etfHandler.GetHoldings)
+		api.GET("/market/snapshot", marketHandler.GetSnapshot)
+		api.GET("/market/clock", marketClockHandler.GetClock)
+		api.GET("/screener", marketHandler.GetScreener)
+
+		// Protected order routes - require authentication
+		api.POST("/orders/place", authMiddleware, userPrefs, tradingOpen, tradeAccess, orderHandler.PlaceOrder)
+		api.POST("/orders/basket", authMiddleware, userPrefs, tradingOpen, tradeAccess, basketHandler.PlaceBasket)
+		api.GET("/orders/basket/:id", authMiddleware, userPrefs, basketHandler.GetBasket)
+		api.GET("/portfolio", authMiddleware, userPrefs, etag, orderHandler.GetPortfolio)
+		api.PUT("/portfolio/:symbol/drip", authMiddleware, userPrefs, orderHandler.SetDRIP)
+		api.POST("/portfolio/:symbol/close", authMiddleware, userPrefs, tradingOpen, tradeAccess, orderHandler.ClosePosition)
+		api.PUT("/portfolio/cost-basis", authMiddleware, userPrefs, costBasisHandler.SetMethod)
+		api.GET("/portfolio/risk", authMiddleware, userPrefs, riskHandler.GetRisk)
+		api.POST("/portfolio/stress-test", authMiddleware, userPrefs, riskHandler.StressTest)
+		api.GET("/orders", authMiddleware, userPrefs, etag, orderHandler.GetOrders)
+		api.GET("/orders/stats", authMiddleware, userPrefs, orderHandler.GetOrderStats)
+		api.GET("/orders/:id/events", authMiddleware, userPrefs, orderHistoryHandler.GetEvents)
+		api.GET("/orders/:id/as-of", authMiddleware, userPrefs, orderHistoryHandler.GetAsOf)
+		api.GET("/executions", authMiddleware, userPrefs, orderHandler.GetExecutions)
+
+		// Protected advanced order routes - require authentication
+		api.POST("/advanced-orders/stop", authMiddleware, userPrefs, tradingOpen, tradeAccess, advancedOrderHandler.CreateStopOrder)
+		api.GET("/advanced-orders/active", authMiddleware, userPrefs, advancedOrderHandler.GetActiveOrders)
+		api.GET("/advanced-orders/history", authMiddleware, userPrefs, advancedOrderHandler.GetOrderHistory)
+		api.POST("/advanced-orders/cancel/:id", authMiddleware, userPrefs, tradingOpen, tradeAccess, advancedOrderHandler.CancelOrder)
+
+		// Auth routes
+		api.POST("/auth/register", authHandler.Register)
+		api.POST("/auth/login", authHandler.Login)
+		api.GET("/auth/me", authMiddleware, authHandler.GetCurrentUser)
+		api.PUT("/auth/preferences", authMiddleware, authHandler.UpdatePreferences)
+		api.PUT("/auth/email", authMiddleware, userPrefs, authHandler.ChangeEmail)
+		api.POST("/auth/email/verify", authHandler.VerifyEmail)
+		api.PUT("/auth/username", authMiddleware, userPrefs, authHandler.ChangeUsername)
+		api.GET("/tiers", tierHandler.ListTiers)
+
+		// Push notification devices
+		api.GET("/devices", authMiddleware, deviceHandler.ListDevices)
+		api.POST("/devices", authMiddleware, deviceHandler.RegisterDevice)
+		api.PUT("/devices/:id/preferences", authMiddleware, deviceHandler.UpdatePreferences)
+		api.DELETE("/devices/:id", authMiddleware, deviceHandler.DeleteDevice)
+		// Messages kept while the user was offline
+		api.GET("/inbox", authMiddleware, userPrefs, inboxHandler.GetInbox)
+		api.POST("/inbox/read", authMiddleware, inboxHandler.MarkRead)
+
+		// Account data routes
+		api.GET("/account/summary", authMiddleware, userPrefs, accountHandler.Summary)
+		api.GET("/account/ledger", authMiddleware, userPrefs, accountHandler.GetLedger)
+		api.GET("/account/journal", authMiddleware, userPrefs, journalHandler.GetJournal)
+		api.GET("/account/journal/balances", authMiddleware, userPrefs, journalHandler.GetBalances)
+		api.GET("/account/digest", authMiddleware, userPrefs, accountHandler.PreviewDigest)
+		// Deposits and withdrawals, reviewed by an admin when large in a group
+		api.POST("/account/cash-requests", authMiddleware, userPrefs, tradingOpen, cashRequestHandler.CreateRequest)
+		api.GET("/account/cash-requests", authMiddleware, userPrefs, cashRequestHandler.ListRequests)
+		api.GET("/statements", authMiddleware, userPrefs, statementHandler.ListStatements)
+		api.GET("/statements/:year/:month", handlers.Timeout(time.Minute), authMiddleware, userPrefs, statementHandler.GetStatement)
+		api.GET("/account/export", handlers.Timeout(time.Minute), authMiddleware, userPrefs, accountHandler.Export)
+		api.DELETE("/account", authMiddleware, accountHandler.DeleteAccount)
+		api.GET("/account/trusted-ips", authMiddleware, accessHandler.GetTrustedIPs)
+		api.PUT("/account/trusted-ips", authMiddleware, userPrefs, accessHandler.SetTrustedIPs)
+		api.GET("/account/permissions", authMiddleware, classroomHandler.GetPermissions)
+
+		// Strategy routes - published strategies form the marketplace
+		api.GET("/strategies", authMiddleware, userPrefs, strategyHandler.ListStrategies)
+		api.POST("/strategies", authMiddleware, userPrefs, strategyHandler.CreateStrategy)
+		api.GET("/strategies/marketplace", authMiddleware, userPrefs, strategyHandler.Marketplace)
+		api.GET("/strategies/pnl", authMiddleware, strategyHandler.GetProfitLoss)
+		api.GET("/strategies/:id", authMiddleware, userPrefs, strategyHandler.GetStrategy)
+		api.PUT("/strategies/:id", authMiddleware, userPrefs, strategyHandler.UpdateStrategy)
+		api.DELETE("/strategies/:id", authMiddleware, strategyHandler.DeleteStrategy)
+		api.POST("/strategies/:id/publish", authMiddleware, userPrefs, strategyHandler.PublishStrategy)
+		api.POST("/strategies/:id/clone", authMiddleware, userPrefs, strategyHandler.CloneStrategy)
+		api.POST("/strategies/:id/paper-run", authMiddleware, userPrefs, strategyHandler.PaperRun)
+		api.GET("/strategies/:id/runs", authMiddleware, userPrefs, strategyHandler.GetRuns)
+		api.GET("/strategies/:id/runs/:runId/logs", authMiddleware, userPrefs, strategyHandler.GetRunLogs)
+		api.POST("/strategies/:id/start", authMiddleware, userPrefs, tradingOpen, tradeAccess, strategyHandler.StartStrategy)
+		api.POST("/strategies/:id/stop", authMiddleware, userPrefs, strategyHandler.StopStrategy)
+
+		// Competition routes
+		api.GET("/competitions", competitionsEnabled, competitionHandler.ListCompetitions)
+		api.GET("/competitions/:id", competitionsEnabled, competitionHandler.GetCompetition)
+		api.POST("/competitions", authMiddleware, competitionsEnabled, competitionHandler.CreateCompetition)
+		api.PUT("/competitions/:id/rules", authMiddleware, competitionsEnabled, competitionHandler.UpdateRules)
+		api.POST("/competitions/:id/join", authMiddleware, userPrefs, competitionsEnabled, competitionHandler.JoinCompetition)
+		api.GET("/competitions/:id/portfolio", authMiddleware, userPrefs, competitionsEnabled, competitionHandler.GetCompetitionPortfolio)
+
+		// Classroom routes - teachers manage their own classes
+		api.POST("/classrooms", authMiddleware, classroomHandler.CreateClassroom)
+		api.GET("/classrooms", authMiddleware, classroomHandler.ListClassrooms)
+		api.POST("/classrooms/join", authMiddleware, classroomHandler.JoinClassroom)
+		api.GET("/classrooms/:id/dashboard", authMiddleware, userPrefs, classroomHandler.GetDashboard)
+		api.POST("/classrooms/:id/students/:studentId/reset", authMiddleware, classroomHandler.SeedStudent)
+		api.PUT("/classrooms/:id/restrictions", authMiddleware, userPrefs, classroomHandler.SetRestrictions)
+		api.GET("/classrooms/:id/cash-requests", authMiddleware, userPrefs, cashRequestHandler.GetClassroomQueue)
+		api.POST("/classrooms/:id/cash-requests/:requestId/approve", authMiddleware, userPrefs, cashRequestHandler.ApproveForClassroom)
+		api.POST("/classrooms/:id/cash-requests/:requestId/deny", authMiddleware, userPrefs, cashRequestHandler.DenyForClassroom)
 
-	// Protected advanced order routes - require authentication
-	router.POST("/api/advanced-orders/stop", authMiddleware, advancedOrderHandler.CreateStopOrder)
-	router.GET("/api/advanced-orders/active", authMiddleware, advancedOrderHandler.GetActiveOrders)
-	router.POST("/api/advanced-orders/cancel/:id", authMiddleware, advancedOrderHandler.CancelOrder)
+		// Referral routes
+		api.GET("/referrals", authMiddleware, userPrefs, referralHandler.GetReferrals)
 
-	// Auth routes
-	router.POST("/api/auth/register", authHandler.Register)
-	router.POST("/api/auth/login", authHandler.Login)
-	router.GET("/api/auth/me", authMiddleware, authHandler.GetCurrentUser)
+		// Achievement routes
+		api.GET("/achievements", authMiddleware, userPrefs, achievementHandler.GetAchievements)
+		api.GET("/users/search", authMiddleware, userPrefs, profileHandler.SearchUsers)
+		api.GET("/users/:username/profile", authMiddleware, userPrefs, profileHandler.GetProfile)
+
+		// Corporate action routes
+		api.GET("/corporate-actions", corporateActionHandler.GetCorporateActions)
+
+		// Admin routes - require the admin role
+		api.GET("/admin/violations", authMiddleware, adminMiddleware, userPrefs, adminHandler.GetViolations)
+		api.GET("/admin/cash-requests", authMiddleware, adminMiddleware, userPrefs, cashRequestHandler.GetQueue)
+		api.POST("/admin/cash-requests/:id/approve", authMiddleware, adminMiddleware, userPrefs, cashRequestHandler.Approve)
+		api.POST("/admin/cash-requests/:id/deny", authMiddleware, adminMiddleware, userPrefs, cashRequestHandler.Deny)
+		api.GET("/admin/audit-log", authMiddleware, platformAdmin, userPrefs, auditHandler.GetAuditLog)
+		api.POST("/admin/impersonate/:userID", authMiddleware, platformAdmin, authHandler.Impersonate)
+		api.GET("/admin/stats", authMiddleware, adminMiddleware, userPrefs, adminHandler.GetStats)
+		api.GET("/admin/metrics", authMiddleware, adminMiddleware, userPrefs, metricsHandler.GetMetrics)
+		api.GET("/admin/market-stream", authMiddleware, platformAdmin, marketStreamHandler.GetStatus)
+		api.POST("/admin/metrics/load-tests", authMiddleware, adminMiddleware, userPrefs, metricsHandler.SubmitLoadTest)
+		api.GET("/admin/ws/connections", authMiddleware, adminMiddleware, userPrefs, connectionHandler.ListConnections)
+		api.POST("/admin/ws/connections/:id/disconnect", authMiddleware, adminMiddleware, connectionHandler.Disconnect)
+		api.GET("/admin/feature-flags", authMiddleware, adminMiddleware, featureFlagHandler.ListFlags)
+		api.PUT("/admin/feature-flags/:name", authMiddleware, platformAdmin, featureFlagHandler.SetFlag)
+		api.PUT("/admin/tiers/:name", authMiddleware, platformAdmin, tierHandler.SetTier)
+		api.PUT("/admin/users/:id/tier", authMiddleware, adminMiddleware, tierHandler.AssignTier)
+		api.GET("/admin/maintenance", authMiddleware, adminMiddleware, maintenanceHandler.GetMaintenance)
+		api.PUT("/admin/maintenance", authMiddleware, platformAdmin, maintenanceHandler.SetMaintenance)
+		api.GET("/admin/simulation", authMiddleware, adminMiddleware, simulationHandler.GetSimulation)
+		api.GET("/admin/symbols", authMiddleware, platformAdmin, symbolAdminHandler.ListSymbols)
+		api.PUT("/admin/symbols/:symbol/borrow", authMiddleware, platformAdmin, symbolAdminHandler.UpdateBorrow)
+		api.PUT("/admin/symbols/:symbol/halt", authMiddleware, platformAdmin, marketClockHandler.HaltSymbol)
+		api.DELETE("/admin/symbols/:symbol/halt", authMiddleware, platformAdmin, marketClockHandler.ResumeSymbol)
+		api.POST("/admin/etfs", authMiddleware, platformAdmin, etfHandler.CreateETF)
+		api.PUT("/admin/simulation", authMiddleware, platformAdmin, simulationHandler.SetSimulation)
+		api.GET("/admin/training", authMiddleware, adminMiddleware, trainingHandler.GetTraining)
+		api.PUT("/admin/training", authMiddleware, adminMiddleware, trainingHandler.SetTraining)
+		api.GET("/admin/data-mode", authMiddleware, platformAdmin, dataModeHandler.GetDataMode)
+		api.PUT("/admin/data-mode", authMiddleware, platformAdmin, dataModeHandler.SetDataMode)
+		api.PUT("/admin/users/:id/data-mode", authMiddleware, adminMiddleware, dataModeHandler.SetUserDataMode)
+
+		// Tenant routes - tenants are managed by admins outside any tenant
+		api.GET("/tenant", tenantHandler.GetTenant)
+		api.GET("/admin/tenants", authMiddleware, platformAdmin, tenantHandler.ListTenants)
+		api.PUT("/admin/tenants/:id", authMiddleware, platformAdmin, tenantHandler.SaveTenant)
+
+		// Webhook integrations for platform events
+		api.GET("/admin/integrations/webhooks", authMiddleware, platformAdmin, integrationHandler.ListWebhooks)
+		api.POST("/admin/integrations/webhooks", authMiddleware, platformAdmin, integrationHandler.CreateWebhook)
+		api.PUT("/admin/integrations/webhooks/:id", authMiddleware, platformAdmin, integrationHandler.UpdateWebhook)
+		api.DELETE("/admin/integrations/webhooks/:id", authMiddleware, platformAdmin, integrationHandler.DeleteWebhook)
+		api.POST("/admin/integrations/webhooks/:id/test", authMiddleware, platformAdmin, integrationHandler.TestWebhook)
+		api.POST("/admin/secrets/rotate", authMiddleware, platformAdmin, integrationHandler.RotateSecrets)
+		// Fault injection routes only exist with CHAOS_ENABLED=true
+		if chaos.Enabled() {
+			api.GET("/admin/chaos", authMiddleware, platformAdmin, chaosHandler.ListFaults)
+			api.PUT("/admin/chaos/:target", authMiddleware, platformAdmin, chaosHandler.SetFault)
+			api.DELETE("/admin/chaos/:target", authMiddleware, platformAdmin, chaosHandler.ClearFault)
+			api.DELETE("/admin/chaos", authMiddleware, platformAdmin, chaosHandler.ClearFaults)
+		}
+		api.GET("/admin/integrations/deliveries", authMiddleware, platformAdmin, userPrefs, integrationHandler.ListDeliveries)
+
+		// Feature flags as seen by the current user
+		api.GET("/features", authMiddleware, featureFlagHandler.GetFeatures)
+		api.POST("/admin/corporate-actions", authMiddleware, platformAdmin, corporateActionHandler.CreateCorporateAction)
+		api.POST("/admin/cost-basis/recompute", handlers.Timeout(time.Minute), authMiddleware, platformAdmin, costBasisHandler.Recompute)
+		api.GET("/admin/invariants", handlers.Timeout(time.Minute), authMiddleware, platformAdmin, invariantHandler.Check)
+		api.POST("/admin/journal/materialize", handlers.Timeout(time.Minute), authMiddleware, platformAdmin, journalHandler.Materialize)
+		api.GET("/admin/orders/:id/events", authMiddleware, platformAdmin, orderHistoryHandler.GetAnyEvents)
+	}
+	registerAPI(router.Group("/api", requestTimeout, resolveTenant)) // Unversioned paths are v1
+	registerAPI(router.Group("/api/v1", handlers.APIVersion(1), requestTimeout, resolveTenant))
+	registerAPI(router.Group("/api/v2", handlers.APIVersion(2), requestTimeout, resolveTenant))
+
+	// API documentation
+	docsHandler := handlers.NewDocsHandler(router)
+	router.GET("/api/docs", docsHandler.SwaggerUI)
+	router.GET("/api/docs/openapi.json", docsHandler.OpenAPI)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -159,54 +856,334 @@ func main() {
 }
 
 // Simulate market data updates
-func simulateMarketData(hub *services.WebSocketHub, marketService *services.MarketDataService) {
+func simulateMarketData(events *services.EventBus, marketService *services.MarketDataService, simulationService *services.SimulationService, dataModeService *services.DataModeService, stream *services.MarketStream) {
 	symbols := []string{"AAPL", "GOOGL", "MSFT", "TSLA", "AMZN"}
 	
 	// Add delay before starting to allow server to fully initialize
 	time.Sleep(2 * time.Second)
 	log.Println("📈 Starting market data simulation...")
 
-	// Get initial real data once
-	log.Println("🔄 Fetching initial real stock data...")
+	// Get initial real data once, unless the deployment is in mock mode
+	marketService.SetDataMode(dataModeService.Deployment())
+	log.Printf("🔄 Fetching initial stock data in %s mode...", marketService.DataMode())
 	for _, symbol := range symbols {
-		stock, err := marketService.GetStockPrice(symbol)
+		stock, err := marketService.GetStockPrice(context.Background(), symbol)
 		if err != nil {
 			log.Printf("❌ Error fetching %s: %v", symbol, err)
 			continue
 		}
-		hub.BroadcastStock(*stock)
+		events.Publish(services.EventPriceTick, "", *stock)
 		log.Printf("✅ Initial data: %s - $%.2f", symbol, stock.Price)
 		time.Sleep(1 * time.Second) // Respect API limits
 	}
 
 	// Use mock data for continuous updates (no API calls)
 	log.Println("🤖 Switching to mock data for real-time updates...")
-	ticker := time.NewTicker(3 * time.Second) // Update every 3 seconds
+	// Interval, volatility and pause are adjustable via PUT /api/admin/simulation
+	interval := simulationService.GetSettings().TickInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
+		marketService.SetDataMode(dataModeService.Deployment())
+		settings := simulationService.GetSettings()
+		if next := settings.TickInterval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+		if settings.Paused {
+			continue
+		}
+		marketService.SetVolatility(settings.VolatilityMultiplier)
+
 		// Use mock data only - no API calls
 		for _, symbol := range symbols {
-			stock, err := marketService.GetMockStockPrice(symbol)
+			if stream.Owns(symbol) {
+				continue // Priced by the real-time stream
+			}
+			stock, err := marketService.AdvanceTick(symbol)
 			if err != nil {
 				log.Printf("❌ Mock data error for %s: %v", symbol, err)
 				continue
 			}
-			hub.BroadcastStock(*stock)
+			events.Publish(services.EventPriceTick, "", *stock)
+		}
+
+		// Custom symbols follow their own volatility and drift
+		for _, custom := range marketService.CustomSymbols() {
+			events.Publish(services.EventPriceTick, "", *marketService.AdvanceCustomTick(custom))
+		}
+
+		// ETFs last, from the prices their constituents just ticked to
+		for _, etf := range marketService.ETFs() {
+			if stock, ok := marketService.AdvanceETFTick(etf); ok {
+				events.Publish(services.EventPriceTick, "", *stock)
+			}
 		}
 	}
 }
 
 // Monitor stop orders in background
-func monitorStopOrders(advancedOrderService *services.AdvancedOrderService) {
+func monitorStopOrders(advancedOrderService *services.AdvancedOrderService, maintenanceService *services.MaintenanceService) {
 	// Wait for server to fully initialize
 	time.Sleep(5 * time.Second)
 	log.Println("🛑 Starting stop order monitoring...")
+	advancedOrderService.RecoverOrders()
 
 	ticker := time.NewTicker(10 * time.Second) // Check every 10 seconds
 	defer ticker.Stop()
 
 	for range ticker.C {
+		// Resting orders do not fill while trading is frozen
+		if maintenanceService.Active() {
+			continue
+		}
 		advancedOrderService.CheckAndExecuteStopOrders()
 	}
-}
\ No newline at end of file
+}
+
+// Activate scheduled orders once their time comes. While trading is frozen
+// they stay scheduled and go live when it resumes.
+func activateScheduledOrders(advancedOrderService *services.AdvancedOrderService, maintenanceService *services.MaintenanceService) {
+	time.Sleep(5 * time.Second)
+	log.Println("⏰ Starting scheduled order activation...")
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if maintenanceService.Active() {
+			continue
+		}
+		advancedOrderService.ActivateScheduledOrders()
+	}
+}
+
+// Cancel stop orders past their expiry or without shares to sell
+func sweepStaleOrders(advancedOrderService *services.AdvancedOrderService) {
+	time.Sleep(10 * time.Second)
+	log.Println("🧹 Starting stale order sweeper...")
+
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		advancedOrderService.SweepStaleOrders()
+	}
+}
+
+// Post a day of interest and borrow fees to every account. Checking hourly
+// catches the new day soon after midnight UTC; charges already posted for a
+// day are skipped.
+func accrueDailyCharges(interestService *services.InterestService, borrowService *services.BorrowService) {
+	time.Sleep(30 * time.Second)
+	log.Println("💰 Starting interest and borrow fee accrual...")
+
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		interestService.AccrueDaily(ctx, time.Now())
+		borrowService.ChargeDailyFees(ctx, time.Now())
+		cancel()
+		<-ticker.C
+	}
+}
+
+// Check accounts against their history once a day. Discrepancies are
+// logged; the admin endpoint lists them.
+func checkInvariants(invariantService *services.InvariantService) {
+	time.Sleep(10 * time.Minute)
+	log.Println("🔎 Starting daily accounting checks...")
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		report, err := invariantService.Check(ctx, "")
+		cancel()
+		if err != nil {
+			log.Printf("Error checking accounts: %v", err)
+		} else {
+			for _, account := range report.Accounts {
+				log.Printf("⚠️ Account of %s %s is off by $%.2f", account.Username, account.CompetitionID, account.Difference)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// Rebuild stored cash from the journal shortly after startup, which gives
+// accounts from before the journal their opening entries, and then daily
+func materializeBalances(journalService *services.JournalService) {
+	time.Sleep(1 * time.Minute)
+	log.Println("📒 Starting journal balance materialization...")
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		if _, err := journalService.MaterializeScheduled(ctx); err != nil {
+			log.Printf("Error materializing balances: %v", err)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// Send connected traders their day P&L as prices move
+func pushDayChanges(accountService *services.AccountService) {
+	interval := time.Duration(config.GetEnvInt("ACCOUNT_PUSH_SECONDS", 5)) * time.Second
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		accountService.PushDayChanges(ctx)
+		cancel()
+	}
+}
+
+// Deliver outbox events to the event bus
+func dispatchOutbox(outboxService *services.OutboxService) {
+	log.Println("📤 Starting outbox dispatcher...")
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(1 * time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			outboxService.DispatchPending()
+		case <-purge.C:
+			outboxService.PurgeDelivered()
+		}
+	}
+}
+
+// Snapshot every account periodically for portfolio-based achievements
+func monitorAchievements(achievementService *services.AchievementService) {
+	time.Sleep(10 * time.Second)
+	log.Println("🏆 Starting achievement monitoring...")
+
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		achievementService.EvaluateAll()
+	}
+}
+
+// Generate simulated corporate actions and apply those that have gone ex
+func processCorporateActions(corporateActionService *services.CorporateActionService) {
+	time.Sleep(15 * time.Second)
+	log.Println("🏢 Starting corporate action processing...")
+
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		corporateActionService.GenerateActions()
+		corporateActionService.ApplyDueActions()
+		<-ticker.C
+	}
+}
+
+// Purge data of accounts deleted longer ago than the retention period
+func purgeDeletedAccounts(accountService *services.AccountService) {
+	time.Sleep(30 * time.Second)
+	log.Println("🗑️ Starting deleted account purge...")
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		accountService.PurgeDeletedAccounts()
+		<-ticker.C
+	}
+}
+
+// Announce competition starts, ends and leaderboard changes
+func announceCompetitions(announcer *services.CompetitionAnnouncer) {
+	interval := time.Duration(config.GetEnvInt("COMPETITION_CHECK_SECONDS", 30)) * time.Second
+	if interval <= 0 {
+		return
+	}
+	time.Sleep(15 * time.Second)
+	log.Println("📣 Starting competition announcements...")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		announcer.Check(ctx, time.Now())
+		cancel()
+		<-ticker.C
+	}
+}
+
+// Post queued webhook messages and purge old ones
+func deliverWebhooks(integrationService *services.IntegrationService) {
+	log.Println("🪝 Starting webhook delivery...")
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(1 * time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			integrationService.DeliverPending(ctx)
+			cancel()
+		case <-purge.C:
+			integrationService.PurgeFinished(context.Background())
+		}
+	}
+}
+
+// Email digests to users whose daily or weekly digest is due
+func sendDigests(digestService *services.DigestService) {
+	time.Sleep(30 * time.Second)
+	log.Println("📧 Starting email digests...")
+
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		digestService.SendDue(ctx, time.Now().UTC())
+		cancel()
+		<-ticker.C
+	}
+}
+
+// Generate last month's statements once the month is over. Checking every
+// few hours catches the new month soon after it starts; statements already
+// stored are skipped.
+func generateStatements(statementService *services.StatementService) {
+	time.Sleep(1 * time.Minute)
+	log.Println("🧾 Starting monthly statement generation...")
+
+	ticker := time.NewTicker(6 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		statementService.GenerateMonth(ctx, time.Now())
+		cancel()
+		<-ticker.C
+	}
+}
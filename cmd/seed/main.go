@@ -0,0 +1,161 @@
+// Command seed fills the configured database with demo data: users with
+// portfolios built from sample orders, and daily candle history for every
+// simulated symbol.
+//
+//	go run ./cmd/seed -users 10 -days 180
+//
+// It reads the same .env as the server. Running it again reuses existing demo
+// users and regenerates the candles.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/joho/godotenv"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+)
+
+// Password of every demo account
+const demoPassword = "demo1234"
+
+func main() {
+	users := flag.Int("users", 5, "demo traders to create")
+	days := flag.Int("days", 180, "days of candle history per symbol")
+	orders := flag.Int("orders", 4, "sample orders per demo trader")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ No .env file, using the process environment")
+	}
+	config.ConnectDB()
+	defer config.DisconnectDB()
+
+	ctx := context.Background()
+	marketService := services.NewMarketDataService()
+	symbolService := services.NewSymbolService()
+	eventBus := services.NewEventBus()
+	tierService := services.NewTierService()
+	flags := services.NewFeatureFlagService(tierService)
+	orderService := services.NewOrderService(
+		marketService,
+		symbolService,
+		services.NewOrderGuardService(),
+		services.NewCompetitionService(marketService, flags),
+		eventBus,
+		services.NewOutboxService(eventBus),
+		services.NewDataModeService(),
+		flags,
+	)
+	tenantService := services.NewTenantService()
+	// Demo trades are seeded whatever the market hours
+	orderEngine := services.NewOrderEngine(orderService, tenantService, nil, nil, nil)
+	authService := services.NewAuthService(services.NewReferralService(), tenantService, tierService, eventBus, services.LogMailer{})
+	candleService := services.NewCandleService()
+
+	prices := seedCandles(ctx, candleService, marketService, symbolService, *days)
+	if len(prices) == 0 {
+		log.Fatal("No symbols with a simulated price to seed")
+	}
+
+	if _, created := ensureUser(ctx, authService, "demoadmin", "admin"); created {
+		log.Println("✅ Created admin demoadmin")
+	}
+	for i := 1; i <= *users; i++ {
+		user, created := ensureUser(ctx, authService, fmt.Sprintf("demo%d", i), "")
+		if user == nil || !created {
+			continue
+		}
+		placed := placeSampleOrders(ctx, orderEngine, user, prices, *orders)
+		log.Printf("✅ Created trader %s with %d orders", user.Username, placed)
+	}
+
+	fmt.Printf("🌱 Seed complete. Log in as demo1..demo%d or demoadmin with password %q\n", *users, demoPassword)
+}
+
+// seedCandles writes candle history for every symbol the simulator prices
+// and returns the latest close per symbol
+func seedCandles(ctx context.Context, candleService *services.CandleService, marketService *services.MarketDataService, symbolService *services.SymbolService, days int) map[string]float64 {
+	prices := make(map[string]float64)
+	for _, info := range symbolService.ListSymbols() {
+		price, ok := marketService.GetLastPrice(info.Symbol)
+		if !ok || price <= 0 {
+			continue
+		}
+
+		candles := services.GenerateCandles(info.Symbol, price, days, time.Now())
+		if err := candleService.SaveCandles(ctx, candles); err != nil {
+			log.Fatalf("Failed to save candles for %s: %v", info.Symbol, err)
+		}
+		if info.AssetClass == "stock" {
+			prices[info.Symbol] = candles[len(candles)-1].Close
+		}
+		log.Printf("📈 %d candles for %s", len(candles), info.Symbol)
+	}
+	return prices
+}
+
+// ensureUser registers a demo account unless it already exists. It reports
+// whether the account was created by this run.
+func ensureUser(ctx context.Context, authService *services.AuthService, username, role string) (*models.User, bool) {
+	if user, err := authService.Login(ctx, username, demoPassword); err == nil {
+		return user, false
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    username + "@demo.local",
+		Password: demoPassword,
+		Role:     role,
+	}
+	if err := authService.Register(ctx, user, ""); err != nil {
+		log.Printf("⚠️ Skipping %s: %v", username, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// placeSampleOrders buys a few random positions at the latest close. Each
+// order is in a different symbol so none is flagged as a wash trade.
+func placeSampleOrders(ctx context.Context, orderEngine *services.OrderEngine, user *models.User, prices map[string]float64, count int) int {
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	rand.Shuffle(len(symbols), func(i, j int) { symbols[i], symbols[j] = symbols[j], symbols[i] })
+	if count > len(symbols) {
+		count = len(symbols)
+	}
+
+	// Spend up to about 80% of the starting cash
+	budget := user.CashBalance * 0.8 / float64(max(count, 1))
+	placed := 0
+	for _, symbol := range symbols[:count] {
+		price := prices[symbol]
+		quantity := math.Floor(budget * (0.5 + rand.Float64()*0.5) / price)
+		if quantity < 1 {
+			continue
+		}
+		order := &models.Order{
+			UserID:    user.ID.Hex(),
+			Symbol:    symbol,
+			Type:      "buy",
+			OrderType: "market",
+			Quantity:  quantity,
+			Price:     price,
+		}
+		if err := orderEngine.Execute(ctx, order); err != nil {
+			log.Printf("⚠️ Order for %s in %s failed: %v", user.Username, symbol, err)
+			continue
+		}
+		placed++
+	}
+	return placed
+}
@@ -0,0 +1,349 @@
+// Command tradectl is a command line client for the trading simulator API.
+//
+//	tradectl login -u alice -p secret
+//	tradectl quote AAPL MSFT
+//	tradectl buy AAPL 10
+//	tradectl sell AAPL 5 -price 190.25
+//	tradectl portfolio
+//	tradectl watch AAPL TSLA
+//
+// The server is taken from -url or TRADECTL_URL. login stores the token in
+// the user config directory; TRADECTL_TOKEN overrides it. Every command exits
+// non-zero on failure so it can be used in scripts and smoke tests.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"trading-simulator/internal/models"
+)
+
+const usage = `usage: tradectl [-url URL] <command> [args]
+
+commands:
+  login -u USER -p PASSWORD   log in and store the token
+  quote SYMBOL...             show current prices
+  buy SYMBOL QTY [-price P]   buy at P, or at the current price
+  sell SYMBOL QTY [-price P]  sell at P, or at the current price
+                              QTY may be fractional, or $AMOUNT for a notional order
+  portfolio                   show positions and cash
+  watch [SYMBOL...]           stream prices until interrupted
+`
+
+type apiClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func main() {
+	global := flag.NewFlagSet("tradectl", flag.ExitOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	baseURL := global.String("url", envOr("TRADECTL_URL", "http://localhost:8080"), "server base URL")
+	global.Parse(os.Args[1:])
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	client := &apiClient{
+		baseURL: strings.TrimRight(*baseURL, "/"),
+		token:   loadToken(),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+
+	cmd, args := global.Arg(0), global.Args()[1:]
+	var err error
+	switch cmd {
+	case "login":
+		err = client.login(args)
+	case "quote":
+		err = client.quote(args)
+	case "buy", "sell":
+		err = client.trade(cmd, args)
+	case "portfolio":
+		err = client.portfolio()
+	case "watch":
+		err = client.watch(args)
+	default:
+		global.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tradectl:", err)
+		os.Exit(1)
+	}
+}
+
+func (c *apiClient) login(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	username := fs.String("u", "", "username")
+	password := fs.String("p", "", "password")
+	fs.Parse(args)
+	if *username == "" || *password == "" {
+		return errors.New("login needs -u and -p")
+	}
+
+	var auth struct {
+		Token string      `json:"token"`
+		User  models.User `json:"user"`
+	}
+	err := c.do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"username": *username,
+		"password": *password,
+	}, &auth)
+	if err != nil {
+		return err
+	}
+	if err := saveToken(auth.Token); err != nil {
+		return err
+	}
+	fmt.Printf("Logged in as %s (cash $%.2f)\n", auth.User.Username, auth.User.CashBalance)
+	return nil
+}
+
+func (c *apiClient) quote(symbols []string) error {
+	if len(symbols) == 0 {
+		return errors.New("quote needs at least one symbol")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tPRICE\tCHANGE\tVOLUME")
+	for _, symbol := range symbols {
+		stock, err := c.getQuote(symbol)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%+.2f%%\t%d\n", stock.Symbol, stock.Price, stock.ChangePercent, stock.Volume)
+	}
+	return w.Flush()
+}
+
+func (c *apiClient) getQuote(symbol string) (*models.Stock, error) {
+	var stock models.Stock
+	if err := c.do(http.MethodGet, "/api/v1/stocks/"+url.PathEscape(symbol), nil, &stock); err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+func (c *apiClient) trade(side string, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%s needs SYMBOL and QTY", side)
+	}
+	symbol := strings.ToUpper(args[0])
+	// $250 places a notional order for that dollar amount
+	size := "quantity"
+	amount := args[1]
+	if strings.HasPrefix(amount, "$") {
+		size, amount = "notional", amount[1:]
+	}
+	quantity, err := strconv.ParseFloat(amount, 64)
+	if err != nil || quantity <= 0 {
+		return errors.New("QTY must be a positive number or $AMOUNT")
+	}
+	fs := flag.NewFlagSet(side, flag.ExitOnError)
+	price := fs.Float64("price", 0, "order price; defaults to the current quote")
+	fs.Parse(args[2:])
+
+	if *price <= 0 {
+		stock, err := c.getQuote(symbol)
+		if err != nil {
+			return err
+		}
+		*price = stock.Price
+	}
+
+	var result struct {
+		Order models.Order `json:"order"`
+	}
+	err = c.do(http.MethodPost, "/api/v1/orders/place", map[string]interface{}{
+		"symbol":    symbol,
+		"type":      side,
+		"orderType": "market",
+		size:        quantity,
+		"price":     *price,
+	}, &result)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Filled %s %g %s @ $%.2f (order %s)\n", result.Order.Type, result.Order.Quantity, result.Order.Symbol, result.Order.Price, result.Order.ID.Hex())
+	return nil
+}
+
+func (c *apiClient) portfolio() error {
+	var result struct {
+		Portfolio   []models.Portfolio `json:"portfolio"`
+		CashBalance float64            `json:"cashBalance"`
+		BuyingPower float64            `json:"buyingPower"`
+		TotalAssets float64            `json:"totalAssets"`
+	}
+	if err := c.do(http.MethodGet, "/api/v1/portfolio", nil, &result); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSHARES\tAVG COST")
+	for _, pos := range result.Portfolio {
+		fmt.Fprintf(w, "%s\t%g\t%.2f\n", pos.Symbol, pos.Shares, pos.AvgCost)
+	}
+	w.Flush()
+	fmt.Printf("\nCash $%.2f  Buying power $%.2f  Total assets $%.2f\n", result.CashBalance, result.BuyingPower, result.TotalAssets)
+	return nil
+}
+
+// watch prints price ticks from the WebSocket feed, optionally only for the
+// given symbols, until interrupted
+func (c *apiClient) watch(symbols []string) error {
+	wanted := make(map[string]bool)
+	for _, symbol := range symbols {
+		wanted[strings.ToUpper(symbol)] = true
+	}
+
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = "/ws"
+	u.RawQuery = url.Values{"username": {"tradectl"}}.Encode()
+
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Ping periodically so the server does not drop the anonymous socket as idle
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		ping := time.NewTicker(time.Minute)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ping.C:
+				conn.WriteJSON(map[string]string{"action": "ping"})
+			case <-interrupt:
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		var tick models.Stock
+		if json.Unmarshal(data, &tick) != nil || tick.Symbol == "" {
+			continue // Control messages such as pong
+		}
+		if len(wanted) > 0 && !wanted[tick.Symbol] {
+			continue
+		}
+		fmt.Printf("%s  %-8s %12.2f %+7.2f%%\n", tick.Timestamp.Local().Format("15:04:05"), tick.Symbol, tick.Price, tick.ChangePercent)
+	}
+}
+
+// do sends a request with the stored token and decodes the JSON response.
+// Errors carry the server's message in either API error format.
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error json.RawMessage `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		var message string
+		if json.Unmarshal(failure.Error, &message) != nil {
+			var v2 struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(failure.Error, &v2)
+			message = v2.Message
+		}
+		if message == "" {
+			message = resp.Status
+		}
+		return fmt.Errorf("%s %s: %s", method, path, message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func tokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tradectl", "token"), nil
+}
+
+func loadToken() string {
+	if token := os.Getenv("TRADECTL_TOKEN"); token != "" {
+		return token
+	}
+	path, err := tokenPath()
+	if err != nil {
+		return ""
+	}
+	data, _ := os.ReadFile(path)
+	return strings.TrimSpace(string(data))
+}
+
+func saveToken(token string) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token), 0o600)
+}
+
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
@@ -9,6 +9,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 var DB *mongo.Client
@@ -24,7 +25,7 @@ func ConnectDB() {
 	defer cancel()
 
 	// Use mongo.Connect() instead of mongo.NewClient()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	client, err := mongo.Connect(ctx, clientOptions(mongoURI))
 	if err != nil {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
@@ -39,13 +40,28 @@ func ConnectDB() {
 	fmt.Println("✅ Connected to MongoDB")
 }
 
+// financialCollections hold balances, positions and fills. Writes to them
+// wait for a majority of the replica set so a failover cannot lose them.
+var financialCollections = map[string]bool{
+	"users":               true,
+	"orders":              true,
+	"advanced_orders":     true,
+	"portfolio":           true,
+	"competition_entries": true,
+	"outbox":              true,
+}
+
 // Getting database collections
 func GetCollection(collectionName string) *mongo.Collection {
 	databaseName := os.Getenv("DATABASE_NAME")
 	if databaseName == "" {
 		databaseName = "trading-simulator"
 	}
-	collection := DB.Database(databaseName).Collection(collectionName)
+	var opts []*options.CollectionOptions
+	if financialCollections[collectionName] {
+		opts = append(opts, options.Collection().SetWriteConcern(writeconcern.Majority()))
+	}
+	collection := DB.Database(databaseName).Collection(collectionName, opts...)
 	return collection
 }
 
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// GetEnvInt reads an integer environment variable, falling back to def when
+// it is unset or invalid
+func GetEnvInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️ Invalid value for %s (%q), using default %d", name, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvFloat reads a float environment variable, falling back to def when
+// it is unset or invalid
+func GetEnvFloat(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("⚠️ Invalid value for %s (%q), using default %v", name, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnv reads a string environment variable, falling back to def when unset
+func GetEnv(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/internal/chaos"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// clientOptions builds the Mongo client options. Unset variables keep the
+// value from MONGODB_URI or the driver default.
+//
+//	MONGO_MAX_POOL_SIZE                     connections per server (driver default 100)
+//	MONGO_MIN_POOL_SIZE                     idle connections kept open
+//	MONGO_MAX_CONN_IDLE_SECONDS             close connections idle for longer
+//	MONGO_CONNECT_TIMEOUT_SECONDS           dialing a server
+//	MONGO_SERVER_SELECTION_TIMEOUT_SECONDS  finding a usable server
+//	MONGO_READ_CONCERN                      e.g. "local" or "majority"
+//	MONGO_WRITE_CONCERN                     "majority" or a number
+//	MONGO_SLOW_QUERY_MS                     log commands slower than this (default 200, 0 disables)
+//
+// With CHAOS_ENABLED=true connections are dialed through the chaos package
+// so Mongo faults can be injected.
+func clientOptions(uri string) *options.ClientOptions {
+	opts := options.Client().ApplyURI(uri)
+
+	if size := GetEnvInt("MONGO_MAX_POOL_SIZE", 0); size > 0 {
+		opts.SetMaxPoolSize(uint64(size))
+	}
+	if size := GetEnvInt("MONGO_MIN_POOL_SIZE", 0); size > 0 {
+		opts.SetMinPoolSize(uint64(size))
+	}
+	if idle := GetEnvInt("MONGO_MAX_CONN_IDLE_SECONDS", 0); idle > 0 {
+		opts.SetMaxConnIdleTime(time.Duration(idle) * time.Second)
+	}
+	if timeout := GetEnvInt("MONGO_CONNECT_TIMEOUT_SECONDS", 0); timeout > 0 {
+		opts.SetConnectTimeout(time.Duration(timeout) * time.Second)
+	}
+	if timeout := GetEnvInt("MONGO_SERVER_SELECTION_TIMEOUT_SECONDS", 0); timeout > 0 {
+		opts.SetServerSelectionTimeout(time.Duration(timeout) * time.Second)
+	}
+	if level := GetEnv("MONGO_READ_CONCERN", ""); level != "" {
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: level})
+	}
+	if w := GetEnv("MONGO_WRITE_CONCERN", ""); w != "" {
+		if n, err := strconv.Atoi(w); err == nil {
+			opts.SetWriteConcern(&writeconcern.WriteConcern{W: n})
+		} else {
+			opts.SetWriteConcern(&writeconcern.WriteConcern{W: w})
+		}
+	}
+	if slow := GetEnvInt("MONGO_SLOW_QUERY_MS", 200); slow > 0 {
+		opts.SetMonitor(slowQueryMonitor(time.Duration(slow) * time.Millisecond))
+	}
+	if chaos.Enabled() {
+		opts.SetDialer(chaos.Dialer(&net.Dialer{KeepAlive: 5 * time.Minute}))
+	}
+	return opts
+}
+
+// slowQueryMonitor logs commands that take longer than threshold with the
+// shape of the command: its collection, the fields it filters on and its
+// pipeline stages. Values are left out since they include password and
+// token hashes and email addresses.
+func slowQueryMonitor(threshold time.Duration) *event.CommandMonitor {
+	var started sync.Map // request ID -> bson.Raw command
+
+	finished := func(e event.CommandFinishedEvent, outcome string) {
+		command, ok := started.LoadAndDelete(e.RequestID)
+		if !ok || e.Duration < threshold {
+			return
+		}
+		summary := commandShape(command.(bson.Raw))
+		log.Printf("🐢 Slow Mongo %s on %s took %v (%s): %s", e.CommandName, e.DatabaseName, e.Duration.Round(time.Millisecond), outcome, summary)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			// The driver reuses the command's buffer after the callback
+			started.Store(e.RequestID, append(bson.Raw(nil), e.Command...))
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent, "ok")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.CommandFinishedEvent, "failed: "+e.Failure)
+		},
+	}
+}
+
+// commandShape describes a command without any of its values, as in
+// "users filter [_id tenant_id]" or "orders pipeline [$match $group]"
+func commandShape(command bson.Raw) string {
+	elements, err := command.Elements()
+	if err != nil || len(elements) == 0 {
+		return "?"
+	}
+	// The first element names the command and its value the collection
+	shape := []string{}
+	if collection, ok := elements[0].Value().StringValueOK(); ok {
+		shape = append(shape, collection)
+	}
+
+	for _, key := range []string{"filter", "query"} {
+		if filter, ok := command.Lookup(key).DocumentOK(); ok {
+			shape = append(shape, key+" "+fmt.Sprint(documentKeys(filter)))
+		}
+	}
+	// Updates and deletes carry their filters in statements; a bulk write's
+	// first one stands for the rest
+	for _, key := range []string{"updates", "deletes"} {
+		statements, ok := command.Lookup(key).ArrayOK()
+		if !ok {
+			continue
+		}
+		first, err := statements.IndexErr(0)
+		if err != nil {
+			continue
+		}
+		if doc, ok := first.Value().DocumentOK(); ok {
+			if filter, ok := doc.Lookup("q").DocumentOK(); ok {
+				shape = append(shape, key+" "+fmt.Sprint(documentKeys(filter)))
+			}
+		}
+	}
+	if pipeline, ok := command.Lookup("pipeline").ArrayOK(); ok {
+		var stages []string
+		values, _ := pipeline.Values()
+		for _, stage := range values {
+			if doc, ok := stage.DocumentOK(); ok {
+				stages = append(stages, documentKeys(doc)...)
+			}
+		}
+		shape = append(shape, "pipeline "+fmt.Sprint(stages))
+	}
+	return strings.Join(shape, " ")
+}
+
+// documentKeys returns the top-level keys of a document
+func documentKeys(doc bson.Raw) []string {
+	elements, _ := doc.Elements()
+	keys := make([]string, 0, len(elements))
+	for _, element := range elements {
+		keys = append(keys, element.Key())
+	}
+	return keys
+}
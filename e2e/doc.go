@@ -0,0 +1,15 @@
+// Package e2e runs the main trading flow end to end against a real server
+// and fails if any step breaks an invariant: register, login, a market buy,
+// the portfolio it leaves, a stop order that triggers, and an order placed
+// and filled over an authenticated WebSocket.
+//
+// The tests are behind the integration build tag:
+//
+//	make e2e                                   # go test -tags integration ./...
+//	E2E_URL=http://localhost:8080 make e2e     # against a server already running
+//
+// Without E2E_URL they start a single-node Mongo replica set with
+// testcontainers (fills run in transactions, which need one), build and
+// start the server on a free port against it, and remove both when done.
+// E2E_MONGO_IMAGE (mongo:7) and E2E_SYMBOL (AAPL) override the defaults.
+package e2e
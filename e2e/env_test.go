@@ -0,0 +1,211 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// baseURL is the server every test talks to, set by TestMain
+var baseURL string
+
+func TestMain(m *testing.M) {
+	// Teardown runs before exiting with the result
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	baseURL = os.Getenv("E2E_URL")
+	if baseURL != "" {
+		baseURL = strings.TrimRight(baseURL, "/")
+		return m.Run()
+	}
+
+	env, err := start(context.Background())
+	defer env.stop()
+	if err != nil {
+		log.Printf("Failed to start the test environment: %v", err)
+		env.printLog()
+		return 2
+	}
+	baseURL = env.baseURL
+	code := m.Run()
+	if code != 0 {
+		env.printLog()
+	}
+	return code
+}
+
+// environment is a throwaway Mongo container and server
+type environment struct {
+	mongo   testcontainers.Container
+	server  *exec.Cmd
+	dir     string
+	baseURL string
+}
+
+// start brings up Mongo and the server. The environment it returns is
+// stopped by the caller, even when starting fails partway.
+func start(ctx context.Context) (*environment, error) {
+	env := &environment{}
+
+	var err error
+	env.mongo, err = testcontainers.Run(ctx, envOr("E2E_MONGO_IMAGE", "mongo:7"),
+		testcontainers.WithExposedPorts("27017/tcp"),
+		testcontainers.WithCmd("--replSet", "rs0", "--bind_ip_all"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort("27017/tcp")),
+	)
+	if err != nil {
+		return env, fmt.Errorf("starting Mongo: %w", err)
+	}
+	mongoAddr, err := env.mongo.PortEndpoint(ctx, "27017/tcp", "")
+	if err != nil {
+		return env, err
+	}
+	log.Printf("🐳 Mongo listening on %s", mongoAddr)
+
+	// Initiate the replica set, then wait for it to elect a primary
+	initiate := `try { rs.status().ok } catch (e) { rs.initiate({_id: "rs0", members: [{_id: 0, host: "localhost:27017"}]}).ok }`
+	if err := waitFor(60*time.Second, func() bool {
+		return env.mongosh(ctx, initiate) == "1"
+	}); err != nil {
+		return env, fmt.Errorf("replica set did not start: %w", err)
+	}
+	if err := waitFor(60*time.Second, func() bool {
+		return env.mongosh(ctx, "db.hello().isWritablePrimary") == "true"
+	}); err != nil {
+		return env, fmt.Errorf("no primary elected: %w", err)
+	}
+
+	env.dir, err = os.MkdirTemp("", "trading-e2e-")
+	if err != nil {
+		return env, err
+	}
+	binary := filepath.Join(env.dir, "server")
+	build := exec.Command("go", "build", "-o", binary, "./cmd")
+	build.Dir = ".."
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		return env, fmt.Errorf("building the server: %w", err)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return env, err
+	}
+	// The server refuses to start without a .env file
+	dotenv := strings.Join([]string{
+		"MONGODB_URI=mongodb://" + mongoAddr + "/?directConnection=true",
+		"DATABASE_NAME=e2e",
+		fmt.Sprintf("PORT=%d", port),
+		// Back-to-back orders in one symbol are the point of the flow
+		"ORDER_MIN_SYMBOL_INTERVAL_MS=0",
+		"MONGO_SLOW_QUERY_MS=0",
+	}, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(env.dir, ".env"), []byte(dotenv), 0o600); err != nil {
+		return env, err
+	}
+
+	env.server = exec.Command(binary)
+	env.server.Dir = env.dir
+	logFile, err := os.Create(filepath.Join(env.dir, "server.log"))
+	if err != nil {
+		return env, err
+	}
+	env.server.Stdout, env.server.Stderr = logFile, logFile
+	if err := env.server.Start(); err != nil {
+		return env, fmt.Errorf("starting the server: %w", err)
+	}
+	env.baseURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	if err := waitFor(60*time.Second, func() bool {
+		resp, err := client.Get(env.baseURL + "/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}); err != nil {
+		return env, fmt.Errorf("server did not become healthy: %w", err)
+	}
+	log.Printf("🚀 Server running at %s", env.baseURL)
+	return env, nil
+}
+
+// mongosh evaluates a script in the container and returns its trimmed output
+func (env *environment) mongosh(ctx context.Context, script string) string {
+	code, out, err := env.mongo.Exec(ctx, []string{"mongosh", "--quiet", "--eval", script}, tcexec.Multiplexed())
+	if err != nil || code != 0 {
+		return ""
+	}
+	data, _ := io.ReadAll(out)
+	return strings.TrimSpace(string(data))
+}
+
+// printLog writes the server's output to stderr to explain a failure
+func (env *environment) printLog() {
+	if env.dir == "" {
+		return
+	}
+	if data, err := os.ReadFile(filepath.Join(env.dir, "server.log")); err == nil {
+		fmt.Fprintf(os.Stderr, "--- server log ---\n%s", data)
+	}
+}
+
+func (env *environment) stop() {
+	if env.server != nil && env.server.Process != nil {
+		env.server.Process.Kill()
+		env.server.Wait()
+	}
+	if env.mongo != nil {
+		if err := testcontainers.TerminateContainer(env.mongo); err != nil {
+			log.Printf("Failed to remove the Mongo container: %v", err)
+		}
+	}
+	if env.dir != "" {
+		os.RemoveAll(env.dir)
+	}
+}
+
+func waitFor(timeout time.Duration, ready func() bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for !ready() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,315 @@
+//go:build integration
+
+package e2e
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"trading-simulator/internal/models"
+)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// flow carries what earlier steps learned to later ones
+type flow struct {
+	symbol   string
+	username string
+	password string
+	token    string
+	price    float64
+	cash     float64
+	shares   float64
+}
+
+// TestTradingFlow walks one new account through the main trading flow.
+// Each step needs the ones before it, so the first failure ends the test.
+func TestTradingFlow(t *testing.T) {
+	id := randomID(t)
+	f := &flow{
+		symbol:   envOr("E2E_SYMBOL", "AAPL"),
+		username: "e2e" + id,
+		password: "e2e-password-" + id,
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"register", f.register},
+		{"login", f.login},
+		{"market buy", f.buy},
+		{"portfolio", f.checkPortfolio},
+		{"stop trigger", f.stopTrigger},
+		{"WebSocket fill", f.socketFill},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+func (f *flow) register(t *testing.T) {
+	var auth struct {
+		Token string      `json:"token"`
+		User  models.User `json:"user"`
+	}
+	call(t, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"username": f.username,
+		"email":    f.username + "@e2e.local",
+		"password": f.password,
+	}, &auth)
+	if auth.Token == "" {
+		t.Fatal("no token returned")
+	}
+	f.cash = auth.User.CashBalance
+	if f.cash <= 0 {
+		t.Fatal("new account has no starting cash")
+	}
+}
+
+func (f *flow) login(t *testing.T) {
+	var auth struct {
+		Token string `json:"token"`
+	}
+	call(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"username": f.username,
+		"password": f.password,
+	}, &auth)
+	f.token = auth.Token
+
+	var me map[string]interface{}
+	call(t, http.MethodGet, "/api/auth/me", f.token, nil, &me)
+	if !strings.Contains(fmt.Sprint(me), f.username) {
+		t.Fatalf("/api/auth/me does not return %s", f.username)
+	}
+}
+
+// buy places a market buy and checks the cash it took
+func (f *flow) buy(t *testing.T) {
+	var stock models.Stock
+	call(t, http.MethodGet, "/api/stocks/"+f.symbol, "", nil, &stock)
+	if stock.Price <= 0 {
+		t.Fatalf("no price for %s", f.symbol)
+	}
+	f.price = stock.Price
+
+	var placed struct {
+		Order models.Order `json:"order"`
+	}
+	call(t, http.MethodPost, "/api/orders/place", f.token, map[string]interface{}{
+		"symbol":    f.symbol,
+		"type":      "buy",
+		"orderType": "market",
+		"quantity":  10,
+		"price":     f.price,
+	}, &placed)
+	if placed.Order.Status != "filled" || placed.Order.Quantity != 10 {
+		t.Fatalf("order not filled: %+v", placed.Order)
+	}
+	f.cash -= placed.Order.Price*placed.Order.Quantity + placed.Order.Fees
+	f.shares += placed.Order.Quantity
+}
+
+// checkPortfolio compares the stored account with what the fills say
+func (f *flow) checkPortfolio(t *testing.T) {
+	cash, shares := f.account(t)
+	if math.Abs(cash-f.cash) > 0.01 {
+		t.Fatalf("cash is $%.2f, fills add up to $%.2f", cash, f.cash)
+	}
+	if shares != f.shares {
+		t.Fatalf("holding %g %s, fills add up to %g", shares, f.symbol, f.shares)
+	}
+}
+
+// stopTrigger places a sell stop above the market, which the stop monitor
+// must fill on its next pass
+func (f *flow) stopTrigger(t *testing.T) {
+	var created struct {
+		Order models.Order `json:"order"`
+	}
+	call(t, http.MethodPost, "/api/advanced-orders/stop", f.token, map[string]interface{}{
+		"symbol":    f.symbol,
+		"type":      "sell",
+		"orderType": "stop",
+		"quantity":  4,
+		"price":     f.price,
+		"stopPrice": math.Round(f.price*200) / 100,
+	}, &created)
+
+	deadline := time.Now().Add(45 * time.Second)
+	for time.Now().Before(deadline) {
+		var history struct {
+			Orders []models.Order `json:"orders"`
+		}
+		call(t, http.MethodGet, "/api/advanced-orders/history", f.token, nil, &history)
+		for _, order := range history.Orders {
+			if order.ID != created.Order.ID {
+				continue
+			}
+			if order.Status != "triggered" {
+				t.Fatalf("stop order ended %s: %s", order.Status, order.FailMessage)
+			}
+			cash, shares := f.account(t)
+			if shares != f.shares-4 {
+				t.Fatalf("holding %g %s after the stop, want %g", shares, f.symbol, f.shares-4)
+			}
+			if cash <= f.cash {
+				t.Fatalf("cash did not rise after the stop sold: $%.2f", cash)
+			}
+			f.cash, f.shares = cash, shares
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	t.Fatalf("stop order %s did not trigger within 45s", created.Order.ID.Hex())
+}
+
+// socketFill places an order over an authenticated WebSocket and waits for
+// the fill in the reply
+func (f *flow) socketFill(t *testing.T) {
+	u, _ := url.Parse(baseURL)
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = "/ws"
+	u.RawQuery = url.Values{"token": {f.token}, "username": {f.username}}.Encode()
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	messages := make(chan map[string]interface{}, 256)
+	go func() {
+		defer close(messages)
+		for {
+			var message map[string]interface{}
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			select {
+			case messages <- message:
+			default: // Ticks nobody waits for
+			}
+		}
+	}()
+
+	err = conn.WriteJSON(map[string]interface{}{
+		"action": "place_order",
+		"id":     "e2e-sell",
+		"order": map[string]interface{}{
+			"symbol":    f.symbol,
+			"type":      "sell",
+			"orderType": "market",
+			"quantity":  1,
+			"price":     f.price,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(15 * time.Second)
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				t.Fatal("socket closed before the reply")
+			}
+			if message["id"] != "e2e-sell" {
+				continue
+			}
+			if message["type"] == "error" {
+				t.Fatalf("order refused: %v", message["error"])
+			}
+			order, _ := message["order"].(map[string]interface{})
+			if order["status"] != "filled" {
+				t.Fatalf("order was not filled: %v", message)
+			}
+			f.shares--
+			if _, shares := f.account(t); shares != f.shares {
+				t.Fatalf("holding %g %s after the socket sell, want %g", shares, f.symbol, f.shares)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no reply within 15s")
+		}
+	}
+}
+
+// account returns the user's cash and position in the traded symbol
+func (f *flow) account(t *testing.T) (float64, float64) {
+	var portfolio struct {
+		Portfolio   []models.Portfolio `json:"portfolio"`
+		CashBalance float64            `json:"cashBalance"`
+	}
+	call(t, http.MethodGet, "/api/portfolio", f.token, nil, &portfolio)
+	shares := 0.0
+	for _, pos := range portfolio.Portfolio {
+		if pos.Symbol == f.symbol {
+			shares = pos.Shares
+		}
+	}
+	return portfolio.CashBalance, shares
+}
+
+// call sends an optional JSON body to the server and decodes the response
+// into out when it is not nil. Any failure ends the test.
+func call(t *testing.T, method, path, token string, body, out interface{}) {
+	t.Helper()
+	var payload bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload.Reset(data)
+	}
+	req, err := http.NewRequest(method, baseURL+path, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		t.Fatalf("%s %s returned %s: %s", method, path, resp.Status, failure.Error)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+}
+
+func randomID(t *testing.T) string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("Failed to generate run ID: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
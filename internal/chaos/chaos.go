@@ -0,0 +1,206 @@
+// Package chaos injects latency and errors into the Mongo connection, the
+// market data provider and the WebSocket hub, to check that the server and
+// its clients cope with them.
+//
+// It does nothing unless CHAOS_ENABLED=true, which only development and
+// test deployments should set. Faults are switched on and off at runtime
+// through /api/admin/chaos and expire on their own.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Targets faults can be injected into
+const (
+	TargetMongo      = "mongo"       // Every read and write on Mongo connections
+	TargetMarketData = "market_data" // Price lookups
+	TargetWebSocket  = "websocket"   // Messages written to WebSocket clients
+)
+
+var Targets = []string{TargetMongo, TargetMarketData, TargetWebSocket}
+
+const (
+	DefaultDuration = 5 * time.Minute
+	MaxDuration     = time.Hour
+	MaxLatency      = 30 * time.Second
+)
+
+var (
+	ErrDisabled      = errors.New("fault injection is disabled; set CHAOS_ENABLED=true")
+	ErrUnknownTarget = fmt.Errorf("unknown chaos target; use one of %v", Targets)
+)
+
+// InjectedError is returned in place of a real failure
+type InjectedError struct {
+	Target string
+}
+
+func (e *InjectedError) Error() string { return "chaos: injected " + e.Target + " failure" }
+
+// Fault slows down and fails calls to a target until it expires
+type Fault struct {
+	Target    string    `json:"target"`
+	LatencyMs int       `json:"latencyMs"` // Added before every call
+	JitterMs  int       `json:"jitterMs"`  // Up to this much more latency, picked at random per call
+	ErrorRate float64   `json:"errorRate"` // Chance, 0 to 1, that a call fails
+	ExpiresAt time.Time `json:"expiresAt"`
+	SetBy     string    `json:"setBy,omitempty"`
+}
+
+var (
+	enabled atomic.Bool
+
+	mu     sync.RWMutex
+	faults = make(map[string]Fault)
+)
+
+// Enable turns fault injection on. It must be called before the Mongo
+// client is created for Mongo faults to work.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled reports whether fault injection is on
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Set starts a fault, replacing any on the same target. A zero ExpiresAt
+// lasts DefaultDuration.
+func Set(fault Fault) (Fault, error) {
+	if !Enabled() {
+		return fault, ErrDisabled
+	}
+	if !validTarget(fault.Target) {
+		return fault, ErrUnknownTarget
+	}
+	if fault.LatencyMs < 0 || fault.JitterMs < 0 || time.Duration(fault.LatencyMs+fault.JitterMs)*time.Millisecond > MaxLatency {
+		return fault, fmt.Errorf("latency plus jitter must be between 0 and %v", MaxLatency)
+	}
+	if fault.ErrorRate < 0 || fault.ErrorRate > 1 {
+		return fault, errors.New("error rate must be between 0 and 1")
+	}
+	now := time.Now().UTC()
+	if fault.ExpiresAt.IsZero() {
+		fault.ExpiresAt = now.Add(DefaultDuration)
+	}
+	if !fault.ExpiresAt.After(now) || fault.ExpiresAt.Sub(now) > MaxDuration {
+		return fault, fmt.Errorf("faults must expire within %v", MaxDuration)
+	}
+
+	mu.Lock()
+	faults[fault.Target] = fault
+	mu.Unlock()
+	return fault, nil
+}
+
+// Clear stops the fault on a target, or every fault for an empty target
+func Clear(target string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if target == "" {
+		faults = make(map[string]Fault)
+		return
+	}
+	delete(faults, target)
+}
+
+// List returns the active faults sorted by target
+func List() []Fault {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]Fault, 0, len(faults))
+	now := time.Now()
+	for _, fault := range faults {
+		if fault.ExpiresAt.After(now) {
+			list = append(list, fault)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Target < list[j].Target })
+	return list
+}
+
+// Inject applies the target's fault, if any: it waits out the latency and
+// then returns an *InjectedError as often as the error rate says
+func Inject(ctx context.Context, target string) error {
+	if !Enabled() {
+		return nil
+	}
+	mu.RLock()
+	fault, ok := faults[target]
+	mu.RUnlock()
+	if !ok || time.Now().After(fault.ExpiresAt) {
+		return nil
+	}
+
+	delay := time.Duration(fault.LatencyMs) * time.Millisecond
+	if fault.JitterMs > 0 {
+		delay += time.Duration(rand.Intn(fault.JitterMs+1)) * time.Millisecond
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fault.ErrorRate > 0 && rand.Float64() < fault.ErrorRate {
+		return &InjectedError{Target: target}
+	}
+	return nil
+}
+
+func validTarget(target string) bool {
+	for _, t := range Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialer wraps a Mongo dialer so the connections it opens are subject to
+// Mongo faults. Latency is added to every write to the server, and an
+// injected error fails the write like a dropped connection would, which is
+// what the driver sees when the database becomes unreachable.
+func Dialer(dialer *net.Dialer) *MongoDialer {
+	return &MongoDialer{dialer: dialer}
+}
+
+type MongoDialer struct {
+	dialer *net.Dialer
+}
+
+func (d *MongoDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := Inject(ctx, TargetMongo); err != nil {
+		return nil, err
+	}
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyConn{Conn: conn}, nil
+}
+
+type faultyConn struct {
+	net.Conn
+}
+
+func (c *faultyConn) Write(b []byte) (int, error) {
+	if err := Inject(context.Background(), TargetMongo); err != nil {
+		c.Conn.Close()
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AccessHandler struct {
+	policy *services.AccessPolicyService
+}
+
+func NewAccessHandler(policy *services.AccessPolicyService) *AccessHandler {
+	return &AccessHandler{policy: policy}
+}
+
+// TrustedIPsRequest replaces the user's trusted IPs and ranges
+type TrustedIPsRequest struct {
+	TrustedIPs []string `json:"trustedIps"` // e.g. ["203.0.113.7", "198.51.100.0/24"]; empty allows any IP
+}
+
+// RequireAccess blocks requests for the action from IPs or countries the
+// access policy does not allow. It must run after AuthMiddleware.
+func (h *AccessHandler) RequireAccess(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.Check(c, c.GetString("userID"), action); err != nil {
+			respondError(c, accessErrorStatus(err), err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Check applies the access policy to the request for the user
+func (h *AccessHandler) Check(c *gin.Context, userID, action string) error {
+	attempt := models.AccessAttempt{UserID: userID, Action: action, IP: c.ClientIP()}
+	if h.policy.GeoRestricted() {
+		if header := h.policy.CountryHeader(); header != "" {
+			attempt.Country = strings.ToUpper(c.GetHeader(header))
+		} else {
+			attempt.Country = h.policy.Resolve(c.Request.Context(), attempt.IP)
+		}
+	}
+	return h.policy.Check(c.Request.Context(), attempt)
+}
+
+// GetTrustedIPs lists the IPs and ranges the account is restricted to
+func (h *AccessHandler) GetTrustedIPs(c *gin.Context) {
+	ranges, err := h.policy.TrustedIPs(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		respondError(c, accessErrorStatus(err), err)
+		return
+	}
+	if ranges == nil {
+		ranges = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"trustedIps": ranges, "currentIp": c.ClientIP()})
+}
+
+// SetTrustedIPs restricts logins and trades to the given IPs and ranges.
+// The request's own IP must be included.
+func (h *AccessHandler) SetTrustedIPs(c *gin.Context) {
+	var req TrustedIPsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	ranges, err := h.policy.SetTrustedIPs(c.Request.Context(), c.GetString("userID"), c.ClientIP(), req.TrustedIPs)
+	if err != nil {
+		respondError(c, accessErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trustedIps": ranges})
+}
+
+// accessErrorStatus maps access policy errors to a status
+func accessErrorStatus(err error) int {
+	switch {
+	case errors.As(err, new(*services.AccessDeniedError)):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTrustedIPs):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
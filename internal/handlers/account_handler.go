@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+	ledgerService  *services.LedgerService
+	digestService  *services.DigestService
+	fx             *services.FXService
+}
+
+func NewAccountHandler(accountService *services.AccountService, ledgerService *services.LedgerService, digestService *services.DigestService, fx *services.FXService) *AccountHandler {
+	return &AccountHandler{accountService: accountService, ledgerService: ledgerService, digestService: digestService, fx: fx}
+}
+
+// Summary returns cash, buying power, equity, day P&L and open order counts
+// for the user's main account
+func (h *AccountHandler) Summary(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	summary, err := h.accountService.Summary(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build account summary: " + err.Error()})
+		return
+	}
+	services.ConvertSummary(summary, h.fx.Rate(c.GetString("currency")))
+	jsonLocal(c, http.StatusOK, summary)
+}
+
+// GetLedger lists cash movements other than trades, such as interest,
+// newest first. ?type= narrows the list; ?limit= caps it (default 100).
+func (h *AccountHandler) GetLedger(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	entries, err := h.ledgerService.List(c.Request.Context(), c.GetString("userID"), c.Query("type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ledger: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"entries": entries})
+}
+
+// PreviewDigest returns the email digest the user would get now, with its
+// rendered text. ?frequency=daily|weekly overrides the user's setting.
+func (h *AccountHandler) PreviewDigest(c *gin.Context) {
+	digest, email, err := h.digestService.Preview(c.Request.Context(), c.GetString("userID"), c.Query("frequency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"digest": digest, "subject": email.Subject, "text": email.Text})
+}
+
+// Export returns everything stored about the user as JSON, or as a ZIP
+// archive with ?format=zip
+func (h *AccountHandler) Export(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	export, err := h.accountService.Export(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("account-export-%s", export.ExportedAt.Format("20060102"))
+	if c.Query("format") != "zip" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		jsonLocal(c, http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	file, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "account.json",
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(export)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(c.Request.Context(), userID.(string), req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted. Your data will be removed after the retention period."})
+}
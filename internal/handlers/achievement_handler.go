@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler struct {
+	achievementService *services.AchievementService
+}
+
+func NewAchievementHandler(achievementService *services.AchievementService) *AchievementHandler {
+	return &AchievementHandler{achievementService: achievementService}
+}
+
+func (h *AchievementHandler) GetAchievements(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	achievements, err := h.achievementService.GetAchievements(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements: " + err.Error()})
+		return
+	}
+	lang := language(c)
+	for i := range achievements {
+		achievements[i].Name = i18n.Translate(lang, "achievement."+achievements[i].Code+".name")
+		achievements[i].Description = i18n.Translate(lang, "achievement."+achievements[i].Code+".description")
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"achievements": achievements})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	orderGuard   *services.OrderGuardService
+	statsService *services.StatsService
+}
+
+func NewAdminHandler(orderGuard *services.OrderGuardService, statsService *services.StatsService) *AdminHandler {
+	return &AdminHandler{orderGuard: orderGuard, statsService: statsService}
+}
+
+// GetViolations lists recent order throttling and wash trade violations in
+// the request's tenant
+func (h *AdminHandler) GetViolations(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	violations, err := h.orderGuard.GetViolations(c.Request.Context(), c.GetString("tenantID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch violations: " + err.Error()})
+		return
+	}
+
+	jsonLocal(c, http.StatusOK, gin.H{"violations": violations})
+}
+
+// GetStats returns aggregates for the admin dashboard, scoped to the
+// request's tenant when tenancy is on
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.statsService.GetStats(c.GetString("tenantID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, stats)
+}
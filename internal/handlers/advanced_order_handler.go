@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"trading-simulator/internal/models"
@@ -21,10 +22,29 @@ type StopOrderRequest struct {
 	Symbol     string  `json:"symbol" binding:"required"`
 	Type       string  `json:"type" binding:"required"`
 	OrderType  string  `json:"orderType" binding:"required"`
-	Quantity   int     `json:"quantity" binding:"required,min=1"`
+	Quantity   float64 `json:"quantity" binding:"required,gt=0"`
 	Price      float64 `json:"price" binding:"required,min=0.01"`
 	StopPrice  float64 `json:"stopPrice" binding:"required,min=0.01"`
 	LimitPrice float64 `json:"limitPrice,omitempty"`
+	// Required for trailing stops: distance of the stop from the best price seen
+	TrailingPercent float64 `json:"trailingPercent,omitempty"`
+	// Optional: ID of another of your active orders; whichever triggers first cancels the other
+	OCOWith string `json:"ocoWith,omitempty"`
+	// Optional: place the order in a competition account instead of the main account
+	CompetitionID string `json:"competitionId"`
+	// Optional: cancel the order if it has not triggered by then. Defaults to
+	// ADVANCED_ORDER_VALIDITY_DAYS after placement.
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	// Optional: keep the order scheduled until this time before it starts
+	// watching the price
+	ActivateAt time.Time `json:"activateAt,omitempty"`
+	// Optional: only trigger while another symbol's price condition holds,
+	// e.g. {"expression":"SPY > 450"}
+	Condition *models.OrderCondition `json:"condition,omitempty"`
+	// Optional: confirm an order identical to one placed moments ago
+	AllowDuplicate bool `json:"allowDuplicate"`
+	// Optional: allow the order to trigger pre-market and after-hours
+	ExtendedHours bool `json:"extendedHours"`
 }
 
 func (h *AdvancedOrderHandler) CreateStopOrder(c *gin.Context) {
@@ -41,20 +61,28 @@ func (h *AdvancedOrderHandler) CreateStopOrder(c *gin.Context) {
 	}
 
 	o := &models.Order{
-		UserID:     userID.(string),
-		Symbol:     req.Symbol,
-		Type:       req.Type,
-		OrderType:  req.OrderType,
-		Quantity:   req.Quantity,
-		Price:      req.Price,
-		StopPrice:  req.StopPrice,
-		LimitPrice: req.LimitPrice,
-		Status:     "active",
-		Timestamp:  time.Now(),
+		UserID:          userID.(string),
+		Symbol:          req.Symbol,
+		Type:            req.Type,
+		OrderType:       req.OrderType,
+		Quantity:        req.Quantity,
+		Price:           req.Price,
+		StopPrice:       req.StopPrice,
+		LimitPrice:      req.LimitPrice,
+		TrailingPercent: req.TrailingPercent,
+		CompetitionID:   req.CompetitionID,
+		TenantID:        c.GetString("tenantID"),
+		ExpiresAt:       req.ExpiresAt,
+		ActivateAt:      req.ActivateAt,
+		Condition:       req.Condition,
+		AllowDuplicate:  req.AllowDuplicate,
+		ExtendedHours:   req.ExtendedHours,
+		Status:          "active",
+		Timestamp:       time.Now().UTC(),
 	}
 
-	if err := h.service.CreateStopOrder(o); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.CreateStopOrder(c.Request.Context(), o, req.OCOWith); err != nil {
+		respondError(c, orderErrorStatus(err), err)
 		return
 	}
 
@@ -70,12 +98,40 @@ func (h *AdvancedOrderHandler) GetActiveOrders(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
 		return
 	}
-	list, err := h.service.GetActiveStopOrders(userID.(string))
+	list, err := h.service.GetActiveStopOrders(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"orders": list})
+	jsonLocal(c, http.StatusOK, gin.H{"orders": list})
+}
+
+// GetOrderHistory lists stop orders that are no longer active. Failed
+// orders carry failReason and failMessage; ?status= narrows the list to
+// triggered, failed or cancelled.
+func (h *AdvancedOrderHandler) GetOrderHistory(c *gin.Context) {
+	userID, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return
+	}
+	status := c.Query("status")
+	if status != "" && status != "triggered" && status != "failed" && status != "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be triggered, failed or cancelled"})
+		return
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	list, err := h.service.GetStopOrderHistory(c.Request.Context(), userID.(string), status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"orders": list})
 }
 
 // userID is extracted but not used in service → keep it for consistency
@@ -87,9 +143,9 @@ func (h *AdvancedOrderHandler) CancelOrder(c *gin.Context) {
 	}
 	orderID := c.Param("id")
 
-	if err := h.service.CancelStopOrder(orderID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.CancelStopOrder(c.Request.Context(), orderID); err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "order cancelled"})
-}
\ No newline at end of file
+}
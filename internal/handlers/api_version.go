@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion tags requests with the API version of their route group.
+// Version 2 and later use the structured error format.
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("apiVersion", version)
+		c.Header("API-Version", "v"+strconv.Itoa(version))
+		if version < 2 {
+			c.Next()
+			return
+		}
+
+		writer := &errorWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		writer.flush()
+	}
+}
+
+// apiVersion returns the version of the request's route group. Unversioned
+// /api paths are version 1.
+func apiVersion(c *gin.Context) int {
+	if version := c.GetInt("apiVersion"); version > 0 {
+		return version
+	}
+	return 1
+}
+
+// errorWriter holds back error responses so they can be rewritten from the
+// v1 shape {"error": "...", "code": "..."} to the v2 shape
+// {"error": {"code": "...", "message": "...", "status": 400}}. Successful
+// responses pass straight through.
+type errorWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorWriter) Write(data []byte) (int, error) {
+	if w.Status() < http.StatusBadRequest {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *errorWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *errorWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.body.Len() > 0
+}
+
+func (w *errorWriter) flush() {
+	if w.body.Len() == 0 {
+		return
+	}
+
+	var v1 struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &v1); err != nil || v1.Error == "" {
+		w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+	if v1.Code == "" {
+		v1.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(w.Status())), " ", "_")
+	}
+
+	body, _ := json.Marshal(gin.H{"error": gin.H{
+		"code":    v1.Code,
+		"message": v1.Error,
+		"status":  w.Status(),
+	}})
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// GetAuditLog lists audit entries, newest first. ?userId= and ?action=
+// narrow the list; ?limit= caps it (default 100).
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	entries, err := h.auditService.List(c.Request.Context(), models.AuditFilter{
+		UserID: c.Query("userId"),
+		Action: c.Query("action"),
+		Limit:  limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"entries": entries})
+}
@@ -1,24 +1,36 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"trading-simulator/internal/i18n"
 	"trading-simulator/internal/models"
 	"trading-simulator/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// Impersonation tokens expire after this time
+const impersonationTTL = 30 * time.Minute
+
 type AuthHandler struct {
-	authService *services.AuthService
-	jwtSecret   string
+	authService  *services.AuthService
+	auditService *services.AuditService
+	access       *AccessHandler
+	jwtSecret    string
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, auditService *services.AuditService, access *AccessHandler) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		jwtSecret:   "your-super-secret-jwt-key-change-in-production",
+		authService:  authService,
+		auditService: auditService,
+		access:       access,
+		jwtSecret:    "your-super-secret-jwt-key-change-in-production",
 	}
 }
 
@@ -26,6 +38,10 @@ type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=20"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+	// Optional invite code from an existing user
+	InviteCode string `json:"inviteCode"`
+	// Optional account tier, e.g. "pro"; see GET /api/tiers
+	Tier string `json:"tier"`
 }
 
 type LoginRequest struct {
@@ -49,9 +65,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
+		TenantID: c.GetString("tenantID"),
+		Tier:     req.Tier,
 	}
 
-	err := h.authService.Register(user)
+	err := h.authService.Register(c.Request.Context(), user, req.InviteCode)
+	if errors.As(err, new(*services.UserConflictError)) {
+		respondError(c, http.StatusConflict, err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -77,11 +99,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.Login(req.Username, req.Password)
+	user, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
+	if err == nil && !canUseTenant(user, c.GetString("tenantID")) {
+		err = errors.New("invalid username or password")
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	// Only checked once the password is right, so strangers cannot probe
+	// an account's trusted IPs
+	if err := h.access.Check(c, user.ID.Hex(), services.AccessLogin); err != nil {
+		respondError(c, accessErrorStatus(err), err)
+		return
+	}
 
 	token, err := h.generateToken(user)
 	if err != nil {
@@ -96,10 +127,105 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// ChangeEmailRequest asks to move the account to a new email
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"` // Current password
+}
+
+// VerifyEmailRequest confirms a new email with the code mailed to it
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ChangeUsernameRequest renames the account
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=20"`
+	Password string `json:"password" binding:"required"` // Current password
+}
+
+// ChangeEmail mails a verification code to the new address; the email
+// changes once it is verified
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	user, err := h.authService.RequestEmailChange(c.Request.Context(), c.GetString("userID"), req.Password, req.Email)
+	if err != nil {
+		respondError(c, accountChangeStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Verification code sent to " + user.PendingEmail,
+		"email":        user.Email,
+		"pendingEmail": user.PendingEmail,
+	})
+}
+
+// VerifyEmail switches the account to its pending email. The code
+// identifies the account, so the request needs no token.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	user, err := h.authService.VerifyEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		respondError(c, accountChangeStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email changed", "email": user.Email})
+}
+
+// ChangeUsername renames the user and returns a token carrying the new name
+func (h *AuthHandler) ChangeUsername(c *gin.Context) {
+	var req ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	user, err := h.authService.ChangeUsername(c.Request.Context(), c.GetString("userID"), req.Password, req.Username)
+	if err != nil {
+		respondError(c, accountChangeStatus(err), err)
+		return
+	}
+	token, err := h.generateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, AuthResponse{Token: token, User: *user})
+}
+
+// accountChangeStatus maps email and username change errors to a status
+func accountChangeStatus(err error) int {
+	var msgErr *i18n.Error
+	switch {
+	case errors.As(err, new(*services.UserConflictError)):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &msgErr) && msgErr.Code == "user.wrong_password":
+		return http.StatusForbidden
+	case errors.As(err, &msgErr) && msgErr.Code == "user.username_cooldown":
+		return http.StatusTooManyRequests
+	case errors.As(err, &msgErr):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *AuthHandler) generateToken(user *models.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userID":   user.ID.Hex(),
 		"username": user.Username,
+		"tenantID": user.TenantID,
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 	})
 	return token.SignedString([]byte(h.jwtSecret))
@@ -117,24 +243,233 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			tokenString = tokenString[7:]
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(h.jwtSecret), nil
-		})
+		claims, err := h.parseToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		// Tokens stay valid until they expire, so a deleted account is
+		// checked for on every request
+		user, err := h.authService.GetUserByID(c.Request.Context(), claims["userID"].(string))
+		if err != nil || !user.DeletedAt.IsZero() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account not found or deleted"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || claims["userID"] == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		// A token only works for its own tenant; platform admins can act in any
+		tokenTenant, _ := claims["tenantID"].(string)
+		if tenant := c.GetString("tenantID"); tokenTenant != tenant && !canUseTenant(user, tenant) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid for this tenant"})
 			c.Abort()
 			return
 		}
 
 		c.Set("userID", claims["userID"].(string))
+		c.Set("user", user)
+		if impersonatorID, _ := claims["impersonatorID"].(string); impersonatorID != "" {
+			c.Set("impersonatorID", impersonatorID)
+			if !h.auditImpersonation(c, impersonatorID) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// auditImpersonation records a request made with an impersonation token
+// before it runs, and refuses anything but reads. Requests that cannot be
+// recorded are refused too.
+func (h *AuthHandler) auditImpersonation(c *gin.Context, impersonatorID string) bool {
+	readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions
+	action := services.AuditImpersonatedRequest
+	if !readOnly {
+		action = services.AuditImpersonationBlocked
+	}
+	err := h.auditService.Record(c.Request.Context(), models.AuditEntry{
+		Action:   action,
+		UserID:   c.GetString("userID"),
+		ActorID:  impersonatorID,
+		TenantID: c.GetString("tenantID"),
+		Details: map[string]string{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.RequestURI(),
+			"ip":     c.ClientIP(),
+		},
+	})
+	switch {
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Impersonated requests cannot be audited right now"})
+	case !readOnly:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Impersonation is read-only"})
+	default:
+		return true
+	}
+	c.Abort()
+	return false
+}
+
+// ImpersonateRequest gives the support reason for viewing a user's account
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Impersonate issues a read-only token for another user's account, valid
+// for 30 minutes, so support can see what they see. Admins cannot be
+// impersonated. The grant and every request made with the token are
+// recorded in the audit log.
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if c.GetString("impersonatorID") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Impersonation is read-only"})
+		return
+	}
+
+	user, err := h.authService.GetUserByID(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Role == "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot be impersonated"})
+		return
+	}
+
+	expiresAt := time.Now().Add(impersonationTTL).UTC()
+	adminID := c.GetString("userID")
+	err = h.auditService.Record(c.Request.Context(), models.AuditEntry{
+		Action:   services.AuditImpersonationStarted,
+		UserID:   user.ID.Hex(),
+		ActorID:  adminID,
+		TenantID: user.TenantID,
+		Details: map[string]string{
+			"reason":    req.Reason,
+			"expiresAt": expiresAt.Format(time.RFC3339),
+			"ip":        c.ClientIP(),
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impersonation: " + err.Error()})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID":         user.ID.Hex(),
+		"username":       user.Username,
+		"tenantID":       user.TenantID,
+		"impersonatorID": adminID,
+		"exp":            expiresAt.Unix(),
+	})
+	signed, err := token.SignedString([]byte(h.jwtSecret))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	log.Printf("🕵️ Admin %s is impersonating %s until %s: %s", adminID, user.Username, expiresAt.Format(time.RFC3339), req.Reason)
+	user.Password = ""
+	c.JSON(http.StatusOK, gin.H{"token": signed, "expiresAt": expiresAt, "readOnly": true, "user": user})
+}
+
+// OptionalAuth identifies the user on public routes that personalize their
+// response. Requests without a valid token for the tenant stay anonymous
+// instead of being rejected.
+func (h *AuthHandler) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString != "" {
+			claims, err := h.parseToken(tokenString)
+			if tenantID, _ := claims["tenantID"].(string); err == nil && tenantID == c.GetString("tenantID") {
+				c.Set("userID", claims["userID"].(string))
+			}
+		}
+		c.Next()
+	}
+}
+
+// parseToken validates a JWT and returns its claims
+func (h *AuthHandler) parseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(h.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["userID"] == nil {
+		return nil, errors.New("Invalid token claims")
+	}
+	return claims, nil
+}
+
+// AuthenticateToken returns the user ID, username and tenant in a valid
+// JWT, for connections such as WebSockets that pass the token outside the
+// Authorization header. Tokens of deleted accounts are refused.
+func (h *AuthHandler) AuthenticateToken(ctx context.Context, tokenString string) (userID, username, tenantID string, err error) {
+	claims, err := h.parseToken(tokenString)
+	if err != nil {
+		return "", "", "", err
+	}
+	// Authenticated sockets can trade, which impersonation never allows
+	if impersonatorID, _ := claims["impersonatorID"].(string); impersonatorID != "" {
+		return "", "", "", errors.New("Impersonation tokens cannot open authenticated sockets")
+	}
+	userID, _ = claims["userID"].(string)
+	username, _ = claims["username"].(string)
+	tenantID, _ = claims["tenantID"].(string)
+	user, err := h.authService.GetUserByID(ctx, userID)
+	if err != nil || !user.DeletedAt.IsZero() {
+		return "", "", "", errors.New("Account not found or deleted")
+	}
+	return userID, username, tenantID, nil
+}
+
+// currentUser returns the user AuthMiddleware loaded for the request
+func (h *AuthHandler) currentUser(c *gin.Context) (*models.User, error) {
+	if user, ok := c.Get("user"); ok {
+		return user.(*models.User), nil
+	}
+	return h.authService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+}
+
+// canUseTenant reports whether the user may act in the tenant: their own,
+// or any tenant for admins who belong to none
+func canUseTenant(user *models.User, tenantID string) bool {
+	return user.TenantID == tenantID || (user.TenantID == "" && user.Role == "admin")
+}
+
+// AdminMiddleware restricts a route to users with the admin role. Tenant
+// admins only administer their own tenant, which AuthMiddleware has already
+// checked. It must run after AuthMiddleware.
+func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := h.currentUser(c)
+		if err != nil || user.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// PlatformAdminMiddleware restricts a route to admins who belong to no
+// tenant, such as tenant management. It must run after AuthMiddleware.
+func (h *AuthHandler) PlatformAdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := h.currentUser(c)
+		if err != nil || user.Role != "admin" || user.TenantID != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Platform admin access required"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -147,7 +482,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.GetUserByID(userID.(string))
+	user, err := h.authService.GetUserByID(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
 		return
@@ -158,10 +493,92 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":          user.ID.Hex(),
-			"username":    user.Username,
-			"email":       user.Email,
-			"cashBalance": user.CashBalance,
+			"id":            user.ID.Hex(),
+			"username":      user.Username,
+			"email":         user.Email,
+			"cashBalance":   user.CashBalance,
+			"reservedCash":  user.ReservedCash,
+			"role":          user.Role,
+			"tenantId":      user.TenantID,
+			"tier":          user.Tier,
+			"timezone":      user.Location().String(),
+			"language":      user.Language,
+			"digest":        user.Digest,
+			"publicProfile": user.PublicProfile,
 		},
+		"impersonatedBy": c.GetString("impersonatorID"), // Set when support is viewing the account
 	})
-}
\ No newline at end of file
+}
+
+type PreferencesRequest struct {
+	Timezone *string `json:"timezone"` // IANA name, e.g. "America/New_York"
+	Language *string `json:"language"` // "en" or "es"; empty to follow Accept-Language
+	Currency *string `json:"currency"` // ISO code balances are shown in, e.g. "EUR"; empty for USD
+	// Hide the user from presence lists; they still count in online totals
+	HidePresence *bool `json:"hidePresence"`
+	// "daily" or "weekly" email digest; empty to stop them
+	Digest *string `json:"digest"`
+	// Appear in user search with a public profile
+	PublicProfile *bool `json:"publicProfile"`
+}
+
+// UpdatePreferences changes the user's display preferences
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if req.Timezone == nil && req.Language == nil && req.Currency == nil && req.HidePresence == nil && req.Digest == nil && req.PublicProfile == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: nothing to update"})
+		return
+	}
+	if req.Timezone != nil {
+		if err := h.authService.SetTimezone(c.Request.Context(), userID.(string), *req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Language != nil {
+		if err := h.authService.SetLanguage(c.Request.Context(), userID.(string), *req.Language); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Currency != nil {
+		if err := h.authService.SetCurrency(c.Request.Context(), userID.(string), *req.Currency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.HidePresence != nil {
+		if err := h.authService.SetHidePresence(c.Request.Context(), userID.(string), *req.HidePresence); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.Digest != nil {
+		if err := h.authService.SetDigest(c.Request.Context(), userID.(string), *req.Digest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.PublicProfile != nil {
+		if err := h.authService.SetPublicProfile(c.Request.Context(), userID.(string), *req.PublicProfile); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences updated"})
+}
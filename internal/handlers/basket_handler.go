@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BasketHandler struct {
+	basketService *services.BasketService
+}
+
+func NewBasketHandler(basketService *services.BasketService) *BasketHandler {
+	return &BasketHandler{basketService: basketService}
+}
+
+// BasketLegRequest is one order of a basket, shaped like a regular order
+type BasketLegRequest struct {
+	Symbol    string  `json:"symbol" binding:"required"`
+	Type      string  `json:"type" binding:"required"`      // "buy" or "sell"
+	OrderType string  `json:"orderType" binding:"required"` // "market" or "limit"
+	Quantity  float64 `json:"quantity" binding:"omitempty,gt=0"`
+	Notional  float64 `json:"notional" binding:"omitempty,gt=0"`
+	Price     float64 `json:"price" binding:"required,min=0.01"`
+}
+
+type PlaceBasketRequest struct {
+	Legs []BasketLegRequest `json:"legs" binding:"required,min=1,dive"`
+	// Optional: fill every leg or none of them
+	AllOrNothing bool `json:"allOrNothing"`
+	// Optional: place the basket in a competition account instead of the main account
+	CompetitionID string `json:"competitionId"`
+}
+
+// PlaceBasket fills several orders in one request and returns each leg's
+// result. A basket with rejected legs is still a 200; its status says how
+// much of it filled.
+func (h *BasketHandler) PlaceBasket(c *gin.Context) {
+	var req PlaceBasketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	basket := &models.Basket{
+		UserID:        c.GetString("userID"),
+		CompetitionID: req.CompetitionID,
+		TenantID:      c.GetString("tenantID"),
+		AllOrNothing:  req.AllOrNothing,
+	}
+	for _, leg := range req.Legs {
+		basket.Legs = append(basket.Legs, models.BasketLeg{
+			Symbol:    leg.Symbol,
+			Type:      leg.Type,
+			OrderType: leg.OrderType,
+			Quantity:  leg.Quantity,
+			Notional:  leg.Notional,
+			Price:     leg.Price,
+		})
+	}
+
+	err := h.basketService.PlaceBasket(c.Request.Context(), basket, language(c))
+	if errors.Is(err, services.ErrBasketNeedsTransactions) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{
+		"basketId": basket.ID.Hex(),
+		"basket":   basket,
+	})
+}
+
+func (h *BasketHandler) GetBasket(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid basket ID"})
+		return
+	}
+
+	basket, err := h.basketService.GetBasket(c.Request.Context(), c.GetString("userID"), id)
+	if errors.Is(err, services.ErrBasketNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch basket: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"basket": basket})
+}
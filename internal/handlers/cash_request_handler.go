@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CashRequestHandler struct {
+	cashRequests *services.CashRequestService
+	classrooms   *services.ClassroomService
+}
+
+func NewCashRequestHandler(cashRequests *services.CashRequestService, classrooms *services.ClassroomService) *CashRequestHandler {
+	return &CashRequestHandler{cashRequests: cashRequests, classrooms: classrooms}
+}
+
+type CashRequestBody struct {
+	Type   string  `json:"type" binding:"required"` // "deposit" or "withdrawal"
+	Amount float64 `json:"amount" binding:"required"`
+	Note   string  `json:"note"`
+}
+
+type ReviewCashRequestBody struct {
+	Note string `json:"note"`
+}
+
+// CreateRequest deposits or withdraws simulated cash. It answers 201 when
+// the request was applied and 202 when it waits for an admin's or teacher's
+// review.
+func (h *CashRequestHandler) CreateRequest(c *gin.Context) {
+	var req CashRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	request, err := h.cashRequests.Request(c.Request.Context(), c.GetString("userID"), req.Type, req.Amount, req.Note)
+	if errors.Is(err, services.ErrCashRequestType) || errors.Is(err, services.ErrCashRequestAmount) || errors.Is(err, services.ErrCashRequestFunds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cash request: " + err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if request.Status == "pending" {
+		status = http.StatusAccepted
+	}
+	jsonLocal(c, status, gin.H{"request": request})
+}
+
+// ListRequests returns the user's deposits and withdrawals, newest first.
+// ?limit= caps them (default 50).
+func (h *CashRequestHandler) ListRequests(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	requests, err := h.cashRequests.List(c.Request.Context(), c.GetString("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash requests: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"requests": requests})
+}
+
+// GetQueue returns the requests awaiting review, scoped to the request's
+// tenant when tenancy is on. ?status= picks approved or denied requests
+// instead, or all of them; ?limit= caps them (default 100).
+func (h *CashRequestHandler) GetQueue(c *gin.Context) {
+	status, limit, ok := queueParams(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.cashRequests.Queue(c.Request.Context(), c.GetString("tenantID"), status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash requests: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"requests": requests})
+}
+
+// GetClassroomQueue returns the requests of a classroom's students to its
+// teacher, with the same query parameters as GetQueue
+func (h *CashRequestHandler) GetClassroomQueue(c *gin.Context) {
+	status, limit, ok := queueParams(c)
+	if !ok {
+		return
+	}
+	classroom, err := h.classrooms.GetTeacherClassroom(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	requests, err := h.cashRequests.ClassroomQueue(c.Request.Context(), classroom, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash requests: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"requests": requests})
+}
+
+// queueParams reads ?status= and ?limit= for a review queue, answering 400
+// when either is invalid
+func queueParams(c *gin.Context) (string, int64, bool) {
+	status := c.DefaultQuery("status", "pending")
+	switch status {
+	case "pending", "approved", "denied":
+	case "all":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved, denied or all"})
+		return "", 0, false
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return "", 0, false
+	}
+	return status, limit, true
+}
+
+// Approve applies a pending request
+func (h *CashRequestHandler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+// Deny rejects a pending request
+func (h *CashRequestHandler) Deny(c *gin.Context) {
+	h.review(c, false)
+}
+
+// ApproveForClassroom lets a classroom's teacher apply a pending request
+// from one of its students
+func (h *CashRequestHandler) ApproveForClassroom(c *gin.Context) {
+	h.reviewForClassroom(c, true)
+}
+
+// DenyForClassroom lets a classroom's teacher reject a pending request from
+// one of its students
+func (h *CashRequestHandler) DenyForClassroom(c *gin.Context) {
+	h.reviewForClassroom(c, false)
+}
+
+func (h *CashRequestHandler) review(c *gin.Context, approve bool) {
+	note, ok := reviewNote(c)
+	if !ok {
+		return
+	}
+	request, err := h.cashRequests.Review(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.GetString("tenantID"), approve, note)
+	respondReview(c, request, err)
+}
+
+func (h *CashRequestHandler) reviewForClassroom(c *gin.Context, approve bool) {
+	note, ok := reviewNote(c)
+	if !ok {
+		return
+	}
+	classroom, err := h.classrooms.GetTeacherClassroom(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	request, err := h.cashRequests.ReviewForClassroom(c.Request.Context(), classroom, c.Param("requestId"), approve, note)
+	respondReview(c, request, err)
+}
+
+// reviewNote reads the optional review body, answering 400 when it is
+// invalid
+func reviewNote(c *gin.Context) (string, bool) {
+	var req ReviewCashRequestBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return "", false
+		}
+	}
+	return req.Note, true
+}
+
+func respondReview(c *gin.Context, request *models.CashRequest, err error) {
+	switch {
+	case errors.Is(err, services.ErrCashRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCashRequestReviewed), errors.Is(err, services.ErrCashRequestFunds):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCashRequestOwn):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review cash request: " + err.Error()})
+	default:
+		jsonLocal(c, http.StatusOK, gin.H{"request": request})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"trading-simulator/internal/chaos"
+	"github.com/gin-gonic/gin"
+)
+
+// ChaosHandler switches fault injection on and off. Its routes only exist
+// when CHAOS_ENABLED=true.
+type ChaosHandler struct{}
+
+func NewChaosHandler() *ChaosHandler {
+	return &ChaosHandler{}
+}
+
+// SetFaultRequest describes a fault. Omitting the duration keeps it for
+// five minutes.
+type SetFaultRequest struct {
+	LatencyMs       int     `json:"latencyMs"`
+	JitterMs        int     `json:"jitterMs"`
+	ErrorRate       float64 `json:"errorRate"`
+	DurationSeconds int     `json:"durationSeconds"`
+}
+
+func (h *ChaosHandler) ListFaults(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"faults": chaos.List(), "targets": chaos.Targets})
+}
+
+// SetFault starts or replaces the fault on a target
+func (h *ChaosHandler) SetFault(c *gin.Context) {
+	var req SetFaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	fault := chaos.Fault{
+		Target:    c.Param("target"),
+		LatencyMs: req.LatencyMs,
+		JitterMs:  req.JitterMs,
+		ErrorRate: req.ErrorRate,
+		SetBy:     c.GetString("userID"),
+	}
+	if req.DurationSeconds > 0 {
+		fault.ExpiresAt = time.Now().UTC().Add(time.Duration(req.DurationSeconds) * time.Second)
+	}
+	fault, err := chaos.Set(fault)
+	switch {
+	case errors.Is(err, chaos.ErrUnknownTarget):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fault": fault})
+}
+
+// ClearFault stops the fault on a target
+func (h *ChaosHandler) ClearFault(c *gin.Context) {
+	chaos.Clear(c.Param("target"))
+	c.JSON(http.StatusOK, gin.H{"faults": chaos.List()})
+}
+
+// ClearFaults stops every fault
+func (h *ChaosHandler) ClearFaults(c *gin.Context) {
+	chaos.Clear("")
+	c.JSON(http.StatusOK, gin.H{"faults": chaos.List()})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ClassroomHandler struct {
+	classroomService *services.ClassroomService
+}
+
+func NewClassroomHandler(classroomService *services.ClassroomService) *ClassroomHandler {
+	return &ClassroomHandler{classroomService: classroomService}
+}
+
+type CreateClassroomRequest struct {
+	Name string `json:"name" binding:"required,min=3,max=60"`
+}
+
+type JoinClassroomRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// SeedStudentRequest sets a student's cash and positions. Omitting cash
+// resets to the default $10,000 starting balance.
+type SeedStudentRequest struct {
+	CashBalance *float64           `json:"cashBalance"`
+	Positions   []models.Portfolio `json:"positions"`
+}
+
+func (h *ClassroomHandler) CreateClassroom(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	classroom, err := h.classroomService.CreateClassroom(c.Request.Context(), req.Name, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create classroom: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Classroom created",
+		"classroom": classroom,
+	})
+}
+
+func (h *ClassroomHandler) ListClassrooms(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	classrooms, err := h.classroomService.ListClassrooms(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch classrooms: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"classrooms": classrooms})
+}
+
+func (h *ClassroomHandler) JoinClassroom(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req JoinClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	classroom, err := h.classroomService.JoinClassroom(c.Request.Context(), req.Code, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Joined classroom",
+		"classroom": gin.H{"id": classroom.ID, "name": classroom.Name},
+	})
+}
+
+// GetDashboard shows the teacher every student's portfolio and recent trades
+func (h *ClassroomHandler) GetDashboard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	classroom, students, err := h.classroomService.GetDashboard(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	jsonLocal(c, http.StatusOK, gin.H{
+		"classroom": classroom,
+		"students":  students,
+	})
+}
+
+func (h *ClassroomHandler) SeedStudent(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SeedStudentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	cash := 10000.0
+	if req.CashBalance != nil {
+		cash = *req.CashBalance
+	}
+
+	err := h.classroomService.SeedStudent(c.Request.Context(), c.Param("id"), userID.(string), c.Param("studentId"), cash, req.Positions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Student account updated"})
+}
+
+// SetRestrictions limits what the classroom's students can trade
+func (h *ClassroomHandler) SetRestrictions(c *gin.Context) {
+	var req models.TradingRestrictions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	classroom, err := h.classroomService.SetRestrictions(c.Request.Context(), c.Param("id"), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{
+		"message":   "Trading restrictions updated",
+		"classroom": classroom,
+	})
+}
+
+// GetPermissions shows what the user may trade after their group's and
+// classrooms' restrictions
+func (h *ClassroomHandler) GetPermissions(c *gin.Context) {
+	perms, err := h.classroomService.Permissions(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permissions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": perms})
+}
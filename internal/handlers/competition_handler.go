@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CompetitionHandler struct {
+	competitionService *services.CompetitionService
+}
+
+func NewCompetitionHandler(competitionService *services.CompetitionService) *CompetitionHandler {
+	return &CompetitionHandler{competitionService: competitionService}
+}
+
+type CreateCompetitionRequest struct {
+	Name        string                  `json:"name" binding:"required,min=3,max=60"`
+	Description string                  `json:"description"`
+	Rules       models.CompetitionRules `json:"rules"`
+	StartsAt    time.Time               `json:"startsAt"`
+	EndsAt      time.Time               `json:"endsAt"`
+}
+
+func (h *CompetitionHandler) CreateCompetition(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateCompetitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	competition := &models.Competition{
+		Name:        req.Name,
+		Description: req.Description,
+		OrganizerID: userID.(string),
+		TenantID:    c.GetString("tenantID"),
+		Rules:       req.Rules,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+	}
+	if err := h.competitionService.CreateCompetition(c.Request.Context(), competition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Competition created",
+		"competition": competition,
+	})
+}
+
+func (h *CompetitionHandler) ListCompetitions(c *gin.Context) {
+	competitions, err := h.competitionService.ListCompetitions(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch competitions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"competitions": competitions})
+}
+
+func (h *CompetitionHandler) GetCompetition(c *gin.Context) {
+	competition, err := h.competitionService.GetCompetition(c.Request.Context(), c.Param("id"), c.GetString("tenantID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"competition": competition})
+}
+
+// UpdateRules lets the organizer change the competition rule set
+func (h *CompetitionHandler) UpdateRules(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var rules models.CompetitionRules
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	competition, err := h.competitionService.UpdateRules(c.Request.Context(), c.Param("id"), userID.(string), c.GetString("tenantID"), rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Competition rules updated",
+		"competition": competition,
+	})
+}
+
+func (h *CompetitionHandler) JoinCompetition(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entry, err := h.competitionService.Join(c.Request.Context(), c.Param("id"), userID.(string), c.GetString("tenantID"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Joined competition",
+		"entry":   entry,
+	})
+}
+
+func (h *CompetitionHandler) GetCompetitionPortfolio(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entry, positions, err := h.competitionService.GetPortfolio(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	jsonLocal(c, http.StatusOK, gin.H{
+		"portfolio":   positions,
+		"cashBalance": entry.CashBalance,
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ConnectionHandler struct {
+	hub *services.WebSocketHub
+}
+
+func NewConnectionHandler(hub *services.WebSocketHub) *ConnectionHandler {
+	return &ConnectionHandler{hub: hub}
+}
+
+type DisconnectRequest struct {
+	Reason string `json:"reason"` // Optional, shown to the client
+}
+
+// ListConnections lists connected WebSocket clients. Tenant admins only see
+// sockets authenticated in their tenant.
+func (h *ConnectionHandler) ListConnections(c *gin.Context) {
+	connections := h.hub.Connections()
+	if tenantID := c.GetString("tenantID"); tenantID != "" {
+		scoped := connections[:0]
+		for _, conn := range connections {
+			if conn.TenantID == tenantID {
+				scoped = append(scoped, conn)
+			}
+		}
+		connections = scoped
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"connections": connections, "total": len(connections)})
+}
+
+// Disconnect closes a WebSocket client by its connection ID
+func (h *ConnectionHandler) Disconnect(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connection id"})
+		return
+	}
+	var req DisconnectRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	if tenantID := c.GetString("tenantID"); tenantID != "" && !h.inTenant(id, tenantID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		return
+	}
+	if !h.hub.Disconnect(id, req.Reason) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Connection closed", "id": id})
+}
+
+func (h *ConnectionHandler) inTenant(id uint64, tenantID string) bool {
+	for _, conn := range h.hub.Connections() {
+		if conn.ID == id {
+			return conn.TenantID == tenantID
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CorporateActionHandler struct {
+	corporateActionService *services.CorporateActionService
+}
+
+func NewCorporateActionHandler(corporateActionService *services.CorporateActionService) *CorporateActionHandler {
+	return &CorporateActionHandler{corporateActionService: corporateActionService}
+}
+
+type CreateCorporateActionRequest struct {
+	Symbol    string    `json:"symbol" binding:"required"`
+	Type      string    `json:"type" binding:"required"` // "dividend", "split" or "symbol_change"
+	Amount    float64   `json:"amount"`
+	Ratio     float64   `json:"ratio"`
+	NewSymbol string    `json:"newSymbol"`
+	ExDate    time.Time `json:"exDate"`
+}
+
+// GetCorporateActions lists corporate actions. Optional filters: symbol,
+// type, and from/to ex-dates as YYYY-MM-DD (to is inclusive).
+func (h *CorporateActionHandler) GetCorporateActions(c *gin.Context) {
+	filter := models.CorporateActionFilter{
+		Symbol: c.Query("symbol"),
+		Type:   c.Query("type"),
+	}
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+			return
+		}
+		filter.From = date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
+			return
+		}
+		filter.To = date.Add(24 * time.Hour)
+	}
+
+	actions, err := h.corporateActionService.ListActions(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch corporate actions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"actions": actions})
+}
+
+// CreateCorporateAction lets an admin schedule a dividend, split or symbol change
+func (h *CorporateActionHandler) CreateCorporateAction(c *gin.Context) {
+	var req CreateCorporateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	action := &models.CorporateAction{
+		Symbol:    req.Symbol,
+		Type:      req.Type,
+		Amount:    req.Amount,
+		Ratio:     req.Ratio,
+		NewSymbol: req.NewSymbol,
+		ExDate:    req.ExDate.UTC(),
+	}
+	if err := h.corporateActionService.CreateAction(c.Request.Context(), action); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Corporate action scheduled",
+		"action":  action,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CostBasisHandler struct {
+	costBasis *services.CostBasisService
+}
+
+func NewCostBasisHandler(costBasis *services.CostBasisService) *CostBasisHandler {
+	return &CostBasisHandler{costBasis: costBasis}
+}
+
+// CostBasisRequest picks how positions' average costs are worked out
+type CostBasisRequest struct {
+	Method string `json:"method" binding:"required"` // average or fifo
+}
+
+// SetMethod switches the user's cost basis method and restates their open
+// positions under it
+func (h *CostBasisHandler) SetMethod(c *gin.Context) {
+	var req CostBasisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	report, err := h.costBasis.SetMethod(c.Request.Context(), c.GetString("userID"), req.Method)
+	switch {
+	case errors.Is(err, services.ErrUnknownCostBasis):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrCostBasisUserMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change cost basis method: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"method": req.Method, "report": report})
+}
+
+// Recompute rebuilds cost bases from execution history, for one user with
+// ?userId= or for every position
+func (h *CostBasisHandler) Recompute(c *gin.Context) {
+	report, err := h.costBasis.Recompute(c.Request.Context(), c.Query("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recompute cost basis: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CustomSymbolHandler struct {
+	customSymbolService *services.CustomSymbolService
+	authService         *services.AuthService
+}
+
+func NewCustomSymbolHandler(customSymbolService *services.CustomSymbolService, authService *services.AuthService) *CustomSymbolHandler {
+	return &CustomSymbolHandler{customSymbolService: customSymbolService, authService: authService}
+}
+
+type CreateCustomSymbolRequest struct {
+	Symbol     string  `json:"symbol" binding:"required"`
+	Name       string  `json:"name"`
+	BasePrice  float64 `json:"basePrice" binding:"required"`
+	Volatility float64 `json:"volatility"` // Largest random move per tick, percent
+	Drift      float64 `json:"drift"`      // Constant move per tick, percent
+}
+
+// ListCustomSymbols lists the custom symbols in the request's tenant
+func (h *CustomSymbolHandler) ListCustomSymbols(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"symbols": h.customSymbolService.List(c.GetString("tenantID"))})
+}
+
+// CreateCustomSymbol creates a fictional ticker that the simulator starts
+// pricing on its next tick
+func (h *CustomSymbolHandler) CreateCustomSymbol(c *gin.Context) {
+	var req CreateCustomSymbolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	custom, err := h.customSymbolService.Create(c.Request.Context(), models.CustomSymbol{
+		Symbol:     req.Symbol,
+		Name:       req.Name,
+		BasePrice:  req.BasePrice,
+		Volatility: req.Volatility,
+		Drift:      req.Drift,
+		CreatedBy:  c.GetString("userID"),
+		TenantID:   c.GetString("tenantID"),
+	}, h.isAdmin(c))
+	switch {
+	case errors.Is(err, services.ErrSymbolExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrCustomSymbolLimit):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"symbol": custom})
+}
+
+// DeleteCustomSymbol removes a custom symbol nobody holds
+func (h *CustomSymbolHandler) DeleteCustomSymbol(c *gin.Context) {
+	err := h.customSymbolService.Delete(c.Request.Context(), c.Param("symbol"), c.GetString("userID"), h.isAdmin(c))
+	switch {
+	case errors.Is(err, services.ErrCustomSymbolNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrNotSymbolOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrCustomSymbolInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Custom symbol deleted"})
+}
+
+func (h *CustomSymbolHandler) isAdmin(c *gin.Context) bool {
+	user, err := h.authService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	return err == nil && user.Role == "admin"
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DataModeHandler struct {
+	dataModeService *services.DataModeService
+	authService     *services.AuthService
+}
+
+func NewDataModeHandler(dataModeService *services.DataModeService, authService *services.AuthService) *DataModeHandler {
+	return &DataModeHandler{dataModeService: dataModeService, authService: authService}
+}
+
+type SetDataModeRequest struct {
+	Mode string `json:"mode" binding:"required"` // "mock", "delayed" or "hybrid"
+}
+
+// SetUserDataModeRequest overrides a user's data mode; an empty mode
+// returns them to the deployment's
+type SetUserDataModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+func (h *DataModeHandler) GetDataMode(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"dataMode": h.dataModeService.GetSettings()})
+}
+
+// SetDataMode switches the deployment's data mode for every user without
+// an override
+func (h *DataModeHandler) SetDataMode(c *gin.Context) {
+	var req SetDataModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	settings, err := h.dataModeService.SetSettings(c.Request.Context(), models.DataModeSettings{
+		Mode:      req.Mode,
+		UpdatedBy: c.GetString("userID"),
+	})
+	if errors.Is(err, services.ErrUnknownDataMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dataMode": settings})
+}
+
+// SetUserDataMode puts a user on another data mode than the deployment's
+func (h *DataModeHandler) SetUserDataMode(c *gin.Context) {
+	var req SetUserDataModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	admin, err := h.authService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err = h.dataModeService.SetUserMode(c.Request.Context(), admin, c.Param("id"), req.Mode)
+	switch {
+	case errors.Is(err, services.ErrUnknownDataMode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrDataModeUserMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Data mode updated", "dataMode": h.dataModeService.ModeFor(c.Param("id"))})
+}
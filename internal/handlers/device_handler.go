@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeviceHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewDeviceHandler(notificationService *services.NotificationService) *DeviceHandler {
+	return &DeviceHandler{notificationService: notificationService}
+}
+
+type RegisterDeviceRequest struct {
+	Platform    string          `json:"platform" binding:"required"` // "android" or "ios"
+	Token       string          `json:"token" binding:"required"`    // FCM registration token or APNs device token
+	Name        string          `json:"name"`
+	Preferences map[string]bool `json:"preferences"` // Notification type -> wanted
+}
+
+type DevicePreferencesRequest struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
+
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	devices, err := h.notificationService.ListDevices(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list devices: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// RegisterDevice adds the caller's device for push notifications. Apps call
+// it on every launch; registering a known token updates it.
+func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	device, err := h.notificationService.RegisterDevice(c.Request.Context(), c.GetString("userID"), models.Device{
+		Platform:    req.Platform,
+		Token:       req.Token,
+		Name:        req.Name,
+		Preferences: req.Preferences,
+	})
+	if err != nil {
+		deviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"device": device})
+}
+
+func (h *DeviceHandler) UpdatePreferences(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	var req DevicePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	device, err := h.notificationService.UpdatePreferences(c.Request.Context(), c.GetString("userID"), id, req.Preferences)
+	if err != nil {
+		deviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"device": device})
+}
+
+func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	if err := h.notificationService.DeleteDevice(c.Request.Context(), c.GetString("userID"), id); err != nil {
+		deviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Device removed"})
+}
+
+func deviceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDeviceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDeviceLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsHandler serves an OpenAPI description of the versioned API, built from
+// the routes registered on the router, and a Swagger UI page for it
+type DocsHandler struct {
+	router *gin.Engine
+	once   sync.Once
+	spec   gin.H
+}
+
+func NewDocsHandler(router *gin.Engine) *DocsHandler {
+	return &DocsHandler{router: router}
+}
+
+var (
+	pathParam   = regexp.MustCompile(`:(\w+)`)
+	handlerName = regexp.MustCompile(`\.\(\*(\w+)Handler\)\.(\w+)`)
+	nonWord     = regexp.MustCompile(`\W+`)
+)
+
+// OpenAPI returns the OpenAPI 3 document. Routes are read on first use, after
+// all of them have been registered.
+func (h *DocsHandler) OpenAPI(c *gin.Context) {
+	h.once.Do(func() { h.spec = h.buildSpec() })
+	c.JSON(http.StatusOK, h.spec)
+}
+
+func (h *DocsHandler) buildSpec() gin.H {
+	routes := h.router.Routes()
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+
+	paths := gin.H{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/v1/") && !strings.HasPrefix(route.Path, "/api/v2/") {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+
+		tag, summary := "api", route.Handler
+		if m := handlerName.FindStringSubmatch(route.Handler); m != nil {
+			tag, summary = strings.ToLower(m[1]), m[2]
+		}
+		operation := gin.H{
+			"tags":        []string{tag},
+			"summary":     summary,
+			"operationId": strings.ToLower(route.Method) + nonWord.ReplaceAllString(route.Path, "_"),
+			"responses": gin.H{
+				"200": gin.H{"description": "OK"},
+				"400": gin.H{"$ref": "#/components/responses/Error"},
+				"401": gin.H{"$ref": "#/components/responses/Error"},
+			},
+		}
+		if params := pathParam.FindAllStringSubmatch(route.Path, -1); params != nil {
+			var parameters []gin.H
+			for _, p := range params {
+				parameters = append(parameters, gin.H{
+					"name":     p[1],
+					"in":       "path",
+					"required": true,
+					"schema":   gin.H{"type": "string"},
+				})
+			}
+			operation["parameters"] = parameters
+		}
+		if route.Method == http.MethodPost || route.Method == http.MethodPut {
+			operation["requestBody"] = gin.H{
+				"content": gin.H{"application/json": gin.H{"schema": gin.H{"type": "object"}}},
+			}
+		}
+
+		item, _ := paths[path].(gin.H)
+		if item == nil {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "Trading Simulator API",
+			"version":     "2.0.0",
+			"description": "v1 keeps the original request and response shapes. v2 returns errors as {\"error\": {\"code\", \"message\", \"status\"}}. Unversioned /api paths are v1.",
+		},
+		"paths": paths,
+		// Most routes need a bearer token; public ones ignore it
+		"security": []gin.H{{"bearerAuth": []string{}}, {}},
+		"components": gin.H{
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+			"responses": gin.H{
+				"Error": gin.H{"description": "Error; the shape depends on the API version"},
+			},
+		},
+	}
+}
+
+// SwaggerUI serves a Swagger UI page for the OpenAPI document
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerPage))
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Trading Simulator API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: "/api/docs/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ETFHandler struct {
+	etfService *services.ETFService
+}
+
+func NewETFHandler(etfService *services.ETFService) *ETFHandler {
+	return &ETFHandler{etfService: etfService}
+}
+
+type ETFHoldingRequest struct {
+	Symbol string  `json:"symbol" binding:"required"`
+	Weight float64 `json:"weight" binding:"required,gt=0"`
+}
+
+type CreateETFRequest struct {
+	Symbol    string              `json:"symbol" binding:"required"`
+	Name      string              `json:"name"`
+	BasePrice float64             `json:"basePrice"` // Optional: starting price, 100 by default
+	Holdings  []ETFHoldingRequest `json:"holdings" binding:"required,min=2,dive"`
+}
+
+// ListETFs lists the synthetic ETFs and their holdings
+func (h *ETFHandler) ListETFs(c *gin.Context) {
+	jsonLocal(c, http.StatusOK, gin.H{"etfs": h.etfService.List()})
+}
+
+// GetHoldings returns an ETF's constituents with their current prices and
+// weights
+func (h *ETFHandler) GetHoldings(c *gin.Context) {
+	etf, err := h.etfService.Holdings(c.Param("symbol"))
+	if errors.Is(err, services.ErrETFNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   etf.Symbol,
+		"name":     etf.Name,
+		"holdings": etf.Holdings,
+	})
+}
+
+// CreateETF defines a new ETF, sized to the base price at the constituents'
+// current prices
+func (h *ETFHandler) CreateETF(c *gin.Context) {
+	var req CreateETFRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	etf := models.ETF{
+		Symbol:    req.Symbol,
+		Name:      req.Name,
+		BasePrice: req.BasePrice,
+		CreatedBy: c.GetString("userID"),
+	}
+	for _, holding := range req.Holdings {
+		etf.Holdings = append(etf.Holdings, models.ETFHolding{Symbol: holding.Symbol, Weight: holding.Weight})
+	}
+
+	created, err := h.etfService.Create(c.Request.Context(), etf)
+	switch {
+	case errors.Is(err, services.ErrSymbolExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusCreated, gin.H{"etf": created})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type FeatureFlagHandler struct {
+	flagService *services.FeatureFlagService
+}
+
+func NewFeatureFlagHandler(flagService *services.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{flagService: flagService}
+}
+
+type SetFeatureFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// Optional: percentage of users who get the feature, defaults to 100
+	RolloutPercent *int   `json:"rolloutPercent"`
+	Description    string `json:"description"`
+}
+
+// Require blocks the route with 404 when the feature is off for the caller
+func (h *FeatureFlagHandler) Require(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.flagService.IsEnabledFor(name, c.GetString("userID")) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "This feature is not available"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetFeatures returns which features are on for the current user
+func (h *FeatureFlagHandler) GetFeatures(c *gin.Context) {
+	userID := c.GetString("userID")
+	features := gin.H{}
+	for _, flag := range h.flagService.ListFlags() {
+		features[flag.Name] = h.flagService.IsEnabledFor(flag.Name, userID)
+	}
+	c.JSON(http.StatusOK, gin.H{"features": features})
+}
+
+func (h *FeatureFlagHandler) ListFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"flags": h.flagService.ListFlags()})
+}
+
+func (h *FeatureFlagHandler) SetFlag(c *gin.Context) {
+	var req SetFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	flag := models.FeatureFlag{
+		Name:           c.Param("name"),
+		Enabled:        *req.Enabled,
+		RolloutPercent: 100,
+		Description:    req.Description,
+		UpdatedBy:      c.GetString("userID"),
+	}
+	if req.RolloutPercent != nil {
+		flag.RolloutPercent = *req.RolloutPercent
+	}
+
+	saved, err := h.flagService.SetFlag(c.Request.Context(), flag)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flag": saved})
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type FXHandler struct {
+	fx *services.FXService
+}
+
+func NewFXHandler(fx *services.FXService) *FXHandler {
+	return &FXHandler{fx: fx}
+}
+
+// GetRates lists the rate from dollars to every currency balances can be
+// shown in
+func (h *FXHandler) GetRates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"base": services.BaseCurrency, "rates": h.fx.Rates()})
+}
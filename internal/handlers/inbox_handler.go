@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type InboxHandler struct {
+	inbox *services.InboxService
+}
+
+func NewInboxHandler(inbox *services.InboxService) *InboxHandler {
+	return &InboxHandler{inbox: inbox}
+}
+
+// GetInbox lists the messages kept for the user while they were offline,
+// newest first, with the unread count. ?limit= caps it (default 50).
+func (h *InboxHandler) GetInbox(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	messages, unread, err := h.inbox.List(c.Request.Context(), c.GetString("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbox: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"messages": messages, "unread": unread})
+}
+
+// MarkRead marks every message in the user's inbox read
+func (h *InboxHandler) MarkRead(c *gin.Context) {
+	marked, err := h.inbox.MarkRead(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark inbox read: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/secrets"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IntegrationHandler struct {
+	integrationService *services.IntegrationService
+}
+
+func NewIntegrationHandler(integrationService *services.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{integrationService: integrationService}
+}
+
+type SaveWebhookRequest struct {
+	Name      string            `json:"name" binding:"required"`
+	Kind      string            `json:"kind" binding:"required"` // "slack", "discord" or "generic"
+	URL       string            `json:"url" binding:"required"`
+	Events    []string          `json:"events"`    // Empty delivers every event
+	Templates map[string]string `json:"templates"` // Event -> message template
+	Enabled   *bool             `json:"enabled"`   // Defaults to true
+	Secret    string            `json:"secret"`    // Optional: sign bodies in X-Webhook-Signature; empty on update keeps the current one
+}
+
+func (h *IntegrationHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.integrationService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *IntegrationHandler) CreateWebhook(c *gin.Context) {
+	h.saveWebhook(c, primitive.NilObjectID, http.StatusCreated)
+}
+
+func (h *IntegrationHandler) UpdateWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	h.saveWebhook(c, id, http.StatusOK)
+}
+
+func (h *IntegrationHandler) saveWebhook(c *gin.Context, id primitive.ObjectID, status int) {
+	var req SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	webhook, err := h.integrationService.SaveWebhook(c.Request.Context(), models.Webhook{
+		ID:        id,
+		Name:      req.Name,
+		Kind:      req.Kind,
+		URL:       req.URL,
+		Events:    req.Events,
+		Templates: req.Templates,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Secret:    req.Secret,
+		CreatedBy: c.GetString("userID"),
+	})
+	if err != nil {
+		integrationError(c, err)
+		return
+	}
+	c.JSON(status, gin.H{"webhook": webhook})
+}
+
+func (h *IntegrationHandler) DeleteWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	if err := h.integrationService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		integrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// TestWebhook queues a test message; its outcome shows up in the deliveries
+func (h *IntegrationHandler) TestWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	delivery, err := h.integrationService.TestWebhook(c.Request.Context(), id)
+	if err != nil {
+		integrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
+
+func (h *IntegrationHandler) ListDeliveries(c *gin.Context) {
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	deliveries, err := h.integrationService.Deliveries(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deliveries: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RotateSecrets re-encrypts stored secrets with the active key, after a new
+// key was put first in SECRETS_KEYS
+func (h *IntegrationHandler) RotateSecrets(c *gin.Context) {
+	rotated, err := h.integrationService.RotateSecrets(c.Request.Context())
+	if errors.Is(err, secrets.ErrNoKeys) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate secrets: " + err.Error(), "webhooks": rotated})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": rotated})
+}
+
+func integrationError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type InvariantHandler struct {
+	invariants *services.InvariantService
+}
+
+func NewInvariantHandler(invariants *services.InvariantService) *InvariantHandler {
+	return &InvariantHandler{invariants: invariants}
+}
+
+// Check reports accounts and positions that do not add up to their
+// history, for one user with ?userId= or for every user
+func (h *InvariantHandler) Check(c *gin.Context) {
+	report, err := h.invariants.Check(c.Request.Context(), c.Query("userId"))
+	switch {
+	case errors.Is(err, services.ErrCostBasisUserMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check accounts: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type JournalHandler struct {
+	journal *services.JournalService
+}
+
+func NewJournalHandler(journal *services.JournalService) *JournalHandler {
+	return &JournalHandler{journal: journal}
+}
+
+// GetJournal lists the double-entry journal of the user's main account, or
+// of a competition account with ?competitionId=, newest first. ?limit= caps
+// it (default 100).
+func (h *JournalHandler) GetJournal(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	entries, err := h.journal.List(c.Request.Context(), c.GetString("userID"), c.Query("competitionId"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch journal: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"entries": entries})
+}
+
+// GetBalances returns the balance of each ledger account the user's
+// journal posts to, with the cash stored on the account
+func (h *JournalHandler) GetBalances(c *gin.Context) {
+	balances, err := h.journal.Balances(c.Request.Context(), c.GetString("userID"), c.Query("competitionId"))
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balances: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, balances)
+}
+
+// Materialize rebuilds stored cash balances from the journal, for one user
+// with ?userId= or for every account
+func (h *JournalHandler) Materialize(c *gin.Context) {
+	report, err := h.journal.Materialize(c.Request.Context(), c.Query("userId"))
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to materialize balances: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonLocal writes the response with every timestamp converted to the zone
+// stored by Preferences. Times are stored in UTC; the converted values keep
+// their explicit offset in the JSON.
+func jsonLocal(c *gin.Context, status int, body interface{}) {
+	loc, ok := c.Value("location").(*time.Location)
+	if !ok || loc == time.UTC || body == nil {
+		c.JSON(status, body)
+		return
+	}
+	c.JSON(status, inLocation(reflect.ValueOf(body), loc).Interface())
+}
+
+// inLocation returns a copy of v with its times converted. The original is
+// left alone because it may be shared, e.g. a cached result.
+func inLocation(v reflect.Value, loc *time.Location) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			t := v.Interface().(time.Time)
+			if t.IsZero() {
+				return v
+			}
+			return reflect.ValueOf(t.In(loc))
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				out.Field(i).Set(inLocation(v.Field(i), loc))
+			}
+		}
+		return out
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(inLocation(v.Elem(), loc))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(inLocation(v.Elem(), loc))
+		return out
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(inLocation(v.Index(i), loc))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), inLocation(iter.Value(), loc))
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService *services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenanceService: maintenanceService}
+}
+
+type SetMaintenanceRequest struct {
+	Enabled *bool     `json:"enabled" binding:"required"`
+	Message string    `json:"message"`
+	EndsAt  time.Time `json:"endsAt"` // Optional scheduled end shown to users
+}
+
+// BlockDuringMaintenance rejects trading requests with 503 while trading is frozen
+func (h *MaintenanceHandler) BlockDuringMaintenance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := h.maintenanceService.GetState()
+		if !state.Enabled {
+			c.Next()
+			return
+		}
+
+		response := gin.H{"error": "Trading is paused for maintenance", "message": state.Message}
+		if !state.EndsAt.IsZero() {
+			response["maintenanceEndsAt"] = state.EndsAt
+			if wait := time.Until(state.EndsAt); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			}
+		}
+		c.JSON(http.StatusServiceUnavailable, response)
+		c.Abort()
+	}
+}
+
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"maintenance": h.maintenanceService.GetState()})
+}
+
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	state, err := h.maintenanceService.SetState(c.Request.Context(), models.MaintenanceState{
+		Enabled:   *req.Enabled,
+		Message:   req.Message,
+		EndsAt:    req.EndsAt.UTC(),
+		UpdatedBy: c.GetString("userID"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance mode: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"maintenance": state})
+}
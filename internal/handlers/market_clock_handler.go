@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MarketClockHandler struct {
+	clock *services.MarketClock
+}
+
+func NewMarketClockHandler(clock *services.MarketClock) *MarketClockHandler {
+	return &MarketClockHandler{clock: clock}
+}
+
+// HaltRequest halts trading in a symbol
+type HaltRequest struct {
+	Reason   string    `json:"reason" binding:"required"`
+	ResumeAt time.Time `json:"resumeAt"` // Optional: lift the halt automatically at this time
+}
+
+// GetClock returns the trading session, when it next opens or closes and
+// the symbols halted
+func (h *MarketClockHandler) GetClock(c *gin.Context) {
+	jsonLocal(c, http.StatusOK, gin.H{"clock": h.clock.Status(time.Now())})
+}
+
+// HaltSymbol stops trading in a symbol. Orders for it are rejected, or
+// queued until resumeAt when the market closed policy is queue.
+func (h *MarketClockHandler) HaltSymbol(c *gin.Context) {
+	var req HaltRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	halt, err := h.clock.Halt(c.Request.Context(), models.TradingHalt{
+		Symbol:   c.Param("symbol"),
+		Reason:   req.Reason,
+		ResumeAt: req.ResumeAt,
+		HaltedBy: c.GetString("userID"),
+	})
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"halt": halt})
+}
+
+// ResumeSymbol lifts a symbol's halt
+func (h *MarketClockHandler) ResumeSymbol(c *gin.Context) {
+	err := h.clock.Resume(c.Request.Context(), c.Param("symbol"), c.GetString("userID"))
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume trading: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trading resumed"})
+}
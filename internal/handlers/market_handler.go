@@ -1,27 +1,207 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
+
+	"trading-simulator/internal/models"
 	"trading-simulator/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 type MarketHandler struct {
-	marketService *services.MarketDataService
+	marketService       *services.MarketDataService
+	symbolService       *services.SymbolService
+	screenerService     *services.ScreenerService
+	fundamentalsService *services.FundamentalsService
+	candleService       *services.CandleService
+	symbolStatsService  *services.SymbolStatsService
+	maintenanceService  *services.MaintenanceService
+	simulationService   *services.SimulationService
+	hub                 *services.WebSocketHub
+	dataModes           *services.DataModeService
 }
 
-func NewMarketHandler(marketService *services.MarketDataService) *MarketHandler {
-	return &MarketHandler{marketService: marketService}
+func NewMarketHandler(marketService *services.MarketDataService, symbolService *services.SymbolService, screenerService *services.ScreenerService, fundamentalsService *services.FundamentalsService, candleService *services.CandleService, symbolStatsService *services.SymbolStatsService, maintenanceService *services.MaintenanceService, simulationService *services.SimulationService, hub *services.WebSocketHub, dataModes *services.DataModeService) *MarketHandler {
+	return &MarketHandler{
+		marketService:       marketService,
+		symbolService:       symbolService,
+		screenerService:     screenerService,
+		fundamentalsService: fundamentalsService,
+		candleService:       candleService,
+		symbolStatsService:  symbolStatsService,
+		maintenanceService:  maintenanceService,
+		simulationService:   simulationService,
+		hub:                 hub,
+		dataModes:           dataModes,
+	}
 }
 
+// GetStockPrice quotes a symbol in the caller's data mode, or the
+// deployment's for anonymous requests
 func (h *MarketHandler) GetStockPrice(c *gin.Context) {
 	symbol := c.Param("symbol")
-	
-	stock, err := h.marketService.GetStockPrice(symbol)
+
+	mode := h.dataModes.ModeFor(c.GetString("userID"))
+	stock, err := h.marketService.GetStockPriceIn(c.Request.Context(), symbol, mode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	stock.Stats = h.symbolStatsService.GetStats(stock.Symbol)
 
 	c.JSON(http.StatusOK, stock)
-}
\ No newline at end of file
+}
+
+// GetStats returns rolling volume, range and volatility statistics for a
+// symbol, tracked in simulated days
+func (h *MarketHandler) GetStats(c *gin.Context) {
+	stats := h.symbolStatsService.GetStats(c.Param("symbol"))
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No statistics for " + c.Param("symbol") + " yet"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFundamentals returns real company metrics for a symbol from the fundamentals provider
+func (h *MarketHandler) GetFundamentals(c *gin.Context) {
+	fundamentals, err := h.fundamentalsService.GetFundamentals(c.Request.Context(), c.Param("symbol"))
+	if errors.Is(err, services.ErrUnknownSymbol) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Fundamentals for %s failed: %v", c.Param("symbol"), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Fundamentals unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fundamentals)
+}
+
+// GetCandles returns daily candles for a symbol, oldest first. The limit
+// query parameter caps how many of the most recent days are returned.
+func (h *MarketHandler) GetCandles(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "90"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	candles, err := h.candleService.GetCandles(c.Request.Context(), c.Param("symbol"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch candles: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"candles": candles})
+}
+
+// GetSnapshot returns the latest price, change and volume of every symbol
+// in the request's universe in one payload
+func (h *MarketHandler) GetSnapshot(c *gin.Context) {
+	snapshot := models.MarketSnapshot{
+		Status:        "open",
+		Symbols:       []models.SymbolSnapshot{},
+		OnlineTraders: h.hub.OnlineTraders(c.GetString("tenantID")),
+		GeneratedAt:   time.Now().UTC(),
+	}
+	switch {
+	case h.maintenanceService.Active():
+		snapshot.Status = "maintenance"
+	case h.simulationService.GetSettings().Paused:
+		snapshot.Status = "paused"
+	}
+
+	tenant := requestTenant(c)
+	for _, info := range h.symbolService.ListSymbols() {
+		if tenant != nil && !tenant.AllowsSymbol(info.Symbol) {
+			continue
+		}
+		entry := models.SymbolSnapshot{
+			Symbol:     info.Symbol,
+			Name:       info.Name,
+			AssetClass: info.AssetClass,
+			Status:     snapshot.Status,
+		}
+		if tick, ok := h.marketService.GetLatestTick(info.Symbol); ok {
+			entry.Price = tick.Price
+			entry.Change = tick.Change
+			entry.ChangePercent = tick.ChangePercent
+			entry.Volume = tick.Volume
+			entry.UpdatedAt = tick.Timestamp
+		} else {
+			entry.Status = "no_data"
+			entry.Price, _ = h.marketService.GetLastPrice(info.Symbol)
+		}
+		snapshot.Symbols = append(snapshot.Symbols, entry)
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GetSymbols lists tradable symbols with their price precision and lot size
+// rules, limited to the tenant's symbol universe when tenancy is on
+func (h *MarketHandler) GetSymbols(c *gin.Context) {
+	symbols := h.symbolService.ListSymbols()
+	if tenant := requestTenant(c); tenant != nil {
+		allowed := symbols[:0]
+		for _, info := range symbols {
+			if tenant.AllowsSymbol(info.Symbol) {
+				allowed = append(allowed, info)
+			}
+		}
+		symbols = allowed
+	}
+	c.JSON(http.StatusOK, gin.H{"symbols": symbols})
+}
+
+// GetScreener filters symbols on synthetic fundamentals and analyst ratings.
+// Supports sector, assetClass, rating, minPE, maxPE, minMarketCap, maxMarketCap,
+// sort, order (asc|desc), page and pageSize query parameters.
+func (h *MarketHandler) GetScreener(c *gin.Context) {
+	filter := models.ScreenerFilter{
+		Sector:        c.Query("sector"),
+		AssetClass:    c.Query("assetClass"),
+		AnalystRating: c.Query("rating"),
+		SortBy:        c.DefaultQuery("sort", "symbol"),
+		Descending:    c.Query("order") == "desc",
+	}
+
+	var err error
+	floatParams := map[string]*float64{
+		"minPE":        &filter.MinPE,
+		"maxPE":        &filter.MaxPE,
+		"minMarketCap": &filter.MinMarketCap,
+		"maxMarketCap": &filter.MaxMarketCap,
+	}
+	for name, target := range floatParams {
+		if value := c.Query(name); value != "" {
+			if *target, err = strconv.ParseFloat(value, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+				return
+			}
+		}
+	}
+
+	filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || filter.Page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+	filter.PageSize, err = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || filter.PageSize < 1 || filter.PageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be between 1 and 100"})
+		return
+	}
+
+	results, total := h.screenerService.Screen(filter)
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"total":    total,
+		"page":     filter.Page,
+		"pageSize": filter.PageSize,
+	})
+}
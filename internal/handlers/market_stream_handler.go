@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MarketStreamHandler struct {
+	stream *services.MarketStream // Nil when no provider is configured
+}
+
+func NewMarketStreamHandler(stream *services.MarketStream) *MarketStreamHandler {
+	return &MarketStreamHandler{stream: stream}
+}
+
+// GetStatus reports the connection to the real-time market data provider
+func (h *MarketStreamHandler) GetStatus(c *gin.Context) {
+	if h.stream == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"enabled": true, "stream": h.stream.Status()})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	performanceService *services.PerformanceService
+}
+
+func NewMetricsHandler(performanceService *services.PerformanceService) *MetricsHandler {
+	return &MetricsHandler{performanceService: performanceService}
+}
+
+// GetMetrics returns runtime statistics and recent load test reports
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	jsonLocal(c, http.StatusOK, h.performanceService.GetMetrics())
+}
+
+// SubmitLoadTest records a report posted by cmd/loadgen
+func (h *MetricsHandler) SubmitLoadTest(c *gin.Context) {
+	var report models.LoadTestReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusCreated, gin.H{"loadTest": h.performanceService.RecordLoadTest(report)})
+}
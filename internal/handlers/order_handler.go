@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"trading-simulator/internal/models"
@@ -10,20 +12,36 @@ import (
 )
 
 type OrderHandler struct {
-	orderService *services.OrderService
+	orderService   *services.OrderService
+	engine         *services.OrderEngine
+	advanced       *services.AdvancedOrderService
+	accountService *services.AccountService
+	fx             *services.FXService
 }
 
-func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
-	return &OrderHandler{orderService: orderService}
+func NewOrderHandler(orderService *services.OrderService, engine *services.OrderEngine, advanced *services.AdvancedOrderService, accountService *services.AccountService, fx *services.FXService) *OrderHandler {
+	return &OrderHandler{orderService: orderService, engine: engine, advanced: advanced, accountService: accountService, fx: fx}
 }
 
 // PlaceOrderRequest - for regular market/limit orders
 type PlaceOrderRequest struct {
 	Symbol    string  `json:"symbol" binding:"required"`
-	Type      string  `json:"type" binding:"required"`      // "buy" or "sell"
-	OrderType string  `json:"orderType" binding:"required"` // "market" or "limit"
-	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	Type      string  `json:"type" binding:"required"`           // "buy" or "sell"
+	OrderType string  `json:"orderType" binding:"required"`      // "market" or "limit"
+	Quantity  float64 `json:"quantity" binding:"omitempty,gt=0"` // Shares, to 4 decimals
+	Notional  float64 `json:"notional" binding:"omitempty,gt=0"` // Dollar amount, instead of quantity
 	Price     float64 `json:"price" binding:"required,min=0.01"`
+	// Optional: place the order in a competition account instead of the main account
+	CompetitionID string `json:"competitionId"`
+	// Optional: confirm an order identical to one placed moments ago
+	AllowDuplicate bool `json:"allowDuplicate"`
+	// Optional: hold the order as scheduled and fill it once at this time
+	ActivateAt time.Time `json:"activateAt,omitempty"`
+	// Optional: hold the order until another symbol's price condition holds,
+	// e.g. {"expression":"SPY > 450"}
+	Condition *models.OrderCondition `json:"condition,omitempty"`
+	// Optional: allow the order to fill pre-market and after-hours
+	ExtendedHours bool `json:"extendedHours"`
 }
 
 func (h *OrderHandler) PlaceOrder(c *gin.Context) {
@@ -40,43 +58,109 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
-	// Validate order type
-	if req.OrderType != "market" && req.OrderType != "limit" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order type. Must be 'market' or 'limit'"})
-		return
-	}
-
-	// Validate order type (buy/sell)
-	if req.Type != "buy" && req.Type != "sell" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order type. Must be 'buy' or 'sell'"})
+	if (req.Quantity > 0) == (req.Notional > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: exactly one of quantity or notional is required"})
 		return
 	}
 
 	// Create order object
 	order := &models.Order{
-		UserID:    userID.(string),
-		Symbol:    req.Symbol,
-		Type:      req.Type,
-		OrderType: req.OrderType,
-		Quantity:  req.Quantity,
-		Price:     req.Price,
-		Status:    "filled", // Immediate execution
-		Timestamp: time.Now(),
+		UserID:         userID.(string),
+		Symbol:         req.Symbol,
+		Type:           req.Type,
+		OrderType:      req.OrderType,
+		Quantity:       req.Quantity,
+		Notional:       req.Notional,
+		Price:          req.Price,
+		CompetitionID:  req.CompetitionID,
+		TenantID:       c.GetString("tenantID"),
+		AllowDuplicate: req.AllowDuplicate,
+		ActivateAt:     req.ActivateAt,
+		Condition:      req.Condition,
+		ExtendedHours:  req.ExtendedHours,
+		Status:         "filled", // Immediate execution
+		Timestamp:      time.Now().UTC(),
+	}
+
+	h.submit(c, order, "Order placed successfully")
+}
+
+// submit places a new order: scheduled and conditional orders rest with the
+// advanced orders, and the rest fill now or, while the market is closed,
+// are rejected or queued for the open. placed is the message of an order
+// that filled.
+func (h *OrderHandler) submit(c *gin.Context, order *models.Order, placed string) {
+	// While the market is closed the policy may queue the order for the open
+	queued := false
+	if order.ActivateAt.IsZero() && order.Condition == nil {
+		order.ActivateAt, queued = h.engine.QueueUntil(order)
+	}
+
+	// Scheduled and conditional orders rest with the advanced orders
+	if !order.ActivateAt.IsZero() || order.Condition != nil {
+		if err := h.advanced.CreateStopOrder(c.Request.Context(), order, ""); err != nil {
+			respondError(c, orderErrorStatus(err), err)
+			return
+		}
+		message := "Order scheduled"
+		if queued {
+			message = "Market closed; order queued for the open"
+		} else if order.ActivateAt.IsZero() {
+			message = "Conditional order placed"
+		}
+		response := gin.H{
+			"message": message,
+			"order":   order,
+		}
+		if queued {
+			response["queued"] = true
+		}
+		jsonLocal(c, http.StatusOK, response)
+		return
 	}
 
 	// Execute the order
-	err := h.orderService.PlaceOrder(order)
+	err := h.engine.PlaceOrder(c.Request.Context(), order)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, orderErrorStatus(err), err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Order placed successfully",
+		"message": placed,
 		"order":   order,
 	})
 }
 
+// ClosePositionRequest closes part or all of a position at market
+type ClosePositionRequest struct {
+	Percent  float64 `json:"percent" binding:"omitempty,gt=0,lte=100"` // e.g. 50 for half
+	Quantity float64 `json:"quantity" binding:"omitempty,gt=0"`        // Shares, instead of a percent
+	// Optional: close a position of a competition account instead of the main account
+	CompetitionID string `json:"competitionId"`
+	// Optional: confirm a close identical to one placed moments ago
+	AllowDuplicate bool `json:"allowDuplicate"`
+}
+
+// ClosePosition sells a percent or a number of shares of a position at
+// market, or buys them back when the position is short
+func (h *OrderHandler) ClosePosition(c *gin.Context) {
+	var req ClosePositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	order, err := h.engine.CloseOrder(c.Request.Context(), c.GetString("userID"), c.GetString("tenantID"),
+		req.CompetitionID, c.Param("symbol"), req.Percent, req.Quantity)
+	if err != nil {
+		respondError(c, orderErrorStatus(err), err)
+		return
+	}
+	order.AllowDuplicate = req.AllowDuplicate
+	h.submit(c, order, "Position closed")
+}
+
 func (h *OrderHandler) GetPortfolio(c *gin.Context) {
 	// Get authenticated user ID from JWT
 	userID, exists := c.Get("userID")
@@ -85,21 +169,63 @@ func (h *OrderHandler) GetPortfolio(c *gin.Context) {
 		return
 	}
 
-	portfolio, err := h.orderService.GetUserPortfolio(userID.(string))
+	portfolio, err := h.orderService.GetUserPortfolio(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch portfolio: " + err.Error()})
 		return
 	}
 
-	cashBalance := h.orderService.GetCashBalance(userID.(string))
+	summary, err := h.accountService.Summary(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account summary: " + err.Error()})
+		return
+	}
+
+	changes := make(map[string]models.PositionDayChange, len(summary.PositionChanges))
+	for _, change := range summary.PositionChanges {
+		changes[change.Symbol] = change
+	}
+	for i := range portfolio {
+		portfolio[i].DayChange = changes[portfolio[i].Symbol].DayChange
+		portfolio[i].DayChangePercent = changes[portfolio[i].Symbol].DayChangePercent
+	}
+	rate := h.fx.Rate(c.GetString("currency"))
+	services.ConvertPositions(portfolio, rate)
+	services.ConvertSummary(summary, rate)
 
-	c.JSON(http.StatusOK, gin.H{
-		"portfolio":    portfolio,
-		"cashBalance":  cashBalance,
-		"totalAssets":  cashBalance + h.orderService.GetTotalPortfolioValue(userID.(string)),
+	jsonLocal(c, http.StatusOK, gin.H{
+		"portfolio":            portfolio,
+		"cashBalance":          summary.CashBalance,
+		"reservedCash":         summary.ReservedCash,
+		"buyingPower":          summary.BuyingPower,
+		"totalAssets":          summary.Equity,
+		"dayProfitLoss":        summary.DayProfitLoss,
+		"dayProfitLossPercent": summary.DayProfitLossPercent,
+		"fx":                   rate,
 	})
 }
 
+// SetDRIPRequest turns dividend reinvestment on or off for a position
+type SetDRIPRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetDRIP toggles dividend reinvestment for a position in the main account
+func (h *OrderHandler) SetDRIP(c *gin.Context) {
+	var req SetDRIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	position, err := h.orderService.SetDRIP(c.Request.Context(), c.GetString("userID"), c.Param("symbol"), req.Enabled)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"position": position})
+}
+
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	// Get authenticated user ID from JWT
 	userID, exists := c.Get("userID")
@@ -108,11 +234,92 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		return
 	}
 
-	orders, err := h.orderService.GetUserOrders(userID.(string))
+	orders, err := h.orderService.GetUserOrders(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"orders": orders})
-}
\ No newline at end of file
+	jsonLocal(c, http.StatusOK, gin.H{"orders": orders})
+}
+
+// GetOrderStats summarizes the user's orders: counts by status, side, order
+// type and symbol, the traded value, and orders per ?bucket= (day, week or
+// month, default day) with the busiest days. ?from= and ?to= are
+// YYYY-MM-DD dates in the user's timezone (to is inclusive).
+func (h *OrderHandler) GetOrderStats(c *gin.Context) {
+	filter := models.OrderStatsFilter{Bucket: c.Query("bucket"), Location: time.UTC}
+	if loc, ok := c.Value("location").(*time.Location); ok {
+		filter.Location = loc
+	}
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, filter.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+			return
+		}
+		filter.From = date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, filter.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
+			return
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+
+	stats, err := h.orderService.GetOrderStats(c.Request.Context(), c.GetString("userID"), filter)
+	if errors.Is(err, services.ErrOrderStatsBucket) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute order stats: " + err.Error()})
+		return
+	}
+	services.ConvertOrderStats(stats, h.fx.Rate(c.GetString("currency")))
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// GetExecutions lists the user's fills, newest first. ?symbol= and ?orderId=
+// narrow the list; ?limit= caps it (default 100).
+func (h *OrderHandler) GetExecutions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	executions, err := h.orderService.GetExecutions(c.Request.Context(), userID.(string), c.Query("symbol"), c.Query("orderId"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch executions: " + err.Error()})
+		return
+	}
+
+	jsonLocal(c, http.StatusOK, gin.H{"executions": executions})
+}
+
+// GetMyActivity returns the user's position, open orders, fills and realized
+// P&L in one symbol, for the main account or ?competitionId=. ?limit= caps
+// the fills listed (default 100).
+func (h *OrderHandler) GetMyActivity(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	activity, err := h.orderService.MyActivity(c.Request.Context(), c.GetString("userID"), c.Query("competitionId"), c.Param("symbol"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"activity": activity})
+}
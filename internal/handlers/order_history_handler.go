@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type OrderHistoryHandler struct {
+	history *services.OrderHistoryService
+}
+
+func NewOrderHistoryHandler(history *services.OrderHistoryService) *OrderHistoryHandler {
+	return &OrderHistoryHandler{history: history}
+}
+
+// GetEvents lists the events of one of the user's orders, oldest first
+func (h *OrderHistoryHandler) GetEvents(c *gin.Context) {
+	h.events(c, c.GetString("userID"))
+}
+
+// GetAnyEvents lists the events of any user's order, for support
+func (h *OrderHistoryHandler) GetAnyEvents(c *gin.Context) {
+	h.events(c, "")
+}
+
+func (h *OrderHistoryHandler) events(c *gin.Context, userID string) {
+	events, err := h.history.Events(c.Request.Context(), c.Param("id"), userID)
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order events: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"events": events})
+}
+
+// GetAsOf returns one of the user's orders as it stood at ?at=, an RFC 3339
+// time
+func (h *OrderHistoryHandler) GetAsOf(c *gin.Context) {
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+		return
+	}
+
+	order, err := h.history.AsOf(c.Request.Context(), c.Param("id"), c.GetString("userID"), at)
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebuild order: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"order": order, "asOf": at})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Fastest playback speed, as a multiple of real time
+	maxPlaybackSpeed = 1000
+	// Longest wait between two replayed events, so quiet stretches do not
+	// stall the stream
+	maxPlaybackPause = 5 * time.Second
+)
+
+type PlaybackHandler struct {
+	playbackService *services.PlaybackService
+}
+
+func NewPlaybackHandler(playbackService *services.PlaybackService) *PlaybackHandler {
+	return &PlaybackHandler{playbackService: playbackService}
+}
+
+// StreamPlayback replays a symbol's recorded ticks and the fills made in a
+// past period as Server-Sent Events. from and to are RFC 3339 times; speed
+// (default 1) is a multiple of real time. Each event is named "tick" or
+// "fill", and a final "end" event carries the number of events sent.
+func (h *PlaybackHandler) StreamPlayback(c *gin.Context) {
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 time"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 time"})
+		return
+	}
+	speed, err := strconv.ParseFloat(c.DefaultQuery("speed", "1"), 64)
+	if err != nil || speed <= 0 || speed > maxPlaybackSpeed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "speed must be greater than 0 and at most 1000"})
+		return
+	}
+
+	events, err := h.playbackService.Events(c.Request.Context(), c.Param("symbol"), c.GetString("tenantID"), c.Query("competitionId"), from.UTC(), to.UTC())
+	if errors.Is(err, services.ErrPlaybackRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load playback: " + err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	sent := 0
+	c.Stream(func(w io.Writer) bool {
+		if sent == len(events) {
+			c.SSEvent("end", gin.H{"events": sent})
+			return false
+		}
+		event := events[sent]
+		if sent > 0 {
+			pause := time.Duration(float64(event.Time.Sub(events[sent-1].Time)) / speed)
+			select {
+			case <-time.After(min(pause, maxPlaybackPause)):
+			case <-c.Request.Context().Done():
+				return false
+			}
+		}
+		c.SSEvent(event.Type, event)
+		sent++
+		return true
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Preferences loads the user's timezone, language and currency so responses
+// can be shown in them. It must run after AuthMiddleware.
+func (h *AuthHandler) Preferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user, err := h.currentUser(c); err == nil {
+			c.Set("location", user.Location())
+			if user.Language != "" {
+				c.Set("language", user.Language)
+			}
+			c.Set("currency", user.Currency)
+		}
+		c.Next()
+	}
+}
+
+// language returns the user's preferred language, or the best match for the
+// Accept-Language header when they haven't chosen one
+func language(c *gin.Context) string {
+	if lang := c.GetString("language"); lang != "" {
+		return lang
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// respondError writes err in the request's language. Errors with a message
+// code also carry the code for programmatic handling.
+func respondError(c *gin.Context, status int, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	var msgErr *i18n.Error
+	if errors.As(err, &msgErr) {
+		response := gin.H{"error": msgErr.Translate(language(c)), "code": msgErr.Code}
+		var closed *services.MarketClosedError
+		if errors.As(err, &closed) && !closed.NextOpen.IsZero() {
+			response["nextOpen"] = closed.NextOpen
+		}
+		var conflict *services.UserConflictError
+		if errors.As(err, &conflict) {
+			response["field"] = conflict.Field
+		}
+		c.JSON(status, response)
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// orderErrorStatus is 409 for an order held back as a likely double
+// submission, so clients can ask the user to confirm, and 400 otherwise
+func orderErrorStatus(err error) int {
+	if services.IsDuplicateOrder(err) {
+		return http.StatusConflict
+	}
+	if services.IsRoutingFailure(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
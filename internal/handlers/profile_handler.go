@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileService *services.ProfileService
+}
+
+func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+// SearchUsers finds public profiles whose username starts with ?q=
+func (h *ProfileHandler) SearchUsers(c *gin.Context) {
+	users, err := h.profileService.Search(c.Request.Context(), c.GetString("tenantID"), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"users": users})
+}
+
+// GetProfile returns a user's public profile: return, rank, badges and join
+// date. Private profiles are a 404 to everyone but their owner.
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	profile, err := h.profileService.Profile(c.Request.Context(), c.GetString("userID"), c.GetString("tenantID"), c.Param("username"))
+	if errors.Is(err, services.ErrProfileNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load profile: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"profile": profile})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ReferralHandler struct {
+	referralService *services.ReferralService
+}
+
+func NewReferralHandler(referralService *services.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// GetReferrals returns the user's invite code and everyone who registered with it
+func (h *ReferralHandler) GetReferrals(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	code, err := h.referralService.GetInviteCode(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invite code: " + err.Error()})
+		return
+	}
+
+	referrals, err := h.referralService.GetReferrals(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch referrals: " + err.Error()})
+		return
+	}
+
+	totalBonus := 0.0
+	for _, r := range referrals {
+		totalBonus += r.Bonus
+	}
+
+	jsonLocal(c, http.StatusOK, gin.H{
+		"inviteCode": code,
+		"referrals":  referrals,
+		"count":      len(referrals),
+		"totalBonus": totalBonus,
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type RiskHandler struct {
+	riskService *services.RiskService
+	fx          *services.FXService
+}
+
+func NewRiskHandler(riskService *services.RiskService, fx *services.FXService) *RiskHandler {
+	return &RiskHandler{riskService: riskService, fx: fx}
+}
+
+func (h *RiskHandler) GetRisk(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	metrics, err := h.riskService.GetRiskMetrics(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate risk: " + err.Error()})
+		return
+	}
+	services.ConvertRisk(metrics, h.fx.Rate(c.GetString("currency")))
+	jsonLocal(c, http.StatusOK, metrics)
+}
+
+type StressTestRequest struct {
+	Shocks map[string]float64 `json:"shocks" binding:"required"` // e.g. {"Technology": -15, "Energy": 5}
+}
+
+func (h *RiskHandler) StressTest(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req StressTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	result, err := h.riskService.StressTest(c.Request.Context(), userID.(string), req.Shocks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	services.ConvertStressTest(result, h.fx.Rate(c.GetString("currency")))
+	jsonLocal(c, http.StatusOK, result)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SimulationHandler struct {
+	simulationService *services.SimulationService
+}
+
+func NewSimulationHandler(simulationService *services.SimulationService) *SimulationHandler {
+	return &SimulationHandler{simulationService: simulationService}
+}
+
+// SetSimulationRequest changes only the fields that are present
+type SetSimulationRequest struct {
+	TickIntervalMs       *int     `json:"tickIntervalMs"`
+	VolatilityMultiplier *float64 `json:"volatilityMultiplier"`
+	Paused               *bool    `json:"paused"`
+}
+
+func (h *SimulationHandler) GetSimulation(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"simulation": h.simulationService.GetSettings()})
+}
+
+func (h *SimulationHandler) SetSimulation(c *gin.Context) {
+	var req SetSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	settings := h.simulationService.GetSettings()
+	if req.TickIntervalMs != nil {
+		settings.TickIntervalMs = *req.TickIntervalMs
+	}
+	if req.VolatilityMultiplier != nil {
+		settings.VolatilityMultiplier = *req.VolatilityMultiplier
+	}
+	if req.Paused != nil {
+		settings.Paused = *req.Paused
+	}
+	settings.UpdatedBy = c.GetString("userID")
+
+	settings, err := h.simulationService.SetSettings(c.Request.Context(), settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"simulation": settings})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type StatementHandler struct {
+	statementService *services.StatementService
+}
+
+func NewStatementHandler(statementService *services.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// ListStatements returns the user's generated statements without their
+// line items
+func (h *StatementHandler) ListStatements(c *gin.Context) {
+	statements, err := h.statementService.List(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list statements: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"statements": statements})
+}
+
+// GetStatement returns the statement for a finished month, generating it on
+// first request
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	year, yearErr := strconv.Atoi(c.Param("year"))
+	month, monthErr := strconv.Atoi(c.Param("month"))
+	if yearErr != nil || monthErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Year and month must be numbers"})
+		return
+	}
+
+	statement, err := h.statementService.Get(c.Request.Context(), c.GetString("userID"), year, month)
+	switch {
+	case errors.Is(err, services.ErrNoStatement):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStatementNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		jsonLocal(c, http.StatusOK, gin.H{"statement": statement})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SPA serves a single page app build. Existing files are served as is;
+// hashed build assets are cached for a year and index.html is revalidated on
+// every load so a deploy is picked up immediately. Paths without a file
+// extension fall back to index.html for client-side (history mode) routing.
+// Unknown /api paths still get a JSON 404. Register it with router.NoRoute.
+func SPA(files fs.FS) gin.HandlerFunc {
+	fileServer := http.FileServer(http.FS(files))
+
+	return func(c *gin.Context) {
+		urlPath := c.Request.URL.Path
+		if strings.HasPrefix(urlPath, "/api/") || urlPath == "/api" || urlPath == "/ws" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(urlPath), "/")
+		if name == "" || name == "index.html" {
+			serveIndex(c, files)
+			return
+		}
+		if info, err := fs.Stat(files, name); err != nil || info.IsDir() {
+			if path.Ext(name) != "" {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			serveIndex(c, files)
+			return
+		}
+
+		if strings.HasPrefix(name, "assets/") {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "public, max-age=3600")
+		}
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+func serveIndex(c *gin.Context, files fs.FS) {
+	index, err := fs.ReadFile(files, "index.html")
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+}
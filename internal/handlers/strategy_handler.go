@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type StrategyHandler struct {
+	strategyService *services.StrategyService
+	runner          *services.StrategyRunner
+}
+
+func NewStrategyHandler(strategyService *services.StrategyService, runner *services.StrategyRunner) *StrategyHandler {
+	return &StrategyHandler{strategyService: strategyService, runner: runner}
+}
+
+type StrategyRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description string               `json:"description"`
+	Rules       models.StrategyRules `json:"rules"`
+}
+
+type PublishStrategyRequest struct {
+	Published bool `json:"published"`
+}
+
+type PaperRunRequest struct {
+	Days int `json:"days"` // Candle history to run over, default 180
+}
+
+// CreateStrategy stores a new strategy for the user
+func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
+	var req StrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	strategy, err := h.strategyService.Create(c.Request.Context(), models.Strategy{
+		UserID:      c.GetString("userID"),
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+		TenantID:    c.GetString("tenantID"),
+	})
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusCreated, gin.H{"strategy": strategy})
+}
+
+// ListStrategies lists the user's own strategies
+func (h *StrategyHandler) ListStrategies(c *gin.Context) {
+	strategies, err := h.strategyService.ListOwn(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch strategies: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"strategies": strategies})
+}
+
+// Marketplace lists published strategies. ?sort= is return (default), runs,
+// clones or newest; ?limit= caps the list (default 50).
+func (h *StrategyHandler) Marketplace(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	strategies, err := h.strategyService.Marketplace(c.Request.Context(), c.GetString("tenantID"), c.Query("sort"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch strategies: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"strategies": strategies})
+}
+
+// GetStrategy returns one of the user's strategies or a published one
+func (h *StrategyHandler) GetStrategy(c *gin.Context) {
+	strategy, err := h.strategyService.Get(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.GetString("tenantID"))
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"strategy": strategy})
+}
+
+// UpdateStrategy replaces the name, description and rules of the user's strategy
+func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
+	var req StrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	strategy, err := h.strategyService.Update(c.Request.Context(), c.Param("id"), c.GetString("userID"), models.Strategy{
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+	})
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"strategy": strategy})
+}
+
+// DeleteStrategy stops the user's strategy if it runs live and removes it
+// with its runs
+func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
+	_, err := h.runner.Stop(c.Request.Context(), c.Param("id"), c.GetString("userID"), "deleted")
+	if err != nil && !errors.Is(err, services.ErrStrategyNotRunning) {
+		strategyError(c, err)
+		return
+	}
+	if err := h.strategyService.Delete(c.Request.Context(), c.Param("id"), c.GetString("userID")); err != nil {
+		strategyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Strategy deleted"})
+}
+
+// PublishStrategy lists the user's strategy in the marketplace, or withdraws
+// it with {"published":false}
+func (h *StrategyHandler) PublishStrategy(c *gin.Context) {
+	req := PublishStrategyRequest{Published: true}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+	strategy, err := h.strategyService.SetPublished(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.Published)
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"strategy": strategy})
+}
+
+// CloneStrategy copies a published strategy into the user's own strategies
+func (h *StrategyHandler) CloneStrategy(c *gin.Context) {
+	strategy, err := h.strategyService.Clone(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.GetString("tenantID"))
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusCreated, gin.H{"strategy": strategy})
+}
+
+// PaperRun simulates a strategy over candle history from the user's buying
+// power without placing orders
+func (h *StrategyHandler) PaperRun(c *gin.Context) {
+	var req PaperRunRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+	run, err := h.strategyService.PaperRun(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.GetString("tenantID"), req.Days)
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"run": run})
+}
+
+// GetRuns lists the user's runs of a strategy, newest first (?limit=, default 20)
+func (h *StrategyHandler) GetRuns(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	runs, err := h.strategyService.Runs(c.Request.Context(), c.Param("id"), c.GetString("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"runs": runs})
+}
+
+// StartStrategy starts trading the user's strategy live
+func (h *StrategyHandler) StartStrategy(c *gin.Context) {
+	run, err := h.runner.Start(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.GetString("tenantID"))
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"run": run})
+}
+
+// StopStrategy stops the user's live run of a strategy, keeping any position
+func (h *StrategyHandler) StopStrategy(c *gin.Context) {
+	run, err := h.runner.Stop(c.Request.Context(), c.Param("id"), c.GetString("userID"), "user")
+	if err != nil {
+		strategyError(c, err)
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"run": run})
+}
+
+// GetRunLogs lists the steps of one of the user's live runs, newest first
+// (?limit=, default 100)
+func (h *StrategyHandler) GetRunLogs(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	logs, err := h.runner.Logs(c.Request.Context(), c.Param("id"), c.Param("runId"), c.GetString("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run logs: " + err.Error()})
+		return
+	}
+	jsonLocal(c, http.StatusOK, gin.H{"logs": logs})
+}
+
+// GetProfitLoss attributes the user's fills to the strategies that placed them
+func (h *StrategyHandler) GetProfitLoss(c *gin.Context) {
+	attribution, err := h.runner.ProfitLoss(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute strategy P&L: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"strategies": attribution})
+}
+
+// strategyError maps strategy service errors to HTTP statuses; anything
+// else is a validation error
+func strategyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrStrategyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotStrategyOwner), errors.Is(err, services.ErrStrategyLimit), errors.Is(err, services.ErrLiveRunLimit):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEnoughHistory), errors.Is(err, services.ErrStrategyRunning),
+		errors.Is(err, services.ErrStrategyNotRunning), errors.Is(err, services.ErrStrategyBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
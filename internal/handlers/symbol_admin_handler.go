@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SymbolAdminHandler struct {
+	symbolService *services.SymbolService
+	borrowService *services.BorrowService
+	orderService  *services.OrderService
+}
+
+func NewSymbolAdminHandler(symbolService *services.SymbolService, borrowService *services.BorrowService, orderService *services.OrderService) *SymbolAdminHandler {
+	return &SymbolAdminHandler{symbolService: symbolService, borrowService: borrowService, orderService: orderService}
+}
+
+// UpdateBorrowRequest sets a symbol's short-sale settings
+type UpdateBorrowRequest struct {
+	HardToBorrow  bool    `json:"hardToBorrow"`
+	BorrowFeeRate float64 `json:"borrowFeeRate"` // Annual percent
+	BorrowLimit   float64 `json:"borrowLimit"`   // Shares; 0 is unlimited
+}
+
+// symbolBorrow is a symbol's rules with the shares currently held short
+type symbolBorrow struct {
+	models.SymbolInfo
+	ShortInterest float64 `json:"shortInterest"`
+}
+
+// ListSymbols lists every symbol with its borrow settings and short interest
+func (h *SymbolAdminHandler) ListSymbols(c *gin.Context) {
+	symbols := h.symbolService.ListSymbols()
+	list := make([]symbolBorrow, 0, len(symbols))
+	for _, info := range symbols {
+		short, err := h.orderService.ShortInterest(c.Request.Context(), info.Symbol)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load short interest: " + err.Error()})
+			return
+		}
+		list = append(list, symbolBorrow{SymbolInfo: info, ShortInterest: short})
+	}
+	c.JSON(http.StatusOK, gin.H{"symbols": list})
+}
+
+// UpdateBorrow changes whether a symbol can be shorted, how much and at what fee
+func (h *SymbolAdminHandler) UpdateBorrow(c *gin.Context) {
+	var req UpdateBorrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	settings, err := h.borrowService.Update(c.Request.Context(), models.BorrowSettings{
+		Symbol:        c.Param("symbol"),
+		HardToBorrow:  req.HardToBorrow,
+		BorrowFeeRate: req.BorrowFeeRate,
+		BorrowLimit:   req.BorrowLimit,
+		UpdatedBy:     c.GetString("userID"),
+	})
+	switch {
+	case errors.Is(err, services.ErrUnknownSymbol):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"borrow": settings})
+}
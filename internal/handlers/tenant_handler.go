@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TenantHandler struct {
+	tenantService *services.TenantService
+}
+
+func NewTenantHandler(tenantService *services.TenantService) *TenantHandler {
+	return &TenantHandler{tenantService: tenantService}
+}
+
+type SaveTenantRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Hosts           []string `json:"hosts"`
+	Symbols         []string `json:"symbols"` // Empty allows every symbol
+	StartingBalance float64  `json:"startingBalance"`
+}
+
+// ResolveTenant tags the request with its tenant, found from the X-Tenant-ID
+// header or the request host. With tenancy off it does nothing; otherwise
+// requests that match no tenant are rejected.
+func (h *TenantHandler) ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.tenantService.Enabled() {
+			c.Next()
+			return
+		}
+		tenant, err := h.tenantService.Resolve(c.GetHeader("X-Tenant-ID"), c.Request.Host)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+			c.Abort()
+			return
+		}
+		c.Set("tenantID", tenant.ID)
+		c.Set("tenant", tenant)
+		c.Next()
+	}
+}
+
+// requestTenant returns the tenant the request was resolved to, or nil with
+// tenancy off
+func requestTenant(c *gin.Context) *models.Tenant {
+	tenant, _ := c.Value("tenant").(*models.Tenant)
+	return tenant
+}
+
+// GetTenant returns the tenant the request resolved to, so a frontend can
+// show its name before anyone logs in
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.tenantService.Enabled(), "tenant": requestTenant(c)})
+}
+
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"tenants": h.tenantService.ListTenants()})
+}
+
+// SaveTenant creates or replaces the tenant named in the path
+func (h *TenantHandler) SaveTenant(c *gin.Context) {
+	var req SaveTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantService.SaveTenant(c.Request.Context(), models.Tenant{
+		ID:              c.Param("id"),
+		Name:            req.Name,
+		Hosts:           req.Hosts,
+		Symbols:         req.Symbols,
+		StartingBalance: req.StartingBalance,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TickLogHandler struct {
+	tickLog *services.TickLogService
+}
+
+func NewTickLogHandler(tickLog *services.TickLogService) *TickLogHandler {
+	return &TickLogHandler{tickLog: tickLog}
+}
+
+// GetTicks returns a symbol's broadcast ticks between from and to (RFC 3339,
+// default the last hour), oldest first. ?limit= caps the ticks (default and
+// most 10000); when more remain, truncated is set and the next page starts
+// at next.
+func (h *TickLogHandler) GetTicks(c *gin.Context) {
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 time"})
+			return
+		}
+		to = parsed.UTC()
+	}
+	from := to.Add(-time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 time"})
+			return
+		}
+		from = parsed.UTC()
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "10000"), 10, 64)
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	ticks, truncated, err := h.tickLog.Ticks(c.Request.Context(), c.Param("symbol"), from, to, limit)
+	if errors.Is(err, services.ErrTickRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticks: " + err.Error()})
+		return
+	}
+
+	response := gin.H{"ticks": ticks, "truncated": truncated}
+	if truncated {
+		response["next"] = ticks[len(ticks)-1].Timestamp.Add(time.Millisecond)
+	}
+	c.JSON(http.StatusOK, response)
+}
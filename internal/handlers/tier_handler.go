@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TierHandler struct {
+	tierService *services.TierService
+	authService *services.AuthService
+}
+
+func NewTierHandler(tierService *services.TierService, authService *services.AuthService) *TierHandler {
+	return &TierHandler{tierService: tierService, authService: authService}
+}
+
+type SetTierRequest struct {
+	StartingBalance *float64 `json:"startingBalance" binding:"required"` // 0 uses the tenant's starting balance
+	Features        []string `json:"features"`
+	Description     string   `json:"description"`
+}
+
+type AssignTierRequest struct {
+	Tier string `json:"tier" binding:"required"`
+}
+
+// ListTiers returns the tiers an account can register in
+func (h *TierHandler) ListTiers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"tiers": h.tierService.ListTiers()})
+}
+
+// SetTier creates or changes a tier. Changes apply to the starting balance
+// of accounts registered afterwards and to every user's features at once.
+func (h *TierHandler) SetTier(c *gin.Context) {
+	var req SetTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	saved, err := h.tierService.SetTier(c.Request.Context(), models.AccountTier{
+		Name:            c.Param("name"),
+		StartingBalance: *req.StartingBalance,
+		Features:        req.Features,
+		Description:     req.Description,
+		UpdatedBy:       c.GetString("userID"),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tier": saved})
+}
+
+// AssignTier moves a user to another tier without changing their balance
+func (h *TierHandler) AssignTier(c *gin.Context) {
+	var req AssignTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	admin, err := h.authService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err = h.tierService.Assign(c.Request.Context(), admin, c.Param("id"), req.Tier)
+	switch {
+	case errors.Is(err, services.ErrUnknownTier):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrTierUserMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tier updated", "tier": req.Tier})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds how long services may spend on a request. Services receive
+// the deadline through c.Request.Context(); when it passes, the response is
+// 504 Gateway Timeout. A later Timeout on the same route replaces an earlier
+// one, so a group-wide default can be raised for slow routes.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, ok := c.Value("requestContext").(context.Context)
+		if !ok {
+			parent = c.Request.Context()
+			c.Set("requestContext", parent)
+		}
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		if _, wrapped := c.Writer.(*timeoutWriter); !wrapped {
+			c.Writer = &timeoutWriter{ResponseWriter: c.Writer, c: c}
+		}
+		c.Next()
+
+		if timedOut(c) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		}
+	}
+}
+
+func timedOut(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
+
+// timeoutWriter turns server errors caused by the deadline into 504s
+type timeoutWriter struct {
+	gin.ResponseWriter
+	c *gin.Context
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && timedOut(w.c) {
+		code = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"trading-simulator/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TrainingHandler struct {
+	trainingService *services.TrainingService
+}
+
+func NewTrainingHandler(trainingService *services.TrainingService) *TrainingHandler {
+	return &TrainingHandler{trainingService: trainingService}
+}
+
+// SetTrainingRequest changes only the fields that are present
+type SetTrainingRequest struct {
+	Enabled           *bool    `json:"enabled"`
+	RejectProbability *float64 `json:"rejectProbability"`
+	DelayProbability  *float64 `json:"delayProbability"`
+	MaxDelayMs        *int     `json:"maxDelayMs"`
+}
+
+// GetTraining shows the training mode settings of the admin's group
+func (h *TrainingHandler) GetTraining(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"training": h.trainingService.GetSettings(c.GetString("tenantID"))})
+}
+
+// SetTraining changes the training mode settings of the admin's group
+func (h *TrainingHandler) SetTraining(c *gin.Context) {
+	var req SetTrainingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	settings := h.trainingService.GetSettings(c.GetString("tenantID"))
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.RejectProbability != nil {
+		settings.RejectProbability = *req.RejectProbability
+	}
+	if req.DelayProbability != nil {
+		settings.DelayProbability = *req.DelayProbability
+	}
+	if req.MaxDelayMs != nil {
+		settings.MaxDelayMs = *req.MaxDelayMs
+	}
+	settings.UpdatedBy = c.GetString("userID")
+
+	settings, err := h.trainingService.SetSettings(c.Request.Context(), settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"training": settings})
+}
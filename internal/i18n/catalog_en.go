@@ -0,0 +1,99 @@
+package i18n
+
+var english = map[string]string{
+	// Order validation and execution
+	"order.invalid_side":                 "invalid order side %q. must be 'buy' or 'sell'",
+	"order.unsupported_type":             "unsupported order type: %s",
+	"order.advanced_only":                "%s orders must be placed as advanced orders",
+	"order.immediate_only":               "%s orders execute immediately; place them as regular orders",
+	"order.price_not_positive":           "price must be positive",
+	"order.stop_price_required":          "stop orders need a stop price",
+	"order.stop_limit_prices_required":   "stop-limit orders need a stop price and a limit price",
+	"order.trailing_percent_invalid":     "trailing stop needs a trailing percent between 0 and 100",
+	"order.insufficient_funds":           "insufficient funds. have $%.2f, need $%.2f",
+	"order.insufficient_buying_power":    "insufficient buying power. need $%.2f, have $%.2f",
+	"order.no_position":                  "you own no %s",
+	"order.hard_to_borrow":               "%s is hard to borrow; short sales are not accepted",
+	"order.borrow_unavailable":           "only %g shares of %s are available to borrow",
+	"order.insufficient_shares":          "insufficient shares: have %g, want %g",
+	"order.insufficient_shares_for_stop": "insufficient shares for stop loss order",
+	"order.oco_invalid_id":               "invalid OCO order id",
+	"order.oco_not_found":                "OCO order not found or no longer active",
+	"order.oco_other_account":            "OCO orders must belong to the same account",
+	"order.not_active":                   "stop order is no longer active",
+	"order.rate_limited":                 "order throttled: more than %d orders per second",
+	"order.symbol_interval":              "order throttled: orders in %s must be at least %v apart",
+	"order.below_min_quantity":           "quantity %v is below the minimum of %v for %s",
+	"order.lot_size":                     "quantity %v must be a multiple of the lot size %v for %s",
+	"order.below_tick":                   "price must be at least one tick (%v) for %s",
+	"order.symbol_not_available":         "%s is not tradable in this group",
+	"order.expiry_in_past":               "expiry must be in the future",
+	"order.activation_in_past":           "activation time must be in the future",
+	"order.expiry_before_activation":     "expiry must be after the activation time",
+	"order.condition_invalid":            "invalid condition %q; use a symbol, >, >=, < or <= and a positive price, like \"SPY > 450\"",
+	"order.condition_unknown_symbol":     "unknown condition symbol %s",
+	"order.duplicate":                    "identical order placed within %v; resubmit with allowDuplicate to place it again",
+	"order.limit_not_marketable":         "limit %s at $%.2f is not marketable; the best price is $%.2f",
+	"order.market_closed":                "the market is closed for %s; it opens at %s",
+	"order.symbol_halted":                "trading in %s is halted",
+	"order.close_amount_invalid":         "give either a percent between 0 and 100 or a quantity to close",
+	"order.close_exceeds_position":       "cannot close %g shares; the %[3]s position is %[2]g shares",
+	"order.asset_class_restricted":       "%[2]s cannot be traded: your classroom does not allow %[1]s",
+	"order.symbol_restricted":            "your classroom does not allow trading %s",
+	"order.short_restricted":             "your classroom does not allow short selling %s",
+
+	// Training mode broker errors
+	"order.routing_failed":        "order routing failed for %s; the venue did not accept the order, try again",
+	"order.venue_timeout":         "the venue did not acknowledge the %s order in time; check your orders before resending",
+	"order.liquidity_unavailable": "liquidity not available for %s at this time",
+
+	// Registration
+	"user.username_taken": "username %s is already taken",
+	"user.email_taken":    "an account with email %s already exists",
+
+	// Account changes
+	"user.wrong_password":       "current password is incorrect",
+	"user.email_unchanged":      "%s is already your email",
+	"user.username_unchanged":   "%s is already your username",
+	"user.username_cooldown":    "you can change your username again after %s",
+	"user.verification_invalid": "the verification link is invalid or has expired",
+
+	// Access policy
+	"access.untrusted_ip":    "%s blocked: %s is not one of your trusted IPs",
+	"access.country_blocked": "%s blocked: access from %s is not allowed",
+
+	// Competition trading rules
+	"competition.disabled":           "competitions are currently disabled",
+	"competition.not_joined":         "you have not joined this competition",
+	"competition.not_started":        "competition has not started yet",
+	"competition.ended":              "competition has ended",
+	"competition.symbol_not_allowed": "%s is not tradable in this competition",
+	"competition.daily_trade_limit":  "competition limit of %d trades per day reached",
+	"competition.shorting_disabled":  "short selling is disabled in this competition",
+	"competition.no_equity":          "competition account has no equity left",
+	"competition.max_leverage":       "order exceeds max leverage of %.1fx (exposure $%.2f, equity $%.2f)",
+	"competition.max_position":       "position would be %.1f%% of equity, max is %.1f%%",
+
+	// Notifications
+	"achievement.first_trade.name":               "First Trade",
+	"achievement.first_trade.description":        "Place your first filled order",
+	"achievement.ten_percent_return.name":        "Double Digits",
+	"achievement.ten_percent_return.description": "Grow your account 10% above the starting balance",
+	"achievement.diversified.name":               "Diversified",
+	"achievement.diversified.description":        "Hold positions in 5 or more symbols at once",
+	"achievement.survived_crash.name":            "Survived a Crash",
+	"achievement.survived_crash.description":     "Recover to a new high after a 10% drawdown",
+	"notification.achievement_unlocked":          "Achievement unlocked: %s",
+	"notification.maintenance_started":           "Trading is paused for maintenance",
+	"notification.maintenance_ended":             "Maintenance is over, trading has resumed",
+	"notification.order_expired":                 "Your %s %s order for %g %s expired and was cancelled",
+	"notification.order_no_position":             "Your %s %s order for %g %s was cancelled because you no longer hold the shares",
+	"notification.order_filled":                  "Your %s order for %g %s filled at $%.2f",
+	"notification.stop_triggered":                "Your %s %s order for %g %s triggered and filled at $%.2f",
+	"notification.stop_failed":                   "Your %s %s order for %g %s triggered at $%.2f but could not be filled",
+	"notification.order_failed":                  "Your %s %s order for %g %s went live but could not be filled at $%.2f",
+	"push.title.fill":                            "Order filled",
+	"push.title.stop_triggered":                  "Stop triggered",
+	"push.title.order_cancelled":                 "Order cancelled",
+	"push.title.maintenance":                     "Maintenance",
+}
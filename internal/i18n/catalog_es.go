@@ -0,0 +1,99 @@
+package i18n
+
+var spanish = map[string]string{
+	// Order validation and execution
+	"order.invalid_side":                 "lado de orden %q no válido. debe ser 'buy' o 'sell'",
+	"order.unsupported_type":             "tipo de orden no admitido: %s",
+	"order.advanced_only":                "las órdenes %s deben enviarse como órdenes avanzadas",
+	"order.immediate_only":               "las órdenes %s se ejecutan al instante; envíalas como órdenes normales",
+	"order.price_not_positive":           "el precio debe ser positivo",
+	"order.stop_price_required":          "las órdenes stop necesitan un precio stop",
+	"order.stop_limit_prices_required":   "las órdenes stop-limit necesitan un precio stop y un precio límite",
+	"order.trailing_percent_invalid":     "el trailing stop necesita un porcentaje entre 0 y 100",
+	"order.insufficient_funds":           "fondos insuficientes. tienes $%.2f, necesitas $%.2f",
+	"order.insufficient_buying_power":    "poder de compra insuficiente. necesitas $%.2f, tienes $%.2f",
+	"order.no_position":                  "no tienes acciones de %s",
+	"order.hard_to_borrow":               "%s es difícil de tomar en préstamo; no se aceptan ventas en corto",
+	"order.borrow_unavailable":           "solo hay %g acciones de %s disponibles para préstamo",
+	"order.insufficient_shares":          "acciones insuficientes: tienes %g, quieres %g",
+	"order.insufficient_shares_for_stop": "acciones insuficientes para la orden stop loss",
+	"order.oco_invalid_id":               "id de orden OCO no válido",
+	"order.oco_not_found":                "la orden OCO no existe o ya no está activa",
+	"order.oco_other_account":            "las órdenes OCO deben pertenecer a la misma cuenta",
+	"order.not_active":                   "la orden stop ya no está activa",
+	"order.rate_limited":                 "orden limitada: más de %d órdenes por segundo",
+	"order.symbol_interval":              "orden limitada: las órdenes de %s deben estar separadas al menos %v",
+	"order.below_min_quantity":           "la cantidad %v es inferior al mínimo de %v para %s",
+	"order.lot_size":                     "la cantidad %v debe ser múltiplo del lote %v para %s",
+	"order.below_tick":                   "el precio debe ser de al menos un tick (%v) para %s",
+	"order.expiry_in_past":               "el vencimiento debe estar en el futuro",
+	"order.activation_in_past":           "la hora de activación debe estar en el futuro",
+	"order.expiry_before_activation":     "el vencimiento debe ser posterior a la hora de activación",
+	"order.condition_invalid":            "condición %q no válida; usa un símbolo, >, >=, < o <= y un precio positivo, como \"SPY > 450\"",
+	"order.condition_unknown_symbol":     "símbolo de condición desconocido: %s",
+	"order.duplicate":                    "orden idéntica enviada hace menos de %v; reenvíala con allowDuplicate para colocarla de nuevo",
+	"order.limit_not_marketable":         "la orden límite de %s a $%.2f no es ejecutable; el mejor precio es $%.2f",
+	"order.market_closed":                "el mercado está cerrado para %s; abre el %s",
+	"order.symbol_halted":                "la negociación de %s está suspendida",
+	"order.close_amount_invalid":         "indica un porcentaje entre 0 y 100 o una cantidad a cerrar",
+	"order.close_exceeds_position":       "no se pueden cerrar %g acciones; la posición de %[3]s es de %[2]g acciones",
+	"order.symbol_not_available":         "%s no se puede negociar en este grupo",
+	"order.asset_class_restricted":       "%[2]s no se puede negociar: tu clase no permite %[1]s",
+	"order.symbol_restricted":            "tu clase no permite negociar %s",
+	"order.short_restricted":             "tu clase no permite vender en corto %s",
+
+	// Training mode broker errors
+	"order.routing_failed":        "falló el enrutamiento de la orden de %s; el mercado no aceptó la orden, inténtalo de nuevo",
+	"order.venue_timeout":         "el mercado no confirmó a tiempo la orden de %s; revisa tus órdenes antes de reenviarla",
+	"order.liquidity_unavailable": "no hay liquidez disponible para %s en este momento",
+
+	// Registration
+	"user.username_taken": "el nombre de usuario %s ya está en uso",
+	"user.email_taken":    "ya existe una cuenta con el correo %s",
+
+	// Account changes
+	"user.wrong_password":       "la contraseña actual es incorrecta",
+	"user.email_unchanged":      "%s ya es tu correo",
+	"user.username_unchanged":   "%s ya es tu nombre de usuario",
+	"user.username_cooldown":    "podrás cambiar tu nombre de usuario de nuevo después del %s",
+	"user.verification_invalid": "el enlace de verificación no es válido o ha caducado",
+
+	// Access policy
+	"access.untrusted_ip":    "%s bloqueado: %s no es una de tus IP de confianza",
+	"access.country_blocked": "%s bloqueado: no se permite el acceso desde %s",
+
+	// Competition trading rules
+	"competition.disabled":           "las competiciones están desactivadas",
+	"competition.not_joined":         "no te has unido a esta competición",
+	"competition.not_started":        "la competición aún no ha empezado",
+	"competition.ended":              "la competición ha terminado",
+	"competition.symbol_not_allowed": "%s no se puede negociar en esta competición",
+	"competition.daily_trade_limit":  "se alcanzó el límite de %d operaciones diarias de la competición",
+	"competition.shorting_disabled":  "las ventas en corto están desactivadas en esta competición",
+	"competition.no_equity":          "la cuenta de la competición no tiene patrimonio",
+	"competition.max_leverage":       "la orden supera el apalancamiento máximo de %.1fx (exposición $%.2f, patrimonio $%.2f)",
+	"competition.max_position":       "la posición sería el %.1f%% del patrimonio, el máximo es %.1f%%",
+
+	// Notifications
+	"achievement.first_trade.name":               "Primera operación",
+	"achievement.first_trade.description":        "Completa tu primera orden",
+	"achievement.ten_percent_return.name":        "Doble dígito",
+	"achievement.ten_percent_return.description": "Haz crecer tu cuenta un 10% por encima del saldo inicial",
+	"achievement.diversified.name":               "Diversificado",
+	"achievement.diversified.description":        "Mantén posiciones en 5 o más símbolos a la vez",
+	"achievement.survived_crash.name":            "Superviviente",
+	"achievement.survived_crash.description":     "Alcanza un nuevo máximo tras una caída del 10%",
+	"notification.achievement_unlocked":          "Logro desbloqueado: %s",
+	"notification.maintenance_started":           "El trading está en pausa por mantenimiento",
+	"notification.maintenance_ended":             "El mantenimiento ha terminado, el trading se ha reanudado",
+	"notification.order_expired":                 "Tu orden %s de %s por %g %s ha vencido y se ha cancelado",
+	"notification.order_no_position":             "Tu orden %s de %s por %g %s se ha cancelado porque ya no tienes las acciones",
+	"notification.order_filled":                  "Tu orden de %s por %g %s se ejecutó a $%.2f",
+	"notification.stop_triggered":                "Tu orden %s de %s por %g %s se activó y se ejecutó a $%.2f",
+	"notification.stop_failed":                   "Tu orden %s de %s por %g %s se activó a $%.2f pero no se pudo ejecutar",
+	"notification.order_failed":                  "Tu orden %s de %s por %g %s se activó pero no se pudo ejecutar a $%.2f",
+	"push.title.fill":                            "Orden ejecutada",
+	"push.title.stop_triggered":                  "Stop activado",
+	"push.title.order_cancelled":                 "Orden cancelada",
+	"push.title.maintenance":                     "Mantenimiento",
+}
@@ -0,0 +1,100 @@
+// Package i18n translates user-facing messages. Messages are identified by a
+// stable code so clients can handle errors programmatically whatever the
+// language of the text.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// catalogs maps a language to its messages. Every code must exist in the
+// English catalog, which is the fallback for missing translations.
+var catalogs = map[string]map[string]string{
+	English: english,
+	Spanish: spanish,
+}
+
+// Error is a user-facing error with a stable code. Error returns the English
+// text so logs and callers that don't translate keep working.
+type Error struct {
+	Code string
+	Args []interface{}
+}
+
+func NewError(code string, args ...interface{}) *Error {
+	return &Error{Code: code, Args: args}
+}
+
+func (e *Error) Error() string {
+	return Translate(English, e.Code, e.Args...)
+}
+
+// Translate returns the message in the given language
+func (e *Error) Translate(lang string) string {
+	return Translate(lang, e.Code, e.Args...)
+}
+
+// Translate formats the message for code in lang, falling back to English
+// and then to the code itself
+func Translate(lang, code string, args ...interface{}) string {
+	format, ok := catalogs[lang][code]
+	if !ok {
+		format, ok = english[code]
+	}
+	if !ok {
+		return code
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Supported reports whether there is a catalog for lang
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Negotiate picks the best supported language from an Accept-Language
+// header, e.g. "es-MX,es;q=0.9,en;q=0.8". It defaults to English.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.Index(lang, "-"); i > 0 {
+			lang = lang[:i]
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if q, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if Supported(lang) && quality > 0 {
+			candidates = append(candidates, candidate{lang, quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return English
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
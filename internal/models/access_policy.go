@@ -0,0 +1,10 @@
+package models
+
+// AccessAttempt is a login or trade request checked against the access
+// policy
+type AccessAttempt struct {
+	UserID  string
+	Action  string // "login" or "trade"
+	IP      string
+	Country string // ISO 3166 code from the geo resolver or header; empty if unknown
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AccountExport is a machine-readable copy of everything stored about a user
+type AccountExport struct {
+	ExportedAt         time.Time          `json:"exportedAt"`
+	Profile            User               `json:"profile"`
+	Orders             []Order            `json:"orders"`
+	Executions         []Execution        `json:"executions"`
+	Ledger             []LedgerEntry      `json:"ledger"`
+	Journal            []JournalEntry     `json:"journal"`
+	Inbox              []InboxMessage     `json:"inbox"`
+	CashRequests       []CashRequest      `json:"cashRequests"`
+	AdvancedOrders     []Order            `json:"advancedOrders"`
+	OrderEvents        []OrderEvent       `json:"orderEvents"`
+	Positions          []Portfolio        `json:"positions"`
+	CompetitionEntries []CompetitionEntry `json:"competitionEntries"`
+	Achievements       []Achievement      `json:"achievements"`
+	Strategies         []Strategy         `json:"strategies"`
+	StrategyRuns       []StrategyRun      `json:"strategyRuns"` // Runs by the user, of any strategy
+	StrategyRunLogs    []StrategyRunLog   `json:"strategyRunLogs"`
+	Devices            []Device           `json:"devices"`
+	Statements         []Statement        `json:"statements"`
+	Baskets            []Basket           `json:"baskets"`
+	Referrals          []Referral         `json:"referrals"`  // Made by or to the user
+	Classrooms         []Classroom        `json:"classrooms"` // Taught or joined
+}
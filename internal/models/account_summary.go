@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AccountSummary is the headline numbers of a user's main account
+type AccountSummary struct {
+	CashBalance          float64             `json:"cashBalance"`
+	ReservedCash         float64             `json:"reservedCash"` // Held for open buy orders
+	BuyingPower          float64             `json:"buyingPower"`  // Cash less holds, or margin-adjusted when margin is enabled
+	MarginEnabled        bool                `json:"marginEnabled"`
+	PositionsValue       float64             `json:"positionsValue"`
+	Equity               float64             `json:"equity"`
+	DayProfitLoss        float64             `json:"dayProfitLoss"`
+	DayProfitLossPercent float64             `json:"dayProfitLossPercent"`
+	Positions            int                 `json:"positions"`
+	PositionChanges      []PositionDayChange `json:"positionChanges"` // Today's change per position
+	OpenOrders           OpenOrderCounts     `json:"openOrders"`
+	FX                   *FXRate             `json:"fx,omitempty"` // Rate the amounts were converted at, on responses
+	GeneratedAt          time.Time           `json:"generatedAt"`
+}
+
+// OpenOrderCounts counts orders still waiting to fill
+type OpenOrderCounts struct {
+	Total int `json:"total"`
+	Buy   int `json:"buy"`
+	Sell  int `json:"sell"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Achievement is a milestone unlocked by a user
+type Achievement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"userId"`
+	Code        string             `bson:"code" json:"code"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Unlocked    bool               `bson:"-" json:"unlocked"`
+	UnlockedAt  time.Time          `bson:"unlocked_at" json:"unlockedAt"`
+}
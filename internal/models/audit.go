@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records a change to an account or an action taken on a user's
+// behalf
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Action    string             `bson:"action" json:"action"` // Event topic, e.g. "user.email_changed"
+	UserID    string             `bson:"user_id" json:"userId"`
+	ActorID   string             `bson:"actor_id,omitempty" json:"actorId,omitempty"` // Who acted, when not the user
+	TenantID  string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	Details   map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// AuditFilter narrows the audit log
+type AuditFilter struct {
+	UserID string
+	Action string
+	Limit  int64
+}
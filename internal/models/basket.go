@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Basket is a set of market or limit orders submitted together
+type Basket struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"userId"`
+	CompetitionID string             `bson:"competition_id,omitempty" json:"competitionId,omitempty"`
+	TenantID      string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	AllOrNothing  bool               `bson:"all_or_nothing" json:"allOrNothing"` // Legs fill together or not at all
+	Status        string             `bson:"status" json:"status"`               // "filled", "partial" or "rejected"
+	Legs          []BasketLeg        `bson:"legs" json:"legs"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// BasketLeg is one order of a basket and what became of it
+type BasketLeg struct {
+	Symbol    string  `bson:"symbol" json:"symbol"`
+	Type      string  `bson:"type" json:"type"`            // "buy" or "sell"
+	OrderType string  `bson:"order_type" json:"orderType"` // "market" or "limit"
+	Quantity  float64 `bson:"quantity" json:"quantity"`    // Shares; computed for notional legs
+	Notional  float64 `bson:"notional,omitempty" json:"notional,omitempty"`
+	Price     float64 `bson:"price" json:"price"`   // Submitted price; the fill price once filled
+	Status    string  `bson:"status" json:"status"` // "filled", "rejected" or "not_filled" when another leg sank an all-or-nothing basket
+	OrderID   string  `bson:"order_id,omitempty" json:"orderId,omitempty"`
+	Fees      float64 `bson:"fees,omitempty" json:"fees,omitempty"`
+	Error     string  `bson:"error,omitempty" json:"error,omitempty"` // Why the leg did not fill, in the submitter's language
+	ErrorCode string  `bson:"error_code,omitempty" json:"errorCode,omitempty"`
+}
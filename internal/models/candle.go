@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Candle is one day of open/high/low/close prices for a symbol
+type Candle struct {
+	Symbol string    `bson:"symbol" json:"symbol"`
+	Time   time.Time `bson:"time" json:"time"` // Start of the period, UTC midnight
+	Open   float64   `bson:"open" json:"open"`
+	High   float64   `bson:"high" json:"high"`
+	Low    float64   `bson:"low" json:"low"`
+	Close  float64   `bson:"close" json:"close"`
+	Volume int64     `bson:"volume" json:"volume"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CashRequest is a user's request to add simulated cash to their account or
+// take it out. Large requests wait for an admin or the user's teacher to
+// review them.
+type CashRequest struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       string             `bson:"user_id" json:"userId"`
+	Username     string             `bson:"username" json:"username"`
+	TenantID     string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	Type         string             `bson:"type" json:"type"`     // "deposit" or "withdrawal"
+	Amount       float64            `bson:"amount" json:"amount"` // Always positive
+	Note         string             `bson:"note,omitempty" json:"note,omitempty"`
+	Status       string             `bson:"status" json:"status"`                                  // "pending", "approved" or "denied"
+	AutoApproved bool               `bson:"auto_approved,omitempty" json:"autoApproved,omitempty"` // Applied without review
+	ReviewerID   string             `bson:"reviewer_id,omitempty" json:"reviewerId,omitempty"`
+	ReviewNote   string             `bson:"review_note,omitempty" json:"reviewNote,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+	ReviewedAt   time.Time          `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
+}
+
+// DailyTotal adds up amounts over one UTC day
+type DailyTotal struct {
+	Day   string  `bson:"day"` // YYYY-MM-DD
+	Total float64 `bson:"total"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Classroom groups students under a teacher who can monitor and manage
+// their accounts
+type Classroom struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	TeacherID  string             `bson:"teacher_id" json:"teacherId"`
+	JoinCode   string             `bson:"join_code" json:"joinCode"`
+	StudentIDs []string           `bson:"student_ids" json:"studentIds"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+
+	Restrictions TradingRestrictions `bson:"restrictions,omitempty" json:"restrictions"`
+}
+
+// TradingRestrictions limit what a classroom's students can trade. Empty
+// lists allow everything.
+type TradingRestrictions struct {
+	AssetClasses   []string `bson:"asset_classes,omitempty" json:"assetClasses"`     // Allowed asset classes: stock, etf or crypto
+	Symbols        []string `bson:"symbols,omitempty" json:"symbols"`                // Allowed symbols
+	BlockedSymbols []string `bson:"blocked_symbols,omitempty" json:"blockedSymbols"` // Never tradable, even if their class is allowed
+	NoShorting     bool     `bson:"no_shorting,omitempty" json:"noShorting"`         // Sells may not take a position below zero
+}
+
+// TradingPermissions is what a user may trade once the restrictions of
+// their group and every classroom they attend are combined
+type TradingPermissions struct {
+	AssetClasses   []string `json:"assetClasses"`      // Tradable asset classes
+	Symbols        []string `json:"symbols,omitempty"` // Tradable symbols; empty allows every symbol of those classes
+	BlockedSymbols []string `json:"blockedSymbols"`
+	Shorting       bool     `json:"shorting"`   // Whether short sales are allowed where competition rules permit them
+	Classrooms     []string `json:"classrooms"` // IDs of the classrooms that restrict the user
+}
+
+// StudentSummary is one row of a teacher's classroom dashboard
+type StudentSummary struct {
+	UserID         string      `json:"userId"`
+	Username       string      `json:"username"`
+	CashBalance    float64     `json:"cashBalance"`
+	PortfolioValue float64     `json:"portfolioValue"`
+	TotalAssets    float64     `json:"totalAssets"`
+	Positions      []Portfolio `json:"positions"`
+	RecentOrders   []Order     `json:"recentOrders"`
+}
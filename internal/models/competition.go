@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CompetitionRules are the organizer-configured limits applied to every
+// order placed against a competition portfolio
+type CompetitionRules struct {
+	AllowedSymbols     []string `bson:"allowed_symbols" json:"allowedSymbols"` // Empty allows every symbol
+	AllowShorting      bool     `bson:"allow_shorting" json:"allowShorting"`
+	MaxLeverage        float64  `bson:"max_leverage" json:"maxLeverage"`                // Gross exposure / equity, 0 means 1x
+	MaxPositionPercent float64  `bson:"max_position_percent" json:"maxPositionPercent"` // Max single position as % of equity, 0 disables
+	MaxTradesPerDay    int      `bson:"max_trades_per_day" json:"maxTradesPerDay"`      // 0 disables
+	StartingCash       float64  `bson:"starting_cash" json:"startingCash"`
+}
+
+type Competition struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	OrganizerID string             `bson:"organizer_id" json:"organizerId"`
+	TenantID    string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"` // Only the tenant's users see and join it
+	Rules       CompetitionRules   `bson:"rules" json:"rules"`
+	StartsAt    time.Time          `bson:"starts_at" json:"startsAt"`
+	EndsAt      time.Time          `bson:"ends_at,omitempty" json:"endsAt"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	// Set once the start or end has been announced to integrations
+	StartAnnounced bool `bson:"start_announced,omitempty" json:"-"`
+	EndAnnounced   bool `bson:"end_announced,omitempty" json:"-"`
+}
+
+// CompetitionEntry is a user's separate cash account inside a competition
+type CompetitionEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CompetitionID string             `bson:"competition_id" json:"competitionId"`
+	UserID        string             `bson:"user_id" json:"userId"`
+	TenantID      string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	CashBalance   float64            `bson:"cash_balance" json:"cashBalance"`
+	JoinedAt      time.Time          `bson:"joined_at" json:"joinedAt"`
+}
+
+// LeaderboardEntry is a competitor's standing, valued at the latest prices
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"`
+	UserID   string  `json:"userId"`
+	Username string  `json:"username"`
+	Equity   float64 `json:"equity"`
+}
+
+// LeaderboardChange is an entrant whose place changed in a live
+// leaderboard update
+type LeaderboardChange struct {
+	UserID       string  `json:"userId"`
+	Username     string  `json:"username"`
+	Rank         int     `json:"rank"`
+	PreviousRank int     `json:"previousRank"` // 0 for a new entrant
+	Equity       float64 `json:"equity"`
+}
+
+// Leaderboard is a competition's ranking at a point in time
+type Leaderboard struct {
+	Competition Competition        `json:"competition"`
+	Entries     []LeaderboardEntry `json:"entries"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CorporateAction is a dividend, split or symbol change applied to every
+// position in the symbol on its ex-date
+type CorporateAction struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key       string             `bson:"key" json:"-"` // Dedupes simulator-generated actions
+	Symbol    string             `bson:"symbol" json:"symbol"`
+	Type      string             `bson:"type" json:"type"`                                // "dividend", "split", "symbol_change"
+	Amount    float64            `bson:"amount,omitempty" json:"amount,omitempty"`        // Cash per share for dividends
+	Ratio     float64            `bson:"ratio,omitempty" json:"ratio,omitempty"`          // New shares per old share for splits
+	NewSymbol string             `bson:"new_symbol,omitempty" json:"newSymbol,omitempty"` // Target symbol for symbol changes
+	ExDate    time.Time          `bson:"ex_date" json:"exDate"`
+	Status    string             `bson:"status" json:"status"` // "pending", "applied"
+	AppliedAt time.Time          `bson:"applied_at,omitempty" json:"appliedAt"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// CorporateActionFilter narrows the corporate action feed
+type CorporateActionFilter struct {
+	Symbol string
+	Type   string
+	From   time.Time
+	To     time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TaxLot is shares opened by one fill and not closed yet. Lots of a short
+// position are shares sold short.
+type TaxLot struct {
+	Quantity   float64   `bson:"quantity" json:"quantity"`
+	Price      float64   `bson:"price" json:"price"`
+	AcquiredAt time.Time `bson:"acquired_at,omitempty" json:"acquiredAt,omitempty"` // Zero for positions opened before lots were kept
+}
+
+// CostBasisReport is the result of recomputing cost bases from executions
+type CostBasisReport struct {
+	Checked int             `json:"checked"`
+	Updated int             `json:"updated"`
+	Drift   float64         `json:"drift"` // Dollars of cost basis corrected, summed over positions
+	Skipped []CostBasisSkip `json:"skipped"`
+}
+
+// CostBasisSkip is a position whose cost basis could not be recomputed
+type CostBasisSkip struct {
+	UserID        string  `json:"userId"`
+	Symbol        string  `json:"symbol"`
+	CompetitionID string  `json:"competitionId,omitempty"`
+	Shares        float64 `json:"shares"`
+	Replayed      float64 `json:"replayed"` // Shares the executions add up to
+	Reason        string  `json:"reason"`
+}
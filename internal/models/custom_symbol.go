@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CustomSymbol is a fictional ticker the simulator prices from user-chosen
+// parameters
+type CustomSymbol struct {
+	Symbol     string    `bson:"_id" json:"symbol"`
+	Name       string    `bson:"name" json:"name"`
+	BasePrice  float64   `bson:"base_price" json:"basePrice"`  // Price of the first tick
+	Volatility float64   `bson:"volatility" json:"volatility"` // Largest random move per tick, percent
+	Drift      float64   `bson:"drift" json:"drift"`           // Constant move added to every tick, percent
+	CreatedBy  string    `bson:"created_by" json:"createdBy"`  // User ID
+	TenantID   string    `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"createdAt"`
+}
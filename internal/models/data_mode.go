@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// DataModeSettings is the deployment's price feed, changed at runtime by
+// platform admins
+type DataModeSettings struct {
+	Mode      string    `bson:"mode" json:"mode"` // "mock", "delayed" or "hybrid"
+	UpdatedBy string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DelayedQuote is a real quote as fetched from Alpha Vantage. It is only
+// served once it is older than the quote delay.
+type DelayedQuote struct {
+	Symbol        string    `bson:"symbol" json:"symbol"`
+	Price         float64   `bson:"price" json:"price"`
+	Change        float64   `bson:"change" json:"change"`
+	ChangePercent float64   `bson:"change_percent" json:"changePercent"`
+	FetchedAt     time.Time `bson:"fetched_at" json:"fetchedAt"`
+}
+
+// QuoteDelay tells clients how old a delayed quote is
+type QuoteDelay struct {
+	Minutes    int       `json:"minutes"` // Quotes are held back this long before they are served
+	AsOf       time.Time `json:"asOf"`    // When the quote was fetched
+	AgeSeconds int64     `json:"ageSeconds"`
+	Stale      bool      `json:"stale"` // A newer quote was due but none arrived; refreshes are failing
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Device is a phone registered for push notifications
+type Device struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"userId"`
+	Platform    string             `bson:"platform" json:"platform"` // "android" (FCM) or "ios" (APNs)
+	Token       string             `bson:"token" json:"token"`
+	Name        string             `bson:"name,omitempty" json:"name,omitempty"`
+	Preferences map[string]bool    `bson:"preferences,omitempty" json:"preferences"` // Notification type -> wanted; unset types use the defaults
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+}
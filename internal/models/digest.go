@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Digest summarizes a user's account over the period since their previous
+// digest email
+type Digest struct {
+	Username          string              `json:"username"`
+	Frequency         string              `json:"frequency"` // "daily" or "weekly"
+	From              time.Time           `json:"from"`
+	To                time.Time           `json:"to"`
+	Equity            float64             `json:"equity"`
+	ProfitLoss        float64             `json:"profitLoss"` // Equity change since the previous digest, or today's change for the first one
+	ProfitLossPercent float64             `json:"profitLossPercent"`
+	Positions         []PositionDayChange `json:"positions"`
+	Fills             []Execution         `json:"fills"`
+	FillCount         int64               `json:"fillCount"` // Fills lists at most the latest 50
+	TriggeredStops    []Order             `json:"triggeredStops"`
+	Earnings          []EarningsEvent     `json:"earnings"` // Upcoming reports for held symbols
+}
+
+// EarningsEvent is a scheduled earnings report of a held symbol
+type EarningsEvent struct {
+	Symbol string    `json:"symbol"`
+	Date   time.Time `json:"date"`
+	Shares float64   `json:"shares"`
+}
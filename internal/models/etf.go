@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ETF is a synthetic fund priced every tick from a fixed number of shares
+// of each constituent, like the creation unit of a real ETF
+type ETF struct {
+	Symbol    string       `bson:"_id" json:"symbol"`
+	Name      string       `bson:"name" json:"name"`
+	BasePrice float64      `bson:"base_price" json:"basePrice"` // Price when created; the shares are sized to it
+	Holdings  []ETFHolding `bson:"holdings" json:"holdings"`
+	CreatedBy string       `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+	CreatedAt time.Time    `bson:"created_at" json:"createdAt"`
+	// Corporate actions of constituents already applied to the holdings
+	AppliedActions []string `bson:"applied_actions,omitempty" json:"-"`
+}
+
+// ETFHolding is one constituent of an ETF
+type ETFHolding struct {
+	Symbol string  `bson:"symbol" json:"symbol"`
+	Weight float64 `bson:"weight" json:"weight"` // Target fraction of the fund at creation; weights sum to 1
+	Shares float64 `bson:"shares" json:"shares"` // Held per ETF share
+	// Filled in for holdings responses
+	Price         float64 `bson:"-" json:"price,omitempty"`
+	Value         float64 `bson:"-" json:"value,omitempty"`         // Shares times price
+	CurrentWeight float64 `bson:"-" json:"currentWeight,omitempty"` // Share of the fund's value now
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Execution is the immutable record of one fill. It is written with the fill
+// and never updated, so P&L and exports can be rebuilt from executions alone.
+type Execution struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	TradeID          string             `bson:"trade_id" json:"tradeId"` // Unique, also stored on the order
+	OrderID          string             `bson:"order_id" json:"orderId"`
+	UserID           string             `bson:"user_id" json:"userId"`
+	Symbol           string             `bson:"symbol" json:"symbol"`
+	Side             string             `bson:"side" json:"side"` // "buy" or "sell"
+	Quantity         float64            `bson:"quantity" json:"quantity"`
+	Price            float64            `bson:"price" json:"price"`
+	Fees             float64            `bson:"fees" json:"fees"`
+	RequestedPrice   float64            `bson:"requested_price,omitempty" json:"requestedPrice,omitempty"` // Submitted price, or the limit for limit orders
+	PriceImprovement float64            `bson:"price_improvement" json:"priceImprovement"`                 // Dollars the fill at the best bid or ask saved against RequestedPrice; negative is slippage
+	Liquidity        string             `bson:"liquidity" json:"liquidity"`                                // "maker" for fills of orders that rested until triggered or activated, "drip" for dividend reinvestments, "seed" for positions a teacher placed, "taker" otherwise
+	CompetitionID    string             `bson:"competition_id,omitempty" json:"competitionId,omitempty"`
+	TenantID         string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	StrategyID       string             `bson:"strategy_id,omitempty" json:"strategyId,omitempty"`
+	ExecutedAt       time.Time          `bson:"executed_at" json:"executedAt"`
+}
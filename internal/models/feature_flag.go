@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FeatureFlag gates a feature. RolloutPercent limits an enabled flag to a
+// stable subset of users; 100 enables it for everyone.
+type FeatureFlag struct {
+	Name           string    `bson:"name" json:"name"`
+	Enabled        bool      `bson:"enabled" json:"enabled"`
+	RolloutPercent int       `bson:"rollout_percent" json:"rolloutPercent"`
+	Description    string    `bson:"description" json:"description"`
+	UpdatedAt      time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+	UpdatedBy      string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Fundamentals holds company metrics from an external data provider
+type Fundamentals struct {
+	Symbol             string    `json:"symbol"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	Exchange           string    `json:"exchange"`
+	Currency           string    `json:"currency"`
+	Sector             string    `json:"sector"`
+	Industry           string    `json:"industry"`
+	MarketCap          float64   `json:"marketCap"`
+	PERatio            float64   `json:"peRatio"`
+	PEGRatio           float64   `json:"pegRatio"`
+	EPS                float64   `json:"eps"`
+	BookValue          float64   `json:"bookValue"`
+	DividendPerShare   float64   `json:"dividendPerShare"`
+	DividendYield      float64   `json:"dividendYield"`
+	ProfitMargin       float64   `json:"profitMargin"`
+	Beta               float64   `json:"beta"`
+	High52Week         float64   `json:"high52Week"`
+	Low52Week          float64   `json:"low52Week"`
+	AnalystTargetPrice float64   `json:"analystTargetPrice"`
+	Source             string    `json:"source"`
+	FetchedAt          time.Time `json:"fetchedAt"`
+	Stale              bool      `json:"stale"` // Served from an expired cache entry after a provider error
+}
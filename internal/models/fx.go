@@ -0,0 +1,24 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// FXRate converts US dollars, the currency accounts are kept in, to the
+// currency a response is shown in
+type FXRate struct {
+	Base     string    `json:"base"` // Always "USD"
+	Currency string    `json:"currency"`
+	Rate     float64   `json:"rate"`   // Units of Currency per dollar
+	Source   string    `json:"source"` // "simulated", the provider's name, or "fixed" for dollars
+	AsOf     time.Time `json:"asOf"`
+}
+
+// Convert returns a dollar amount in the rate's currency, to 4 decimals
+func (r FXRate) Convert(amount float64) float64 {
+	if r.Rate == 0 || r.Rate == 1 {
+		return amount
+	}
+	return math.Round(amount*r.Rate*10000) / 10000
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InboxMessage is a WebSocket message kept for a user who had no open
+// socket to take it. It is sent when they next connect, and stays listed
+// until it expires.
+type InboxMessage struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID      string                 `bson:"user_id" json:"-"`
+	Type        string                 `bson:"type" json:"type"` // The message's own type, such as "order_filled" or "achievement"
+	Message     map[string]interface{} `bson:"message" json:"message"`
+	CreatedAt   time.Time              `bson:"created_at" json:"createdAt"`
+	DeliveredAt time.Time              `bson:"delivered_at,omitempty" json:"deliveredAt,omitempty"` // Sent over a socket on connect
+	ReadAt      time.Time              `bson:"read_at,omitempty" json:"readAt,omitempty"`
+}
+
+// DeliveryStats count the messages sent to individual users over the
+// WebSocket hub since startup
+type DeliveryStats struct {
+	Delivered uint64 `json:"delivered"` // Queued on at least one of the user's sockets
+	Dropped   uint64 `json:"dropped"`   // The user was connected but every socket's buffer was full
+	Offline   uint64 `json:"offline"`   // The user had no open socket
+	Inboxed   uint64 `json:"inboxed"`   // Kept in the inbox after not being delivered
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is an outgoing integration that posts platform events to Slack,
+// Discord or any endpoint accepting JSON
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Kind      string             `bson:"kind" json:"kind"` // "slack", "discord" or "generic"
+	URL       string             `bson:"url" json:"url"`
+	Events    []string           `bson:"events" json:"events"`                           // Event topics delivered; empty for all
+	Templates map[string]string  `bson:"templates,omitempty" json:"templates,omitempty"` // Topic -> message template replacing the default
+	Enabled   bool               `bson:"enabled" json:"enabled"`
+	Secret    string             `bson:"secret,omitempty" json:"-"` // Signs generic webhook bodies with HMAC-SHA256; encrypted at rest
+	CreatedBy string             `bson:"created_by" json:"createdBy"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// WebhookDelivery is one message queued for a webhook. Failed posts are
+// retried with backoff until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID     string             `bson:"webhook_id" json:"webhookId"`
+	Event         string             `bson:"event" json:"event"`
+	Message       string             `bson:"message" json:"message"`
+	Body          string             `bson:"body" json:"-"`        // JSON posted to the webhook
+	Status        string             `bson:"status" json:"status"` // "pending", "delivered" or "failed"
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"` // Also the lease while a post is in flight
+	LastError     string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	DeliveredAt   time.Time          `bson:"delivered_at,omitempty" json:"deliveredAt,omitempty"`
+}
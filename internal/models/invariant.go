@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// InvariantReport is the result of checking that accounts add up to their
+// history
+type InvariantReport struct {
+	CheckedAccounts  int                   `json:"checkedAccounts"`
+	CheckedPositions int                   `json:"checkedPositions"`
+	Accounts         []AccountDiscrepancy  `json:"accounts"`
+	Positions        []PositionDiscrepancy `json:"positions"`
+	CheckedAt        time.Time             `json:"checkedAt"`
+}
+
+// AccountDiscrepancy is an account whose cash does not match what its
+// starting balance, credits and trades leave. Cash plus the cost of open
+// positions, less realized P&L, plus fees should equal the starting balance
+// plus credits.
+type AccountDiscrepancy struct {
+	UserID          string  `json:"userId"`
+	Username        string  `json:"username,omitempty"`
+	CompetitionID   string  `json:"competitionId,omitempty"` // Empty for the main account
+	Cash            float64 `json:"cash"`
+	Expected        float64 `json:"expected"`
+	Difference      float64 `json:"difference"`  // Cash less Expected
+	JournalCash     float64 `json:"journalCash"` // What the journal's cash account sums to
+	StartingBalance float64 `json:"startingBalance"`
+	AssumedStart    bool    `json:"assumedStart,omitempty"` // The account predates stored starting balances; the group's default was used
+	Credits         float64 `json:"credits"`                // Ledger entries and referral bonuses
+	Bought          float64 `json:"bought"`                 // Cost of buys, before fees
+	Sold            float64 `json:"sold"`                   // Proceeds of sells, before fees
+	Fees            float64 `json:"fees"`
+	Seeded          float64 `json:"seeded"`      // Cost of positions a teacher placed in the account
+	OpenCost        float64 `json:"openCost"`    // Cost of open positions; negative for shorts
+	RealizedPnL     float64 `json:"realizedPnl"` // Sold less Bought and Seeded, plus OpenCost
+}
+
+// PositionDiscrepancy is a position its executions do not add up to
+type PositionDiscrepancy struct {
+	UserID          string  `json:"userId"`
+	Symbol          string  `json:"symbol"`
+	CompetitionID   string  `json:"competitionId,omitempty"`
+	Shares          float64 `json:"shares"`
+	ReplayedShares  float64 `json:"replayedShares"`
+	AvgCost         float64 `json:"avgCost"`
+	ReplayedAvgCost float64 `json:"replayedAvgCost"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// JournalEntry is one balanced double-entry transaction in an account.
+// Every movement of money posts one: a fill, a ledger entry, a bonus or an
+// account opening. Debits are positive and credits negative, so the
+// postings of an entry add up to zero and an account's balances are the
+// sums of its postings.
+type JournalEntry struct {
+	ID            string    `bson:"_id" json:"id"` // Derived from what caused it, such as the trade ID, so it is posted once
+	UserID        string    `bson:"user_id" json:"userId"`
+	Type          string    `bson:"type" json:"type"` // "opening", "trade", "drip", "reset", "referral", "restatement" or the ledger entry type
+	Symbol        string    `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Description   string    `bson:"description" json:"description"`
+	Postings      []Posting `bson:"postings" json:"postings"`
+	CompetitionID string    `bson:"competition_id,omitempty" json:"competitionId,omitempty"` // Empty for the main account
+	TenantID      string    `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	CreatedAt     time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// Posting moves an amount into or out of one ledger account
+type Posting struct {
+	Account string  `bson:"account" json:"account"` // "cash", "positions", "fees", "dividends", "interest", "realized_pnl" or "capital"
+	Amount  float64 `bson:"amount" json:"amount"`   // Positive debits, negative credits
+}
+
+// JournalBalances are an account's ledger account balances, summed from its
+// journal, next to the cash balance stored on the account
+type JournalBalances struct {
+	UserID        string             `json:"userId"`
+	CompetitionID string             `json:"competitionId,omitempty"`
+	Balances      map[string]float64 `json:"balances"`
+	CachedCash    float64            `json:"cachedCash"`
+	Entries       int                `json:"entries"`
+}
+
+// MaterializeReport is the result of rebuilding stored cash balances from
+// the journal
+type MaterializeReport struct {
+	Checked   int     `json:"checked"`
+	Opened    int     `json:"opened"`    // Accounts given an opening entry for the balance they had before the journal
+	Corrected int     `json:"corrected"` // Accounts whose stored cash differed from the journal
+	Drift     float64 `json:"drift"`     // Dollars corrected, summed over accounts
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LedgerEntry is one cash movement in a user's account that did not come
+// from a trade
+type LedgerEntry struct {
+	ID            string    `bson:"_id" json:"id"`
+	UserID        string    `bson:"user_id" json:"userId"`
+	Type          string    `bson:"type" json:"type"`                     // "cash_interest", "margin_interest", "borrow_fee", "dividend", "cash_in_lieu", "deposit" or "withdrawal"
+	Amount        float64   `bson:"amount" json:"amount"`                 // Positive credits, negative debits
+	Balance       float64   `bson:"balance" json:"balance"`               // Balance or position value the amount was computed on
+	Rate          float64   `bson:"rate,omitempty" json:"rate,omitempty"` // Annual percent
+	Description   string    `bson:"description" json:"description"`
+	CompetitionID string    `bson:"competition_id,omitempty" json:"competitionId,omitempty"` // Empty for the main account
+	TenantID      string    `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	CreatedAt     time.Time `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MaintenanceState describes a read-only trading freeze
+type MaintenanceState struct {
+	Enabled   bool      `bson:"enabled" json:"enabled"`
+	Message   string    `bson:"message" json:"message"`
+	EndsAt    time.Time `bson:"ends_at,omitempty" json:"endsAt,omitempty"` // Scheduled end, informational only
+	StartedAt time.Time `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	UpdatedBy string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// MarketClockStatus is the trading session now and when it next changes
+type MarketClockStatus struct {
+	Hours     string        `json:"hours"`   // "always" or "regular"
+	Session   string        `json:"session"` // "regular", "extended" or "closed"
+	Timezone  string        `json:"timezone"`
+	NextOpen  time.Time     `json:"nextOpen,omitempty"`  // Start of the next regular session, while it is not open
+	NextClose time.Time     `json:"nextClose,omitempty"` // End of the current regular session
+	Policy    string        `json:"policy"`              // What happens to orders while closed: "reject" or "queue"
+	Halts     []TradingHalt `json:"halts"`
+	Time      time.Time     `json:"time"`
+}
+
+// TradingHalt stops trading in one symbol until an admin lifts it or, when
+// set, ResumeAt passes
+type TradingHalt struct {
+	Symbol   string    `bson:"_id" json:"symbol"`
+	Reason   string    `bson:"reason" json:"reason"`
+	ResumeAt time.Time `bson:"resume_at,omitempty" json:"resumeAt,omitempty"`
+	HaltedBy string    `bson:"halted_by,omitempty" json:"haltedBy,omitempty"`
+	HaltedAt time.Time `bson:"halted_at" json:"haltedAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// MarketStreamStatus describes the connection to a real-time market data
+// provider for admins
+type MarketStreamStatus struct {
+	Provider    string    `json:"provider"`
+	Connected   bool      `json:"connected"`
+	Symbols     []string  `json:"symbols"`
+	ConnectedAt time.Time `json:"connectedAt,omitempty"`
+	LastTradeAt time.Time `json:"lastTradeAt,omitempty"`
+	Trades      uint64    `json:"trades"`     // Trades received, snapshots included
+	Ticks       uint64    `json:"ticks"`      // Ticks published, at most one per symbol per interval
+	Stale       uint64    `json:"stale"`      // Trades older than the symbol's latest, dropped
+	Reconnects  uint64    `json:"reconnects"` // Connections after the first
+	GapsFilled  uint64    `json:"gapsFilled"` // Symbols brought up to date from a snapshot after a reconnect
+	LastError   string    `json:"lastError,omitempty"`
+}
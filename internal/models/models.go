@@ -6,35 +6,82 @@ import (
 )
 
 type Stock struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Symbol    string             `bson:"symbol" json:"symbol"`
-	Name      string             `bson:"name" json:"name"`
-	Price     float64            `bson:"price" json:"price"`
-	Change    float64            `bson:"change" json:"change"`
-	ChangePercent float64        `bson:"change_percent" json:"changePercent"`
-	Volume    int64              `bson:"volume" json:"volume"`
-	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	Name          string             `bson:"name" json:"name"`
+	Price         float64            `bson:"price" json:"price"`
+	Change        float64            `bson:"change" json:"change"`
+	ChangePercent float64            `bson:"change_percent" json:"changePercent"`
+	Volume        int64              `bson:"volume" json:"volume"`
+	Timestamp     time.Time          `bson:"timestamp" json:"timestamp"`
+	Stats         *SymbolStats       `bson:"-" json:"stats,omitempty"`    // Set on quotes, not on streamed ticks
+	Source        string             `bson:"-" json:"source,omitempty"`   // "simulated", "delayed" (a delayed real quote) or "streamed" (a real-time trade)
+	DataMode      string             `bson:"-" json:"dataMode,omitempty"` // Mode the quote was served in; set on quotes, not on streamed ticks
+	Delay         *QuoteDelay        `bson:"-" json:"delay,omitempty"`    // Set on delayed quotes
 }
 
 type Order struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID          string             `bson:"user_id" json:"userId"`
-	Symbol          string             `bson:"symbol" json:"symbol"`
-	Type            string             `bson:"type" json:"type"`                         // "buy" or "sell"
-	OrderType       string             `bson:"order_type" json:"orderType"`             // "market", "limit", "stop", "stop_limit", "trailing_stop"
-	Quantity        int                `bson:"quantity" json:"quantity"`
-	Price           float64            `bson:"price" json:"price"`                      // Execution price for market/limit, limit price for stop-limit
-	StopPrice       float64            `bson:"stop_price,omitempty" json:"stopPrice"`   // Trigger price for stop orders
-	LimitPrice      float64            `bson:"limit_price,omitempty" json:"limitPrice"` // Limit price for stop-limit orders
-	TrailingPercent float64            `bson:"trailing_percent,omitempty" json:"trailingPercent"`
-	Status          string             `bson:"status" json:"status"` // "pending", "filled", "cancelled", "active", "triggered"
-	Timestamp       time.Time          `bson:"timestamp" json:"timestamp"`
-	TriggeredAt     time.Time          `bson:"triggered_at,omitempty" json:"triggeredAt"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID           string             `bson:"user_id" json:"userId"`
+	Symbol           string             `bson:"symbol" json:"symbol"`
+	Type             string             `bson:"type" json:"type"`                                              // "buy" or "sell"
+	OrderType        string             `bson:"order_type" json:"orderType"`                                   // "market", "limit", "stop", "stop_limit", "trailing_stop"
+	Quantity         float64            `bson:"quantity" json:"quantity"`                                      // Shares, to 4 decimals
+	Notional         float64            `bson:"notional,omitempty" json:"notional,omitempty"`                  // Dollar amount the quantity was computed from
+	Price            float64            `bson:"price" json:"price"`                                            // Execution price for market/limit, limit price for stop-limit
+	RequestedPrice   float64            `bson:"requested_price,omitempty" json:"requestedPrice,omitempty"`     // Price submitted with a market order, or the limit; Price is what it filled at
+	PriceImprovement float64            `bson:"price_improvement,omitempty" json:"priceImprovement,omitempty"` // Dollars the fill beat RequestedPrice by; negative is slippage
+	StopPrice        float64            `bson:"stop_price,omitempty" json:"stopPrice"`                         // Trigger price for stop orders
+	LimitPrice       float64            `bson:"limit_price,omitempty" json:"limitPrice"`                       // Limit price for stop-limit orders
+	TrailingPercent  float64            `bson:"trailing_percent,omitempty" json:"trailingPercent"`
+	HighWaterMark    float64            `bson:"high_water_mark,omitempty" json:"highWaterMark,omitempty"`  // Best price seen by a trailing stop
+	OCOGroup         string             `bson:"oco_group,omitempty" json:"ocoGroup,omitempty"`             // Orders in a group cancel each other when one triggers
+	ReservedAmount   float64            `bson:"reserved_amount,omitempty" json:"reservedAmount,omitempty"` // Cash held while an open buy order is active
+	Status           string             `bson:"status" json:"status"`                                      // "pending", "filled", "cancelled", "scheduled", "active", "triggering", "triggered", "failed"
+	Timestamp        time.Time          `bson:"timestamp" json:"timestamp"`
+	TriggeredAt      time.Time          `bson:"triggered_at,omitempty" json:"triggeredAt"`
+	ActivateAt       time.Time          `bson:"activate_at,omitempty" json:"activateAt,omitempty"`     // Scheduled orders go live at this time
+	Condition        *OrderCondition    `bson:"condition,omitempty" json:"condition,omitempty"`        // Holds the order back until another symbol's price condition is met
+	ExpiresAt        time.Time          `bson:"expires_at,omitempty" json:"expiresAt,omitempty"`       // Resting orders are cancelled once this passes
+	CancelReason     string             `bson:"cancel_reason,omitempty" json:"cancelReason,omitempty"` // "user", "oco", "expired", "no_position", "account_reset" or "account_closed"
+	FailReason       string             `bson:"fail_reason,omitempty" json:"failReason,omitempty"`     // Message code of why a triggered order did not fill
+	FailMessage      string             `bson:"fail_message,omitempty" json:"failMessage,omitempty"`
+	TradeID          string             `bson:"trade_id,omitempty" json:"tradeId,omitempty"`         // Execution record of the fill
+	PriceSource      string             `bson:"price_source,omitempty" json:"priceSource,omitempty"` // "simulated" or "delayed": the quote the fill was priced from
+	Fees             float64            `bson:"fees,omitempty" json:"fees,omitempty"`
+	FilledQuantity   float64            `bson:"filled_quantity,omitempty" json:"filledQuantity,omitempty"` // Set when a sell stop was cut down to the shares left
+	CompetitionID    string             `bson:"competition_id,omitempty" json:"competitionId,omitempty"`   // Empty for the main account
+	TenantID         string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`             // Empty when tenancy is off
+	StrategyID       string             `bson:"strategy_id,omitempty" json:"strategyId,omitempty"`         // Set on orders placed by a live strategy run
+	BasketID         string             `bson:"basket_id,omitempty" json:"basketId,omitempty"`             // Set on the legs of a basket order
+	ParentOrderID    string             `bson:"parent_order_id,omitempty" json:"parentOrderId,omitempty"`  // The stop order a triggered market order fills
+	ExtendedHours    bool               `bson:"extended_hours,omitempty" json:"extendedHours,omitempty"`   // May fill pre-market and after-hours when MARKET_HOURS is regular
+	AllowDuplicate   bool               `bson:"-" json:"-"`                                                // Submitted with allowDuplicate to confirm a repeat of an identical order
 }
+
+// OrderCondition holds an order back until a symbol's last price compares
+// with Value as Op says, as in "buy TSLA if SPY > 450". Expression can be
+// submitted instead of the other fields; it is not stored.
+type OrderCondition struct {
+	Symbol     string  `bson:"symbol" json:"symbol"`
+	Op         string  `bson:"op" json:"op"` // ">", ">=", "<" or "<="
+	Value      float64 `bson:"value" json:"value"`
+	Expression string  `bson:"-" json:"expression,omitempty"` // e.g. "SPY > 450"
+}
+
 type Portfolio struct {
-	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID  string             `bson:"user_id" json:"userId"`
-	Symbol  string             `bson:"symbol" json:"symbol"`
-	Shares  int                `bson:"shares" json:"shares"`
-	AvgCost float64            `bson:"avg_cost" json:"avgCost"`
-}
\ No newline at end of file
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"userId"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	Shares        float64            `bson:"shares" json:"shares"` // Negative for short positions
+	AvgCost       float64            `bson:"avg_cost" json:"avgCost"`
+	CompetitionID string             `bson:"competition_id,omitempty" json:"competitionId,omitempty"` // Empty for the main account
+	TenantID      string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	DRIP          bool               `bson:"drip,omitempty" json:"drip"`           // Reinvest dividends in the same symbol instead of paying cash
+	Lots          []TaxLot           `bson:"lots,omitempty" json:"lots,omitempty"` // Open lots, oldest first
+	// Corporate actions already applied, so each is applied once
+	AppliedActions []string `bson:"applied_actions,omitempty" json:"-"`
+	// Move since the session open, filled in for portfolio responses
+	DayChange        float64 `bson:"-" json:"dayChange"`
+	DayChangePercent float64 `bson:"-" json:"dayChangePercent"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// MyActivity is everything a user has done in one symbol in one account
+type MyActivity struct {
+	Symbol               string      `json:"symbol"`
+	CompetitionID        string      `json:"competitionId,omitempty"`
+	Position             *Portfolio  `json:"position"` // Nil when no shares are held
+	Price                float64     `json:"price,omitempty"`
+	MarketValue          float64     `json:"marketValue"`
+	UnrealizedProfitLoss float64     `json:"unrealizedProfitLoss"`
+	RealizedProfitLoss   float64     `json:"realizedProfitLoss"` // From closing fills, before fees
+	Fees                 float64     `json:"fees"`
+	OpenOrders           []Order     `json:"openOrders"`
+	Executions           []Execution `json:"executions"` // Newest first
+}
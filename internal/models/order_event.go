@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderEvent is one step in an order's life. Events are only ever
+// appended; the order document is their projection, and replaying them
+// gives the order as it stood at any time.
+type OrderEvent struct {
+	ID       primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	OrderID  string                 `bson:"order_id" json:"orderId"`
+	UserID   string                 `bson:"user_id" json:"userId"`
+	Type     string                 `bson:"type" json:"type"`                             // "created", "activated", "amended", "triggered", "partially_filled", "filled", "failed" or "cancelled"
+	Changes  map[string]interface{} `bson:"changes,omitempty" json:"changes,omitempty"`   // Fields the event set, by their stored names
+	Snapshot *Order                 `bson:"snapshot,omitempty" json:"snapshot,omitempty"` // The whole order, on the event that created it
+	Cause    string                 `bson:"cause,omitempty" json:"cause,omitempty"`       // What made the change when it was not the user, e.g. a corporate action ID
+	At       time.Time              `bson:"at" json:"at"`
+}
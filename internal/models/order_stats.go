@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// OrderStatsFilter narrows and buckets a user's order statistics
+type OrderStatsFilter struct {
+	From     time.Time
+	To       time.Time
+	Bucket   string         // "day", "week" or "month"
+	Location *time.Location // Buckets follow this timezone's calendar
+}
+
+// OrderStats summarizes a user's orders
+type OrderStats struct {
+	TotalOrders int64            `json:"totalOrders"`
+	ByStatus    map[string]int64 `json:"byStatus"`
+	BySide      map[string]int64 `json:"bySide"`      // "buy" and "sell"
+	ByOrderType map[string]int64 `json:"byOrderType"` // "market", "limit", ...
+	BySymbol    []SymbolActivity `json:"bySymbol"`    // Most orders first; volume is the traded value
+	TradedValue float64          `json:"tradedValue"` // Notional value of filled orders
+	Bucket      string           `json:"bucket"`
+	Timezone    string           `json:"timezone"`
+	Buckets     []OrderBucket    `json:"buckets"`      // Every period with orders, oldest first
+	BusiestDays []OrderBucket    `json:"busiestDays"`  // Days with the most orders
+	FX          *FXRate          `json:"fx,omitempty"` // Rate the traded values were converted at
+}
+
+// OrderBucket is the orders in one period
+type OrderBucket struct {
+	Period      string  `bson:"_id" json:"period"` // "2026-01-05", "2026-W02" or "2026-01"
+	Orders      int64   `bson:"orders" json:"orders"`
+	Filled      int64   `bson:"filled" json:"filled"`
+	TradedValue float64 `bson:"traded_value" json:"tradedValue"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxEvent is an event stored alongside the write that produced it and
+// delivered to the event bus by the outbox dispatcher
+type OutboxEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Topic       string             `bson:"topic"`
+	UserID      string             `bson:"user_id"`
+	Payload     bson.Raw           `bson:"payload"`
+	Status      string             `bson:"status"` // "pending", "delivered" or "dead" once it ran out of attempts
+	Attempts    int                `bson:"attempts"`
+	LastError   string             `bson:"last_error,omitempty"`
+	LockedUntil time.Time          `bson:"locked_until"` // Lease held by the dispatcher delivering it
+	CreatedAt   time.Time          `bson:"created_at"`
+	DeliveredAt time.Time          `bson:"delivered_at,omitempty"`
+	DeadAt      time.Time          `bson:"dead_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// LoadTestReport summarizes a cmd/loadgen run against a live server
+type LoadTestReport struct {
+	Users           int           `json:"users"`
+	Subscribers     int           `json:"subscribers"`
+	Duration        float64       `json:"durationSeconds"`
+	OrdersPlaced    int64         `json:"ordersPlaced"`
+	OrdersFailed    int64         `json:"ordersFailed"`
+	OrdersPerSecond float64       `json:"ordersPerSecond"`
+	OrderLatency    LatencyReport `json:"orderLatency"`
+	TicksReceived   int64         `json:"ticksReceived"`
+	TicksPerSecond  float64       `json:"ticksPerSecond"`
+	SubscriberDrops int64         `json:"subscriberDrops"` // Subscribers disconnected before the run ended
+	Label           string        `json:"label,omitempty"` // Free-form tag such as a commit hash
+	ReceivedAt      time.Time     `json:"receivedAt"`
+}
+
+// LatencyReport holds latency percentiles in milliseconds
+type LatencyReport struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// PerformanceMetrics is the payload of the admin metrics endpoint
+type PerformanceMetrics struct {
+	UptimeSeconds         int64            `json:"uptimeSeconds"`
+	Goroutines            int              `json:"goroutines"`
+	HeapAllocBytes        uint64           `json:"heapAllocBytes"`
+	HeapObjects           uint64           `json:"heapObjects"`
+	GCCycles              uint32           `json:"gcCycles"`
+	GCPauseTotalNs        uint64           `json:"gcPauseTotalNs"`
+	WebSocketConnections  int              `json:"webSocketConnections"`
+	WebSocketTicksDropped uint64           `json:"webSocketTicksDropped"` // Ticks discarded because the hub fell behind
+	WebSocketDelivery     DeliveryStats    `json:"webSocketDelivery"`     // Messages sent to individual users
+	TickLogDropped        int64            `json:"tickLogDropped"`        // Ticks not kept in the tick log because Mongo fell behind
+	LoadTests             []LoadTestReport `json:"loadTests"`             // Most recent first
+	GeneratedAt           time.Time        `json:"generatedAt"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TickSample is a symbol's market state recorded at intervals for playback
+type TickSample struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	Price         float64            `bson:"price" json:"price"`
+	Change        float64            `bson:"change" json:"change"`
+	ChangePercent float64            `bson:"change_percent" json:"changePercent"`
+	Volume        int64              `bson:"volume" json:"volume"`
+	Timestamp     time.Time          `bson:"timestamp" json:"timestamp"`
+}
+
+// PlaybackFill is an execution as replayed to reviewers, without the account
+// or order it belongs to
+type PlaybackFill struct {
+	Symbol        string    `json:"symbol"`
+	Side          string    `json:"side"`
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	Liquidity     string    `json:"liquidity"`
+	CompetitionID string    `json:"competitionId,omitempty"`
+	ExecutedAt    time.Time `json:"executedAt"`
+}
+
+// PlaybackEvent is one step of a replayed period, either a tick or a fill
+type PlaybackEvent struct {
+	Type string        `json:"type"` // "tick" or "fill"
+	Time time.Time     `json:"time"`
+	Tick *TickSample   `json:"tick,omitempty"`
+	Fill *PlaybackFill `json:"fill,omitempty"`
+}
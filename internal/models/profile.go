@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// PublicProfile is what other traders see of a user who made their profile
+// public
+type PublicProfile struct {
+	Username      string    `json:"username"`
+	JoinedAt      time.Time `json:"joinedAt"`
+	Public        bool      `json:"public"`             // False only when viewing your own private profile
+	ReturnPercent float64   `json:"returnPercent"`      // Main account equity against the starting balance
+	Rank          int       `json:"rank,omitempty"`     // By return among public profiles in the tenant; 0 while private
+	RankedOf      int       `json:"rankedOf,omitempty"` // Number of public profiles ranked
+	Badges        []Badge   `json:"badges"`
+}
+
+// Badge is an unlocked achievement as shown on a public profile
+type Badge struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlockedAt"`
+}
+
+// ProfileSummary is one user search result
+type ProfileSummary struct {
+	Username string    `bson:"username" json:"username"`
+	JoinedAt time.Time `bson:"created_at" json:"joinedAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Referral records a user who registered with another user's invite code
+type Referral struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReferrerID      string             `bson:"referrer_id" json:"referrerId"`
+	RefereeID       string             `bson:"referee_id" json:"refereeId"`
+	RefereeUsername string             `bson:"referee_username" json:"refereeUsername"`
+	Code            string             `bson:"code" json:"code"`
+	Bonus           float64            `bson:"bonus" json:"bonus"` // Credited to each party, 0 when a limit was hit
+	Note            string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
+}
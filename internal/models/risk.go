@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// RiskMetrics summarizes the market risk of a user's portfolio, computed
+// from returns between recorded simulator ticks, scaled to simulated days
+type RiskMetrics struct {
+	Equity               float64        `json:"equity"`
+	PositionsValue       float64        `json:"positionsValue"`
+	Beta                 float64        `json:"beta"`                 // Versus the equal-weighted simulated stock index
+	Volatility           float64        `json:"volatility"`           // 1-day standard deviation of returns, percent
+	AnnualizedVolatility float64        `json:"annualizedVolatility"` // Percent, over 252 simulated days
+	VaR95                float64        `json:"var95"`                // 1-day 95% historical value at risk, dollars
+	VaR95Percent         float64        `json:"var95Percent"`
+	Samples              int            `json:"samples"` // Number of returns used
+	Positions            []PositionRisk `json:"positions"`
+	Margin               *MarginSummary `json:"margin,omitempty"` // Only for accounts with margin
+	FX                   *FXRate        `json:"fx,omitempty"`     // Rate the amounts were converted at
+	CalculatedAt         time.Time      `json:"calculatedAt"`
+}
+
+// PositionRisk is a single position's share of portfolio risk
+type PositionRisk struct {
+	Symbol              string  `json:"symbol"`
+	Shares              float64 `json:"shares"`
+	Value               float64 `json:"value"`
+	Weight              float64 `json:"weight"` // Percent of equity, negative for shorts
+	Beta                float64 `json:"beta"`
+	Volatility          float64 `json:"volatility"`          // 1-day, percent
+	RiskContribution    float64 `json:"riskContribution"`    // Share of portfolio volatility, percent points
+	RiskContributionPct float64 `json:"riskContributionPct"` // Percent of total portfolio risk
+	HasHistory          bool    `json:"hasHistory"`
+}
+
+// StressTestResult projects the portfolio under hypothetical price shocks
+type StressTestResult struct {
+	Shocks            map[string]float64 `json:"shocks"` // Percent move keyed by sector or symbol
+	CurrentValue      float64            `json:"currentValue"`
+	ProjectedValue    float64            `json:"projectedValue"`
+	ProfitLoss        float64            `json:"profitLoss"`
+	ProfitLossPercent float64            `json:"profitLossPercent"`
+	CurrentMargin     MarginSummary      `json:"currentMargin"`
+	ProjectedMargin   MarginSummary      `json:"projectedMargin"`
+	Positions         []PositionStress   `json:"positions"`
+	FX                *FXRate            `json:"fx,omitempty"` // Rate the amounts were converted at
+}
+
+// PositionStress is a single position's projected move
+type PositionStress struct {
+	Symbol         string  `json:"symbol"`
+	Sector         string  `json:"sector"`
+	Shares         float64 `json:"shares"`
+	ShockPercent   float64 `json:"shockPercent"`
+	CurrentPrice   float64 `json:"currentPrice"`
+	ProjectedPrice float64 `json:"projectedPrice"`
+	ProfitLoss     float64 `json:"profitLoss"`
+}
+
+// MarginSummary compares account equity to the maintenance requirement
+type MarginSummary struct {
+	Equity      float64 `json:"equity"`
+	Requirement float64 `json:"requirement"`
+	Excess      float64 `json:"excess"` // Negative when a margin call would be issued
+	MarginCall  bool    `json:"marginCall"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// ScreenerResult holds synthetic fundamentals and an analyst rating for a symbol
+type ScreenerResult struct {
+	Symbol        string  `json:"symbol"`
+	Name          string  `json:"name"`
+	Sector        string  `json:"sector"`
+	AssetClass    string  `json:"assetClass"`
+	Price         float64 `json:"price"`
+	MarketCap     float64 `json:"marketCap"`
+	PERatio       float64 `json:"peRatio"` // 0 when not applicable
+	EPS           float64 `json:"eps"`
+	DividendYield float64 `json:"dividendYield"` // Percent
+	AnalystRating string  `json:"analystRating"` // "Strong Buy" ... "Strong Sell"
+	AnalystScore  int     `json:"analystScore"`  // 1 (Strong Sell) to 5 (Strong Buy)
+	PriceTarget   float64 `json:"priceTarget"`
+}
+
+// ScreenerFilter narrows and orders screener results
+type ScreenerFilter struct {
+	Sector        string
+	AssetClass    string
+	AnalystRating string
+	MinPE         float64
+	MaxPE         float64
+	MinMarketCap  float64
+	MaxMarketCap  float64
+	SortBy        string // "symbol", "price", "marketCap", "peRatio", "dividendYield", "analystScore"
+	Descending    bool
+	Page          int
+	PageSize      int
+}
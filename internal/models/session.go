@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SessionOpen is a symbol's price at the open of the current simulated
+// trading session, the reference for "today's change"
+type SessionOpen struct {
+	Symbol   string    `bson:"_id" json:"symbol"`
+	Price    float64   `bson:"price" json:"price"`
+	Session  int       `bson:"session" json:"session"` // Sessions opened since the symbol was first seen
+	OpenedAt time.Time `bson:"opened_at" json:"openedAt"`
+}
+
+// PositionDayChange is how much a position has moved since the session open
+type PositionDayChange struct {
+	Symbol           string  `json:"symbol"`
+	Shares           float64 `json:"shares"`
+	SessionOpen      float64 `json:"sessionOpen"`
+	Price            float64 `json:"price"`
+	DayChange        float64 `json:"dayChange"`
+	DayChangePercent float64 `json:"dayChangePercent"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SimulationSettings control the market simulator at runtime
+type SimulationSettings struct {
+	TickIntervalMs       int       `bson:"tick_interval_ms" json:"tickIntervalMs"`            // Time between ticks
+	VolatilityMultiplier float64   `bson:"volatility_multiplier" json:"volatilityMultiplier"` // Scales the ±1.5% base move per tick; 0 holds prices flat
+	Paused               bool      `bson:"paused" json:"paused"`                              // No ticks are generated while paused
+	UpdatedBy            string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt            time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+}
+
+// TickInterval returns the tick interval as a duration
+func (s SimulationSettings) TickInterval() time.Duration {
+	return time.Duration(s.TickIntervalMs) * time.Millisecond
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// MarketSnapshot is the latest state of every symbol in the universe, for
+// clients bootstrapping before they start streaming ticks
+type MarketSnapshot struct {
+	Status        string           `json:"status"` // "open", "paused" or "maintenance"
+	Symbols       []SymbolSnapshot `json:"symbols"`
+	OnlineTraders int              `json:"onlineTraders"` // Authenticated users with an open WebSocket
+	GeneratedAt   time.Time        `json:"generatedAt"`
+}
+
+// SymbolSnapshot is the latest tick of one symbol
+type SymbolSnapshot struct {
+	Symbol        string    `json:"symbol"`
+	Name          string    `json:"name"`
+	AssetClass    string    `json:"assetClass"`
+	Price         float64   `json:"price"`
+	Change        float64   `json:"change"`
+	ChangePercent float64   `json:"changePercent"`
+	Volume        int64     `json:"volume"`
+	Status        string    `json:"status"` // The market status, or "no_data" before the first tick
+	UpdatedAt     time.Time `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Statement is the record of a user's main account for one calendar month
+// (UTC). It is generated once after the month ends and never changed.
+type Statement struct {
+	ID             string              `bson:"_id" json:"id"` // "<user ID>:<YYYY-MM>"
+	UserID         string              `bson:"user_id" json:"userId"`
+	Username       string              `bson:"username" json:"username"`
+	Year           int                 `bson:"year" json:"year"`
+	Month          int                 `bson:"month" json:"month"`
+	PeriodStart    time.Time           `bson:"period_start" json:"periodStart"`
+	PeriodEnd      time.Time           `bson:"period_end" json:"periodEnd"` // Exclusive
+	OpeningBalance float64             `bson:"opening_balance" json:"openingBalance"`
+	ClosingBalance float64             `bson:"closing_balance" json:"closingBalance"`
+	PositionsValue float64             `bson:"positions_value" json:"positionsValue"` // At month-end closing prices
+	ClosingEquity  float64             `bson:"closing_equity" json:"closingEquity"`
+	Totals         StatementTotals     `bson:"totals" json:"totals"`
+	Trades         []Execution         `bson:"trades" json:"trades"`
+	LedgerEntries  []LedgerEntry       `bson:"ledger_entries" json:"ledgerEntries"`
+	Positions      []StatementPosition `bson:"positions" json:"positions"`
+	GeneratedAt    time.Time           `bson:"generated_at" json:"generatedAt"`
+}
+
+// StatementTotals sums a month's cash movements. Opening balance plus
+// proceeds, interest and other adjustments, less purchases and fees, gives
+// the closing balance.
+type StatementTotals struct {
+	Purchases   float64 `bson:"purchases" json:"purchases"` // Gross, before fees
+	Proceeds    float64 `bson:"proceeds" json:"proceeds"`   // Gross, before fees
+	TradingFees float64 `bson:"trading_fees" json:"tradingFees"`
+	BorrowFees  float64 `bson:"borrow_fees" json:"borrowFees"`
+	Interest    float64 `bson:"interest" json:"interest"` // Cash interest less margin interest
+	// Movements without a ledger entry, such as dividends and referral
+	// bonuses; only known when the previous month has a statement
+	OtherAdjustments float64 `bson:"other_adjustments" json:"otherAdjustments"`
+}
+
+// StatementPosition is a holding at month end
+type StatementPosition struct {
+	Symbol      string  `bson:"symbol" json:"symbol"`
+	Shares      float64 `bson:"shares" json:"shares"`
+	Price       float64 `bson:"price" json:"price"` // Last daily close of the month
+	MarketValue float64 `bson:"market_value" json:"marketValue"`
+}
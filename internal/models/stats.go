@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PlatformStats are platform-wide aggregates for the admin dashboard
+type PlatformStats struct {
+	TotalUsers           int64            `json:"totalUsers"`
+	DailyActiveTraders   int64            `json:"dailyActiveTraders"` // Users with an order in the last 24 hours
+	OrdersByType         map[string]int64 `json:"ordersByType"`       // Filled orders and advanced orders by order type
+	TotalVolume          float64          `json:"totalVolume"`        // Notional value of all filled orders
+	TopSymbols           []SymbolActivity `json:"topSymbols"`
+	WebSocketConnections int              `json:"webSocketConnections"`
+	GeneratedAt          time.Time        `json:"generatedAt"`
+}
+
+// SymbolActivity is trading activity in one symbol
+type SymbolActivity struct {
+	Symbol     string  `bson:"_id" json:"symbol"`
+	OrderCount int64   `bson:"order_count" json:"orderCount"`
+	Volume     float64 `bson:"volume" json:"volume"`
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StrategyOperand is an indicator computed from a symbol's closing prices
+type StrategyOperand struct {
+	Indicator string `bson:"indicator" json:"indicator"`               // "price", "sma", "ema", "rsi" or "change_percent"
+	Period    int    `bson:"period,omitempty" json:"period,omitempty"` // Closes averaged; ignored for price and change_percent
+}
+
+// StrategyCondition compares an indicator with a constant or with another
+// indicator
+type StrategyCondition struct {
+	StrategyOperand `bson:",inline"`
+	Op              string           `bson:"op" json:"op"`                           // ">", ">=", "<", "<=", "crosses_above" or "crosses_below"
+	Value           float64          `bson:"value,omitempty" json:"value,omitempty"` // Compared with when Compare is not set
+	Compare         *StrategyOperand `bson:"compare,omitempty" json:"compare,omitempty"`
+}
+
+// StrategyRules is the rule set a strategy trades one symbol by. It buys
+// when every entry condition holds and sells when any exit condition, the
+// stop loss or the take profit is hit. Strategies only go long.
+type StrategyRules struct {
+	Symbol            string              `bson:"symbol" json:"symbol"`
+	Entry             []StrategyCondition `bson:"entry" json:"entry"`
+	Exit              []StrategyCondition `bson:"exit" json:"exit"`
+	StopLossPercent   float64             `bson:"stop_loss_percent,omitempty" json:"stopLossPercent,omitempty"`
+	TakeProfitPercent float64             `bson:"take_profit_percent,omitempty" json:"takeProfitPercent,omitempty"`
+	AllocationPercent float64             `bson:"allocation_percent" json:"allocationPercent"` // Share of cash spent on each entry
+}
+
+// StrategyStats summarises every paper run of a strategy, by anyone
+type StrategyStats struct {
+	Runs               int       `bson:"runs" json:"runs"`
+	AvgReturnPercent   float64   `bson:"avg_return_percent" json:"avgReturnPercent"`
+	BestReturnPercent  float64   `bson:"best_return_percent" json:"bestReturnPercent"`
+	WorstReturnPercent float64   `bson:"worst_return_percent" json:"worstReturnPercent"`
+	AvgWinRatePercent  float64   `bson:"avg_win_rate_percent" json:"avgWinRatePercent"`
+	LastRunAt          time.Time `bson:"last_run_at,omitempty" json:"lastRunAt,omitempty"`
+}
+
+// Strategy is a user's rule-based trading strategy. Published strategies are
+// listed in the marketplace where others can clone and paper-run them.
+type Strategy struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"userId"` // Owner
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Rules       StrategyRules      `bson:"rules" json:"rules"`
+	Published   bool               `bson:"published" json:"published"`
+	ClonedFrom  string             `bson:"cloned_from,omitempty" json:"clonedFrom,omitempty"` // Strategy ID
+	Clones      int                `bson:"clones" json:"clones"`
+	Stats       StrategyStats      `bson:"stats" json:"stats"`
+	TenantID    string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// StrategyTrade is one round trip made during a run
+type StrategyTrade struct {
+	EntryAt    time.Time `bson:"entry_at" json:"entryAt"`
+	EntryPrice float64   `bson:"entry_price" json:"entryPrice"`
+	ExitAt     time.Time `bson:"exit_at" json:"exitAt"`
+	ExitPrice  float64   `bson:"exit_price" json:"exitPrice"`
+	Quantity   float64   `bson:"quantity" json:"quantity"`
+	ProfitLoss float64   `bson:"profit_loss" json:"profitLoss"`
+	Reason     string    `bson:"reason" json:"reason"` // "exit", "stop_loss", "take_profit" or "end"
+}
+
+// StrategyRun is the result of running a strategy, starting from the
+// runner's buying power. Paper runs replay candle history and place no
+// orders; live runs trade on ticks from start until stopped.
+type StrategyRun struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StrategyID         string             `bson:"strategy_id" json:"strategyId"`
+	UserID             string             `bson:"user_id" json:"userId"` // Who ran it
+	Mode               string             `bson:"mode" json:"mode"`      // "paper" or "live"
+	Symbol             string             `bson:"symbol" json:"symbol"`
+	From               time.Time          `bson:"from" json:"from"`
+	To                 time.Time          `bson:"to" json:"to"`
+	StartingCash       float64            `bson:"starting_cash" json:"startingCash"`
+	FinalEquity        float64            `bson:"final_equity" json:"finalEquity"`
+	ReturnPercent      float64            `bson:"return_percent" json:"returnPercent"`
+	MaxDrawdownPercent float64            `bson:"max_drawdown_percent" json:"maxDrawdownPercent"`
+	WinRatePercent     float64            `bson:"win_rate_percent" json:"winRatePercent"`
+	Trades             []StrategyTrade    `bson:"trades" json:"trades"`
+	CreatedAt          time.Time          `bson:"created_at" json:"createdAt"`
+	// Live runs only
+	Status             string         `bson:"status,omitempty" json:"status,omitempty"`          // "running" or "stopped"
+	StopReason         string         `bson:"stop_reason,omitempty" json:"stopReason,omitempty"` // "user", "max_loss" or "deleted"
+	TenantID           string         `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	Position           *StrategyTrade `bson:"position,omitempty" json:"position,omitempty"` // Open entry, without exit fields
+	RealizedProfitLoss float64        `bson:"realized_profit_loss,omitempty" json:"realizedProfitLoss,omitempty"`
+	Orders             int            `bson:"orders,omitempty" json:"orders,omitempty"`
+}
+
+// StrategyRunLog is one step of a live run: starting, an order placed or
+// rejected, or stopping
+type StrategyRunLog struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RunID      string             `bson:"run_id" json:"runId"`
+	StrategyID string             `bson:"strategy_id" json:"strategyId"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Event      string             `bson:"event" json:"event"` // "start", "entry", "exit", "rejected" or "stop"
+	Message    string             `bson:"message" json:"message"`
+	Price      float64            `bson:"price,omitempty" json:"price,omitempty"`
+	OrderID    string             `bson:"order_id,omitempty" json:"orderId,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// StrategyProfitLoss attributes the fills of orders a strategy placed live.
+// ProfitLoss is proceeds plus the value of shares still held, less what was
+// spent and fees.
+type StrategyProfitLoss struct {
+	StrategyID    string  `bson:"_id" json:"strategyId"`
+	Name          string  `bson:"-" json:"name"`
+	Symbol        string  `bson:"symbol" json:"symbol"`
+	Fills         int     `bson:"fills" json:"fills"`
+	Invested      float64 `bson:"invested" json:"invested"`
+	Proceeds      float64 `bson:"proceeds" json:"proceeds"`
+	Fees          float64 `bson:"fees" json:"fees"`
+	SharesHeld    float64 `bson:"shares_held" json:"sharesHeld"`
+	PositionValue float64 `bson:"-" json:"positionValue"`
+	ProfitLoss    float64 `bson:"-" json:"profitLoss"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SymbolInfo describes the trading rules for a tradable symbol
+type SymbolInfo struct {
+	Symbol         string  `bson:"symbol" json:"symbol"`
+	Name           string  `bson:"name" json:"name"`
+	AssetClass     string  `bson:"asset_class" json:"assetClass"` // "stock", "crypto" or "etf"
+	Sector         string  `bson:"sector" json:"sector"`
+	TickSize       float64 `bson:"tick_size" json:"tickSize"`             // Minimum price increment
+	PricePrecision int     `bson:"price_precision" json:"pricePrecision"` // Decimal places for prices
+	LotSize        float64 `bson:"lot_size" json:"lotSize"`               // Minimum quantity increment
+	MinQuantity    float64 `bson:"min_quantity" json:"minQuantity"`
+	Custom         bool    `bson:"custom,omitempty" json:"custom,omitempty"`  // Created through POST /api/symbols/custom
+	HardToBorrow   bool    `bson:"hard_to_borrow" json:"hardToBorrow"`        // Short sales are rejected
+	BorrowFeeRate  float64 `bson:"borrow_fee_rate" json:"borrowFeeRate"`      // Annual percent of a short position's value, charged daily
+	BorrowLimit    float64 `bson:"borrow_limit" json:"borrowLimit,omitempty"` // Shares that can be short across all accounts; 0 is unlimited
+}
+
+// BorrowSettings are the short-sale settings an admin has set for a symbol
+type BorrowSettings struct {
+	Symbol        string    `bson:"_id" json:"symbol"`
+	HardToBorrow  bool      `bson:"hard_to_borrow" json:"hardToBorrow"`
+	BorrowFeeRate float64   `bson:"borrow_fee_rate" json:"borrowFeeRate"`
+	BorrowLimit   float64   `bson:"borrow_limit" json:"borrowLimit"`
+	UpdatedBy     string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updatedAt"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SymbolStats are rolling liquidity and range statistics for one symbol. Days
+// are simulated trading days of SIM_TICKS_PER_DAY ticks, so the 52-week range
+// covers the last 252 simulated days.
+type SymbolStats struct {
+	Symbol             string    `json:"symbol"`
+	SimDay             int       `json:"simDay"` // Completed simulated days, including history loaded from candles
+	DayOpen            float64   `json:"dayOpen"`
+	DayHigh            float64   `json:"dayHigh"`
+	DayLow             float64   `json:"dayLow"`
+	DayVolume          int64     `json:"dayVolume"`
+	AverageDailyVolume float64   `json:"averageDailyVolume"` // Over the last 20 days
+	AverageTrueRange   float64   `json:"averageTrueRange"`   // Over the last 14 days
+	Week52High         float64   `json:"week52High"`
+	Week52Low          float64   `json:"week52Low"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Tenant is a school or group sharing one deployment. Its users, orders and
+// positions are invisible to other tenants.
+type Tenant struct {
+	ID              string    `bson:"_id" json:"id"` // Slug, also accepted in the X-Tenant-ID header
+	Name            string    `bson:"name" json:"name"`
+	Hosts           []string  `bson:"hosts,omitempty" json:"hosts,omitempty"`     // Request hosts that resolve to this tenant
+	Symbols         []string  `bson:"symbols,omitempty" json:"symbols,omitempty"` // Tradable symbols; empty allows every symbol
+	StartingBalance float64   `bson:"starting_balance" json:"startingBalance"`
+	CreatedAt       time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+}
+
+// AllowsSymbol reports whether the tenant's symbol universe includes symbol
+func (t *Tenant) AllowsSymbol(symbol string) bool {
+	if len(t.Symbols) == 0 {
+		return true
+	}
+	for _, s := range t.Symbols {
+		if strings.EqualFold(s, symbol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TickRecord is a price tick as it was broadcast, kept for offline analysis
+type TickRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Symbol     string             `bson:"symbol" json:"symbol"`
+	Price      float64            `bson:"price" json:"price"`
+	Volume     int64              `bson:"volume" json:"volume"`
+	Source     string             `bson:"source,omitempty" json:"source,omitempty"` // "simulated", "delayed" or "streamed"
+	Timestamp  time.Time          `bson:"timestamp" json:"timestamp"`               // Time of the price, from the simulator or provider
+	RecordedAt time.Time          `bson:"recorded_at" json:"recordedAt"`            // When the server published the tick
+}
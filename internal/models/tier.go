@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AccountTier sets a new account's starting balance and the features its
+// users get on top of the feature flag rollouts
+type AccountTier struct {
+	Name            string    `bson:"name" json:"name"`
+	StartingBalance float64   `bson:"starting_balance" json:"startingBalance"` // 0 uses the tenant's starting balance
+	Features        []string  `bson:"features" json:"features"`                // Feature flags turned on for the tier's users
+	Description     string    `bson:"description" json:"description"`
+	UpdatedAt       time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+	UpdatedBy       string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TrainingSettings make the execution engine fail some of a group's orders
+// the way a real broker does, so bots get to exercise their error handling
+type TrainingSettings struct {
+	TenantID          string    `bson:"_id" json:"tenantId"` // Group the settings apply to; empty for users outside any group
+	Enabled           bool      `bson:"enabled" json:"enabled"`
+	RejectProbability float64   `bson:"reject_probability" json:"rejectProbability"` // Chance, 0 to 1, that an order is rejected with a broker error
+	DelayProbability  float64   `bson:"delay_probability" json:"delayProbability"`   // Chance, 0 to 1, that an order is held before it executes
+	MaxDelayMs        int       `bson:"max_delay_ms" json:"maxDelayMs"`              // Each delay is random up to this
+	UpdatedBy         string    `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt         time.Time `bson:"updated_at,omitempty" json:"updatedAt,omitempty"`
+}
@@ -10,9 +10,34 @@ type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Username  string             `bson:"username" json:"username"`
 	Email     string             `bson:"email" json:"email"`
+	PendingEmail string          `bson:"pending_email,omitempty" json:"pendingEmail,omitempty"` // New address waiting to be verified
+	EmailTokenHash string        `bson:"email_token_hash,omitempty" json:"-"` // SHA-256 of the verification token sent to PendingEmail
+	EmailTokenExpires time.Time  `bson:"email_token_expires,omitempty" json:"-"`
+	UsernameChangedAt time.Time  `bson:"username_changed_at,omitempty" json:"usernameChangedAt,omitempty"`
+	TrustedIPs []string          `bson:"trusted_ips,omitempty" json:"trustedIps,omitempty"` // IPs and CIDR ranges logins and trades must come from; empty allows any
 	Password  string             `bson:"password" json:"-"`
 	CashBalance float64          `bson:"cash_balance" json:"cashBalance"`
+	ReservedCash float64         `bson:"reserved_cash" json:"reservedCash"` // Held for open buy orders
+	StartingBalance float64      `bson:"starting_balance,omitempty" json:"-"` // Cash the account opened with, or was last reset to
+	AccountResetAt time.Time     `bson:"account_reset_at,omitempty" json:"-"` // When a teacher last reset the account; activity before it no longer counts
+	CashAutoApproved map[string]DailyTotal `bson:"cash_auto_approved,omitempty" json:"-"` // Cash requests applied without review today, by type
+	Role      string             `bson:"role,omitempty" json:"role,omitempty"` // "" for traders, "admin" for administrators
+	Tier      string             `bson:"tier,omitempty" json:"tier,omitempty"` // Account tier; empty is the default "beginner" tier
+	TenantID  string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"` // Admins without a tenant manage the whole platform
+	DataMode  string             `bson:"data_mode,omitempty" json:"dataMode,omitempty"` // Price feed set by an admin; empty follows the deployment's mode
+	CostBasisMethod string       `bson:"cost_basis_method,omitempty" json:"costBasisMethod,omitempty"` // "average" (the default) or "fifo"
+	InviteCode string            `bson:"invite_code,omitempty" json:"inviteCode,omitempty"`
+	ReferredBy string            `bson:"referred_by,omitempty" json:"referredBy,omitempty"`
+	Timezone  string             `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA zone for report times, e.g. "Europe/London"; empty means UTC
+	Language  string             `bson:"language,omitempty" json:"language,omitempty"` // Message language, e.g. "es"; empty follows Accept-Language
+	Currency  string             `bson:"currency,omitempty" json:"currency,omitempty"` // Currency balances are shown in, e.g. "EUR"; empty means USD
+	HidePresence bool            `bson:"hide_presence,omitempty" json:"hidePresence,omitempty"` // Keep the user out of presence lists; they still count as online
+	PublicProfile bool           `bson:"public_profile,omitempty" json:"publicProfile,omitempty"` // Opt in to search and a public profile with return, rank and badges
+	Digest    string             `bson:"digest,omitempty" json:"digest,omitempty"` // "daily" or "weekly" to receive email digests; empty for none
+	DigestSentAt time.Time       `bson:"digest_sent_at,omitempty" json:"-"`
+	DigestEquity float64         `bson:"digest_equity,omitempty" json:"-"` // Equity in the previous digest, the base of the next one's P&L
 	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	DeletedAt time.Time          `bson:"deleted_at,omitempty" json:"deletedAt,omitempty"` // Set when the user closes the account; data is purged after the retention period
 }
 
 // HashPassword hashes the user's password
@@ -29,4 +54,16 @@ func (u *User) HashPassword() error {
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
-}
\ No newline at end of file
+}
+
+// Location returns the user's preferred timezone, falling back to UTC
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
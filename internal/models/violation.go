@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderViolation records an order that broke a throttling or anti-gaming rule
+type OrderViolation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	TenantID  string             `bson:"tenant_id,omitempty" json:"tenantId,omitempty"`
+	Symbol    string             `bson:"symbol" json:"symbol"`
+	Kind      string             `bson:"kind" json:"kind"` // "rate_limit", "symbol_interval", "duplicate", "wash_trade"
+	Detail    string             `bson:"detail" json:"detail"`
+	Rejected  bool               `bson:"rejected" json:"rejected"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
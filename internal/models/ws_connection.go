@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// WSConnection describes one connected WebSocket client for admins
+type WSConnection struct {
+	ID              uint64    `json:"id"`
+	Username        string    `json:"username"`
+	UserID          string    `json:"userId,omitempty"` // Empty for unauthenticated sockets
+	TenantID        string    `json:"tenantId,omitempty"`
+	RemoteAddr      string    `json:"remoteAddr"`
+	Binary          bool      `json:"binary"`
+	AllSymbols      bool      `json:"allSymbols"` // Receives every symbol rather than a subscription list
+	Subscriptions   []string  `json:"subscriptions"`
+	ConnectedAt     time.Time `json:"connectedAt"`
+	MessagesSent    uint64    `json:"messagesSent"`
+	MessagesDropped uint64    `json:"messagesDropped"`
+	Backlog         int       `json:"backlog"`                // Messages queued or coalesced but not yet written
+	Leaderboards    []string  `json:"leaderboards,omitempty"` // Competitions whose live leaderboard is followed
+}
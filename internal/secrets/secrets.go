@@ -0,0 +1,120 @@
+// Package secrets encrypts secrets stored in Mongo, such as webhook URLs
+// and signing keys, with AES-256-GCM.
+//
+// Keys come from SECRETS_KEYS, a comma-separated list of id:key pairs with
+// base64-encoded 32-byte keys, e.g. "k2:BASE64,k1:BASE64". A KMS or secret
+// manager can inject it at deploy time. The first key encrypts; every key
+// decrypts. To rotate, put a new key first, re-encrypt stored secrets with
+// POST /api/admin/secrets/rotate, then drop the old key.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Encrypted values are stored as prefix + key ID + ":" + base64(nonce|ciphertext)
+const prefix = "enc:v1:"
+
+var (
+	ErrUnknownKey = errors.New("secret was encrypted with a key that is no longer configured")
+	ErrNoKeys     = errors.New("no encryption keys configured; set SECRETS_KEYS")
+)
+
+// Keyring encrypts with its active key and decrypts with any of its keys.
+// An empty keyring stores secrets as plain text, for development.
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// NewKeyring parses a SECRETS_KEYS list; the first key is the active one
+func NewKeyring(list string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("secret key %q must be id:base64key", entry)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("secret key %s must be 32 bytes of base64", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("secret key %s is listed twice", id)
+		}
+		k.keys[id] = aead
+		if k.active == "" {
+			k.active = id
+		}
+	}
+	return k, nil
+}
+
+// Enabled reports whether secrets are encrypted
+func (k *Keyring) Enabled() bool {
+	return k.active != ""
+}
+
+// Encrypt seals plaintext with the active key. Empty values and values on
+// an empty keyring are returned as they are.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !k.Enabled() {
+		return plaintext, nil
+	}
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.active))
+	return prefix + k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values stored before encryption
+// was turned on are returned as they are.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	id, encoded, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("secret is corrupted")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", errors.New("secret is corrupted or was encrypted with another key")
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether a stored value is not sealed with the
+// active key: plain text, or sealed with an older key
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" || !k.Enabled() {
+		return false
+	}
+	return !strings.HasPrefix(value, prefix+k.active+":")
+}
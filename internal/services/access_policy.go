@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Access policy actions
+const (
+	AccessLogin = "login"
+	AccessTrade = "trade"
+)
+
+// Most trusted IP entries a user can keep
+const maxTrustedIPs = 20
+
+// A user is told about blocked attempts from the same IP at most this often
+const accessNoticeInterval = time.Hour
+
+var ErrInvalidTrustedIPs = errors.New("invalid trusted IPs")
+
+// AuditAccessBlocked is recorded in the audit log for every blocked attempt
+const AuditAccessBlocked = "access.blocked"
+
+// GeoResolver finds the country an IP is in, as an ISO 3166 alpha-2 code.
+// Deployments plug in a GeoIP database or service with SetGeoResolver; an
+// empty code means unknown.
+type GeoResolver interface {
+	Country(ctx context.Context, ip string) (string, error)
+}
+
+// AccessDeniedError blocks a login or trade from an unexpected location
+type AccessDeniedError struct {
+	Err *i18n.Error
+}
+
+func (e *AccessDeniedError) Error() string { return e.Err.Error() }
+func (e *AccessDeniedError) Unwrap() error { return e.Err }
+
+// AccessPolicyService decides whether logins and trades may come from an IP.
+// Users can restrict their account to trusted IPs and ranges. The
+// deployment can block countries with BLOCKED_COUNTRIES, or allow only
+// ALLOWED_COUNTRIES, both comma-separated ISO codes; countries come from the
+// geo resolver. Blocked attempts are audited and the user is emailed.
+type AccessPolicyService struct {
+	userCollection *mongo.Collection
+	audit          *AuditService
+	mailer         Mailer
+	blocked        map[string]bool
+	allowed        map[string]bool
+	countryHeader  string // Request header a CDN sets to the client's country, from GEO_COUNTRY_HEADER
+
+	mu       sync.Mutex
+	resolver GeoResolver
+	notified map[string]time.Time // By user and IP
+}
+
+func NewAccessPolicyService(audit *AuditService, mailer Mailer) *AccessPolicyService {
+	return &AccessPolicyService{
+		userCollection: config.GetCollection("users"),
+		audit:          audit,
+		mailer:         mailer,
+		blocked:        countrySet(config.GetEnv("BLOCKED_COUNTRIES", "")),
+		allowed:        countrySet(config.GetEnv("ALLOWED_COUNTRIES", "")),
+		countryHeader:  config.GetEnv("GEO_COUNTRY_HEADER", ""),
+		notified:       make(map[string]time.Time),
+	}
+}
+
+func countrySet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, code := range strings.Split(list, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
+
+// SetGeoResolver plugs in the resolver for country rules
+func (s *AccessPolicyService) SetGeoResolver(resolver GeoResolver) {
+	s.mu.Lock()
+	s.resolver = resolver
+	s.mu.Unlock()
+}
+
+// GeoRestricted reports whether any country rules are configured
+func (s *AccessPolicyService) GeoRestricted() bool {
+	return len(s.blocked) > 0 || len(s.allowed) > 0
+}
+
+// CountryHeader is the request header carrying the client's country when a
+// CDN in front of the server sets one, such as CF-IPCountry; it takes
+// precedence over the resolver
+func (s *AccessPolicyService) CountryHeader() string {
+	return s.countryHeader
+}
+
+// Resolve looks up the country of an IP, or returns "" without a resolver
+func (s *AccessPolicyService) Resolve(ctx context.Context, ip string) string {
+	s.mu.Lock()
+	resolver := s.resolver
+	s.mu.Unlock()
+	if resolver == nil {
+		return ""
+	}
+	country, err := resolver.Country(ctx, ip)
+	if err != nil {
+		log.Printf("Error resolving the country of %s: %v", ip, err)
+		return ""
+	}
+	return strings.ToUpper(country)
+}
+
+// Check returns an *AccessDeniedError if the attempt comes from outside the
+// user's trusted IPs or from a country the deployment does not allow. An
+// unknown country only fails an allowlist.
+func (s *AccessPolicyService) Check(ctx context.Context, attempt models.AccessAttempt) error {
+	ranges, err := s.TrustedIPs(ctx, attempt.UserID)
+	if err != nil {
+		return err
+	}
+
+	var denied *AccessDeniedError
+	switch {
+	case len(ranges) > 0 && !ipInRanges(attempt.IP, ranges):
+		denied = &AccessDeniedError{Err: i18n.NewError("access.untrusted_ip", attempt.Action, attempt.IP)}
+	case s.blocked[attempt.Country] || (len(s.allowed) > 0 && !s.allowed[attempt.Country]):
+		country := attempt.Country
+		if country == "" {
+			country = "unknown"
+		}
+		denied = &AccessDeniedError{Err: i18n.NewError("access.country_blocked", attempt.Action, country)}
+	default:
+		return nil
+	}
+
+	s.recordBlocked(attempt)
+	return denied
+}
+
+// recordBlocked audits a blocked attempt and emails the user, at most once
+// an hour for the same IP
+func (s *AccessPolicyService) recordBlocked(attempt models.AccessAttempt) {
+	log.Printf("🚫 Blocked %s for %s from %s (%s)", attempt.Action, attempt.UserID, attempt.IP, attempt.Country)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := s.audit.Record(ctx, models.AuditEntry{
+			Action:  AuditAccessBlocked,
+			UserID:  attempt.UserID,
+			Details: map[string]string{"action": attempt.Action, "ip": attempt.IP, "country": attempt.Country},
+		})
+		if err != nil {
+			log.Printf("Error auditing blocked %s for %s: %v", attempt.Action, attempt.UserID, err)
+		}
+
+		key := attempt.UserID + "|" + attempt.IP
+		s.mu.Lock()
+		recent := time.Since(s.notified[key]) < accessNoticeInterval
+		if !recent {
+			s.notified[key] = time.Now()
+		}
+		s.mu.Unlock()
+		if recent {
+			return
+		}
+		s.notify(ctx, attempt)
+	}()
+}
+
+func (s *AccessPolicyService) notify(ctx context.Context, attempt models.AccessAttempt) {
+	objID, err := primitive.ObjectIDFromHex(attempt.UserID)
+	if err != nil {
+		return
+	}
+	var user models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"username": 1, "email": 1})).Decode(&user)
+	if err != nil || user.Email == "" {
+		return
+	}
+	location := attempt.IP
+	if attempt.Country != "" {
+		location += " (" + attempt.Country + ")"
+	}
+	err = s.mailer.Send(ctx, Email{
+		To:      user.Email,
+		Subject: "Blocked " + attempt.Action + " attempt on your account",
+		Text: fmt.Sprintf("Hi %s,\n\nWe blocked a %s on your account from %s at %s because it came from outside the locations your account allows.\n\nIf this was you, add the address to your trusted IPs. If not, change your password.\n",
+			user.Username, attempt.Action, location, time.Now().UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.Printf("Error emailing %s about a blocked %s: %v", user.Username, attempt.Action, err)
+	}
+}
+
+// TrustedIPs returns the IPs and ranges the user restricted their account to
+func (s *AccessPolicyService) TrustedIPs(ctx context.Context, userID string) ([]string, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	var user models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"trusted_ips": 1})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user.TrustedIPs, nil
+}
+
+// SetTrustedIPs restricts the account to IPs and CIDR ranges, or lifts the
+// restriction with none. The IP making the change must be among them, so
+// users cannot lock themselves out.
+func (s *AccessPolicyService) SetTrustedIPs(ctx context.Context, userID, currentIP string, ranges []string) ([]string, error) {
+	if len(ranges) > maxTrustedIPs {
+		return nil, fmt.Errorf("%w: at most %d IPs and ranges", ErrInvalidTrustedIPs, maxTrustedIPs)
+	}
+	normalized := make([]string, 0, len(ranges))
+	for _, entry := range ranges {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			normalized = append(normalized, network.String())
+		} else if ip := net.ParseIP(entry); ip != nil {
+			normalized = append(normalized, ip.String())
+		} else {
+			return nil, fmt.Errorf("%w: %q is not an IP address or CIDR range", ErrInvalidTrustedIPs, entry)
+		}
+	}
+	if len(normalized) > 0 && !ipInRanges(currentIP, normalized) {
+		return nil, fmt.Errorf("%w: your current IP %s must be one of them", ErrInvalidTrustedIPs, currentIP)
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	update := bson.M{"$set": bson.M{"trusted_ips": normalized}}
+	if len(normalized) == 0 {
+		update = bson.M{"$unset": bson.M{"trusted_ips": ""}}
+	}
+	result, err := s.userCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrAccountNotFound
+	}
+	return normalized, nil
+}
+
+// ipInRanges reports whether ip is one of the IPs or inside one of the
+// CIDR ranges
+func ipInRanges(ip string, ranges []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range ranges {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+		} else if trusted := net.ParseIP(entry); trusted != nil && trusted.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// A new email must be verified within this time
+const emailVerificationTTL = 24 * time.Hour
+
+var ErrAccountNotFound = errors.New("user not found")
+
+// RequestEmailChange starts moving the user to a new email. The address
+// only changes once the token mailed to it is verified; until then the
+// user keeps logging in with the old one. Requesting again replaces the
+// pending address.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID, password, email string) (*models.User, error) {
+	user, err := s.accountForChange(ctx, userID, password)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == user.Email {
+		return nil, i18n.NewError("user.email_unchanged", email)
+	}
+	candidate := *user
+	candidate.Email = email
+	if err := s.checkAvailable(ctx, &candidate, "email"); err != nil {
+		return nil, err
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().UTC().Add(emailVerificationTTL)
+	_, err = s.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{
+		"pending_email":       email,
+		"email_token_hash":    hashToken(token),
+		"email_token_expires": expires,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(ctx, Email{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Text: fmt.Sprintf("Hi %s,\n\nConfirm %s as your new email address by sending this code to POST /api/auth/email/verify before %s:\n\n%s\n\nIf you did not ask for this, ignore this email; your account keeps its current address.\n",
+			user.Username, email, expires.Format(time.RFC1123), token),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	user.PendingEmail = email
+	return user, nil
+}
+
+// VerifyEmailChange switches the account holding the token to its pending
+// email and tells the old address
+func (s *AuthService) VerifyEmailChange(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+	err := s.userCollection.FindOne(ctx, bson.M{
+		"email_token_hash":    hashToken(token),
+		"email_token_expires": bson.M{"$gt": time.Now().UTC()},
+		"deleted_at":          bson.M{"$exists": false},
+	}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, i18n.NewError("user.verification_invalid")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	oldEmail := user.Email
+	user.Email = user.PendingEmail
+	if err := s.checkAvailable(ctx, &user, "email"); err != nil {
+		return nil, err
+	}
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "email_token_hash": user.EmailTokenHash},
+		bson.M{
+			"$set":   bson.M{"email": user.Email},
+			"$unset": bson.M{"pending_email": "", "email_token_hash": "", "email_token_expires": ""},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, userConflict(&user, "email")
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.PendingEmail, user.EmailTokenHash, user.EmailTokenExpires = "", "", time.Time{}
+
+	err = s.mailer.Send(ctx, Email{
+		To:      oldEmail,
+		Subject: "Your email address was changed",
+		Text:    fmt.Sprintf("Hi %s,\n\nThe email address of your account is now %s. If you did not make this change, contact support.\n", user.Username, user.Email),
+	})
+	if err != nil {
+		log.Printf("Error notifying %s of their email change: %v", user.Username, err)
+	}
+
+	log.Printf("📧 %s changed their email", user.Username)
+	s.events.Publish(EventUserEmailChanged, user.ID.Hex(), models.AuditEntry{
+		UserID:   user.ID.Hex(),
+		TenantID: user.TenantID,
+		Details:  map[string]string{"from": oldEmail, "to": user.Email},
+	})
+	user.Password = ""
+	return &user, nil
+}
+
+// ChangeUsername renames the user. Usernames are unique regardless of case
+// and can change once per USERNAME_CHANGE_COOLDOWN_DAYS (default 30).
+func (s *AuthService) ChangeUsername(ctx context.Context, userID, password, username string) (*models.User, error) {
+	user, err := s.accountForChange(ctx, userID, password)
+	if err != nil {
+		return nil, err
+	}
+	username = strings.TrimSpace(username)
+	if username == user.Username {
+		return nil, i18n.NewError("user.username_unchanged", username)
+	}
+	if next := user.UsernameChangedAt.Add(s.usernameCooldown); s.usernameCooldown > 0 && time.Now().Before(next) {
+		return nil, i18n.NewError("user.username_cooldown", next.In(user.Location()).Format("2006-01-02 15:04 MST"))
+	}
+
+	oldUsername := user.Username
+	user.Username = username
+	// A change of case only is the same username, so it is not a clash
+	if !strings.EqualFold(oldUsername, username) {
+		if err := s.checkAvailable(ctx, user, "username"); err != nil {
+			return nil, err
+		}
+	}
+
+	user.UsernameChangedAt = time.Now().UTC()
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "username": oldUsername},
+		bson.M{"$set": bson.M{"username": username, "username_changed_at": user.UsernameChangedAt}},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, userConflict(user, "username")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✏️ %s is now %s", oldUsername, username)
+	s.events.Publish(EventUserUsernameChanged, userID, models.AuditEntry{
+		UserID:   userID,
+		TenantID: user.TenantID,
+		Details:  map[string]string{"from": oldUsername, "to": username},
+	})
+	return user, nil
+}
+
+// accountForChange loads the user and checks their current password
+func (s *AuthService) accountForChange(ctx context.Context, userID, password string) (*models.User, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	var user models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID, "deleted_at": bson.M{"$exists": false}}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.CheckPassword(password) {
+		return nil, i18n.NewError("user.wrong_password")
+	}
+	user.Password = ""
+	return &user, nil
+}
+
+// checkAvailable returns a *UserConflictError if another account has the
+// user's username or email, ignoring case
+func (s *AuthService) checkAvailable(ctx context.Context, user *models.User, field string) error {
+	value := user.Username
+	if field == "email" {
+		value = user.Email
+	}
+	err := s.userCollection.FindOne(ctx,
+		bson.M{field: value, "_id": bson.M{"$ne": user.ID}},
+		options.FindOne().SetCollation(usernameCollation).SetProjection(bson.M{"_id": 1}),
+	).Err()
+	if err == nil {
+		return userConflict(user, field)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+func newVerificationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is what is stored of a token, so a leaked database cannot
+// verify addresses
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
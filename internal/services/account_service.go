@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AccountService struct {
+	orderService            *OrderService
+	marketService           *MarketDataService
+	sessions                *SessionService
+	flags                   *FeatureFlagService
+	hub                     *WebSocketHub
+	userCollection          *mongo.Collection
+	orderCollection         *mongo.Collection
+	executionCollection     *mongo.Collection
+	ledgerCollection        *mongo.Collection
+	journalCollection       *mongo.Collection
+	inboxCollection         *mongo.Collection
+	advancedOrderCollection *mongo.Collection
+	orderEventCollection    *mongo.Collection
+	portfolioCollection     *mongo.Collection
+	entryCollection         *mongo.Collection
+	achievementCollection   *mongo.Collection
+	progressCollection      *mongo.Collection
+	referralCollection      *mongo.Collection
+	classroomCollection     *mongo.Collection
+	violationCollection     *mongo.Collection
+	strategyCollection      *mongo.Collection
+	strategyRunCollection   *mongo.Collection
+	strategyLogCollection   *mongo.Collection
+	deviceCollection        *mongo.Collection
+	statementCollection     *mongo.Collection
+	basketCollection        *mongo.Collection
+	cashRequestCollection   *mongo.Collection
+	retention               time.Duration
+}
+
+func NewAccountService(orderService *OrderService, marketService *MarketDataService, sessions *SessionService, flags *FeatureFlagService, hub *WebSocketHub) *AccountService {
+	return &AccountService{
+		orderService:            orderService,
+		marketService:           marketService,
+		sessions:                sessions,
+		flags:                   flags,
+		hub:                     hub,
+		userCollection:          config.GetCollection("users"),
+		orderCollection:         config.GetCollection("orders"),
+		executionCollection:     config.GetCollection("executions"),
+		ledgerCollection:        config.GetCollection("ledger"),
+		journalCollection:       config.GetCollection("journal"),
+		inboxCollection:         config.GetCollection("inbox"),
+		cashRequestCollection:   config.GetCollection("cash_requests"),
+		advancedOrderCollection: config.GetCollection("advanced_orders"),
+		orderEventCollection:    config.GetCollection("order_events"),
+		portfolioCollection:     config.GetCollection("portfolio"),
+		entryCollection:         config.GetCollection("competition_entries"),
+		achievementCollection:   config.GetCollection("achievements"),
+		progressCollection:      config.GetCollection("achievement_progress"),
+		referralCollection:      config.GetCollection("referrals"),
+		classroomCollection:     config.GetCollection("classrooms"),
+		violationCollection:     config.GetCollection("order_violations"),
+		strategyCollection:      config.GetCollection("strategies"),
+		strategyRunCollection:   config.GetCollection("strategy_runs"),
+		strategyLogCollection:   config.GetCollection("strategy_run_logs"),
+		deviceCollection:        config.GetCollection("devices"),
+		statementCollection:     config.GetCollection("statements"),
+		basketCollection:        config.GetCollection("baskets"),
+		retention:               time.Duration(config.GetEnvInt("DELETED_ACCOUNT_RETENTION_DAYS", 30)) * 24 * time.Hour,
+	}
+}
+
+// Summary computes the main account's cash, holds, buying power, equity,
+// day P&L and open order counts. Day P&L compares each position with its
+// symbol's price at the open of the current simulated session.
+func (s *AccountService) Summary(ctx context.Context, userID string) (*models.AccountSummary, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	positions, err := s.orderService.GetUserPortfolio(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.AccountSummary{
+		CashBalance:   round2(user.CashBalance),
+		ReservedCash:  round2(user.ReservedCash),
+		MarginEnabled: s.flags.IsEnabledFor(FeatureMargin, userID),
+		Positions:     len(positions),
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	mode := s.orderService.DataMode(userID)
+	prices := make(map[string]float64, len(positions))
+	positionsValue, dayPnL, openValue := 0.0, 0.0, 0.0
+	summary.PositionChanges = make([]models.PositionDayChange, 0, len(positions))
+	for _, pos := range positions {
+		price, ok := s.marketService.GetMarkPrice(pos.Symbol, mode)
+		if !ok {
+			price = pos.AvgCost
+		}
+		prices[pos.Symbol] = price
+		positionsValue += price * pos.Shares
+
+		change := s.dayChange(pos, price, mode)
+		summary.PositionChanges = append(summary.PositionChanges, change)
+		dayPnL += change.DayChange
+		openValue += change.SessionOpen * pos.Shares
+	}
+	equity := user.CashBalance + positionsValue
+	summary.PositionsValue = round2(positionsValue)
+	summary.Equity = round2(equity)
+	summary.DayProfitLoss = round2(dayPnL)
+	if startEquity := user.CashBalance + openValue; startEquity > 0 {
+		summary.DayProfitLossPercent = round2(dayPnL / startEquity * 100)
+	}
+
+	summary.BuyingPower = round2(math.Max(buyingPower(user, positions, prices, summary.MarginEnabled), 0))
+
+	cursor, err := s.advancedOrderCollection.Find(ctx, bson.M{"user_id": userID, "status": bson.M{"$in": openOrderStatuses}},
+		options.Find().SetProjection(bson.M{"type": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var open []models.Order
+	if err := cursor.All(ctx, &open); err != nil {
+		return nil, err
+	}
+	for _, order := range open {
+		summary.OpenOrders.Total++
+		if order.Type == "buy" {
+			summary.OpenOrders.Buy++
+		} else {
+			summary.OpenOrders.Sell++
+		}
+	}
+	return summary, nil
+}
+
+// dayChange is the position's move since the session open. A position
+// opened during the session still counts from the open, like a broker's
+// "today's change" on a holding.
+func (s *AccountService) dayChange(pos models.Portfolio, price float64, mode string) models.PositionDayChange {
+	open, ok := s.sessions.OpenPrice(pos.Symbol)
+	if mode == DataModeDelayed {
+		// Delayed quotes follow the real trading day, which starts from the
+		// previous close rather than the simulated session's open
+		if quote, found := s.marketService.GetDelayedQuote(pos.Symbol); found {
+			open, ok = quote.Price-quote.Change, true
+		}
+	}
+	if !ok {
+		open = price
+	}
+	change := models.PositionDayChange{
+		Symbol:      pos.Symbol,
+		Shares:      pos.Shares,
+		SessionOpen: round2(open),
+		Price:       round2(price),
+		DayChange:   round2((price - open) * pos.Shares),
+	}
+	if open > 0 {
+		change.DayChangePercent = round2((price - open) / open * 100)
+	}
+	return change
+}
+
+// PushDayChanges sends each connected trader their account's current day
+// P&L as an "account" message
+func (s *AccountService) PushDayChanges(ctx context.Context) {
+	for userID, username := range s.hub.OnlineUsers() {
+		summary, err := s.Summary(ctx, userID)
+		if err != nil {
+			log.Printf("Error computing day change for %s: %v", username, err)
+			continue
+		}
+		s.hub.SendLive(userID, map[string]interface{}{
+			"type":                 "account",
+			"equity":               summary.Equity,
+			"buyingPower":          summary.BuyingPower,
+			"dayProfitLoss":        summary.DayProfitLoss,
+			"dayProfitLossPercent": summary.DayProfitLossPercent,
+			"positions":            summary.PositionChanges,
+			"timestamp":            summary.GeneratedAt,
+		})
+	}
+}
+
+// Export collects every record stored about the user
+func (s *AccountService) Export(ctx context.Context, userID string) (*models.AccountExport, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.AccountExport{ExportedAt: time.Now().UTC()}
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&export.Profile); err != nil {
+		return nil, err
+	}
+	export.Profile.Password = ""
+
+	byUser := bson.M{"user_id": userID}
+	finds := []struct {
+		collection *mongo.Collection
+		filter     bson.M
+		results    interface{}
+	}{
+		{s.orderCollection, byUser, &export.Orders},
+		{s.executionCollection, byUser, &export.Executions},
+		{s.ledgerCollection, byUser, &export.Ledger},
+		{s.journalCollection, byUser, &export.Journal},
+		{s.inboxCollection, byUser, &export.Inbox},
+		{s.cashRequestCollection, byUser, &export.CashRequests},
+		{s.advancedOrderCollection, byUser, &export.AdvancedOrders},
+		{s.orderEventCollection, byUser, &export.OrderEvents},
+		{s.portfolioCollection, byUser, &export.Positions},
+		{s.entryCollection, byUser, &export.CompetitionEntries},
+		{s.achievementCollection, byUser, &export.Achievements},
+		{s.strategyCollection, byUser, &export.Strategies},
+		{s.strategyRunCollection, byUser, &export.StrategyRuns},
+		{s.strategyLogCollection, byUser, &export.StrategyRunLogs},
+		{s.deviceCollection, byUser, &export.Devices},
+		{s.statementCollection, byUser, &export.Statements},
+		{s.basketCollection, byUser, &export.Baskets},
+		{s.referralCollection, bson.M{"$or": bson.A{bson.M{"referrer_id": userID}, bson.M{"referee_id": userID}}}, &export.Referrals},
+		{s.classroomCollection, bson.M{"$or": bson.A{bson.M{"teacher_id": userID}, bson.M{"student_ids": userID}}}, &export.Classrooms},
+	}
+	for _, f := range finds {
+		cursor, err := f.collection.Find(ctx, f.filter)
+		if err != nil {
+			return nil, err
+		}
+		err = cursor.All(ctx, f.results)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+// DeleteAccount closes the account after checking the password. Open orders
+// are cancelled now; the data itself is purged once the retention period ends.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID, password string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return err
+	}
+	if !user.DeletedAt.IsZero() {
+		return errors.New("account is already deleted")
+	}
+	if !user.CheckPassword(password) {
+		return errors.New("incorrect password")
+	}
+
+	err = s.orderService.history.cancelAll(ctx, s.advancedOrderCollection, bson.M{"user_id": userID}, "account_closed")
+	if err != nil {
+		return err
+	}
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"deleted_at": time.Now().UTC(), "reserved_cash": 0}},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🗑️ Account %s closed, data will be purged after %s", user.Username, s.retention)
+	return nil
+}
+
+// PurgeDeletedAccounts removes all data of accounts deleted longer ago than
+// the retention period. The user document goes last so an interrupted purge
+// is picked up again on the next run.
+func (s *AccountService) PurgeDeletedAccounts() {
+	cursor, err := s.userCollection.Find(context.Background(),
+		bson.M{"deleted_at": bson.M{"$lte": time.Now().Add(-s.retention)}},
+		options.Find().SetProjection(bson.M{"_id": 1, "username": 1}),
+	)
+	if err != nil {
+		log.Printf("Error loading deleted accounts: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var users []models.User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return
+	}
+
+	for _, user := range users {
+		if err := s.purgeUser(user.ID); err != nil {
+			log.Printf("Error purging account %s: %v", user.ID.Hex(), err)
+			continue
+		}
+		log.Printf("🗑️ Purged data for deleted account %s", user.ID.Hex())
+	}
+}
+
+func (s *AccountService) purgeUser(objID primitive.ObjectID) error {
+	ctx := context.Background()
+	userID := objID.Hex()
+	byUser := bson.M{"user_id": userID}
+
+	for _, collection := range []*mongo.Collection{
+		s.orderCollection,
+		s.executionCollection,
+		s.ledgerCollection,
+		s.journalCollection,
+		s.inboxCollection,
+		s.cashRequestCollection,
+		s.advancedOrderCollection,
+		s.orderEventCollection,
+		s.portfolioCollection,
+		s.entryCollection,
+		s.achievementCollection,
+		s.progressCollection,
+		s.violationCollection,
+		s.strategyCollection,
+		s.strategyRunCollection,
+		s.strategyLogCollection,
+		s.deviceCollection,
+		s.statementCollection,
+		s.basketCollection,
+	} {
+		if _, err := collection.DeleteMany(ctx, byUser); err != nil {
+			return err
+		}
+	}
+
+	// Referrals and classrooms also belong to other users, so only this
+	// user's side is removed
+	if _, err := s.referralCollection.DeleteMany(ctx, bson.M{"referee_id": userID}); err != nil {
+		return err
+	}
+	if _, err := s.referralCollection.DeleteMany(ctx, bson.M{"referrer_id": userID}); err != nil {
+		return err
+	}
+	if _, err := s.classroomCollection.UpdateMany(ctx,
+		bson.M{"student_ids": userID},
+		bson.M{"$pull": bson.M{"student_ids": userID}},
+	); err != nil {
+		return err
+	}
+	if _, err := s.classroomCollection.DeleteMany(ctx, bson.M{"teacher_id": userID}); err != nil {
+		return err
+	}
+	if _, err := s.userCollection.UpdateMany(ctx,
+		bson.M{"referred_by": userID},
+		bson.M{"$unset": bson.M{"referred_by": ""}},
+	); err != nil {
+		return err
+	}
+
+	_, err := s.userCollection.DeleteOne(ctx, bson.M{"_id": objID})
+	return err
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	diversifiedPositions = 5
+	crashDrawdownPercent = 10.0
+	targetReturnPercent  = 10.0
+)
+
+type achievementDefinition struct {
+	Code        string
+	Name        string
+	Description string
+}
+
+var achievementDefinitions = []achievementDefinition{
+	{"first_trade", "First Trade", "Place your first filled order"},
+	{"ten_percent_return", "Double Digits", "Grow your account 10% above the starting balance"},
+	{"diversified", "Diversified", "Hold positions in 5 or more symbols at once"},
+	{"survived_crash", "Survived a Crash", "Recover to a new high after a 10% drawdown"},
+}
+
+// accountProgress tracks the running peak and worst drawdown of an account
+type accountProgress struct {
+	UserID      string  `bson:"user_id"`
+	PeakValue   float64 `bson:"peak_value"`
+	MaxDrawdown float64 `bson:"max_drawdown"`
+}
+
+type AchievementService struct {
+	achievementCollection *mongo.Collection
+	progressCollection    *mongo.Collection
+	userCollection        *mongo.Collection
+	orderService          *OrderService
+	tenants               *TenantService
+	ledger                *LedgerService
+	hub                   *WebSocketHub
+}
+
+func NewAchievementService(orderService *OrderService, tenants *TenantService, ledger *LedgerService, hub *WebSocketHub, events *EventBus) *AchievementService {
+	s := &AchievementService{
+		achievementCollection: config.GetCollection("achievements"),
+		progressCollection:    config.GetCollection("achievement_progress"),
+		userCollection:        config.GetCollection("users"),
+		orderService:          orderService,
+		tenants:               tenants,
+		ledger:                ledger,
+		hub:                   hub,
+	}
+	events.SubscribeAsync(EventOrderFilled, func(event Event) {
+		order := event.Payload.(models.Order)
+		if order.CompetitionID != "" {
+			return
+		}
+		s.unlock(order.UserID, "first_trade")
+		s.Evaluate(order.UserID)
+	})
+	return s
+}
+
+// GetAchievements returns every achievement with the user's unlock status
+func (s *AchievementService) GetAchievements(ctx context.Context, userID string) ([]models.Achievement, error) {
+	cursor, err := s.achievementCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var unlocked []models.Achievement
+	if err := cursor.All(ctx, &unlocked); err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]models.Achievement, len(unlocked))
+	for _, a := range unlocked {
+		byCode[a.Code] = a
+	}
+
+	list := make([]models.Achievement, 0, len(achievementDefinitions))
+	for _, def := range achievementDefinitions {
+		achievement, ok := byCode[def.Code]
+		if !ok {
+			achievement = models.Achievement{UserID: userID, Code: def.Code, Name: def.Name, Description: def.Description}
+		}
+		achievement.Unlocked = ok
+		list = append(list, achievement)
+	}
+	return list, nil
+}
+
+// Evaluate checks the portfolio-based achievements against the user's
+// current account snapshot
+func (s *AchievementService) Evaluate(userID string) {
+	positions, err := s.orderService.GetUserPortfolio(context.Background(), userID)
+	if err != nil {
+		return
+	}
+	if len(positions) >= diversifiedPositions {
+		s.unlock(userID, "diversified")
+	}
+
+	user := s.user(userID)
+	if user == nil {
+		return
+	}
+	deposited, err := s.ledger.NetDeposits(context.Background(), userID, user.AccountResetAt)
+	if err != nil {
+		return
+	}
+	// Deposits and withdrawals are neither gains nor drawdowns
+	totalValue := s.orderService.GetCashBalance(context.Background(), userID) + s.orderService.GetTotalPortfolioValue(context.Background(), userID) - deposited
+	if start := s.tenants.OpeningBalance(*user); start > 0 && totalValue >= start*(1+targetReturnPercent/100) {
+		s.unlock(userID, "ten_percent_return")
+	}
+
+	progress := accountProgress{UserID: userID}
+	err = s.progressCollection.FindOne(context.Background(), bson.M{"user_id": userID}).Decode(&progress)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return
+	}
+
+	if totalValue >= progress.PeakValue {
+		if progress.MaxDrawdown >= crashDrawdownPercent {
+			s.unlock(userID, "survived_crash")
+		}
+		progress.PeakValue = totalValue
+		progress.MaxDrawdown = 0
+	} else if progress.PeakValue > 0 {
+		drawdown := (progress.PeakValue - totalValue) / progress.PeakValue * 100
+		if drawdown > progress.MaxDrawdown {
+			progress.MaxDrawdown = drawdown
+		}
+	}
+
+	_, err = s.progressCollection.UpdateOne(context.Background(),
+		bson.M{"user_id": userID},
+		bson.M{"$set": progress},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Error saving achievement progress for %s: %v", userID, err)
+	}
+}
+
+// EvaluateAll takes a snapshot of every account and checks achievements
+func (s *AchievementService) EvaluateAll() {
+	cursor, err := s.userCollection.Find(context.Background(), bson.M{},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Printf("Error loading users for achievements: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			continue
+		}
+		s.Evaluate(user.ID.Hex())
+	}
+}
+
+// unlock stores the achievement once and announces it on the user's socket
+func (s *AchievementService) unlock(userID, code string) {
+	var def achievementDefinition
+	for _, d := range achievementDefinitions {
+		if d.Code == code {
+			def = d
+		}
+	}
+
+	achievement := models.Achievement{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		Code:        def.Code,
+		Name:        def.Name,
+		Description: def.Description,
+		Unlocked:    true,
+		UnlockedAt:  time.Now().UTC(),
+	}
+	result, err := s.achievementCollection.UpdateOne(context.Background(),
+		bson.M{"user_id": userID, "code": code},
+		bson.M{"$setOnInsert": achievement},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Error unlocking achievement %s for %s: %v", code, userID, err)
+		return
+	}
+	if result.UpsertedCount == 0 {
+		return
+	}
+
+	log.Printf("🏆 Achievement unlocked: %s for user %s", def.Name, userID)
+	if user := s.user(userID); user != nil {
+		lang := user.Language
+		if lang == "" {
+			lang = i18n.English
+		}
+		achievement.Name = i18n.Translate(lang, "achievement."+code+".name")
+		achievement.Description = i18n.Translate(lang, "achievement."+code+".description")
+		s.hub.SendToUser(userID, map[string]interface{}{
+			"type":        "achievement",
+			"code":        "notification.achievement_unlocked",
+			"message":     i18n.Translate(lang, "notification.achievement_unlocked", achievement.Name),
+			"achievement": achievement,
+		})
+	}
+}
+
+func (s *AchievementService) user(userID string) *models.User {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil
+	}
+	return &user
+}
@@ -2,64 +2,215 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"trading-simulator/internal/i18n"
 	"trading-simulator/internal/models"
 	"trading-simulator/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Statuses of orders that have not filled or been cancelled yet
+var openOrderStatuses = []string{"scheduled", "active"}
+
 type AdvancedOrderService struct {
 	orderCollection     *mongo.Collection
 	portfolioCollection *mongo.Collection
+	userCollection      *mongo.Collection
 	marketDataService   *MarketDataService
 	orderService        *OrderService
+	engine              *OrderEngine
+	hub                 *WebSocketHub
+	defaultValidity     time.Duration // Applied to orders placed without an expiry; zero for none
+	downgradeSells      bool          // Sell stops fill the shares left instead of failing when short
+
+	mu            sync.Mutex
+	trailingMarks map[primitive.ObjectID]float64 // High-water marks of active trailing stops
 }
 
-func NewAdvancedOrderService(marketDataService *MarketDataService) *AdvancedOrderService {
+func NewAdvancedOrderService(marketDataService *MarketDataService, engine *OrderEngine, hub *WebSocketHub) *AdvancedOrderService {
 	return &AdvancedOrderService{
 		orderCollection:     config.GetCollection("advanced_orders"),
 		portfolioCollection: config.GetCollection("portfolio"),
+		userCollection:      config.GetCollection("users"),
 		marketDataService:   marketDataService,
-		orderService:        NewOrderService(marketDataService), // fixed: pass marketDataService
+		orderService:        engine.orderService,
+		engine:              engine,
+		hub:                 hub,
+		defaultValidity:     time.Duration(config.GetEnvInt("ADVANCED_ORDER_VALIDITY_DAYS", 90)) * 24 * time.Hour,
+		downgradeSells:      config.GetEnv("STOP_DOWNGRADE_TO_POSITION", "true") == "true",
+		trailingMarks:       make(map[primitive.ObjectID]float64),
 	}
 }
 
-func (s *AdvancedOrderService) CreateStopOrder(order *models.Order) error {
+// CreateStopOrder rests a stop order until it triggers. When ocoWith names
+// another open order of the user, the two become one-cancels-other. An order
+// with ActivateAt set, of any type, is stored as scheduled until then: stops
+// start watching the price, market and limit orders fill once. An order with
+// a Condition rests until the condition holds, and a stop with one triggers
+// only while it holds.
+func (s *AdvancedOrderService) CreateStopOrder(ctx context.Context, order *models.Order, ocoWith string) error {
+	strategy, err := s.engine.Prepare(ctx, order)
+	if err != nil {
+		return err
+	}
+	scheduled := !order.ActivateAt.IsZero()
+	resting := strategy.Resting() || order.Condition != nil
+	if !resting && !scheduled {
+		return i18n.NewError("order.immediate_only", order.OrderType)
+	}
+
 	order.ID = primitive.NewObjectID()
-	order.Timestamp = time.Now()
+	order.Timestamp = time.Now().UTC()
 	order.Status = "active"
+	live := order.Timestamp
+	if scheduled {
+		order.Status = "scheduled"
+		order.ActivateAt = order.ActivateAt.UTC()
+		if !order.ActivateAt.After(order.Timestamp) {
+			return i18n.NewError("order.activation_in_past")
+		}
+		live = order.ActivateAt
+	}
+	// Validity runs from when the order starts watching the price; scheduled
+	// market and limit orders fill or fail when they activate
+	if order.ExpiresAt.IsZero() && s.defaultValidity > 0 && resting {
+		order.ExpiresAt = live.Add(s.defaultValidity)
+	}
+	if !order.ExpiresAt.IsZero() && !order.ExpiresAt.After(order.Timestamp) {
+		return i18n.NewError("order.expiry_in_past")
+	}
+	if scheduled && !order.ExpiresAt.IsZero() && !order.ExpiresAt.After(order.ActivateAt) {
+		return i18n.NewError("order.expiry_before_activation")
+	}
+
+	var pair *models.Order
+	if ocoWith != "" {
+		if pair, err = s.findOCOPair(ctx, order, ocoWith); err != nil {
+			return err
+		}
+		order.OCOGroup = pair.OCOGroup
+		if order.OCOGroup == "" {
+			order.OCOGroup = pair.ID.Hex()
+		}
+	}
 
-	if order.Type == "sell" {
+	if order.Type == "sell" && order.CompetitionID == "" {
 		var portfolio models.Portfolio
-		err := s.portfolioCollection.FindOne(context.Background(), bson.M{
-			"user_id": order.UserID,
-			"symbol":  order.Symbol,
-		}).Decode(&portfolio)
+		err := s.portfolioCollection.FindOne(ctx,
+			positionFilter(order.UserID, "", order.Symbol),
+		).Decode(&portfolio)
 
 		if err != nil || portfolio.Shares < order.Quantity {
-			return fmt.Errorf("insufficient shares for stop loss order")
+			return i18n.NewError("order.insufficient_shares_for_stop")
 		}
 	}
 
-	_, err := s.orderCollection.InsertOne(context.Background(), order)
+	// Hold cash for open buys at the worst price the order can fill at
+	if order.Type == "buy" && order.CompetitionID == "" {
+		order.ReservedAmount = s.engine.HoldPrice(order) * order.Quantity
+		if err := s.orderService.ReserveCash(ctx, order.UserID, order.ReservedAmount); err != nil {
+			return err
+		}
+	}
+
+	// The order and the link to its pair are stored together, so an order
+	// is never left open outside the group it was placed in
+	var pairChanges bson.M
+	err = s.orderService.runInTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.orderCollection.InsertOne(ctx, order); err != nil {
+			return err
+		}
+		if pair == nil || pair.OCOGroup != "" {
+			return nil
+		}
+		// The pair may have triggered or been cancelled since it was found
+		changes := bson.M{"oco_group": order.OCOGroup}
+		result, err := s.orderCollection.UpdateOne(ctx,
+			bson.M{"_id": pair.ID, "status": bson.M{"$in": openOrderStatuses}},
+			bson.M{"$set": changes},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return i18n.NewError("order.oco_not_found")
+		}
+		pairChanges = changes
+		return nil
+	})
 	if err != nil {
+		// Without transactions the insert may have gone through
+		ctx := context.WithoutCancel(ctx)
+		if _, delErr := s.orderCollection.DeleteOne(ctx, bson.M{"_id": order.ID}); delErr != nil {
+			log.Printf("Error removing unlinked OCO order %s: %v", order.ID.Hex(), delErr)
+		}
+		s.releaseHold(ctx, order)
 		return err
 	}
+	if err := s.orderService.history.created(ctx, OrderEventCreated, order); err != nil {
+		log.Printf("Error recording creation of order %s: %v", order.ID.Hex(), err)
+	}
+	if pairChanges != nil {
+		s.orderService.history.changed(ctx, pair, OrderEventAmended, pairChanges, order.ID.Hex())
+	}
 
-	log.Printf("STOP Order Created: %s %s %d shares @ $%.2f trigger for user %s",
-		order.Symbol, order.Type, order.Quantity, order.StopPrice, order.UserID)
+	if scheduled {
+		log.Printf("SCHEDULED Order Created: %s %s %s %g shares at %s for user %s",
+			order.Symbol, order.OrderType, order.Type, order.Quantity, order.ActivateAt.Format(time.RFC3339), order.UserID)
+	} else if !strategy.Resting() {
+		log.Printf("CONDITIONAL Order Created: %s %s %s %g shares if %s for user %s",
+			order.Symbol, order.OrderType, order.Type, order.Quantity, order.Condition.Expression, order.UserID)
+	} else {
+		log.Printf("STOP Order Created: %s %s %g shares @ $%.2f trigger for user %s",
+			order.Symbol, order.Type, order.Quantity, order.StopPrice, order.UserID)
+	}
+	s.orderService.events.Publish(EventOrderPlaced, order.UserID, *order)
 	return nil
 }
 
+func (s *AdvancedOrderService) findOCOPair(ctx context.Context, order *models.Order, pairID string) (*models.Order, error) {
+	objID, err := primitive.ObjectIDFromHex(pairID)
+	if err != nil {
+		return nil, i18n.NewError("order.oco_invalid_id")
+	}
+
+	var pair models.Order
+	err = s.orderCollection.FindOne(ctx, bson.M{
+		"_id":     objID,
+		"user_id": order.UserID,
+		"status":  bson.M{"$in": openOrderStatuses},
+	}).Decode(&pair)
+	if err == mongo.ErrNoDocuments {
+		return nil, i18n.NewError("order.oco_not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pair.CompetitionID != order.CompetitionID {
+		return nil, i18n.NewError("order.oco_other_account")
+	}
+	return &pair, nil
+}
+
+// CheckAndExecuteStopOrders fills active stops whose trigger is hit and
+// conditional orders whose condition holds. A stop with a condition needs
+// both. Orders wait while their symbol is halted or its market closed.
 func (s *AdvancedOrderService) CheckAndExecuteStopOrders() {
 	cursor, err := s.orderCollection.Find(context.Background(), bson.M{
 		"status": "active",
-		"order_type": bson.M{"$in": []string{"stop", "stop_limit", "trailing_stop"}},
+		"$or": bson.A{
+			bson.M{"order_type": bson.M{"$in": []string{"stop", "stop_limit", "trailing_stop"}}},
+			bson.M{"condition": bson.M{"$exists": true}},
+		},
 	})
 	if err != nil {
 		return
@@ -72,100 +223,572 @@ func (s *AdvancedOrderService) CheckAndExecuteStopOrders() {
 	}
 
 	for _, order := range activeOrders {
+		if !s.engine.CanTrade(&order) {
+			continue
+		}
 		currentPrice := s.getCurrentPrice(order.Symbol)
 
-		if s.shouldTriggerStopOrder(order, currentPrice) {
+		if order.OrderType == "trailing_stop" {
+			s.updateTrailingStop(&order, currentPrice)
+		}
+		if order.Condition != nil {
+			price, ok := s.marketDataService.GetLastPrice(order.Condition.Symbol)
+			if !ok || !orderConditionHolds(order.Condition, price) {
+				continue
+			}
+			if strategy, ok := s.engine.strategies[order.OrderType]; ok && !strategy.Resting() {
+				s.executeOnce(&order, "active")
+				continue
+			}
+		}
+		if s.engine.Triggered(&order, currentPrice) {
 			s.executeStopOrder(&order, currentPrice)
 		}
 	}
 }
 
+// updateTrailingStop moves the high-water mark, and the stop with it, when
+// the price runs in the order's favour. Marks are persisted on the order so
+// RecoverOrders can rebuild them after a restart.
+func (s *AdvancedOrderService) updateTrailingStop(order *models.Order, currentPrice float64) {
+	if order.TrailingPercent <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	mark, ok := s.trailingMarks[order.ID]
+	if !ok {
+		mark = order.HighWaterMark
+	}
+	improved := mark == 0 ||
+		(order.Type == "sell" && currentPrice > mark) ||
+		(order.Type == "buy" && currentPrice < mark)
+	if improved {
+		mark = currentPrice
+	}
+	s.trailingMarks[order.ID] = mark
+	s.mu.Unlock()
+
+	if !improved {
+		return
+	}
+
+	stopPrice := mark * (1 - order.TrailingPercent/100)
+	if order.Type == "buy" {
+		stopPrice = mark * (1 + order.TrailingPercent/100)
+	}
+	order.HighWaterMark = mark
+	order.StopPrice = stopPrice
+
+	changes := bson.M{"high_water_mark": mark, "stop_price": stopPrice}
+	result, err := s.orderCollection.UpdateOne(context.Background(),
+		bson.M{"_id": order.ID, "status": "active"},
+		bson.M{"$set": changes},
+	)
+	if err != nil {
+		log.Printf("Error saving trailing stop %s: %v", order.ID.Hex(), err)
+	} else if result.ModifiedCount > 0 {
+		s.orderService.history.changed(context.Background(), order, OrderEventAmended, changes, "trailing_stop")
+	}
+}
+
+// RecoverOrders reloads active stop orders from Mongo on startup, rebuilds
+// trailing-stop high-water marks and logs a consistency report
+func (s *AdvancedOrderService) RecoverOrders() {
+	cursor, err := s.orderCollection.Find(context.Background(), bson.M{"status": "active"})
+	if err != nil {
+		log.Printf("Error loading active stop orders for recovery: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var orders []models.Order
+	if err := cursor.All(context.Background(), &orders); err != nil {
+		log.Printf("Error decoding active stop orders for recovery: %v", err)
+		return
+	}
+
+	byType := make(map[string]int)
+	var problems []string
+	marks := make(map[primitive.ObjectID]float64)
+
+	for _, order := range orders {
+		byType[order.OrderType]++
+		id := order.ID.Hex()
+
+		switch order.OrderType {
+		case "stop":
+			if order.StopPrice <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: stop order has no stop price", id))
+			}
+		case "stop_limit":
+			if order.StopPrice <= 0 || order.LimitPrice <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: stop-limit order is missing stop or limit price", id))
+			}
+		case "trailing_stop":
+			if order.TrailingPercent <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: trailing stop has no trailing percent", id))
+				continue
+			}
+			mark := order.HighWaterMark
+			if mark == 0 && order.StopPrice > 0 {
+				// Orders saved before marks were persisted: derive the mark from the stop
+				if order.Type == "sell" {
+					mark = order.StopPrice / (1 - order.TrailingPercent/100)
+				} else {
+					mark = order.StopPrice / (1 + order.TrailingPercent/100)
+				}
+			}
+			marks[order.ID] = mark
+		case "market", "limit":
+			if order.Condition == nil {
+				problems = append(problems, fmt.Sprintf("%s: active %s order has no condition", id, order.OrderType))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown order type %q", id, order.OrderType))
+		}
+
+		if order.Type == "sell" && order.CompetitionID == "" {
+			var position models.Portfolio
+			err := s.portfolioCollection.FindOne(context.Background(),
+				positionFilter(order.UserID, "", order.Symbol),
+			).Decode(&position)
+			if err != nil || position.Shares < order.Quantity {
+				problems = append(problems, fmt.Sprintf("%s: sell stop for %g %s exceeds held shares", id, order.Quantity, order.Symbol))
+			}
+		}
+	}
+
+	// Claims left behind by an instance that stopped mid-execution. These
+	// may or may not have filled, so they are reported rather than retried.
+	stuck, err := s.orderCollection.CountDocuments(context.Background(), bson.M{"status": "triggering"})
+	if err == nil && stuck > 0 {
+		problems = append(problems, fmt.Sprintf("%d stop orders stuck in triggering state need manual review", stuck))
+	}
+
+	s.mu.Lock()
+	s.trailingMarks = marks
+	s.mu.Unlock()
+
+	log.Printf("🔁 Recovered %d active stop orders (stop=%d stop_limit=%d trailing_stop=%d conditional=%d), %d trailing marks rebuilt",
+		len(orders), byType["stop"], byType["stop_limit"], byType["trailing_stop"], byType["market"]+byType["limit"], len(marks))
+	if len(problems) == 0 {
+		log.Println("✅ Stop order consistency check passed")
+		return
+	}
+	log.Printf("⚠️ Stop order consistency check found %d issues:\n  %s", len(problems), strings.Join(problems, "\n  "))
+}
+
 func (s *AdvancedOrderService) getCurrentPrice(symbol string) float64 {
-	stock, err := s.marketDataService.GetStockPrice(symbol)
+	stock, err := s.marketDataService.GetStockPrice(context.Background(), symbol)
 	if err != nil {
 		return 100.0
 	}
 	return stock.Price
 }
 
-func (s *AdvancedOrderService) shouldTriggerStopOrder(order models.Order, currentPrice float64) bool {
-	switch order.OrderType {
-	case "stop":
-		if order.Type == "sell" {
-			return currentPrice <= order.StopPrice
-		}
-		return currentPrice >= order.StopPrice
-	case "stop_limit":
-		if order.Type == "sell" {
-			return currentPrice <= order.StopPrice && currentPrice >= order.LimitPrice
+// executeStopOrder claims the order by moving it from active to triggering
+// in a single atomic update. Only the instance whose update matched goes on
+// to fill it, so a stop executes exactly once even with several monitors.
+func (s *AdvancedOrderService) executeStopOrder(order *models.Order, currentPrice float64) {
+	var claimed models.Order
+	triggeredAt := time.Now().UTC()
+	changes := bson.M{
+		"status":       "triggering",
+		"triggered_at": triggeredAt,
+		"price":        currentPrice,
+	}
+	err := s.orderCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": order.ID, "status": "active"},
+		bson.M{"$set": changes},
+	).Decode(&claimed)
+	if err == mongo.ErrNoDocuments {
+		return // Cancelled or claimed by another instance
+	}
+	if err != nil {
+		log.Printf("Error claiming stop order: %v", err)
+		return
+	}
+	s.orderService.history.changed(context.Background(), order, OrderEventTriggered, changes, "")
+	s.forgetTrailingMark(order.ID)
+
+	executionOrder := &models.Order{
+		UserID:        order.UserID,
+		Symbol:        order.Symbol,
+		Type:          order.Type,
+		OrderType:     "market",
+		Quantity:      order.Quantity,
+		Price:         currentPrice,
+		CompetitionID: order.CompetitionID,
+		TenantID:      order.TenantID,
+		ParentOrderID: order.ID.Hex(),
+	}
+	s.fillClaimed(order, executionOrder, triggeredAt)
+}
+
+// ActivateScheduledOrders puts scheduled orders into effect once their
+// activation time has passed. Stop types become active and wait for their
+// trigger; market and limit orders fill once at the current quote, or fail.
+// Market and limit orders stay scheduled while their symbol cannot trade,
+// which is how orders queued while the market was closed fill at the open.
+func (s *AdvancedOrderService) ActivateScheduledOrders() {
+	ctx := context.Background()
+	cursor, err := s.orderCollection.Find(ctx, bson.M{
+		"status":      "scheduled",
+		"activate_at": bson.M{"$lte": time.Now().UTC()},
+	})
+	if err != nil {
+		log.Printf("Error loading scheduled orders: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var due []models.Order
+	if err := cursor.All(ctx, &due); err != nil {
+		log.Printf("Error decoding scheduled orders: %v", err)
+		return
+	}
+
+	activated := 0
+	for _, order := range due {
+		if strategy, ok := s.engine.strategies[order.OrderType]; (ok && strategy.Resting()) || order.Condition != nil {
+			changes := bson.M{"status": "active"}
+			result, err := s.orderCollection.UpdateOne(ctx,
+				bson.M{"_id": order.ID, "status": "scheduled"},
+				bson.M{"$set": changes},
+			)
+			if err != nil {
+				log.Printf("Error activating scheduled order %s: %v", order.ID.Hex(), err)
+			} else if result.ModifiedCount > 0 {
+				s.orderService.history.changed(ctx, &order, OrderEventActivated, changes, "")
+				activated++
+			}
+			continue
 		}
-		return currentPrice >= order.StopPrice && currentPrice <= order.LimitPrice
-	case "trailing_stop":
-		if order.Type == "sell" {
-			return currentPrice <= order.StopPrice
+		if !s.engine.CanTrade(&order) {
+			continue
 		}
-		return currentPrice >= order.StopPrice
+		s.executeOnce(&order, "scheduled")
+	}
+	if activated > 0 {
+		log.Printf("⏰ Activated %d scheduled orders", activated)
 	}
-	return false
 }
 
-func (s *AdvancedOrderService) executeStopOrder(order *models.Order, currentPrice float64) {
-	_, err := s.orderCollection.UpdateOne(
+// executeOnce claims a market or limit order that is due, moving it from
+// status to triggering the same way as a triggered stop, and fills it once.
+// A limit that is not marketable by then fails rather than resting.
+func (s *AdvancedOrderService) executeOnce(order *models.Order, status string) {
+	triggeredAt := time.Now().UTC()
+	changes := bson.M{"status": "triggering", "triggered_at": triggeredAt}
+	err := s.orderCollection.FindOneAndUpdate(
 		context.Background(),
-		bson.M{"_id": order.ID},
-		bson.M{"$set": bson.M{
-			"status":       "triggered",
-			"triggered_at": time.Now(),
-			"price":        currentPrice,
-		}},
-	)
+		bson.M{"_id": order.ID, "status": status},
+		bson.M{"$set": changes},
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return // Cancelled or claimed by another instance
+	}
 	if err != nil {
-		log.Printf("Error updating stop order: %v", err)
+		log.Printf("Error claiming %s order: %v", status, err)
 		return
 	}
+	s.orderService.history.changed(context.Background(), order, OrderEventTriggered, changes, "")
 
 	executionOrder := &models.Order{
-		UserID:    order.UserID,
-		Symbol:    order.Symbol,
-		Type:      order.Type,
-		OrderType: "market",
-		Quantity:  order.Quantity,
-		Price:     currentPrice,
+		UserID:        order.UserID,
+		Symbol:        order.Symbol,
+		Type:          order.Type,
+		OrderType:     order.OrderType,
+		Quantity:      order.Quantity,
+		Price:         order.Price,
+		CompetitionID: order.CompetitionID,
+		TenantID:      order.TenantID,
+		ParentOrderID: order.ID.Hex(),
 	}
+	if order.OrderType == "market" {
+		executionOrder.Price = s.getCurrentPrice(order.Symbol)
+	}
+	s.fillClaimed(order, executionOrder, triggeredAt)
+}
+
+// fillClaimed fills an order claimed into the triggering state through
+// executionOrder, records the outcome on it and publishes it
+func (s *AdvancedOrderService) fillClaimed(order, executionOrder *models.Order, triggeredAt time.Time) {
+	s.releaseHold(context.Background(), order) // The fill below spends the cash instead
+	s.cancelOCOSiblings(order)
 
-	if err = s.orderService.PlaceOrder(executionOrder); err != nil {
-		log.Printf("Error executing stop order: %v", err)
+	update := bson.M{"status": "triggered"}
+	err := s.checkPosition(executionOrder)
+	if err == nil {
+		err = s.engine.Execute(context.Background(), executionOrder)
+	}
+	if err != nil {
+		update["status"] = "failed"
+		update["fail_reason"] = "execution_error"
+		var msgErr *i18n.Error
+		if errors.As(err, &msgErr) {
+			update["fail_reason"] = msgErr.Code
+		}
+		update["fail_message"] = err.Error()
+		log.Printf("Error executing %s order %s: %v", order.OrderType, order.ID.Hex(), err)
 	} else {
-		log.Printf("STOP Order Triggered: %s %s %d shares @ $%.2f for user %s",
-			order.Symbol, order.Type, order.Quantity, currentPrice, order.UserID)
+		update["trade_id"] = executionOrder.TradeID
+		if executionOrder.Quantity != order.Quantity {
+			update["filled_quantity"] = executionOrder.Quantity
+		}
+		log.Printf("%s Order Triggered: %s %s %g shares @ $%.2f for user %s",
+			strings.ToUpper(order.OrderType), order.Symbol, order.Type, executionOrder.Quantity, executionOrder.Price, order.UserID)
+	}
+
+	status := update["status"]
+	result, err := s.orderCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": order.ID, "status": "triggering"},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		log.Printf("Error updating %s order %s to %s: %v", order.OrderType, order.ID.Hex(), status, err)
+	} else if result.ModifiedCount > 0 {
+		eventType := OrderEventFilled
+		if status == "failed" {
+			eventType = OrderEventFailed
+		} else if executionOrder.Quantity != order.Quantity {
+			eventType = OrderEventPartiallyFilled
+		}
+		s.orderService.history.changed(context.Background(), order, eventType, update, executionOrder.ID.Hex())
 	}
+
+	triggered := *order
+	triggered.Status = status.(string)
+	triggered.Price = executionOrder.Price
+	triggered.TriggeredAt = triggeredAt
+	triggered.FilledQuantity = executionOrder.Quantity
+	s.orderService.events.Publish(EventOrderTriggered, order.UserID, triggered)
 }
 
-func (s *AdvancedOrderService) GetActiveStopOrders(userID string) ([]models.Order, error) {
-	cursor, err := s.orderCollection.Find(context.Background(), bson.M{
+// checkPosition re-validates a triggered sell stop against the shares held
+// now, since they may have been sold since the stop was placed. With
+// STOP_DOWNGRADE_TO_POSITION on, a short position shrinks the order instead
+// of failing it.
+func (s *AdvancedOrderService) checkPosition(order *models.Order) error {
+	if order.Type != "sell" || order.CompetitionID != "" {
+		return nil
+	}
+	var position models.Portfolio
+	err := s.portfolioCollection.FindOne(context.Background(),
+		positionFilter(order.UserID, "", order.Symbol),
+	).Decode(&position)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if position.Shares <= 0 {
+		return i18n.NewError("order.no_position", order.Symbol)
+	}
+	if position.Shares < order.Quantity {
+		if !s.downgradeSells {
+			return i18n.NewError("order.insufficient_shares", position.Shares, order.Quantity)
+		}
+		order.Quantity = position.Shares
+	}
+	return nil
+}
+
+// GetStopOrderHistory returns the user's stop orders that are no longer
+// active, newest first, with the reasons any failed or were cancelled
+func (s *AdvancedOrderService) GetStopOrderHistory(ctx context.Context, userID, status string, limit int64) ([]models.Order, error) {
+	filter := bson.M{
 		"user_id": userID,
-		"status":  "active",
+		"status":  bson.M{"$in": []string{"triggered", "failed", "cancelled"}},
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.M{"timestamp": -1}).SetLimit(limit)
+	cursor, err := s.orderCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	err = cursor.All(ctx, &orders)
+	return orders, err
+}
+
+// GetActiveStopOrders returns the user's open orders, including scheduled ones
+func (s *AdvancedOrderService) GetActiveStopOrders(ctx context.Context, userID string) ([]models.Order, error) {
+	cursor, err := s.orderCollection.Find(ctx, bson.M{
+		"user_id": userID,
+		"status":  bson.M{"$in": openOrderStatuses},
 	})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var orders []models.Order
-	err = cursor.All(context.Background(), &orders)
+	err = cursor.All(ctx, &orders)
 	return orders, err
 }
 
-func (s *AdvancedOrderService) CancelStopOrder(orderID string) error {
+func (s *AdvancedOrderService) CancelStopOrder(ctx context.Context, orderID string) error {
 	objID, err := primitive.ObjectIDFromHex(orderID)
 	if err != nil {
 		return err
 	}
-
-	_, err = s.orderCollection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": objID},
-		bson.M{"$set": bson.M{"status": "cancelled"}},
-	)
+	_, err = s.cancelOrder(ctx, objID, "user")
 	return err
-}
\ No newline at end of file
+}
+
+// cancelOrder moves an active or scheduled order to cancelled, recording
+// why, and returns the hold on its cash
+func (s *AdvancedOrderService) cancelOrder(ctx context.Context, orderID primitive.ObjectID, reason string) (*models.Order, error) {
+	var order models.Order
+	changes := bson.M{"status": "cancelled", "cancel_reason": reason}
+	err := s.orderCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": orderID, "status": bson.M{"$in": openOrderStatuses}},
+		bson.M{"$set": changes},
+	).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, i18n.NewError("order.not_active")
+	}
+	if err != nil {
+		return nil, err
+	}
+	order.Status = "cancelled"
+	order.CancelReason = reason
+	s.orderService.history.changed(context.WithoutCancel(ctx), &order, OrderEventCancelled, changes, "")
+
+	s.forgetTrailingMark(orderID)
+	// The order is cancelled now, so its hold goes back even if the request
+	// has timed out in the meantime
+	s.releaseHold(context.WithoutCancel(ctx), &order)
+	s.orderService.events.Publish(EventOrderCancelled, order.UserID, order)
+	return &order, nil
+}
+
+// SweepStaleOrders cancels open orders whose validity has run out, and
+// active sell stops in the main account whose shares are all gone, then
+// tells the owners over the WebSocket
+func (s *AdvancedOrderService) SweepStaleOrders() {
+	ctx := context.Background()
+	cursor, err := s.orderCollection.Find(ctx, bson.M{"status": bson.M{"$in": openOrderStatuses}})
+	if err != nil {
+		log.Printf("Error loading active orders for sweep: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		log.Printf("Error decoding active orders for sweep: %v", err)
+		return
+	}
+
+	now := time.Now()
+	cancelled := 0
+	for _, order := range orders {
+		reason := ""
+		switch {
+		case !order.ExpiresAt.IsZero() && now.After(order.ExpiresAt):
+			reason = "expired"
+		case order.Status == "active" && order.Type == "sell" && order.CompetitionID == "" && !s.holdsShares(ctx, &order):
+			reason = "no_position"
+		default:
+			continue
+		}
+
+		stale, err := s.cancelOrder(ctx, order.ID, reason)
+		if err != nil {
+			continue // Triggered or cancelled since it was loaded
+		}
+		cancelled++
+		s.notifyCancelled(stale)
+	}
+	if cancelled > 0 {
+		log.Printf("🧹 Auto-cancelled %d stale stop orders", cancelled)
+	}
+}
+
+// holdsShares reports whether the user still has any of the order's symbol
+// in their main account
+func (s *AdvancedOrderService) holdsShares(ctx context.Context, order *models.Order) bool {
+	var position models.Portfolio
+	err := s.portfolioCollection.FindOne(ctx, positionFilter(order.UserID, "", order.Symbol)).Decode(&position)
+	if err == mongo.ErrNoDocuments {
+		return false
+	}
+	// Keep the order when the position cannot be read
+	return err != nil || position.Shares > 0
+}
+
+// notifyCancelled tells the order's owner, in their language, that the
+// system cancelled it
+func (s *AdvancedOrderService) notifyCancelled(order *models.Order) {
+	objID, err := primitive.ObjectIDFromHex(order.UserID)
+	if err != nil {
+		return
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&user); err != nil {
+		return
+	}
+	lang := user.Language
+	if lang == "" {
+		lang = i18n.English
+	}
+
+	code := "notification.order_" + order.CancelReason
+	s.hub.SendToUser(order.UserID, map[string]interface{}{
+		"type":    "order_cancelled",
+		"code":    code,
+		"message": i18n.Translate(lang, code, order.OrderType, order.Type, order.Quantity, order.Symbol),
+		"order":   order,
+	})
+}
+
+// cancelOCOSiblings cancels the other open orders in a triggered order's group
+func (s *AdvancedOrderService) cancelOCOSiblings(order *models.Order) {
+	if order.OCOGroup == "" {
+		return
+	}
+
+	cursor, err := s.orderCollection.Find(context.Background(), bson.M{
+		"oco_group": order.OCOGroup,
+		"status":    bson.M{"$in": openOrderStatuses},
+		"_id":       bson.M{"$ne": order.ID},
+	})
+	if err != nil {
+		log.Printf("Error loading OCO siblings of %s: %v", order.ID.Hex(), err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var siblings []models.Order
+	if err := cursor.All(context.Background(), &siblings); err != nil {
+		return
+	}
+	for _, sibling := range siblings {
+		if _, err := s.cancelOrder(context.Background(), sibling.ID, "oco"); err != nil {
+			log.Printf("Error cancelling OCO sibling %s: %v", sibling.ID.Hex(), err)
+		}
+	}
+}
+
+func (s *AdvancedOrderService) releaseHold(ctx context.Context, order *models.Order) {
+	if order.ReservedAmount <= 0 {
+		return
+	}
+	if err := s.orderService.ReleaseCash(ctx, order.UserID, order.ReservedAmount); err != nil {
+		log.Printf("Error releasing $%.2f held for order %s: %v", order.ReservedAmount, order.ID.Hex(), err)
+	}
+}
+
+func (s *AdvancedOrderService) forgetTrailingMark(orderID primitive.ObjectID) {
+	s.mu.Lock()
+	delete(s.trailingMarks, orderID)
+	s.mu.Unlock()
+}
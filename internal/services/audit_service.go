@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Topics recorded in the audit log
+var auditTopics = []string{EventUserEmailChanged, EventUserUsernameChanged}
+
+// Audit actions recorded directly rather than through the event bus
+const (
+	AuditImpersonationStarted = "impersonation.started" // An admin was issued a token for the user's account
+	AuditImpersonatedRequest  = "impersonation.request" // A request made with that token
+	AuditImpersonationBlocked = "impersonation.blocked" // A write refused because the token is read-only
+)
+
+// AuditService keeps the audit log: account changes published on the event
+// bus, stored so support can see who changed what and when
+type AuditService struct {
+	auditCollection *mongo.Collection
+}
+
+func NewAuditService(events *EventBus) *AuditService {
+	s := &AuditService{auditCollection: config.GetCollection("audit_log")}
+	for _, topic := range auditTopics {
+		events.SubscribeAsync(topic, s.onEvent)
+	}
+	return s
+}
+
+func (s *AuditService) onEvent(event Event) {
+	entry, ok := event.Payload.(models.AuditEntry)
+	if !ok {
+		return
+	}
+	entry.Action = event.Topic
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = event.Timestamp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Record(ctx, entry); err != nil {
+		log.Printf("Error recording %s for %s in the audit log: %v", entry.Action, entry.UserID, err)
+	}
+}
+
+// Record appends an entry to the audit log
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.auditCollection.InsertOne(ctx, entry)
+	return err
+}
+
+// List returns audit entries, newest first
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := bson.M{}
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(filter.Limit)
+	cursor, err := s.auditCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.AuditEntry{}
+	err = cursor.All(ctx, &entries)
+	return entries, err
+}
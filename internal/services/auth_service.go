@@ -3,39 +3,125 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"trading-simulator/internal/i18n"
 	"trading-simulator/internal/models"
 	"trading-simulator/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// usernameCollation compares usernames and emails ignoring case, so Alice
+// and alice are the same account
+var usernameCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// UserConflictError rejects a registration whose username or email belongs
+// to another account
+type UserConflictError struct {
+	Err   *i18n.Error
+	Field string // "username" or "email"
+}
+
+func (e *UserConflictError) Error() string { return e.Err.Error() }
+func (e *UserConflictError) Unwrap() error { return e.Err }
+
+func userConflict(user *models.User, field string) *UserConflictError {
+	if field == "email" {
+		return &UserConflictError{Err: i18n.NewError("user.email_taken", user.Email), Field: field}
+	}
+	return &UserConflictError{Err: i18n.NewError("user.username_taken", user.Username), Field: field}
+}
+
 type AuthService struct {
-	userCollection *mongo.Collection
+	userCollection  *mongo.Collection
+	referralService *ReferralService
+	tenants         *TenantService
+	tiers           *TierService
+	events          *EventBus
+	mailer          Mailer
+	journal         *journal
+
+	usernameCooldown time.Duration // Minimum time between username changes, from USERNAME_CHANGE_COOLDOWN_DAYS
 }
 
-func NewAuthService() *AuthService {
+func NewAuthService(referralService *ReferralService, tenants *TenantService, tiers *TierService, events *EventBus, mailer Mailer) *AuthService {
 	return &AuthService{
-		userCollection: config.GetCollection("users"),
+		userCollection:   config.GetCollection("users"),
+		referralService:  referralService,
+		tenants:          tenants,
+		tiers:            tiers,
+		events:           events,
+		mailer:           mailer,
+		journal:          newJournal(),
+		usernameCooldown: time.Duration(config.GetEnvInt("USERNAME_CHANGE_COOLDOWN_DAYS", 30)) * 24 * time.Hour,
 	}
 }
 
-// Register creates a new user
-func (s *AuthService) Register(user *models.User) error {
-	// Check if user already exists
+// EnsureIndexes makes usernames and emails unique regardless of case. It
+// fails while duplicates registered before the indexes existed remain.
+func (s *AuthService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.userCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName("username_unique").SetUnique(true).SetCollation(usernameCollation),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true).SetCollation(usernameCollation),
+		},
+	})
+	return err
+}
+
+// Register creates a new user in their chosen tier, the default one if
+// none. An optional invite code links the user to their referrer and
+// credits the referral bonus.
+func (s *AuthService) Register(ctx context.Context, user *models.User, inviteCode string) error {
+	var referrer *models.User
+	if inviteCode != "" {
+		var err error
+		referrer, err = s.referralService.FindReferrer(ctx, inviteCode)
+		if err != nil {
+			return err
+		}
+		// Invite codes only work inside the referrer's own tenant
+		if referrer.TenantID != user.TenantID {
+			return errors.New("invalid invite code")
+		}
+	}
+
+	if user.Tier == "" {
+		user.Tier = defaultTier
+	}
+	tier, err := s.tiers.GetTier(user.Tier)
+	if err != nil {
+		return err
+	}
+
+	user.Username = strings.TrimSpace(user.Username)
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+
+	// Check if user already exists; the unique indexes catch registrations
+	// racing past this check
 	var existingUser models.User
-	err := s.userCollection.FindOne(context.Background(), bson.M{
+	err = s.userCollection.FindOne(ctx, bson.M{
 		"$or": []bson.M{
 			{"username": user.Username},
 			{"email": user.Email},
 		},
-	}).Decode(&existingUser)
+	}, options.FindOne().SetCollation(usernameCollation)).Decode(&existingUser)
 
 	if err == nil {
-		return errors.New("username or email already exists")
+		if strings.EqualFold(existingUser.Username, user.Username) {
+			return userConflict(user, "username")
+		}
+		return userConflict(user, "email")
 	} else if err != mongo.ErrNoDocuments {
 		return err
 	}
@@ -48,25 +134,54 @@ func (s *AuthService) Register(user *models.User) error {
 
 	// Set default values
 	user.ID = primitive.NewObjectID()
-	user.CashBalance = 10000.0 // Start with $10,000
-	user.CreatedAt = time.Now()
+	user.CashBalance = tier.StartingBalance
+	if user.CashBalance <= 0 {
+		user.CashBalance = s.tenants.StartingBalance(user.TenantID) // $10,000 unless the tenant sets its own
+	}
+	user.StartingBalance = user.CashBalance
+	user.CreatedAt = time.Now().UTC()
 
 	// Insert user
-	_, err = s.userCollection.InsertOne(context.Background(), user)
+	_, err = s.userCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		if strings.Contains(err.Error(), "email_unique") {
+			return userConflict(user, "email")
+		}
+		return userConflict(user, "username")
+	}
 	if err != nil {
 		return err
 	}
 
 	log.Printf("✅ New user registered: %s", user.Username)
+
+	// The balance was written with the user; the journal only records it
+	if _, err := s.journal.record(ctx, openingEntry(user.ID.Hex(), "", user.TenantID, user.CashBalance, 0, user.CreatedAt)); err != nil {
+		log.Printf("Error recording opening balance for %s: %v", user.Username, err)
+	}
+
+	if referrer != nil {
+		bonus, err := s.referralService.RecordReferral(ctx, referrer, user)
+		if err != nil {
+			log.Printf("Error recording referral for %s: %v", user.Username, err)
+		} else {
+			user.ReferredBy = referrer.ID.Hex()
+			user.CashBalance += bonus
+		}
+	}
+
+	registered := *user
+	registered.Password = ""
+	s.events.Publish(EventUserRegistered, user.ID.Hex(), registered)
 	return nil
 }
 
 // Login authenticates a user
-func (s *AuthService) Login(username, password string) (*models.User, error) {
+func (s *AuthService) Login(ctx context.Context, username, password string) (*models.User, error) {
 	var user models.User
-	err := s.userCollection.FindOne(context.Background(), bson.M{
+	err := s.userCollection.FindOne(ctx, bson.M{
 		"username": username,
-	}).Decode(&user)
+	}, options.FindOne().SetCollation(usernameCollation)).Decode(&user)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -76,7 +191,7 @@ func (s *AuthService) Login(username, password string) (*models.User, error) {
 	}
 
 	// Check password
-	if !user.CheckPassword(password) {
+	if !user.CheckPassword(password) || !user.DeletedAt.IsZero() {
 		return nil, errors.New("invalid username or password")
 	}
 
@@ -86,14 +201,14 @@ func (s *AuthService) Login(username, password string) (*models.User, error) {
 }
 
 // GetUserByID returns a user by their ID
-func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
+func (s *AuthService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, err
 	}
 
 	var user models.User
-	err = s.userCollection.FindOne(context.Background(), bson.M{
+	err = s.userCollection.FindOne(ctx, bson.M{
 		"_id": objID,
 	}).Decode(&user)
 
@@ -103,4 +218,108 @@ func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 
 	user.Password = ""
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// SetTimezone stores the user's preferred timezone for report times
+func (s *AuthService) SetTimezone(ctx context.Context, userID, timezone string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return fmt.Errorf("unknown timezone %q", timezone)
+	}
+	if timezone == "UTC" {
+		timezone = ""
+	}
+
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"timezone": timezone}},
+	)
+	return err
+}
+
+// SetLanguage stores the user's preferred message language
+// SetHidePresence opts the user out of, or back into, presence lists
+func (s *AuthService) SetHidePresence(ctx context.Context, userID string, hide bool) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"hide_presence": hide}},
+	)
+	return err
+}
+
+// SetPublicProfile makes the user findable in search with a public
+// profile, or hides them again
+func (s *AuthService) SetPublicProfile(ctx context.Context, userID string, public bool) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"public_profile": public}},
+	)
+	return err
+}
+
+// SetDigest subscribes the user to daily or weekly email digests, or
+// unsubscribes them with an empty frequency
+func (s *AuthService) SetDigest(ctx context.Context, userID, frequency string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	if frequency != "" && digestPeriod(frequency) == 0 {
+		return fmt.Errorf("digest must be daily, weekly or empty")
+	}
+
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"digest": frequency}},
+	)
+	return err
+}
+
+// SetCurrency stores the currency the user's balances are shown in; USD is
+// stored as empty
+func (s *AuthService) SetCurrency(ctx context.Context, userID, currency string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	currency = strings.ToUpper(currency)
+	if currency != "" && !SupportedCurrency(currency) {
+		return fmt.Errorf("unsupported currency %q", currency)
+	}
+	if currency == BaseCurrency {
+		currency = ""
+	}
+
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"currency": currency}},
+	)
+	return err
+}
+
+func (s *AuthService) SetLanguage(ctx context.Context, userID, language string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	if language != "" && !i18n.Supported(language) {
+		return fmt.Errorf("unsupported language %q", language)
+	}
+
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"language": language}},
+	)
+	return err
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrBasketNotFound = errors.New("basket not found")
+	// ErrBasketNeedsTransactions rejects an all-or-nothing basket on a
+	// standalone MongoDB, where its legs could not fill atomically
+	ErrBasketNeedsTransactions = errors.New("all-or-nothing baskets need a MongoDB replica set with transactions; place the basket without allOrNothing")
+)
+
+// BasketService places several market or limit orders in one request, for
+// rebalancing a portfolio or tracking an index. Sells fill before buys so
+// their proceeds can pay for the buys.
+type BasketService struct {
+	basketCollection *mongo.Collection
+	engine           *OrderEngine
+	maxLegs          int
+}
+
+func NewBasketService(engine *OrderEngine) *BasketService {
+	return &BasketService{
+		basketCollection: config.GetCollection("baskets"),
+		engine:           engine,
+		maxLegs:          config.GetEnvInt("BASKET_MAX_LEGS", 25),
+	}
+}
+
+// PlaceBasket fills the basket's legs and stores the basket with what became
+// of each. An all-or-nothing basket fills in a single transaction and is
+// rejected as a whole when any leg fails, or refused outright when MongoDB
+// cannot run transactions; otherwise each leg fills or fails on its own.
+// Leg errors are written in lang.
+func (s *BasketService) PlaceBasket(ctx context.Context, basket *models.Basket, lang string) error {
+	if len(basket.Legs) == 0 || len(basket.Legs) > s.maxLegs {
+		return fmt.Errorf("a basket needs 1-%d legs", s.maxLegs)
+	}
+	seen := make(map[string]bool, len(basket.Legs))
+	for i := range basket.Legs {
+		leg := &basket.Legs[i]
+		leg.Symbol = strings.ToUpper(strings.TrimSpace(leg.Symbol))
+		if seen[leg.Symbol] {
+			return fmt.Errorf("%s appears in more than one leg", leg.Symbol)
+		}
+		seen[leg.Symbol] = true
+	}
+	if basket.AllOrNothing && s.engine.orderService.transactionsUnsupported.Load() {
+		return ErrBasketNeedsTransactions
+	}
+	if err := s.engine.orderService.guard.CheckBasket(basket.UserID, basket.TenantID); err != nil {
+		return err
+	}
+
+	basket.ID = primitive.NewObjectID()
+	basket.CreatedAt = time.Now().UTC()
+
+	orders := make([]*models.Order, len(basket.Legs))
+	var ready []int
+	for i, leg := range basket.Legs {
+		orders[i] = &models.Order{
+			UserID:        basket.UserID,
+			Symbol:        leg.Symbol,
+			Type:          leg.Type,
+			OrderType:     leg.OrderType,
+			Quantity:      leg.Quantity,
+			Notional:      leg.Notional,
+			Price:         leg.Price,
+			CompetitionID: basket.CompetitionID,
+			TenantID:      basket.TenantID,
+			BasketID:      basket.ID.Hex(),
+			Timestamp:     basket.CreatedAt,
+		}
+		if (leg.Quantity > 0) == (leg.Notional > 0) {
+			rejectLeg(&basket.Legs[i], errors.New("exactly one of quantity or notional is required"), lang)
+			continue
+		}
+		strategy, err := s.engine.prepare(ctx, orders[i], false)
+		if err == nil && strategy.Resting() {
+			err = i18n.NewError("order.advanced_only", leg.OrderType)
+		}
+		if err != nil {
+			rejectLeg(&basket.Legs[i], err, lang)
+			continue
+		}
+		ready = append(ready, i)
+	}
+	sort.SliceStable(ready, func(a, b int) bool {
+		return orders[ready[a]].Type == "sell" && orders[ready[b]].Type == "buy"
+	})
+
+	if basket.AllOrNothing {
+		if err := s.fillTogether(ctx, basket, orders, ready, lang); err != nil {
+			return err
+		}
+	} else {
+		for _, i := range ready {
+			s.engine.orderService.events.Publish(EventOrderPlaced, basket.UserID, *orders[i])
+			if err := s.engine.orderService.fillOrder(ctx, orders[i]); err != nil {
+				rejectLeg(&basket.Legs[i], err, lang)
+				continue
+			}
+			fillLeg(&basket.Legs[i], orders[i])
+		}
+	}
+
+	filled := 0
+	for _, leg := range basket.Legs {
+		if leg.Status == "filled" {
+			filled++
+		}
+	}
+	switch filled {
+	case len(basket.Legs):
+		basket.Status = "filled"
+	case 0:
+		basket.Status = "rejected"
+	default:
+		basket.Status = "partial"
+	}
+
+	// The fills stand even if the basket record cannot be written
+	if _, err := s.basketCollection.InsertOne(context.WithoutCancel(ctx), basket); err != nil {
+		log.Printf("Error saving basket %s: %v", basket.ID.Hex(), err)
+	}
+	log.Printf("BASKET Order: %d of %d legs filled for user %s (%s)", filled, len(basket.Legs), basket.UserID, basket.ID.Hex())
+	return nil
+}
+
+// fillTogether fills the ready legs of an all-or-nothing basket, or none of
+// them when any leg was rejected or fails to fill. It returns
+// ErrBasketNeedsTransactions when MongoDB turns out not to support them.
+func (s *BasketService) fillTogether(ctx context.Context, basket *models.Basket, orders []*models.Order, ready []int, lang string) error {
+	if len(ready) < len(basket.Legs) {
+		for _, i := range ready {
+			basket.Legs[i].Status = "not_filled"
+		}
+		return nil
+	}
+
+	batch := make([]*models.Order, len(ready))
+	for n, i := range ready {
+		batch[n] = orders[i]
+		s.engine.orderService.events.Publish(EventOrderPlaced, basket.UserID, *orders[i])
+	}
+	err := s.engine.orderService.fillAll(ctx, batch)
+	if errors.Is(err, errTransactionsUnsupported) {
+		return ErrBasketNeedsTransactions
+	}
+	if err == nil {
+		for _, i := range ready {
+			fillLeg(&basket.Legs[i], orders[i])
+		}
+		return nil
+	}
+
+	failed := -1
+	var fillErr *fillError
+	if errors.As(err, &fillErr) {
+		failed = fillErr.Index
+		err = fillErr.Err
+	}
+	for n, i := range ready {
+		if n == failed || failed < 0 {
+			rejectLeg(&basket.Legs[i], err, lang)
+		} else {
+			basket.Legs[i].Status = "not_filled"
+		}
+	}
+	return nil
+}
+
+// GetBasket returns one of the user's baskets
+func (s *BasketService) GetBasket(ctx context.Context, userID string, basketID primitive.ObjectID) (*models.Basket, error) {
+	var basket models.Basket
+	err := s.basketCollection.FindOne(ctx, bson.M{"_id": basketID, "user_id": userID}).Decode(&basket)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrBasketNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &basket, nil
+}
+
+func fillLeg(leg *models.BasketLeg, order *models.Order) {
+	leg.Status = "filled"
+	leg.OrderID = order.ID.Hex()
+	leg.Quantity = order.Quantity
+	leg.Price = order.Price
+	leg.Fees = order.Fees
+}
+
+func rejectLeg(leg *models.BasketLeg, err error, lang string) {
+	leg.Status = "rejected"
+	leg.Error = err.Error()
+	var msgErr *i18n.Error
+	if errors.As(err, &msgErr) {
+		leg.Error = msgErr.Translate(lang)
+		leg.ErrorCode = msgErr.Code
+	}
+}
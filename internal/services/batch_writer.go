@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"trading-simulator/config"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BatchWriter buffers documents for one collection and inserts them in
+// batches, when a batch fills up or on a periodic flush, so high-frequency
+// data costs one round trip per batch instead of one per document. When
+// Mongo falls behind and the buffer is full, writers wait briefly for room
+// and the document is dropped if none frees up, so a slow database sheds
+// samples instead of stalling the callers.
+type BatchWriter struct {
+	collection *mongo.Collection
+	docs       chan interface{}
+	batchSize  int
+	flushEvery time.Duration
+	maxWait    time.Duration
+	dropped    atomic.Int64
+}
+
+// NewBatchWriter creates a writer for the collection. BATCH_WRITE_SIZE
+// (default 500) caps a batch, BATCH_WRITE_FLUSH_MS (default 1000) is the
+// longest a document waits to be written, BATCH_WRITE_BUFFER (default 10000)
+// bounds the documents held in memory and BATCH_WRITE_MAX_WAIT_MS (default
+// 50) is how long Write waits for room in a full buffer.
+func NewBatchWriter(collection *mongo.Collection) *BatchWriter {
+	return &BatchWriter{
+		collection: collection,
+		docs:       make(chan interface{}, max(config.GetEnvInt("BATCH_WRITE_BUFFER", 10000), 1)),
+		batchSize:  max(config.GetEnvInt("BATCH_WRITE_SIZE", 500), 1),
+		flushEvery: time.Duration(max(config.GetEnvInt("BATCH_WRITE_FLUSH_MS", 1000), 10)) * time.Millisecond,
+		maxWait:    time.Duration(config.GetEnvInt("BATCH_WRITE_MAX_WAIT_MS", 50)) * time.Millisecond,
+	}
+}
+
+// Write queues a document. It reports false when the buffer stayed full for
+// the whole wait and the document was dropped.
+func (w *BatchWriter) Write(doc interface{}) bool {
+	select {
+	case w.docs <- doc:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(w.maxWait)
+	defer timer.Stop()
+	select {
+	case w.docs <- doc:
+		return true
+	case <-timer.C:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns how many documents have been dropped for a full buffer
+func (w *BatchWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Run writes queued documents until the process exits
+func (w *BatchWriter) Run() {
+	ticker := time.NewTicker(w.flushEvery)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, w.batchSize)
+	reported := int64(0)
+	for {
+		select {
+		case doc := <-w.docs:
+			batch = append(batch, doc)
+			if len(batch) < w.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if dropped := w.dropped.Load(); dropped > reported {
+				log.Printf("⚠️ %s writer dropped %d documents; Mongo is not keeping up", w.collection.Name(), dropped-reported)
+				reported = dropped
+			}
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		w.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush inserts a batch unordered, so one bad document does not hold back
+// the rest
+func (w *BatchWriter) flush(batch []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := w.collection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false)); err != nil {
+		log.Printf("Error writing %d documents to %s: %v", len(batch), w.collection.Name(), err)
+	}
+}
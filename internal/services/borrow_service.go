@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrUnknownSymbol is returned when configuring a symbol that does not exist
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
+// BorrowService keeps the admin's short-sale settings per symbol and charges
+// the daily borrow fee on short positions
+type BorrowService struct {
+	settingsCollection  *mongo.Collection
+	portfolioCollection *mongo.Collection
+	symbolService       *SymbolService
+	marketService       *MarketDataService
+	ledger              *LedgerService
+}
+
+func NewBorrowService(symbolService *SymbolService, marketService *MarketDataService, ledger *LedgerService) *BorrowService {
+	return &BorrowService{
+		settingsCollection:  config.GetCollection("symbol_borrow"),
+		portfolioCollection: config.GetCollection("portfolio"),
+		symbolService:       symbolService,
+		marketService:       marketService,
+		ledger:              ledger,
+	}
+}
+
+// Load applies the stored settings. Custom symbols must be loaded first.
+func (s *BorrowService) Load(ctx context.Context) {
+	cursor, err := s.settingsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("Error loading borrow settings: %v", err)
+		return
+	}
+	var stored []models.BorrowSettings
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("Error decoding borrow settings: %v", err)
+		return
+	}
+	for _, settings := range stored {
+		s.symbolService.SetBorrow(settings)
+	}
+}
+
+// Update validates, stores and applies a symbol's short-sale settings
+func (s *BorrowService) Update(ctx context.Context, settings models.BorrowSettings) (*models.BorrowSettings, error) {
+	settings.Symbol = strings.ToUpper(settings.Symbol)
+	if !s.symbolService.HasSymbol(settings.Symbol) {
+		return nil, ErrUnknownSymbol
+	}
+	if settings.BorrowFeeRate < 0 || settings.BorrowFeeRate > 1000 {
+		return nil, fmt.Errorf("borrow fee rate must be between 0 and 1000 percent a year")
+	}
+	if settings.BorrowLimit < 0 {
+		return nil, fmt.Errorf("borrow limit cannot be negative")
+	}
+	settings.BorrowLimit = roundQuantity(settings.BorrowLimit)
+	settings.UpdatedAt = time.Now().UTC()
+
+	_, err := s.settingsCollection.ReplaceOne(ctx, bson.M{"_id": settings.Symbol}, settings, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	s.symbolService.SetBorrow(settings)
+	log.Printf("📉 Borrow settings for %s: hard to borrow %v, fee %.2f%%, limit %g", settings.Symbol, settings.HardToBorrow, settings.BorrowFeeRate, settings.BorrowLimit)
+	return &settings, nil
+}
+
+// ChargeDailyFees posts a day of borrow fees on every short position, main
+// and competition accounts alike: the position's value times its symbol's
+// annual fee rate over 365. Fees are keyed by position and UTC date, so
+// running it again on the same day does nothing.
+func (s *BorrowService) ChargeDailyFees(ctx context.Context, now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	cursor, err := s.portfolioCollection.Find(ctx, bson.M{"shares": bson.M{"$lt": 0}})
+	if err != nil {
+		log.Printf("Error loading short positions: %v", err)
+		return
+	}
+	var positions []models.Portfolio
+	if err := cursor.All(ctx, &positions); err != nil {
+		log.Printf("Error decoding short positions: %v", err)
+		return
+	}
+
+	charged := 0
+	for _, pos := range positions {
+		rate := s.symbolService.GetSymbol(pos.Symbol).BorrowFeeRate
+		price, ok := s.marketService.GetLastPrice(pos.Symbol)
+		if !ok {
+			price = pos.AvgCost
+		}
+		value := round2(-pos.Shares * price)
+		fee := math.Round(value*rate/100/interestDaysPerYear*100) / 100
+		if fee <= 0 {
+			continue
+		}
+
+		done, err := s.ledger.Post(ctx, models.LedgerEntry{
+			ID:            fmt.Sprintf("borrow_fee-%s-%s", pos.ID.Hex(), day),
+			UserID:        pos.UserID,
+			Type:          "borrow_fee",
+			Amount:        -fee,
+			Balance:       value,
+			Rate:          rate,
+			Description:   fmt.Sprintf("Borrow fee on %g %s short ($%.2f) at %.2f%% a year", -pos.Shares, pos.Symbol, value, rate),
+			CompetitionID: pos.CompetitionID,
+			TenantID:      pos.TenantID,
+			CreatedAt:     now.UTC(),
+		})
+		if err != nil {
+			log.Printf("Error charging borrow fee for %s %s: %v", pos.UserID, pos.Symbol, err)
+			continue
+		}
+		if done {
+			charged++
+		}
+	}
+	if charged > 0 {
+		log.Printf("📉 Charged borrow fees on %d short positions for %s", charged, day)
+	}
+}
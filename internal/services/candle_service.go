@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Most candles returned for one symbol
+const maxCandles = 365
+
+// Candles written per bulk write when importing history
+const candleImportBatch = 1000
+
+// CandleService stores daily price history
+type CandleService struct {
+	candleCollection *mongo.Collection
+}
+
+func NewCandleService() *CandleService {
+	return &CandleService{candleCollection: config.GetCollection("candles")}
+}
+
+// GetCandles returns up to limit of the most recent candles for a symbol,
+// oldest first
+func (s *CandleService) GetCandles(ctx context.Context, symbol string, limit int) ([]models.Candle, error) {
+	if limit <= 0 || limit > maxCandles {
+		limit = maxCandles
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.candleCollection.Find(ctx, bson.M{"symbol": strings.ToUpper(symbol)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	candles := []models.Candle{}
+	if err := cursor.All(ctx, &candles); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+	return candles, nil
+}
+
+// CloseBefore returns the close of the symbol's last candle starting before t
+func (s *CandleService) CloseBefore(ctx context.Context, symbol string, t time.Time) (float64, bool) {
+	var candle models.Candle
+	err := s.candleCollection.FindOne(ctx,
+		bson.M{"symbol": strings.ToUpper(symbol), "time": bson.M{"$lt": t}},
+		options.FindOne().SetSort(bson.D{{Key: "time", Value: -1}}),
+	).Decode(&candle)
+	if err != nil {
+		return 0, false
+	}
+	return candle.Close, true
+}
+
+// SaveCandles upserts candles by symbol and time, so regenerating a range
+// replaces it instead of duplicating it
+func (s *CandleService) SaveCandles(ctx context.Context, candles []models.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(candles))
+	for _, candle := range candles {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"symbol": candle.Symbol, "time": candle.Time}).
+			SetReplacement(candle).
+			SetUpsert(true))
+	}
+	_, err := s.candleCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// EnsureIndexes keeps one candle per symbol and day
+func (s *CandleService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.candleCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "time", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ImportCandles writes imported candles by symbol and time. Candles already
+// stored are left alone unless replace is set. It returns how many candles
+// were added and how many stored ones were replaced.
+func (s *CandleService) ImportCandles(ctx context.Context, candles []models.Candle, replace bool) (added, replaced int64, err error) {
+	for start := 0; start < len(candles); start += candleImportBatch {
+		batch := candles[start:min(start+candleImportBatch, len(candles))]
+		writes := make([]mongo.WriteModel, 0, len(batch))
+		for _, candle := range batch {
+			filter := bson.M{"symbol": candle.Symbol, "time": candle.Time}
+			if replace {
+				writes = append(writes, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(candle).SetUpsert(true))
+				continue
+			}
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$setOnInsert": candle}).
+				SetUpsert(true))
+		}
+		result, err := s.candleCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		if result != nil {
+			added += result.UpsertedCount
+			replaced += result.ModifiedCount
+		}
+		if err != nil {
+			return added, replaced, err
+		}
+	}
+	return added, replaced, nil
+}
+
+// GenerateCandles builds a random walk of daily candles for the days before
+// end, finishing at the given close price
+func GenerateCandles(symbol string, lastClose float64, days int, end time.Time) []models.Candle {
+	symbol = strings.ToUpper(symbol)
+	day := end.UTC().Truncate(24 * time.Hour)
+	candles := make([]models.Candle, days)
+
+	closePrice := lastClose
+	for i := days - 1; i >= 0; i-- {
+		day = day.Add(-24 * time.Hour)
+		// Walk backwards: the open is the previous day's close
+		openPrice := closePrice / (1 + (rand.Float64()*4-2)/100)
+		high := math.Max(openPrice, closePrice) * (1 + rand.Float64()/100)
+		low := math.Min(openPrice, closePrice) * (1 - rand.Float64()/100)
+		candles[i] = models.Candle{
+			Symbol: symbol,
+			Time:   day,
+			Open:   round2(openPrice),
+			High:   round2(high),
+			Low:    round2(low),
+			Close:  round2(closePrice),
+			Volume: rand.Int63n(5000000) + 1000000,
+		}
+		closePrice = openPrice
+	}
+	return candles
+}
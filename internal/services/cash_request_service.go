@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrCashRequestType is returned for a request that is neither a deposit nor a withdrawal
+	ErrCashRequestType = errors.New("type must be deposit or withdrawal")
+	// ErrCashRequestAmount is returned for an amount that is not positive or above CASH_REQUEST_MAX
+	ErrCashRequestAmount = errors.New("amount must be positive and within the request limit")
+	// ErrCashRequestFunds is returned for a withdrawal larger than the user's cash, less what open orders hold
+	ErrCashRequestFunds = errors.New("withdrawal exceeds available cash")
+	// ErrCashRequestNotFound is returned for a request that does not exist, or belongs to another tenant
+	ErrCashRequestNotFound = errors.New("cash request not found")
+	// ErrCashRequestReviewed is returned for a request that was already approved or denied
+	ErrCashRequestReviewed = errors.New("cash request was already reviewed")
+	// ErrCashRequestOwn is returned when an admin or teacher reviews their own request
+	ErrCashRequestOwn = errors.New("you cannot review your own request")
+)
+
+// CashRequestService lets users deposit simulated cash and withdraw it. So
+// that nobody can wipe out their losses with a deposit, a request that
+// brings the user's auto-approved requests of its type for the UTC day
+// above CASH_APPROVAL_THRESHOLD (default 10000) waits in a queue for an
+// admin of the user's group, or the teacher of one of the user's
+// classrooms, to approve or deny; smaller ones are applied at once. No
+// request may exceed CASH_REQUEST_MAX (default 1000000). Applied requests
+// are posted to the ledger against capital, and the requester is told of
+// each review over their socket or inbox.
+type CashRequestService struct {
+	requestCollection *mongo.Collection
+	userCollection    *mongo.Collection
+	ledger            *LedgerService
+	hub               *WebSocketHub
+	threshold         float64
+	maxAmount         float64
+}
+
+func NewCashRequestService(ledger *LedgerService, hub *WebSocketHub) *CashRequestService {
+	return &CashRequestService{
+		requestCollection: config.GetCollection("cash_requests"),
+		userCollection:    config.GetCollection("users"),
+		ledger:            ledger,
+		hub:               hub,
+		threshold:         config.GetEnvFloat("CASH_APPROVAL_THRESHOLD", 10000),
+		maxAmount:         config.GetEnvFloat("CASH_REQUEST_MAX", 1000000),
+	}
+}
+
+// EnsureIndexes indexes requests by user and the review queue by tenant
+func (s *CashRequestService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.requestCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+// Request records a deposit or withdrawal for the user and applies it, or
+// leaves it pending when it needs review
+func (s *CashRequestService) Request(ctx context.Context, userID, requestType string, amount float64, note string) (*models.CashRequest, error) {
+	if requestType != "deposit" && requestType != "withdrawal" {
+		return nil, ErrCashRequestType
+	}
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 || amount > s.maxAmount {
+		return nil, ErrCashRequestAmount
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	// Withdrawals come out of cash, not out of margin
+	if requestType == "withdrawal" && amount > user.CashBalance-user.ReservedCash {
+		return nil, ErrCashRequestFunds
+	}
+
+	request := &models.CashRequest{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Username:  user.Username,
+		TenantID:  user.TenantID,
+		Type:      requestType,
+		Amount:    amount,
+		Note:      note,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC(),
+	}
+	day := request.CreatedAt.Format(time.DateOnly)
+	auto, err := s.reserveAutoApproval(ctx, objID, requestType, amount, day)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requestCollection.InsertOne(ctx, request); err != nil {
+		if auto {
+			s.releaseAutoApproval(context.WithoutCancel(ctx), objID, requestType, amount, day)
+		}
+		return nil, err
+	}
+	if !auto {
+		return request, nil
+	}
+
+	request.Status = "approved"
+	request.AutoApproved = true
+	err = s.apply(ctx, request, bson.M{"status": "approved", "auto_approved": true})
+	if err != nil {
+		// A request that could not be applied is dropped rather than queued
+		s.requestCollection.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": request.ID})
+		s.releaseAutoApproval(context.WithoutCancel(ctx), objID, requestType, amount, day)
+		return nil, err
+	}
+	return request, nil
+}
+
+// reserveAutoApproval adds the amount to the user's requests of the type
+// applied without review on the day, unless that would take them above the
+// threshold, and reports whether it did. It is one conditional update on the
+// user, so parallel requests cannot all slip under the threshold.
+func (s *CashRequestService) reserveAutoApproval(ctx context.Context, userID primitive.ObjectID, requestType string, amount float64, day string) (bool, error) {
+	if amount > s.threshold {
+		return false, nil
+	}
+	field := "cash_auto_approved." + requestType
+	result, err := s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": userID, "$or": bson.A{
+			bson.M{field + ".day": bson.M{"$ne": day}},
+			bson.M{field + ".total": bson.M{"$lte": s.threshold - amount}},
+		}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{field: bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$" + field + ".day", day}},
+			bson.M{"day": day, "total": bson.M{"$add": bson.A{"$" + field + ".total", amount}}},
+			bson.M{"day": day, "total": amount},
+		}}}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// releaseAutoApproval takes back an amount reserveAutoApproval added for a
+// request that was not applied
+func (s *CashRequestService) releaseAutoApproval(ctx context.Context, userID primitive.ObjectID, requestType string, amount float64, day string) {
+	field := "cash_auto_approved." + requestType
+	s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": userID, field + ".day": day},
+		bson.M{"$inc": bson.M{field + ".total": -amount}})
+}
+
+// List returns the user's requests, newest first
+func (s *CashRequestService) List(ctx context.Context, userID string, limit int64) ([]models.CashRequest, error) {
+	return s.find(ctx, bson.M{"user_id": userID}, -1, limit)
+}
+
+// Queue returns requests with the status, or of any status when it is
+// empty, limited to the tenant when tenantID is set. Pending requests come
+// oldest first, so the queue is worked in order; others newest first.
+func (s *CashRequestService) Queue(ctx context.Context, tenantID, status string, limit int64) ([]models.CashRequest, error) {
+	filter := bson.M{}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	}
+	order := -1
+	if status != "" {
+		filter["status"] = status
+		if status == "pending" {
+			order = 1
+		}
+	}
+	return s.find(ctx, filter, order, limit)
+}
+
+// ClassroomQueue returns the requests of the classroom's students, like
+// Queue
+func (s *CashRequestService) ClassroomQueue(ctx context.Context, classroom *models.Classroom, status string, limit int64) ([]models.CashRequest, error) {
+	filter := bson.M{"user_id": bson.M{"$in": classroom.StudentIDs}}
+	order := -1
+	if status != "" {
+		filter["status"] = status
+		if status == "pending" {
+			order = 1
+		}
+	}
+	return s.find(ctx, filter, order, limit)
+}
+
+// Review approves or denies a pending request, applying it when approved,
+// and tells the requester. Admins of a tenant only see its requests, and
+// nobody reviews their own.
+func (s *CashRequestService) Review(ctx context.Context, requestID, reviewerID, tenantID string, approve bool, note string) (*models.CashRequest, error) {
+	request, err := s.get(ctx, requestID)
+	if err == nil && tenantID != "" && request.TenantID != tenantID {
+		return nil, ErrCashRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, request, reviewerID, approve, note)
+}
+
+// ReviewForClassroom lets the classroom's teacher review a request from one
+// of its students, like Review
+func (s *CashRequestService) ReviewForClassroom(ctx context.Context, classroom *models.Classroom, requestID string, approve bool, note string) (*models.CashRequest, error) {
+	request, err := s.get(ctx, requestID)
+	if err == nil && !containsString(classroom.StudentIDs, request.UserID) {
+		return nil, ErrCashRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, request, classroom.TeacherID, approve, note)
+}
+
+func (s *CashRequestService) get(ctx context.Context, requestID string) (models.CashRequest, error) {
+	var request models.CashRequest
+	objID, err := primitive.ObjectIDFromHex(requestID)
+	if err != nil {
+		return request, ErrCashRequestNotFound
+	}
+	err = s.requestCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		return request, ErrCashRequestNotFound
+	}
+	return request, err
+}
+
+func (s *CashRequestService) review(ctx context.Context, request models.CashRequest, reviewerID string, approve bool, note string) (*models.CashRequest, error) {
+	if request.UserID == reviewerID {
+		return nil, ErrCashRequestOwn
+	}
+	if request.Status != "pending" {
+		return nil, ErrCashRequestReviewed
+	}
+
+	request.ReviewerID = reviewerID
+	request.ReviewNote = note
+	request.ReviewedAt = time.Now().UTC()
+	review := bson.M{"reviewer_id": reviewerID, "review_note": note, "reviewed_at": request.ReviewedAt}
+	var err error
+	if approve {
+		request.Status = "approved"
+		review["status"] = "approved"
+		err = s.apply(ctx, &request, review)
+	} else {
+		request.Status = "denied"
+		review["status"] = "denied"
+		err = s.claim(ctx, request.ID, review)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.hub.SendToUser(request.UserID, map[string]interface{}{
+		"type":    "cash_request",
+		"request": request,
+	})
+	return &request, nil
+}
+
+// apply marks a pending request with the fields in set and posts it to the
+// ledger, putting it back to pending if the post fails
+func (s *CashRequestService) apply(ctx context.Context, request *models.CashRequest, set bson.M) error {
+	if err := s.claim(ctx, request.ID, set); err != nil {
+		return err
+	}
+
+	amount, description := request.Amount, "Deposit"
+	var check func(ctx context.Context) error
+	if request.Type == "withdrawal" {
+		amount, description = -amount, "Withdrawal"
+		check = func(ctx context.Context) error { return s.checkFunds(ctx, request) }
+	}
+	if request.Note != "" {
+		description += ": " + request.Note
+	}
+	_, err := s.ledger.PostIf(ctx, models.LedgerEntry{
+		ID:          "cash_request:" + request.ID.Hex(),
+		UserID:      request.UserID,
+		Type:        request.Type,
+		Amount:      amount,
+		Description: description,
+		TenantID:    request.TenantID,
+		CreatedAt:   time.Now().UTC(),
+	}, check)
+	if err != nil {
+		s.requestCollection.UpdateOne(context.WithoutCancel(ctx),
+			bson.M{"_id": request.ID},
+			bson.M{
+				"$set":   bson.M{"status": "pending"},
+				"$unset": bson.M{"auto_approved": "", "reviewer_id": "", "review_note": "", "reviewed_at": ""},
+			})
+		return err
+	}
+	return nil
+}
+
+// checkFunds refuses a withdrawal larger than the user's cash less what open
+// orders hold. It runs in the ledger transaction, so a fill or another
+// withdrawal cannot spend the cash between the check and the post.
+func (s *CashRequestService) checkFunds(ctx context.Context, request *models.CashRequest) error {
+	objID, err := primitive.ObjectIDFromHex(request.UserID)
+	if err != nil {
+		return err
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return err
+	}
+	if request.Amount > user.CashBalance-user.ReservedCash {
+		return ErrCashRequestFunds
+	}
+	return nil
+}
+
+// claim sets the fields on a request that is still pending, so two admins
+// cannot both review it
+func (s *CashRequestService) claim(ctx context.Context, requestID primitive.ObjectID, set bson.M) error {
+	result, err := s.requestCollection.UpdateOne(ctx,
+		bson.M{"_id": requestID, "status": "pending"},
+		bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCashRequestReviewed
+	}
+	return nil
+}
+
+func (s *CashRequestService) find(ctx context.Context, filter bson.M, order int, limit int64) ([]models.CashRequest, error) {
+	cursor, err := s.requestCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: order}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	requests := []models.CashRequest{}
+	err = cursor.All(ctx, &requests)
+	return requests, err
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// assetClasses are the asset classes a classroom can allow. The simulator
+// has no options or margin accounts; short selling is its only leverage.
+var assetClasses = []string{"stock", "etf", "crypto"}
+
+// SetRestrictions replaces what the classroom's students may trade. Only
+// orders placed afterwards are checked; open orders are left alone.
+func (s *ClassroomService) SetRestrictions(ctx context.Context, classroomID, teacherID string, restrictions models.TradingRestrictions) (*models.Classroom, error) {
+	classroom, err := s.GetTeacherClassroom(ctx, classroomID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]string, 0, len(restrictions.AssetClasses))
+	for _, class := range restrictions.AssetClasses {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if !containsString(assetClasses, class) {
+			return nil, fmt.Errorf("unknown asset class %q; use %s", class, strings.Join(assetClasses, ", "))
+		}
+		if !containsString(classes, class) {
+			classes = append(classes, class)
+		}
+	}
+	restrictions.AssetClasses = classes
+	restrictions.Symbols = normalizeSymbols(restrictions.Symbols)
+	restrictions.BlockedSymbols = normalizeSymbols(restrictions.BlockedSymbols)
+
+	_, err = s.classroomCollection.UpdateOne(ctx,
+		bson.M{"_id": classroom.ID},
+		bson.M{"$set": bson.M{"restrictions": restrictions}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	classroom.Restrictions = restrictions
+	return classroom, nil
+}
+
+// Permissions combines the user's group symbol universe with the
+// restrictions of every classroom they attend. Each classroom can only take
+// permissions away, so a student in two classrooms gets the stricter of both.
+func (s *ClassroomService) Permissions(ctx context.Context, tenantID, userID string) (*models.TradingPermissions, error) {
+	cursor, err := s.classroomCollection.Find(ctx, bson.M{"student_ids": userID})
+	if err != nil {
+		return nil, err
+	}
+	var classrooms []models.Classroom
+	if err := cursor.All(ctx, &classrooms); err != nil {
+		return nil, err
+	}
+
+	perms := &models.TradingPermissions{
+		AssetClasses:   append([]string{}, assetClasses...),
+		BlockedSymbols: []string{},
+		Shorting:       true,
+		Classrooms:     []string{},
+	}
+	// nil allows every symbol
+	var allowed map[string]bool
+	tenant, err := s.tenants.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != nil && len(tenant.Symbols) > 0 {
+		allowed = intersectSymbols(allowed, normalizeSymbols(tenant.Symbols))
+	}
+
+	for _, classroom := range classrooms {
+		r := classroom.Restrictions
+		if len(r.AssetClasses) == 0 && len(r.Symbols) == 0 && len(r.BlockedSymbols) == 0 && !r.NoShorting {
+			continue
+		}
+		perms.Classrooms = append(perms.Classrooms, classroom.ID.Hex())
+		if len(r.AssetClasses) > 0 {
+			kept := perms.AssetClasses[:0]
+			for _, class := range perms.AssetClasses {
+				if containsString(r.AssetClasses, class) {
+					kept = append(kept, class)
+				}
+			}
+			perms.AssetClasses = kept
+		}
+		if len(r.Symbols) > 0 {
+			allowed = intersectSymbols(allowed, r.Symbols)
+		}
+		for _, symbol := range r.BlockedSymbols {
+			if !containsString(perms.BlockedSymbols, symbol) {
+				perms.BlockedSymbols = append(perms.BlockedSymbols, symbol)
+			}
+		}
+		if r.NoShorting {
+			perms.Shorting = false
+		}
+	}
+	sort.Strings(perms.BlockedSymbols)
+
+	if allowed != nil {
+		// An allowed symbol is still untradable if it is blocked or its
+		// class is not allowed
+		perms.Symbols = []string{}
+		for symbol := range allowed {
+			class := s.orderService.symbolService.GetSymbol(symbol).AssetClass
+			if containsString(perms.AssetClasses, class) && !containsString(perms.BlockedSymbols, symbol) {
+				perms.Symbols = append(perms.Symbols, symbol)
+			}
+		}
+		sort.Strings(perms.Symbols)
+	}
+	return perms, nil
+}
+
+// CheckOrder rejects an order its user's classrooms do not allow. A sell
+// is a short sale when it is for more shares than the position holds.
+func (s *ClassroomService) CheckOrder(ctx context.Context, order *models.Order) error {
+	perms, err := s.Permissions(ctx, order.TenantID, order.UserID)
+	if err != nil {
+		return err
+	}
+	if len(perms.Classrooms) == 0 {
+		return nil
+	}
+
+	symbol := strings.ToUpper(order.Symbol)
+	class := s.orderService.symbolService.GetSymbol(symbol).AssetClass
+	if !containsString(perms.AssetClasses, class) {
+		return i18n.NewError("order.asset_class_restricted", class, symbol)
+	}
+	if containsString(perms.BlockedSymbols, symbol) || (perms.Symbols != nil && !containsString(perms.Symbols, symbol)) {
+		return i18n.NewError("order.symbol_restricted", symbol)
+	}
+	if !perms.Shorting && order.Type == "sell" {
+		var pos models.Portfolio
+		err := s.portfolioCollection.FindOne(ctx, positionFilter(order.UserID, order.CompetitionID, symbol)).Decode(&pos)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if roundQuantity(order.Quantity-pos.Shares) > 0 {
+			return i18n.NewError("order.short_restricted", symbol)
+		}
+	}
+	return nil
+}
+
+// normalizeSymbols uppercases symbols and drops blanks and duplicates
+func normalizeSymbols(symbols []string) []string {
+	normalized := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol != "" && !containsString(normalized, symbol) {
+			normalized = append(normalized, symbol)
+		}
+	}
+	return normalized
+}
+
+// intersectSymbols narrows an allowed set to symbols; a nil set allows all
+func intersectSymbols(allowed map[string]bool, symbols []string) map[string]bool {
+	narrowed := make(map[string]bool)
+	for _, symbol := range symbols {
+		if allowed == nil || allowed[symbol] {
+			narrowed[symbol] = true
+		}
+	}
+	return narrowed
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const recentOrdersPerStudent = 20
+
+type ClassroomService struct {
+	classroomCollection     *mongo.Collection
+	userCollection          *mongo.Collection
+	portfolioCollection     *mongo.Collection
+	advancedOrderCollection *mongo.Collection
+	orderService            *OrderService
+	tenants                 *TenantService
+}
+
+func NewClassroomService(orderService *OrderService, tenants *TenantService) *ClassroomService {
+	return &ClassroomService{
+		classroomCollection:     config.GetCollection("classrooms"),
+		userCollection:          config.GetCollection("users"),
+		portfolioCollection:     config.GetCollection("portfolio"),
+		advancedOrderCollection: config.GetCollection("advanced_orders"),
+		orderService:            orderService,
+		tenants:                 tenants,
+	}
+}
+
+// CreateClassroom creates a class owned by the teacher with a fresh join code
+func (s *ClassroomService) CreateClassroom(ctx context.Context, name, teacherID string) (*models.Classroom, error) {
+	code, err := generateCode(6)
+	if err != nil {
+		return nil, err
+	}
+
+	classroom := &models.Classroom{
+		ID:         primitive.NewObjectID(),
+		Name:       name,
+		TeacherID:  teacherID,
+		JoinCode:   code,
+		StudentIDs: []string{},
+		CreatedAt:  time.Now().UTC(),
+	}
+	if _, err := s.classroomCollection.InsertOne(ctx, classroom); err != nil {
+		return nil, err
+	}
+	return classroom, nil
+}
+
+// ListClassrooms returns classes the user teaches or attends
+func (s *ClassroomService) ListClassrooms(ctx context.Context, userID string) ([]models.Classroom, error) {
+	cursor, err := s.classroomCollection.Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"teacher_id": userID},
+			{"student_ids": userID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var classrooms []models.Classroom
+	err = cursor.All(ctx, &classrooms)
+	return classrooms, err
+}
+
+// JoinClassroom adds the user to the class with the given join code
+func (s *ClassroomService) JoinClassroom(ctx context.Context, code, userID string) (*models.Classroom, error) {
+	var classroom models.Classroom
+	err := s.classroomCollection.FindOne(ctx, bson.M{
+		"join_code": strings.ToUpper(strings.TrimSpace(code)),
+	}).Decode(&classroom)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("invalid join code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if classroom.TeacherID == userID {
+		return nil, errors.New("teachers cannot join their own class")
+	}
+
+	_, err = s.classroomCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": classroom.ID},
+		bson.M{"$addToSet": bson.M{"student_ids": userID}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &classroom, nil
+}
+
+// GetTeacherClassroom loads a class and checks the caller is its teacher
+func (s *ClassroomService) GetTeacherClassroom(ctx context.Context, classroomID, teacherID string) (*models.Classroom, error) {
+	objID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, errors.New("invalid classroom ID")
+	}
+
+	var classroom models.Classroom
+	err = s.classroomCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&classroom)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("classroom not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if classroom.TeacherID != teacherID {
+		return nil, errors.New("only the teacher can manage this classroom")
+	}
+	return &classroom, nil
+}
+
+// GetDashboard returns a read-only summary of every student's account
+func (s *ClassroomService) GetDashboard(ctx context.Context, classroomID, teacherID string) (*models.Classroom, []models.StudentSummary, error) {
+	classroom, err := s.GetTeacherClassroom(ctx, classroomID, teacherID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summaries := make([]models.StudentSummary, 0, len(classroom.StudentIDs))
+	for _, studentID := range classroom.StudentIDs {
+		objID, err := primitive.ObjectIDFromHex(studentID)
+		if err != nil {
+			continue
+		}
+		var student models.User
+		if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&student); err != nil {
+			continue
+		}
+
+		positions, _ := s.orderService.GetUserPortfolio(ctx, studentID)
+		orders, _ := s.orderService.GetUserOrders(ctx, studentID)
+		sort.Slice(orders, func(i, j int) bool { return orders[i].Timestamp.After(orders[j].Timestamp) })
+		if len(orders) > recentOrdersPerStudent {
+			orders = orders[:recentOrdersPerStudent]
+		}
+
+		portfolioValue := s.orderService.GetTotalPortfolioValue(ctx, studentID)
+		summaries = append(summaries, models.StudentSummary{
+			UserID:         studentID,
+			Username:       student.Username,
+			CashBalance:    student.CashBalance,
+			PortfolioValue: portfolioValue,
+			TotalAssets:    student.CashBalance + portfolioValue,
+			Positions:      positions,
+			RecentOrders:   orders,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].TotalAssets > summaries[j].TotalAssets })
+	return classroom, summaries, nil
+}
+
+// SeedStudent replaces a student's main account with the given cash balance
+// and positions, cancelling any active advanced orders. Passing no positions
+// resets the account to cash only.
+func (s *ClassroomService) SeedStudent(ctx context.Context, classroomID, teacherID, studentID string, cashBalance float64, positions []models.Portfolio) error {
+	classroom, err := s.GetTeacherClassroom(ctx, classroomID, teacherID)
+	if err != nil {
+		return err
+	}
+	if !containsString(classroom.StudentIDs, studentID) {
+		return errors.New("student is not in this classroom")
+	}
+	if cashBalance < 0 {
+		return errors.New("cash balance cannot be negative")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(studentID)
+	if err != nil {
+		return errors.New("invalid student ID")
+	}
+
+	// The journal records the reset as capital taken out or paid in, to take
+	// the account from what it held to what it is given
+	held, err := s.orderService.GetUserPortfolio(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	positionCost := 0.0
+	for _, pos := range held {
+		positionCost -= openCost(pos)
+	}
+	balances, _, err := s.orderService.journal.balances(ctx, studentID, "")
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.portfolioCollection.DeleteMany(ctx, positionFilter(studentID, "", "")); err != nil {
+		return err
+	}
+	if err := s.orderService.history.cancelAll(ctx, s.advancedOrderCollection,
+		bson.M{"user_id": studentID, "competition_id": nil}, "account_reset",
+	); err != nil {
+		return err
+	}
+
+	for _, pos := range positions {
+		if pos.Shares <= 0 || pos.Symbol == "" {
+			continue
+		}
+		seeded := models.Portfolio{
+			ID:      primitive.NewObjectID(),
+			UserID:  studentID,
+			Symbol:  strings.ToUpper(pos.Symbol),
+			Shares:  pos.Shares,
+			AvgCost: pos.AvgCost,
+		}
+		if _, err := s.portfolioCollection.InsertOne(ctx, seeded); err != nil {
+			return err
+		}
+		if err := s.orderService.RecordSeed(ctx, seeded); err != nil {
+			return err
+		}
+		positionCost += openCost(seeded)
+	}
+
+	// The account starts over: only activity from now on has to add up to
+	// the new balance
+	now := time.Now().UTC()
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"cash_balance": cashBalance, "starting_balance": cashBalance, "account_reset_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	cash := cashBalance - balances[JournalCash]
+	_, err = s.orderService.journal.record(ctx, models.JournalEntry{
+		ID:          "reset:" + primitive.NewObjectID().Hex(),
+		UserID:      studentID,
+		Type:        "reset",
+		Description: "Account reset by teacher",
+		Postings: []models.Posting{
+			{Account: JournalCash, Amount: cash},
+			{Account: JournalPositions, Amount: positionCost},
+			{Account: JournalCapital, Amount: -(cash + positionCost)},
+		},
+		CreatedAt: now,
+	})
+	return err
+}
+
+// generateCode returns a random uppercase code without easily confused characters
+func generateCode(length int) (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Places watched for leaderboard announcements
+const leaderboardTopPlaces = 3
+
+// CompetitionAnnouncer publishes competition starts, ends and changes in the
+// top places of running competitions on the event bus
+type CompetitionAnnouncer struct {
+	competitions *CompetitionService
+	events       *EventBus
+
+	mu      sync.Mutex
+	leaders map[string][]string // Competition ID -> user IDs in the top places
+}
+
+func NewCompetitionAnnouncer(competitions *CompetitionService, events *EventBus) *CompetitionAnnouncer {
+	return &CompetitionAnnouncer{
+		competitions: competitions,
+		events:       events,
+		leaders:      make(map[string][]string),
+	}
+}
+
+// Check announces what changed since the previous check. The first check of
+// a running competition only records its leaders.
+func (a *CompetitionAnnouncer) Check(ctx context.Context, now time.Time) {
+	started, ended, err := a.competitions.ClaimAnnouncements(ctx, now)
+	if err != nil {
+		log.Printf("Error checking competition announcements: %v", err)
+		return
+	}
+	for _, competition := range started {
+		a.events.Publish(EventCompetitionStarted, "", competition)
+	}
+	for _, competition := range ended {
+		leaderboard, err := a.competitions.Leaderboard(ctx, competition)
+		if err != nil {
+			log.Printf("Error ranking competition %s: %v", competition.ID.Hex(), err)
+			continue
+		}
+		a.events.Publish(EventCompetitionEnded, "", *leaderboard)
+		a.mu.Lock()
+		delete(a.leaders, competition.ID.Hex())
+		a.mu.Unlock()
+	}
+
+	active, err := a.competitions.ActiveCompetitions(ctx, now)
+	if err != nil {
+		log.Printf("Error loading active competitions: %v", err)
+		return
+	}
+	for _, competition := range active {
+		leaderboard, err := a.competitions.Leaderboard(ctx, competition)
+		if err != nil {
+			log.Printf("Error ranking competition %s: %v", competition.ID.Hex(), err)
+			continue
+		}
+		top := make([]string, 0, leaderboardTopPlaces)
+		for _, entry := range leaderboard.Entries[:min(len(leaderboard.Entries), leaderboardTopPlaces)] {
+			top = append(top, entry.UserID)
+		}
+
+		id := competition.ID.Hex()
+		a.mu.Lock()
+		previous, seen := a.leaders[id]
+		a.leaders[id] = top
+		a.mu.Unlock()
+		if seen && !slices.Equal(previous, top) {
+			a.events.Publish(EventLeaderboardChanged, "", *leaderboard)
+		}
+	}
+}
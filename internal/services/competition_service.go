@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Starts and ends older than this are not announced, so competitions that
+// passed while announcements were off are not reported late
+const competitionAnnounceWindow = time.Hour
+
+type CompetitionService struct {
+	competitionCollection *mongo.Collection
+	entryCollection       *mongo.Collection
+	portfolioCollection   *mongo.Collection
+	orderCollection       *mongo.Collection
+	userCollection        *mongo.Collection
+	marketService         *MarketDataService
+	flags                 *FeatureFlagService
+	journal               *journal
+}
+
+func NewCompetitionService(marketService *MarketDataService, flags *FeatureFlagService) *CompetitionService {
+	return &CompetitionService{
+		competitionCollection: config.GetCollection("competitions"),
+		entryCollection:       config.GetCollection("competition_entries"),
+		portfolioCollection:   config.GetCollection("portfolio"),
+		orderCollection:       config.GetCollection("orders"),
+		userCollection:        config.GetCollection("users"),
+		marketService:         marketService,
+		flags:                 flags,
+		journal:               newJournal(),
+	}
+}
+
+// CreateCompetition stores a new competition organized by the given user
+func (s *CompetitionService) CreateCompetition(ctx context.Context, competition *models.Competition) error {
+	normalizeRules(&competition.Rules)
+	if !competition.EndsAt.IsZero() && competition.EndsAt.Before(competition.StartsAt) {
+		return errors.New("competition must end after it starts")
+	}
+
+	competition.ID = primitive.NewObjectID()
+	competition.CreatedAt = time.Now().UTC()
+	if competition.StartsAt.IsZero() {
+		competition.StartsAt = competition.CreatedAt
+	}
+
+	_, err := s.competitionCollection.InsertOne(ctx, competition)
+	return err
+}
+
+// ListCompetitions returns the tenant's competitions, or every competition
+// when tenantID is empty
+func (s *CompetitionService) ListCompetitions(ctx context.Context, tenantID string) ([]models.Competition, error) {
+	filter := bson.M{}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "starts_at", Value: -1}})
+	cursor, err := s.competitionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var competitions []models.Competition
+	err = cursor.All(ctx, &competitions)
+	return competitions, err
+}
+
+// GetCompetition returns a competition. One belonging to another tenant is
+// not found, unless tenantID is empty.
+func (s *CompetitionService) GetCompetition(ctx context.Context, competitionID, tenantID string) (*models.Competition, error) {
+	objID, err := primitive.ObjectIDFromHex(competitionID)
+	if err != nil {
+		return nil, errors.New("invalid competition ID")
+	}
+
+	var competition models.Competition
+	err = s.competitionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&competition)
+	if err == mongo.ErrNoDocuments || (err == nil && tenantID != "" && competition.TenantID != tenantID) {
+		return nil, errors.New("competition not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &competition, nil
+}
+
+// UpdateRules replaces a competition's rule set. Only the organizer may do this.
+func (s *CompetitionService) UpdateRules(ctx context.Context, competitionID, organizerID, tenantID string, rules models.CompetitionRules) (*models.Competition, error) {
+	competition, err := s.GetCompetition(ctx, competitionID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if competition.OrganizerID != organizerID {
+		return nil, errors.New("only the organizer can change competition rules")
+	}
+
+	normalizeRules(&rules)
+	_, err = s.competitionCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": competition.ID},
+		bson.M{"$set": bson.M{"rules": rules}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	competition.Rules = rules
+	return competition, nil
+}
+
+// Join opens a competition account for the user funded with the starting
+// cash. Users only join competitions of their own tenant.
+func (s *CompetitionService) Join(ctx context.Context, competitionID, userID, tenantID string) (*models.CompetitionEntry, error) {
+	competition, err := s.GetCompetition(ctx, competitionID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !competition.EndsAt.IsZero() && time.Now().After(competition.EndsAt) {
+		return nil, i18n.NewError("competition.ended")
+	}
+
+	if _, err := s.GetEntry(ctx, competitionID, userID); err == nil {
+		return nil, errors.New("already joined this competition")
+	}
+
+	entry := &models.CompetitionEntry{
+		ID:            primitive.NewObjectID(),
+		CompetitionID: competitionID,
+		UserID:        userID,
+		TenantID:      competition.TenantID,
+		CashBalance:   competition.Rules.StartingCash,
+		JoinedAt:      time.Now().UTC(),
+	}
+	if _, err := s.entryCollection.InsertOne(ctx, entry); err != nil {
+		return nil, err
+	}
+	if _, err := s.journal.record(ctx, openingEntry(userID, competitionID, "", entry.CashBalance, 0, entry.JoinedAt)); err != nil {
+		log.Printf("Error recording opening balance of %s in competition %s: %v", userID, competitionID, err)
+	}
+	return entry, nil
+}
+
+func (s *CompetitionService) GetEntry(ctx context.Context, competitionID, userID string) (*models.CompetitionEntry, error) {
+	var entry models.CompetitionEntry
+	err := s.entryCollection.FindOne(ctx, bson.M{
+		"competition_id": competitionID,
+		"user_id":        userID,
+	}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, i18n.NewError("competition.not_joined")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetPortfolio returns the user's competition cash account and positions
+func (s *CompetitionService) GetPortfolio(ctx context.Context, competitionID, userID string) (*models.CompetitionEntry, []models.Portfolio, error) {
+	entry, err := s.GetEntry(ctx, competitionID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	positions, err := s.positions(ctx, competitionID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, positions, nil
+}
+
+// ValidateOrder checks an order against the competition's rule set and
+// returns the rules so the caller can apply shorting permissions
+func (s *CompetitionService) ValidateOrder(ctx context.Context, order *models.Order) (*models.CompetitionRules, error) {
+	if !s.flags.IsEnabledFor(FeatureCompetitions, order.UserID) {
+		return nil, i18n.NewError("competition.disabled")
+	}
+	competition, err := s.GetCompetition(ctx, order.CompetitionID, order.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	rules := competition.Rules
+	if !s.flags.IsEnabledFor(FeatureShorting, order.UserID) {
+		rules.AllowShorting = false
+	}
+
+	now := time.Now()
+	if now.Before(competition.StartsAt) {
+		return nil, i18n.NewError("competition.not_started")
+	}
+	if !competition.EndsAt.IsZero() && now.After(competition.EndsAt) {
+		return nil, i18n.NewError("competition.ended")
+	}
+
+	entry, err := s.GetEntry(ctx, order.CompetitionID, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules.AllowedSymbols) > 0 && !containsSymbol(rules.AllowedSymbols, order.Symbol) {
+		return nil, i18n.NewError("competition.symbol_not_allowed", order.Symbol)
+	}
+
+	if rules.MaxTradesPerDay > 0 {
+		count, err := s.orderCollection.CountDocuments(ctx, bson.M{
+			"user_id":        order.UserID,
+			"competition_id": order.CompetitionID,
+			"timestamp":      bson.M{"$gte": now.Add(-24 * time.Hour)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if int(count) >= rules.MaxTradesPerDay {
+			return nil, i18n.NewError("competition.daily_trade_limit", rules.MaxTradesPerDay)
+		}
+	}
+
+	positions, err := s.positions(ctx, order.CompetitionID, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Value the account with the order's symbol at the order price
+	equity := entry.CashBalance
+	grossExposure := 0.0
+	newShares := 0.0
+	found := false
+	for _, pos := range positions {
+		price := order.Price
+		shares := pos.Shares
+		if pos.Symbol == order.Symbol {
+			found = true
+			shares = applyOrderToShares(shares, order)
+			newShares = shares
+		} else if last, ok := s.marketService.GetLastPrice(pos.Symbol); ok {
+			price = last
+		} else {
+			price = pos.AvgCost
+		}
+		equity += pos.Shares * price
+		grossExposure += math.Abs(shares) * price
+	}
+	if !found {
+		newShares = applyOrderToShares(0, order)
+		grossExposure += math.Abs(newShares) * order.Price
+	}
+
+	if newShares < 0 && !rules.AllowShorting {
+		return nil, i18n.NewError("competition.shorting_disabled")
+	}
+	if equity <= 0 {
+		return nil, i18n.NewError("competition.no_equity")
+	}
+
+	leverage := math.Max(rules.MaxLeverage, 1)
+	if grossExposure > equity*leverage+0.005 {
+		return nil, i18n.NewError("competition.max_leverage", leverage, grossExposure, equity)
+	}
+
+	if rules.MaxPositionPercent > 0 {
+		positionPercent := math.Abs(newShares) * order.Price / equity * 100
+		if positionPercent > rules.MaxPositionPercent {
+			return nil, i18n.NewError("competition.max_position", positionPercent, rules.MaxPositionPercent)
+		}
+	}
+
+	return &rules, nil
+}
+
+// Leaderboard ranks a competition's entries by equity, valuing positions at
+// the latest prices
+func (s *CompetitionService) Leaderboard(ctx context.Context, competition models.Competition) (*models.Leaderboard, error) {
+	competitionID := competition.ID.Hex()
+	entries, usernames, err := s.entrants(ctx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking := make([]models.LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		positions, err := s.positions(ctx, competitionID, entry.UserID)
+		if err != nil {
+			return nil, err
+		}
+		equity := entry.CashBalance
+		for _, pos := range positions {
+			price, ok := s.marketService.GetLastPrice(pos.Symbol)
+			if !ok {
+				price = pos.AvgCost
+			}
+			equity += pos.Shares * price
+		}
+		ranking = append(ranking, models.LeaderboardEntry{UserID: entry.UserID, Equity: math.Round(equity*100) / 100})
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Equity > ranking[j].Equity })
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+		ranking[i].Username = usernames[ranking[i].UserID]
+	}
+	return &models.Leaderboard{Competition: competition, Entries: ranking, UpdatedAt: time.Now().UTC()}, nil
+}
+
+// ClaimAnnouncements returns the competitions that started or ended within
+// the last hour and have not been announced, marking them announced. Marking
+// is atomic, so with several instances each announcement is claimed once.
+func (s *CompetitionService) ClaimAnnouncements(ctx context.Context, now time.Time) (started, ended []models.Competition, err error) {
+	claim := func(filter bson.M, field string) ([]models.Competition, error) {
+		cursor, err := s.competitionCollection.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		var candidates []models.Competition
+		if err := cursor.All(ctx, &candidates); err != nil {
+			return nil, err
+		}
+		var claimed []models.Competition
+		for _, competition := range candidates {
+			result, err := s.competitionCollection.UpdateOne(ctx,
+				bson.M{"_id": competition.ID, field: bson.M{"$ne": true}},
+				bson.M{"$set": bson.M{field: true}},
+			)
+			if err != nil {
+				return nil, err
+			}
+			if result.ModifiedCount > 0 {
+				claimed = append(claimed, competition)
+			}
+		}
+		return claimed, nil
+	}
+
+	since := now.Add(-competitionAnnounceWindow)
+	started, err = claim(bson.M{"starts_at": bson.M{"$gt": since, "$lte": now}, "start_announced": bson.M{"$ne": true}}, "start_announced")
+	if err != nil {
+		return nil, nil, err
+	}
+	ended, err = claim(bson.M{"ends_at": bson.M{"$gt": since, "$lte": now}, "end_announced": bson.M{"$ne": true}}, "end_announced")
+	return started, ended, err
+}
+
+// ActiveCompetitions returns the competitions that have started and not ended
+func (s *CompetitionService) ActiveCompetitions(ctx context.Context, now time.Time) ([]models.Competition, error) {
+	cursor, err := s.competitionCollection.Find(ctx, bson.M{
+		"starts_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"ends_at": bson.M{"$exists": false}},
+			bson.M{"ends_at": time.Time{}},
+			bson.M{"ends_at": bson.M{"$gt": now}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	competitions := []models.Competition{}
+	err = cursor.All(ctx, &competitions)
+	return competitions, err
+}
+
+// entrants returns a competition's entries and the entrants' usernames by
+// user ID
+func (s *CompetitionService) entrants(ctx context.Context, competitionID string) ([]models.CompetitionEntry, map[string]string, error) {
+	cursor, err := s.entryCollection.Find(ctx, bson.M{"competition_id": competitionID})
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []models.CompetitionEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		if objID, err := primitive.ObjectIDFromHex(entry.UserID); err == nil {
+			userIDs = append(userIDs, objID)
+		}
+	}
+	usernames := make(map[string]string, len(entries))
+	cursor, err = s.userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}}, options.Find().SetProjection(bson.M{"username": 1}))
+	if err != nil {
+		return nil, nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, nil, err
+	}
+	for _, user := range users {
+		usernames[user.ID.Hex()] = user.Username
+	}
+	return entries, usernames, nil
+}
+
+func (s *CompetitionService) positions(ctx context.Context, competitionID, userID string) ([]models.Portfolio, error) {
+	cursor, err := s.portfolioCollection.Find(ctx, positionFilter(userID, competitionID, ""))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var positions []models.Portfolio
+	err = cursor.All(ctx, &positions)
+	return positions, err
+}
+
+func applyOrderToShares(shares float64, order *models.Order) float64 {
+	if order.Type == "sell" {
+		return roundQuantity(shares - order.Quantity)
+	}
+	return roundQuantity(shares + order.Quantity)
+}
+
+func containsSymbol(symbols []string, symbol string) bool {
+	for _, s := range symbols {
+		if strings.EqualFold(s, symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRules(rules *models.CompetitionRules) {
+	for i, symbol := range rules.AllowedSymbols {
+		rules.AllowedSymbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	if rules.StartingCash <= 0 {
+		rules.StartingCash = 10000.0
+	}
+	if rules.MaxLeverage < 1 {
+		rules.MaxLeverage = 1
+	}
+}
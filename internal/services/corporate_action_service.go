@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CorporateActionService struct {
+	actionCollection        *mongo.Collection
+	portfolioCollection     *mongo.Collection
+	advancedOrderCollection *mongo.Collection
+	orderService            *OrderService
+	symbolService           *SymbolService
+	marketService           *MarketDataService
+	etfService              *ETFService
+	ledger                  *LedgerService
+}
+
+func NewCorporateActionService(orderService *OrderService, symbolService *SymbolService, marketService *MarketDataService, etfService *ETFService, ledger *LedgerService) *CorporateActionService {
+	return &CorporateActionService{
+		actionCollection:        config.GetCollection("corporate_actions"),
+		portfolioCollection:     config.GetCollection("portfolio"),
+		advancedOrderCollection: config.GetCollection("advanced_orders"),
+		orderService:            orderService,
+		symbolService:           symbolService,
+		marketService:           marketService,
+		etfService:              etfService,
+		ledger:                  ledger,
+	}
+}
+
+// ListActions returns the corporate action feed filtered by symbol, type and ex-date range
+func (s *CorporateActionService) ListActions(ctx context.Context, filter models.CorporateActionFilter) ([]models.CorporateAction, error) {
+	query := bson.M{}
+	if filter.Symbol != "" {
+		query["symbol"] = strings.ToUpper(filter.Symbol)
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	dateRange := bson.M{}
+	if !filter.From.IsZero() {
+		dateRange["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		dateRange["$lt"] = filter.To
+	}
+	if len(dateRange) > 0 {
+		query["ex_date"] = dateRange
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "ex_date", Value: -1}})
+	cursor, err := s.actionCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var actions []models.CorporateAction
+	err = cursor.All(ctx, &actions)
+	return actions, err
+}
+
+// CreateAction schedules a corporate action
+func (s *CorporateActionService) CreateAction(ctx context.Context, action *models.CorporateAction) error {
+	action.Symbol = strings.ToUpper(action.Symbol)
+	action.NewSymbol = strings.ToUpper(action.NewSymbol)
+
+	switch action.Type {
+	case "dividend":
+		if action.Amount <= 0 {
+			return errors.New("dividend amount must be positive")
+		}
+	case "split":
+		if action.Ratio <= 0 || action.Ratio == 1 {
+			return errors.New("split ratio must be positive and not 1")
+		}
+	case "symbol_change":
+		if action.NewSymbol == "" || action.NewSymbol == action.Symbol {
+			return errors.New("symbol change needs a different new symbol")
+		}
+	default:
+		return fmt.Errorf("invalid corporate action type: %s", action.Type)
+	}
+
+	action.ID = primitive.NewObjectID()
+	action.Status = "pending"
+	action.CreatedAt = time.Now().UTC()
+	if action.ExDate.IsZero() {
+		action.ExDate = action.CreatedAt
+	}
+	if action.Key == "" {
+		action.Key = action.ID.Hex()
+	}
+
+	_, err := s.actionCollection.UpdateOne(ctx,
+		bson.M{"key": action.Key},
+		bson.M{"$setOnInsert": action},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// GenerateActions schedules simulated dividends and splits for tomorrow.
+// Each symbol gets at most one generated action of each type per day, so
+// calling this repeatedly or after a restart does not create duplicates.
+func (s *CorporateActionService) GenerateActions() {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	exDate := day.Add(24 * time.Hour)
+	rng := rand.New(rand.NewSource(symbolSeed(day.Format("2006-01-02"))))
+
+	for _, info := range s.symbolService.ListSymbols() {
+		if info.AssetClass != "stock" {
+			continue
+		}
+		price, ok := s.marketService.GetLastPrice(info.Symbol)
+		if !ok || price <= 0 {
+			continue
+		}
+
+		if rng.Float64() < 0.10 {
+			amount := round2(price * (0.002 + rng.Float64()*0.006))
+			s.createGenerated(&models.CorporateAction{
+				Key:    fmt.Sprintf("%s:dividend:%s", info.Symbol, day.Format("2006-01-02")),
+				Symbol: info.Symbol,
+				Type:   "dividend",
+				Amount: amount,
+				ExDate: exDate,
+			})
+		}
+		if price > 500 && rng.Float64() < 0.02 {
+			s.createGenerated(&models.CorporateAction{
+				Key:    fmt.Sprintf("%s:split:%s", info.Symbol, day.Format("2006-01-02")),
+				Symbol: info.Symbol,
+				Type:   "split",
+				Ratio:  2,
+				ExDate: exDate,
+			})
+		}
+	}
+}
+
+func (s *CorporateActionService) createGenerated(action *models.CorporateAction) {
+	if err := s.CreateAction(context.Background(), action); err != nil {
+		log.Printf("Error generating %s for %s: %v", action.Type, action.Symbol, err)
+	}
+}
+
+// ApplyDueActions applies every pending action whose ex-date has passed
+func (s *CorporateActionService) ApplyDueActions() {
+	cursor, err := s.actionCollection.Find(context.Background(), bson.M{
+		"status":  "pending",
+		"ex_date": bson.M{"$lte": time.Now()},
+	}, options.Find().SetSort(bson.D{{Key: "ex_date", Value: 1}}))
+	if err != nil {
+		log.Printf("Error loading corporate actions: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var actions []models.CorporateAction
+	if err := cursor.All(context.Background(), &actions); err != nil {
+		return
+	}
+
+	for _, action := range actions {
+		if err := s.applyAction(action); err != nil {
+			log.Printf("Error applying %s for %s: %v", action.Type, action.Symbol, err)
+			continue
+		}
+		_, err := s.actionCollection.UpdateOne(context.Background(),
+			bson.M{"_id": action.ID, "status": "pending"},
+			bson.M{"$set": bson.M{"status": "applied", "applied_at": time.Now().UTC()}},
+		)
+		if err != nil {
+			log.Printf("Error marking corporate action applied: %v", err)
+			continue
+		}
+		log.Printf("🏢 Applied %s for %s", action.Type, action.Symbol)
+	}
+}
+
+// applyAction updates every position in the symbol. Each position records
+// the actions applied to it, so a run interrupted by a restart resumes
+// without applying an action to the same position twice.
+func (s *CorporateActionService) applyAction(action models.CorporateAction) error {
+	actionID := action.ID.Hex()
+	cursor, err := s.portfolioCollection.Find(context.Background(), bson.M{
+		"symbol":          action.Symbol,
+		"applied_actions": bson.M{"$ne": actionID},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var positions []models.Portfolio
+	if err := cursor.All(context.Background(), &positions); err != nil {
+		return err
+	}
+
+	for _, pos := range positions {
+		update := bson.M{"$addToSet": bson.M{"applied_actions": actionID}}
+		cashDelta := 0.0
+		reinvested, reinvestPrice, reinvestCost := 0.0, 0.0, 0.0
+
+		switch action.Type {
+		case "dividend":
+			// Short positions pay the dividend
+			cashDelta = round2(pos.Shares * action.Amount)
+			// DRIP positions buy fractional shares with it; the remainder is paid as cash
+			if reinvested, reinvestPrice = s.reinvestment(pos, cashDelta); reinvested > 0 {
+				drip := pos
+				drip.Lots = append([]models.TaxLot(nil), pos.Lots...)
+				applyLots(&drip, "buy", reinvested, reinvestPrice, time.Now().UTC(),
+					costBasisMethod(context.Background(), s.orderService.userCollection, pos.UserID))
+				update["$set"] = bson.M{"shares": drip.Shares, "avg_cost": drip.AvgCost, "lots": drip.Lots}
+				reinvestCost = round2(cashDelta - round2(cashDelta-reinvested*reinvestPrice))
+			}
+		case "split":
+			exact := pos.Shares * action.Ratio
+			newShares := math.Trunc(exact*quantityPrecision) / quantityPrecision
+			cashDelta = round2((exact - newShares) * pos.AvgCost / action.Ratio) // Cash in lieu of shares below 0.0001
+			set := bson.M{"shares": newShares, "avg_cost": pos.AvgCost / action.Ratio}
+			if len(pos.Lots) > 0 {
+				set["lots"] = splitLots(pos.Lots, action.Ratio, newShares)
+			}
+			update["$set"] = set
+		case "symbol_change":
+			update["$set"] = bson.M{"symbol": action.NewSymbol}
+		}
+
+		result, err := s.portfolioCollection.UpdateOne(context.Background(),
+			bson.M{"_id": pos.ID, "applied_actions": bson.M{"$ne": actionID}},
+			update,
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 && reinvested > 0 {
+			if err := s.orderService.RecordReinvestment(context.Background(), pos, reinvested, reinvestPrice); err != nil {
+				log.Printf("Error recording dividend reinvestment for %s: %v", pos.UserID, err)
+			}
+		}
+		if result.ModifiedCount == 0 || cashDelta == 0 {
+			continue
+		}
+		// The whole dividend is booked and the reinvested part paid out of
+		// it like a buy, so the ledger and the executions add up to the cash
+		entryType, description := "dividend", fmt.Sprintf("Dividend of $%g a share on %g %s", action.Amount, pos.Shares, pos.Symbol)
+		if action.Type == "split" {
+			entryType, description = "cash_in_lieu", fmt.Sprintf("Cash in lieu of fractional %s shares after a %g-for-1 split", pos.Symbol, action.Ratio)
+		}
+		_, err = s.ledger.Post(context.Background(), models.LedgerEntry{
+			ID:            actionID + ":" + pos.ID.Hex(),
+			UserID:        pos.UserID,
+			Type:          entryType,
+			Amount:        cashDelta,
+			Description:   description,
+			CompetitionID: pos.CompetitionID,
+			TenantID:      pos.TenantID,
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			log.Printf("Error crediting %s cash for %s: %v", action.Type, pos.UserID, err)
+			continue
+		}
+		if reinvestCost != 0 {
+			_, err := s.orderService.PostJournal(context.Background(), models.JournalEntry{
+				ID:          "drip:" + actionID + ":" + pos.ID.Hex(),
+				UserID:      pos.UserID,
+				Type:        "drip",
+				Symbol:      pos.Symbol,
+				Description: fmt.Sprintf("Reinvested %g %s at %g", reinvested, pos.Symbol, reinvestPrice),
+				Postings: []models.Posting{
+					{Account: JournalPositions, Amount: reinvestCost},
+					{Account: JournalCash, Amount: -reinvestCost},
+				},
+				CompetitionID: pos.CompetitionID,
+				TenantID:      pos.TenantID,
+				CreatedAt:     time.Now().UTC(),
+			})
+			if err != nil {
+				log.Printf("Error paying dividend reinvestment for %s: %v", pos.UserID, err)
+			}
+		}
+	}
+
+	if action.Type == "split" {
+		s.marketService.ApplySplit(action.Symbol, action.Ratio)
+	}
+	s.etfService.ApplyAction(context.Background(), action)
+	return s.adjustOpenOrders(action)
+}
+
+// reinvestment returns how many shares a DRIP position's dividend buys at the
+// current price, rounded down to 0.0001, and that price
+func (s *CorporateActionService) reinvestment(pos models.Portfolio, dividend float64) (float64, float64) {
+	if !pos.DRIP || dividend <= 0 {
+		return 0, 0
+	}
+	price, ok := s.marketService.GetLastPrice(pos.Symbol)
+	if !ok || price <= 0 {
+		return 0, 0
+	}
+	return math.Floor(dividend/price*quantityPrecision) / quantityPrecision, price
+}
+
+// adjustOpenOrders keeps active and scheduled orders consistent with the action,
+// marking them the same way as positions. Each order is updated on its own so
+// its history records the values it was amended to.
+func (s *CorporateActionService) adjustOpenOrders(action models.CorporateAction) error {
+	actionID := action.ID.Hex()
+	if action.Type != "split" && action.Type != "symbol_change" {
+		return nil
+	}
+
+	err := s.amendOrders(bson.M{"symbol": action.Symbol}, actionID, actionID, func(order *models.Order) bson.M {
+		if action.Type == "split" {
+			return bson.M{
+				"quantity":    order.Quantity * action.Ratio,
+				"stop_price":  order.StopPrice / action.Ratio,
+				"limit_price": order.LimitPrice / action.Ratio,
+				"price":       order.Price / action.Ratio,
+			}
+		}
+		return bson.M{"symbol": action.NewSymbol}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Conditions on the symbol move with it too, tracked separately since an
+	// order can be both for and conditional on the same symbol
+	return s.amendOrders(bson.M{"condition.symbol": action.Symbol}, actionID, actionID+":condition", func(order *models.Order) bson.M {
+		if action.Type == "split" {
+			return bson.M{"condition.value": order.Condition.Value / action.Ratio}
+		}
+		return bson.M{"condition.symbol": action.NewSymbol}
+	})
+}
+
+// amendOrders sets the changes amend computes on each open order matching
+// filter that mark has not been applied to, and records them in its history
+// as caused by the action
+func (s *CorporateActionService) amendOrders(filter bson.M, actionID, mark string, amend func(*models.Order) bson.M) error {
+	ctx := context.Background()
+	filter["status"] = bson.M{"$in": openOrderStatuses}
+	filter["applied_actions"] = bson.M{"$ne": mark}
+	cursor, err := s.advancedOrderCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		changes := amend(&order)
+		result, err := s.advancedOrderCollection.UpdateOne(ctx,
+			bson.M{"_id": order.ID, "status": bson.M{"$in": openOrderStatuses}, "applied_actions": bson.M{"$ne": mark}},
+			bson.M{"$set": changes, "$addToSet": bson.M{"applied_actions": mark}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 {
+			s.orderService.history.changed(ctx, &order, OrderEventAmended, changes, actionID)
+		}
+	}
+	return nil
+}
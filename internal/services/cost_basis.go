@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cost basis methods
+const (
+	CostBasisAverage = "average" // Running average of every opening fill
+	CostBasisFIFO    = "fifo"    // Cost of the lots still held, closing the oldest first
+)
+
+var (
+	ErrUnknownCostBasis     = errors.New("cost basis method must be average or fifo")
+	ErrCostBasisUserMissing = errors.New("user not found")
+)
+
+// applyLots books a fill into a position. Opening quantity becomes a new lot
+// and closing quantity uses up the oldest lots first, whatever the method.
+// Under the average method the cost basis is the running average of the
+// opening fills; under FIFO it is the cost of the lots left. A position
+// that flips from long to short or back starts over at the fill price.
+func applyLots(pos *models.Portfolio, side string, quantity, price float64, at time.Time, method string) {
+	if len(pos.Lots) == 0 && pos.Shares != 0 {
+		// Positions opened before lots were kept are one lot at their cost
+		pos.Lots = []models.TaxLot{{Quantity: math.Abs(pos.Shares), Price: pos.AvgCost}}
+	}
+	delta := quantity
+	if side == "sell" {
+		delta = -quantity
+	}
+	held := math.Abs(pos.Shares)
+	newShares := roundQuantity(pos.Shares + delta)
+
+	switch {
+	case pos.Shares == 0 || (pos.Shares > 0) == (delta > 0):
+		pos.Lots = append(pos.Lots, models.TaxLot{Quantity: quantity, Price: price, AcquiredAt: at})
+		pos.AvgCost = (pos.AvgCost*held + price*quantity) / (held + quantity)
+	case newShares == 0 || (newShares > 0) == (pos.Shares > 0):
+		pos.Lots = closeLots(pos.Lots, quantity)
+	default:
+		pos.Lots = []models.TaxLot{{Quantity: math.Abs(newShares), Price: price, AcquiredAt: at}}
+		pos.AvgCost = price
+	}
+
+	pos.Shares = newShares
+	if newShares == 0 {
+		pos.Lots = nil
+		return
+	}
+	if method == CostBasisFIFO {
+		pos.AvgCost = lotCost(pos.Lots) / math.Abs(newShares)
+	}
+}
+
+// closeLots takes quantity from the oldest lots
+func closeLots(lots []models.TaxLot, quantity float64) []models.TaxLot {
+	for len(lots) > 0 && quantity > 0 {
+		if lots[0].Quantity > quantity {
+			lots[0].Quantity = roundQuantity(lots[0].Quantity - quantity)
+			break
+		}
+		quantity = roundQuantity(quantity - lots[0].Quantity)
+		lots = lots[1:]
+	}
+	return lots
+}
+
+// splitLots restates lots after a split and trims them to the shares left
+// once fractions below 0.0001 were paid out as cash, taking the trim from
+// the newest lots
+func splitLots(lots []models.TaxLot, ratio, shares float64) []models.TaxLot {
+	split := make([]models.TaxLot, len(lots))
+	excess := 0.0
+	for i, lot := range lots {
+		split[i] = models.TaxLot{Quantity: roundQuantity(lot.Quantity * ratio), Price: lot.Price / ratio, AcquiredAt: lot.AcquiredAt}
+		excess += split[i].Quantity
+	}
+	excess = roundQuantity(excess - math.Abs(shares))
+	for i := len(split) - 1; i >= 0 && excess > 0; i-- {
+		trim := math.Min(excess, split[i].Quantity)
+		split[i].Quantity = roundQuantity(split[i].Quantity - trim)
+		excess = roundQuantity(excess - trim)
+	}
+	kept := split[:0]
+	for _, lot := range split {
+		if lot.Quantity > 0 {
+			kept = append(kept, lot)
+		}
+	}
+	return kept
+}
+
+// lotCost is what the lots cost in total
+func lotCost(lots []models.TaxLot) float64 {
+	cost := 0.0
+	for _, lot := range lots {
+		cost += lot.Quantity * lot.Price
+	}
+	return cost
+}
+
+// costBasisMethod returns the user's cost basis method
+func costBasisMethod(ctx context.Context, users *mongo.Collection, userID string) string {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return CostBasisAverage
+	}
+	var user models.User
+	err = users.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"cost_basis_method": 1})).Decode(&user)
+	if err == nil && user.CostBasisMethod == CostBasisFIFO {
+		return CostBasisFIFO
+	}
+	return CostBasisAverage
+}
+
+// CostBasisService switches users between cost basis methods and rebuilds
+// cost bases from execution history, correcting drift in stored averages
+type CostBasisService struct {
+	orderService        *OrderService
+	portfolioCollection *mongo.Collection
+	executionCollection *mongo.Collection
+	actionCollection    *mongo.Collection
+	userCollection      *mongo.Collection
+}
+
+func NewCostBasisService(orderService *OrderService) *CostBasisService {
+	return &CostBasisService{
+		orderService:        orderService,
+		portfolioCollection: config.GetCollection("portfolio"),
+		executionCollection: config.GetCollection("executions"),
+		actionCollection:    config.GetCollection("corporate_actions"),
+		userCollection:      config.GetCollection("users"),
+	}
+}
+
+// SetMethod changes the user's cost basis method and restates their
+// positions under it
+func (s *CostBasisService) SetMethod(ctx context.Context, userID, method string) (*models.CostBasisReport, error) {
+	if method != CostBasisAverage && method != CostBasisFIFO {
+		return nil, ErrUnknownCostBasis
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrCostBasisUserMissing
+	}
+	result, err := s.userCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"cost_basis_method": method}})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrCostBasisUserMissing
+	}
+	return s.Recompute(ctx, userID)
+}
+
+// Recompute replays every position's executions, with the splits applied
+// to it, under its owner's method and stores the result where it differs.
+// Positions whose executions do not add up to the shares held, such as
+// those seeded by a classroom reset before seeds were recorded, are
+// reported and left alone. An empty
+// userID recomputes every position.
+func (s *CostBasisService) Recompute(ctx context.Context, userID string) (*models.CostBasisReport, error) {
+	filter := bson.M{}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	cursor, err := s.portfolioCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var positions []models.Portfolio
+	if err := cursor.All(ctx, &positions); err != nil {
+		return nil, err
+	}
+
+	report := &models.CostBasisReport{Skipped: []models.CostBasisSkip{}}
+	methods := make(map[string]string)
+	for _, pos := range positions {
+		method, ok := methods[pos.UserID]
+		if !ok {
+			method = costBasisMethod(ctx, s.userCollection, pos.UserID)
+			methods[pos.UserID] = method
+		}
+
+		report.Checked++
+		replayed, err := s.replay(ctx, pos, method)
+		if err != nil {
+			return nil, err
+		}
+		if math.Abs(replayed.Shares-pos.Shares) >= quantityStep {
+			report.Skipped = append(report.Skipped, models.CostBasisSkip{
+				UserID:        pos.UserID,
+				Symbol:        pos.Symbol,
+				CompetitionID: pos.CompetitionID,
+				Shares:        pos.Shares,
+				Replayed:      replayed.Shares,
+				Reason:        "executions do not add up to the position",
+			})
+			continue
+		}
+		if math.Abs(replayed.AvgCost-pos.AvgCost) < 1e-9 && lotsEqual(replayed.Lots, pos.Lots) {
+			continue
+		}
+
+		updated := false
+		err = s.orderService.runInTransaction(ctx, func(ctx context.Context) error {
+			// Left alone if a fill changed the position meanwhile
+			result, err := s.portfolioCollection.UpdateOne(ctx,
+				bson.M{"_id": pos.ID, "shares": pos.Shares},
+				bson.M{"$set": bson.M{"avg_cost": replayed.AvgCost, "lots": replayed.Lots}},
+			)
+			if err != nil || result.MatchedCount == 0 {
+				return err
+			}
+			updated = true
+			_, err = s.orderService.journal.record(ctx, restatementEntry(pos, replayed.AvgCost, method))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			report.Skipped = append(report.Skipped, models.CostBasisSkip{
+				UserID:        pos.UserID,
+				Symbol:        pos.Symbol,
+				CompetitionID: pos.CompetitionID,
+				Shares:        pos.Shares,
+				Replayed:      replayed.Shares,
+				Reason:        "position changed while recomputing",
+			})
+			continue
+		}
+		report.Updated++
+		report.Drift += math.Abs(replayed.AvgCost-pos.AvgCost) * math.Abs(pos.Shares)
+	}
+	report.Drift = round2(report.Drift)
+
+	log.Printf("🧮 Recomputed cost basis of %d positions: %d updated, %d skipped, $%.2f drift corrected",
+		report.Checked, report.Updated, len(report.Skipped), report.Drift)
+	return report, nil
+}
+
+// restatementEntry moves the change in a position's open cost between
+// positions and realized P&L, so the journal follows a restated cost basis.
+// No cash moves.
+func restatementEntry(pos models.Portfolio, avgCost float64, method string) models.JournalEntry {
+	restated := pos
+	restated.AvgCost = avgCost
+	positions := openCost(restated) - openCost(pos)
+	return models.JournalEntry{
+		ID:          "restatement:" + primitive.NewObjectID().Hex(),
+		UserID:      pos.UserID,
+		Type:        "restatement",
+		Symbol:      pos.Symbol,
+		Description: fmt.Sprintf("%s cost basis restated from %g to %g (%s)", pos.Symbol, pos.AvgCost, avgCost, method),
+		Postings: []models.Posting{
+			{Account: JournalPositions, Amount: positions},
+			{Account: JournalRealizedPnL, Amount: -positions},
+		},
+		CompetitionID: pos.CompetitionID,
+		TenantID:      pos.TenantID,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// replay rebuilds a position from the executions since it was opened and
+// the splits applied to it. Executions under a symbol it was renamed from
+// count too.
+func (s *CostBasisService) replay(ctx context.Context, pos models.Portfolio, method string) (models.Portfolio, error) {
+	symbols := []string{pos.Symbol}
+	var splits []models.CorporateAction
+	var actionIDs []primitive.ObjectID
+	for _, id := range pos.AppliedActions {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			actionIDs = append(actionIDs, objID)
+		}
+	}
+	if len(actionIDs) > 0 {
+		cursor, err := s.actionCollection.Find(ctx, bson.M{"_id": bson.M{"$in": actionIDs}})
+		if err != nil {
+			return pos, err
+		}
+		var actions []models.CorporateAction
+		if err := cursor.All(ctx, &actions); err != nil {
+			return pos, err
+		}
+		for _, action := range actions {
+			switch action.Type {
+			case "split":
+				splits = append(splits, action)
+			case "symbol_change":
+				symbols = append(symbols, action.Symbol)
+			}
+		}
+	}
+
+	// Main account executions have no competition ID
+	var competitionID interface{}
+	if pos.CompetitionID != "" {
+		competitionID = pos.CompetitionID
+	}
+	// A position's ID is created with its opening fill; the second of
+	// slack covers an execution stamped just before it
+	cursor, err := s.executionCollection.Find(ctx, bson.M{
+		"user_id":        pos.UserID,
+		"competition_id": competitionID,
+		"symbol":         bson.M{"$in": symbols},
+		"executed_at":    bson.M{"$gte": pos.ID.Timestamp().Add(-time.Second)},
+	}, options.Find().SetSort(bson.D{{Key: "executed_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return pos, err
+	}
+	var executions []models.Execution
+	if err := cursor.All(ctx, &executions); err != nil {
+		return pos, err
+	}
+
+	splitAt := func(action models.CorporateAction) time.Time {
+		if action.AppliedAt.IsZero() {
+			return action.ExDate
+		}
+		return action.AppliedAt
+	}
+	sort.Slice(splits, func(i, j int) bool { return splitAt(splits[i]).Before(splitAt(splits[j])) })
+
+	replayed := models.Portfolio{}
+	next := 0
+	applySplits := func(until time.Time) {
+		for ; next < len(splits) && !splitAt(splits[next]).After(until); next++ {
+			if replayed.Shares == 0 || splits[next].Ratio <= 0 {
+				continue
+			}
+			exact := replayed.Shares * splits[next].Ratio
+			replayed.Shares = math.Trunc(exact*quantityPrecision) / quantityPrecision
+			replayed.AvgCost /= splits[next].Ratio
+			replayed.Lots = splitLots(replayed.Lots, splits[next].Ratio, replayed.Shares)
+		}
+	}
+	for _, execution := range executions {
+		applySplits(execution.ExecutedAt)
+		applyLots(&replayed, execution.Side, execution.Quantity, execution.Price, execution.ExecutedAt, method)
+	}
+	applySplits(time.Now())
+	return replayed, nil
+}
+
+// lotsEqual compares lots to the precision they are kept at
+func lotsEqual(a, b []models.TaxLot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if roundQuantity(a[i].Quantity) != roundQuantity(b[i].Quantity) || math.Abs(a[i].Price-b[i].Price) >= 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Limits on custom symbol parameters
+const (
+	maxCustomBasePrice  = 1000000.0
+	maxCustomVolatility = 50.0 // Percent per tick
+	maxCustomDrift      = 10.0 // Percent per tick, either way
+)
+
+var customSymbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9.]{0,9}$`)
+
+var (
+	// ErrSymbolExists is returned when a custom symbol would shadow another symbol
+	ErrSymbolExists = errors.New("symbol already exists")
+	// ErrCustomSymbolNotFound is returned for a custom symbol that does not exist
+	ErrCustomSymbolNotFound = errors.New("custom symbol not found")
+	// ErrCustomSymbolLimit is returned when a user already has their maximum of custom symbols
+	ErrCustomSymbolLimit = errors.New("custom symbol limit reached")
+	// ErrCustomSymbolInUse is returned when deleting a custom symbol someone still holds
+	ErrCustomSymbolInUse = errors.New("custom symbol still has open positions")
+	// ErrNotSymbolOwner is returned when a user deletes someone else's custom symbol
+	ErrNotSymbolOwner = errors.New("only the creator or an admin can delete this symbol")
+)
+
+// CustomSymbolService manages fictional tickers with user-chosen base price,
+// volatility and drift. Created symbols are tradable and ticked by the
+// simulator like the built-in ones.
+type CustomSymbolService struct {
+	customCollection    *mongo.Collection
+	portfolioCollection *mongo.Collection
+	symbolService       *SymbolService
+	marketService       *MarketDataService
+	perUser             int
+}
+
+func NewCustomSymbolService(symbolService *SymbolService, marketService *MarketDataService) *CustomSymbolService {
+	return &CustomSymbolService{
+		customCollection:    config.GetCollection("custom_symbols"),
+		portfolioCollection: config.GetCollection("portfolio"),
+		symbolService:       symbolService,
+		marketService:       marketService,
+		perUser:             config.GetEnvInt("CUSTOM_SYMBOLS_PER_USER", 3),
+	}
+}
+
+// Load registers the stored custom symbols so they keep ticking after a restart
+func (s *CustomSymbolService) Load(ctx context.Context) {
+	cursor, err := s.customCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("Error loading custom symbols: %v", err)
+		return
+	}
+	var stored []models.CustomSymbol
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("Error decoding custom symbols: %v", err)
+		return
+	}
+	for _, custom := range stored {
+		s.register(custom)
+	}
+	if len(stored) > 0 {
+		log.Printf("🧪 Loaded %d custom symbols", len(stored))
+	}
+}
+
+// Create validates and stores a custom symbol and starts simulating it.
+// Admins are not held to CUSTOM_SYMBOLS_PER_USER.
+func (s *CustomSymbolService) Create(ctx context.Context, custom models.CustomSymbol, admin bool) (*models.CustomSymbol, error) {
+	custom.Symbol = strings.ToUpper(strings.TrimSpace(custom.Symbol))
+	custom.Name = strings.TrimSpace(custom.Name)
+	switch {
+	case !customSymbolPattern.MatchString(custom.Symbol):
+		return nil, fmt.Errorf("symbol must be 1-10 letters, digits or dots, starting with a letter")
+	case custom.BasePrice <= 0 || custom.BasePrice > maxCustomBasePrice:
+		return nil, fmt.Errorf("base price must be between 0 and %.0f", maxCustomBasePrice)
+	case custom.Volatility < 0 || custom.Volatility > maxCustomVolatility:
+		return nil, fmt.Errorf("volatility must be between 0 and %.0f percent per tick", maxCustomVolatility)
+	case custom.Drift < -maxCustomDrift || custom.Drift > maxCustomDrift:
+		return nil, fmt.Errorf("drift must be between -%.0f and %.0f percent per tick", maxCustomDrift, maxCustomDrift)
+	}
+	if custom.Name == "" {
+		custom.Name = custom.Symbol
+	}
+	if s.symbolService.HasSymbol(custom.Symbol) {
+		return nil, ErrSymbolExists
+	}
+
+	if !admin && s.perUser > 0 {
+		owned, err := s.customCollection.CountDocuments(ctx, bson.M{"created_by": custom.CreatedBy})
+		if err != nil {
+			return nil, err
+		}
+		if owned >= int64(s.perUser) {
+			return nil, ErrCustomSymbolLimit
+		}
+	}
+
+	custom.CreatedAt = time.Now().UTC()
+	if _, err := s.customCollection.InsertOne(ctx, custom); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSymbolExists
+		}
+		return nil, err
+	}
+	s.register(custom)
+	log.Printf("🧪 Custom symbol %s created at $%.2f (volatility %.2f%%, drift %+.2f%%)", custom.Symbol, custom.BasePrice, custom.Volatility, custom.Drift)
+	return &custom, nil
+}
+
+// List returns the custom symbols visible in the tenant, sorted by symbol
+func (s *CustomSymbolService) List(tenantID string) []models.CustomSymbol {
+	list := s.marketService.CustomSymbols()
+	visible := list[:0]
+	for _, custom := range list {
+		if tenantID == "" || custom.TenantID == tenantID {
+			visible = append(visible, custom)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].Symbol < visible[j].Symbol })
+	return visible
+}
+
+// Delete stops simulating a custom symbol and removes it. Only its creator or
+// an admin can delete it, and only once nobody holds a position in it.
+func (s *CustomSymbolService) Delete(ctx context.Context, symbol, userID string, admin bool) error {
+	symbol = strings.ToUpper(symbol)
+	var custom models.CustomSymbol
+	if err := s.customCollection.FindOne(ctx, bson.M{"_id": symbol}).Decode(&custom); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrCustomSymbolNotFound
+		}
+		return err
+	}
+	if !admin && custom.CreatedBy != userID {
+		return ErrNotSymbolOwner
+	}
+	held, err := s.portfolioCollection.CountDocuments(ctx, bson.M{"symbol": symbol, "shares": bson.M{"$ne": 0}})
+	if err != nil {
+		return err
+	}
+	if held > 0 {
+		return ErrCustomSymbolInUse
+	}
+
+	if _, err := s.customCollection.DeleteOne(ctx, bson.M{"_id": symbol}); err != nil {
+		return err
+	}
+	s.marketService.RemoveCustomSymbol(symbol)
+	s.symbolService.RemoveSymbol(symbol)
+	log.Printf("🧪 Custom symbol %s deleted", symbol)
+	return nil
+}
+
+// register makes a custom symbol tradable with stock rules and adds it to
+// the simulator
+func (s *CustomSymbolService) register(custom models.CustomSymbol) {
+	info := defaultStockRules(custom.Symbol)
+	info.Name = custom.Name
+	info.Sector = "Custom"
+	info.Custom = true
+	s.symbolService.AddSymbol(info)
+	s.marketService.AddCustomSymbol(custom)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Data modes decide which prices quotes, fills and valuations use
+const (
+	DataModeMock    = "mock"    // Simulated prices only; Alpha Vantage is never called
+	DataModeDelayed = "delayed" // Delayed real quotes from Alpha Vantage
+	DataModeHybrid  = "hybrid"  // Simulated ticks, real quotes for symbols that have not ticked yet
+)
+
+// Sources reported on quotes and fills
+const (
+	PriceSourceSimulated = "simulated"
+	PriceSourceDelayed   = "delayed"
+)
+
+// Other instances pick up a deployment change within this interval
+const dataModeCacheTTL = 5 * time.Second
+
+var (
+	ErrUnknownDataMode     = errors.New("data mode must be mock, delayed or hybrid")
+	ErrDataModeUserMissing = errors.New("user not found")
+)
+
+// ValidDataMode reports whether mode is one of the data modes
+func ValidDataMode(mode string) bool {
+	return mode == DataModeMock || mode == DataModeDelayed || mode == DataModeHybrid
+}
+
+// DataModeService holds the deployment's data mode and per-user overrides.
+// The deployment mode is kept in Mongo so it survives restarts; until a
+// platform admin changes it DATA_MODE (default hybrid) applies. Admins can
+// put individual users on another mode.
+type DataModeService struct {
+	settingsCollection *mongo.Collection
+	userCollection     *mongo.Collection
+	defaults           models.DataModeSettings
+
+	mu       sync.Mutex
+	settings models.DataModeSettings
+	loadedAt time.Time
+}
+
+func NewDataModeService() *DataModeService {
+	defaults := models.DataModeSettings{Mode: config.GetEnv("DATA_MODE", DataModeHybrid)}
+	if !ValidDataMode(defaults.Mode) {
+		log.Printf("⚠️ Unknown DATA_MODE %q, using %s", defaults.Mode, DataModeHybrid)
+		defaults.Mode = DataModeHybrid
+	}
+	return &DataModeService{
+		settingsCollection: config.GetCollection("settings"),
+		userCollection:     config.GetCollection("users"),
+		defaults:           defaults,
+		settings:           defaults,
+	}
+}
+
+// GetSettings returns the deployment's data mode
+func (s *DataModeService) GetSettings() models.DataModeSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.loadedAt) < dataModeCacheTTL {
+		return s.settings
+	}
+
+	var stored struct {
+		Settings *models.DataModeSettings `bson:"settings"`
+	}
+	err := s.settingsCollection.FindOne(context.Background(), bson.M{"_id": "data_mode"}).Decode(&stored)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error loading data mode: %v", err)
+		return s.settings
+	}
+	s.settings = s.defaults
+	if stored.Settings != nil && ValidDataMode(stored.Settings.Mode) {
+		s.settings = *stored.Settings
+	}
+	s.loadedAt = time.Now()
+	return s.settings
+}
+
+// Deployment returns the mode of users without an override
+func (s *DataModeService) Deployment() string {
+	return s.GetSettings().Mode
+}
+
+// SetSettings stores a new deployment data mode
+func (s *DataModeService) SetSettings(ctx context.Context, settings models.DataModeSettings) (models.DataModeSettings, error) {
+	if !ValidDataMode(settings.Mode) {
+		return s.GetSettings(), ErrUnknownDataMode
+	}
+	settings.UpdatedAt = time.Now().UTC()
+
+	_, err := s.settingsCollection.UpdateOne(ctx,
+		bson.M{"_id": "data_mode"},
+		bson.M{"$set": bson.M{"settings": settings}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return s.GetSettings(), err
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	log.Printf("📡 Data mode changed to %s by %s", settings.Mode, settings.UpdatedBy)
+	return settings, nil
+}
+
+// ModeFor returns the user's data mode: their override, or the deployment's.
+// Anonymous requests get the deployment's mode.
+func (s *DataModeService) ModeFor(userID string) string {
+	if objID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		var user models.User
+		err = s.userCollection.FindOne(context.Background(), bson.M{"_id": objID},
+			options.FindOne().SetProjection(bson.M{"data_mode": 1})).Decode(&user)
+		if err == nil && ValidDataMode(user.DataMode) {
+			return user.DataMode
+		}
+	}
+	return s.Deployment()
+}
+
+// InUse reports whether the deployment or any user is in the mode
+func (s *DataModeService) InUse(ctx context.Context, mode string) (bool, error) {
+	if s.Deployment() == mode {
+		return true, nil
+	}
+	count, err := s.userCollection.CountDocuments(ctx, bson.M{"data_mode": mode}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// SetUserMode overrides a user's data mode; an empty mode clears the
+// override. Admins of a tenant can only change their own tenant's users.
+func (s *DataModeService) SetUserMode(ctx context.Context, admin *models.User, userID, mode string) error {
+	if mode != "" && !ValidDataMode(mode) {
+		return ErrUnknownDataMode
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrDataModeUserMissing
+	}
+	filter := bson.M{"_id": objID}
+	if admin.TenantID != "" {
+		filter["tenant_id"] = admin.TenantID
+	}
+	update := bson.M{"$set": bson.M{"data_mode": mode}}
+	if mode == "" {
+		update = bson.M{"$unset": bson.M{"data_mode": ""}}
+	}
+	result, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrDataModeUserMissing
+	}
+	log.Printf("📡 User %s data mode set to %q by %s", userID, mode, admin.Username)
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fetched quotes are kept this long, well past any quote delay
+const delayedQuoteRetention = 24 * time.Hour
+
+// DelayedQuoteService fetches real stock quotes for delayed mode. Instead
+// of calling Alpha Vantage per request, it fetches every stock in one sweep
+// each DELAYED_QUOTE_REFRESH_MINUTES (default 15), paced to
+// ALPHA_VANTAGE_CALLS_PER_MINUTE (default 5), and stores the quotes so a
+// restart can serve them straight away. Sweeps only run while the
+// deployment or some user is in delayed mode.
+type DelayedQuoteService struct {
+	quoteCollection *mongo.Collection
+	market          *MarketDataService
+	symbols         *SymbolService
+	dataModes       *DataModeService
+	callSpacing     time.Duration
+}
+
+func NewDelayedQuoteService(market *MarketDataService, symbols *SymbolService, dataModes *DataModeService) *DelayedQuoteService {
+	return &DelayedQuoteService{
+		quoteCollection: config.GetCollection("delayed_quotes"),
+		market:          market,
+		symbols:         symbols,
+		dataModes:       dataModes,
+		callSpacing:     time.Minute / time.Duration(max(config.GetEnvInt("ALPHA_VANTAGE_CALLS_PER_MINUTE", 5), 1)),
+	}
+}
+
+// EnsureIndexes indexes quotes by symbol and time and expires them after a day
+func (s *DelayedQuoteService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.quoteCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "fetched_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "fetched_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(delayedQuoteRetention / time.Second)),
+		},
+	})
+	return err
+}
+
+// Load restores the quotes still needed to serve delayed quotes
+func (s *DelayedQuoteService) Load(ctx context.Context) {
+	since := time.Now().Add(-s.market.DelayedQuoteWindow())
+	cursor, err := s.quoteCollection.Find(ctx, bson.M{"fetched_at": bson.M{"$gte": since}},
+		options.Find().SetSort(bson.D{{Key: "fetched_at", Value: 1}}))
+	if err != nil {
+		log.Printf("Error loading delayed quotes: %v", err)
+		return
+	}
+	var quotes []models.DelayedQuote
+	if err := cursor.All(ctx, &quotes); err != nil {
+		log.Printf("Error decoding delayed quotes: %v", err)
+		return
+	}
+	for _, quote := range quotes {
+		s.market.AddDelayedQuote(quote)
+	}
+	log.Printf("⏱️ Loaded %d delayed quotes", len(quotes))
+}
+
+// Run sweeps quotes until the process exits
+func (s *DelayedQuoteService) Run() {
+	ticker := time.NewTicker(s.market.DelayedQuoteRefresh())
+	defer ticker.Stop()
+	for {
+		inUse, err := s.dataModes.InUse(context.Background(), DataModeDelayed)
+		if err != nil {
+			log.Printf("Error checking for delayed mode users: %v", err)
+		}
+		if inUse {
+			s.Refresh(context.Background())
+		}
+		<-ticker.C
+	}
+}
+
+// Refresh fetches a quote for every stock and stores them. A rate limit
+// error ends the sweep; the rest wait for the next one.
+func (s *DelayedQuoteService) Refresh(ctx context.Context) {
+	var quotes []interface{}
+	for _, info := range s.symbols.ListSymbols() {
+		if info.AssetClass != "stock" {
+			continue
+		}
+		if len(quotes) > 0 {
+			time.Sleep(s.callSpacing)
+		}
+		stock, err := s.market.getRealStockPrice(ctx, info.Symbol)
+		if err != nil {
+			log.Printf("⚠️ Delayed quote for %s failed: %v", info.Symbol, err)
+			if strings.Contains(err.Error(), "rate limit") {
+				break
+			}
+			continue
+		}
+		quote := models.DelayedQuote{
+			Symbol:        stock.Symbol,
+			Price:         stock.Price,
+			Change:        stock.Change,
+			ChangePercent: stock.ChangePercent,
+			FetchedAt:     stock.Timestamp,
+		}
+		s.market.AddDelayedQuote(quote)
+		quotes = append(quotes, quote)
+	}
+	if len(quotes) == 0 {
+		return
+	}
+
+	if _, err := s.quoteCollection.InsertMany(ctx, quotes); err != nil {
+		log.Printf("Error storing delayed quotes: %v", err)
+	}
+	log.Printf("⏱️ Fetched %d delayed quotes", len(quotes))
+}
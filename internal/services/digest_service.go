@@ -0,0 +1,328 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fills listed in one digest; the rest are only counted
+const digestFillLimit = 50
+
+var digestFuncs = map[string]interface{}{
+	"money":  func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"signed": signedMoney,
+	"day":    func(t time.Time) string { return t.Format("Mon Jan 2") },
+	"time":   func(t time.Time) string { return t.Format("Jan 2 15:04") },
+}
+
+var digestTextTemplate = template.Must(template.New("digest").Funcs(digestFuncs).Parse(
+	`Hi {{.Username}},
+
+Your {{.Frequency}} trading summary for {{day .From}} to {{day .To}}:
+
+Equity: {{money .Equity}} ({{signed .ProfitLoss}}, {{printf "%+.2f" .ProfitLossPercent}}%)
+{{if .Positions}}
+Positions today:
+{{range .Positions}}  {{.Symbol}}  {{.Shares}} @ {{money .Price}}  {{printf "%+.2f" .DayChange}} ({{printf "%+.2f" .DayChangePercent}}%)
+{{end}}{{end}}
+Fills: {{.FillCount}}
+{{range .Fills}}  {{time .ExecutedAt}}  {{.Side}} {{.Quantity}} {{.Symbol}} @ {{money .Price}}
+{{end}}{{if .TriggeredStops}}
+Triggered stops:
+{{range .TriggeredStops}}  {{time .TriggeredAt}}  {{.Type}} {{.Quantity}} {{.Symbol}} ({{.OrderType}} at {{money .StopPrice}}) - {{.Status}}
+{{end}}{{end}}{{if .Earnings}}
+Upcoming earnings:
+{{range .Earnings}}  {{day .Date}}  {{.Symbol}} ({{.Shares}} shares held)
+{{end}}{{end}}
+Change how often you get this email, or turn it off, with PUT /api/auth/preferences {"digest": ""}.
+`))
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(digestFuncs).Parse(
+	`<html><body style="font-family:sans-serif">
+<p>Hi {{.Username}},</p>
+<p>Your {{.Frequency}} trading summary for {{day .From}} to {{day .To}}:</p>
+<h2>{{money .Equity}} <small style="color:{{if ge .ProfitLoss 0.0}}green{{else}}red{{end}}">{{signed .ProfitLoss}} ({{printf "%+.2f" .ProfitLossPercent}}%)</small></h2>
+{{if .Positions}}<h3>Positions today</h3>
+<table>{{range .Positions}}<tr><td>{{.Symbol}}</td><td>{{.Shares}}</td><td>{{money .Price}}</td><td>{{printf "%+.2f" .DayChange}} ({{printf "%+.2f" .DayChangePercent}}%)</td></tr>{{end}}</table>{{end}}
+<h3>Fills ({{.FillCount}})</h3>
+{{if .Fills}}<table>{{range .Fills}}<tr><td>{{time .ExecutedAt}}</td><td>{{.Side}}</td><td>{{.Quantity}} {{.Symbol}}</td><td>{{money .Price}}</td></tr>{{end}}</table>{{else}}<p>No trades this period.</p>{{end}}
+{{if .TriggeredStops}}<h3>Triggered stops</h3>
+<table>{{range .TriggeredStops}}<tr><td>{{time .TriggeredAt}}</td><td>{{.Type}} {{.Quantity}} {{.Symbol}}</td><td>{{.OrderType}} at {{money .StopPrice}}</td><td>{{.Status}}</td></tr>{{end}}</table>{{end}}
+{{if .Earnings}}<h3>Upcoming earnings</h3>
+<table>{{range .Earnings}}<tr><td>{{day .Date}}</td><td>{{.Symbol}}</td><td>{{.Shares}} shares held</td></tr>{{end}}</table>{{end}}
+</body></html>
+`))
+
+// DigestService emails opted-in users a daily or weekly summary of their
+// main account: P&L, fills, triggered stops and upcoming earnings of the
+// symbols they hold
+type DigestService struct {
+	accountService          *AccountService
+	symbolService           *SymbolService
+	mailer                  Mailer
+	userCollection          *mongo.Collection
+	executionCollection     *mongo.Collection
+	advancedOrderCollection *mongo.Collection
+	sendHour                int // Local hour of the user's timezone digests go out at
+}
+
+func NewDigestService(accountService *AccountService, symbolService *SymbolService, mailer Mailer) *DigestService {
+	return &DigestService{
+		accountService:          accountService,
+		symbolService:           symbolService,
+		mailer:                  mailer,
+		userCollection:          config.GetCollection("users"),
+		executionCollection:     config.GetCollection("executions"),
+		advancedOrderCollection: config.GetCollection("advanced_orders"),
+		sendHour:                min(max(config.GetEnvInt("DIGEST_HOUR", 7), 0), 23),
+	}
+}
+
+// Build summarizes the user's account from the previous digest, or one
+// period back, until now
+func (s *DigestService) Build(ctx context.Context, user models.User, frequency string, now time.Time) (*models.Digest, error) {
+	period := digestPeriod(frequency)
+	if period == 0 {
+		return nil, fmt.Errorf("digest frequency must be daily or weekly")
+	}
+	userID := user.ID.Hex()
+	loc := user.Location()
+
+	from := now.Add(-period)
+	if !user.DigestSentAt.IsZero() && user.DigestSentAt.After(now.Add(-2*period)) {
+		from = user.DigestSentAt
+	}
+	summary, err := s.accountService.Summary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		Username:       user.Username,
+		Frequency:      frequency,
+		From:           from.In(loc),
+		To:             now.In(loc),
+		Equity:         summary.Equity,
+		Positions:      summary.PositionChanges,
+		Fills:          []models.Execution{},
+		TriggeredStops: []models.Order{},
+		Earnings:       []models.EarningsEvent{},
+	}
+	if user.DigestEquity > 0 && from.Equal(user.DigestSentAt) {
+		digest.ProfitLoss = round2(summary.Equity - user.DigestEquity)
+		digest.ProfitLossPercent = round2(digest.ProfitLoss / user.DigestEquity * 100)
+	} else {
+		digest.ProfitLoss = summary.DayProfitLoss
+		digest.ProfitLossPercent = summary.DayProfitLossPercent
+	}
+
+	window := bson.M{"$gte": from, "$lt": now}
+	fillFilter := bson.M{"user_id": userID, "executed_at": window}
+	if digest.FillCount, err = s.executionCollection.CountDocuments(ctx, fillFilter); err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "executed_at", Value: -1}}).SetLimit(digestFillLimit)
+	cursor, err := s.executionCollection.Find(ctx, fillFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &digest.Fills); err != nil {
+		return nil, err
+	}
+
+	opts = options.Find().SetSort(bson.D{{Key: "triggered_at", Value: 1}})
+	cursor, err = s.advancedOrderCollection.Find(ctx, bson.M{"user_id": userID, "triggered_at": window}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &digest.TriggeredStops); err != nil {
+		return nil, err
+	}
+
+	for i := range digest.Fills {
+		digest.Fills[i].ExecutedAt = digest.Fills[i].ExecutedAt.In(loc)
+	}
+	for i := range digest.TriggeredStops {
+		digest.TriggeredStops[i].TriggeredAt = digest.TriggeredStops[i].TriggeredAt.In(loc)
+	}
+	for _, pos := range summary.PositionChanges {
+		if s.symbolService.GetSymbol(pos.Symbol).AssetClass != "stock" {
+			continue
+		}
+		if date := nextEarningsDate(pos.Symbol, now); date.Before(now.Add(2 * period)) {
+			digest.Earnings = append(digest.Earnings, models.EarningsEvent{Symbol: pos.Symbol, Date: date.In(loc), Shares: pos.Shares})
+		}
+	}
+	sort.Slice(digest.Earnings, func(i, j int) bool { return digest.Earnings[i].Date.Before(digest.Earnings[j].Date) })
+	return digest, nil
+}
+
+// Preview builds the digest the user would receive now, for the given
+// frequency or their own
+func (s *DigestService) Preview(ctx context.Context, userID, frequency string) (*models.Digest, *Email, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil, nil, err
+	}
+	if frequency == "" {
+		frequency = user.Digest
+	}
+	if frequency == "" {
+		frequency = "daily"
+	}
+	digest, err := s.Build(ctx, user, frequency, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	email, err := s.Render(digest, user.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	return digest, &email, nil
+}
+
+// Render turns a digest into an email
+func (s *DigestService) Render(digest *models.Digest, to string) (Email, error) {
+	var text, html bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, digest); err != nil {
+		return Email{}, err
+	}
+	if err := digestHTMLTemplate.Execute(&html, digest); err != nil {
+		return Email{}, err
+	}
+	subject := fmt.Sprintf("Your %s trading summary: %s", digest.Frequency, signedMoney(digest.ProfitLoss))
+	return Email{To: to, Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// SendDue emails every opted-in user whose digest is due. A user is claimed
+// before sending so concurrent instances send once; a failed send releases
+// the claim to retry on the next pass.
+func (s *DigestService) SendDue(ctx context.Context, now time.Time) {
+	cursor, err := s.userCollection.Find(ctx, bson.M{
+		"digest":     bson.M{"$in": bson.A{"daily", "weekly"}},
+		"email":      bson.M{"$ne": ""},
+		"deleted_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		log.Printf("Error loading digest subscribers: %v", err)
+		return
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Error loading digest subscribers: %v", err)
+		return
+	}
+
+	sent := 0
+	for _, user := range users {
+		if !user.DigestSentAt.Before(s.lastSchedule(user, now)) {
+			continue
+		}
+		if err := s.send(ctx, user, now); err != nil {
+			log.Printf("Error sending digest to %s: %v", user.Username, err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		log.Printf("📧 Sent %d email digests", sent)
+	}
+}
+
+func (s *DigestService) send(ctx context.Context, user models.User, now time.Time) error {
+	claim := bson.M{"_id": user.ID, "digest_sent_at": user.DigestSentAt}
+	if user.DigestSentAt.IsZero() {
+		claim["digest_sent_at"] = bson.M{"$exists": false}
+	}
+	result, err := s.userCollection.UpdateOne(ctx, claim, bson.M{"$set": bson.M{"digest_sent_at": now}})
+	if err != nil || result.ModifiedCount == 0 {
+		return err
+	}
+
+	digest, err := s.Build(ctx, user, user.Digest, now)
+	if err == nil {
+		var email Email
+		if email, err = s.Render(digest, user.Email); err == nil {
+			err = s.mailer.Send(ctx, email)
+		}
+	}
+	if err != nil {
+		release := bson.M{"$unset": bson.M{"digest_sent_at": ""}}
+		if !user.DigestSentAt.IsZero() {
+			release = bson.M{"$set": bson.M{"digest_sent_at": user.DigestSentAt}}
+		}
+		if _, releaseErr := s.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, release); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	_, err = s.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"digest_equity": digest.Equity}})
+	return err
+}
+
+// lastSchedule is the latest time the user's digest was due: today at the
+// send hour in their timezone for daily digests, or Monday's for weekly ones
+func (s *DigestService) lastSchedule(user models.User, now time.Time) time.Time {
+	local := now.In(user.Location())
+	due := time.Date(local.Year(), local.Month(), local.Day(), s.sendHour, 0, 0, 0, local.Location())
+	if due.After(local) {
+		due = due.AddDate(0, 0, -1)
+	}
+	if user.Digest == "weekly" {
+		due = due.AddDate(0, 0, -((int(due.Weekday()) + 6) % 7))
+	}
+	return due
+}
+
+// signedMoney formats an amount as +$1.50 or -$1.50
+func signedMoney(v float64) string {
+	if v < 0 {
+		return fmt.Sprintf("-$%.2f", math.Abs(v))
+	}
+	return fmt.Sprintf("+$%.2f", v)
+}
+
+func digestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// nextEarningsDate is the symbol's first quarterly report on or after from.
+// The simulator has no earnings feed, so like the screener's fundamentals
+// each symbol gets a fixed reporting day 20 to 59 days into every quarter.
+func nextEarningsDate(symbol string, from time.Time) time.Time {
+	offset := int(uint64(symbolSeed(symbol+":earnings"))%40) + 20
+	from = from.UTC()
+	quarter := time.Date(from.Year(), (from.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	for {
+		date := quarter.AddDate(0, 0, offset)
+		if !date.Before(from.Truncate(24 * time.Hour)) {
+			return date
+		}
+		quarter = quarter.AddDate(0, 3, 0)
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+// Limits on ETF definitions
+const (
+	maxETFHoldings      = 20
+	defaultETFBasePrice = 100.0
+)
+
+// ErrETFNotFound is returned for a symbol that is not an ETF
+var ErrETFNotFound = errors.New("ETF not found")
+
+// The ETF created on first start: the five simulated stocks, equally weighted
+var defaultETF = models.ETF{
+	Symbol:    "TECH5",
+	Name:      "Simulated Tech 5 Index",
+	BasePrice: defaultETFBasePrice,
+	Holdings: []models.ETFHolding{
+		{Symbol: "AAPL", Weight: 0.2},
+		{Symbol: "GOOGL", Weight: 0.2},
+		{Symbol: "MSFT", Weight: 0.2},
+		{Symbol: "TSLA", Weight: 0.2},
+		{Symbol: "AMZN", Weight: 0.2},
+	},
+}
+
+// ETFService manages synthetic ETFs. Each one holds a fixed number of shares
+// of its constituents, sized at creation so the weights add up to the base
+// price; from then on the simulator prices it every tick as the value of
+// those shares. ETFs trade like stocks.
+type ETFService struct {
+	etfCollection *mongo.Collection
+	symbolService *SymbolService
+	marketService *MarketDataService
+}
+
+func NewETFService(symbolService *SymbolService, marketService *MarketDataService) *ETFService {
+	return &ETFService{
+		etfCollection: config.GetCollection("etfs"),
+		symbolService: symbolService,
+		marketService: marketService,
+	}
+}
+
+// Load registers the stored ETFs, creating the default TECH5 on first start
+func (s *ETFService) Load(ctx context.Context) {
+	cursor, err := s.etfCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("Error loading ETFs: %v", err)
+		return
+	}
+	var stored []models.ETF
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("Error decoding ETFs: %v", err)
+		return
+	}
+	for _, etf := range stored {
+		s.register(etf)
+	}
+
+	if len(stored) == 0 && !s.symbolService.HasSymbol(defaultETF.Symbol) {
+		etf := defaultETF
+		etf.Holdings = append([]models.ETFHolding(nil), defaultETF.Holdings...)
+		if _, err := s.Create(ctx, etf); err != nil {
+			log.Printf("Error creating %s: %v", etf.Symbol, err)
+			return
+		}
+		stored = append(stored, etf)
+	}
+	log.Printf("🧺 Loaded %d ETFs", len(stored))
+}
+
+// Create validates an ETF, sizes its holdings at the constituents' current
+// prices and starts pricing it
+func (s *ETFService) Create(ctx context.Context, etf models.ETF) (*models.ETF, error) {
+	etf.Symbol = strings.ToUpper(strings.TrimSpace(etf.Symbol))
+	etf.Name = strings.TrimSpace(etf.Name)
+	switch {
+	case !customSymbolPattern.MatchString(etf.Symbol):
+		return nil, fmt.Errorf("symbol must be 1-10 letters, digits or dots, starting with a letter")
+	case len(etf.Holdings) < 2 || len(etf.Holdings) > maxETFHoldings:
+		return nil, fmt.Errorf("an ETF needs 2-%d holdings", maxETFHoldings)
+	case etf.BasePrice < 0 || etf.BasePrice > maxCustomBasePrice:
+		return nil, fmt.Errorf("base price must be between 0 and %.0f", maxCustomBasePrice)
+	}
+	if etf.Name == "" {
+		etf.Name = etf.Symbol
+	}
+	if etf.BasePrice == 0 {
+		etf.BasePrice = defaultETFBasePrice
+	}
+	if s.symbolService.HasSymbol(etf.Symbol) {
+		return nil, ErrSymbolExists
+	}
+
+	total := 0.0
+	seen := make(map[string]bool, len(etf.Holdings))
+	for i := range etf.Holdings {
+		holding := &etf.Holdings[i]
+		holding.Symbol = strings.ToUpper(strings.TrimSpace(holding.Symbol))
+		switch {
+		case seen[holding.Symbol]:
+			return nil, fmt.Errorf("%s is listed more than once", holding.Symbol)
+		case !s.symbolService.HasSymbol(holding.Symbol):
+			return nil, fmt.Errorf("unknown constituent %q", holding.Symbol)
+		case s.isETF(holding.Symbol):
+			return nil, fmt.Errorf("%s is an ETF; ETFs cannot hold other ETFs", holding.Symbol)
+		case s.isCustom(holding.Symbol):
+			return nil, fmt.Errorf("%s is a custom symbol; ETFs hold shared symbols only", holding.Symbol)
+		case !(holding.Weight > 0) || math.IsInf(holding.Weight, 1):
+			return nil, fmt.Errorf("weight of %s must be positive", holding.Symbol)
+		}
+		seen[holding.Symbol] = true
+		total += holding.Weight
+	}
+	for i := range etf.Holdings {
+		holding := &etf.Holdings[i]
+		price, ok := s.marketService.GetLastPrice(holding.Symbol)
+		if !ok || price <= 0 {
+			return nil, fmt.Errorf("%s has no price yet", holding.Symbol)
+		}
+		holding.Weight /= total
+		holding.Shares = etf.BasePrice * holding.Weight / price
+	}
+
+	etf.CreatedAt = time.Now().UTC()
+	if _, err := s.etfCollection.InsertOne(ctx, etf); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSymbolExists
+		}
+		return nil, err
+	}
+	s.register(etf)
+	log.Printf("🧺 ETF %s created at $%.2f with %d holdings", etf.Symbol, etf.BasePrice, len(etf.Holdings))
+	return &etf, nil
+}
+
+// List returns every ETF, sorted by symbol
+func (s *ETFService) List() []models.ETF {
+	list := s.marketService.ETFs()
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	return list
+}
+
+// Holdings returns the ETF with each holding's current price, value and
+// share of the fund, largest first
+func (s *ETFService) Holdings(symbol string) (*models.ETF, error) {
+	etf, ok := s.marketService.ETF(symbol)
+	if !ok {
+		return nil, ErrETFNotFound
+	}
+	holdings := make([]models.ETFHolding, len(etf.Holdings))
+	total := 0.0
+	for i, holding := range etf.Holdings {
+		holding.Price, _ = s.marketService.GetLastPrice(holding.Symbol)
+		holding.Value = holding.Shares * holding.Price
+		total += holding.Value
+		holdings[i] = holding
+	}
+	for i := range holdings {
+		if total > 0 {
+			holdings[i].CurrentWeight = math.Round(holdings[i].Value/total*10000) / 10000
+		}
+		holdings[i].Price = round2(holdings[i].Price)
+		holdings[i].Value = round2(holdings[i].Value)
+	}
+	sort.SliceStable(holdings, func(i, j int) bool { return holdings[i].Value > holdings[j].Value })
+	etf.Holdings = holdings
+	return &etf, nil
+}
+
+// ApplyAction keeps ETFs holding a constituent whole through its splits and
+// symbol changes. Each ETF records the actions applied to it, so a retried
+// action does not split its holding twice.
+func (s *ETFService) ApplyAction(ctx context.Context, action models.CorporateAction) {
+	if action.Type != "split" && action.Type != "symbol_change" {
+		return
+	}
+	actionID := action.ID.Hex()
+	for _, etf := range s.marketService.ETFs() {
+		if slices.Contains(etf.AppliedActions, actionID) {
+			continue
+		}
+		etf.Holdings = slices.Clone(etf.Holdings)
+		changed := false
+		for i := range etf.Holdings {
+			if etf.Holdings[i].Symbol != action.Symbol {
+				continue
+			}
+			if action.Type == "split" {
+				etf.Holdings[i].Shares *= action.Ratio
+			} else {
+				etf.Holdings[i].Symbol = action.NewSymbol
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		_, err := s.etfCollection.UpdateOne(ctx,
+			bson.M{"_id": etf.Symbol, "applied_actions": bson.M{"$ne": actionID}},
+			bson.M{
+				"$set":      bson.M{"holdings": etf.Holdings},
+				"$addToSet": bson.M{"applied_actions": actionID},
+			},
+		)
+		if err != nil {
+			log.Printf("Error applying %s of %s to ETF %s: %v", action.Type, action.Symbol, etf.Symbol, err)
+			continue
+		}
+		etf.AppliedActions = append(etf.AppliedActions, actionID)
+		s.marketService.AddETF(etf)
+	}
+}
+
+func (s *ETFService) isETF(symbol string) bool {
+	_, ok := s.marketService.ETF(symbol)
+	return ok
+}
+
+func (s *ETFService) isCustom(symbol string) bool {
+	_, ok := s.marketService.customSymbol(symbol)
+	return ok
+}
+
+// register makes an ETF tradable with stock rules and prices it straight
+// away, so it can be traded before the next tick
+func (s *ETFService) register(etf models.ETF) {
+	info := defaultStockRules(etf.Symbol)
+	info.Name = etf.Name
+	info.AssetClass = "etf"
+	info.Sector = "ETF"
+	s.symbolService.AddSymbol(info)
+	s.marketService.AddETF(etf)
+	s.marketService.AdvanceETFTick(etf)
+}
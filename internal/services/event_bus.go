@@ -0,0 +1,98 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event topics published inside the server
+const (
+	EventOrderPlaced    = "order.placed"    // Payload: models.Order, accepted but not yet filled
+	EventOrderFilled    = "order.filled"    // Payload: models.Order
+	EventOrderCancelled = "order.cancelled" // Payload: models.Order with its cancel reason
+	EventOrderTriggered = "order.triggered" // Payload: models.Order, a stop or scheduled order with status "triggered" or "failed"
+	EventPriceTick      = "price.tick"      // Payload: models.Stock
+	EventUserRegistered = "user.registered" // Payload: models.User, password cleared
+
+	EventUserEmailChanged    = "user.email_changed"    // Payload: models.AuditEntry with the old and new email
+	EventUserUsernameChanged = "user.username_changed" // Payload: models.AuditEntry with the old and new username
+
+	EventCompetitionStarted = "competition.started"     // Payload: models.Competition
+	EventCompetitionEnded   = "competition.ended"       // Payload: models.Leaderboard with the final standings
+	EventLeaderboardChanged = "competition.leaderboard" // Payload: models.Leaderboard whose top places changed
+	EventMaintenanceChanged = "maintenance.changed"     // Payload: models.MaintenanceState
+)
+
+// Event is a message on the event bus
+type Event struct {
+	Topic     string
+	UserID    string // Empty for market-wide events
+	Payload   interface{}
+	Timestamp time.Time
+}
+
+type subscription struct {
+	handler func(Event)
+	async   bool
+}
+
+// EventBus is an in-process pub/sub bus that lets services react to each
+// other's events without holding references to one another
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscription
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[string][]subscription)}
+}
+
+// Subscribe runs handler on the publisher's goroutine, in publish order.
+// Handlers must return quickly; use SubscribeAsync for slow work.
+func (b *EventBus) Subscribe(topic string, handler func(Event)) {
+	b.subscribe(topic, subscription{handler: handler})
+}
+
+// SubscribeAsync runs handler on its own goroutine for every event
+func (b *EventBus) SubscribeAsync(topic string, handler func(Event)) {
+	b.subscribe(topic, subscription{handler: handler, async: true})
+}
+
+func (b *EventBus) subscribe(topic string, sub subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[topic] = append(b.subscribers[topic], sub)
+}
+
+// Publish delivers an event to every subscriber of its topic
+func (b *EventBus) Publish(topic, userID string, payload interface{}) {
+	event := Event{
+		Topic:     topic,
+		UserID:    userID,
+		Payload:   payload,
+		Timestamp: time.Now().UTC(),
+	}
+
+	b.mu.RLock()
+	subs := b.subscribers[topic]
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.async {
+			go b.deliver(sub.handler, event)
+		} else {
+			b.deliver(sub.handler, event)
+		}
+	}
+}
+
+// deliver keeps a panicking subscriber from taking down the publisher
+func (b *EventBus) deliver(handler func(Event), event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Event subscriber for %s panicked: %v", event.Topic, r)
+		}
+	}()
+	handler(event)
+}
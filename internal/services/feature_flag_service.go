@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Feature flag names
+const (
+	FeatureShorting     = "shorting"
+	FeatureOptions      = "options"
+	FeatureMargin       = "margin"
+	FeatureCompetitions = "competitions"
+)
+
+// Flags that exist before an operator has stored anything
+var defaultFeatureFlags = []models.FeatureFlag{
+	{Name: FeatureShorting, Enabled: true, RolloutPercent: 100, Description: "Short selling in accounts whose rules allow it"},
+	{Name: FeatureOptions, Enabled: false, RolloutPercent: 100, Description: "Options trading"},
+	{Name: FeatureMargin, Enabled: false, RolloutPercent: 100, Description: "Margin borrowing in the main account"},
+	{Name: FeatureCompetitions, Enabled: true, RolloutPercent: 100, Description: "Trading competitions"},
+}
+
+type FeatureFlagService struct {
+	flagCollection *mongo.Collection
+	tiers          *TierService
+	cacheTTL       time.Duration
+
+	mu       sync.RWMutex
+	flags    map[string]models.FeatureFlag
+	loadedAt time.Time
+}
+
+func NewFeatureFlagService(tiers *TierService) *FeatureFlagService {
+	return &FeatureFlagService{
+		flagCollection: config.GetCollection("feature_flags"),
+		tiers:          tiers,
+		cacheTTL:       time.Duration(config.GetEnvInt("FEATURE_FLAG_CACHE_SECONDS", 30)) * time.Second,
+	}
+}
+
+// IsEnabled reports whether a flag is on for everyone
+func (s *FeatureFlagService) IsEnabled(name string) bool {
+	flag, ok := s.current()[name]
+	return ok && flag.Enabled && flag.RolloutPercent >= 100
+}
+
+// IsEnabledFor reports whether a flag is on for a user, either through the
+// flag's rollout or because the user's account tier includes the feature.
+// Partial rollouts bucket users by a hash of flag and user so each user sees
+// a stable answer.
+func (s *FeatureFlagService) IsEnabledFor(name, userID string) bool {
+	if s.rolledOut(name, userID) {
+		return true
+	}
+	return s.tiers != nil && s.tiers.Grants(userID, name)
+}
+
+func (s *FeatureFlagService) rolledOut(name, userID string) bool {
+	flag, ok := s.current()[name]
+	if !ok || !flag.Enabled {
+		return false
+	}
+	if flag.RolloutPercent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + userID))
+	return int(h.Sum32()%100) < flag.RolloutPercent
+}
+
+// ListFlags returns every flag sorted by name
+func (s *FeatureFlagService) ListFlags() []models.FeatureFlag {
+	flags := s.current()
+	list := make([]models.FeatureFlag, 0, len(flags))
+	for _, flag := range flags {
+		list = append(list, flag)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// SetFlag stores a flag and refreshes the cache
+func (s *FeatureFlagService) SetFlag(ctx context.Context, flag models.FeatureFlag) (*models.FeatureFlag, error) {
+	if flag.RolloutPercent < 0 || flag.RolloutPercent > 100 {
+		return nil, fmt.Errorf("rollout percent must be between 0 and 100")
+	}
+	if existing, ok := s.current()[flag.Name]; ok && flag.Description == "" {
+		flag.Description = existing.Description
+	}
+	flag.UpdatedAt = time.Now().UTC()
+
+	_, err := s.flagCollection.UpdateOne(ctx,
+		bson.M{"name": flag.Name},
+		bson.M{"$set": flag},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+	log.Printf("🚩 Feature flag %s set to enabled=%t rollout=%d%% by %s", flag.Name, flag.Enabled, flag.RolloutPercent, flag.UpdatedBy)
+	return &flag, nil
+}
+
+// current returns the cached flags, reloading them once the cache expires.
+// If Mongo is unreachable the last known flags stay in effect.
+func (s *FeatureFlagService) current() map[string]models.FeatureFlag {
+	s.mu.RLock()
+	if s.flags != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		flags := s.flags
+		s.mu.RUnlock()
+		return flags
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flags != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		return s.flags
+	}
+
+	flags := make(map[string]models.FeatureFlag, len(defaultFeatureFlags))
+	for _, flag := range defaultFeatureFlags {
+		flags[flag.Name] = flag
+	}
+
+	cursor, err := s.flagCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error loading feature flags: %v", err)
+		if s.flags == nil {
+			s.flags = flags
+		}
+		return s.flags
+	}
+	defer cursor.Close(context.Background())
+
+	var stored []models.FeatureFlag
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		log.Printf("Error decoding feature flags: %v", err)
+	}
+	for _, flag := range stored {
+		flags[flag.Name] = flag
+	}
+
+	s.flags = flags
+	s.loadedAt = time.Now()
+	return flags
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/internal/models"
+)
+
+// FundamentalsProvider fetches company fundamentals from an external source
+type FundamentalsProvider interface {
+	Name() string
+	GetFundamentals(ctx context.Context, symbol string) (*models.Fundamentals, error)
+}
+
+type fundamentalsCacheEntry struct {
+	data      models.Fundamentals
+	expiresAt time.Time
+}
+
+// FundamentalsService caches provider results, since fundamentals change
+// rarely and provider rate limits are tight
+type FundamentalsService struct {
+	provider      FundamentalsProvider
+	symbolService *SymbolService
+	ttl           time.Duration
+
+	mu    sync.Mutex
+	cache map[string]fundamentalsCacheEntry
+}
+
+func NewFundamentalsService(provider FundamentalsProvider, symbolService *SymbolService) *FundamentalsService {
+	return &FundamentalsService{
+		provider:      provider,
+		symbolService: symbolService,
+		ttl:           24 * time.Hour,
+		cache:         make(map[string]fundamentalsCacheEntry),
+	}
+}
+
+// GetFundamentals returns cached fundamentals when fresh, otherwise asks the
+// provider. If the provider fails an expired entry is served marked as stale.
+// Symbols the simulator does not trade are refused without asking the provider.
+func (s *FundamentalsService) GetFundamentals(ctx context.Context, symbol string) (*models.Fundamentals, error) {
+	symbol = strings.ToUpper(symbol)
+	if !s.symbolService.HasSymbol(symbol) {
+		return nil, ErrUnknownSymbol
+	}
+
+	s.mu.Lock()
+	entry, cached := s.cache[symbol]
+	s.mu.Unlock()
+
+	if cached && time.Now().Before(entry.expiresAt) {
+		data := entry.data
+		return &data, nil
+	}
+
+	data, err := s.provider.GetFundamentals(ctx, symbol)
+	if err != nil {
+		if cached {
+			log.Printf("⚠️ %s fundamentals failed for %s, serving stale cache: %v", s.provider.Name(), symbol, err)
+			stale := entry.data
+			stale.Stale = true
+			return &stale, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[symbol] = fundamentalsCacheEntry{data: *data, expiresAt: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+	return data, nil
+}
+
+type alphaVantageOverview struct {
+	Symbol               string `json:"Symbol"`
+	Name                 string `json:"Name"`
+	Description          string `json:"Description"`
+	Exchange             string `json:"Exchange"`
+	Currency             string `json:"Currency"`
+	Sector               string `json:"Sector"`
+	Industry             string `json:"Industry"`
+	MarketCapitalization string `json:"MarketCapitalization"`
+	PERatio              string `json:"PERatio"`
+	PEGRatio             string `json:"PEGRatio"`
+	EPS                  string `json:"EPS"`
+	BookValue            string `json:"BookValue"`
+	DividendPerShare     string `json:"DividendPerShare"`
+	DividendYield        string `json:"DividendYield"`
+	ProfitMargin         string `json:"ProfitMargin"`
+	Beta                 string `json:"Beta"`
+	High52Week           string `json:"52WeekHigh"`
+	Low52Week            string `json:"52WeekLow"`
+	AnalystTargetPrice   string `json:"AnalystTargetPrice"`
+}
+
+// AlphaVantageFundamentalsProvider reads the Alpha Vantage OVERVIEW endpoint
+type AlphaVantageFundamentalsProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+func NewAlphaVantageFundamentalsProvider(apiKey string) *AlphaVantageFundamentalsProvider {
+	return &AlphaVantageFundamentalsProvider{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *AlphaVantageFundamentalsProvider) Name() string {
+	return "alphavantage"
+}
+
+func (p *AlphaVantageFundamentalsProvider) GetFundamentals(ctx context.Context, symbol string) (*models.Fundamentals, error) {
+	query := url.Values{"function": {"OVERVIEW"}, "symbol": {symbol}, "apikey": {p.apiKey}}
+	endpoint := "https://www.alphavantage.co/query?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// The *url.Error message includes the URL and with it the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var apiError AlphaVantageError
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Information != "" {
+		return nil, fmt.Errorf("API error: %s", apiError.Information)
+	}
+
+	var overview alphaVantageOverview
+	if err := json.Unmarshal(body, &overview); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	if overview.Symbol == "" {
+		return nil, fmt.Errorf("no fundamentals returned for symbol %s", symbol)
+	}
+
+	return &models.Fundamentals{
+		Symbol:             strings.ToUpper(overview.Symbol),
+		Name:               overview.Name,
+		Description:        overview.Description,
+		Exchange:           overview.Exchange,
+		Currency:           overview.Currency,
+		Sector:             overview.Sector,
+		Industry:           overview.Industry,
+		MarketCap:          parseMetric(overview.MarketCapitalization),
+		PERatio:            parseMetric(overview.PERatio),
+		PEGRatio:           parseMetric(overview.PEGRatio),
+		EPS:                parseMetric(overview.EPS),
+		BookValue:          parseMetric(overview.BookValue),
+		DividendPerShare:   parseMetric(overview.DividendPerShare),
+		DividendYield:      parseMetric(overview.DividendYield),
+		ProfitMargin:       parseMetric(overview.ProfitMargin),
+		Beta:               parseMetric(overview.Beta),
+		High52Week:         parseMetric(overview.High52Week),
+		Low52Week:          parseMetric(overview.Low52Week),
+		AnalystTargetPrice: parseMetric(overview.AnalystTargetPrice),
+		Source:             p.Name(),
+		FetchedAt:          time.Now().UTC(),
+	}, nil
+}
+
+// parseMetric parses a provider number, treating "None", "-" and blanks as 0
+func parseMetric(value string) float64 {
+	parsed, err := parsePrice(value)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+// BaseCurrency is the currency every account is kept in
+const BaseCurrency = "USD"
+
+const (
+	// Standard deviation of a simulated rate's move per refresh
+	fxSimulatedVolatility = 0.002
+	// Share of the gap to the reference rate a simulated rate closes per
+	// refresh, so rates wander without drifting off
+	fxSimulatedReversion = 0.1
+)
+
+// Units of each supported currency per dollar, where simulated rates start
+// and revert to
+var fxReferenceRates = map[string]float64{
+	"AUD": 1.52,
+	"BRL": 5.0,
+	"CAD": 1.36,
+	"CHF": 0.88,
+	"CNY": 7.2,
+	"EUR": 0.92,
+	"GBP": 0.79,
+	"INR": 83.0,
+	"JPY": 150.0,
+	"MXN": 17.0,
+	"NGN": 1500.0,
+	"ZAR": 18.5,
+}
+
+// SupportedCurrency reports whether values can be shown in the currency
+func SupportedCurrency(currency string) bool {
+	_, ok := fxReferenceRates[currency]
+	return ok || currency == BaseCurrency
+}
+
+// FXRateProvider fetches exchange rates from an external source
+type FXRateProvider interface {
+	Name() string
+	// Rate returns units of currency per dollar
+	Rate(ctx context.Context, currency string) (float64, error)
+}
+
+// FXService keeps exchange rates from dollars to every supported currency
+// so balances can be shown in the user's currency. Without a provider the
+// rates are simulated: each refresh moves them a little at random, pulled
+// back toward a reference rate. With one, the currencies users have asked
+// for are fetched when first asked for and then every refresh; until a
+// fetch succeeds a currency keeps its previous rate. Refreshes run every
+// FX_REFRESH_MINUTES (default 60).
+type FXService struct {
+	provider FXRateProvider
+	interval time.Duration
+	wanted   chan string
+
+	mu    sync.RWMutex
+	rates map[string]models.FXRate
+	used  map[string]bool // Currencies asked for, fetched from the provider
+}
+
+// NewFXService returns a service using the provider, or simulating rates
+// when it is nil
+func NewFXService(provider FXRateProvider) *FXService {
+	s := &FXService{
+		provider: provider,
+		interval: time.Duration(max(config.GetEnvInt("FX_REFRESH_MINUTES", 60), 1)) * time.Minute,
+		wanted:   make(chan string, len(fxReferenceRates)),
+		rates:    make(map[string]models.FXRate, len(fxReferenceRates)),
+		used:     make(map[string]bool),
+	}
+	now := time.Now().UTC()
+	for currency, rate := range fxReferenceRates {
+		s.rates[currency] = models.FXRate{Base: BaseCurrency, Currency: currency, Rate: rate, Source: "simulated", AsOf: now}
+	}
+	return s
+}
+
+// Rate returns the latest rate from dollars to the currency. Dollars, an
+// empty currency and unsupported currencies get a fixed rate of 1.
+func (s *FXService) Rate(currency string) models.FXRate {
+	currency = strings.ToUpper(currency)
+	s.mu.RLock()
+	rate, ok := s.rates[currency]
+	first := ok && s.provider != nil && !s.used[currency]
+	s.mu.RUnlock()
+	if !ok {
+		return models.FXRate{Base: BaseCurrency, Currency: BaseCurrency, Rate: 1, Source: "fixed", AsOf: time.Now().UTC()}
+	}
+
+	if first {
+		s.mu.Lock()
+		if !s.used[currency] {
+			s.used[currency] = true
+			select {
+			case s.wanted <- currency:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+	return rate
+}
+
+// Rates returns the latest rate of every supported currency, by code
+func (s *FXService) Rates() []models.FXRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rates := make([]models.FXRate, 0, len(s.rates))
+	for _, rate := range s.rates {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Currency < rates[j].Currency })
+	return rates
+}
+
+// Run refreshes the rates until the process exits
+func (s *FXService) Run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case currency := <-s.wanted:
+			s.fetch(currency)
+		case <-ticker.C:
+			if s.provider == nil {
+				s.simulate()
+				continue
+			}
+			s.mu.RLock()
+			currencies := make([]string, 0, len(s.used))
+			for currency := range s.used {
+				currencies = append(currencies, currency)
+			}
+			s.mu.RUnlock()
+			for _, currency := range currencies {
+				s.fetch(currency)
+			}
+		}
+	}
+}
+
+// simulate moves every rate one step
+func (s *FXService) simulate() {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for currency, rate := range s.rates {
+		reference := fxReferenceRates[currency]
+		next := rate.Rate * (1 + rand.NormFloat64()*fxSimulatedVolatility)
+		next += (reference - next) * fxSimulatedReversion
+		rate.Rate = next
+		rate.AsOf = now
+		s.rates[currency] = rate
+	}
+}
+
+// fetch updates one currency from the provider
+func (s *FXService) fetch(currency string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	value, err := s.provider.Rate(ctx, currency)
+	if err != nil {
+		log.Printf("⚠️ %s rate for %s failed, keeping the previous one: %v", s.provider.Name(), currency, err)
+		return
+	}
+	s.mu.Lock()
+	s.rates[currency] = models.FXRate{Base: BaseCurrency, Currency: currency, Rate: value, Source: s.provider.Name(), AsOf: time.Now().UTC()}
+	s.mu.Unlock()
+}
+
+// ConvertSummary converts an account summary's amounts and records the rate
+func ConvertSummary(summary *models.AccountSummary, rate models.FXRate) {
+	summary.CashBalance = rate.Convert(summary.CashBalance)
+	summary.ReservedCash = rate.Convert(summary.ReservedCash)
+	summary.BuyingPower = rate.Convert(summary.BuyingPower)
+	summary.PositionsValue = rate.Convert(summary.PositionsValue)
+	summary.Equity = rate.Convert(summary.Equity)
+	summary.DayProfitLoss = rate.Convert(summary.DayProfitLoss)
+	for i := range summary.PositionChanges {
+		change := &summary.PositionChanges[i]
+		change.SessionOpen = rate.Convert(change.SessionOpen)
+		change.Price = rate.Convert(change.Price)
+		change.DayChange = rate.Convert(change.DayChange)
+	}
+	summary.FX = &rate
+}
+
+// ConvertPositions converts positions' costs and day changes
+func ConvertPositions(positions []models.Portfolio, rate models.FXRate) {
+	for i := range positions {
+		position := &positions[i]
+		position.AvgCost = rate.Convert(position.AvgCost)
+		position.DayChange = rate.Convert(position.DayChange)
+		for j := range position.Lots {
+			position.Lots[j].Price = rate.Convert(position.Lots[j].Price)
+		}
+	}
+}
+
+// ConvertRisk converts risk metrics' amounts and records the rate
+func ConvertRisk(metrics *models.RiskMetrics, rate models.FXRate) {
+	metrics.Equity = rate.Convert(metrics.Equity)
+	metrics.PositionsValue = rate.Convert(metrics.PositionsValue)
+	metrics.VaR95 = rate.Convert(metrics.VaR95)
+	for i := range metrics.Positions {
+		metrics.Positions[i].Value = rate.Convert(metrics.Positions[i].Value)
+	}
+	if metrics.Margin != nil {
+		margin := convertMargin(*metrics.Margin, rate)
+		metrics.Margin = &margin
+	}
+	metrics.FX = &rate
+}
+
+// ConvertStressTest converts a stress test's amounts and records the rate
+func ConvertStressTest(result *models.StressTestResult, rate models.FXRate) {
+	result.CurrentValue = rate.Convert(result.CurrentValue)
+	result.ProjectedValue = rate.Convert(result.ProjectedValue)
+	result.ProfitLoss = rate.Convert(result.ProfitLoss)
+	result.CurrentMargin = convertMargin(result.CurrentMargin, rate)
+	result.ProjectedMargin = convertMargin(result.ProjectedMargin, rate)
+	for i := range result.Positions {
+		position := &result.Positions[i]
+		position.CurrentPrice = rate.Convert(position.CurrentPrice)
+		position.ProjectedPrice = rate.Convert(position.ProjectedPrice)
+		position.ProfitLoss = rate.Convert(position.ProfitLoss)
+	}
+	result.FX = &rate
+}
+
+// ConvertOrderStats converts order statistics' traded values and records
+// the rate
+func ConvertOrderStats(stats *models.OrderStats, rate models.FXRate) {
+	stats.TradedValue = rate.Convert(stats.TradedValue)
+	for i := range stats.BySymbol {
+		stats.BySymbol[i].Volume = rate.Convert(stats.BySymbol[i].Volume)
+	}
+	for i := range stats.Buckets {
+		stats.Buckets[i].TradedValue = rate.Convert(stats.Buckets[i].TradedValue)
+	}
+	for i := range stats.BusiestDays {
+		stats.BusiestDays[i].TradedValue = rate.Convert(stats.BusiestDays[i].TradedValue)
+	}
+	stats.FX = &rate
+}
+
+func convertMargin(margin models.MarginSummary, rate models.FXRate) models.MarginSummary {
+	margin.Equity = rate.Convert(margin.Equity)
+	margin.Requirement = rate.Convert(margin.Requirement)
+	margin.Excess = rate.Convert(margin.Excess)
+	return margin
+}
+
+// AlphaVantageFXProvider reads the Alpha Vantage CURRENCY_EXCHANGE_RATE
+// endpoint
+type AlphaVantageFXProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewFXRateProvider returns the provider FX_PROVIDER names
+func NewFXRateProvider(name, apiKey string) (FXRateProvider, error) {
+	if strings.ToLower(name) != "alphavantage" {
+		return nil, fmt.Errorf("unknown exchange rate provider %q, expected alphavantage", name)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("ALPHA_VANTAGE_API_KEY is required for %s exchange rates", name)
+	}
+	return &AlphaVantageFXProvider{apiKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (p *AlphaVantageFXProvider) Name() string {
+	return "alphavantage"
+}
+
+func (p *AlphaVantageFXProvider) Rate(ctx context.Context, currency string) (float64, error) {
+	var response struct {
+		Rate struct {
+			Value string `json:"5. Exchange Rate"`
+		} `json:"Realtime Currency Exchange Rate"`
+		Information string `json:"Information"`
+		Note        string `json:"Note"`
+	}
+	endpoint := fmt.Sprintf("https://www.alphavantage.co/query?function=CURRENCY_EXCHANGE_RATE&from_currency=%s&to_currency=%s&apikey=%s",
+		BaseCurrency, url.QueryEscape(currency), url.QueryEscape(p.apiKey))
+	if err := getJSON(ctx, p.client, endpoint, &response); err != nil {
+		return 0, err
+	}
+	if message := response.Information + response.Note; message != "" {
+		return 0, fmt.Errorf("API error: %s", message)
+	}
+	rate, err := strconv.ParseFloat(response.Rate.Value, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate returned for %s", currency)
+	}
+	return rate, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Messages sent to a user when they connect, oldest first; older ones are
+// only listed
+const inboxConnectLimit = 100
+
+// InboxService keeps the WebSocket messages of users who were offline, so
+// fills and alerts reach them when they come back
+type InboxService struct {
+	messageCollection *mongo.Collection
+	retention         time.Duration
+}
+
+func NewInboxService() *InboxService {
+	return &InboxService{
+		messageCollection: config.GetCollection("inbox"),
+		retention:         time.Duration(config.GetEnvInt("INBOX_RETENTION_DAYS", 30)) * 24 * time.Hour,
+	}
+}
+
+// EnsureIndexes indexes messages by user and time and expires them after
+// INBOX_RETENTION_DAYS
+func (s *InboxService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.messageCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(s.retention / time.Second)),
+		},
+	})
+	return err
+}
+
+// Store keeps a message for the user
+func (s *InboxService) Store(ctx context.Context, userID string, message map[string]interface{}) error {
+	messageType, _ := message["type"].(string)
+	_, err := s.messageCollection.InsertOne(ctx, models.InboxMessage{
+		UserID:    userID,
+		Type:      messageType,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	})
+	return err
+}
+
+// Undelivered returns the messages not yet sent over a socket, oldest first.
+// They stay undelivered until MarkDelivered is called for them.
+func (s *InboxService) Undelivered(ctx context.Context, userID string) ([]models.InboxMessage, error) {
+	cursor, err := s.messageCollection.Find(ctx,
+		bson.M{"user_id": userID, "delivered_at": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(inboxConnectLimit))
+	if err != nil {
+		return nil, err
+	}
+	var messages []models.InboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkDelivered records that the messages were queued on a socket
+func (s *InboxService) MarkDelivered(ctx context.Context, ids []primitive.ObjectID) error {
+	_, err := s.messageCollection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "delivered_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"delivered_at": time.Now().UTC()}},
+	)
+	return err
+}
+
+// List returns the user's messages, newest first, with how many are unread
+func (s *InboxService) List(ctx context.Context, userID string, limit int64) ([]models.InboxMessage, int64, error) {
+	cursor, err := s.messageCollection.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	messages := []models.InboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.messageCollection.CountDocuments(ctx, bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}})
+	return messages, unread, err
+}
+
+// MarkRead marks all of the user's messages read and returns how many were
+// unread
+func (s *InboxService) MarkRead(ctx context.Context, userID string) (int64, error) {
+	result, err := s.messageCollection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"read_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"trading-simulator/internal/secrets"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Topic of messages sent with the test endpoint
+	webhookTestTopic = "webhook.test"
+	// How long a dispatcher owns a delivery while posting it
+	webhookLease = time.Minute
+	// Delay before the first retry, doubled for every further attempt
+	webhookRetryDelay = 30 * time.Second
+	// Delivered and failed messages are kept this long for troubleshooting
+	webhookRetention = 7 * 24 * time.Hour
+	// Discord rejects longer messages
+	discordMessageLimit = 2000
+)
+
+// Message templates per topic. Templates see .Event, .Competition,
+// .Leaderboard, .Top (the first three places) and .Maintenance.
+var defaultWebhookTemplates = map[string]string{
+	EventCompetitionStarted: `🏁 Competition "{{.Competition.Name}}" has started{{if not .Competition.EndsAt.IsZero}} and runs until {{.Competition.EndsAt.Format "Jan 2 15:04 MST"}}{{end}}.`,
+	EventCompetitionEnded:   `🏆 Competition "{{.Competition.Name}}" has ended.{{range .Top}} {{.Rank}}. {{.Username}} ${{printf "%.2f" .Equity}}{{end}}`,
+	EventLeaderboardChanged: `📊 New leaders in "{{.Competition.Name}}":{{range .Top}} {{.Rank}}. {{.Username}} ${{printf "%.2f" .Equity}}{{end}}`,
+	EventMaintenanceChanged: `{{if .Maintenance.Enabled}}🚧 Trading is paused for maintenance{{with .Maintenance.Message}}: {{.}}{{end}}{{else}}✅ Maintenance is over, trading has resumed{{end}}`,
+	webhookTestTopic:        `🔔 Test message from the trading simulator`,
+}
+
+var webhookKinds = map[string]bool{"slack": true, "discord": true, "generic": true}
+
+// ErrWebhookNotFound is returned for a webhook that does not exist
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// IntegrationService posts platform events — competition starts and ends,
+// leaderboard changes and maintenance — to the Slack, Discord and generic
+// webhooks admins configure. Messages are queued in webhook_deliveries and
+// retried with backoff, so a webhook that is down misses nothing. Webhook
+// URLs, which carry Slack and Discord tokens, and signing secrets are
+// encrypted at rest.
+type IntegrationService struct {
+	webhookCollection  *mongo.Collection
+	deliveryCollection *mongo.Collection
+	client             *http.Client
+	maxAttempts        int
+	keyring            *secrets.Keyring
+}
+
+func NewIntegrationService(events *EventBus, keyring *secrets.Keyring) *IntegrationService {
+	s := &IntegrationService{
+		webhookCollection:  config.GetCollection("webhooks"),
+		deliveryCollection: config.GetCollection("webhook_deliveries"),
+		client:             &http.Client{Timeout: 10 * time.Second},
+		maxAttempts:        max(config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 5), 1),
+		keyring:            keyring,
+	}
+	for _, topic := range []string{EventCompetitionStarted, EventCompetitionEnded, EventLeaderboardChanged, EventMaintenanceChanged} {
+		events.SubscribeAsync(topic, func(event Event) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := s.Notify(ctx, event.Topic, event.Payload); err != nil {
+				log.Printf("Error queueing %s webhooks: %v", event.Topic, err)
+			}
+		})
+	}
+	return s
+}
+
+// ListWebhooks returns every configured webhook
+func (s *IntegrationService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	cursor, err := s.webhookCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		if err := s.decryptWebhook(&webhooks[i]); err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", webhooks[i].Name, err)
+		}
+	}
+	return webhooks, nil
+}
+
+// SaveWebhook validates and stores a webhook, creating it when it has no ID
+func (s *IntegrationService) SaveWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error) {
+	if err := validateWebhook(&webhook); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	webhook.UpdatedAt = now
+	if webhook.ID.IsZero() {
+		webhook.ID = primitive.NewObjectID()
+		webhook.CreatedAt = now
+		stored, err := s.encryptWebhook(webhook)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.webhookCollection.InsertOne(ctx, stored); err != nil {
+			return nil, err
+		}
+		return &webhook, nil
+	}
+
+	var existing models.Webhook
+	if err := s.webhookCollection.FindOne(ctx, bson.M{"_id": webhook.ID}).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	webhook.CreatedAt, webhook.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	// An update without a secret keeps the current one
+	if webhook.Secret == "" {
+		secret, err := s.keyring.Decrypt(existing.Secret)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+	stored, err := s.encryptWebhook(webhook)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookCollection.ReplaceOne(ctx, bson.M{"_id": webhook.ID}, stored); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// encryptWebhook returns the webhook as stored, with its URL and secret
+// encrypted
+func (s *IntegrationService) encryptWebhook(webhook models.Webhook) (models.Webhook, error) {
+	var err error
+	if webhook.URL, err = s.keyring.Encrypt(webhook.URL); err != nil {
+		return webhook, err
+	}
+	webhook.Secret, err = s.keyring.Encrypt(webhook.Secret)
+	return webhook, err
+}
+
+// decryptWebhook restores a stored webhook's URL and secret
+func (s *IntegrationService) decryptWebhook(webhook *models.Webhook) error {
+	var err error
+	if webhook.URL, err = s.keyring.Decrypt(webhook.URL); err != nil {
+		return err
+	}
+	webhook.Secret, err = s.keyring.Decrypt(webhook.Secret)
+	return err
+}
+
+// RotateSecrets re-encrypts every webhook's URL and secret that is not
+// sealed with the active key, and returns how many changed
+func (s *IntegrationService) RotateSecrets(ctx context.Context) (int, error) {
+	if !s.keyring.Enabled() {
+		return 0, secrets.ErrNoKeys
+	}
+	cursor, err := s.webhookCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, webhook := range webhooks {
+		if !s.keyring.NeedsRotation(webhook.URL) && !s.keyring.NeedsRotation(webhook.Secret) {
+			continue
+		}
+		current := webhook
+		if err := s.decryptWebhook(&webhook); err != nil {
+			return rotated, fmt.Errorf("webhook %s: %w", webhook.Name, err)
+		}
+		stored, err := s.encryptWebhook(webhook)
+		if err != nil {
+			return rotated, err
+		}
+		// Left for the next run if the webhook was saved meanwhile
+		result, err := s.webhookCollection.UpdateOne(ctx,
+			bson.M{"_id": webhook.ID, "url": current.URL, "secret": current.Secret},
+			bson.M{"$set": bson.M{"url": stored.URL, "secret": stored.Secret}},
+		)
+		if err != nil {
+			return rotated, err
+		}
+		rotated += int(result.ModifiedCount)
+	}
+	log.Printf("🔑 Re-encrypted the secrets of %d webhooks", rotated)
+	return rotated, nil
+}
+
+// DeleteWebhook removes a webhook and its queued messages
+func (s *IntegrationService) DeleteWebhook(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.webhookCollection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	_, err = s.deliveryCollection.DeleteMany(ctx, bson.M{"webhook_id": id.Hex(), "status": "pending"})
+	return err
+}
+
+// TestWebhook queues a test message for one webhook, enabled or not
+func (s *IntegrationService) TestWebhook(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var webhook models.Webhook
+	if err := s.webhookCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return s.enqueue(ctx, webhook, webhookTestTopic, nil)
+}
+
+// Notify queues a message about an event for every enabled webhook
+// subscribed to its topic
+func (s *IntegrationService) Notify(ctx context.Context, topic string, payload interface{}) error {
+	cursor, err := s.webhookCollection.Find(ctx, bson.M{
+		"enabled": true,
+		"$or":     bson.A{bson.M{"events": topic}, bson.M{"events": bson.M{"$size": 0}}},
+	})
+	if err != nil {
+		return err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		if _, err := s.enqueue(ctx, webhook, topic, payload); err != nil {
+			log.Printf("Error queueing %s for webhook %s: %v", topic, webhook.Name, err)
+		}
+	}
+	return nil
+}
+
+// Deliveries returns queued and past messages, newest first, optionally
+// with one status
+func (s *IntegrationService) Deliveries(ctx context.Context, status string, limit int64) ([]models.WebhookDelivery, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := s.deliveryCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []models.WebhookDelivery{}
+	err = cursor.All(ctx, &deliveries)
+	return deliveries, err
+}
+
+// DeliverPending posts every message that is due. Each is leased before
+// posting so several instances can deliver at once.
+func (s *IntegrationService) DeliverPending(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		var delivery models.WebhookDelivery
+		err := s.deliveryCollection.FindOneAndUpdate(ctx,
+			bson.M{"status": "pending", "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{
+				"$set": bson.M{"next_attempt_at": now.Add(webhookLease)},
+				"$inc": bson.M{"attempts": 1},
+			},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&delivery)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			log.Printf("Error claiming webhook delivery: %v", err)
+			return
+		}
+
+		var webhook models.Webhook
+		err = s.webhookCollection.FindOne(ctx, bson.M{"_id": mustObjectID(delivery.WebhookID)}).Decode(&webhook)
+		if err == nil {
+			err = s.decryptWebhook(&webhook)
+		}
+		if err == nil {
+			err = s.post(ctx, webhook, delivery.Body)
+		}
+
+		update := bson.M{"status": "delivered", "delivered_at": time.Now().UTC()}
+		if err != nil {
+			update = bson.M{"last_error": err.Error(), "next_attempt_at": time.Now().UTC().Add(webhookRetryDelay << (delivery.Attempts - 1))}
+			if delivery.Attempts >= s.maxAttempts {
+				update["status"] = "failed"
+				log.Printf("⚠️ Webhook %s gave up on %s after %d attempts: %v", delivery.WebhookID, delivery.Event, delivery.Attempts, err)
+			}
+		}
+		if _, err := s.deliveryCollection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{"$set": update}); err != nil {
+			log.Printf("Error updating webhook delivery %s: %v", delivery.ID.Hex(), err)
+		}
+	}
+}
+
+// PurgeFinished removes delivered and failed messages past the retention window
+func (s *IntegrationService) PurgeFinished(ctx context.Context) {
+	_, err := s.deliveryCollection.DeleteMany(ctx, bson.M{
+		"status":     bson.M{"$in": bson.A{"delivered", "failed"}},
+		"created_at": bson.M{"$lt": time.Now().Add(-webhookRetention)},
+	})
+	if err != nil {
+		log.Printf("Error purging webhook deliveries: %v", err)
+	}
+}
+
+// enqueue renders the message for a webhook and queues it for delivery
+func (s *IntegrationService) enqueue(ctx context.Context, webhook models.Webhook, topic string, payload interface{}) (*models.WebhookDelivery, error) {
+	message, err := renderWebhookMessage(webhook.Templates[topic], topic, payload)
+	if err != nil {
+		return nil, err
+	}
+	var body interface{}
+	switch webhook.Kind {
+	case "slack":
+		body = map[string]string{"text": message}
+	case "discord":
+		if runes := []rune(message); len(runes) > discordMessageLimit {
+			message = string(runes[:discordMessageLimit-1]) + "…"
+		}
+		body = map[string]string{"content": message}
+	default:
+		body = map[string]interface{}{"event": topic, "text": message, "payload": payload}
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	delivery := models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     webhook.ID.Hex(),
+		Event:         topic,
+		Message:       message,
+		Body:          string(raw),
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if _, err := s.deliveryCollection.InsertOne(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *IntegrationService) post(ctx context.Context, webhook models.Webhook, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write([]byte(body))
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// renderWebhookMessage fills in the template for a topic, or the default
+// template when custom is empty
+func renderWebhookMessage(custom, topic string, payload interface{}) (string, error) {
+	text := custom
+	if text == "" {
+		text = defaultWebhookTemplates[topic]
+	}
+	tmpl, err := template.New(topic).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]interface{}{"Event": topic}
+	switch p := payload.(type) {
+	case models.Competition:
+		data["Competition"] = p
+	case models.Leaderboard:
+		data["Competition"] = p.Competition
+		data["Leaderboard"] = p
+		data["Top"] = p.Entries[:min(len(p.Entries), leaderboardTopPlaces)]
+	case models.MaintenanceState:
+		data["Maintenance"] = p
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func validateWebhook(webhook *models.Webhook) error {
+	webhook.Name = strings.TrimSpace(webhook.Name)
+	webhook.Kind = strings.ToLower(webhook.Kind)
+	if webhook.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !webhookKinds[webhook.Kind] {
+		return fmt.Errorf("kind must be slack, discord or generic")
+	}
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	for _, topic := range webhook.Events {
+		if _, ok := defaultWebhookTemplates[topic]; !ok || topic == webhookTestTopic {
+			return fmt.Errorf("unknown event %q", topic)
+		}
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	for topic, text := range webhook.Templates {
+		if _, ok := defaultWebhookTemplates[topic]; !ok {
+			return fmt.Errorf("template for unknown event %q", topic)
+		}
+		if _, err := template.New(topic).Parse(text); err != nil {
+			return fmt.Errorf("template for %s: %v", topic, err)
+		}
+	}
+	return nil
+}
+
+func mustObjectID(hex string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(hex)
+	return id
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Days interest is spread over in a year
+const interestDaysPerYear = 365
+
+// InterestService accrues a day of interest on every main account: idle
+// cash (cash less holds) earns CASH_INTEREST_RATE and a negative cash
+// balance, money borrowed on margin, is charged MARGIN_INTEREST_RATE. Both
+// are annual percentages.
+type InterestService struct {
+	userCollection *mongo.Collection
+	ledger         *LedgerService
+	cashRate       float64
+	marginRate     float64
+}
+
+func NewInterestService(ledger *LedgerService) *InterestService {
+	return &InterestService{
+		userCollection: config.GetCollection("users"),
+		ledger:         ledger,
+		cashRate:       config.GetEnvFloat("CASH_INTEREST_RATE", 2),
+		marginRate:     config.GetEnvFloat("MARGIN_INTEREST_RATE", 8),
+	}
+}
+
+// AccrueDaily posts the day's interest for every open account. Entries are
+// keyed by user and UTC date, so running it again on the same day does
+// nothing.
+func (s *InterestService) AccrueDaily(ctx context.Context, now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	cursor, err := s.userCollection.Find(ctx,
+		bson.M{"deleted_at": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"cash_balance": 1, "reserved_cash": 1, "tenant_id": 1}),
+	)
+	if err != nil {
+		log.Printf("Error loading accounts for interest: %v", err)
+		return
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Error decoding accounts for interest: %v", err)
+		return
+	}
+
+	posted := 0
+	for _, user := range users {
+		entry, ok := s.accrual(user, day, now)
+		if !ok {
+			continue
+		}
+		done, err := s.ledger.Post(ctx, entry)
+		if err != nil {
+			log.Printf("Error posting %s for %s: %v", entry.Type, entry.UserID, err)
+			continue
+		}
+		if done {
+			posted++
+		}
+	}
+	if posted > 0 {
+		log.Printf("💰 Posted interest for %d accounts for %s", posted, day)
+	}
+}
+
+// accrual returns the day's interest entry for an account, if any is due
+func (s *InterestService) accrual(user models.User, day string, now time.Time) (models.LedgerEntry, bool) {
+	entry := models.LedgerEntry{
+		UserID:    user.ID.Hex(),
+		TenantID:  user.TenantID,
+		CreatedAt: now.UTC(),
+	}
+	switch idle := user.CashBalance - user.ReservedCash; {
+	case user.CashBalance < 0 && s.marginRate > 0:
+		entry.Type = "margin_interest"
+		entry.Balance = round2(-user.CashBalance)
+		entry.Rate = s.marginRate
+		entry.Amount = -math.Round(entry.Balance*s.marginRate/100/interestDaysPerYear*100) / 100
+		entry.Description = fmt.Sprintf("Margin interest on $%.2f borrowed at %.2f%% a year", entry.Balance, s.marginRate)
+	case idle > 0 && s.cashRate > 0:
+		entry.Type = "cash_interest"
+		entry.Balance = round2(idle)
+		entry.Rate = s.cashRate
+		entry.Amount = math.Round(entry.Balance*s.cashRate/100/interestDaysPerYear*100) / 100
+		entry.Description = fmt.Sprintf("Interest on $%.2f idle cash at %.2f%% a year", entry.Balance, s.cashRate)
+	default:
+		return entry, false
+	}
+	if entry.Amount == 0 {
+		return entry, false
+	}
+	entry.ID = fmt.Sprintf("%s-%s-%s", entry.Type, entry.UserID, day)
+	return entry, true
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cash within a cent of what the history says is not a discrepancy; every
+// movement is rounded to cents on its own
+const invariantTolerance = 0.01
+
+// InvariantService checks that stored balances agree with the records they
+// were built from. Cash, positions and executions are written separately,
+// so a fill that stopped halfway, or a job that moved cash without booking
+// it, leaves an account that no longer adds up.
+type InvariantService struct {
+	userCollection        *mongo.Collection
+	entryCollection       *mongo.Collection
+	competitionCollection *mongo.Collection
+	portfolioCollection   *mongo.Collection
+	executionCollection   *mongo.Collection
+	ledgerCollection      *mongo.Collection
+	referralCollection    *mongo.Collection
+	costBasis             *CostBasisService
+	tenants               *TenantService
+	journal               *journal
+}
+
+func NewInvariantService(costBasis *CostBasisService, tenants *TenantService) *InvariantService {
+	return &InvariantService{
+		userCollection:        config.GetCollection("users"),
+		entryCollection:       config.GetCollection("competition_entries"),
+		competitionCollection: config.GetCollection("competitions"),
+		portfolioCollection:   config.GetCollection("portfolio"),
+		executionCollection:   config.GetCollection("executions"),
+		ledgerCollection:      config.GetCollection("ledger"),
+		referralCollection:    config.GetCollection("referrals"),
+		costBasis:             costBasis,
+		tenants:               tenants,
+		journal:               newJournal(),
+	}
+}
+
+// Check verifies the main and competition accounts of one user, or of every
+// user when userID is empty. Each account's cash must equal its starting
+// balance plus credits plus sell proceeds, less buy costs and fees, counting
+// only activity since the account was last reset, and equal the journal's
+// cash balance; each position must match a replay of its executions.
+func (s *InvariantService) Check(ctx context.Context, userID string) (*models.InvariantReport, error) {
+	report := &models.InvariantReport{
+		Accounts:  []models.AccountDiscrepancy{},
+		Positions: []models.PositionDiscrepancy{},
+		CheckedAt: time.Now().UTC(),
+	}
+
+	filter := bson.M{"deleted_at": bson.M{"$exists": false}}
+	if userID != "" {
+		objID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, ErrCostBasisUserMissing
+		}
+		filter["_id"] = objID
+	}
+	cursor, err := s.userCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		if err := s.checkUser(ctx, user, report); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔎 Checked %d accounts and %d positions: %d accounts and %d positions do not add up",
+		report.CheckedAccounts, report.CheckedPositions, len(report.Accounts), len(report.Positions))
+	return report, nil
+}
+
+func (s *InvariantService) checkUser(ctx context.Context, user models.User, report *models.InvariantReport) error {
+	userID := user.ID.Hex()
+
+	start, assumed := user.StartingBalance, false
+	if start == 0 && user.AccountResetAt.IsZero() {
+		start, assumed = s.tenants.StartingBalance(user.TenantID), true
+	}
+	account := models.AccountDiscrepancy{
+		UserID:          userID,
+		Username:        user.Username,
+		Cash:            user.CashBalance,
+		StartingBalance: start,
+		AssumedStart:    assumed,
+	}
+	if err := s.checkAccount(ctx, &account, user.AccountResetAt, report); err != nil {
+		return err
+	}
+
+	cursor, err := s.entryCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+	var entries []models.CompetitionEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		competitionID, err := primitive.ObjectIDFromHex(entry.CompetitionID)
+		if err != nil {
+			continue
+		}
+		var competition models.Competition
+		if err := s.competitionCollection.FindOne(ctx, bson.M{"_id": competitionID}).Decode(&competition); err != nil {
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			return err
+		}
+		account := models.AccountDiscrepancy{
+			UserID:          userID,
+			Username:        user.Username,
+			CompetitionID:   entry.CompetitionID,
+			Cash:            entry.CashBalance,
+			StartingBalance: competition.Rules.StartingCash,
+		}
+		if err := s.checkAccount(ctx, &account, time.Time{}, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAccount totals the account's history since since, compares it with
+// the stored cash and replays its positions
+func (s *InvariantService) checkAccount(ctx context.Context, account *models.AccountDiscrepancy, since time.Time, report *models.InvariantReport) error {
+	// Main account records have no competition ID
+	var competitionID interface{}
+	if account.CompetitionID != "" {
+		competitionID = account.CompetitionID
+	}
+
+	credits, err := sumField(ctx, s.ledgerCollection, bson.M{
+		"user_id":        account.UserID,
+		"competition_id": competitionID,
+		"created_at":     bson.M{"$gte": since},
+	}, "$amount")
+	if err != nil {
+		return err
+	}
+	account.Credits = credits
+	if account.CompetitionID == "" {
+		bonuses, err := sumField(ctx, s.referralCollection, bson.M{
+			"$or":        bson.A{bson.M{"referee_id": account.UserID}, bson.M{"referrer_id": account.UserID}},
+			"created_at": bson.M{"$gte": since},
+		}, "$bonus")
+		if err != nil {
+			return err
+		}
+		account.Credits += bonuses
+	}
+
+	// Seeded positions were placed by a teacher, not paid for, so they are
+	// kept apart from buys
+	cursor, err := s.executionCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":        account.UserID,
+			"competition_id": competitionID,
+			"executed_at":    bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$liquidity", "seed"}}, "seed", "$side"}},
+			"notional": bson.M{"$sum": bson.M{"$multiply": bson.A{"$quantity", "$price"}}},
+			"fees":     bson.M{"$sum": "$fees"},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var sides []struct {
+		Side     string  `bson:"_id"`
+		Notional float64 `bson:"notional"`
+		Fees     float64 `bson:"fees"`
+	}
+	if err := cursor.All(ctx, &sides); err != nil {
+		return err
+	}
+	for _, side := range sides {
+		switch side.Side {
+		case "seed":
+			account.Seeded += side.Notional
+		case "sell":
+			account.Sold += side.Notional
+		default:
+			account.Bought += side.Notional
+		}
+		account.Fees += side.Fees
+	}
+
+	cursor, err = s.portfolioCollection.Find(ctx, positionFilter(account.UserID, account.CompetitionID, ""))
+	if err != nil {
+		return err
+	}
+	var positions []models.Portfolio
+	if err := cursor.All(ctx, &positions); err != nil {
+		return err
+	}
+	method := costBasisMethod(ctx, s.userCollection, account.UserID)
+	for _, pos := range positions {
+		account.OpenCost += pos.Shares * pos.AvgCost
+		report.CheckedPositions++
+
+		replayed, err := s.costBasis.replay(ctx, pos, method)
+		if err != nil {
+			return err
+		}
+		if math.Abs(replayed.Shares-pos.Shares) >= quantityStep || math.Abs(replayed.AvgCost-pos.AvgCost) >= invariantTolerance {
+			report.Positions = append(report.Positions, models.PositionDiscrepancy{
+				UserID:          pos.UserID,
+				Symbol:          pos.Symbol,
+				CompetitionID:   pos.CompetitionID,
+				Shares:          pos.Shares,
+				ReplayedShares:  replayed.Shares,
+				AvgCost:         pos.AvgCost,
+				ReplayedAvgCost: replayed.AvgCost,
+			})
+		}
+	}
+
+	// The stored cash must also be what the journal's cash account sums to
+	balances, entries, err := s.journal.balances(ctx, account.UserID, account.CompetitionID)
+	if err != nil {
+		return err
+	}
+	journalOff := false
+	if entries > 0 {
+		account.JournalCash = round2(balances[JournalCash])
+		journalOff = math.Abs(account.Cash-balances[JournalCash]) >= invariantTolerance
+	}
+
+	report.CheckedAccounts++
+	account.Expected = account.StartingBalance + account.Credits + account.Sold - account.Bought - account.Fees
+	account.Difference = round2(account.Cash - account.Expected)
+	if math.Abs(account.Difference) < invariantTolerance && !journalOff {
+		return nil
+	}
+	account.Expected = round2(account.Expected)
+	account.Credits = round2(account.Credits)
+	account.Bought = round2(account.Bought)
+	account.Sold = round2(account.Sold)
+	account.Fees = round2(account.Fees)
+	account.Seeded = round2(account.Seeded)
+	account.OpenCost = round2(account.OpenCost)
+	account.RealizedPnL = round2(account.Sold - account.Bought - account.Seeded + account.OpenCost)
+	report.Accounts = append(report.Accounts, *account)
+	return nil
+}
+
+// sumField adds up a field over the documents matching filter
+func sumField(ctx context.Context, collection *mongo.Collection, filter bson.M, field string) (float64, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": field}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var totals []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil || len(totals) == 0 {
+		return 0, err
+	}
+	return totals[0].Total, nil
+}
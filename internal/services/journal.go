@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Ledger accounts journal entries post to. Cash and positions are assets;
+// capital and income accounts carry credit balances.
+const (
+	JournalCash        = "cash"
+	JournalPositions   = "positions"    // Cost of open positions; negative for shorts
+	JournalFees        = "fees"         // Trade and borrow fees paid
+	JournalDividends   = "dividends"    // Dividends received
+	JournalInterest    = "interest"     // Cash interest earned less margin interest paid
+	JournalRealizedPnL = "realized_pnl" // Gains credited, losses debited
+	JournalCapital     = "capital"      // Starting balances, bonuses, resets, deposits and withdrawals
+)
+
+// journalTolerance absorbs float error in postings that are not rounded to cents
+const journalTolerance = 1e-6
+
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+// ledgerAccounts is the account each ledger entry type offsets cash against
+var ledgerAccounts = map[string]string{
+	"cash_interest":   JournalInterest,
+	"margin_interest": JournalInterest,
+	"borrow_fee":      JournalFees,
+	"dividend":        JournalDividends,
+	"cash_in_lieu":    JournalPositions, // The fraction of a share is sold at its cost
+	"deposit":         JournalCapital,
+	"withdrawal":      JournalCapital,
+}
+
+// journal writes journal entries and keeps the cash stored on accounts in
+// step with them. The stored cash is a cache of the journal's cash account,
+// read on every order; the journal is the record it can be rebuilt from.
+type journal struct {
+	journalCollection *mongo.Collection
+	userCollection    *mongo.Collection
+	entryCollection   *mongo.Collection
+}
+
+func newJournal() *journal {
+	return &journal{
+		journalCollection: config.GetCollection("journal"),
+		userCollection:    config.GetCollection("users"),
+		entryCollection:   config.GetCollection("competition_entries"),
+	}
+}
+
+// post records the entry and applies its cash to the account's stored
+// balance. It reports false without changing anything if an entry with the
+// same ID was already posted. The two writes are only atomic inside a
+// transaction, so callers run it in one where they can.
+func (j *journal) post(ctx context.Context, entry models.JournalEntry) (bool, error) {
+	posted, err := j.record(ctx, entry)
+	if !posted || err != nil {
+		return posted, err
+	}
+	if cash := entryCash(entry); cash != 0 {
+		return true, j.adjustCash(ctx, entry.UserID, entry.CompetitionID, cash)
+	}
+	return true, nil
+}
+
+// record stores the entry without touching the account's stored cash, for
+// entries whose cash was written with the account itself, such as openings
+func (j *journal) record(ctx context.Context, entry models.JournalEntry) (bool, error) {
+	entry.Postings = compactPostings(entry.Postings)
+	if !balanced(entry.Postings) {
+		return false, ErrUnbalancedEntry
+	}
+	_, err := j.journalCollection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// adjustCash applies a cash movement to a user's main account, or to their
+// competition account when competitionID is set
+func (j *journal) adjustCash(ctx context.Context, userIDHex, competitionID string, delta float64) error {
+	if competitionID != "" {
+		_, err := j.entryCollection.UpdateOne(ctx,
+			bson.M{"competition_id": competitionID, "user_id": userIDHex},
+			bson.M{"$inc": bson.M{"cash_balance": delta}},
+		)
+		return err
+	}
+	userID, _ := primitive.ObjectIDFromHex(userIDHex)
+	_, err := j.userCollection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"cash_balance": delta}},
+	)
+	return err
+}
+
+// balances sums the postings of an account's journal by ledger account
+func (j *journal) balances(ctx context.Context, userID, competitionID string) (map[string]float64, int, error) {
+	// Main account entries have no competition ID
+	var competition interface{}
+	if competitionID != "" {
+		competition = competitionID
+	}
+	match := bson.M{"user_id": userID, "competition_id": competition}
+
+	entries, err := j.journalCollection.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor, err := j.journalCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$postings"}},
+		{{Key: "$group", Value: bson.M{"_id": "$postings.account", "total": bson.M{"$sum": "$postings.amount"}}}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	var totals []struct {
+		Account string  `bson:"_id"`
+		Total   float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, 0, err
+	}
+	balances := make(map[string]float64, len(totals))
+	for _, total := range totals {
+		balances[total.Account] = total.Total
+	}
+	return balances, int(entries), nil
+}
+
+// tradeEntry is the journal entry of a fill. costBefore and costAfter are
+// the position's open cost either side of it; the part of the cash that did
+// not go into or come out of the position at cost is realized P&L.
+func tradeEntry(order *models.Order, costBefore, costAfter float64) models.JournalEntry {
+	cash := order.Price*order.Quantity - order.Fees
+	if order.Type == "buy" {
+		cash = -(order.Price*order.Quantity + order.Fees)
+	}
+	positions := costAfter - costBefore
+	return models.JournalEntry{
+		ID:          "trade:" + order.TradeID,
+		UserID:      order.UserID,
+		Type:        "trade",
+		Symbol:      order.Symbol,
+		Description: fmt.Sprintf("%s %g %s at %g", order.Type, order.Quantity, order.Symbol, order.Price),
+		Postings: []models.Posting{
+			{Account: JournalCash, Amount: cash},
+			{Account: JournalPositions, Amount: positions},
+			{Account: JournalFees, Amount: order.Fees},
+			{Account: JournalRealizedPnL, Amount: -(cash + positions + order.Fees)},
+		},
+		CompetitionID: order.CompetitionID,
+		TenantID:      order.TenantID,
+		CreatedAt:     order.Timestamp,
+	}
+}
+
+// openingEntry is the first entry of an account: the cash it opened with,
+// and the cost of any positions it already held, paid in as capital
+func openingEntry(userID, competitionID, tenantID string, cash, positions float64, at time.Time) models.JournalEntry {
+	id := "opening:" + userID
+	if competitionID != "" {
+		id += ":" + competitionID
+	}
+	return models.JournalEntry{
+		ID:          id,
+		UserID:      userID,
+		Type:        "opening",
+		Description: "Opening balance",
+		Postings: []models.Posting{
+			{Account: JournalCash, Amount: cash},
+			{Account: JournalPositions, Amount: positions},
+			{Account: JournalCapital, Amount: -(cash + positions)},
+		},
+		CompetitionID: competitionID,
+		TenantID:      tenantID,
+		CreatedAt:     at,
+	}
+}
+
+// openCost is what a position cost; negative for shorts
+func openCost(pos models.Portfolio) float64 {
+	return pos.Shares * pos.AvgCost
+}
+
+// entryCash is the net of an entry's cash postings
+func entryCash(entry models.JournalEntry) float64 {
+	cash := 0.0
+	for _, posting := range entry.Postings {
+		if posting.Account == JournalCash {
+			cash += posting.Amount
+		}
+	}
+	return cash
+}
+
+// compactPostings drops zero postings
+func compactPostings(postings []models.Posting) []models.Posting {
+	kept := make([]models.Posting, 0, len(postings))
+	for _, posting := range postings {
+		if math.Abs(posting.Amount) >= journalTolerance {
+			kept = append(kept, posting)
+		}
+	}
+	return kept
+}
+
+func balanced(postings []models.Posting) bool {
+	total := 0.0
+	for _, posting := range postings {
+		total += posting.Amount
+	}
+	return math.Abs(total) < journalTolerance
+}
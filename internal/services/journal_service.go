@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JournalService reads the double-entry journal and rebuilds the cash
+// stored on accounts from it
+type JournalService struct {
+	userCollection      *mongo.Collection
+	entryCollection     *mongo.Collection
+	portfolioCollection *mongo.Collection
+	orderService        *OrderService
+}
+
+func NewJournalService(orderService *OrderService) *JournalService {
+	return &JournalService{
+		userCollection:      config.GetCollection("users"),
+		entryCollection:     config.GetCollection("competition_entries"),
+		portfolioCollection: config.GetCollection("portfolio"),
+		orderService:        orderService,
+	}
+}
+
+// EnsureIndexes creates the index account journals are read by
+func (s *JournalService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.orderService.journal.journalCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "competition_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+// List returns an account's journal entries, newest first
+func (s *JournalService) List(ctx context.Context, userID, competitionID string, limit int64) ([]models.JournalEntry, error) {
+	filter := bson.M{"user_id": userID, "competition_id": nil}
+	if competitionID != "" {
+		filter["competition_id"] = competitionID
+	}
+	cursor, err := s.orderService.journal.journalCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.JournalEntry{}
+	err = cursor.All(ctx, &entries)
+	return entries, err
+}
+
+// Balances sums an account's journal by ledger account. Its cash balance
+// and the stored cash should agree; Materialize corrects them if not.
+func (s *JournalService) Balances(ctx context.Context, userID, competitionID string) (*models.JournalBalances, error) {
+	balances, entries, err := s.orderService.journal.balances(ctx, userID, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := s.cachedCash(ctx, userID, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	for account, balance := range balances {
+		balances[account] = round2(balance)
+	}
+	return &models.JournalBalances{
+		UserID:        userID,
+		CompetitionID: competitionID,
+		Balances:      balances,
+		CachedCash:    round2(cached),
+		Entries:       entries,
+	}, nil
+}
+
+// Materialize rebuilds the stored cash of one user's accounts, or of every
+// account when userID is empty, from the journal. An account without an
+// opening entry dates from before the journal; it is given one that carries
+// its balance forward instead of being corrected.
+func (s *JournalService) Materialize(ctx context.Context, userID string) (*models.MaterializeReport, error) {
+	return s.materializeAll(ctx, userID, s.orderService.runInTransaction)
+}
+
+// MaterializeScheduled is the daily run of Materialize over every account.
+// Without transactions a fill can be summed from the journal before its
+// cash reaches the account, and correcting the cash then would count it
+// twice, so the run is skipped on a server that cannot run them.
+func (s *JournalService) MaterializeScheduled(ctx context.Context) (*models.MaterializeReport, error) {
+	report, err := s.materializeAll(ctx, "", s.orderService.transact)
+	if errors.Is(err, errTransactionsUnsupported) {
+		log.Println("📒 Skipping balance materialization, MongoDB does not support transactions")
+		return &models.MaterializeReport{}, nil
+	}
+	return report, err
+}
+
+// materializeAll checks and corrects each account inside transact
+func (s *JournalService) materializeAll(ctx context.Context, userID string, transact func(context.Context, func(context.Context) error) error) (*models.MaterializeReport, error) {
+	userFilter := bson.M{}
+	entryFilter := bson.M{}
+	if userID != "" {
+		objID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, ErrAccountNotFound
+		}
+		userFilter["_id"] = objID
+		entryFilter["user_id"] = userID
+	}
+
+	type account struct{ userID, competitionID, tenantID string }
+	var accounts []account
+	cursor, err := s.userCollection.Find(ctx, userFilter, options.Find().SetProjection(bson.M{"_id": 1, "tenant_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		accounts = append(accounts, account{userID: user.ID.Hex(), tenantID: user.TenantID})
+	}
+	cursor, err = s.entryCollection.Find(ctx, entryFilter)
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.CompetitionEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		accounts = append(accounts, account{userID: entry.UserID, competitionID: entry.CompetitionID})
+	}
+
+	report := &models.MaterializeReport{}
+	for _, a := range accounts {
+		// A fill in between would change the cash after it was summed, so
+		// the check and the correction happen in one transaction
+		err := transact(ctx, func(ctx context.Context) error {
+			return s.materialize(ctx, a.userID, a.competitionID, a.tenantID, report)
+		})
+		if err != nil {
+			return nil, err
+		}
+		report.Checked++
+	}
+	report.Drift = round2(report.Drift)
+
+	log.Printf("📒 Materialized %d account balances: %d opened, %d corrected, $%.2f drift",
+		report.Checked, report.Opened, report.Corrected, report.Drift)
+	return report, nil
+}
+
+func (s *JournalService) materialize(ctx context.Context, userID, competitionID, tenantID string, report *models.MaterializeReport) error {
+	journal := s.orderService.journal
+	balances, _, err := journal.balances(ctx, userID, competitionID)
+	if err != nil {
+		return err
+	}
+	cached, err := s.cachedCash(ctx, userID, competitionID)
+	if err != nil {
+		return err
+	}
+
+	opening := openingEntry(userID, competitionID, tenantID, 0, 0, time.Now().UTC())
+	err = journal.journalCollection.FindOne(ctx, bson.M{"_id": opening.ID}).Err()
+	if err == mongo.ErrNoDocuments {
+		cursor, err := s.portfolioCollection.Find(ctx, positionFilter(userID, competitionID, ""))
+		if err != nil {
+			return err
+		}
+		var positions []models.Portfolio
+		if err := cursor.All(ctx, &positions); err != nil {
+			return err
+		}
+		positionCost := 0.0
+		for _, pos := range positions {
+			positionCost += openCost(pos)
+		}
+		// Entries posted since the journal started are already counted
+		opening = openingEntry(userID, competitionID, tenantID,
+			cached-balances[JournalCash], positionCost-balances[JournalPositions], opening.CreatedAt)
+		opening.Description = "Balance carried forward"
+		if _, err := journal.record(ctx, opening); err != nil {
+			return err
+		}
+		report.Opened++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	drift := cached - balances[JournalCash]
+	if math.Abs(drift) < journalTolerance {
+		return nil
+	}
+	// Only corrected if the cash is still what was read, so a movement
+	// applied meanwhile is not overwritten
+	var result *mongo.UpdateResult
+	if competitionID != "" {
+		result, err = s.entryCollection.UpdateOne(ctx,
+			bson.M{"competition_id": competitionID, "user_id": userID, "cash_balance": cached},
+			bson.M{"$set": bson.M{"cash_balance": balances[JournalCash]}},
+		)
+	} else {
+		objID, _ := primitive.ObjectIDFromHex(userID)
+		result, err = s.userCollection.UpdateOne(ctx,
+			bson.M{"_id": objID, "cash_balance": cached},
+			bson.M{"$set": bson.M{"cash_balance": balances[JournalCash]}},
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		log.Printf("📒 Cash of %s %s changed while materializing, left for the next run", userID, competitionID)
+		return nil
+	}
+	log.Printf("📒 Corrected cash of %s %s by $%.2f to match the journal", userID, competitionID, -drift)
+	report.Corrected++
+	report.Drift += math.Abs(drift)
+	return nil
+}
+
+// cachedCash returns the cash stored on an account
+func (s *JournalService) cachedCash(ctx context.Context, userID, competitionID string) (float64, error) {
+	if competitionID != "" {
+		var entry models.CompetitionEntry
+		err := s.entryCollection.FindOne(ctx, bson.M{"competition_id": competitionID, "user_id": userID}).Decode(&entry)
+		if err == mongo.ErrNoDocuments {
+			return 0, ErrAccountNotFound
+		}
+		return entry.CashBalance, err
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, ErrAccountNotFound
+	}
+	var user models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"cash_balance": 1})).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return 0, ErrAccountNotFound
+	}
+	return user.CashBalance, err
+}
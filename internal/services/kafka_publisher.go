@@ -0,0 +1,58 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KafkaRESTPublisher produces to Kafka through a Kafka REST Proxy (v2 API),
+// which keeps the simulator free of a native Kafka client
+type KafkaRESTPublisher struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewKafkaRESTPublisher(baseURL string) (*KafkaRESTPublisher, error) {
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid KAFKA_REST_URL: %v", err)
+	}
+	log.Printf("✅ Streaming events to Kafka REST proxy at %s", baseURL)
+	return &KafkaRESTPublisher{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *KafkaRESTPublisher) Publish(topic string, payload []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]json.RawMessage{{"value": payload}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Post(p.baseURL+"/topics/"+url.PathEscape(topic),
+		"application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Kafka REST proxy returned %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+func (p *KafkaRESTPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
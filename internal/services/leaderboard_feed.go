@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+// LeaderboardChannel carries live leaderboard updates. Clients subscribe per
+// competition; each competition's updates are sequenced on its own
+// "leaderboard:<competition ID>" channel for resume.
+const LeaderboardChannel = "leaderboard"
+
+// Places sent in full with live leaderboard updates
+const leaderboardLiveTop = 10
+
+// LeaderboardFeed keeps the standings of running competitions in memory and
+// pushes what changed to WebSocket subscribers, at most every
+// LEADERBOARD_PUSH_MS (default 2000). A tick only revalues the entrants
+// holding the symbol and a fill only reloads the entrant who traded; each
+// is moved to its new place instead of re-ranking everyone. Entries are
+// reloaded from Mongo every LEADERBOARD_RESYNC_SECONDS (default 60) to pick
+// up new entrants and cash movements such as dividends.
+type LeaderboardFeed struct {
+	competitions *CompetitionService
+	market       *MarketDataService
+	hub          *WebSocketHub
+	pushEvery    time.Duration
+	resyncEvery  time.Duration
+
+	mu     sync.Mutex
+	boards map[string]*liveBoard // By competition ID
+}
+
+// liveEntry is an entrant's account as the feed last loaded it
+type liveEntry struct {
+	userID    string
+	username  string
+	cash      float64
+	positions []models.Portfolio
+	equity    float64
+	index     int // Position in liveBoard.ranked
+	rank      int // Rank in the last push; 0 until the entrant first appears in one
+}
+
+// liveBoard is one competition's standings
+type liveBoard struct {
+	tenantID string // Only the tenant's sockets follow it
+	entries map[string]*liveEntry      // By user ID
+	holders map[string]map[string]bool // Symbol -> user IDs holding it
+	ranked  []*liveEntry               // Best first
+	dirty   map[string]bool            // User IDs to revalue before the next push
+	removed []string                   // User IDs dropped since the last push
+	top     []models.LeaderboardEntry  // Top places in the last push
+
+	// Span of ranked whose ranks may have changed since the last push
+	changedFrom, changedTo int
+}
+
+func NewLeaderboardFeed(competitions *CompetitionService, market *MarketDataService, hub *WebSocketHub, events *EventBus) *LeaderboardFeed {
+	f := &LeaderboardFeed{
+		competitions: competitions,
+		market:       market,
+		hub:          hub,
+		pushEvery:    time.Duration(max(config.GetEnvInt("LEADERBOARD_PUSH_MS", 2000), 250)) * time.Millisecond,
+		resyncEvery:  time.Duration(config.GetEnvInt("LEADERBOARD_RESYNC_SECONDS", 60)) * time.Second,
+		boards:       make(map[string]*liveBoard),
+	}
+	events.Subscribe(EventPriceTick, f.onTick)
+	events.SubscribeAsync(EventOrderFilled, f.onFill)
+	hub.SetLeaderboards(f)
+	return f
+}
+
+// Run pushes leaderboard updates until the process exits
+func (f *LeaderboardFeed) Run() {
+	ticker := time.NewTicker(f.pushEvery)
+	defer ticker.Stop()
+
+	var synced time.Time
+	for range ticker.C {
+		if time.Since(synced) >= f.resyncEvery {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := f.sync(ctx); err != nil {
+				log.Printf("Error loading live leaderboards: %v", err)
+			}
+			cancel()
+			synced = time.Now()
+		}
+		f.push()
+	}
+}
+
+// onTick marks the entrants holding the symbol for revaluation
+func (f *LeaderboardFeed) onTick(event Event) {
+	stock := event.Payload.(models.Stock)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, board := range f.boards {
+		for userID := range board.holders[stock.Symbol] {
+			board.dirty[userID] = true
+		}
+	}
+}
+
+// onFill reloads the account of an entrant who traded in a competition
+func (f *LeaderboardFeed) onFill(event Event) {
+	order := event.Payload.(models.Order)
+	if order.CompetitionID == "" {
+		return
+	}
+	f.mu.Lock()
+	_, tracked := f.boards[order.CompetitionID]
+	f.mu.Unlock()
+	if !tracked {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	entry, positions, err := f.competitions.GetPortfolio(ctx, order.CompetitionID, order.UserID)
+	if err != nil {
+		log.Printf("Error reloading competition entry of %s: %v", order.UserID, err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if board, ok := f.boards[order.CompetitionID]; ok {
+		username := ""
+		if existing, ok := board.entries[order.UserID]; ok {
+			username = existing.username
+		}
+		f.setEntry(board, *entry, positions, username)
+	}
+}
+
+// sync reloads every running competition's entries from Mongo
+func (f *LeaderboardFeed) sync(ctx context.Context) error {
+	active, err := f.competitions.ActiveCompetitions(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	type loadedEntry struct {
+		entry     models.CompetitionEntry
+		positions []models.Portfolio
+	}
+	loaded := make(map[string][]loadedEntry, len(active))
+	usernames := make(map[string]map[string]string, len(active))
+	tenants := make(map[string]string, len(active))
+	for _, competition := range active {
+		id := competition.ID.Hex()
+		entries, names, err := f.competitions.entrants(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			positions, err := f.competitions.positions(ctx, id, entry.UserID)
+			if err != nil {
+				return err
+			}
+			loaded[id] = append(loaded[id], loadedEntry{entry: entry, positions: positions})
+		}
+		usernames[id] = names
+		tenants[id] = competition.TenantID
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id := range f.boards {
+		if _, ok := usernames[id]; !ok {
+			delete(f.boards, id)
+		}
+	}
+	for id, names := range usernames {
+		board, ok := f.boards[id]
+		if !ok {
+			board = &liveBoard{
+				entries:     make(map[string]*liveEntry),
+				holders:     make(map[string]map[string]bool),
+				dirty:       make(map[string]bool),
+				changedFrom: -1,
+			}
+			f.boards[id] = board
+		}
+		board.tenantID = tenants[id]
+		present := make(map[string]bool, len(loaded[id]))
+		for _, l := range loaded[id] {
+			present[l.entry.UserID] = true
+			f.setEntry(board, l.entry, l.positions, names[l.entry.UserID])
+		}
+		for userID, entry := range board.entries {
+			if !present[userID] {
+				board.remove(entry)
+			}
+		}
+	}
+	return nil
+}
+
+// setEntry stores an entrant's account, adding them to the ranking if new
+func (f *LeaderboardFeed) setEntry(board *liveBoard, entry models.CompetitionEntry, positions []models.Portfolio, username string) {
+	live, ok := board.entries[entry.UserID]
+	if !ok {
+		live = &liveEntry{userID: entry.UserID, index: len(board.ranked)}
+		board.entries[entry.UserID] = live
+		board.ranked = append(board.ranked, live)
+		board.touch(live.index, live.index)
+	}
+	for _, pos := range live.positions {
+		delete(board.holders[pos.Symbol], entry.UserID)
+	}
+	for _, pos := range positions {
+		if board.holders[pos.Symbol] == nil {
+			board.holders[pos.Symbol] = make(map[string]bool)
+		}
+		board.holders[pos.Symbol][entry.UserID] = true
+	}
+	if username != "" {
+		live.username = username
+	}
+	live.cash = entry.CashBalance
+	live.positions = positions
+	board.dirty[entry.UserID] = true
+}
+
+// push revalues the marked entrants, moves them to their new places and
+// sends each competition's changes to its subscribers
+func (f *LeaderboardFeed) push() {
+	type update struct {
+		competitionID string
+		tenantID      string
+		payload       map[string]interface{}
+	}
+	var updates []update
+
+	f.mu.Lock()
+	for id, board := range f.boards {
+		for userID := range board.dirty {
+			if entry, ok := board.entries[userID]; ok {
+				entry.equity = f.equity(entry)
+				board.reposition(entry)
+			}
+		}
+		clear(board.dirty)
+
+		changes := []models.LeaderboardChange{}
+		if board.changedFrom >= 0 {
+			for i := board.changedFrom; i <= board.changedTo && i < len(board.ranked); i++ {
+				entry := board.ranked[i]
+				if entry.rank != i+1 {
+					changes = append(changes, models.LeaderboardChange{
+						UserID:       entry.userID,
+						Username:     entry.username,
+						Rank:         i + 1,
+						PreviousRank: entry.rank,
+						Equity:       round2(entry.equity),
+					})
+					entry.rank = i + 1
+				}
+			}
+		}
+		board.changedFrom, board.changedTo = -1, -1
+
+		top := board.topPlaces()
+		topChanged := !slices.Equal(top, board.top)
+		if len(changes) == 0 && len(board.removed) == 0 && !topChanged {
+			continue
+		}
+		payload := map[string]interface{}{
+			"type":          "leaderboard",
+			"competitionId": id,
+			"changes":       changes,
+			"entrants":      len(board.ranked),
+			"updatedAt":     time.Now().UTC(),
+		}
+		if topChanged {
+			payload["top"] = top
+			board.top = top
+		}
+		if len(board.removed) > 0 {
+			payload["removed"] = board.removed
+			board.removed = nil
+		}
+		updates = append(updates, update{competitionID: id, tenantID: board.tenantID, payload: payload})
+	}
+	f.mu.Unlock()
+
+	// Sent outside the lock: the hub calls back into the feed for snapshots
+	for _, u := range updates {
+		f.hub.PublishLeaderboard(u.competitionID, u.tenantID, u.payload)
+	}
+}
+
+// snapshot returns a competition's current top places for a new subscriber
+// of the tenant. Competitions of other tenants are not found.
+func (f *LeaderboardFeed) snapshot(competitionID, tenantID string) (map[string]interface{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	board, ok := f.boards[competitionID]
+	if !ok || board.tenantID != tenantID {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"top":      board.topPlaces(),
+		"entrants": len(board.ranked),
+	}, true
+}
+
+// equity values an entrant's account at the simulator's last prices, the
+// same prices competition orders fill at
+func (f *LeaderboardFeed) equity(entry *liveEntry) float64 {
+	equity := entry.cash
+	for _, pos := range entry.positions {
+		price, ok := f.market.GetLastPrice(pos.Symbol)
+		if !ok {
+			price = pos.AvgCost
+		}
+		equity += pos.Shares * price
+	}
+	return equity
+}
+
+// reposition moves an entry up or down the ranking to match its equity,
+// shifting only the entries it passes
+func (b *liveBoard) reposition(entry *liveEntry) {
+	from, i := entry.index, entry.index
+	for i > 0 && b.ranked[i-1].equity < entry.equity {
+		b.ranked[i] = b.ranked[i-1]
+		b.ranked[i].index = i
+		i--
+	}
+	for i < len(b.ranked)-1 && b.ranked[i+1].equity > entry.equity {
+		b.ranked[i] = b.ranked[i+1]
+		b.ranked[i].index = i
+		i++
+	}
+	b.ranked[i] = entry
+	entry.index = i
+	b.touch(min(from, i), max(from, i))
+}
+
+// remove drops an entrant who left the competition
+func (b *liveBoard) remove(entry *liveEntry) {
+	b.ranked = slices.Delete(b.ranked, entry.index, entry.index+1)
+	for i := entry.index; i < len(b.ranked); i++ {
+		b.ranked[i].index = i
+	}
+	for _, pos := range entry.positions {
+		delete(b.holders[pos.Symbol], entry.userID)
+	}
+	delete(b.entries, entry.userID)
+	delete(b.dirty, entry.userID)
+	b.removed = append(b.removed, entry.userID)
+	if entry.index < len(b.ranked) {
+		b.touch(entry.index, len(b.ranked)-1)
+	}
+}
+
+// touch records that ranks between from and to may have changed
+func (b *liveBoard) touch(from, to int) {
+	if b.changedFrom < 0 || from < b.changedFrom {
+		b.changedFrom = from
+	}
+	if to > b.changedTo {
+		b.changedTo = to
+	}
+}
+
+// topPlaces returns the leading entrants
+func (b *liveBoard) topPlaces() []models.LeaderboardEntry {
+	top := make([]models.LeaderboardEntry, 0, leaderboardLiveTop)
+	for i, entry := range b.ranked[:min(len(b.ranked), leaderboardLiveTop)] {
+		top = append(top, models.LeaderboardEntry{
+			Rank:     i + 1,
+			UserID:   entry.userID,
+			Username: entry.username,
+			Equity:   math.Round(entry.equity*100) / 100,
+		})
+	}
+	return top
+}
+
+// leaderboardUpdate is a live leaderboard message for one competition
+type leaderboardUpdate struct {
+	competitionID string
+	tenantID      string
+	payload       map[string]interface{}
+}
+
+// SetLeaderboards enables the leaderboard channel. It must be called before
+// Run.
+func (h *WebSocketHub) SetLeaderboards(feed *LeaderboardFeed) {
+	h.leaderboards = feed
+}
+
+// PublishLeaderboard sends a live leaderboard update to the competition's
+// subscribers in its tenant
+func (h *WebSocketHub) PublishLeaderboard(competitionID, tenantID string, payload map[string]interface{}) {
+	h.leaderboard <- leaderboardUpdate{competitionID: competitionID, tenantID: tenantID, payload: payload}
+}
+
+// publishLeaderboard sequences an update on the competition's channel,
+// records it for resume and sends it to subscribers. Runs on the hub
+// goroutine.
+func (h *WebSocketHub) publishLeaderboard(update leaderboardUpdate) {
+	channel := LeaderboardChannel + ":" + update.competitionID
+	seq := h.sequences[channel] + 1
+	update.payload["channel"] = channel
+	update.payload["seq"] = seq
+	text, err := json.Marshal(update.payload)
+	if err != nil {
+		log.Printf("Error marshaling leaderboard update: %v", err)
+		return
+	}
+	message := outboundMessage{text: text}
+	h.sequences[channel] = seq
+	h.channelHistory(channel).push(seq, message)
+
+	for client := range h.clients {
+		if !client.leaderboards[update.competitionID] || client.tenantID != update.tenantID {
+			continue
+		}
+		client.trySend(message)
+	}
+}
+
+// changeLeaderboardSubscription turns a competition's leaderboard updates
+// on or off for a client. Subscribing replies with the current top places
+// and the channel's sequence to resume from; unsubscribing without a
+// competition stops every leaderboard.
+func (h *WebSocketHub) changeLeaderboardSubscription(change subscriptionChange) {
+	client := change.client
+	reply := map[string]interface{}{
+		"type":          "response",
+		"action":        "unsubscribe",
+		"channel":       LeaderboardChannel,
+		"competitionId": change.competitionID,
+		"ok":            true,
+	}
+	if change.id != "" {
+		reply["id"] = change.id
+	}
+
+	if !change.subscribe {
+		if change.competitionID == "" {
+			client.leaderboards = nil
+		} else {
+			delete(client.leaderboards, change.competitionID)
+		}
+		h.sendControl(client, reply)
+		return
+	}
+
+	reply["action"] = "subscribe"
+	var snapshot map[string]interface{}
+	ok := false
+	if h.leaderboards != nil {
+		snapshot, ok = h.leaderboards.snapshot(change.competitionID, client.tenantID)
+	}
+	if !ok {
+		reply["type"] = "error"
+		reply["ok"] = false
+		reply["code"] = "unknown_competition"
+		reply["error"] = "no running competition with that ID"
+		h.sendControl(client, reply)
+		return
+	}
+	if client.leaderboards == nil {
+		client.leaderboards = make(map[string]bool)
+	}
+	client.leaderboards[change.competitionID] = true
+	for key, value := range snapshot {
+		reply[key] = value
+	}
+	reply["seq"] = h.sequences[LeaderboardChannel+":"+change.competitionID]
+	h.sendControl(client, reply)
+}
+
+// follows reports whether the client may see a channel's messages: every
+// channel but the leaderboards of competitions it does not subscribe to
+func (c *WebSocketClient) follows(channel string) bool {
+	competitionID, ok := strings.CutPrefix(channel, LeaderboardChannel+":")
+	return !ok || c.leaderboards[competitionID]
+}
+
+// leaderboardCompetitions returns the competitions a client follows, sorted
+func (c *WebSocketClient) leaderboardCompetitions() []string {
+	ids := make([]string, 0, len(c.leaderboards))
+	for id := range c.leaderboards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
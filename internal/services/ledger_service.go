@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LedgerService records cash movements that are not trades, such as
+// interest. Entry IDs are chosen by the caller so a job that runs twice
+// cannot book the same movement twice.
+type LedgerService struct {
+	ledgerCollection *mongo.Collection
+	orderService     *OrderService
+}
+
+func NewLedgerService(orderService *OrderService) *LedgerService {
+	return &LedgerService{
+		ledgerCollection: config.GetCollection("ledger"),
+		orderService:     orderService,
+	}
+}
+
+// Post records the entry and posts it to the journal, against the ledger
+// account its type belongs to, in one transaction. It reports false without
+// changing anything if an entry with the same ID was already posted.
+func (s *LedgerService) Post(ctx context.Context, entry models.LedgerEntry) (bool, error) {
+	return s.PostIf(ctx, entry, nil)
+}
+
+// PostIf posts the entry like Post once check, run in the same transaction,
+// passes, so what check reads cannot change before the entry lands. A nil
+// check always passes.
+func (s *LedgerService) PostIf(ctx context.Context, entry models.LedgerEntry, check func(ctx context.Context) error) (bool, error) {
+	account, ok := ledgerAccounts[entry.Type]
+	if !ok {
+		return false, fmt.Errorf("unknown ledger entry type %q", entry.Type)
+	}
+	err := s.orderService.runInTransaction(ctx, func(ctx context.Context) error {
+		if check != nil {
+			if err := check(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err := s.ledgerCollection.InsertOne(ctx, entry); err != nil {
+			return err
+		}
+		_, err := s.orderService.journal.post(ctx, models.JournalEntry{
+			ID:          "ledger:" + entry.ID,
+			UserID:      entry.UserID,
+			Type:        entry.Type,
+			Description: entry.Description,
+			Postings: []models.Posting{
+				{Account: JournalCash, Amount: entry.Amount},
+				{Account: account, Amount: -entry.Amount},
+			},
+			CompetitionID: entry.CompetitionID,
+			TenantID:      entry.TenantID,
+			CreatedAt:     entry.CreatedAt,
+		})
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns the user's ledger entries, newest first, optionally of one type
+func (s *LedgerService) List(ctx context.Context, userID, entryType string, limit int64) ([]models.LedgerEntry, error) {
+	filter := bson.M{"user_id": userID}
+	if entryType != "" {
+		filter["type"] = entryType
+	}
+	cursor, err := s.ledgerCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.LedgerEntry{}
+	err = cursor.All(ctx, &entries)
+	return entries, err
+}
+
+// NetDeposits returns what the user deposited into their main account since
+// the time, less what they withdrew, so returns can leave it out
+func (s *LedgerService) NetDeposits(ctx context.Context, userID string, since time.Time) (float64, error) {
+	cursor, err := s.ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":        userID,
+			"type":           bson.M{"$in": bson.A{"deposit", "withdrawal"}},
+			"competition_id": bson.M{"$in": bson.A{nil, ""}},
+			"created_at":     bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var totals []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil || len(totals) == 0 {
+		return 0, err
+	}
+	return totals[0].Total, nil
+}
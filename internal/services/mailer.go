@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"trading-simulator/config"
+)
+
+// Email is one message with a plain text body and an optional HTML
+// alternative
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// NewMailer returns an SMTP mailer when SMTP_HOST is set, otherwise one
+// that only logs, so development setups need no mail server
+func NewMailer() Mailer {
+	host := config.GetEnv("SMTP_HOST", "")
+	if host == "" {
+		log.Println("⚠️ SMTP_HOST not set, emails are logged instead of sent")
+		return LogMailer{}
+	}
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(host, config.GetEnv("SMTP_PORT", "587")),
+		from: config.GetEnv("MAIL_FROM", "no-reply@trading-simulator.local"),
+	}
+	if username := config.GetEnv("SMTP_USERNAME", ""); username != "" {
+		mailer.auth = smtp.PlainAuth("", username, config.GetEnv("SMTP_PASSWORD", ""), host)
+	}
+	return mailer
+}
+
+// SMTPMailer sends multipart emails through an SMTP relay
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	fmt.Fprintf(&body, "From: %s\r\n", m.from)
+	fmt.Fprintf(&body, "To: %s\r\n", email.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&body, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	alternatives := []struct{ contentType, content string }{{"text/plain", email.Text}, {"text/html", email.HTML}}
+	for _, alt := range alternatives {
+		if alt.content == "" {
+			continue
+		}
+		part, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {alt.contentType + "; charset=utf-8"}})
+		if err != nil {
+			return err
+		}
+		part.Write([]byte(alt.content))
+	}
+	if err := parts.Close(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, body.Bytes())
+}
+
+// LogMailer logs emails instead of sending them
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, email Email) error {
+	log.Printf("📧 Email to %s: %s\n%s", email.To, email.Subject, email.Text)
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Other instances pick up a toggle within this interval
+const maintenanceCacheTTL = 5 * time.Second
+
+// MaintenanceService holds the read-only trading freeze. The state is kept
+// in Mongo so every instance honours it.
+type MaintenanceService struct {
+	settingsCollection *mongo.Collection
+	hub                *WebSocketHub
+	events             *EventBus
+
+	mu       sync.Mutex
+	state    models.MaintenanceState
+	loadedAt time.Time
+}
+
+func NewMaintenanceService(hub *WebSocketHub, events *EventBus) *MaintenanceService {
+	return &MaintenanceService{
+		settingsCollection: config.GetCollection("settings"),
+		hub:                hub,
+		events:             events,
+	}
+}
+
+// GetState returns the current maintenance state
+func (s *MaintenanceService) GetState() models.MaintenanceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.loadedAt) < maintenanceCacheTTL {
+		return s.state
+	}
+
+	var stored struct {
+		State models.MaintenanceState `bson:"state"`
+	}
+	err := s.settingsCollection.FindOne(context.Background(), bson.M{"_id": "maintenance"}).Decode(&stored)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error loading maintenance state: %v", err)
+		return s.state
+	}
+	s.state = stored.State
+	s.loadedAt = time.Now()
+	return s.state
+}
+
+// Active reports whether trading is frozen
+func (s *MaintenanceService) Active() bool {
+	return s.GetState().Enabled
+}
+
+// SetState turns maintenance on or off and notifies WebSocket clients and
+// integrations
+func (s *MaintenanceService) SetState(ctx context.Context, state models.MaintenanceState) (models.MaintenanceState, error) {
+	current := s.GetState()
+	if state.Enabled {
+		state.StartedAt = current.StartedAt
+		if !current.Enabled {
+			state.StartedAt = time.Now().UTC()
+		}
+	} else {
+		state.Message = ""
+		state.EndsAt = time.Time{}
+	}
+
+	_, err := s.settingsCollection.UpdateOne(ctx,
+		bson.M{"_id": "maintenance"},
+		bson.M{"$set": bson.M{"state": state}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return current, err
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	code := "notification.maintenance_ended"
+	if state.Enabled {
+		code = "notification.maintenance_started"
+	}
+	notice := map[string]interface{}{
+		"type":        "maintenance",
+		"code":        code,
+		"message":     i18n.Translate(i18n.English, code),
+		"maintenance": state,
+	}
+	s.hub.Broadcast(notice)
+	s.events.Publish(EventMaintenanceChanged, "", state)
+	if state.Enabled {
+		s.hub.SetGreeting(notice)
+		log.Printf("🚧 Maintenance mode enabled by %s: %s", state.UpdatedBy, state.Message)
+	} else {
+		s.hub.SetGreeting(nil)
+		log.Printf("✅ Maintenance mode disabled by %s", state.UpdatedBy)
+	}
+	return state, nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Market hours settings
+const (
+	MarketHoursAlways  = "always"  // Trading around the clock, as the simulator always has
+	MarketHoursRegular = "regular" // Exchange hours on weekdays; crypto still trades around the clock
+)
+
+// Trading sessions
+const (
+	SessionRegular  = "regular"
+	SessionExtended = "extended" // Pre-market or after-hours
+	SessionClosed   = "closed"
+)
+
+// What happens to an order placed while its symbol cannot trade
+const (
+	MarketClosedReject = "reject"
+	MarketClosedQueue  = "queue" // Scheduled to fill at the next open
+)
+
+// Session boundaries in minutes after midnight in the market's timezone
+const (
+	extendedOpenMinute  = 4 * 60    // 04:00
+	regularOpenMinute   = 9*60 + 30 // 09:30
+	regularCloseMinute  = 16 * 60   // 16:00
+	extendedCloseMinute = 20 * 60   // 20:00
+)
+
+// Other instances pick up a halt within this interval
+const haltCacheTTL = 5 * time.Second
+
+// MarketClosedError rejects an order whose symbol cannot trade right now.
+// NextOpen is when it can, or zero for a halt with no resume time.
+type MarketClosedError struct {
+	Err      *i18n.Error
+	NextOpen time.Time
+}
+
+func (e *MarketClosedError) Error() string { return e.Err.Error() }
+func (e *MarketClosedError) Unwrap() error { return e.Err }
+
+// MarketClock decides when symbols can trade. MARKET_HOURS (default always)
+// keeps the simulator open around the clock or follows regular exchange
+// hours, 9:30 to 16:00 on weekdays in MARKET_TIMEZONE (default
+// America/New_York), with extended hours from 4:00 to 20:00 for orders that
+// ask for them. Admins can halt single symbols. MARKET_CLOSED_POLICY
+// (default reject) decides whether orders placed while closed are rejected
+// or queued for the next open.
+type MarketClock struct {
+	haltCollection *mongo.Collection
+	symbols        *SymbolService
+	hours          string
+	location       *time.Location
+	policy         string
+
+	mu       sync.Mutex
+	halts    map[string]models.TradingHalt
+	loadedAt time.Time
+}
+
+func NewMarketClock(symbols *SymbolService) *MarketClock {
+	hours := config.GetEnv("MARKET_HOURS", MarketHoursAlways)
+	if hours != MarketHoursAlways && hours != MarketHoursRegular {
+		log.Printf("⚠️ Unknown MARKET_HOURS %q, using %s", hours, MarketHoursAlways)
+		hours = MarketHoursAlways
+	}
+	location, err := time.LoadLocation(config.GetEnv("MARKET_TIMEZONE", "America/New_York"))
+	if err != nil {
+		log.Printf("⚠️ Unknown MARKET_TIMEZONE, using UTC: %v", err)
+		location = time.UTC
+	}
+	policy := config.GetEnv("MARKET_CLOSED_POLICY", MarketClosedReject)
+	if policy != MarketClosedReject && policy != MarketClosedQueue {
+		log.Printf("⚠️ Unknown MARKET_CLOSED_POLICY %q, using %s", policy, MarketClosedReject)
+		policy = MarketClosedReject
+	}
+	return &MarketClock{
+		haltCollection: config.GetCollection("trading_halts"),
+		symbols:        symbols,
+		hours:          hours,
+		location:       location,
+		policy:         policy,
+		halts:          make(map[string]models.TradingHalt),
+	}
+}
+
+// Status returns the session at now, when it next changes and the halts in
+// effect
+func (c *MarketClock) Status(now time.Time) models.MarketClockStatus {
+	status := models.MarketClockStatus{
+		Hours:    c.hours,
+		Session:  c.session(now),
+		Timezone: c.location.String(),
+		Policy:   c.policy,
+		Halts:    []models.TradingHalt{},
+		Time:     now.UTC(),
+	}
+	if c.hours == MarketHoursRegular {
+		if status.Session == SessionRegular {
+			local := now.In(c.location)
+			status.NextClose = atMinute(local, regularCloseMinute).UTC()
+		} else {
+			status.NextOpen = c.nextOpen(now, false)
+		}
+	}
+	for _, halt := range c.currentHalts(now) {
+		status.Halts = append(status.Halts, halt)
+	}
+	sort.Slice(status.Halts, func(i, j int) bool { return status.Halts[i].Symbol < status.Halts[j].Symbol })
+	return status
+}
+
+// CheckOrder returns a *MarketClosedError if the order's symbol cannot trade
+// now: it is halted, or the market is outside its hours. Extended-hours
+// orders also trade pre-market and after-hours.
+func (c *MarketClock) CheckOrder(order *models.Order) error {
+	if closed := c.check(order.Symbol, order.ExtendedHours, time.Now()); closed != nil {
+		return closed
+	}
+	return nil
+}
+
+// CanTrade reports whether a resting order may fill now
+func (c *MarketClock) CanTrade(order *models.Order) bool {
+	return c.check(order.Symbol, order.ExtendedHours, time.Now()) == nil
+}
+
+// QueueUntil returns when an order that cannot trade now should be
+// scheduled to fill, if MARKET_CLOSED_POLICY is queue. Halts with no resume
+// time are never queued.
+func (c *MarketClock) QueueUntil(order *models.Order) (time.Time, bool) {
+	if c.policy != MarketClosedQueue {
+		return time.Time{}, false
+	}
+	closed := c.check(order.Symbol, order.ExtendedHours, time.Now())
+	if closed == nil || closed.NextOpen.IsZero() {
+		return time.Time{}, false
+	}
+	return closed.NextOpen, true
+}
+
+func (c *MarketClock) check(symbol string, extended bool, now time.Time) *MarketClosedError {
+	symbol = strings.ToUpper(symbol)
+	if halt, ok := c.currentHalts(now)[symbol]; ok {
+		closed := &MarketClosedError{Err: i18n.NewError("order.symbol_halted", symbol)}
+		if !halt.ResumeAt.IsZero() {
+			closed.NextOpen = halt.ResumeAt
+			if !c.tradesAt(symbol, extended, halt.ResumeAt) {
+				closed.NextOpen = c.nextOpen(halt.ResumeAt, extended)
+			}
+		}
+		return closed
+	}
+	if c.tradesAt(symbol, extended, now) {
+		return nil
+	}
+	next := c.nextOpen(now, extended)
+	return &MarketClosedError{
+		Err:      i18n.NewError("order.market_closed", symbol, next.In(c.location).Format(time.RFC3339)),
+		NextOpen: next,
+	}
+}
+
+// tradesAt reports whether the symbol's market is open at t
+func (c *MarketClock) tradesAt(symbol string, extended bool, t time.Time) bool {
+	if c.hours == MarketHoursAlways || c.symbols.GetSymbol(symbol).AssetClass == "crypto" {
+		return true
+	}
+	switch c.session(t) {
+	case SessionRegular:
+		return true
+	case SessionExtended:
+		return extended
+	}
+	return false
+}
+
+// session returns the exchange session at t
+func (c *MarketClock) session(t time.Time) string {
+	if c.hours == MarketHoursAlways {
+		return SessionRegular
+	}
+	local := t.In(c.location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return SessionClosed
+	}
+	minute := local.Hour()*60 + local.Minute()
+	switch {
+	case minute >= regularOpenMinute && minute < regularCloseMinute:
+		return SessionRegular
+	case minute >= extendedOpenMinute && minute < extendedCloseMinute:
+		return SessionExtended
+	}
+	return SessionClosed
+}
+
+// nextOpen returns the start of the next session after t: the regular open,
+// or the pre-market open for extended-hours orders
+func (c *MarketClock) nextOpen(t time.Time, extended bool) time.Time {
+	openMinute := regularOpenMinute
+	if extended {
+		openMinute = extendedOpenMinute
+	}
+	local := t.In(c.location)
+	for days := 0; days <= 7; days++ {
+		open := atMinute(local.AddDate(0, 0, days), openMinute)
+		if open.Weekday() != time.Saturday && open.Weekday() != time.Sunday && open.After(t) {
+			return open.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// atMinute returns the given minute of t's day in t's location
+func atMinute(t time.Time, minute int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), minute/60, minute%60, 0, 0, t.Location())
+}
+
+// Halt stops trading in a symbol until it is resumed or ResumeAt passes
+func (c *MarketClock) Halt(ctx context.Context, halt models.TradingHalt) (*models.TradingHalt, error) {
+	halt.Symbol = strings.ToUpper(halt.Symbol)
+	if !c.symbols.HasSymbol(halt.Symbol) {
+		return nil, ErrUnknownSymbol
+	}
+	halt.HaltedAt = time.Now().UTC()
+	if !halt.ResumeAt.IsZero() {
+		if !halt.ResumeAt.After(halt.HaltedAt) {
+			return nil, fmt.Errorf("resume time must be in the future")
+		}
+		halt.ResumeAt = halt.ResumeAt.UTC()
+	}
+
+	_, err := c.haltCollection.ReplaceOne(ctx, bson.M{"_id": halt.Symbol}, halt, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.halts[halt.Symbol] = halt
+	c.mu.Unlock()
+
+	log.Printf("⛔ Trading in %s halted by %s: %s", halt.Symbol, halt.HaltedBy, halt.Reason)
+	return &halt, nil
+}
+
+// Resume lifts a symbol's halt. Lifting a halt that is not in effect is not
+// an error.
+func (c *MarketClock) Resume(ctx context.Context, symbol, by string) error {
+	symbol = strings.ToUpper(symbol)
+	if !c.symbols.HasSymbol(symbol) {
+		return ErrUnknownSymbol
+	}
+	if _, err := c.haltCollection.DeleteOne(ctx, bson.M{"_id": symbol}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	delete(c.halts, symbol)
+	c.mu.Unlock()
+
+	log.Printf("✅ Trading in %s resumed by %s", symbol, by)
+	return nil
+}
+
+// currentHalts returns the halts in effect at now, reloading them once the
+// cache expires. If Mongo is unreachable the last known halts stay in
+// effect.
+func (c *MarketClock) currentHalts(now time.Time) map[string]models.TradingHalt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.loadedAt) >= haltCacheTTL {
+		var stored []models.TradingHalt
+		cursor, err := c.haltCollection.Find(context.Background(), bson.M{})
+		if err == nil {
+			err = cursor.All(context.Background(), &stored)
+		}
+		if err != nil {
+			log.Printf("Error loading trading halts: %v", err)
+		} else {
+			c.halts = make(map[string]models.TradingHalt, len(stored))
+			for _, halt := range stored {
+				c.halts[halt.Symbol] = halt
+			}
+		}
+		c.loadedAt = time.Now()
+	}
+
+	halts := make(map[string]models.TradingHalt, len(c.halts))
+	for symbol, halt := range c.halts {
+		if halt.ResumeAt.IsZero() || halt.ResumeAt.After(now) {
+			halts[symbol] = halt
+		}
+	}
+	return halts
+}
@@ -1,17 +1,24 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"trading-simulator/config"
+	"trading-simulator/internal/chaos"
 	"trading-simulator/internal/models"
 )
 
@@ -32,9 +39,34 @@ type MarketDataService struct {
 	apiKey         string
 	useMockData    bool
 	lastAPISuccess time.Time
-	mockPrices     map[string]float64
+	volatility     atomic.Uint64 // math.Float64bits of the multiplier applied to mock moves
+	spread         float64       // Simulated bid-ask spread as a fraction of the last price
+	dataMode       atomic.Value  // Deployment data mode, kept current by the simulator loop
+
+	// Real quotes per symbol, oldest first, held back by quoteDelay before
+	// users in delayed mode see them
+	delayedMu      sync.RWMutex
+	delayedQuotes  map[string][]models.DelayedQuote
+	quoteDelay     time.Duration
+	delayedRefresh time.Duration // How often DelayedQuoteService fetches new quotes
+
+	// Last simulated price per symbol, moved by the simulator's ticks.
+	// Valuations and fills read it, so reading it never moves the market.
+	pricesMu   sync.RWMutex
+	mockPrices map[string]float64
+
+	customMu sync.RWMutex
+	custom   map[string]models.CustomSymbol // Fictional tickers priced from their own parameters
+	etfs     map[string]models.ETF          // Synthetic funds priced from their constituents
+
+	historyMu    sync.RWMutex
+	priceHistory map[string][]float64 // Recent simulator ticks per symbol, oldest first
+	lastTicks    map[string]models.Stock
 }
 
+// Number of ticks kept per symbol for risk calculations
+const priceHistorySize = 500
+
 func NewMarketDataService() *MarketDataService {
 	apiKey := os.Getenv("ALPHA_VANTAGE_API_KEY")
 	if apiKey == "" {
@@ -43,31 +75,110 @@ func NewMarketDataService() *MarketDataService {
 
 	// Initialize mock prices with realistic values
 	mockPrices := map[string]float64{
-		"AAPL":  175.50,
-		"GOOGL": 138.25,
-		"MSFT":  330.80,
-		"TSLA":  210.75,
-		"AMZN":  178.90,
+		"AAPL":    175.50,
+		"GOOGL":   138.25,
+		"MSFT":    330.80,
+		"TSLA":    210.75,
+		"AMZN":    178.90,
+		"BTC-USD": 67250.00,
+		"ETH-USD": 3450.00,
 	}
 
-	return &MarketDataService{
+	m := &MarketDataService{
 		apiKey:         apiKey,
 		useMockData:    false, // Start with real API
 		lastAPISuccess: time.Now(),
 		mockPrices:     mockPrices,
+		priceHistory:   make(map[string][]float64),
+		lastTicks:      make(map[string]models.Stock),
+		custom:         make(map[string]models.CustomSymbol),
+		etfs:           make(map[string]models.ETF),
+		spread:         config.GetEnvFloat("QUOTE_SPREAD_BPS", 0) / 10000,
+		delayedQuotes:  make(map[string][]models.DelayedQuote),
+		quoteDelay:     time.Duration(config.GetEnvInt("QUOTE_DELAY_MINUTES", 15)) * time.Minute,
+		delayedRefresh: time.Duration(max(config.GetEnvInt("DELAYED_QUOTE_REFRESH_MINUTES", 15), 1)) * time.Minute,
+	}
+	m.SetVolatility(1)
+	m.SetDataMode(DataModeHybrid)
+	return m
+}
+
+// SetDataMode sets the deployment's data mode, used by reads that are not
+// on behalf of a user
+func (m *MarketDataService) SetDataMode(mode string) {
+	m.dataMode.Store(mode)
+}
+
+// DataMode returns the deployment's data mode
+func (m *MarketDataService) DataMode() string {
+	return m.dataMode.Load().(string)
+}
+
+// SetVolatility scales the size of simulated price moves; 1 is the normal ±1.5%
+func (m *MarketDataService) SetVolatility(multiplier float64) {
+	m.volatility.Store(math.Float64bits(multiplier))
+}
+
+// GetStockPrice returns a symbol's current price in the deployment's data
+// mode, without moving it
+func (m *MarketDataService) GetStockPrice(ctx context.Context, symbol string) (*models.Stock, error) {
+	return m.GetStockPriceIn(ctx, symbol, m.DataMode())
+}
+
+// GetStockPriceIn returns a symbol's current price in a data mode, without
+// moving it. Delayed mode serves delayed real quotes, falling back to the
+// simulated price for symbols without one; custom symbols and ETFs only
+// exist in the simulator, so every mode serves their simulated price.
+func (m *MarketDataService) GetStockPriceIn(ctx context.Context, symbol, mode string) (*models.Stock, error) {
+	if err := chaos.Inject(ctx, chaos.TargetMarketData); err != nil {
+		return nil, err
+	}
+	if mode == DataModeDelayed {
+		if stock, ok := m.GetDelayedQuote(symbol); ok {
+			stock.DataMode = mode
+			return stock, nil
+		}
+	}
+
+	stock, err := m.simulatedStockPrice(ctx, symbol, mode == DataModeHybrid)
+	if err != nil {
+		return nil, err
 	}
+	stock.DataMode = mode
+	return stock, nil
 }
 
-func (m *MarketDataService) GetStockPrice(symbol string) (*models.Stock, error) {
+// simulatedStockPrice returns the simulator's price of a symbol. Symbols the
+// simulator ticks are served as of their latest tick, so REST reads and the
+// WebSocket feed agree. With useProvider, others come from Alpha Vantage,
+// falling back to the last simulated price.
+func (m *MarketDataService) simulatedStockPrice(ctx context.Context, symbol string, useProvider bool) (*models.Stock, error) {
+	if tick, ok := m.GetLatestTick(symbol); ok {
+		return &tick, nil
+	}
+
+	// Custom symbols and ETFs only exist in the simulator
+	if custom, ok := m.customSymbol(symbol); ok {
+		return &models.Stock{Symbol: custom.Symbol, Name: custom.Name, Price: custom.BasePrice, Timestamp: time.Now().UTC(), Source: PriceSourceSimulated}, nil
+	}
+	if etf, ok := m.ETF(symbol); ok {
+		return &models.Stock{Symbol: etf.Symbol, Name: etf.Name, Price: etf.BasePrice, Timestamp: time.Now().UTC(), Source: PriceSourceSimulated}, nil
+	}
+
 	// Try real API first (if we haven't been using mock data for too long)
-	if !m.useMockData || time.Since(m.lastAPISuccess) > 30*time.Minute {
-		stock, err := m.getRealStockPrice(symbol)
+	if useProvider && (!m.useMockData || time.Since(m.lastAPISuccess) > 30*time.Minute) {
+		stock, err := m.getRealStockPrice(ctx, symbol)
 		if err == nil {
 			m.lastAPISuccess = time.Now()
 			m.useMockData = false // Real API worked, switch back
 			return stock, nil
 		}
 
+		// A cancelled request says nothing about the provider
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		// If real API fails, switch to mock data
 		log.Printf("⚠️ Real API failed for %s, switching to mock data: %v", symbol, err)
 		m.useMockData = true
@@ -77,12 +188,26 @@ func (m *MarketDataService) GetStockPrice(symbol string) (*models.Stock, error)
 	return m.getMockStockPrice(symbol)
 }
 
-func (m *MarketDataService) getRealStockPrice(symbol string) (*models.Stock, error) {
+// hasRealQuotes reports whether Alpha Vantage can quote the symbol, which
+// it cannot for custom symbols and ETFs
+func (m *MarketDataService) hasRealQuotes(symbol string) bool {
+	if _, ok := m.customSymbol(symbol); ok {
+		return false
+	}
+	_, ok := m.ETF(symbol)
+	return !ok
+}
+
+func (m *MarketDataService) getRealStockPrice(ctx context.Context, symbol string) (*models.Stock, error) {
 	url := fmt.Sprintf("https://www.alphavantage.co/query?function=GLOBAL_QUOTE&symbol=%s&apikey=%s", symbol, m.apiKey)
 
 	// Create HTTP client with timeout
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %v", err)
 	}
@@ -135,41 +260,29 @@ func (m *MarketDataService) getRealStockPrice(symbol string) (*models.Stock, err
 		Change:        change,
 		ChangePercent: changePercent,
 		Volume:        0, // Alpha Vantage doesn't provide volume in this endpoint
-		Timestamp:     time.Now(),
+		Timestamp:     time.Now().UTC(),
+		Source:        PriceSourceDelayed,
 	}
 
 	log.Printf("✅ Real API: %s - $%.2f (%.2f%%)", stock.Symbol, stock.Price, stock.ChangePercent)
 	return stock, nil
 }
 
+// getMockStockPrice returns the last simulated price of a symbol that has
+// not ticked yet, without moving it
 func (m *MarketDataService) getMockStockPrice(symbol string) (*models.Stock, error) {
-	// Get base price or use default
-	basePrice, exists := m.mockPrices[symbol]
-	if !exists {
-		basePrice = 100.0 // Default base price
-		m.mockPrices[symbol] = basePrice
-	}
-
-	// Generate realistic price movement (±2%)
-	changePercent := (rand.Float64()*4 - 2) // -2% to +2%
-	change := basePrice * changePercent / 100
-	newPrice := basePrice + change
-
-	// Update mock price for next call
-	m.mockPrices[symbol] = newPrice
-
-	stock := &models.Stock{
-		Symbol:        strings.ToUpper(symbol),
-		Name:          getStockName(symbol),
-		Price:         newPrice,
-		Change:        change,
-		ChangePercent: changePercent,
-		Volume:        rand.Int63n(10000000) + 1000000, // Random volume
-		Timestamp:     time.Now(),
+	price, ok := m.GetLastPrice(symbol)
+	if !ok {
+		return nil, fmt.Errorf("no simulated price for %s", strings.ToUpper(symbol))
 	}
-
-	log.Printf("🤖 Mock Data: %s - $%.2f (%+.2f%%)", stock.Symbol, stock.Price, stock.ChangePercent)
-	return stock, nil
+	return &models.Stock{
+		Symbol:    strings.ToUpper(symbol),
+		Name:      getStockName(symbol),
+		Price:     price,
+		Volume:    rand.Int63n(10000000) + 1000000, // Random volume
+		Timestamp: time.Now().UTC(),
+		Source:    PriceSourceSimulated,
+	}, nil
 }
 
 func parsePrice(priceStr string) (float64, error) {
@@ -207,14 +320,16 @@ func parseChangePercent(percentStr string) (float64, error) {
 
 func getStockName(symbol string) string {
 	names := map[string]string{
-		"AAPL":  "Apple Inc.",
-		"GOOGL": "Alphabet Inc.",
-		"MSFT":  "Microsoft Corporation",
-		"TSLA":  "Tesla Inc.",
-		"AMZN":  "Amazon.com Inc.",
-		"NVDA":  "NVIDIA Corporation",
-		"META":  "Meta Platforms Inc.",
-		"JPM":   "JPMorgan Chase & Co.",
+		"AAPL":    "Apple Inc.",
+		"GOOGL":   "Alphabet Inc.",
+		"MSFT":    "Microsoft Corporation",
+		"TSLA":    "Tesla Inc.",
+		"AMZN":    "Amazon.com Inc.",
+		"NVDA":    "NVIDIA Corporation",
+		"META":    "Meta Platforms Inc.",
+		"JPM":     "JPMorgan Chase & Co.",
+		"BTC-USD": "Bitcoin",
+		"ETH-USD": "Ethereum",
 	}
 
 	if name, exists := names[strings.ToUpper(symbol)]; exists {
@@ -225,11 +340,11 @@ func getStockName(symbol string) string {
 }
 
 // GetMultipleStockPrices fetches prices for multiple symbols
-func (m *MarketDataService) GetMultipleStockPrices(symbols []string) ([]models.Stock, error) {
+func (m *MarketDataService) GetMultipleStockPrices(ctx context.Context, symbols []string) ([]models.Stock, error) {
 	var stocks []models.Stock
 
 	for _, symbol := range symbols {
-		stock, err := m.GetStockPrice(symbol)
+		stock, err := m.GetStockPrice(ctx, symbol)
 		if err != nil {
 			log.Printf("Error fetching %s: %v", symbol, err)
 			continue // Skip failed requests but continue with others
@@ -243,30 +358,36 @@ func (m *MarketDataService) GetMultipleStockPrices(symbols []string) ([]models.S
 	return stocks, nil
 }
 
-// GetMockStockPrice generates realistic mock stock data without API calls
-func (m *MarketDataService) GetMockStockPrice(symbol string) (*models.Stock, error) {
+// AdvanceTick moves a symbol's simulated price one step and returns the new
+// tick. Only the simulator calls it; reads go through GetStockPrice or
+// GetLastPrice, which never move prices.
+func (m *MarketDataService) AdvanceTick(symbol string) (*models.Stock, error) {
+	m.pricesMu.Lock()
 	// Get base price from our mock prices
 	basePrice, exists := m.mockPrices[symbol]
 	if !exists {
 		// Set realistic base prices for each symbol
 		realisticPrices := map[string]float64{
-			"AAPL":  269.00,
-			"GOOGL": 267.47,
-			"MSFT":  542.07,
-			"TSLA":  460.55,
-			"AMZN":  229.25,
+			"AAPL":    269.00,
+			"GOOGL":   267.47,
+			"MSFT":    542.07,
+			"TSLA":    460.55,
+			"AMZN":    229.25,
+			"BTC-USD": 67250.00,
+			"ETH-USD": 3450.00,
 		}
 		basePrice = realisticPrices[symbol]
 		m.mockPrices[symbol] = basePrice
 	}
 
-	// Generate realistic price movement (±1.5%)
-	changePercent := (rand.Float64()*3 - 1.5) // -1.5% to +1.5%
+	// Generate realistic price movement (±1.5%, scaled by the volatility setting)
+	changePercent := (rand.Float64()*3 - 1.5) * math.Float64frombits(m.volatility.Load())
 	change := basePrice * changePercent / 100
 	newPrice := basePrice + change
 
 	// Update mock price for next call (with some momentum)
 	m.mockPrices[symbol] = newPrice
+	m.pricesMu.Unlock()
 
 	// Generate realistic volume
 	volume := rand.Int63n(5000000) + 1000000
@@ -278,9 +399,295 @@ func (m *MarketDataService) GetMockStockPrice(symbol string) (*models.Stock, err
 		Change:        change,
 		ChangePercent: changePercent,
 		Volume:        volume,
-		Timestamp:     time.Now(),
+		Timestamp:     time.Now().UTC(),
+		Source:        PriceSourceSimulated,
 	}
 
 	log.Printf("🤖 Mock Data: %s - $%.2f (%+.2f%%)", stock.Symbol, stock.Price, stock.ChangePercent)
 	return stock, nil
-}
\ No newline at end of file
+}
+
+// AddCustomSymbol starts simulating a custom symbol
+func (m *MarketDataService) AddCustomSymbol(custom models.CustomSymbol) {
+	m.customMu.Lock()
+	defer m.customMu.Unlock()
+	m.custom[custom.Symbol] = custom
+}
+
+// RemoveCustomSymbol stops simulating a custom symbol
+func (m *MarketDataService) RemoveCustomSymbol(symbol string) {
+	m.customMu.Lock()
+	defer m.customMu.Unlock()
+	delete(m.custom, strings.ToUpper(symbol))
+}
+
+// CustomSymbols returns every custom symbol being simulated
+func (m *MarketDataService) CustomSymbols() []models.CustomSymbol {
+	m.customMu.RLock()
+	defer m.customMu.RUnlock()
+	list := make([]models.CustomSymbol, 0, len(m.custom))
+	for _, custom := range m.custom {
+		list = append(list, custom)
+	}
+	return list
+}
+
+func (m *MarketDataService) customSymbol(symbol string) (models.CustomSymbol, bool) {
+	m.customMu.RLock()
+	defer m.customMu.RUnlock()
+	custom, ok := m.custom[strings.ToUpper(symbol)]
+	return custom, ok
+}
+
+// AdvanceCustomTick generates the next tick of a custom symbol: its drift
+// plus a random move of up to its volatility, scaled by the volatility
+// setting. Prices never fall below one cent. Only the simulator calls it.
+func (m *MarketDataService) AdvanceCustomTick(custom models.CustomSymbol) *models.Stock {
+	m.pricesMu.Lock()
+	defer m.pricesMu.Unlock()
+	basePrice, exists := m.mockPrices[custom.Symbol]
+	if !exists {
+		basePrice = custom.BasePrice
+	}
+
+	changePercent := custom.Drift + (rand.Float64()*2-1)*custom.Volatility*math.Float64frombits(m.volatility.Load())
+	newPrice := math.Max(basePrice*(1+changePercent/100), 0.01)
+	m.mockPrices[custom.Symbol] = newPrice
+
+	return &models.Stock{
+		Symbol:        custom.Symbol,
+		Name:          custom.Name,
+		Price:         newPrice,
+		Change:        newPrice - basePrice,
+		ChangePercent: (newPrice - basePrice) / basePrice * 100,
+		Volume:        rand.Int63n(1000000) + 100000,
+		Timestamp:     time.Now().UTC(),
+		Source:        PriceSourceSimulated,
+	}
+}
+
+// AddETF starts pricing an ETF, or replaces its holdings
+func (m *MarketDataService) AddETF(etf models.ETF) {
+	m.customMu.Lock()
+	defer m.customMu.Unlock()
+	m.etfs[etf.Symbol] = etf
+}
+
+// ETFs returns every ETF being priced
+func (m *MarketDataService) ETFs() []models.ETF {
+	m.customMu.RLock()
+	defer m.customMu.RUnlock()
+	list := make([]models.ETF, 0, len(m.etfs))
+	for _, etf := range m.etfs {
+		list = append(list, etf)
+	}
+	return list
+}
+
+// ETF returns the ETF with the symbol, if there is one
+func (m *MarketDataService) ETF(symbol string) (models.ETF, bool) {
+	m.customMu.RLock()
+	defer m.customMu.RUnlock()
+	etf, ok := m.etfs[strings.ToUpper(symbol)]
+	return etf, ok
+}
+
+// AdvanceETFTick prices an ETF from its constituents' latest prices. It reports
+// false until every constituent has a price.
+func (m *MarketDataService) AdvanceETFTick(etf models.ETF) (*models.Stock, bool) {
+	price := 0.0
+	for _, holding := range etf.Holdings {
+		last, ok := m.GetLastPrice(holding.Symbol)
+		if !ok {
+			return nil, false
+		}
+		price += holding.Shares * last
+	}
+	m.pricesMu.Lock()
+	previous, exists := m.mockPrices[etf.Symbol]
+	if !exists {
+		previous = etf.BasePrice
+	}
+	m.mockPrices[etf.Symbol] = price
+	m.pricesMu.Unlock()
+
+	return &models.Stock{
+		Symbol:        etf.Symbol,
+		Name:          etf.Name,
+		Price:         price,
+		Change:        price - previous,
+		ChangePercent: (price - previous) / previous * 100,
+		Volume:        rand.Int63n(2000000) + 500000,
+		Timestamp:     time.Now().UTC(),
+		Source:        PriceSourceSimulated,
+	}, true
+}
+
+// GetLastPrice returns the latest simulated price without generating a new tick
+func (m *MarketDataService) GetLastPrice(symbol string) (float64, bool) {
+	m.pricesMu.RLock()
+	defer m.pricesMu.RUnlock()
+	price, exists := m.mockPrices[strings.ToUpper(symbol)]
+	return price, exists
+}
+
+// GetQuote returns the simulated best bid and ask, centred on the last
+// price QUOTE_SPREAD_BPS apart
+func (m *MarketDataService) GetQuote(symbol string) (bid, ask float64, ok bool) {
+	price, ok := m.GetLastPrice(symbol)
+	if !ok || price <= 0 {
+		return 0, 0, false
+	}
+	half := price * m.spread / 2
+	return price - half, price + half, true
+}
+
+// GetQuoteIn returns the best bid and ask in a data mode and the source they
+// come from. Delayed mode quotes around the delayed real price, falling back
+// to the simulated quote for symbols without one.
+func (m *MarketDataService) GetQuoteIn(symbol, mode string) (bid, ask float64, source string, ok bool) {
+	if mode == DataModeDelayed {
+		if stock, found := m.GetDelayedQuote(symbol); found {
+			half := stock.Price * m.spread / 2
+			return stock.Price - half, stock.Price + half, PriceSourceDelayed, true
+		}
+	}
+	bid, ask, ok = m.GetQuote(symbol)
+	return bid, ask, PriceSourceSimulated, ok
+}
+
+// GetMarkPrice returns the price positions are valued at in a data mode:
+// the delayed quote in delayed mode, and the simulated price otherwise or
+// for symbols without one
+func (m *MarketDataService) GetMarkPrice(symbol, mode string) (float64, bool) {
+	if mode == DataModeDelayed {
+		if stock, ok := m.GetDelayedQuote(symbol); ok {
+			return stock.Price, true
+		}
+	}
+	return m.GetLastPrice(symbol)
+}
+
+// AddDelayedQuote stores a fetched real quote. It is served once it is
+// QUOTE_DELAY_MINUTES (default 15) old; older ones it replaces are dropped.
+func (m *MarketDataService) AddDelayedQuote(quote models.DelayedQuote) {
+	if quote.Price <= 0 || !m.hasRealQuotes(quote.Symbol) {
+		return
+	}
+	quote.Symbol = strings.ToUpper(quote.Symbol)
+	m.delayedMu.Lock()
+	defer m.delayedMu.Unlock()
+
+	quotes := append(m.delayedQuotes[quote.Symbol], quote)
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].FetchedAt.Before(quotes[j].FetchedAt) })
+	if visible := m.visibleQuote(quotes); visible > 0 {
+		quotes = quotes[visible:]
+	}
+	m.delayedQuotes[quote.Symbol] = quotes
+}
+
+// visibleQuote returns the index of the newest quote old enough to serve,
+// or -1 if none is
+func (m *MarketDataService) visibleQuote(quotes []models.DelayedQuote) int {
+	cutoff := time.Now().Add(-m.quoteDelay)
+	for i := len(quotes) - 1; i >= 0; i-- {
+		if !quotes[i].FetchedAt.After(cutoff) {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetDelayedQuote returns a symbol's delayed real quote with how old it is.
+// It reports false until a quote for the symbol is QUOTE_DELAY_MINUTES old.
+func (m *MarketDataService) GetDelayedQuote(symbol string) (*models.Stock, bool) {
+	symbol = strings.ToUpper(symbol)
+	m.delayedMu.RLock()
+	quotes := m.delayedQuotes[symbol]
+	visible := m.visibleQuote(quotes)
+	if visible < 0 {
+		m.delayedMu.RUnlock()
+		return nil, false
+	}
+	quote := quotes[visible]
+	m.delayedMu.RUnlock()
+
+	age := time.Since(quote.FetchedAt)
+	return &models.Stock{
+		Symbol:        quote.Symbol,
+		Name:          getStockName(quote.Symbol),
+		Price:         quote.Price,
+		Change:        quote.Change,
+		ChangePercent: quote.ChangePercent,
+		Timestamp:     quote.FetchedAt,
+		Source:        PriceSourceDelayed,
+		Delay: &models.QuoteDelay{
+			Minutes:    int(m.quoteDelay / time.Minute),
+			AsOf:       quote.FetchedAt,
+			AgeSeconds: int64(age / time.Second),
+			// Allow one missed refresh before calling the quote stale
+			Stale: age > m.quoteDelay+2*m.delayedRefresh,
+		},
+	}, true
+}
+
+// DelayedQuoteWindow is how far back quotes are needed to serve delayed
+// quotes right away, such as after a restart
+func (m *MarketDataService) DelayedQuoteWindow() time.Duration {
+	return m.quoteDelay + 2*m.delayedRefresh
+}
+
+// DelayedQuoteRefresh is how often new real quotes should be fetched
+func (m *MarketDataService) DelayedQuoteRefresh() time.Duration {
+	return m.delayedRefresh
+}
+
+// ApplySplit rescales the simulated price after a stock split
+func (m *MarketDataService) ApplySplit(symbol string, ratio float64) {
+	symbol = strings.ToUpper(symbol)
+	m.pricesMu.Lock()
+	defer m.pricesMu.Unlock()
+	if price, exists := m.mockPrices[symbol]; exists && ratio > 0 {
+		m.mockPrices[symbol] = price / ratio
+	}
+}
+
+// SetStreamedPrice moves a symbol's price to the latest trade from the
+// market data stream, so fills and valuations follow the real market
+func (m *MarketDataService) SetStreamedPrice(symbol string, price float64) {
+	m.pricesMu.Lock()
+	defer m.pricesMu.Unlock()
+	m.mockPrices[strings.ToUpper(symbol)] = price
+}
+
+// RecordTick appends a simulator tick to the symbol's price history and
+// keeps it as the symbol's latest tick
+func (m *MarketDataService) RecordTick(stock models.Stock) {
+	m.historyMu.Lock()
+	defer m.historyMu.Unlock()
+
+	history := append(m.priceHistory[stock.Symbol], stock.Price)
+	if len(history) > priceHistorySize {
+		history = history[len(history)-priceHistorySize:]
+	}
+	m.priceHistory[stock.Symbol] = history
+	m.lastTicks[stock.Symbol] = stock
+}
+
+// GetLatestTick returns the most recent simulator tick for a symbol
+func (m *MarketDataService) GetLatestTick(symbol string) (models.Stock, bool) {
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	stock, ok := m.lastTicks[strings.ToUpper(symbol)]
+	return stock, ok
+}
+
+// GetPriceHistory returns a copy of the recorded ticks for a symbol, oldest first
+func (m *MarketDataService) GetPriceHistory(symbol string) []float64 {
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	history := m.priceHistory[strings.ToUpper(symbol)]
+	return append([]float64(nil), history...)
+}
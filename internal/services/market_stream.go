@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// A connection that delivers nothing, not even a provider ping, for this
+	// long is treated as dead
+	streamReadTimeout = 90 * time.Second
+	streamMinBackoff  = time.Second
+	streamMaxBackoff  = time.Minute
+	// Backoff starts over once a connection has lasted this long
+	streamStableAfter = time.Minute
+)
+
+// PriceSourceStreamed marks ticks from a real-time provider
+const PriceSourceStreamed = "streamed"
+
+// MarketStream feeds trades from a real-time provider into the price tick
+// path in place of the simulator. Trades are coalesced to at most one tick
+// per symbol every MARKET_STREAM_TICK_MS (default 1000), since a busy symbol
+// trades far faster than clients need. The connection is retried with
+// jittered exponential backoff, and after a reconnect each symbol is brought
+// up to date from a REST snapshot; trades older than a symbol's latest are
+// then dropped so the price never steps backwards.
+type MarketStream struct {
+	provider QuoteStreamProvider
+	market   *MarketDataService
+	events   *EventBus
+	symbols  []string
+	owned    map[string]bool
+	interval time.Duration
+
+	mu            sync.Mutex
+	pending       map[string]StreamTrade // Latest trade per symbol since the last tick
+	volume        map[string]int64       // Volume per symbol since the last tick
+	reference     map[string]float64     // Previous close, or the first price seen, per symbol
+	lastAt        map[string]time.Time   // Time of the latest trade taken per symbol
+	everConnected bool
+	status        models.MarketStreamStatus
+}
+
+func NewMarketStream(provider QuoteStreamProvider, market *MarketDataService, events *EventBus, symbols []string) *MarketStream {
+	s := &MarketStream{
+		provider:  provider,
+		market:    market,
+		events:    events,
+		owned:     make(map[string]bool),
+		interval:  time.Duration(max(config.GetEnvInt("MARKET_STREAM_TICK_MS", 1000), 100)) * time.Millisecond,
+		pending:   make(map[string]StreamTrade),
+		volume:    make(map[string]int64),
+		reference: make(map[string]float64),
+		lastAt:    make(map[string]time.Time),
+	}
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol != "" && !s.owned[symbol] {
+			s.owned[symbol] = true
+			s.symbols = append(s.symbols, symbol)
+		}
+	}
+	return s
+}
+
+// Owns reports whether the stream prices the symbol, so the simulator
+// leaves it alone. Symbols stay simulated until the stream first connects,
+// and in mock mode. A nil stream owns nothing.
+func (s *MarketStream) Owns(symbol string) bool {
+	if s == nil || !s.owned[symbol] || s.market.DataMode() == DataModeMock {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.everConnected
+}
+
+// Status returns the state of the provider connection
+func (s *MarketStream) Status() models.MarketStreamStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Provider = s.provider.Name()
+	status.Symbols = append([]string{}, s.symbols...)
+	return status
+}
+
+// Run keeps a connection to the provider open and publishes ticks. It
+// never returns.
+func (s *MarketStream) Run() {
+	go s.publishTicks()
+
+	log.Printf("📡 Streaming %d symbols from %s", len(s.symbols), s.provider.Name())
+	backoff := streamMinBackoff
+	for {
+		started := time.Now()
+		err := s.connect()
+		s.disconnected(err)
+		if time.Since(started) >= streamStableAfter {
+			backoff = streamMinBackoff
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("📡 %s stream disconnected: %v; reconnecting in %v", s.provider.Name(), err, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		backoff = min(backoff*2, streamMaxBackoff)
+	}
+}
+
+// connect runs one connection until it fails
+func (s *MarketStream) connect() error {
+	conn, _, err := websocket.DefaultDialer.Dial(s.provider.URL(), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := s.provider.Subscribe(conn, s.symbols); err != nil {
+		return err
+	}
+
+	reconnect := s.connected()
+	// Snapshots are fetched alongside, so trades are read from the start
+	go s.fillGap(reconnect)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		trades, err := s.provider.Parse(data)
+		if err != nil {
+			return err
+		}
+		for _, trade := range trades {
+			s.take(trade)
+		}
+	}
+}
+
+// connected records a new connection and reports whether it replaces an
+// earlier one
+func (s *MarketStream) connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reconnect := s.everConnected
+	if reconnect {
+		s.status.Reconnects++
+	}
+	s.everConnected = true
+	s.status.Connected = true
+	s.status.ConnectedAt = time.Now().UTC()
+	s.status.LastError = ""
+	return reconnect
+}
+
+func (s *MarketStream) disconnected(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Connected = false
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
+
+// fillGap fetches each symbol's last trade over REST. On the first
+// connection it seeds the previous close the day's change is measured from;
+// after a reconnect it also covers trades missed while disconnected.
+func (s *MarketStream) fillGap(reconnect bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, symbol := range s.symbols {
+		trade, err := s.provider.Snapshot(ctx, symbol)
+		if err != nil {
+			log.Printf("📡 Error fetching %s snapshot of %s: %v", s.provider.Name(), symbol, err)
+			continue
+		}
+		if s.take(*trade) && reconnect {
+			s.mu.Lock()
+			s.status.GapsFilled++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// take keeps a trade for the symbol's next tick. It reports false for
+// symbols not streamed and for trades older than the symbol's latest.
+func (s *MarketStream) take(trade StreamTrade) bool {
+	symbol := strings.ToUpper(trade.Symbol)
+	if !s.owned[symbol] || trade.Price <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Trades++
+	if trade.PrevClose > 0 {
+		s.reference[symbol] = trade.PrevClose
+	} else if s.reference[symbol] == 0 {
+		s.reference[symbol] = trade.Price
+	}
+	if trade.At.Before(s.lastAt[symbol]) {
+		s.status.Stale++
+		return false
+	}
+	s.lastAt[symbol] = trade.At
+	s.status.LastTradeAt = trade.At
+
+	trade.Symbol = symbol
+	s.pending[symbol] = trade
+	s.volume[symbol] += trade.Volume
+	return true
+}
+
+// publishTicks publishes the latest trade of each symbol that traded since
+// the last interval as a price tick
+func (s *MarketStream) publishTicks() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		pending, volume := s.pending, s.volume
+		s.pending = make(map[string]StreamTrade, len(pending))
+		s.volume = make(map[string]int64, len(volume))
+		// Mock mode keeps the simulator's prices
+		if s.market.DataMode() == DataModeMock {
+			s.mu.Unlock()
+			continue
+		}
+		ticks := make([]models.Stock, 0, len(pending))
+		for symbol, trade := range pending {
+			reference := s.reference[symbol]
+			change := trade.Price - reference
+			ticks = append(ticks, models.Stock{
+				Symbol:        symbol,
+				Name:          getStockName(symbol),
+				Price:         trade.Price,
+				Change:        change,
+				ChangePercent: change / reference * 100,
+				Volume:        volume[symbol],
+				Timestamp:     trade.At,
+				Source:        PriceSourceStreamed,
+			})
+		}
+		s.status.Ticks += uint64(len(ticks))
+		s.mu.Unlock()
+
+		for _, stock := range ticks {
+			s.market.SetStreamedPrice(stock.Symbol, stock.Price)
+			s.events.Publish(EventPriceTick, "", stock)
+		}
+	}
+}
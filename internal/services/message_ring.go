@@ -0,0 +1,51 @@
+package services
+
+// messageRing is a fixed-size buffer of the most recent sequenced messages
+// on a channel. It is owned by the hub goroutine and is not safe for
+// concurrent use.
+type messageRing struct {
+	seqs     []uint64
+	payloads []outboundMessage
+	start    int
+	size     int
+}
+
+func newMessageRing(capacity int) *messageRing {
+	return &messageRing{
+		seqs:     make([]uint64, capacity),
+		payloads: make([]outboundMessage, capacity),
+	}
+}
+
+func (r *messageRing) push(seq uint64, payload outboundMessage) {
+	capacity := len(r.seqs)
+	idx := (r.start + r.size) % capacity
+	r.seqs[idx] = seq
+	r.payloads[idx] = payload
+
+	if r.size < capacity {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % capacity
+	}
+}
+
+// oldest returns the sequence of the oldest buffered message, or 0 if empty
+func (r *messageRing) oldest() uint64 {
+	if r.size == 0 {
+		return 0
+	}
+	return r.seqs[r.start]
+}
+
+// after returns buffered payloads with a sequence greater than seq, oldest first
+func (r *messageRing) after(seq uint64) []outboundMessage {
+	var out []outboundMessage
+	for i := 0; i < r.size; i++ {
+		idx := (r.start + i) % len(r.seqs)
+		if r.seqs[idx] > seq {
+			out = append(out, r.payloads[idx])
+		}
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MyActivity gathers the user's position, open orders and fills in a
+// symbol for the main account (competitionID empty) or a competition
+// account. Realized P&L replays every fill, with the splits applied since,
+// under the user's cost basis method; limit caps the executions listed,
+// not those replayed.
+func (s *OrderService) MyActivity(ctx context.Context, userID, competitionID, symbol string, limit int) (*models.MyActivity, error) {
+	symbol = strings.ToUpper(symbol)
+	activity := &models.MyActivity{
+		Symbol:        symbol,
+		CompetitionID: competitionID,
+		OpenOrders:    []models.Order{},
+		Executions:    []models.Execution{},
+	}
+	filter := positionFilter(userID, competitionID, symbol)
+
+	var position models.Portfolio
+	err := s.portfolioCollection.FindOne(ctx, filter).Decode(&position)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil && position.Shares != 0 {
+		activity.Position = &position
+	}
+
+	orderFilter := bson.M{"status": bson.M{"$in": openOrderStatuses}}
+	for key, value := range filter {
+		orderFilter[key] = value
+	}
+	cursor, err := s.orderCollection.Find(ctx, orderFilter, options.Find().SetSort(bson.M{"timestamp": -1}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &activity.OpenOrders); err != nil {
+		return nil, err
+	}
+
+	var executions []models.Execution
+	cursor, err = s.executionCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "executed_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+	splits, err := s.appliedSplits(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	method := costBasisMethod(ctx, s.userCollection, userID)
+	replayed := models.Portfolio{}
+	next := 0
+	for _, execution := range executions {
+		for ; next < len(splits) && splits[next].AppliedAt.Before(execution.ExecutedAt); next++ {
+			if replayed.Shares != 0 {
+				replayed.Shares = math.Trunc(replayed.Shares*splits[next].Ratio*quantityPrecision) / quantityPrecision
+				replayed.AvgCost /= splits[next].Ratio
+				replayed.Lots = splitLots(replayed.Lots, splits[next].Ratio, replayed.Shares)
+			}
+		}
+		activity.RealizedProfitLoss += realizedOnFill(&replayed, execution, method)
+		activity.Fees += execution.Fees
+	}
+	activity.RealizedProfitLoss = round2(activity.RealizedProfitLoss)
+	activity.Fees = round2(activity.Fees)
+
+	for i := len(executions) - 1; i >= 0 && len(activity.Executions) < limit; i-- {
+		activity.Executions = append(activity.Executions, executions[i])
+	}
+
+	if activity.Position != nil {
+		mode := DataModeMock
+		if competitionID == "" {
+			mode = s.DataMode(userID)
+		}
+		if price, ok := s.marketService.GetMarkPrice(symbol, mode); ok {
+			activity.Price = price
+			activity.MarketValue = round2(position.Shares * price)
+			activity.UnrealizedProfitLoss = round2((price - position.AvgCost) * position.Shares)
+		}
+	}
+	return activity, nil
+}
+
+// appliedSplits returns the splits applied to a symbol, oldest first
+func (s *OrderService) appliedSplits(ctx context.Context, symbol string) ([]models.CorporateAction, error) {
+	var splits []models.CorporateAction
+	cursor, err := config.GetCollection("corporate_actions").Find(ctx, bson.M{
+		"symbol": symbol,
+		"type":   "split",
+		"status": "applied",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &splits); err != nil {
+		return nil, err
+	}
+	sort.Slice(splits, func(i, j int) bool { return splits[i].AppliedAt.Before(splits[j].AppliedAt) })
+	return splits, nil
+}
+
+// realizedOnFill books an execution into a replayed position and returns
+// the P&L of the part that closed shares: against the average cost, or the
+// oldest lots under FIFO
+func realizedOnFill(pos *models.Portfolio, execution models.Execution, method string) float64 {
+	closing := pos.Shares != 0 && (pos.Shares > 0) != (execution.Side == "buy")
+	if !closing {
+		applyLots(pos, execution.Side, execution.Quantity, execution.Price, execution.ExecutedAt, method)
+		return 0
+	}
+
+	closed := math.Min(execution.Quantity, math.Abs(pos.Shares))
+	cost := pos.AvgCost * closed
+	if method == CostBasisFIFO && len(pos.Lots) > 0 {
+		lots := append([]models.TaxLot(nil), pos.Lots...)
+		cost = lotCost(lots) - lotCost(closeLots(lots, closed))
+	}
+	profitLoss := execution.Price*closed - cost
+	if pos.Shares < 0 {
+		profitLoss = -profitLoss
+	}
+	applyLots(pos, execution.Side, execution.Quantity, execution.Price, execution.ExecutedAt, method)
+	return profitLoss
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSPublisher speaks the NATS client protocol directly: a CONNECT
+// handshake, PUB for each message and PONG replies to server PINGs. It
+// reconnects on the next publish after the connection drops.
+type NATSPublisher struct {
+	address string
+	mu      sync.Mutex
+	conn    net.Conn
+	writer  *bufio.Writer
+}
+
+func NewNATSPublisher(rawURL string) (*NATSPublisher, error) {
+	address := rawURL
+	if strings.Contains(rawURL, "://") {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS_URL: %v", err)
+		}
+		address = parsed.Host
+	}
+
+	p := &NATSPublisher{address: address}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Streaming events to NATS at %s", address)
+	return p, nil
+}
+
+// connect must be called with mu held, or before the publisher is shared
+func (p *NATSPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", p.address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("NATS connect failed: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	info, err := reader.ReadString('\n')
+	conn.SetReadDeadline(time.Time{})
+	if err != nil || !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return fmt.Errorf("NATS handshake failed: %v", err)
+	}
+
+	writer := bufio.NewWriter(conn)
+	writer.WriteString(`CONNECT {"verbose":false,"pedantic":false,"name":"trading-simulator"}` + "\r\n")
+	if err := writer.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.writer = writer
+	go p.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers keepalive PINGs and logs server errors
+func (p *NATSPublisher) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			p.mu.Lock()
+			if p.conn == conn {
+				p.writer.WriteString("PONG\r\n")
+				p.writer.Flush()
+			}
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("NATS error: %s", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (p *NATSPublisher) Publish(subject string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(p.writer, "PUB %s %d\r\n", subject, len(payload))
+	p.writer.Write(payload)
+	p.writer.WriteString("\r\n")
+	if err := p.writer.Flush(); err != nil {
+		p.conn.Close()
+		p.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
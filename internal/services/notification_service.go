@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Push notification types a device can turn on or off
+const (
+	PushFill           = "fill"            // An order filled
+	PushStopTriggered  = "stop_triggered"  // A stop order triggered, whether or not it filled
+	PushOrderCancelled = "order_cancelled" // A resting order expired or lost its shares
+	PushMaintenance    = "maintenance"     // Trading paused or resumed
+)
+
+// Whether each type is sent to devices that have not set a preference
+var defaultPushPreferences = map[string]bool{
+	PushFill:           true,
+	PushStopTriggered:  true,
+	PushOrderCancelled: true,
+	PushMaintenance:    false,
+}
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrDeviceLimit    = errors.New("device limit reached")
+)
+
+// NotificationService tells users about fills and order alerts: over their
+// open sockets, or in their inbox while they are offline, and by push to the
+// phones they register, through the sender for each device's platform.
+// Pushes are best effort: a failed send is logged, and devices whose token
+// the push service rejects are removed.
+type NotificationService struct {
+	deviceCollection *mongo.Collection
+	userCollection   *mongo.Collection
+	senders          map[string]PushSender
+	hub              *WebSocketHub
+	maxDevices       int
+}
+
+func NewNotificationService(senders map[string]PushSender, hub *WebSocketHub, events *EventBus) *NotificationService {
+	s := &NotificationService{
+		deviceCollection: config.GetCollection("devices"),
+		userCollection:   config.GetCollection("users"),
+		senders:          senders,
+		hub:              hub,
+		maxDevices:       config.GetEnvInt("DEVICES_PER_USER", 10),
+	}
+	events.SubscribeAsync(EventOrderFilled, s.onOrderFilled)
+	events.SubscribeAsync(EventOrderTriggered, s.onOrderTriggered)
+	events.SubscribeAsync(EventOrderCancelled, s.onOrderCancelled)
+	events.SubscribeAsync(EventMaintenanceChanged, s.onMaintenanceChanged)
+	return s
+}
+
+// RegisterDevice adds a device for the user, or updates it when the token
+// is already registered. A token registered by another user moves to this
+// one, as happens when someone else logs in on the same phone.
+func (s *NotificationService) RegisterDevice(ctx context.Context, userID string, device models.Device) (*models.Device, error) {
+	device.Platform = strings.ToLower(device.Platform)
+	device.Token = strings.TrimSpace(device.Token)
+	if _, ok := s.senders[device.Platform]; !ok {
+		return nil, fmt.Errorf("platform must be android or ios")
+	}
+	if device.Token == "" || len(device.Token) > 4096 {
+		return nil, fmt.Errorf("token is required")
+	}
+	if err := validatePushPreferences(device.Preferences); err != nil {
+		return nil, err
+	}
+
+	count, err := s.deviceCollection.CountDocuments(ctx, bson.M{"user_id": userID, "token": bson.M{"$ne": device.Token}})
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.maxDevices) {
+		return nil, ErrDeviceLimit
+	}
+
+	now := time.Now().UTC()
+	set := bson.M{"user_id": userID, "platform": device.Platform, "name": device.Name, "updated_at": now}
+	if device.Preferences != nil {
+		set["preferences"] = device.Preferences
+	}
+	var saved models.Device
+	err = s.deviceCollection.FindOneAndUpdate(ctx,
+		bson.M{"token": device.Token},
+		bson.M{"$set": set, "$setOnInsert": bson.M{"created_at": now}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// ListDevices returns the user's devices
+func (s *NotificationService) ListDevices(ctx context.Context, userID string) ([]models.Device, error) {
+	cursor, err := s.deviceCollection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	devices := []models.Device{}
+	err = cursor.All(ctx, &devices)
+	return devices, err
+}
+
+// UpdatePreferences turns notification types on or off for one device;
+// types left out keep their current setting
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, deviceID primitive.ObjectID, preferences map[string]bool) (*models.Device, error) {
+	if err := validatePushPreferences(preferences); err != nil {
+		return nil, err
+	}
+	set := bson.M{"updated_at": time.Now().UTC()}
+	for kind, enabled := range preferences {
+		set["preferences."+kind] = enabled
+	}
+
+	var device models.Device
+	err := s.deviceCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": deviceID, "user_id": userID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&device)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// DeleteDevice unregisters one of the user's devices
+func (s *NotificationService) DeleteDevice(ctx context.Context, userID string, deviceID primitive.ObjectID) error {
+	result, err := s.deviceCollection.DeleteOne(ctx, bson.M{"_id": deviceID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// onOrderFilled pushes fills other than those of triggered stops, which
+// onOrderTriggered covers
+func (s *NotificationService) onOrderFilled(event Event) {
+	order := event.Payload.(models.Order)
+	if order.ParentOrderID != "" {
+		return
+	}
+	data := map[string]string{"type": PushFill, "orderId": order.ID.Hex(), "symbol": order.Symbol}
+	args := []interface{}{order.Type, order.Quantity, order.Symbol, order.Price}
+	s.notify(order, "order_filled", "notification.order_filled", args...)
+	s.pushToUser(order.UserID, PushFill, data, "notification.order_filled", args...)
+}
+
+func (s *NotificationService) onOrderTriggered(event Event) {
+	order := event.Payload.(models.Order)
+	// Scheduled and conditional market and limit orders fill once when they
+	// go live
+	if order.OrderType == "market" || order.OrderType == "limit" {
+		data := map[string]string{"type": PushFill, "orderId": order.ID.Hex(), "symbol": order.Symbol, "status": order.Status}
+		if order.Status == "failed" {
+			args := []interface{}{order.OrderType, order.Type, order.Quantity, order.Symbol, order.Price}
+			s.notify(order, "order_failed", "notification.order_failed", args...)
+			s.pushToUser(order.UserID, PushFill, data, "notification.order_failed", args...)
+			return
+		}
+		args := []interface{}{order.Type, order.FilledQuantity, order.Symbol, order.Price}
+		s.notify(order, "order_filled", "notification.order_filled", args...)
+		s.pushToUser(order.UserID, PushFill, data, "notification.order_filled", args...)
+		return
+	}
+	data := map[string]string{"type": PushStopTriggered, "orderId": order.ID.Hex(), "symbol": order.Symbol, "status": order.Status}
+	if order.Status == "failed" {
+		args := []interface{}{order.OrderType, order.Type, order.Quantity, order.Symbol, order.Price}
+		s.notify(order, "order_failed", "notification.stop_failed", args...)
+		s.pushToUser(order.UserID, PushStopTriggered, data, "notification.stop_failed", args...)
+		return
+	}
+	args := []interface{}{order.OrderType, order.Type, order.FilledQuantity, order.Symbol, order.Price}
+	s.notify(order, "order_triggered", "notification.stop_triggered", args...)
+	s.pushToUser(order.UserID, PushStopTriggered, data, "notification.stop_triggered", args...)
+}
+
+// onOrderCancelled alerts about orders the system cancelled; the user's
+// own cancellations and OCO siblings of a fill are not pushed
+func (s *NotificationService) onOrderCancelled(event Event) {
+	order := event.Payload.(models.Order)
+	if order.CancelReason != "expired" && order.CancelReason != "no_position" {
+		return
+	}
+	data := map[string]string{"type": PushOrderCancelled, "orderId": order.ID.Hex(), "symbol": order.Symbol, "reason": order.CancelReason}
+	s.pushToUser(order.UserID, PushOrderCancelled, data, "notification.order_"+order.CancelReason, order.OrderType, order.Type, order.Quantity, order.Symbol)
+}
+
+func (s *NotificationService) onMaintenanceChanged(event Event) {
+	state := event.Payload.(models.MaintenanceState)
+	code := "notification.maintenance_ended"
+	if state.Enabled {
+		code = "notification.maintenance_started"
+	}
+	s.push(bson.M{}, PushMaintenance, map[string]string{"type": PushMaintenance}, code)
+}
+
+// notify sends the order's owner a message in their language over their
+// open sockets, kept in their inbox if none takes it
+func (s *NotificationService) notify(order models.Order, messageType, code string, args ...interface{}) {
+	if order.UserID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lang := s.language(ctx, order.UserID)
+	if lang == "" {
+		return // Account deleted
+	}
+	s.hub.SendToUser(order.UserID, map[string]interface{}{
+		"type":    messageType,
+		"code":    code,
+		"message": i18n.Translate(lang, code, args...),
+		"order":   order,
+	})
+}
+
+func (s *NotificationService) pushToUser(userID, kind string, data map[string]string, code string, args ...interface{}) {
+	if userID == "" {
+		return
+	}
+	s.push(bson.M{"user_id": userID}, kind, data, code, args...)
+}
+
+// push sends a notification of one type to the matching devices that want
+// it, in each owner's language
+func (s *NotificationService) push(filter bson.M, kind string, data map[string]string, code string, args ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	wanted := bson.M{"preferences." + kind: true}
+	if defaultPushPreferences[kind] {
+		wanted = bson.M{"preferences." + kind: bson.M{"$ne": false}}
+	}
+	cursor, err := s.deviceCollection.Find(ctx, bson.M{"$and": bson.A{filter, wanted}})
+	if err != nil {
+		log.Printf("Error loading devices for %s push: %v", kind, err)
+		return
+	}
+	var devices []models.Device
+	if err := cursor.All(ctx, &devices); err != nil {
+		log.Printf("Error loading devices for %s push: %v", kind, err)
+		return
+	}
+
+	languages := make(map[string]string)
+	for _, device := range devices {
+		lang, ok := languages[device.UserID]
+		if !ok {
+			lang = s.language(ctx, device.UserID)
+			languages[device.UserID] = lang
+		}
+		if lang == "" {
+			continue // Account deleted
+		}
+
+		message := PushMessage{
+			Title: i18n.Translate(lang, "push.title."+kind),
+			Body:  i18n.Translate(lang, code, args...),
+			Data:  data,
+		}
+		err := s.senders[device.Platform].Send(ctx, device.Token, message)
+		if errors.Is(err, ErrDeviceUnregistered) {
+			s.deviceCollection.DeleteOne(ctx, bson.M{"_id": device.ID})
+			continue
+		}
+		if err != nil {
+			log.Printf("Error pushing %s to device %s: %v", kind, device.ID.Hex(), err)
+		}
+	}
+}
+
+// language returns the user's message language, or "" for a deleted account
+func (s *NotificationService) language(ctx context.Context, userID string) string {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ""
+	}
+	var user models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID, "deleted_at": bson.M{"$exists": false}},
+		options.FindOne().SetProjection(bson.M{"language": 1})).Decode(&user)
+	if err != nil {
+		return ""
+	}
+	if user.Language == "" {
+		return i18n.English
+	}
+	return user.Language
+}
+
+func validatePushPreferences(preferences map[string]bool) error {
+	for kind := range preferences {
+		if _, ok := defaultPushPreferences[kind]; !ok {
+			return fmt.Errorf("unknown notification type %q", kind)
+		}
+	}
+	return nil
+}
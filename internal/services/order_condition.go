@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+)
+
+// Comparisons an order condition can make, longest first so "<=" is not
+// read as "<"
+var orderConditionOps = []string{">=", "<=", ">", "<"}
+
+// validateOrderCondition parses the condition's expression when one is
+// given, checks the symbol and comparison, and normalizes both forms. The
+// expression is only ever split into symbol, operator and number, never
+// evaluated.
+func (e *OrderEngine) validateOrderCondition(condition *models.OrderCondition) error {
+	if expression := strings.TrimSpace(condition.Expression); expression != "" {
+		parsed, ok := parseOrderCondition(expression)
+		if !ok {
+			return i18n.NewError("order.condition_invalid", expression)
+		}
+		*condition = parsed
+	}
+
+	condition.Symbol = strings.ToUpper(strings.TrimSpace(condition.Symbol))
+	if !e.orderService.symbolService.HasSymbol(condition.Symbol) {
+		return i18n.NewError("order.condition_unknown_symbol", condition.Symbol)
+	}
+	if !slices.Contains(orderConditionOps, condition.Op) || !(condition.Value > 0) || math.IsInf(condition.Value, 1) {
+		return i18n.NewError("order.condition_invalid", fmt.Sprintf("%s %s %g", condition.Symbol, condition.Op, condition.Value))
+	}
+	condition.Expression = fmt.Sprintf("%s %s %g", condition.Symbol, condition.Op, condition.Value)
+	return nil
+}
+
+// parseOrderCondition splits an expression like "SPY > 450" or "spy>=450.5"
+func parseOrderCondition(expression string) (models.OrderCondition, bool) {
+	for _, op := range orderConditionOps {
+		symbol, value, found := strings.Cut(expression, op)
+		if !found {
+			continue
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return models.OrderCondition{}, false
+		}
+		return models.OrderCondition{Symbol: strings.TrimSpace(symbol), Op: op, Value: price}, true
+	}
+	return models.OrderCondition{}, false
+}
+
+// orderConditionHolds compares the condition symbol's price with its value
+func orderConditionHolds(condition *models.OrderCondition, price float64) bool {
+	switch condition.Op {
+	case ">":
+		return price > condition.Value
+	case ">=":
+		return price >= condition.Value
+	case "<":
+		return price < condition.Value
+	case "<=":
+		return price <= condition.Value
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+)
+
+// OrderStrategy holds the behaviour that differs between order types.
+// Everything else (symbol rules, throttling, holds and fills) runs through
+// the same OrderEngine path for every type.
+type OrderStrategy interface {
+	// Validate checks the type-specific fields of a new order
+	Validate(order *models.Order) error
+	// Resting reports whether the order waits for a trigger instead of filling now
+	Resting() bool
+	// Triggered reports whether a resting order should fill at the given price
+	Triggered(order *models.Order, price float64) bool
+	// HoldPrice is the worst price a resting buy can fill at, used to reserve cash
+	HoldPrice(order *models.Order) float64
+}
+
+// OrderEngine is the single entry point for new orders, basic or advanced
+type OrderEngine struct {
+	orderService *OrderService
+	tenants      *TenantService
+	classrooms   *ClassroomService // Classroom trading restrictions; nil to skip them
+	clock        *MarketClock      // Market hours and halts; nil to trade at any time
+	training     *TrainingService  // Simulated broker rejections and delays; nil to never fail
+	strategies   map[string]OrderStrategy
+}
+
+func NewOrderEngine(orderService *OrderService, tenants *TenantService, classrooms *ClassroomService, clock *MarketClock, training *TrainingService) *OrderEngine {
+	e := &OrderEngine{
+		orderService: orderService,
+		tenants:      tenants,
+		classrooms:   classrooms,
+		clock:        clock,
+		training:     training,
+		strategies:   make(map[string]OrderStrategy),
+	}
+	e.Register("market", immediateStrategy{})
+	e.Register("limit", immediateStrategy{})
+	e.Register("stop", stopStrategy{})
+	e.Register("stop_limit", stopLimitStrategy{})
+	e.Register("trailing_stop", trailingStopStrategy{})
+	return e
+}
+
+// Register adds or replaces the strategy for an order type
+func (e *OrderEngine) Register(orderType string, strategy OrderStrategy) {
+	e.strategies[orderType] = strategy
+}
+
+// Prepare runs the validation shared by every order type and returns the
+// order's strategy
+func (e *OrderEngine) Prepare(ctx context.Context, order *models.Order) (OrderStrategy, error) {
+	return e.prepare(ctx, order, true)
+}
+
+// prepare validates an order, throttling it through the order guard unless
+// the caller has already checked the submission as a whole
+func (e *OrderEngine) prepare(ctx context.Context, order *models.Order, throttle bool) (OrderStrategy, error) {
+	if order.Type != "buy" && order.Type != "sell" {
+		return nil, i18n.NewError("order.invalid_side", order.Type)
+	}
+	strategy, ok := e.strategies[order.OrderType]
+	if !ok {
+		return nil, i18n.NewError("order.unsupported_type", order.OrderType)
+	}
+	if err := e.orderService.symbolService.ApplyRules(order); err != nil {
+		return nil, err
+	}
+	if err := e.tenants.CheckSymbol(order.TenantID, order.Symbol); err != nil {
+		return nil, err
+	}
+	if e.classrooms != nil {
+		if err := e.classrooms.CheckOrder(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+	if err := strategy.Validate(order); err != nil {
+		return nil, err
+	}
+	if order.Condition != nil {
+		if err := e.validateOrderCondition(order.Condition); err != nil {
+			return nil, err
+		}
+	}
+	if throttle {
+		if err := e.orderService.guard.CheckOrder(order); err != nil {
+			return nil, err
+		}
+	}
+	if order.CompetitionID != "" {
+		if _, err := e.orderService.competitionService.GetEntry(ctx, order.CompetitionID, order.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return strategy, nil
+}
+
+// PlaceOrder validates and fills an order that executes immediately. Orders
+// for a symbol that cannot trade now fail with a *MarketClosedError. In
+// training mode the order may be held for a while or rejected with a
+// simulated broker error first.
+func (e *OrderEngine) PlaceOrder(ctx context.Context, order *models.Order) error {
+	strategy, err := e.Prepare(ctx, order)
+	if err != nil {
+		return err
+	}
+	if strategy.Resting() {
+		return i18n.NewError("order.advanced_only", order.OrderType)
+	}
+	if e.clock != nil {
+		if err := e.clock.CheckOrder(order); err != nil {
+			return err
+		}
+	}
+	if e.training != nil {
+		if err := e.training.Apply(ctx, order); err != nil {
+			return err
+		}
+	}
+	e.orderService.events.Publish(EventOrderPlaced, order.UserID, *order)
+	return e.orderService.fillOrder(ctx, order)
+}
+
+// QueueUntil returns when an immediate order that cannot trade now should
+// be scheduled to fill instead, if the market closed policy is to queue
+func (e *OrderEngine) QueueUntil(order *models.Order) (time.Time, bool) {
+	if e.clock == nil {
+		return time.Time{}, false
+	}
+	return e.clock.QueueUntil(order)
+}
+
+// CanTrade reports whether a resting order's symbol can trade now
+func (e *OrderEngine) CanTrade(order *models.Order) bool {
+	return e.clock == nil || e.clock.CanTrade(order)
+}
+
+// Triggered reports whether a resting order should fill at the given price
+func (e *OrderEngine) Triggered(order *models.Order, price float64) bool {
+	strategy, ok := e.strategies[order.OrderType]
+	return ok && strategy.Resting() && strategy.Triggered(order, price)
+}
+
+// HoldPrice returns the price used to reserve cash for a resting buy
+func (e *OrderEngine) HoldPrice(order *models.Order) float64 {
+	if strategy, ok := e.strategies[order.OrderType]; ok {
+		return strategy.HoldPrice(order)
+	}
+	return order.Price
+}
+
+// Execute fills a triggered resting order. System-generated orders skip
+// throttling, which only applies to what the user submits.
+func (e *OrderEngine) Execute(ctx context.Context, order *models.Order) error {
+	return e.orderService.fillOrder(ctx, order)
+}
+
+// immediateStrategy fills at the submitted price as soon as the order is placed
+type immediateStrategy struct{}
+
+func (immediateStrategy) Validate(order *models.Order) error {
+	if order.Price <= 0 {
+		return i18n.NewError("order.price_not_positive")
+	}
+	return nil
+}
+
+func (immediateStrategy) Resting() bool                                     { return false }
+func (immediateStrategy) Triggered(order *models.Order, price float64) bool { return false }
+func (immediateStrategy) HoldPrice(order *models.Order) float64             { return order.Price }
+
+// stopStrategy becomes a market order once the price crosses the stop
+type stopStrategy struct{}
+
+func (stopStrategy) Validate(order *models.Order) error {
+	if order.StopPrice <= 0 {
+		return i18n.NewError("order.stop_price_required")
+	}
+	return nil
+}
+
+func (stopStrategy) Resting() bool { return true }
+
+func (stopStrategy) Triggered(order *models.Order, price float64) bool {
+	if order.Type == "sell" {
+		return price <= order.StopPrice
+	}
+	return price >= order.StopPrice
+}
+
+func (stopStrategy) HoldPrice(order *models.Order) float64 { return order.StopPrice }
+
+// stopLimitStrategy triggers at the stop but only fills within the limit
+type stopLimitStrategy struct{}
+
+func (stopLimitStrategy) Validate(order *models.Order) error {
+	if order.StopPrice <= 0 || order.LimitPrice <= 0 {
+		return i18n.NewError("order.stop_limit_prices_required")
+	}
+	return nil
+}
+
+func (stopLimitStrategy) Resting() bool { return true }
+
+func (stopLimitStrategy) Triggered(order *models.Order, price float64) bool {
+	if order.Type == "sell" {
+		return price <= order.StopPrice && price >= order.LimitPrice
+	}
+	return price >= order.StopPrice && price <= order.LimitPrice
+}
+
+func (stopLimitStrategy) HoldPrice(order *models.Order) float64 { return order.LimitPrice }
+
+// trailingStopStrategy is a stop whose price the monitor moves behind the
+// best price seen
+type trailingStopStrategy struct{ stopStrategy }
+
+func (trailingStopStrategy) Validate(order *models.Order) error {
+	if order.TrailingPercent <= 0 || order.TrailingPercent >= 100 {
+		return i18n.NewError("order.trailing_percent_invalid")
+	}
+	return stopStrategy{}.Validate(order)
+}
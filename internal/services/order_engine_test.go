@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"trading-simulator/internal/models"
+)
+
+// BenchmarkOrderPrepare measures the in-memory validation every new order
+// runs through before it is filled. The fill itself is measured by
+// BenchmarkOrderExecute, which needs Mongo and the integration tag.
+func BenchmarkOrderPrepare(b *testing.B) {
+	symbolService := NewSymbolService()
+	engine := NewOrderEngine(&OrderService{symbolService: symbolService}, nil, nil, nil, nil)
+	strategy := engine.strategies["limit"]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		order := models.Order{Symbol: "aapl", Type: "buy", OrderType: "limit", Quantity: 10, Price: 189.253}
+		if err := symbolService.ApplyRules(&order); err != nil {
+			b.Fatal(err)
+		}
+		if err := strategy.Validate(&order); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkOrderTrigger measures the per-tick trigger check the stop order
+// monitor runs against every resting order
+func BenchmarkOrderTrigger(b *testing.B) {
+	engine := NewOrderEngine(&OrderService{}, nil, nil, nil, nil)
+	orders := []models.Order{
+		{Type: "sell", OrderType: "stop", StopPrice: 180},
+		{Type: "buy", OrderType: "stop_limit", StopPrice: 190, LimitPrice: 192},
+		{Type: "sell", OrderType: "trailing_stop", StopPrice: 175, TrailingPercent: 5},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		price := 170 + float64(i%30)
+		for j := range orders {
+			engine.Triggered(&orders[j], price)
+		}
+	}
+}
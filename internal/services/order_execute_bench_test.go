@@ -0,0 +1,107 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BenchmarkOrderExecute measures a market buy filled through
+// OrderEngine.Execute against a real Mongo: the buying power read, the fill
+// transaction and its writes to orders, positions, executions, the journal
+// and the outbox. It starts a single-node replica set with testcontainers,
+// as the e2e tests do, so fills run in transactions.
+//
+//	go test -tags integration -run '^$' -bench OrderExecute ./internal/services
+func BenchmarkOrderExecute(b *testing.B) {
+	ctx := context.Background()
+	startBenchMongo(b, ctx)
+
+	marketService := NewMarketDataService()
+	symbolService := NewSymbolService()
+	events := NewEventBus()
+	flags := NewFeatureFlagService(NewTierService())
+	orderService := NewOrderService(marketService, symbolService, NewOrderGuardService(),
+		NewCompetitionService(marketService, flags), events, NewOutboxService(events), NewDataModeService(), flags)
+	engine := NewOrderEngine(orderService, nil, nil, nil, nil)
+
+	user := models.User{
+		ID:          primitive.NewObjectID(),
+		Username:    "bench",
+		CashBalance: 1e12,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if _, err := config.GetCollection("users").InsertOne(ctx, user); err != nil {
+		b.Fatal(err)
+	}
+
+	// Setup runs once; the sub-benchmark is what gets run b.N times
+	b.Run("market buy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			order := &models.Order{UserID: user.ID.Hex(), Symbol: "AAPL", Type: "buy", OrderType: "market", Quantity: 1, Price: 189.25}
+			if err := engine.Execute(ctx, order); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// startBenchMongo starts a throwaway Mongo replica set and connects
+// config.DB to it for the rest of the benchmark
+func startBenchMongo(b *testing.B, ctx context.Context) {
+	b.Helper()
+	container, err := testcontainers.Run(ctx, "mongo:7",
+		testcontainers.WithExposedPorts("27017/tcp"),
+		testcontainers.WithCmd("--replSet", "rs0", "--bind_ip_all"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort("27017/tcp")),
+	)
+	b.Cleanup(func() {
+		if container != nil {
+			testcontainers.TerminateContainer(container)
+		}
+	})
+	if err != nil {
+		b.Fatalf("starting Mongo: %v", err)
+	}
+	addr, err := container.PortEndpoint(ctx, "27017/tcp", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	mongosh := func(script string) string {
+		code, out, err := container.Exec(ctx, []string{"mongosh", "--quiet", "--eval", script}, tcexec.Multiplexed())
+		if err != nil || code != 0 {
+			return ""
+		}
+		data, _ := io.ReadAll(out)
+		return strings.TrimSpace(string(data))
+	}
+	initiate := `try { rs.status().ok } catch (e) { rs.initiate({_id: "rs0", members: [{_id: 0, host: "localhost:27017"}]}).ok }`
+	for deadline := time.Now().Add(time.Minute); ; time.Sleep(500 * time.Millisecond) {
+		if mongosh(initiate) == "1" && mongosh("db.hello().isWritablePrimary") == "true" {
+			break
+		}
+		if time.Now().After(deadline) {
+			b.Fatal("Mongo replica set did not elect a primary")
+		}
+	}
+
+	b.Setenv("MONGODB_URI", fmt.Sprintf("mongodb://%s/?directConnection=true", addr))
+	b.Setenv("DATABASE_NAME", "bench")
+	b.Setenv("MONGO_SLOW_QUERY_MS", "0")
+	config.ConnectDB()
+	b.Cleanup(config.DisconnectDB)
+}
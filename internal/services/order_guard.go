@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrderGuardService enforces per-user order frequency limits, holds back
+// likely double submissions and flags self-crossing wash trades
+type OrderGuardService struct {
+	violationCollection *mongo.Collection
+	maxOrdersPerSecond  int
+	minSymbolInterval   time.Duration
+	washTradeWindow     time.Duration
+	duplicateWindow     time.Duration
+
+	mu              sync.Mutex
+	recentOrders    map[string][]time.Time  // userID -> order times within the last second
+	lastSymbolOrder map[string]time.Time    // userID|symbol -> last order time
+	lastFills       map[string]models.Order // userID|symbol -> last fill
+	lastAccepted    map[string]time.Time    // Order signature -> when it was last accepted
+}
+
+func NewOrderGuardService() *OrderGuardService {
+	return &OrderGuardService{
+		violationCollection: config.GetCollection("order_violations"),
+		maxOrdersPerSecond:  config.GetEnvInt("ORDER_MAX_PER_SECOND", 5),
+		minSymbolInterval:   time.Duration(config.GetEnvInt("ORDER_MIN_SYMBOL_INTERVAL_MS", 500)) * time.Millisecond,
+		washTradeWindow:     time.Duration(config.GetEnvInt("WASH_TRADE_WINDOW_SECONDS", 60)) * time.Second,
+		duplicateWindow:     time.Duration(config.GetEnvInt("ORDER_DUPLICATE_WINDOW_SECONDS", 5)) * time.Second,
+		recentOrders:        make(map[string][]time.Time),
+		lastSymbolOrder:     make(map[string]time.Time),
+		lastFills:           make(map[string]models.Order),
+		lastAccepted:        make(map[string]time.Time),
+	}
+}
+
+// IsDuplicateOrder reports whether an order was held back as a likely
+// double submission, which the client can confirm with allowDuplicate
+func IsDuplicateOrder(err error) bool {
+	var msgErr *i18n.Error
+	return errors.As(err, &msgErr) && msgErr.Code == "order.duplicate"
+}
+
+// orderSignature identifies orders that are the same as far as the user is
+// concerned: account, symbol, side, type, quantity and prices
+func orderSignature(order *models.Order) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%g|%g|%g|%g", order.UserID, order.CompetitionID, order.Symbol,
+		order.Type, order.OrderType, order.Quantity, order.Price, order.StopPrice, order.LimitPrice)
+}
+
+// CheckOrder rejects orders that exceed the user's order rate, arrive too
+// soon after a previous order in the same symbol, or repeat an identical
+// order within ORDER_DUPLICATE_WINDOW_SECONDS unless AllowDuplicate is set
+func (s *OrderGuardService) CheckOrder(order *models.Order) error {
+	now := time.Now()
+	key := order.UserID + "|" + order.Symbol
+	signature := orderSignature(order)
+
+	s.mu.Lock()
+	recent := s.pruneRecent(order.UserID, now)
+
+	var violation *models.OrderViolation
+	if len(recent) >= s.maxOrdersPerSecond {
+		violation = &models.OrderViolation{
+			Kind:   "rate_limit",
+			Detail: fmt.Sprintf("more than %d orders per second", s.maxOrdersPerSecond),
+		}
+	} else if last, ok := s.lastSymbolOrder[key]; ok && now.Sub(last) < s.minSymbolInterval {
+		violation = &models.OrderViolation{
+			Kind:   "symbol_interval",
+			Detail: fmt.Sprintf("orders in %s must be at least %v apart", order.Symbol, s.minSymbolInterval),
+		}
+	} else if last, ok := s.lastAccepted[signature]; ok && !order.AllowDuplicate && now.Sub(last) < s.duplicateWindow {
+		violation = &models.OrderViolation{
+			Kind: "duplicate",
+			Detail: fmt.Sprintf("%s %g @ $%.2f repeated within %v",
+				order.Type, order.Quantity, order.Price, now.Sub(last).Round(time.Millisecond)),
+		}
+	} else {
+		s.recentOrders[order.UserID] = append(recent, now)
+		s.lastSymbolOrder[key] = now
+		s.lastAccepted[signature] = now
+		s.pruneAccepted(now)
+	}
+	s.mu.Unlock()
+
+	if violation != nil {
+		violation.UserID = order.UserID
+		violation.TenantID = order.TenantID
+		violation.Symbol = order.Symbol
+		violation.Rejected = true
+		s.recordViolation(violation)
+		switch violation.Kind {
+		case "rate_limit":
+			return i18n.NewError("order.rate_limited", s.maxOrdersPerSecond)
+		case "duplicate":
+			return i18n.NewError("order.duplicate", s.duplicateWindow)
+		}
+		return i18n.NewError("order.symbol_interval", order.Symbol, s.minSymbolInterval)
+	}
+	return nil
+}
+
+// CheckBasket applies the order rate limit to a basket, which counts as one
+// order. Its legs skip the symbol interval and duplicate checks.
+func (s *OrderGuardService) CheckBasket(userID, tenantID string) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	recent := s.pruneRecent(userID, now)
+	limited := len(recent) >= s.maxOrdersPerSecond
+	if !limited {
+		s.recentOrders[userID] = append(recent, now)
+	}
+	s.mu.Unlock()
+
+	if !limited {
+		return nil
+	}
+	s.recordViolation(&models.OrderViolation{
+		UserID:   userID,
+		TenantID: tenantID,
+		Kind:     "rate_limit",
+		Detail:   fmt.Sprintf("more than %d orders per second", s.maxOrdersPerSecond),
+		Rejected: true,
+	})
+	return i18n.NewError("order.rate_limited", s.maxOrdersPerSecond)
+}
+
+// pruneRecent drops the user's order times older than a second and returns
+// the rest. Callers hold mu.
+func (s *OrderGuardService) pruneRecent(userID string, now time.Time) []time.Time {
+	recent := s.recentOrders[userID][:0]
+	for _, t := range s.recentOrders[userID] {
+		if now.Sub(t) < time.Second {
+			recent = append(recent, t)
+		}
+	}
+	s.recentOrders[userID] = recent
+	return recent
+}
+
+// pruneAccepted forgets signatures older than the duplicate window once the
+// map grows. Callers hold mu.
+func (s *OrderGuardService) pruneAccepted(now time.Time) {
+	if len(s.lastAccepted) < 1024 {
+		return
+	}
+	for signature, at := range s.lastAccepted {
+		if now.Sub(at) >= s.duplicateWindow {
+			delete(s.lastAccepted, signature)
+		}
+	}
+}
+
+// RecordFill flags a fill that reverses the user's previous fill in the same
+// symbol within the wash trade window. The fill itself is not blocked.
+func (s *OrderGuardService) RecordFill(order *models.Order) {
+	key := order.UserID + "|" + order.Symbol
+
+	s.mu.Lock()
+	prev, ok := s.lastFills[key]
+	s.lastFills[key] = *order
+	s.mu.Unlock()
+
+	if !ok || prev.Type == order.Type || order.Timestamp.Sub(prev.Timestamp) > s.washTradeWindow {
+		return
+	}
+
+	s.recordViolation(&models.OrderViolation{
+		UserID:   order.UserID,
+		TenantID: order.TenantID,
+		Symbol:   order.Symbol,
+		Kind:     "wash_trade",
+		Detail: fmt.Sprintf("%s %g @ $%.2f crossed %s %g @ $%.2f within %v",
+			order.Type, order.Quantity, order.Price, prev.Type, prev.Quantity, prev.Price,
+			order.Timestamp.Sub(prev.Timestamp).Round(time.Second)),
+	})
+}
+
+func (s *OrderGuardService) recordViolation(violation *models.OrderViolation) {
+	violation.CreatedAt = time.Now().UTC()
+	if _, err := s.violationCollection.InsertOne(context.Background(), violation); err != nil {
+		log.Printf("Error recording order violation: %v", err)
+		return
+	}
+	log.Printf("⚠️ Order violation (%s) for user %s on %s: %s",
+		violation.Kind, violation.UserID, violation.Symbol, violation.Detail)
+}
+
+// GetViolations returns the most recent violations, newest first, limited to
+// one tenant unless tenantID is empty
+func (s *OrderGuardService) GetViolations(ctx context.Context, tenantID string, limit int64) ([]models.OrderViolation, error) {
+	filter := bson.M{}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := s.violationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var violations []models.OrderViolation
+	err = cursor.All(ctx, &violations)
+	return violations, err
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Order event types
+const (
+	OrderEventCreated         = "created"
+	OrderEventActivated       = "activated"
+	OrderEventAmended         = "amended"
+	OrderEventTriggered       = "triggered"
+	OrderEventPartiallyFilled = "partially_filled"
+	OrderEventFilled          = "filled"
+	OrderEventFailed          = "failed"
+	OrderEventCancelled       = "cancelled"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+// orderHistory appends order events. An event is written after the change
+// to the order document it describes, in the same transaction where there
+// is one; elsewhere a failed append is logged and the order change stands.
+type orderHistory struct {
+	eventCollection *mongo.Collection
+}
+
+func newOrderHistory() *orderHistory {
+	return &orderHistory{eventCollection: config.GetCollection("order_events")}
+}
+
+// created records a new order with its whole document
+func (h *orderHistory) created(ctx context.Context, eventType string, order *models.Order) error {
+	snapshot := *order
+	_, err := h.eventCollection.InsertOne(ctx, models.OrderEvent{
+		OrderID:  order.ID.Hex(),
+		UserID:   order.UserID,
+		Type:     eventType,
+		Snapshot: &snapshot,
+		At:       order.Timestamp,
+	})
+	return err
+}
+
+// changed records the fields an update set on an order. Failures are
+// logged rather than returned, since the order has already changed.
+func (h *orderHistory) changed(ctx context.Context, order *models.Order, eventType string, changes bson.M, cause string) {
+	_, err := h.eventCollection.InsertOne(ctx, models.OrderEvent{
+		OrderID: order.ID.Hex(),
+		UserID:  order.UserID,
+		Type:    eventType,
+		Changes: changes,
+		Cause:   cause,
+		At:      time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error recording %s event of order %s: %v", eventType, order.ID.Hex(), err)
+	}
+}
+
+// cancelAll cancels the open orders in collection that match filter and
+// records each cancellation
+func (h *orderHistory) cancelAll(ctx context.Context, collection *mongo.Collection, filter bson.M, reason string) error {
+	filter["status"] = bson.M{"$in": openOrderStatuses}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return err
+	}
+
+	changes := bson.M{"status": "cancelled", "cancel_reason": reason}
+	for _, order := range orders {
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": order.ID, "status": bson.M{"$in": openOrderStatuses}},
+			bson.M{"$set": changes},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 {
+			h.changed(ctx, &order, OrderEventCancelled, changes, "")
+		}
+	}
+	return nil
+}
+
+// OrderHistoryService reads order event streams, for a user's own orders
+// or, with an empty userID, for any order
+type OrderHistoryService struct {
+	history *orderHistory
+}
+
+func NewOrderHistoryService() *OrderHistoryService {
+	return &OrderHistoryService{history: newOrderHistory()}
+}
+
+// EnsureIndexes creates the index order events are read by
+func (s *OrderHistoryService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.history.eventCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "at", Value: 1}},
+	})
+	return err
+}
+
+// Events returns an order's events, oldest first
+func (s *OrderHistoryService) Events(ctx context.Context, orderID, userID string) ([]models.OrderEvent, error) {
+	filter := bson.M{"order_id": orderID}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	cursor, err := s.history.eventCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	events := []models.OrderEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return events, nil
+}
+
+// AsOf rebuilds the order as it stood at the given time by applying its
+// events up to then to the snapshot it was created with. It returns
+// ErrOrderNotFound for an order that did not exist yet.
+func (s *OrderHistoryService) AsOf(ctx context.Context, orderID, userID string, at time.Time) (*models.Order, error) {
+	events, err := s.Events(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	for _, event := range events {
+		if event.At.After(at) {
+			break
+		}
+		if event.Snapshot != nil {
+			raw, err := bson.Marshal(event.Snapshot)
+			if err != nil {
+				return nil, err
+			}
+			doc = bson.M{}
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if doc == nil {
+			continue // Orders from before events were kept have no snapshot
+		}
+		for field, value := range event.Changes {
+			setPath(doc, field, value)
+		}
+	}
+	if doc == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var order models.Order
+	err = bson.Unmarshal(raw, &order)
+	return &order, err
+}
+
+// setPath sets a field by its dotted path, as $set does
+func setPath(doc bson.M, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(bson.M)
+		if !ok {
+			next = bson.M{}
+			if d, isD := doc[part].(bson.D); isD {
+				for _, e := range d {
+					next[e.Key] = e.Value
+				}
+			}
+			doc[part] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = value
+}
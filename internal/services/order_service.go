@@ -2,192 +2,712 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"trading-simulator/internal/i18n"
 	"trading-simulator/internal/models"
 	"trading-simulator/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type OrderService struct {
 	orderCollection     *mongo.Collection
+	executionCollection *mongo.Collection
 	portfolioCollection *mongo.Collection
 	userCollection      *mongo.Collection
+	feeRate             float64 // Fraction of notional charged per fill, from TRADE_FEE_BPS
 	marketService       *MarketDataService
+	symbolService       *SymbolService
+	guard               *OrderGuardService
+	competitionService  *CompetitionService
+	events              *EventBus
+	outbox              *OutboxService
+	dataModes           *DataModeService
+	flags               *FeatureFlagService
+	journal             *journal
+	history             *orderHistory
+
+	transactionsUnsupported atomic.Bool // Set once a standalone server rejects transactions
 }
 
-func NewOrderService(marketService *MarketDataService) *OrderService {
+func NewOrderService(marketService *MarketDataService, symbolService *SymbolService, guard *OrderGuardService, competitionService *CompetitionService, events *EventBus, outbox *OutboxService, dataModes *DataModeService, flags *FeatureFlagService) *OrderService {
 	return &OrderService{
 		orderCollection:     config.GetCollection("orders"),
+		executionCollection: config.GetCollection("executions"),
+		feeRate:             float64(config.GetEnvInt("TRADE_FEE_BPS", 0)) / 10000,
 		portfolioCollection: config.GetCollection("portfolio"),
 		userCollection:      config.GetCollection("users"),
 		marketService:       marketService,
+		symbolService:       symbolService,
+		guard:               guard,
+		competitionService:  competitionService,
+		events:              events,
+		outbox:              outbox,
+		dataModes:           dataModes,
+		flags:               flags,
+		journal:             newJournal(),
+		history:             newOrderHistory(),
+	}
+}
+
+// fillOrder executes an order immediately. User orders reach it through
+// OrderEngine; system-generated orders such as triggered stops skip throttling.
+func (s *OrderService) fillOrder(ctx context.Context, order *models.Order) error {
+	allowShort, err := s.prepareFill(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	// The fill event is written with the fill, so a crash cannot lose it;
+	// the outbox dispatcher publishes it to the event bus
+	err = s.runInTransaction(ctx, func(ctx context.Context) error {
+		return s.applyFill(ctx, order, allowShort)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.guard.RecordFill(order)
+	return nil
+}
+
+// fillError is returned by fillAll with the index of the order that failed
+type fillError struct {
+	Index int
+	Err   error
+}
+
+func (e *fillError) Error() string { return e.Err.Error() }
+func (e *fillError) Unwrap() error { return e.Err }
+
+// fillAll fills several orders in one transaction, in the order given, so
+// either all of them fill or none do. On a standalone server, which cannot
+// run transactions, nothing fills and errTransactionsUnsupported is returned.
+func (s *OrderService) fillAll(ctx context.Context, orders []*models.Order) error {
+	allowShort := make([]bool, len(orders))
+	for i, order := range orders {
+		var err error
+		if allowShort[i], err = s.prepareFill(ctx, order); err != nil {
+			return &fillError{Index: i, Err: err}
+		}
+	}
+
+	err := s.transact(ctx, func(ctx context.Context) error {
+		for i, order := range orders {
+			if err := s.applyFill(ctx, order, allowShort[i]); err != nil {
+				return &fillError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		s.guard.RecordFill(order)
 	}
+	return nil
 }
 
-func (s *OrderService) PlaceOrder(order *models.Order) error {
+// prepareFill prices the order and applies symbol and competition rules. It
+// reports whether the account may sell short.
+func (s *OrderService) prepareFill(ctx context.Context, order *models.Order) (bool, error) {
+	if err := s.applyBestPrice(order); err != nil {
+		return false, err
+	}
+	if err := s.symbolService.ApplyRules(order); err != nil {
+		return false, err
+	}
+
+	allowShort := false
+	if order.CompetitionID != "" {
+		rules, err := s.competitionService.ValidateOrder(ctx, order)
+		if err != nil {
+			return false, err
+		}
+		allowShort = rules.AllowShorting
+	}
+
 	order.ID = primitive.NewObjectID()
-	order.Timestamp = time.Now()
+	order.Timestamp = time.Now().UTC()
 	order.Status = "filled"
+	order.TradeID = newTradeID()
+	order.Fees = math.Round(order.Price*order.Quantity*s.feeRate*100) / 100
+	if order.RequestedPrice > 0 {
+		improvement := (order.RequestedPrice - order.Price) * order.Quantity
+		if order.Type == "sell" {
+			improvement = -improvement
+		}
+		order.PriceImprovement = math.Round(improvement*100) / 100
+	}
 
-	switch order.Type {
-	case "buy":
-		return s.executeBuyOrder(order)
-	case "sell":
-		return s.executeSellOrder(order)
-	default:
-		return fmt.Errorf("invalid order type: %s", order.Type)
+	if order.Type != "buy" && order.Type != "sell" {
+		return false, i18n.NewError("order.invalid_side", order.Type)
+	}
+	return allowShort, nil
+}
+
+// applyFill moves the cash and shares of a prepared order, records the
+// execution and queues the fill event. Callers run it in a transaction.
+func (s *OrderService) applyFill(ctx context.Context, order *models.Order, allowShort bool) error {
+	var err error
+	if order.Type == "buy" {
+		err = s.executeBuyOrder(ctx, order)
+	} else {
+		err = s.executeSellOrder(ctx, order, allowShort)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.recordExecution(ctx, order); err != nil {
+		return err
+	}
+	// Immediate orders are created filled, so one event covers both
+	if err := s.history.created(ctx, OrderEventFilled, order); err != nil {
+		return err
+	}
+	return s.outbox.Enqueue(ctx, EventOrderFilled, order.UserID, *order)
+}
+
+// errTransactionsUnsupported is returned by transact on a standalone server
+var errTransactionsUnsupported = errors.New("MongoDB does not support transactions")
+
+// runInTransaction runs fn in a multi-document transaction. Standalone
+// servers cannot run transactions, so there fn runs without one, and without
+// cancellation since a half-applied fill could not be rolled back.
+func (s *OrderService) runInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := s.transact(ctx, fn)
+	if errors.Is(err, errTransactionsUnsupported) {
+		return fn(context.WithoutCancel(ctx))
+	}
+	return err
+}
+
+// transact runs fn in a multi-document transaction, or returns
+// errTransactionsUnsupported without applying anything when the server
+// cannot run one.
+func (s *OrderService) transact(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactionsUnsupported.Load() {
+		return errTransactionsUnsupported
+	}
+
+	session, err := config.DB.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	}, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == 20 { // IllegalOperation
+		if s.transactionsUnsupported.CompareAndSwap(false, true) {
+			log.Println("⚠️ MongoDB does not support transactions, fills will not be atomic")
+		}
+		return errTransactionsUnsupported
 	}
+	return err
 }
 
-func (s *OrderService) executeBuyOrder(order *models.Order) error {
-	cash := s.GetCashBalance(order.UserID)
-	cost := order.Price * float64(order.Quantity)
-	if cash < cost {
-		return fmt.Errorf("insufficient funds. have $%.2f, need $%.2f", cash, cost)
+func (s *OrderService) executeBuyOrder(ctx context.Context, order *models.Order) error {
+	cost := order.Price*order.Quantity + order.Fees
+	// Competition buying power is checked against the leverage rules instead
+	if order.CompetitionID == "" {
+		cash := s.GetBuyingPower(ctx, order.UserID)
+		if cash < cost {
+			return i18n.NewError("order.insufficient_funds", cash, cost)
+		}
 	}
 
-	_, err := s.orderCollection.InsertOne(context.Background(), order)
+	_, err := s.orderCollection.InsertOne(ctx, order)
 	if err != nil {
 		return err
 	}
 
 	var pos models.Portfolio
-	err = s.portfolioCollection.FindOne(context.Background(), bson.M{
-		"user_id": order.UserID,
-		"symbol":  order.Symbol,
-	}).Decode(&pos)
+	err = s.portfolioCollection.FindOne(ctx,
+		positionFilter(order.UserID, order.CompetitionID, order.Symbol),
+	).Decode(&pos)
 
+	method := costBasisMethod(ctx, s.userCollection, order.UserID)
+	costBefore := openCost(pos)
 	if err == mongo.ErrNoDocuments {
 		pos = models.Portfolio{
-			ID:      primitive.NewObjectID(),
-			UserID:  order.UserID,
-			Symbol:  order.Symbol,
-			Shares:  order.Quantity,
-			AvgCost: order.Price,
+			ID:            primitive.NewObjectID(),
+			UserID:        order.UserID,
+			Symbol:        order.Symbol,
+			CompetitionID: order.CompetitionID,
+			TenantID:      order.TenantID,
 		}
-		_, err = s.portfolioCollection.InsertOne(context.Background(), pos)
+		applyLots(&pos, order.Type, order.Quantity, order.Price, order.Timestamp, method)
+		_, err = s.portfolioCollection.InsertOne(ctx, pos)
 	} else if err == nil {
-		totalCost := (pos.AvgCost * float64(pos.Shares)) + cost
-		totalShares := pos.Shares + order.Quantity
-		newAvg := totalCost / float64(totalShares)
+		applyLots(&pos, order.Type, order.Quantity, order.Price, order.Timestamp, method)
+		if pos.Shares == 0 {
+			_, err = s.portfolioCollection.DeleteOne(ctx, bson.M{"_id": pos.ID})
+		} else {
+			_, err = s.portfolioCollection.UpdateOne(
+				ctx,
+				bson.M{"_id": pos.ID},
+				bson.M{"$set": bson.M{
+					"shares":   pos.Shares,
+					"avg_cost": pos.AvgCost,
+					"lots":     pos.Lots,
+				}},
+			)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.journal.post(ctx, tradeEntry(order, costBefore, openCost(pos)))
+	return err
+}
+
+// executeSellOrder sells from a position. When allowShort is set the position
+// may go negative; otherwise the user must hold enough shares.
+func (s *OrderService) executeSellOrder(ctx context.Context, order *models.Order, allowShort bool) error {
+	var pos models.Portfolio
+	err := s.portfolioCollection.FindOne(ctx,
+		positionFilter(order.UserID, order.CompetitionID, order.Symbol),
+	).Decode(&pos)
+	if err == mongo.ErrNoDocuments {
+		if !allowShort {
+			return i18n.NewError("order.no_position", order.Symbol)
+		}
+		pos = models.Portfolio{
+			UserID:        order.UserID,
+			Symbol:        order.Symbol,
+			CompetitionID: order.CompetitionID,
+			TenantID:      order.TenantID,
+		}
+	} else if err != nil {
+		return err
+	}
+	if !allowShort && pos.Shares < order.Quantity {
+		return i18n.NewError("order.insufficient_shares", pos.Shares, order.Quantity)
+	}
+	if borrowed := roundQuantity(order.Quantity - math.Max(pos.Shares, 0)); borrowed > 0 {
+		if err := s.checkBorrow(ctx, order.Symbol, borrowed); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.orderCollection.InsertOne(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	costBefore := openCost(pos)
+	applyLots(&pos, order.Type, order.Quantity, order.Price, order.Timestamp, costBasisMethod(ctx, s.userCollection, order.UserID))
 
+	switch {
+	case pos.ID.IsZero():
+		pos.ID = primitive.NewObjectID()
+		_, err = s.portfolioCollection.InsertOne(ctx, pos)
+	case pos.Shares == 0:
+		_, err = s.portfolioCollection.DeleteOne(ctx, bson.M{"_id": pos.ID})
+	default:
 		_, err = s.portfolioCollection.UpdateOne(
-			context.Background(),
+			ctx,
 			bson.M{"_id": pos.ID},
-			bson.M{"$set": bson.M{
-				"shares":   totalShares,
-				"avg_cost": newAvg,
-			}},
+			bson.M{"$set": bson.M{"shares": pos.Shares, "avg_cost": pos.AvgCost, "lots": pos.Lots}},
 		)
 	}
 	if err != nil {
 		return err
 	}
 
-	userID, _ := primitive.ObjectIDFromHex(order.UserID)
-	_, err = s.userCollection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": userID},
-		bson.M{"$inc": bson.M{"cash_balance": -cost}},
-	)
+	_, err = s.journal.post(ctx, tradeEntry(order, costBefore, openCost(pos)))
 	return err
 }
 
-func (s *OrderService) executeSellOrder(order *models.Order) error {
-	var pos models.Portfolio
-	err := s.portfolioCollection.FindOne(context.Background(), bson.M{
-		"user_id": order.UserID,
-		"symbol":  order.Symbol,
-	}).Decode(&pos)
-	if err == mongo.ErrNoDocuments {
-		return fmt.Errorf("you own no %s", order.Symbol)
+// checkBorrow rejects a short sale of a hard-to-borrow symbol, or one that
+// would take the shares short across all accounts past the symbol's borrow limit
+func (s *OrderService) checkBorrow(ctx context.Context, symbol string, shares float64) error {
+	info := s.symbolService.GetSymbol(symbol)
+	if info.HardToBorrow {
+		return i18n.NewError("order.hard_to_borrow", symbol)
 	}
+	if info.BorrowLimit <= 0 {
+		return nil
+	}
+	short, err := s.ShortInterest(ctx, symbol)
 	if err != nil {
 		return err
 	}
-	if pos.Shares < order.Quantity {
-		return fmt.Errorf("insufficient shares: have %d, want %d", pos.Shares, order.Quantity)
+	if available := roundQuantity(info.BorrowLimit - short); shares > available {
+		return i18n.NewError("order.borrow_unavailable", math.Max(available, 0), symbol)
 	}
+	return nil
+}
 
-	_, err = s.orderCollection.InsertOne(context.Background(), order)
+// applyBestPrice sets the fill price from the best bid and ask in the user's
+// data mode: buys fill at the ask and sells at the bid. A limit order fills
+// there only when that is at or better than its limit, so a marketable limit
+// gets the improvement; one that is not marketable is rejected, since limits
+// do not rest. Without a quote the order fills at its submitted price.
+// Competition orders always fill at simulated prices, so every entrant
+// trades the same market.
+func (s *OrderService) applyBestPrice(order *models.Order) error {
+	if order.RequestedPrice == 0 {
+		order.RequestedPrice = order.Price
+	}
+	mode := DataModeMock
+	if order.CompetitionID == "" {
+		mode = s.DataMode(order.UserID)
+	}
+	bid, ask, source, ok := s.marketService.GetQuoteIn(order.Symbol, mode)
+	if !ok {
+		return nil
+	}
+	order.PriceSource = source
+	best := ask
+	if order.Type == "sell" {
+		best = bid
+	}
+	best = roundPrice(best, s.symbolService.GetSymbol(order.Symbol))
+
+	if order.OrderType == "limit" {
+		if (order.Type == "buy" && best > order.RequestedPrice) || (order.Type == "sell" && best < order.RequestedPrice) {
+			return i18n.NewError("order.limit_not_marketable", order.Type, order.RequestedPrice, best)
+		}
+	}
+	order.Price = best
+	return nil
+}
+
+// ShortInterest returns the shares of a symbol held short across all accounts
+func (s *OrderService) ShortInterest(ctx context.Context, symbol string) (float64, error) {
+	cursor, err := s.portfolioCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"symbol": symbol, "shares": bson.M{"$lt": 0}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "shares": bson.M{"$sum": "$shares"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var totals []struct {
+		Shares float64 `bson:"shares"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil || len(totals) == 0 {
+		return 0, err
+	}
+	return -totals[0].Shares, nil
+}
+
+// recordExecution writes the immutable execution record of a fill
+func (s *OrderService) recordExecution(ctx context.Context, order *models.Order) error {
+	// Only orders that rested before filling made liquidity; a limit that
+	// fills on arrival takes it like a market order
+	liquidity := "taker"
+	if order.ParentOrderID != "" {
+		liquidity = "maker"
+	}
+	_, err := s.executionCollection.InsertOne(ctx, models.Execution{
+		TradeID:          order.TradeID,
+		OrderID:          order.ID.Hex(),
+		UserID:           order.UserID,
+		Symbol:           order.Symbol,
+		Side:             order.Type,
+		Quantity:         order.Quantity,
+		Price:            order.Price,
+		Fees:             order.Fees,
+		RequestedPrice:   order.RequestedPrice,
+		PriceImprovement: order.PriceImprovement,
+		Liquidity:        liquidity,
+		CompetitionID:    order.CompetitionID,
+		TenantID:         order.TenantID,
+		StrategyID:       order.StrategyID,
+		ExecutedAt:       order.Timestamp,
+	})
+	return err
+}
+
+// newTradeID returns a unique, sortable trade ID such as T-20260114-65a3f0c2e1d4b7a9c0f12345
+func newTradeID() string {
+	id := primitive.NewObjectID()
+	return "T-" + id.Timestamp().UTC().Format("20060102") + "-" + id.Hex()
+}
+
+// GetExecutions returns the user's executions, newest first, optionally for
+// one symbol or order
+func (s *OrderService) GetExecutions(ctx context.Context, userID, symbol, orderID string, limit int64) ([]models.Execution, error) {
+	filter := bson.M{"user_id": userID}
+	if symbol != "" {
+		filter["symbol"] = strings.ToUpper(symbol)
+	}
+	if orderID != "" {
+		filter["order_id"] = orderID
+	}
+	opts := options.Find().SetSort(bson.M{"executed_at": -1}).SetLimit(limit)
+	cursor, err := s.executionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	executions := []models.Execution{}
+	err = cursor.All(ctx, &executions)
+	return executions, err
+}
+
+// PostJournal records a balanced journal entry and applies its cash to the
+// account it belongs to. It reports false without changing anything if the
+// entry was already posted.
+func (s *OrderService) PostJournal(ctx context.Context, entry models.JournalEntry) (bool, error) {
+	return s.journal.post(ctx, entry)
+}
+
+// ReserveCash holds cash in the user's main account for an open buy order.
+// The check and the hold happen in one update so concurrent orders cannot
+// reserve more than the account's buying power.
+func (s *OrderService) ReserveCash(ctx context.Context, userIDHex string, amount float64) error {
+	userID, err := primitive.ObjectIDFromHex(userIDHex)
 	if err != nil {
 		return err
 	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return err
+	}
+	// What margin lets the account spend beyond its cash
+	credit := math.Max(s.buyingPowerOf(ctx, user)-(user.CashBalance-user.ReservedCash), 0)
 
-	newShares := pos.Shares - order.Quantity
-	if newShares == 0 {
-		_, err = s.portfolioCollection.DeleteOne(context.Background(), bson.M{"_id": pos.ID})
-	} else {
-		_, err = s.portfolioCollection.UpdateOne(
-			context.Background(),
-			bson.M{"_id": pos.ID},
-			bson.M{"$set": bson.M{"shares": newShares}},
-		)
+	result, err := s.userCollection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": userID,
+			"$expr": bson.M{"$gte": bson.A{
+				bson.M{"$subtract": bson.A{"$cash_balance", bson.M{"$ifNull": bson.A{"$reserved_cash", 0}}}},
+				amount - credit,
+			}},
+		},
+		bson.M{"$inc": bson.M{"reserved_cash": amount}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return i18n.NewError("order.insufficient_buying_power", amount, s.GetBuyingPower(ctx, userIDHex))
 	}
+	return nil
+}
+
+// ReleaseCash returns a hold made by ReserveCash
+func (s *OrderService) ReleaseCash(ctx context.Context, userIDHex string, amount float64) error {
+	userID, err := primitive.ObjectIDFromHex(userIDHex)
 	if err != nil {
 		return err
 	}
 
-	revenue := order.Price * float64(order.Quantity)
-	userID, _ := primitive.ObjectIDFromHex(order.UserID)
 	_, err = s.userCollection.UpdateOne(
-		context.Background(),
+		ctx,
 		bson.M{"_id": userID},
-		bson.M{"$inc": bson.M{"cash_balance": revenue}},
+		bson.M{"$inc": bson.M{"reserved_cash": -amount}},
 	)
 	return err
 }
 
-func (s *OrderService) GetUserPortfolio(userID string) ([]models.Portfolio, error) {
-	cur, err := s.portfolioCollection.Find(context.Background(), bson.M{"user_id": userID})
+// SetDRIP turns dividend reinvestment on or off for a position in the user's
+// main account
+func (s *OrderService) SetDRIP(ctx context.Context, userID, symbol string, enabled bool) (*models.Portfolio, error) {
+	symbol = strings.ToUpper(symbol)
+	var pos models.Portfolio
+	err := s.portfolioCollection.FindOneAndUpdate(ctx,
+		positionFilter(userID, "", symbol),
+		bson.M{"$set": bson.M{"drip": enabled}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&pos)
+	if err == mongo.ErrNoDocuments {
+		return nil, i18n.NewError("order.no_position", symbol)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(context.Background())
+	return &pos, nil
+}
+
+// RecordReinvestment writes the execution of a dividend reinvestment into
+// an existing position
+func (s *OrderService) RecordReinvestment(ctx context.Context, pos models.Portfolio, quantity, price float64) error {
+	_, err := s.executionCollection.InsertOne(ctx, models.Execution{
+		TradeID:       newTradeID(),
+		UserID:        pos.UserID,
+		Symbol:        pos.Symbol,
+		Side:          "buy",
+		Quantity:      quantity,
+		Price:         price,
+		Liquidity:     "drip",
+		CompetitionID: pos.CompetitionID,
+		TenantID:      pos.TenantID,
+		ExecutedAt:    time.Now().UTC(),
+	})
+	return err
+}
+
+// RecordSeed writes an execution for a position a teacher placed in a
+// student's account, so the position's history adds up to its shares. No
+// cash changed hands; the "seed" liquidity tells it apart from a buy.
+func (s *OrderService) RecordSeed(ctx context.Context, pos models.Portfolio) error {
+	_, err := s.executionCollection.InsertOne(ctx, models.Execution{
+		TradeID:    newTradeID(),
+		UserID:     pos.UserID,
+		Symbol:     pos.Symbol,
+		Side:       "buy",
+		Quantity:   pos.Shares,
+		Price:      pos.AvgCost,
+		Liquidity:  "seed",
+		TenantID:   pos.TenantID,
+		ExecutedAt: pos.ID.Timestamp(),
+	})
+	return err
+}
+
+// positionFilter matches a user's positions in either their main account
+// (competitionID empty) or a competition account. Symbol is optional.
+func positionFilter(userID, competitionID, symbol string) bson.M {
+	filter := bson.M{"user_id": userID, "competition_id": nil}
+	if competitionID != "" {
+		filter["competition_id"] = competitionID
+	}
+	if symbol != "" {
+		filter["symbol"] = symbol
+	}
+	return filter
+}
+
+func (s *OrderService) GetUserPortfolio(ctx context.Context, userID string) ([]models.Portfolio, error) {
+	cur, err := s.portfolioCollection.Find(ctx, positionFilter(userID, "", ""))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
 	var list []models.Portfolio
-	_ = cur.All(context.Background(), &list)
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
 	return list, nil
 }
 
-func (s *OrderService) GetUserOrders(userID string) ([]models.Order, error) {
-	cur, err := s.orderCollection.Find(context.Background(), bson.M{"user_id": userID})
+func (s *OrderService) GetUserOrders(ctx context.Context, userID string) ([]models.Order, error) {
+	cur, err := s.orderCollection.Find(ctx, bson.M{"user_id": userID})
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(context.Background())
+	defer cur.Close(ctx)
 	var list []models.Order
-	_ = cur.All(context.Background(), &list)
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
 	return list, nil
 }
 
-func (s *OrderService) GetCashBalance(userID string) float64 {
+func (s *OrderService) GetCashBalance(ctx context.Context, userID string) float64 {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return 10000.0
 	}
 	var u models.User
-	err = s.userCollection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&u)
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&u)
 	if err != nil {
 		return 10000.0
 	}
 	return u.CashBalance
 }
 
-func (s *OrderService) GetTotalPortfolioValue(userID string) float64 {
-	pos, err := s.GetUserPortfolio(userID)
+// GetReservedCash returns the cash held for the user's open buy orders
+func (s *OrderService) GetReservedCash(ctx context.Context, userID string) float64 {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0
+	}
+	var u models.User
+	err = s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&u)
+	if err != nil {
+		return 0
+	}
+	return u.ReservedCash
+}
+
+// GetBuyingPower returns what the user can spend on new orders after holds.
+// The account summary shows it and every order is checked against it.
+func (s *OrderService) GetBuyingPower(ctx context.Context, userID string) float64 {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0
+	}
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return 0
+	}
+	return s.buyingPowerOf(ctx, user)
+}
+
+// buyingPowerOf values the user's positions at their data mode's prices when
+// margin counts toward their buying power
+func (s *OrderService) buyingPowerOf(ctx context.Context, user models.User) float64 {
+	userID := user.ID.Hex()
+	if !s.flags.IsEnabledFor(FeatureMargin, userID) {
+		return buyingPower(user, nil, nil, false)
+	}
+	positions, err := s.GetUserPortfolio(ctx, userID)
+	if err != nil {
+		return buyingPower(user, nil, nil, false)
+	}
+	mode := s.DataMode(userID)
+	prices := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		price, ok := s.marketService.GetMarkPrice(pos.Symbol, mode)
+		if !ok {
+			price = pos.AvgCost
+		}
+		prices[pos.Symbol] = price
+	}
+	return buyingPower(user, positions, prices, true)
+}
+
+// buyingPower is what an account can spend after holds: its cash, or with
+// margin its excess equity at the initial margin rate, valuing positions at
+// the prices given
+func buyingPower(user models.User, positions []models.Portfolio, prices map[string]float64, margin bool) float64 {
+	if !margin {
+		return user.CashBalance - user.ReservedCash
+	}
+	return marginSummary(user.CashBalance, positions, prices).Excess/initialMarginRate - user.ReservedCash
+}
+
+// DataMode returns the data mode the user trades and is valued in
+func (s *OrderService) DataMode(userID string) string {
+	return s.dataModes.ModeFor(userID)
+}
+
+// GetTotalPortfolioValue values the user's positions at the last prices of
+// their data mode, or at cost for symbols that have no price yet
+func (s *OrderService) GetTotalPortfolioValue(ctx context.Context, userID string) float64 {
+	pos, err := s.GetUserPortfolio(ctx, userID)
 	if err != nil {
 		return 0
 	}
+	mode := s.DataMode(userID)
 	val := 0.0
 	for _, p := range pos {
-		stock, err := s.marketService.GetMockStockPrice(p.Symbol)
-		if err == nil {
-			val += stock.Price * float64(p.Shares)
+		price, ok := s.marketService.GetMarkPrice(p.Symbol, mode)
+		if !ok {
+			price = p.AvgCost
 		}
+		val += price * p.Shares
 	}
 	return val
 }
\ No newline at end of file
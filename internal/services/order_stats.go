@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Number of days returned as the busiest
+const busiestDaysLimit = 5
+
+// ErrOrderStatsBucket is returned for an unknown bucket size
+var ErrOrderStatsBucket = errors.New("bucket must be day, week or month")
+
+// Date formats of the order stats buckets, in $dateToString syntax
+var orderStatsBuckets = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V", // ISO week
+	"month": "%Y-%m",
+}
+
+// GetOrderStats summarizes the user's orders in one aggregation, bucketing
+// them by day, week or month in the filter's timezone on the server
+func (s *OrderService) GetOrderStats(ctx context.Context, userID string, filter models.OrderStatsFilter) (*models.OrderStats, error) {
+	if filter.Bucket == "" {
+		filter.Bucket = "day"
+	}
+	format, ok := orderStatsBuckets[filter.Bucket]
+	if !ok {
+		return nil, ErrOrderStatsBucket
+	}
+	if filter.Location == nil {
+		filter.Location = time.UTC
+	}
+
+	match := bson.M{"user_id": userID}
+	window := bson.M{}
+	if !filter.From.IsZero() {
+		window["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		window["$lt"] = filter.To
+	}
+	if len(window) > 0 {
+		match["timestamp"] = window
+	}
+
+	filled := bson.M{"$eq": bson.A{"$status", "filled"}}
+	tradedValue := bson.M{"$sum": bson.M{"$cond": bson.A{filled, bson.M{"$multiply": bson.A{"$price", "$quantity"}}, 0}}}
+	filledCount := bson.M{"$sum": bson.M{"$cond": bson.A{filled, 1, 0}}}
+	count := func(field string) mongo.Pipeline {
+		return mongo.Pipeline{{{Key: "$group", Value: bson.M{"_id": field, "count": bson.M{"$sum": 1}}}}}
+	}
+	bucket := func(format string) bson.D {
+		return bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"date":     "$timestamp",
+				"format":   format,
+				"timezone": filter.Location.String(),
+			}},
+			"orders":       bson.M{"$sum": 1},
+			"filled":       filledCount,
+			"traded_value": tradedValue,
+		}}}
+	}
+
+	cursor, err := s.orderCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"by_status":     count("$status"),
+			"by_side":       count("$type"),
+			"by_order_type": count("$order_type"),
+			"by_symbol": mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{"_id": "$symbol", "order_count": bson.M{"$sum": 1}, "volume": tradedValue}}},
+				{{Key: "$sort", Value: bson.D{{Key: "order_count", Value: -1}, {Key: "_id", Value: 1}}}},
+			},
+			"buckets": mongo.Pipeline{
+				bucket(format),
+				{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+			},
+			"busiest_days": mongo.Pipeline{
+				bucket(orderStatsBuckets["day"]),
+				{{Key: "$sort", Value: bson.D{{Key: "orders", Value: -1}, {Key: "_id", Value: -1}}}},
+				{{Key: "$limit", Value: busiestDaysLimit}},
+			},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	type groupCount struct {
+		Key   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	var facets []struct {
+		ByStatus    []groupCount            `bson:"by_status"`
+		BySide      []groupCount            `bson:"by_side"`
+		ByOrderType []groupCount            `bson:"by_order_type"`
+		BySymbol    []models.SymbolActivity `bson:"by_symbol"`
+		Buckets     []models.OrderBucket    `bson:"buckets"`
+		BusiestDays []models.OrderBucket    `bson:"busiest_days"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+
+	stats := &models.OrderStats{
+		ByStatus:    make(map[string]int64),
+		BySide:      make(map[string]int64),
+		ByOrderType: make(map[string]int64),
+		BySymbol:    []models.SymbolActivity{},
+		Bucket:      filter.Bucket,
+		Timezone:    filter.Location.String(),
+		Buckets:     []models.OrderBucket{},
+		BusiestDays: []models.OrderBucket{},
+	}
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	result := facets[0]
+	for _, group := range result.ByStatus {
+		stats.ByStatus[group.Key] = group.Count
+		stats.TotalOrders += group.Count
+	}
+	for _, group := range result.BySide {
+		stats.BySide[group.Key] = group.Count
+	}
+	for _, group := range result.ByOrderType {
+		stats.ByOrderType[group.Key] = group.Count
+	}
+	for _, symbol := range result.BySymbol {
+		symbol.Volume = round2(symbol.Volume)
+		stats.TradedValue += symbol.Volume
+		stats.BySymbol = append(stats.BySymbol, symbol)
+	}
+	stats.TradedValue = round2(stats.TradedValue)
+	for _, buckets := range []*[]models.OrderBucket{&result.Buckets, &result.BusiestDays} {
+		for i := range *buckets {
+			(*buckets)[i].TradedValue = round2((*buckets)[i].TradedValue)
+		}
+	}
+	if result.Buckets != nil {
+		stats.Buckets = result.Buckets
+	}
+	if result.BusiestDays != nil {
+		stats.BusiestDays = result.BusiestDays
+	}
+	return stats, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a dispatcher owns an event before another may retry it
+const outboxLease = 30 * time.Second
+
+// Delivered events are kept this long for troubleshooting
+const outboxRetention = 24 * time.Hour
+
+// OutboxService stores events in the same transaction as the write that
+// produced them and delivers them to the event bus at least once. An event
+// that fails OUTBOX_MAX_ATTEMPTS times (default 5) is dead-lettered: it is
+// kept with status "dead" and its last error, and no longer retried.
+type OutboxService struct {
+	outboxCollection *mongo.Collection
+	events           *EventBus
+	maxAttempts      int
+}
+
+func NewOutboxService(events *EventBus) *OutboxService {
+	return &OutboxService{
+		outboxCollection: config.GetCollection("outbox"),
+		events:           events,
+		maxAttempts:      max(config.GetEnvInt("OUTBOX_MAX_ATTEMPTS", 5), 1),
+	}
+}
+
+// Enqueue stores an event for delivery. Pass the session context of the
+// surrounding transaction so the event commits or aborts with the write.
+func (s *OutboxService) Enqueue(ctx context.Context, topic, userID string, payload interface{}) error {
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = s.outboxCollection.InsertOne(ctx, models.OutboxEvent{
+		ID:        primitive.NewObjectID(),
+		Topic:     topic,
+		UserID:    userID,
+		Payload:   raw,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC(),
+	})
+	return err
+}
+
+// DispatchPending delivers every pending event in creation order. Each event
+// is leased before delivery so several instances can dispatch at once; an
+// event whose dispatcher crashed is retried once its lease expires, until it
+// runs out of attempts.
+func (s *OutboxService) DispatchPending() {
+	for {
+		now := time.Now()
+		var event models.OutboxEvent
+		err := s.outboxCollection.FindOneAndUpdate(context.Background(),
+			bson.M{"status": "pending", "locked_until": bson.M{"$lte": now}},
+			bson.M{
+				"$set": bson.M{"locked_until": now.Add(outboxLease)},
+				"$inc": bson.M{"attempts": 1},
+			},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&event)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			log.Printf("Error claiming outbox event: %v", err)
+			return
+		}
+
+		// Attempts beyond the last were claims whose dispatcher never finished
+		if event.Attempts > s.maxAttempts {
+			reason := event.LastError
+			if reason == "" {
+				reason = "lease expired before delivery"
+			}
+			s.deadLetter(event, reason)
+			continue
+		}
+		payload, err := decodeOutboxPayload(event)
+		if err != nil {
+			s.failed(event, err)
+			continue
+		}
+		s.events.Publish(event.Topic, event.UserID, payload)
+
+		_, err = s.outboxCollection.UpdateOne(context.Background(),
+			bson.M{"_id": event.ID},
+			bson.M{"$set": bson.M{"status": "delivered", "delivered_at": time.Now().UTC()}},
+		)
+		if err != nil {
+			log.Printf("Error marking outbox event %s delivered: %v", event.ID.Hex(), err)
+		}
+	}
+}
+
+// failed records a failed attempt. The event stays leased and is retried
+// once the lease expires, unless that was its last attempt.
+func (s *OutboxService) failed(event models.OutboxEvent, err error) {
+	if event.Attempts >= s.maxAttempts {
+		s.deadLetter(event, err.Error())
+		return
+	}
+	log.Printf("Error delivering outbox event %s (attempt %d of %d): %v", event.ID.Hex(), event.Attempts, s.maxAttempts, err)
+	_, updateErr := s.outboxCollection.UpdateOne(context.Background(),
+		bson.M{"_id": event.ID},
+		bson.M{"$set": bson.M{"last_error": err.Error()}},
+	)
+	if updateErr != nil {
+		log.Printf("Error recording outbox event %s failure: %v", event.ID.Hex(), updateErr)
+	}
+}
+
+// deadLetter stops retrying an event, keeping it for inspection
+func (s *OutboxService) deadLetter(event models.OutboxEvent, reason string) {
+	log.Printf("⚠️ Outbox event %s (%s) dead-lettered after %d attempts: %s", event.ID.Hex(), event.Topic, event.Attempts, reason)
+	_, err := s.outboxCollection.UpdateOne(context.Background(),
+		bson.M{"_id": event.ID},
+		bson.M{"$set": bson.M{"status": "dead", "last_error": reason, "dead_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		log.Printf("Error dead-lettering outbox event %s: %v", event.ID.Hex(), err)
+	}
+}
+
+// PurgeDelivered removes delivered events past the retention window
+func (s *OutboxService) PurgeDelivered() {
+	_, err := s.outboxCollection.DeleteMany(context.Background(), bson.M{
+		"status":       "delivered",
+		"delivered_at": bson.M{"$lt": time.Now().Add(-outboxRetention)},
+	})
+	if err != nil {
+		log.Printf("Error purging outbox: %v", err)
+	}
+}
+
+// decodeOutboxPayload restores the typed payload subscribers expect for the topic
+func decodeOutboxPayload(event models.OutboxEvent) (interface{}, error) {
+	switch event.Topic {
+	case EventOrderPlaced, EventOrderFilled:
+		var order models.Order
+		err := bson.Unmarshal(event.Payload, &order)
+		return order, err
+	case EventUserRegistered:
+		var user models.User
+		err := bson.Unmarshal(event.Payload, &user)
+		return user, err
+	case EventPriceTick:
+		var stock models.Stock
+		err := bson.Unmarshal(event.Payload, &stock)
+		return stock, err
+	}
+	return nil, fmt.Errorf("unknown outbox topic %s", event.Topic)
+}
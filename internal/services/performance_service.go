@@ -0,0 +1,69 @@
+package services
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"trading-simulator/internal/models"
+)
+
+// Load test reports kept for comparison between runs
+const loadTestHistorySize = 20
+
+// PerformanceService reports the server's runtime statistics and keeps the
+// reports submitted by cmd/loadgen so regressions show up on the admin
+// metrics endpoint. The hot paths have Go benchmarks of their own, run with
+// go test -bench.
+type PerformanceService struct {
+	hub     *WebSocketHub
+	tickLog *TickLogService
+	started time.Time
+
+	mu        sync.Mutex
+	loadTests []models.LoadTestReport
+}
+
+func NewPerformanceService(hub *WebSocketHub, tickLog *TickLogService) *PerformanceService {
+	return &PerformanceService{
+		hub:     hub,
+		tickLog: tickLog,
+		started: time.Now(),
+	}
+}
+
+// RecordLoadTest stores a load test report, keeping the most recent ones
+func (s *PerformanceService) RecordLoadTest(report models.LoadTestReport) models.LoadTestReport {
+	report.ReceivedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadTests = append([]models.LoadTestReport{report}, s.loadTests...)
+	if len(s.loadTests) > loadTestHistorySize {
+		s.loadTests = s.loadTests[:loadTestHistorySize]
+	}
+	return report
+}
+
+// GetMetrics returns runtime statistics with the latest load test reports
+func (s *PerformanceService) GetMetrics() *models.PerformanceMetrics {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &models.PerformanceMetrics{
+		UptimeSeconds:         int64(time.Since(s.started) / time.Second),
+		Goroutines:            runtime.NumGoroutine(),
+		HeapAllocBytes:        mem.HeapAlloc,
+		HeapObjects:           mem.HeapObjects,
+		GCCycles:              mem.NumGC,
+		GCPauseTotalNs:        mem.PauseTotalNs,
+		WebSocketConnections:  s.hub.ClientCount(),
+		WebSocketTicksDropped: s.hub.TicksDropped(),
+		WebSocketDelivery:     s.hub.DeliveryStats(),
+		TickLogDropped:        s.tickLog.Dropped(),
+		LoadTests:             append([]models.LoadTestReport{}, s.loadTests...),
+		GeneratedAt:           time.Now().UTC(),
+	}
+}
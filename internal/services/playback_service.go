@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+const (
+	// Longest period one playback request can cover
+	maxPlaybackRange = 24 * time.Hour
+	// Most ticks and most fills loaded for one playback
+	maxPlaybackEvents = 20000
+	// Name of the TTL index that expires samples
+	tickTTLIndex = "timestamp_ttl"
+)
+
+// ErrPlaybackRange is returned for an empty, reversed or too long period
+var ErrPlaybackRange = errors.New("playback needs from before to, at most 24h apart")
+
+// PlaybackService samples every symbol's ticks into the tick_history
+// collection so past periods can be replayed together with the fills made
+// during them. Samples are written in batches and expired by a TTL index.
+type PlaybackService struct {
+	tickCollection      *mongo.Collection
+	executionCollection *mongo.Collection
+	writer              *BatchWriter
+	interval            time.Duration
+	retention           time.Duration
+
+	mu       sync.Mutex
+	recorded map[string]time.Time // Symbol -> time of the last sample
+}
+
+func NewPlaybackService() *PlaybackService {
+	tickCollection := config.GetCollection("tick_history")
+	return &PlaybackService{
+		tickCollection:      tickCollection,
+		executionCollection: config.GetCollection("executions"),
+		writer:              NewBatchWriter(tickCollection),
+		interval:            time.Duration(config.GetEnvInt("PLAYBACK_RECORD_SECONDS", 5)) * time.Second,
+		retention:           time.Duration(config.GetEnvInt("PLAYBACK_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		recorded:            make(map[string]time.Time),
+	}
+}
+
+// RecordTick samples the tick if the symbol has not been sampled within the
+// recording interval
+func (s *PlaybackService) RecordTick(stock models.Stock) {
+	symbol := strings.ToUpper(stock.Symbol)
+	at := stock.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	s.mu.Lock()
+	if last, ok := s.recorded[symbol]; ok && at.Sub(last) < s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.recorded[symbol] = at
+	s.mu.Unlock()
+
+	s.writer.Write(models.TickSample{
+		Symbol:        symbol,
+		Price:         stock.Price,
+		Change:        stock.Change,
+		ChangePercent: stock.ChangePercent,
+		Volume:        stock.Volume,
+		Timestamp:     at.UTC(),
+	})
+}
+
+// Events returns the symbol's recorded ticks and fills between from and to,
+// oldest first. Fills are limited to the tenant and, when given, the
+// competition.
+func (s *PlaybackService) Events(ctx context.Context, symbol, tenantID, competitionID string, from, to time.Time) ([]models.PlaybackEvent, error) {
+	if !from.Before(to) || to.Sub(from) > maxPlaybackRange {
+		return nil, ErrPlaybackRange
+	}
+	symbol = strings.ToUpper(symbol)
+
+	var ticks []models.TickSample
+	cursor, err := s.tickCollection.Find(ctx, bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$gte": from, "$lte": to},
+	}, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetLimit(maxPlaybackEvents))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &ticks); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"symbol":      symbol,
+		"executed_at": bson.M{"$gte": from, "$lte": to},
+	}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	}
+	if competitionID != "" {
+		filter["competition_id"] = competitionID
+	}
+	var executions []models.Execution
+	cursor, err = s.executionCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "executed_at", Value: 1}}).SetLimit(maxPlaybackEvents))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+
+	events := make([]models.PlaybackEvent, 0, len(ticks)+len(executions))
+	for i := range ticks {
+		events = append(events, models.PlaybackEvent{Type: "tick", Time: ticks[i].Timestamp, Tick: &ticks[i]})
+	}
+	for _, execution := range executions {
+		events = append(events, models.PlaybackEvent{
+			Type: "fill",
+			Time: execution.ExecutedAt,
+			Fill: &models.PlaybackFill{
+				Symbol:        execution.Symbol,
+				Side:          execution.Side,
+				Quantity:      execution.Quantity,
+				Price:         execution.Price,
+				Liquidity:     execution.Liquidity,
+				CompetitionID: execution.CompetitionID,
+				ExecutedAt:    execution.ExecutedAt,
+			},
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+// Run writes the recorded samples until the process exits
+func (s *PlaybackService) Run() {
+	s.writer.Run()
+}
+
+// EnsureIndexes indexes the samples for playback queries and expires them
+// after PLAYBACK_RETENTION_DAYS (default 7) with a TTL index. A retention of
+// zero keeps everything.
+func (s *PlaybackService) EnsureIndexes(ctx context.Context) error {
+	indexes := s.tickCollection.Indexes()
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "timestamp", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	if s.retention <= 0 {
+		if _, err := indexes.DropOne(ctx, tickTTLIndex); err != nil && !isMongoCode(err, 27) { // IndexNotFound
+			return err
+		}
+		return nil
+	}
+	seconds := int32(s.retention / time.Second)
+	_, err = indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "timestamp", Value: 1}},
+		Options: options.Index().SetName(tickTTLIndex).SetExpireAfterSeconds(seconds),
+	})
+	if isMongoCode(err, 85) { // IndexOptionsConflict: the retention changed
+		return s.tickCollection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: s.tickCollection.Name()},
+			{Key: "index", Value: bson.M{"name": tickTTLIndex, "expireAfterSeconds": seconds}},
+		}).Err()
+	}
+	return err
+}
+
+func isMongoCode(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
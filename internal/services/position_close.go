@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CloseOrder builds the market order that closes part or all of a position:
+// a sell for a long position, a buy to cover for a short one. Exactly one of
+// percent (above 0, up to 100) and quantity is given. Partial percentages
+// round down to the symbol's lot size; 100 percent closes every share.
+func (e *OrderEngine) CloseOrder(ctx context.Context, userID, tenantID, competitionID, symbol string, percent, quantity float64) (*models.Order, error) {
+	if (percent > 0) == (quantity > 0) || percent > 100 {
+		return nil, i18n.NewError("order.close_amount_invalid")
+	}
+	symbol = strings.ToUpper(symbol)
+
+	var position models.Portfolio
+	err := e.orderService.portfolioCollection.FindOne(ctx, positionFilter(userID, competitionID, symbol)).Decode(&position)
+	if err == mongo.ErrNoDocuments || (err == nil && roundQuantity(position.Shares) == 0) {
+		return nil, i18n.NewError("order.no_position", symbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	held := roundQuantity(math.Abs(position.Shares))
+	switch {
+	case percent == 100:
+		quantity = held
+	case percent > 0:
+		step := math.Max(e.orderService.symbolService.GetSymbol(symbol).LotSize, quantityStep)
+		quantity = roundQuantity(math.Floor(held*percent/100/step+1e-9) * step)
+	}
+	quantity = roundQuantity(quantity)
+	if quantity > held {
+		return nil, i18n.NewError("order.close_exceeds_position", quantity, held, symbol)
+	}
+
+	side := "sell"
+	if position.Shares < 0 {
+		side = "buy"
+	}
+	mode := DataModeMock
+	if competitionID == "" {
+		mode = e.orderService.DataMode(userID)
+	}
+	price, ok := e.orderService.marketService.GetMarkPrice(symbol, mode)
+	if !ok {
+		price = position.AvgCost
+	}
+
+	return &models.Order{
+		UserID:        userID,
+		Symbol:        symbol,
+		Type:          side,
+		OrderType:     "market",
+		Quantity:      quantity,
+		Price:         price,
+		CompetitionID: competitionID,
+		TenantID:      tenantID,
+		Status:        "filled",
+		Timestamp:     time.Now().UTC(),
+	}, nil
+}
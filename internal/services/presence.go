@@ -0,0 +1,165 @@
+package services
+
+import (
+	"sort"
+	"sync"
+)
+
+// PresenceChannel carries online/offline events of authenticated traders
+const PresenceChannel = "presence"
+
+// presenceEntry is one online user and how many sockets they have open
+type presenceEntry struct {
+	username    string
+	tenantID    string
+	hidden      bool
+	connections int
+}
+
+// presenceTracker counts authenticated users with at least one open socket.
+// The hub goroutine writes it; handlers read the counts.
+type presenceTracker struct {
+	mu     sync.RWMutex
+	online map[string]*presenceEntry // By user ID
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{online: make(map[string]*presenceEntry)}
+}
+
+// connect records a socket and reports whether the user just came online
+func (p *presenceTracker) connect(client *WebSocketClient) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.online[client.userID]
+	if !ok {
+		entry = &presenceEntry{username: client.username, tenantID: client.tenantID}
+		p.online[client.userID] = entry
+	}
+	entry.hidden = client.hidePresence
+	entry.connections++
+	return entry.connections == 1
+}
+
+// disconnect records a closed socket and reports whether the user went offline
+func (p *presenceTracker) disconnect(client *WebSocketClient) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.online[client.userID]
+	if !ok {
+		return false
+	}
+	entry.connections--
+	if entry.connections > 0 {
+		return false
+	}
+	delete(p.online, client.userID)
+	return true
+}
+
+// count returns how many users are online in the tenant, or overall for an
+// empty tenant. Hidden users are counted.
+func (p *presenceTracker) count(tenantID string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	n := 0
+	for _, entry := range p.online {
+		if tenantID == "" || entry.tenantID == tenantID {
+			n++
+		}
+	}
+	return n
+}
+
+// visible returns the sorted usernames of online users in the tenant who
+// have not opted out of presence
+func (p *presenceTracker) visible(tenantID string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := []string{}
+	for _, entry := range p.online {
+		if !entry.hidden && (tenantID == "" || entry.tenantID == tenantID) {
+			names = append(names, entry.username)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnlineTraders returns how many authenticated users are connected in the
+// tenant, or overall for an empty tenant
+func (h *WebSocketHub) OnlineTraders(tenantID string) int {
+	return h.presence.count(tenantID)
+}
+
+// OnlineUsers returns the username of every connected authenticated user,
+// hidden or not, keyed by user ID
+func (h *WebSocketHub) OnlineUsers() map[string]string {
+	h.presence.mu.RLock()
+	defer h.presence.mu.RUnlock()
+	users := make(map[string]string, len(h.presence.online))
+	for userID, entry := range h.presence.online {
+		users[userID] = entry.username
+	}
+	return users
+}
+
+// updatePresence tracks an authenticated client joining or leaving and tells
+// presence subscribers in the same tenant when the user comes online or goes
+// offline. Runs on the hub goroutine.
+func (h *WebSocketHub) updatePresence(client *WebSocketClient, connected bool) {
+	if client.userID == "" {
+		return
+	}
+	event := "offline"
+	changed := false
+	if connected {
+		event = "online"
+		changed = h.presence.connect(client)
+	} else {
+		changed = h.presence.disconnect(client)
+	}
+	if !changed || client.hidePresence {
+		return
+	}
+
+	notice := map[string]interface{}{
+		"type":          "presence",
+		"channel":       PresenceChannel,
+		"event":         event,
+		"username":      client.username,
+		"onlineTraders": h.presence.count(client.tenantID),
+	}
+	for other := range h.clients {
+		if other.presence && other != client && other.tenantID == client.tenantID {
+			h.sendControl(other, notice)
+		}
+	}
+}
+
+// changePresenceSubscription turns presence events on or off for a client
+// and replies with who is online now
+func (h *WebSocketHub) changePresenceSubscription(change subscriptionChange) {
+	client := change.client
+	client.presence = change.subscribe
+
+	reply := map[string]interface{}{
+		"type":    "response",
+		"action":  "unsubscribe",
+		"channel": PresenceChannel,
+		"ok":      true,
+	}
+	if change.subscribe {
+		reply["action"] = "subscribe"
+		reply["users"] = h.presence.visible(client.tenantID)
+		reply["onlineTraders"] = h.presence.count(client.tenantID)
+	}
+	if change.id != "" {
+		reply["id"] = change.id
+	}
+	h.sendControl(client, reply)
+}
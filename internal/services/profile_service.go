@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Most users returned by one search
+const userSearchLimit = 20
+
+// ErrProfileNotFound is returned for users who do not exist, are in another
+// tenant or keep their profile private, so a lookup does not reveal which
+var ErrProfileNotFound = errors.New("profile not found")
+
+// ProfileService serves user search and public profiles. Only users who
+// opted in with the publicProfile preference are searchable, viewable or
+// ranked, and only by users in the same tenant.
+type ProfileService struct {
+	userCollection     *mongo.Collection
+	orderService       *OrderService
+	achievementService *AchievementService
+	tenants            *TenantService
+	ledger             *LedgerService
+	cacheTTL           time.Duration
+
+	mu       sync.Mutex
+	rankings map[string]*profileRanking // By tenant ID
+}
+
+// profileRanking is the public profiles of a tenant by return, best first
+type profileRanking struct {
+	returns     map[string]float64 // User ID -> return percent
+	ranks       map[string]int     // User ID -> rank
+	generatedAt time.Time
+}
+
+func NewProfileService(orderService *OrderService, achievementService *AchievementService, tenants *TenantService, ledger *LedgerService) *ProfileService {
+	return &ProfileService{
+		userCollection:     config.GetCollection("users"),
+		orderService:       orderService,
+		achievementService: achievementService,
+		tenants:            tenants,
+		ledger:             ledger,
+		cacheTTL:           time.Duration(config.GetEnvInt("PROFILE_RANK_CACHE_SECONDS", 300)) * time.Second,
+		rankings:           make(map[string]*profileRanking),
+	}
+}
+
+// Search finds public profiles in the tenant whose username starts with the
+// query, ignoring case
+func (s *ProfileService) Search(ctx context.Context, tenantID, query string) ([]models.ProfileSummary, error) {
+	query = strings.TrimSpace(query)
+	results := []models.ProfileSummary{}
+	if query == "" {
+		return results, nil
+	}
+
+	filter := s.publicFilter(tenantID)
+	filter["username"] = bson.M{"$regex": "^" + regexp.QuoteMeta(query), "$options": "i"}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "username", Value: 1}}).
+		SetLimit(userSearchLimit).
+		SetProjection(bson.M{"username": 1, "created_at": 1})
+	cursor, err := s.userCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &results)
+	return results, err
+}
+
+// Profile returns a user's public profile as seen by the viewer. Users can
+// always see their own profile, so they can preview it before publishing.
+func (s *ProfileService) Profile(ctx context.Context, viewerID, tenantID, username string) (*models.PublicProfile, error) {
+	var user models.User
+	err := s.userCollection.FindOne(ctx, bson.M{
+		"username":   username,
+		"deleted_at": bson.M{"$exists": false},
+	}, options.FindOne().SetCollation(usernameCollation)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	userID := user.ID.Hex()
+	if userID != viewerID && (!user.PublicProfile || user.TenantID != tenantID) {
+		return nil, ErrProfileNotFound
+	}
+
+	profile := &models.PublicProfile{
+		Username:      user.Username,
+		JoinedAt:      user.CreatedAt,
+		Public:        user.PublicProfile,
+		ReturnPercent: s.returnPercent(ctx, user),
+		Badges:        []models.Badge{},
+	}
+	if user.PublicProfile {
+		ranking, err := s.ranking(ctx, user.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		profile.Rank = ranking.ranks[userID]
+		profile.RankedOf = len(ranking.ranks)
+		if profile.Rank > 0 {
+			// Keep the rank and return consistent until the ranking refreshes
+			profile.ReturnPercent = ranking.returns[userID]
+		}
+	}
+
+	achievements, err := s.achievementService.GetAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, achievement := range achievements {
+		if achievement.Unlocked {
+			profile.Badges = append(profile.Badges, models.Badge{
+				Code:        achievement.Code,
+				Name:        achievement.Name,
+				Description: achievement.Description,
+				UnlockedAt:  achievement.UnlockedAt,
+			})
+		}
+	}
+	return profile, nil
+}
+
+// ranking returns the tenant's ranking, recomputing it once it is older
+// than PROFILE_RANK_CACHE_SECONDS (default 300)
+func (s *ProfileService) ranking(ctx context.Context, tenantID string) (*profileRanking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cached := s.rankings[tenantID]; cached != nil && time.Since(cached.generatedAt) < s.cacheTTL {
+		return cached, nil
+	}
+
+	cursor, err := s.userCollection.Find(ctx, s.publicFilter(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	ranking := &profileRanking{
+		returns:     make(map[string]float64, len(users)),
+		ranks:       make(map[string]int, len(users)),
+		generatedAt: time.Now(),
+	}
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		userID := user.ID.Hex()
+		ranking.returns[userID] = s.returnPercent(ctx, user)
+		ids = append(ids, userID)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return ranking.returns[ids[i]] > ranking.returns[ids[j]] })
+	for i, userID := range ids {
+		ranking.ranks[userID] = i + 1
+	}
+	s.rankings[tenantID] = ranking
+	return ranking, nil
+}
+
+// returnPercent compares the main account's equity with the balance it
+// opened with or was last reset to, plus what was deposited since less what
+// was withdrawn
+func (s *ProfileService) returnPercent(ctx context.Context, user models.User) float64 {
+	deposited, err := s.ledger.NetDeposits(ctx, user.ID.Hex(), user.AccountResetAt)
+	if err != nil {
+		log.Printf("Error loading deposits for %s: %v", user.ID.Hex(), err)
+	}
+	invested := s.tenants.OpeningBalance(user) + deposited
+	if invested <= 0 {
+		return 0
+	}
+	equity := user.CashBalance + s.orderService.GetTotalPortfolioValue(ctx, user.ID.Hex())
+	return round2((equity - invested) / invested * 100)
+}
+
+// publicFilter matches the tenant's users with a public profile
+func (s *ProfileService) publicFilter(tenantID string) bson.M {
+	filter := bson.M{
+		"public_profile": true,
+		"deleted_at":     bson.M{"$exists": false},
+	}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	} else {
+		filter["tenant_id"] = bson.M{"$in": bson.A{nil, ""}}
+	}
+	return filter
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrDeviceUnregistered is returned by a PushSender when the push service no
+// longer accepts the device token, so the device should be forgotten
+var ErrDeviceUnregistered = errors.New("device token is no longer registered")
+
+// PushMessage is one notification shown on a device
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string // Delivered to the app alongside the notification
+}
+
+// PushSender delivers notifications to one platform's push service
+type PushSender interface {
+	Send(ctx context.Context, token string, message PushMessage) error
+}
+
+// NewPushSenders returns a sender per platform: FCM for "android" when
+// FCM_CREDENTIALS_FILE is set and APNs for "ios" when APNS_KEY_FILE is set.
+// Unconfigured platforms get a sender that only logs.
+func NewPushSenders() map[string]PushSender {
+	senders := map[string]PushSender{"android": LogPushSender{}, "ios": LogPushSender{}}
+
+	if path := config.GetEnv("FCM_CREDENTIALS_FILE", ""); path != "" {
+		sender, err := NewFCMSender(path)
+		if err != nil {
+			log.Printf("⚠️ FCM push disabled: %v", err)
+		} else {
+			senders["android"] = sender
+		}
+	}
+	if path := config.GetEnv("APNS_KEY_FILE", ""); path != "" {
+		sender, err := NewAPNsSender(path, config.GetEnv("APNS_KEY_ID", ""), config.GetEnv("APNS_TEAM_ID", ""),
+			config.GetEnv("APNS_TOPIC", ""), config.GetEnv("APNS_PRODUCTION", "false") == "true")
+		if err != nil {
+			log.Printf("⚠️ APNs push disabled: %v", err)
+		} else {
+			senders["ios"] = sender
+		}
+	}
+	return senders
+}
+
+// FCMSender sends through the Firebase Cloud Messaging HTTP v1 API with a
+// service account, exchanging a signed JWT for short-lived access tokens
+type FCMSender struct {
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	key         interface{}
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMSender(credentialsFile string) (*FCMSender, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var creds struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	if creds.ProjectID == "" || creds.ClientEmail == "" {
+		return nil, fmt.Errorf("%s is not a service account key", credentialsFile)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &FCMSender{
+		projectID:   creds.ProjectID,
+		clientEmail: creds.ClientEmail,
+		tokenURI:    creds.TokenURI,
+		key:         key,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (f *FCMSender) Send(ctx context.Context, token string, message PushMessage) error {
+	accessToken, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token":        token,
+			"notification": map[string]string{"title": message.Title, "body": message.Body},
+			"data":         message.Data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", f.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode == http.StatusNotFound || bytes.Contains(msg, []byte("UNREGISTERED")) {
+			return ErrDeviceUnregistered
+		}
+		return fmt.Errorf("fcm returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// token returns a cached access token, fetching a new one shortly before
+// the old one expires
+func (f *FCMSender) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.accessToken != "" && time.Now().Before(f.expiresAt) {
+		return f.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.clientEmail,
+		"scope": "https://www.googleapis.com/auth/firebase.messaging",
+		"aud":   f.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(f.key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("fcm token exchange returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	f.accessToken = result.AccessToken
+	f.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return f.accessToken, nil
+}
+
+// APNsSender sends through Apple's HTTP/2 provider API with token-based
+// authentication
+type APNsSender struct {
+	keyID  string
+	teamID string
+	topic  string // The app's bundle ID
+	host   string
+	key    interface{}
+	client *http.Client
+
+	mu            sync.Mutex
+	providerToken string
+	issuedAt      time.Time
+}
+
+// Apple rejects provider tokens older than an hour and throttles refreshing
+// them more often than every 20 minutes
+const apnsTokenLifetime = 45 * time.Minute
+
+func NewAPNsSender(keyFile, keyID, teamID, topic string, production bool) (*APNsSender, error) {
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, fmt.Errorf("APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC are required")
+	}
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, err
+	}
+	host := "https://api.sandbox.push.apple.com"
+	if production {
+		host = "https://api.push.apple.com"
+	}
+	return &APNsSender{
+		keyID:  keyID,
+		teamID: teamID,
+		topic:  topic,
+		host:   host,
+		key:    key,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (a *APNsSender) Send(ctx context.Context, token string, message PushMessage) error {
+	providerToken, err := a.token()
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": message.Title, "body": message.Body},
+			"sound": "default",
+		},
+	}
+	for k, v := range message.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.host+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode == http.StatusGone || bytes.Contains(msg, []byte("BadDeviceToken")) {
+			return ErrDeviceUnregistered
+		}
+		return fmt.Errorf("apns returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (a *APNsSender) token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.providerToken != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.providerToken, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": a.teamID, "iat": now.Unix()})
+	token.Header["kid"] = a.keyID
+	signed, err := token.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+	a.providerToken, a.issuedAt = signed, now
+	return signed, nil
+}
+
+// LogPushSender logs notifications instead of sending them
+type LogPushSender struct{}
+
+func (LogPushSender) Send(ctx context.Context, token string, message PushMessage) error {
+	log.Printf("📱 Push to %s…: %s - %s", token[:min(len(token), 8)], message.Title, message.Body)
+	return nil
+}
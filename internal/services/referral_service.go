@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReferralService struct {
+	userCollection     *mongo.Collection
+	referralCollection *mongo.Collection
+	journal            *journal
+	bonus              float64
+	maxPerUser         int
+	maxPerDay          int
+}
+
+func NewReferralService() *ReferralService {
+	return &ReferralService{
+		userCollection:     config.GetCollection("users"),
+		referralCollection: config.GetCollection("referrals"),
+		journal:            newJournal(),
+		bonus:              float64(config.GetEnvInt("REFERRAL_BONUS", 500)),
+		maxPerUser:         config.GetEnvInt("REFERRAL_MAX_PER_USER", 25),
+		maxPerDay:          config.GetEnvInt("REFERRAL_MAX_PER_DAY", 5),
+	}
+}
+
+// GetInviteCode returns the user's invite code, generating one on first use
+func (s *ReferralService) GetInviteCode(ctx context.Context, userID string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", err
+	}
+
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return "", err
+	}
+	if user.InviteCode != "" {
+		return user.InviteCode, nil
+	}
+
+	code, err := generateCode(8)
+	if err != nil {
+		return "", err
+	}
+	result, err := s.userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "invite_code": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"invite_code": code}},
+	)
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		// A concurrent call stored a code first; that one is the user's
+		if err := s.userCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			return "", err
+		}
+		return user.InviteCode, nil
+	}
+	return code, nil
+}
+
+// FindReferrer returns the owner of an invite code
+func (s *ReferralService) FindReferrer(ctx context.Context, code string) (*models.User, error) {
+	var referrer models.User
+	err := s.userCollection.FindOne(ctx, bson.M{
+		"invite_code": strings.ToUpper(strings.TrimSpace(code)),
+	}).Decode(&referrer)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("invalid invite code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referrer, nil
+}
+
+// RecordReferral links a newly registered user to their referrer and credits
+// the bonus to both, unless the referrer has hit the daily or lifetime limit.
+// It returns the bonus credited to the new user.
+func (s *ReferralService) RecordReferral(ctx context.Context, referrer *models.User, referee *models.User) (float64, error) {
+	referrerID := referrer.ID.Hex()
+	if referrerID == referee.ID.Hex() {
+		return 0, errors.New("cannot refer yourself")
+	}
+
+	bonus := s.bonus
+	note := ""
+	total, err := s.referralCollection.CountDocuments(ctx, bson.M{"referrer_id": referrerID})
+	if err != nil {
+		return 0, err
+	}
+	today, err := s.referralCollection.CountDocuments(ctx, bson.M{
+		"referrer_id": referrerID,
+		"created_at":  bson.M{"$gte": time.Now().Add(-24 * time.Hour)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if int(total) >= s.maxPerUser {
+		bonus, note = 0, "referrer reached lifetime referral limit"
+	} else if int(today) >= s.maxPerDay {
+		bonus, note = 0, "referrer reached daily referral limit"
+	}
+
+	referral := &models.Referral{
+		ID:              primitive.NewObjectID(),
+		ReferrerID:      referrerID,
+		RefereeID:       referee.ID.Hex(),
+		RefereeUsername: referee.Username,
+		Code:            referrer.InviteCode,
+		Bonus:           bonus,
+		Note:            note,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if _, err := s.referralCollection.InsertOne(ctx, referral); err != nil {
+		return 0, err
+	}
+
+	_, err = s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": referee.ID},
+		bson.M{"$set": bson.M{"referred_by": referrerID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if bonus > 0 {
+		for side, user := range map[string]*models.User{"referee": referee, "referrer": referrer} {
+			_, err = s.journal.post(ctx, models.JournalEntry{
+				ID:          "referral:" + referral.ID.Hex() + ":" + side,
+				UserID:      user.ID.Hex(),
+				Type:        "referral",
+				Description: fmt.Sprintf("Referral bonus for %s joining", referee.Username),
+				Postings: []models.Posting{
+					{Account: JournalCash, Amount: bonus},
+					{Account: JournalCapital, Amount: -bonus},
+				},
+				TenantID:  user.TenantID,
+				CreatedAt: referral.CreatedAt,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	log.Printf("🤝 Referral: %s invited %s (bonus $%.2f)", referrer.Username, referee.Username, bonus)
+	return bonus, nil
+}
+
+// GetReferrals lists the users the given user invited, newest first
+func (s *ReferralService) GetReferrals(ctx context.Context, userID string) ([]models.Referral, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.referralCollection.Find(ctx, bson.M{"referrer_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var referrals []models.Referral
+	err = cursor.All(ctx, &referrals)
+	return referrals, err
+}
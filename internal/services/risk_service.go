@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+// Trading days per year used to annualize volatility
+const tradingDaysPerYear = 252
+
+// Fewer returns than this are too noisy to report
+const minRiskSamples = 5
+
+type RiskService struct {
+	orderService  *OrderService
+	marketService *MarketDataService
+	symbolService *SymbolService
+	flags         *FeatureFlagService
+	ticksPerDay   int
+}
+
+func NewRiskService(orderService *OrderService, marketService *MarketDataService, symbolService *SymbolService, flags *FeatureFlagService) *RiskService {
+	return &RiskService{
+		orderService:  orderService,
+		marketService: marketService,
+		symbolService: symbolService,
+		flags:         flags,
+		ticksPerDay:   max(config.GetEnvInt("SIM_TICKS_PER_DAY", 100), 1),
+	}
+}
+
+// GetRiskMetrics computes beta, volatility, historical VaR and per-position
+// risk contribution for the user's main account. Positions are weighted by
+// their current value and replayed over the recorded tick history. Accounts
+// with margin, through a flag or their tier, also get their margin status.
+// Returns are per tick; volatility and VaR are scaled to a simulated day of
+// SIM_TICKS_PER_DAY ticks by the square root of time.
+func (s *RiskService) GetRiskMetrics(ctx context.Context, userID string) (*models.RiskMetrics, error) {
+	positions, err := s.orderService.GetUserPortfolio(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &models.RiskMetrics{
+		Positions:    []models.PositionRisk{},
+		CalculatedAt: time.Now().UTC(),
+	}
+
+	mode := s.orderService.DataMode(userID)
+	returns := make(map[string][]float64, len(positions))
+	values := make(map[string]float64, len(positions))
+	prices := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		history := s.marketService.GetPriceHistory(pos.Symbol)
+		price, ok := s.marketService.GetMarkPrice(pos.Symbol, mode)
+		if !ok && len(history) > 0 {
+			price = history[len(history)-1]
+		}
+		prices[pos.Symbol] = price
+		values[pos.Symbol] = price * pos.Shares
+		metrics.PositionsValue += values[pos.Symbol]
+		if r := tickReturns(history); len(r) > 0 {
+			returns[pos.Symbol] = r
+		}
+	}
+	cash := s.orderService.GetCashBalance(ctx, userID)
+	metrics.Equity = cash + metrics.PositionsValue
+	if s.flags.IsEnabledFor(FeatureMargin, userID) {
+		margin := marginSummary(cash, positions, prices)
+		metrics.Margin = &margin
+	}
+
+	index := s.indexReturns()
+	samples := len(index)
+	for _, r := range returns {
+		if len(r) < samples {
+			samples = len(r)
+		}
+	}
+
+	// Portfolio return per tick, holding today's weights fixed
+	portfolio := make([]float64, samples)
+	if metrics.Equity > 0 {
+		for symbol, r := range returns {
+			weight := values[symbol] / metrics.Equity
+			r = r[len(r)-samples:]
+			for t := range portfolio {
+				portfolio[t] += weight * r[t]
+			}
+		}
+	}
+	index = index[len(index)-samples:]
+	portfolioVol := stdDev(portfolio)
+	daily := math.Sqrt(float64(s.ticksPerDay))
+
+	if samples >= minRiskSamples {
+		metrics.Samples = samples
+		metrics.Beta = round2(beta(portfolio, index))
+		metrics.Volatility = round2(portfolioVol * daily * 100)
+		metrics.AnnualizedVolatility = round2(portfolioVol * daily * math.Sqrt(tradingDaysPerYear) * 100)
+		if loss := -percentile(portfolio, 5) * daily; loss > 0 {
+			metrics.VaR95 = round2(loss * metrics.Equity)
+			metrics.VaR95Percent = round2(loss * 100)
+		}
+	}
+
+	for _, pos := range positions {
+		risk := models.PositionRisk{
+			Symbol: pos.Symbol,
+			Shares: pos.Shares,
+			Value:  round2(values[pos.Symbol]),
+		}
+		if metrics.Equity > 0 {
+			risk.Weight = round2(values[pos.Symbol] / metrics.Equity * 100)
+		}
+
+		r, ok := returns[pos.Symbol]
+		if ok && samples >= minRiskSamples {
+			r = r[len(r)-samples:]
+			risk.HasHistory = true
+			risk.Beta = round2(beta(r, index))
+			risk.Volatility = round2(stdDev(r) * daily * 100)
+			if portfolioVol > 0 && metrics.Equity > 0 {
+				// Euler allocation: contributions sum to portfolio volatility
+				contribution := values[pos.Symbol] / metrics.Equity * covariance(r, portfolio) / portfolioVol
+				risk.RiskContribution = round2(contribution * daily * 100)
+				risk.RiskContributionPct = round2(contribution / portfolioVol * 100)
+			}
+		}
+		metrics.Positions = append(metrics.Positions, risk)
+	}
+
+	return metrics, nil
+}
+
+// indexReturns builds the equal-weighted simulated stock index from every
+// stock symbol with recorded ticks
+func (s *RiskService) indexReturns() []float64 {
+	var series [][]float64
+	length := 0
+	for _, info := range s.symbolService.ListSymbols() {
+		if info.AssetClass != "stock" {
+			continue
+		}
+		r := tickReturns(s.marketService.GetPriceHistory(info.Symbol))
+		if len(r) == 0 {
+			continue
+		}
+		if len(series) == 0 || len(r) < length {
+			length = len(r)
+		}
+		series = append(series, r)
+	}
+
+	index := make([]float64, length)
+	for _, r := range series {
+		r = r[len(r)-length:]
+		for t := range index {
+			index[t] += r[t] / float64(len(series))
+		}
+	}
+	return index
+}
+
+func tickReturns(prices []float64) []float64 {
+	if len(prices) < 2 {
+		return nil
+	}
+	returns := make([]float64, 0, len(prices)-1)
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] <= 0 {
+			continue
+		}
+		returns = append(returns, prices[i]/prices[i-1]-1)
+	}
+	return returns
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func covariance(a, b []float64) float64 {
+	if len(a) < 2 || len(a) != len(b) {
+		return 0
+	}
+	meanA, meanB := mean(a), mean(b)
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return sum / float64(len(a)-1)
+}
+
+func stdDev(values []float64) float64 {
+	return math.Sqrt(covariance(values, values))
+}
+
+func beta(returns, market []float64) float64 {
+	variance := covariance(market, market)
+	if variance == 0 {
+		return 0
+	}
+	return covariance(returns, market) / variance
+}
+
+// percentile returns the p-th percentile of values by linear interpolation
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// Maintenance margin as a fraction of position value
+const (
+	longMaintenanceMargin  = 0.25
+	shortMaintenanceMargin = 0.30
+)
+
+// Initial margin requirement when margin is enabled: each dollar of excess
+// equity buys two dollars of stock
+const initialMarginRate = 0.5
+
+// StressTest revalues the user's main account with each position moved by
+// the shock for its symbol, or else its sector. Keys are matched case-insensitively.
+func (s *RiskService) StressTest(ctx context.Context, userID string, shocks map[string]float64) (*models.StressTestResult, error) {
+	if len(shocks) == 0 {
+		return nil, errors.New("at least one shock is required")
+	}
+	normalized := make(map[string]float64, len(shocks))
+	for key, percent := range shocks {
+		if percent <= -100 {
+			return nil, fmt.Errorf("shock for %s must be greater than -100%%", key)
+		}
+		normalized[strings.ToUpper(strings.TrimSpace(key))] = percent
+	}
+
+	positions, err := s.orderService.GetUserPortfolio(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cash := s.orderService.GetCashBalance(ctx, userID)
+
+	result := &models.StressTestResult{
+		Shocks:    shocks,
+		Positions: []models.PositionStress{},
+	}
+	currentPrices := make(map[string]float64, len(positions))
+	projectedPrices := make(map[string]float64, len(positions))
+
+	for _, pos := range positions {
+		info := s.symbolService.GetSymbol(pos.Symbol)
+		price, ok := s.marketService.GetLastPrice(pos.Symbol)
+		if !ok {
+			if history := s.marketService.GetPriceHistory(pos.Symbol); len(history) > 0 {
+				price = history[len(history)-1]
+			}
+		}
+
+		shock, ok := normalized[pos.Symbol]
+		if !ok {
+			shock = normalized[strings.ToUpper(info.Sector)]
+		}
+		newPrice := price * (1 + shock/100)
+		currentPrices[pos.Symbol] = price
+		projectedPrices[pos.Symbol] = newPrice
+
+		result.Positions = append(result.Positions, models.PositionStress{
+			Symbol:         pos.Symbol,
+			Sector:         info.Sector,
+			Shares:         pos.Shares,
+			ShockPercent:   shock,
+			CurrentPrice:   round2(price),
+			ProjectedPrice: round2(newPrice),
+			ProfitLoss:     round2((newPrice - price) * pos.Shares),
+		})
+	}
+
+	result.CurrentMargin = marginSummary(cash, positions, currentPrices)
+	result.ProjectedMargin = marginSummary(cash, positions, projectedPrices)
+	result.CurrentValue = result.CurrentMargin.Equity
+	result.ProjectedValue = result.ProjectedMargin.Equity
+	result.ProfitLoss = round2(result.ProjectedValue - result.CurrentValue)
+	if result.CurrentValue != 0 {
+		result.ProfitLossPercent = round2(result.ProfitLoss / result.CurrentValue * 100)
+	}
+	return result, nil
+}
+
+func marginSummary(cash float64, positions []models.Portfolio, prices map[string]float64) models.MarginSummary {
+	equity, requirement := cash, 0.0
+	for _, pos := range positions {
+		value := prices[pos.Symbol] * pos.Shares
+		equity += value
+		if pos.Shares < 0 {
+			requirement += -value * shortMaintenanceMargin
+		} else {
+			requirement += value * longMaintenanceMargin
+		}
+	}
+	return models.MarginSummary{
+		Equity:      round2(equity),
+		Requirement: round2(requirement),
+		Excess:      round2(equity - requirement),
+		MarginCall:  equity < requirement,
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+// SessionService records each symbol's price at the open of every simulated
+// trading session. Sessions last SIM_TICKS_PER_DAY ticks, the same simulated
+// day used for symbol statistics. Opens are stored so day P&L survives a
+// restart.
+type SessionService struct {
+	sessionCollection *mongo.Collection
+	ticksPerDay       int
+
+	mu    sync.RWMutex
+	opens map[string]models.SessionOpen
+	ticks map[string]int // Ticks seen in the current session
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		sessionCollection: config.GetCollection("session_opens"),
+		ticksPerDay:       max(config.GetEnvInt("SIM_TICKS_PER_DAY", 100), 1),
+		opens:             make(map[string]models.SessionOpen),
+		ticks:             make(map[string]int),
+	}
+}
+
+// Load restores the stored session opens. The restored sessions continue
+// until they have seen a full day of ticks after the restart.
+func (s *SessionService) Load(ctx context.Context) {
+	cursor, err := s.sessionCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("Error loading session opens: %v", err)
+		return
+	}
+	var stored []models.SessionOpen
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("Error decoding session opens: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, open := range stored {
+		if _, ok := s.opens[open.Symbol]; !ok {
+			s.opens[open.Symbol] = open
+			s.ticks[open.Symbol] = 1
+		}
+	}
+}
+
+// RecordTick opens a new session for the symbol on its first tick of a
+// simulated day
+func (s *SessionService) RecordTick(stock models.Stock) {
+	symbol := strings.ToUpper(stock.Symbol)
+
+	s.mu.Lock()
+	ticks := s.ticks[symbol]
+	var opened *models.SessionOpen
+	if ticks == 0 {
+		open := models.SessionOpen{
+			Symbol:   symbol,
+			Price:    stock.Price,
+			Session:  s.opens[symbol].Session + 1,
+			OpenedAt: stock.Timestamp,
+		}
+		s.opens[symbol] = open
+		opened = &open
+	}
+	s.ticks[symbol] = (ticks + 1) % s.ticksPerDay
+	s.mu.Unlock()
+
+	if opened != nil {
+		go s.save(*opened)
+	}
+}
+
+// OpenPrice returns the symbol's price at the open of the current session
+func (s *SessionService) OpenPrice(symbol string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	open, ok := s.opens[strings.ToUpper(symbol)]
+	return open.Price, ok && open.Price > 0
+}
+
+func (s *SessionService) save(open models.SessionOpen) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := s.sessionCollection.ReplaceOne(ctx, bson.M{"_id": open.Symbol}, open, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Error saving session open for %s: %v", open.Symbol, err)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Other instances pick up a change within this interval
+	simulationCacheTTL = 5 * time.Second
+
+	minTickIntervalMs = 250
+	maxTickIntervalMs = 60000
+	maxVolatility     = 10.0
+)
+
+// SimulationService holds the simulator's tick interval, volatility and
+// pause state. Settings are kept in Mongo so they survive restarts; until
+// an admin changes them the SIM_TICK_INTERVAL_MS and SIM_VOLATILITY
+// defaults apply.
+type SimulationService struct {
+	settingsCollection *mongo.Collection
+	defaults           models.SimulationSettings
+
+	mu       sync.Mutex
+	settings models.SimulationSettings
+	loadedAt time.Time
+}
+
+func NewSimulationService() *SimulationService {
+	defaults := clampSimulationSettings(models.SimulationSettings{
+		TickIntervalMs:       config.GetEnvInt("SIM_TICK_INTERVAL_MS", 3000),
+		VolatilityMultiplier: config.GetEnvFloat("SIM_VOLATILITY", 1),
+	})
+	return &SimulationService{
+		settingsCollection: config.GetCollection("settings"),
+		defaults:           defaults,
+		settings:           defaults,
+	}
+}
+
+// clampSimulationSettings pulls settings that did not come through
+// SetSettings into its bounds, since a zero tick interval would panic the
+// simulator's ticker
+func clampSimulationSettings(settings models.SimulationSettings) models.SimulationSettings {
+	clamped := settings
+	clamped.TickIntervalMs = min(max(settings.TickIntervalMs, minTickIntervalMs), maxTickIntervalMs)
+	clamped.VolatilityMultiplier = min(settings.VolatilityMultiplier, maxVolatility)
+	if !(clamped.VolatilityMultiplier >= 0) { // Also catches NaN
+		clamped.VolatilityMultiplier = 0
+	}
+	if clamped != settings {
+		log.Printf("⚠️ Simulation settings out of range (tick %dms, volatility x%.2f), using tick %dms, volatility x%.2f",
+			settings.TickIntervalMs, settings.VolatilityMultiplier, clamped.TickIntervalMs, clamped.VolatilityMultiplier)
+	}
+	return clamped
+}
+
+// GetSettings returns the current simulation settings. The simulator calls
+// it every tick, so Mongo is only read once the cache expires, and outside
+// the lock.
+func (s *SimulationService) GetSettings() models.SimulationSettings {
+	s.mu.Lock()
+	if time.Since(s.loadedAt) < simulationCacheTTL {
+		defer s.mu.Unlock()
+		return s.settings
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var stored struct {
+		Settings *models.SimulationSettings `bson:"settings"`
+	}
+	err := s.settingsCollection.FindOne(ctx, bson.M{"_id": "simulation"}).Decode(&stored)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error loading simulation settings: %v", err)
+		return s.settings
+	}
+	s.settings = s.defaults
+	if stored.Settings != nil {
+		s.settings = clampSimulationSettings(*stored.Settings)
+	}
+	s.loadedAt = time.Now()
+	return s.settings
+}
+
+// SetSettings validates and stores new simulation settings
+func (s *SimulationService) SetSettings(ctx context.Context, settings models.SimulationSettings) (models.SimulationSettings, error) {
+	if settings.TickIntervalMs < minTickIntervalMs || settings.TickIntervalMs > maxTickIntervalMs {
+		return s.GetSettings(), fmt.Errorf("tick interval must be between %d and %d ms", minTickIntervalMs, maxTickIntervalMs)
+	}
+	if settings.VolatilityMultiplier < 0 || settings.VolatilityMultiplier > maxVolatility {
+		return s.GetSettings(), fmt.Errorf("volatility multiplier must be between 0 and %v", maxVolatility)
+	}
+	settings.UpdatedAt = time.Now().UTC()
+
+	_, err := s.settingsCollection.UpdateOne(ctx,
+		bson.M{"_id": "simulation"},
+		bson.M{"$set": bson.M{"settings": settings}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return s.GetSettings(), err
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	log.Printf("🎛️ Simulation settings changed by %s: tick %dms, volatility x%.2f, paused %v",
+		settings.UpdatedBy, settings.TickIntervalMs, settings.VolatilityMultiplier, settings.Paused)
+	return settings, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+)
+
+// Longest an order command may take before the client gets a timeout
+const socketOrderTimeout = 10 * time.Second
+
+var (
+	errUnknownCommand     = errors.New("unknown command")
+	errSocketTrading      = errors.New("trading is not available on this connection")
+	errSocketAnonymous    = errors.New("connect with ?token= to trade over the socket")
+	errSocketMissing      = errors.New("order or orderId is required")
+	errTradingPaused      = errors.New("Trading is paused for maintenance")
+	errQuantityOrNotional = errors.New("exactly one of quantity or notional must be positive")
+)
+
+// SocketOrder is an order placed over the WebSocket. Market and limit orders
+// fill immediately; stop types rest like those placed over REST.
+type SocketOrder struct {
+	Symbol          string                 `json:"symbol"`
+	Type            string                 `json:"type"`      // "buy" or "sell"
+	OrderType       string                 `json:"orderType"` // "market", "limit", "stop", "stop_limit" or "trailing_stop"
+	Quantity        float64                `json:"quantity"`
+	Notional        float64                `json:"notional,omitempty"` // Dollar amount instead of a quantity
+	Price           float64                `json:"price"`
+	StopPrice       float64                `json:"stopPrice,omitempty"`
+	LimitPrice      float64                `json:"limitPrice,omitempty"`
+	TrailingPercent float64                `json:"trailingPercent,omitempty"`
+	OCOWith         string                 `json:"ocoWith,omitempty"`
+	AllowDuplicate  bool                   `json:"allowDuplicate,omitempty"`
+	ActivateAt      time.Time              `json:"activateAt,omitempty"`    // Hold the order as scheduled until then
+	Condition       *models.OrderCondition `json:"condition,omitempty"`     // Hold the order until another symbol's price condition holds
+	ExtendedHours   bool                   `json:"extendedHours,omitempty"` // May fill pre-market and after-hours
+}
+
+// SocketTrading places and cancels orders for authenticated WebSocket
+// clients through the same engine as the REST API
+type SocketTrading struct {
+	engine      *OrderEngine
+	advanced    *AdvancedOrderService
+	maintenance *MaintenanceService
+}
+
+func NewSocketTrading(engine *OrderEngine, advanced *AdvancedOrderService, maintenance *MaintenanceService) *SocketTrading {
+	return &SocketTrading{engine: engine, advanced: advanced, maintenance: maintenance}
+}
+
+// Handle runs a place_order or cancel_order command and returns the reply
+func (t *SocketTrading) Handle(client *WebSocketClient, cmd clientCommand) map[string]interface{} {
+	switch {
+	case t == nil:
+		return commandError(cmd, errSocketTrading)
+	case client.userID == "":
+		return commandError(cmd, errSocketAnonymous)
+	case t.maintenance.Active():
+		return commandError(cmd, errTradingPaused)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), socketOrderTimeout)
+	defer cancel()
+
+	if cmd.Action == "cancel_order" {
+		if cmd.OrderID == "" {
+			return commandError(cmd, errSocketMissing)
+		}
+		if err := t.cancelOrder(ctx, client.userID, cmd.OrderID); err != nil {
+			return commandError(cmd, err)
+		}
+		reply := commandReply(cmd)
+		reply["orderId"] = cmd.OrderID
+		return reply
+	}
+
+	if cmd.Order == nil {
+		return commandError(cmd, errSocketMissing)
+	}
+	req := cmd.Order
+	order := &models.Order{
+		UserID:          client.userID,
+		Symbol:          strings.ToUpper(req.Symbol),
+		Type:            req.Type,
+		OrderType:       req.OrderType,
+		Quantity:        req.Quantity,
+		Notional:        req.Notional,
+		Price:           req.Price,
+		StopPrice:       req.StopPrice,
+		LimitPrice:      req.LimitPrice,
+		TrailingPercent: req.TrailingPercent,
+		TenantID:        client.tenantID,
+		AllowDuplicate:  req.AllowDuplicate,
+		ActivateAt:      req.ActivateAt,
+		Condition:       req.Condition,
+		ExtendedHours:   req.ExtendedHours,
+		Timestamp:       time.Now().UTC(),
+	}
+	if (order.Quantity > 0) == (order.Notional > 0) {
+		return commandError(cmd, errQuantityOrNotional)
+	}
+	// Queued while the market is closed, to fill at the next open
+	queued := false
+	if strategy, ok := t.engine.strategies[order.OrderType]; ok && !strategy.Resting() && order.ActivateAt.IsZero() && order.Condition == nil {
+		order.ActivateAt, queued = t.engine.QueueUntil(order)
+	}
+
+	var err error
+	if strategy, ok := t.engine.strategies[order.OrderType]; (ok && strategy.Resting()) || !order.ActivateAt.IsZero() || order.Condition != nil {
+		err = t.advanced.CreateStopOrder(ctx, order, req.OCOWith)
+	} else {
+		err = t.engine.PlaceOrder(ctx, order)
+	}
+	if err != nil {
+		return commandError(cmd, err)
+	}
+	reply := commandReply(cmd)
+	reply["order"] = order
+	if queued {
+		reply["queued"] = true
+	}
+	return reply
+}
+
+// cancelOrder cancels one of the user's own active stop orders
+func (t *SocketTrading) cancelOrder(ctx context.Context, userID, orderID string) error {
+	active, err := t.advanced.GetActiveStopOrders(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, order := range active {
+		if order.ID.Hex() == orderID {
+			return t.advanced.CancelStopOrder(ctx, orderID)
+		}
+	}
+	return i18n.NewError("order.not_active")
+}
+
+// commandReply starts a successful reply to a command
+func commandReply(cmd clientCommand) map[string]interface{} {
+	reply := map[string]interface{}{
+		"type":   "response",
+		"action": cmd.Action,
+		"ok":     true,
+	}
+	if cmd.ID != "" {
+		reply["id"] = cmd.ID
+	}
+	return reply
+}
+
+// commandError is the reply to a failed command. Message-coded errors carry
+// their code like REST errors do.
+func commandError(cmd clientCommand, err error) map[string]interface{} {
+	reply := map[string]interface{}{
+		"type":   "error",
+		"action": cmd.Action,
+		"ok":     false,
+		"error":  err.Error(),
+	}
+	var msgErr *i18n.Error
+	if errors.As(err, &msgErr) {
+		reply["code"] = msgErr.Code
+	}
+	var closed *MarketClosedError
+	if errors.As(err, &closed) && !closed.NextOpen.IsZero() {
+		reply["nextOpen"] = closed.NextOpen
+	}
+	if cmd.ID != "" {
+		reply["id"] = cmd.ID
+	}
+	return reply
+}
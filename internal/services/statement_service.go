@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrStatementNotReady = errors.New("statement is available once the month has ended")
+	ErrNoStatement       = errors.New("no statement for this month")
+)
+
+// StatementService produces monthly statements of users' main accounts.
+// Statements are built after the month ends and stored as immutable
+// documents, so later corrections or purges of trades do not rewrite them.
+type StatementService struct {
+	statementCollection *mongo.Collection
+	userCollection      *mongo.Collection
+	executionCollection *mongo.Collection
+	ledgerCollection    *mongo.Collection
+	orderService        *OrderService
+	marketService       *MarketDataService
+	candleService       *CandleService
+}
+
+func NewStatementService(orderService *OrderService, marketService *MarketDataService, candleService *CandleService) *StatementService {
+	return &StatementService{
+		statementCollection: config.GetCollection("statements"),
+		userCollection:      config.GetCollection("users"),
+		executionCollection: config.GetCollection("executions"),
+		ledgerCollection:    config.GetCollection("ledger"),
+		orderService:        orderService,
+		marketService:       marketService,
+		candleService:       candleService,
+	}
+}
+
+// Get returns the user's statement for a month, generating it on first
+// request once the month is over
+func (s *StatementService) Get(ctx context.Context, userID string, year, month int) (*models.Statement, error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return nil, fmt.Errorf("invalid statement month %d-%02d", year, month)
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	if time.Now().Before(start.AddDate(0, 1, 0)) {
+		return nil, ErrStatementNotReady
+	}
+
+	var statement models.Statement
+	err := s.statementCollection.FindOne(ctx, bson.M{"_id": statementID(userID, start)}).Decode(&statement)
+	if err == nil {
+		return &statement, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": mustObjectID(userID)}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return s.generate(ctx, user, start)
+}
+
+// List returns the user's stored statements, newest first, without their
+// trades and ledger entries
+func (s *StatementService) List(ctx context.Context, userID string) ([]models.Statement, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "period_start", Value: -1}}).
+		SetProjection(bson.M{"trades": 0, "ledger_entries": 0, "positions": 0})
+	cursor, err := s.statementCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	statements := []models.Statement{}
+	err = cursor.All(ctx, &statements)
+	return statements, err
+}
+
+// GenerateMonth builds last month's statement for every account that
+// existed during it. Statements already stored are skipped.
+func (s *StatementService) GenerateMonth(ctx context.Context, now time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	end := start.AddDate(0, 1, 0)
+
+	cursor, err := s.userCollection.Find(ctx, bson.M{
+		"created_at": bson.M{"$lt": end},
+		"deleted_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		log.Printf("Error loading users for statements: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	generated := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			log.Printf("Error decoding user for statements: %v", err)
+			continue
+		}
+		exists, err := s.statementCollection.CountDocuments(ctx, bson.M{"_id": statementID(user.ID.Hex(), start)})
+		if err != nil || exists > 0 {
+			continue
+		}
+		if _, err := s.generate(ctx, user, start); err != nil {
+			log.Printf("Error generating %s statement for %s: %v", start.Format("2006-01"), user.Username, err)
+			continue
+		}
+		generated++
+	}
+	if generated > 0 {
+		log.Printf("🧾 Generated %d statements for %s", generated, start.Format("2006-01"))
+	}
+}
+
+// generate builds and stores the statement for the month starting at start.
+// Month-end cash and shares are worked back from the account now through
+// the trades and ledger entries since, so statements should be generated
+// soon after the month ends; movements without a record, such as splits,
+// are not undone.
+func (s *StatementService) generate(ctx context.Context, user models.User, start time.Time) (*models.Statement, error) {
+	userID := user.ID.Hex()
+	end := start.AddDate(0, 1, 0)
+	if !user.CreatedAt.IsZero() && !user.CreatedAt.Before(end) {
+		return nil, ErrNoStatement
+	}
+
+	trades, err := s.trades(ctx, userID, bson.M{"$gte": start, "$lt": end})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ledgerEntries(ctx, userID, bson.M{"$gte": start, "$lt": end})
+	if err != nil {
+		return nil, err
+	}
+	laterTrades, err := s.trades(ctx, userID, bson.M{"$gte": end})
+	if err != nil {
+		return nil, err
+	}
+	laterEntries, err := s.ledgerEntries(ctx, userID, bson.M{"$gte": end})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.Statement{
+		ID:            statementID(userID, start),
+		UserID:        userID,
+		Username:      user.Username,
+		Year:          start.Year(),
+		Month:         int(start.Month()),
+		PeriodStart:   start,
+		PeriodEnd:     end,
+		Trades:        trades,
+		LedgerEntries: entries,
+		Positions:     []models.StatementPosition{},
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	totals := &statement.Totals
+	for _, trade := range trades {
+		if trade.Side == "buy" {
+			totals.Purchases += trade.Price * trade.Quantity
+		} else {
+			totals.Proceeds += trade.Price * trade.Quantity
+		}
+		totals.TradingFees += trade.Fees
+	}
+	for _, entry := range entries {
+		if entry.Type == "borrow_fee" {
+			totals.BorrowFees -= entry.Amount
+		} else {
+			totals.Interest += entry.Amount
+		}
+	}
+	recorded := totals.Proceeds - totals.Purchases - totals.TradingFees - totals.BorrowFees + totals.Interest
+
+	closing := user.CashBalance - cashMovement(laterTrades, laterEntries)
+	opening := closing - recorded
+	var previous models.Statement
+	err = s.statementCollection.FindOne(ctx, bson.M{"_id": statementID(userID, start.AddDate(0, -1, 0))}).Decode(&previous)
+	if err == nil {
+		opening = previous.ClosingBalance
+		totals.OtherAdjustments = round2(closing - opening - recorded)
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	statement.OpeningBalance = round2(opening)
+	statement.ClosingBalance = round2(closing)
+	totals.Purchases = round2(totals.Purchases)
+	totals.Proceeds = round2(totals.Proceeds)
+	totals.TradingFees = round2(totals.TradingFees)
+	totals.BorrowFees = round2(totals.BorrowFees)
+	totals.Interest = round2(totals.Interest)
+
+	positions, err := s.orderService.GetUserPortfolio(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	shares := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		shares[pos.Symbol] += pos.Shares
+	}
+	for _, trade := range laterTrades {
+		if trade.Side == "buy" {
+			shares[trade.Symbol] -= trade.Quantity
+		} else {
+			shares[trade.Symbol] += trade.Quantity
+		}
+	}
+	for symbol, held := range shares {
+		held = roundQuantity(held)
+		if held == 0 {
+			continue
+		}
+		price, ok := s.candleService.CloseBefore(ctx, symbol, end)
+		if !ok {
+			price, _ = s.marketService.GetLastPrice(symbol)
+		}
+		value := round2(price * held)
+		statement.Positions = append(statement.Positions, models.StatementPosition{
+			Symbol:      symbol,
+			Shares:      held,
+			Price:       round2(price),
+			MarketValue: value,
+		})
+		statement.PositionsValue += value
+	}
+	sort.Slice(statement.Positions, func(i, j int) bool { return statement.Positions[i].Symbol < statement.Positions[j].Symbol })
+	statement.PositionsValue = round2(statement.PositionsValue)
+	statement.ClosingEquity = round2(statement.ClosingBalance + statement.PositionsValue)
+
+	if _, err := s.statementCollection.InsertOne(ctx, statement); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// Generated concurrently; the stored one wins
+			var stored models.Statement
+			err = s.statementCollection.FindOne(ctx, bson.M{"_id": statement.ID}).Decode(&stored)
+			return &stored, err
+		}
+		return nil, err
+	}
+	return statement, nil
+}
+
+// trades returns the main account's executions in a time range, oldest first
+func (s *StatementService) trades(ctx context.Context, userID string, window bson.M) ([]models.Execution, error) {
+	cursor, err := s.executionCollection.Find(ctx,
+		bson.M{"user_id": userID, "competition_id": bson.M{"$exists": false}, "executed_at": window},
+		options.Find().SetSort(bson.D{{Key: "executed_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	trades := []models.Execution{}
+	err = cursor.All(ctx, &trades)
+	return trades, err
+}
+
+// ledgerEntries returns the main account's ledger entries in a time range,
+// oldest first
+func (s *StatementService) ledgerEntries(ctx context.Context, userID string, window bson.M) ([]models.LedgerEntry, error) {
+	cursor, err := s.ledgerCollection.Find(ctx,
+		bson.M{"user_id": userID, "competition_id": bson.M{"$exists": false}, "created_at": window},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.LedgerEntry{}
+	err = cursor.All(ctx, &entries)
+	return entries, err
+}
+
+// cashMovement is the net change in cash from trades and ledger entries
+func cashMovement(trades []models.Execution, entries []models.LedgerEntry) float64 {
+	total := 0.0
+	for _, trade := range trades {
+		if trade.Side == "buy" {
+			total -= trade.Price*trade.Quantity + trade.Fees
+		} else {
+			total += trade.Price*trade.Quantity - trade.Fees
+		}
+	}
+	for _, entry := range entries {
+		total += entry.Amount
+	}
+	return total
+}
+
+func statementID(userID string, start time.Time) string {
+	return userID + ":" + start.Format("2006-01")
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const topSymbolsLimit = 10
+
+type StatsService struct {
+	userCollection          *mongo.Collection
+	orderCollection         *mongo.Collection
+	advancedOrderCollection *mongo.Collection
+	hub                     *WebSocketHub
+	cacheTTL                time.Duration
+
+	mu     sync.Mutex
+	cached map[string]*models.PlatformStats // By tenant ID, "" for the whole platform
+}
+
+func NewStatsService(hub *WebSocketHub) *StatsService {
+	return &StatsService{
+		userCollection:          config.GetCollection("users"),
+		orderCollection:         config.GetCollection("orders"),
+		advancedOrderCollection: config.GetCollection("advanced_orders"),
+		hub:                     hub,
+		cacheTTL:                time.Duration(config.GetEnvInt("ADMIN_STATS_CACHE_SECONDS", 60)) * time.Second,
+		cached:                  make(map[string]*models.PlatformStats),
+	}
+}
+
+// GetStats returns the aggregates for a tenant, or the whole platform for an
+// empty tenant ID, recomputing them once the cached copy is older than the
+// cache TTL. The connection count is always live and platform-wide.
+func (s *StatsService) GetStats(tenantID string) (*models.PlatformStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached := s.cached[tenantID]
+	if cached == nil || time.Since(cached.GeneratedAt) > s.cacheTTL {
+		stats, err := s.computeStats(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		s.cached[tenantID] = stats
+		cached = stats
+	}
+
+	stats := *cached
+	stats.WebSocketConnections = s.hub.ClientCount()
+	return &stats, nil
+}
+
+func (s *StatsService) computeStats(tenantID string) (*models.PlatformStats, error) {
+	ctx := context.Background()
+	// scoped limits a filter to the tenant's documents
+	scoped := func(filter bson.M) bson.M {
+		if tenantID != "" {
+			filter["tenant_id"] = tenantID
+		}
+		return filter
+	}
+	stats := &models.PlatformStats{
+		OrdersByType: make(map[string]int64),
+		TopSymbols:   []models.SymbolActivity{},
+		GeneratedAt:  time.Now().UTC(),
+	}
+
+	var err error
+	if stats.TotalUsers, err = s.userCollection.CountDocuments(ctx, scoped(bson.M{})); err != nil {
+		return nil, err
+	}
+
+	var active []struct {
+		Count int64 `bson:"count"`
+	}
+	err = s.aggregate(ctx, s.orderCollection, mongo.Pipeline{
+		{{Key: "$match", Value: scoped(bson.M{"timestamp": bson.M{"$gte": time.Now().Add(-24 * time.Hour)}})}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id"}}},
+		{{Key: "$count", Value: "count"}},
+	}, &active)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		stats.DailyActiveTraders = active[0].Count
+	}
+
+	byType := mongo.Pipeline{
+		{{Key: "$match", Value: scoped(bson.M{})}},
+		{{Key: "$group", Value: bson.M{"_id": "$order_type", "count": bson.M{"$sum": 1}}}},
+	}
+	for _, collection := range []*mongo.Collection{s.orderCollection, s.advancedOrderCollection} {
+		var counts []struct {
+			OrderType string `bson:"_id"`
+			Count     int64  `bson:"count"`
+		}
+		if err := s.aggregate(ctx, collection, byType, &counts); err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			stats.OrdersByType[c.OrderType] += c.Count
+		}
+	}
+
+	notional := bson.M{"$multiply": bson.A{"$price", "$quantity"}}
+	var volume []struct {
+		Total float64 `bson:"total"`
+	}
+	err = s.aggregate(ctx, s.orderCollection, mongo.Pipeline{
+		{{Key: "$match", Value: scoped(bson.M{"status": "filled"})}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": notional}}}},
+	}, &volume)
+	if err != nil {
+		return nil, err
+	}
+	if len(volume) > 0 {
+		stats.TotalVolume = round2(volume[0].Total)
+	}
+
+	err = s.aggregate(ctx, s.orderCollection, mongo.Pipeline{
+		{{Key: "$match", Value: scoped(bson.M{"status": "filled"})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$symbol",
+			"order_count": bson.M{"$sum": 1},
+			"volume":      bson.M{"$sum": notional},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "volume", Value: -1}}}},
+		{{Key: "$limit", Value: topSymbolsLimit}},
+	}, &stats.TopSymbols)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats.TopSymbols {
+		stats.TopSymbols[i].Volume = round2(stats.TopSymbols[i].Volume)
+	}
+
+	return stats, nil
+}
+
+func (s *StatsService) aggregate(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"trading-simulator/internal/models"
+)
+
+// Limits on strategy definitions
+const (
+	maxStrategyConditions = 10
+	maxIndicatorPeriod    = 200
+	defaultAveragePeriod  = 20
+	defaultRSIPeriod      = 14
+)
+
+var strategyOps = map[string]bool{">": true, ">=": true, "<": true, "<=": true, "crosses_above": true, "crosses_below": true}
+
+// validateStrategyRules checks a rule set and fills in default periods and
+// allocation
+func (s *StrategyService) validateStrategyRules(rules *models.StrategyRules) error {
+	rules.Symbol = strings.ToUpper(strings.TrimSpace(rules.Symbol))
+	if !s.symbolService.HasSymbol(rules.Symbol) {
+		return fmt.Errorf("unknown symbol %q", rules.Symbol)
+	}
+	if len(rules.Entry) == 0 || len(rules.Entry) > maxStrategyConditions {
+		return fmt.Errorf("a strategy needs 1-%d entry conditions", maxStrategyConditions)
+	}
+	if len(rules.Exit) > maxStrategyConditions {
+		return fmt.Errorf("a strategy can have at most %d exit conditions", maxStrategyConditions)
+	}
+	for _, conditions := range [][]models.StrategyCondition{rules.Entry, rules.Exit} {
+		for i := range conditions {
+			if err := validateCondition(&conditions[i]); err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case rules.StopLossPercent < 0 || rules.StopLossPercent >= 100:
+		return fmt.Errorf("stop loss must be between 0 and 100 percent")
+	case rules.TakeProfitPercent < 0:
+		return fmt.Errorf("take profit cannot be negative")
+	case rules.AllocationPercent < 0 || rules.AllocationPercent > 100:
+		return fmt.Errorf("allocation must be between 0 and 100 percent")
+	}
+	if rules.AllocationPercent == 0 {
+		rules.AllocationPercent = 100
+	}
+	return nil
+}
+
+func validateCondition(condition *models.StrategyCondition) error {
+	if !strategyOps[condition.Op] {
+		return fmt.Errorf("unsupported operator %q", condition.Op)
+	}
+	if err := validateOperand(&condition.StrategyOperand); err != nil {
+		return err
+	}
+	if condition.Compare != nil {
+		return validateOperand(condition.Compare)
+	}
+	return nil
+}
+
+func validateOperand(operand *models.StrategyOperand) error {
+	operand.Indicator = strings.ToLower(operand.Indicator)
+	switch operand.Indicator {
+	case "price", "change_percent":
+		operand.Period = 0
+	case "sma", "ema", "rsi":
+		if operand.Period == 0 {
+			operand.Period = defaultAveragePeriod
+			if operand.Indicator == "rsi" {
+				operand.Period = defaultRSIPeriod
+			}
+		}
+		if operand.Period < 1 || operand.Period > maxIndicatorPeriod {
+			return fmt.Errorf("%s period must be 1-%d", operand.Indicator, maxIndicatorPeriod)
+		}
+	default:
+		return fmt.Errorf("unsupported indicator %q", operand.Indicator)
+	}
+	return nil
+}
+
+// conditionsHold reports whether every condition holds at prices[i]
+func conditionsHold(conditions []models.StrategyCondition, prices []float64, i int) bool {
+	for _, condition := range conditions {
+		if !conditionHolds(condition, prices, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyConditionHolds reports whether at least one condition holds at prices[i]
+func anyConditionHolds(conditions []models.StrategyCondition, prices []float64, i int) bool {
+	for _, condition := range conditions {
+		if conditionHolds(condition, prices, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionHolds evaluates a condition at prices[i]. Conditions whose
+// indicators do not have enough history yet never hold.
+func conditionHolds(condition models.StrategyCondition, prices []float64, i int) bool {
+	left, ok := indicatorValue(condition.StrategyOperand, prices, i)
+	if !ok {
+		return false
+	}
+	right, ok := comparedValue(condition, prices, i)
+	if !ok {
+		return false
+	}
+
+	switch condition.Op {
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	}
+
+	prevLeft, ok := indicatorValue(condition.StrategyOperand, prices, i-1)
+	if !ok {
+		return false
+	}
+	prevRight, ok := comparedValue(condition, prices, i-1)
+	if !ok {
+		return false
+	}
+	if condition.Op == "crosses_above" {
+		return prevLeft <= prevRight && left > right
+	}
+	return prevLeft >= prevRight && left < right
+}
+
+func comparedValue(condition models.StrategyCondition, prices []float64, i int) (float64, bool) {
+	if condition.Compare == nil {
+		return condition.Value, i >= 0
+	}
+	return indicatorValue(*condition.Compare, prices, i)
+}
+
+// indicatorValue computes an indicator over prices[:i+1]
+func indicatorValue(operand models.StrategyOperand, prices []float64, i int) (float64, bool) {
+	if i < 0 || i >= len(prices) {
+		return 0, false
+	}
+	period := operand.Period
+	switch operand.Indicator {
+	case "price":
+		return prices[i], true
+	case "change_percent":
+		if i == 0 || prices[i-1] == 0 {
+			return 0, false
+		}
+		return (prices[i] - prices[i-1]) / prices[i-1] * 100, true
+	case "sma":
+		if i+1 < period {
+			return 0, false
+		}
+		sum := 0.0
+		for _, price := range prices[i+1-period : i+1] {
+			sum += price
+		}
+		return sum / float64(period), true
+	case "ema":
+		if i+1 < period {
+			return 0, false
+		}
+		// Seeded with the average of the first period prices
+		ema := 0.0
+		for _, price := range prices[:period] {
+			ema += price
+		}
+		ema /= float64(period)
+		k := 2 / float64(period+1)
+		for _, price := range prices[period : i+1] {
+			ema = price*k + ema*(1-k)
+		}
+		return ema, true
+	case "rsi":
+		if i < period {
+			return 0, false
+		}
+		// Wilder's smoothing over every change up to i
+		var gain, loss float64
+		for j := 1; j <= i; j++ {
+			change := prices[j] - prices[j-1]
+			up, down := math.Max(change, 0), math.Max(-change, 0)
+			if j <= period {
+				gain += up / float64(period)
+				loss += down / float64(period)
+				continue
+			}
+			gain = (gain*float64(period-1) + up) / float64(period)
+			loss = (loss*float64(period-1) + down) / float64(period)
+		}
+		if loss == 0 {
+			return 100, true
+		}
+		return 100 - 100/(1+gain/loss), true
+	}
+	return 0, false
+}
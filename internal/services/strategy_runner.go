@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ticks kept per symbol for live indicators, enough for the longest period
+// of every indicator
+const liveHistorySize = 2*maxIndicatorPeriod + 1
+
+var (
+	// ErrStrategyRunning is returned when starting a strategy that already runs
+	ErrStrategyRunning = errors.New("strategy is already running")
+	// ErrStrategyNotRunning is returned when stopping a strategy that does not run
+	ErrStrategyNotRunning = errors.New("strategy is not running")
+	// ErrLiveRunLimit is returned when a user already runs their maximum of strategies
+	ErrLiveRunLimit = errors.New("live strategy limit reached")
+	// ErrStrategyBusy is returned when stopping a run while it has an order in flight
+	ErrStrategyBusy = errors.New("strategy is placing an order, try again")
+)
+
+// StrategyRunner trades users' strategies live. Each running strategy is
+// evaluated on every tick of its symbol and places market orders through the
+// order engine, so the usual symbol rules, throttling and buying power checks
+// apply. Entries are capped at STRATEGY_MAX_ORDER_VALUE and a run stops itself
+// once it has lost STRATEGY_MAX_LOSS_PERCENT of the cash it started with.
+type StrategyRunner struct {
+	strategies          *StrategyService
+	engine              *OrderEngine
+	marketService       *MarketDataService
+	maintenance         *MaintenanceService
+	runCollection       *mongo.Collection
+	logCollection       *mongo.Collection
+	executionCollection *mongo.Collection
+	maxOrderValue       float64
+	maxLossPercent      float64
+	perUser             int
+
+	mu     sync.Mutex
+	runs   map[string]*liveRun  // Run ID -> run
+	prices map[string][]float64 // Symbol -> recent tick prices
+}
+
+// liveRun is a running strategy. busy is set while an order is in flight so
+// later ticks do not act on a position that is about to change.
+type liveRun struct {
+	run   models.StrategyRun
+	rules models.StrategyRules
+	busy  bool
+}
+
+func NewStrategyRunner(strategies *StrategyService, engine *OrderEngine, marketService *MarketDataService, maintenance *MaintenanceService) *StrategyRunner {
+	return &StrategyRunner{
+		strategies:          strategies,
+		engine:              engine,
+		marketService:       marketService,
+		maintenance:         maintenance,
+		runCollection:       config.GetCollection("strategy_runs"),
+		logCollection:       config.GetCollection("strategy_run_logs"),
+		executionCollection: config.GetCollection("executions"),
+		maxOrderValue:       config.GetEnvFloat("STRATEGY_MAX_ORDER_VALUE", 10000),
+		maxLossPercent:      config.GetEnvFloat("STRATEGY_MAX_LOSS_PERCENT", 10),
+		perUser:             config.GetEnvInt("STRATEGY_LIVE_RUNS_PER_USER", 3),
+		runs:                make(map[string]*liveRun),
+		prices:              make(map[string][]float64),
+	}
+}
+
+// Load resumes the live runs that were running when the server stopped.
+// Indicators warm up again from new ticks.
+func (r *StrategyRunner) Load(ctx context.Context) {
+	cursor, err := r.runCollection.Find(ctx, bson.M{"mode": "live", "status": "running"})
+	if err != nil {
+		log.Printf("Error loading live strategy runs: %v", err)
+		return
+	}
+	var stored []models.StrategyRun
+	if err := cursor.All(ctx, &stored); err != nil {
+		log.Printf("Error decoding live strategy runs: %v", err)
+		return
+	}
+
+	for _, run := range stored {
+		strategy, err := r.strategies.find(ctx, run.StrategyID)
+		if err != nil {
+			r.finish(ctx, &liveRun{run: run}, "deleted")
+			continue
+		}
+		r.mu.Lock()
+		r.runs[run.ID.Hex()] = &liveRun{run: run, rules: strategy.Rules}
+		r.mu.Unlock()
+	}
+	if len(stored) > 0 {
+		log.Printf("🤖 Resumed %d live strategy runs", len(stored))
+	}
+}
+
+// Start begins trading one of the user's strategies live. Changes to the
+// strategy's rules take effect the next time it is started.
+func (r *StrategyRunner) Start(ctx context.Context, strategyID, userID, tenantID string) (*models.StrategyRun, error) {
+	strategy, err := r.strategies.owned(ctx, strategyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	running := 0
+	for _, live := range r.runs {
+		if live.run.UserID != userID {
+			continue
+		}
+		if live.run.StrategyID == strategyID {
+			r.mu.Unlock()
+			return nil, ErrStrategyRunning
+		}
+		running++
+	}
+	r.mu.Unlock()
+	if r.perUser > 0 && running >= r.perUser {
+		return nil, ErrLiveRunLimit
+	}
+
+	cash := r.engine.orderService.GetBuyingPower(ctx, userID)
+	if cash <= 0 {
+		return nil, fmt.Errorf("no buying power to run the strategy with")
+	}
+	now := time.Now().UTC()
+	run := models.StrategyRun{
+		StrategyID:   strategyID,
+		UserID:       userID,
+		Mode:         "live",
+		Symbol:       strategy.Rules.Symbol,
+		From:         now,
+		StartingCash: cash,
+		Trades:       []models.StrategyTrade{},
+		CreatedAt:    now,
+		Status:       "running",
+		TenantID:     tenantID,
+	}
+	result, err := r.runCollection.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+
+	r.mu.Lock()
+	r.runs[run.ID.Hex()] = &liveRun{run: run, rules: strategy.Rules}
+	r.mu.Unlock()
+	r.log(run, "start", fmt.Sprintf("Started with $%.2f buying power", cash), 0, "")
+	log.Printf("🤖 Strategy %s started for user %s on %s", strategyID, userID, run.Symbol)
+	return &run, nil
+}
+
+// Stop ends the user's live run of a strategy. An open position is kept in
+// the account and counted in the run's final equity at the last price.
+func (r *StrategyRunner) Stop(ctx context.Context, strategyID, userID, reason string) (*models.StrategyRun, error) {
+	r.mu.Lock()
+	var live *liveRun
+	for id, candidate := range r.runs {
+		if candidate.run.StrategyID == strategyID && candidate.run.UserID == userID {
+			if candidate.busy {
+				r.mu.Unlock()
+				return nil, ErrStrategyBusy
+			}
+			live = candidate
+			delete(r.runs, id)
+			break
+		}
+	}
+	r.mu.Unlock()
+	if live == nil {
+		return nil, ErrStrategyNotRunning
+	}
+	run := r.finish(ctx, live, reason)
+	return &run, nil
+}
+
+// OnTick evaluates every running strategy on the tick's symbol
+func (r *StrategyRunner) OnTick(stock models.Stock) {
+	symbol := strings.ToUpper(stock.Symbol)
+	price := stock.Price
+	if price <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	prices := append(r.prices[symbol], price)
+	if len(prices) > liveHistorySize {
+		prices = prices[len(prices)-liveHistorySize:]
+	}
+	r.prices[symbol] = prices
+	if r.maintenance.Active() {
+		r.mu.Unlock()
+		return
+	}
+
+	var actions []func()
+	last := len(prices) - 1
+	for _, live := range r.runs {
+		if live.run.Symbol != symbol || live.busy {
+			continue
+		}
+		live := live
+		position := live.run.Position
+		switch {
+		case position != nil && r.lostTooMuch(live.run, price):
+			live.busy = true
+			actions = append(actions, func() { r.stopForLoss(live) })
+		case position == nil && conditionsHold(live.rules.Entry, prices, last):
+			live.busy = true
+			actions = append(actions, func() { r.enter(live, price) })
+		case position != nil:
+			reason := exitReason(live.rules, position.EntryPrice, prices, last)
+			if reason != "" {
+				live.busy = true
+				actions = append(actions, func() { r.exit(live, price, reason) })
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	// Orders go through the database, so they are placed off the tick path
+	for _, action := range actions {
+		go action()
+	}
+}
+
+// exitReason returns why an open position should be sold at prices[i], or ""
+func exitReason(rules models.StrategyRules, entryPrice float64, prices []float64, i int) string {
+	price := prices[i]
+	switch {
+	case rules.StopLossPercent > 0 && price <= entryPrice*(1-rules.StopLossPercent/100):
+		return "stop_loss"
+	case rules.TakeProfitPercent > 0 && price >= entryPrice*(1+rules.TakeProfitPercent/100):
+		return "take_profit"
+	case anyConditionHolds(rules.Exit, prices, i):
+		return "exit"
+	}
+	return ""
+}
+
+// lostTooMuch reports whether the run's realized and open losses have
+// reached the loss limit. Called with r.mu held.
+func (r *StrategyRunner) lostTooMuch(run models.StrategyRun, price float64) bool {
+	if r.maxLossPercent <= 0 {
+		return false
+	}
+	return -runProfitLoss(run, price) >= run.StartingCash*r.maxLossPercent/100
+}
+
+// enter buys the strategy's allocation of the user's buying power
+func (r *StrategyRunner) enter(live *liveRun, price float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer r.release(live)
+
+	budget := r.engine.orderService.GetBuyingPower(ctx, live.run.UserID) * live.rules.AllocationPercent / 100
+	if r.maxOrderValue > 0 {
+		budget = math.Min(budget, r.maxOrderValue)
+	}
+	quantity := math.Floor(budget/price*quantityPrecision) / quantityPrecision
+	if quantity <= 0 {
+		r.log(live.run, "rejected", "Not enough buying power to enter", price, "")
+		return
+	}
+
+	order, err := r.place(ctx, live.run, "buy", quantity, price)
+	if err != nil {
+		r.log(live.run, "rejected", "Entry rejected: "+err.Error(), price, "")
+		return
+	}
+
+	r.mu.Lock()
+	live.run.Position = &models.StrategyTrade{EntryAt: order.Timestamp, EntryPrice: order.Price, Quantity: order.Quantity}
+	live.run.Orders++
+	run := live.run
+	r.mu.Unlock()
+	r.save(ctx, run)
+	r.log(run, "entry", fmt.Sprintf("Bought %g %s", order.Quantity, order.Symbol), order.Price, order.ID.Hex())
+}
+
+// exit sells the run's open position
+func (r *StrategyRunner) exit(live *liveRun, price float64, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer r.release(live)
+
+	r.mu.Lock()
+	position := *live.run.Position
+	run := live.run
+	r.mu.Unlock()
+
+	order, err := r.place(ctx, run, "sell", position.Quantity, price)
+	if err != nil {
+		r.log(run, "rejected", "Exit rejected: "+err.Error(), price, "")
+		return
+	}
+
+	position.ExitAt, position.ExitPrice, position.Reason = order.Timestamp, order.Price, reason
+	position.ProfitLoss = round2((position.ExitPrice - position.EntryPrice) * position.Quantity)
+	r.mu.Lock()
+	live.run.Position = nil
+	live.run.Trades = append(live.run.Trades, position)
+	live.run.RealizedProfitLoss = round2(live.run.RealizedProfitLoss + position.ProfitLoss)
+	live.run.Orders++
+	run = live.run
+	r.mu.Unlock()
+	r.save(ctx, run)
+	r.log(run, "exit", fmt.Sprintf("Sold %g %s (%s), P&L $%.2f", order.Quantity, order.Symbol, reason, position.ProfitLoss), order.Price, order.ID.Hex())
+}
+
+// stopForLoss stops a run that hit the loss limit
+func (r *StrategyRunner) stopForLoss(live *liveRun) {
+	r.mu.Lock()
+	delete(r.runs, live.run.ID.Hex())
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r.finish(ctx, live, "max_loss")
+	log.Printf("🤖 Strategy %s of user %s stopped at the loss limit", live.run.StrategyID, live.run.UserID)
+}
+
+// release lets ticks act on the run again
+func (r *StrategyRunner) release(live *liveRun) {
+	r.mu.Lock()
+	live.busy = false
+	r.mu.Unlock()
+}
+
+// place submits a market order tagged with the run's strategy
+func (r *StrategyRunner) place(ctx context.Context, run models.StrategyRun, side string, quantity, price float64) (*models.Order, error) {
+	order := &models.Order{
+		UserID:         run.UserID,
+		Symbol:         run.Symbol,
+		Type:           side,
+		OrderType:      "market",
+		Quantity:       quantity,
+		Price:          price,
+		TenantID:       run.TenantID,
+		StrategyID:     run.StrategyID,
+		AllowDuplicate: true,
+	}
+	if err := r.engine.PlaceOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// finish marks a run stopped with its final equity at the last price
+func (r *StrategyRunner) finish(ctx context.Context, live *liveRun, reason string) models.StrategyRun {
+	r.mu.Lock()
+	run := live.run
+	r.mu.Unlock()
+
+	price, _ := r.marketService.GetLastPrice(run.Symbol)
+	profitLoss := runProfitLoss(run, price)
+	run.Status = "stopped"
+	run.StopReason = reason
+	run.To = time.Now().UTC()
+	run.FinalEquity = round2(run.StartingCash + profitLoss)
+	run.ReturnPercent = round2(profitLoss / run.StartingCash * 100)
+	if len(run.Trades) > 0 {
+		wins := 0
+		for _, trade := range run.Trades {
+			if trade.ProfitLoss > 0 {
+				wins++
+			}
+		}
+		run.WinRatePercent = round2(float64(wins) / float64(len(run.Trades)) * 100)
+	}
+	r.save(ctx, run)
+	r.log(run, "stop", fmt.Sprintf("Stopped (%s) with P&L $%.2f", reason, profitLoss), price, "")
+	return run
+}
+
+// runProfitLoss is the run's realized P&L plus its open position at price
+func runProfitLoss(run models.StrategyRun, price float64) float64 {
+	profitLoss := run.RealizedProfitLoss
+	if run.Position != nil && price > 0 {
+		profitLoss += (price - run.Position.EntryPrice) * run.Position.Quantity
+	}
+	return profitLoss
+}
+
+// Logs returns the steps of one of the user's live runs, newest first
+func (r *StrategyRunner) Logs(ctx context.Context, strategyID, runID, userID string, limit int64) ([]models.StrategyRunLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.logCollection.Find(ctx, bson.M{"run_id": runID, "strategy_id": strategyID, "user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := []models.StrategyRunLog{}
+	err = cursor.All(ctx, &logs)
+	return logs, err
+}
+
+// ProfitLoss attributes the user's fills to the strategies whose live runs
+// placed them. Shares still held are valued at the last price.
+func (r *StrategyRunner) ProfitLoss(ctx context.Context, userID string) ([]models.StrategyProfitLoss, error) {
+	cursor, err := r.executionCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "strategy_id": bson.M{"$nin": bson.A{nil, ""}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$strategy_id",
+			"symbol": bson.M{"$first": "$symbol"},
+			"fills":  bson.M{"$sum": 1},
+			"invested": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$side", "buy"}}, bson.M{"$multiply": bson.A{"$price", "$quantity"}}, 0,
+			}}},
+			"proceeds": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$side", "sell"}}, bson.M{"$multiply": bson.A{"$price", "$quantity"}}, 0,
+			}}},
+			"fees": bson.M{"$sum": "$fees"},
+			"shares_held": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$side", "buy"}}, "$quantity", bson.M{"$multiply": bson.A{"$quantity", -1}},
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attribution := []models.StrategyProfitLoss{}
+	if err := cursor.All(ctx, &attribution); err != nil {
+		return nil, err
+	}
+	for i := range attribution {
+		pl := &attribution[i]
+		if strategy, err := r.strategies.find(ctx, pl.StrategyID); err == nil {
+			pl.Name = strategy.Name
+		}
+		pl.SharesHeld = roundQuantity(pl.SharesHeld)
+		if price, ok := r.marketService.GetLastPrice(pl.Symbol); ok {
+			pl.PositionValue = round2(pl.SharesHeld * price)
+		}
+		pl.Invested, pl.Proceeds, pl.Fees = round2(pl.Invested), round2(pl.Proceeds), round2(pl.Fees)
+		pl.ProfitLoss = round2(pl.Proceeds + pl.PositionValue - pl.Invested - pl.Fees)
+	}
+	return attribution, nil
+}
+
+func (r *StrategyRunner) save(ctx context.Context, run models.StrategyRun) {
+	if _, err := r.runCollection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run); err != nil {
+		log.Printf("Error saving strategy run %s: %v", run.ID.Hex(), err)
+	}
+}
+
+func (r *StrategyRunner) log(run models.StrategyRun, event, message string, price float64, orderID string) {
+	entry := models.StrategyRunLog{
+		RunID:      run.ID.Hex(),
+		StrategyID: run.StrategyID,
+		UserID:     run.UserID,
+		Event:      event,
+		Message:    message,
+		Price:      price,
+		OrderID:    orderID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := r.logCollection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Error writing strategy run log: %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Candle history a paper run covers unless the request asks for another span
+const defaultPaperRunDays = 180
+
+var (
+	// ErrStrategyNotFound is returned for a strategy that does not exist or
+	// is neither the user's own nor published
+	ErrStrategyNotFound = errors.New("strategy not found")
+	// ErrNotStrategyOwner is returned when a user changes someone else's strategy
+	ErrNotStrategyOwner = errors.New("only the owner can change this strategy")
+	// ErrStrategyLimit is returned when a user already has their maximum of strategies
+	ErrStrategyLimit = errors.New("strategy limit reached")
+	// ErrNotEnoughHistory is returned when a symbol has too few candles to paper-run
+	ErrNotEnoughHistory = errors.New("not enough price history to run the strategy")
+)
+
+// StrategyService stores rule-based strategies, shares published ones in the
+// marketplace and paper-runs them over candle history
+type StrategyService struct {
+	strategyCollection *mongo.Collection
+	runCollection      *mongo.Collection
+	logCollection      *mongo.Collection
+	symbolService      *SymbolService
+	candleService      *CandleService
+	orderService       *OrderService
+	perUser            int
+}
+
+func NewStrategyService(symbolService *SymbolService, candleService *CandleService, orderService *OrderService) *StrategyService {
+	return &StrategyService{
+		strategyCollection: config.GetCollection("strategies"),
+		runCollection:      config.GetCollection("strategy_runs"),
+		logCollection:      config.GetCollection("strategy_run_logs"),
+		symbolService:      symbolService,
+		candleService:      candleService,
+		orderService:       orderService,
+		perUser:            config.GetEnvInt("STRATEGIES_PER_USER", 20),
+	}
+}
+
+// Create validates and stores a new, unpublished strategy
+func (s *StrategyService) Create(ctx context.Context, strategy models.Strategy) (*models.Strategy, error) {
+	if err := s.validate(&strategy); err != nil {
+		return nil, err
+	}
+	if err := s.checkLimit(ctx, strategy.UserID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	strategy.ID = primitive.NilObjectID
+	strategy.Published = false
+	strategy.Clones = 0
+	strategy.Stats = models.StrategyStats{}
+	strategy.CreatedAt, strategy.UpdatedAt = now, now
+	result, err := s.strategyCollection.InsertOne(ctx, strategy)
+	if err != nil {
+		return nil, err
+	}
+	strategy.ID = result.InsertedID.(primitive.ObjectID)
+	return &strategy, nil
+}
+
+// Update replaces the name, description and rules of the user's strategy
+func (s *StrategyService) Update(ctx context.Context, id, userID string, update models.Strategy) (*models.Strategy, error) {
+	strategy, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	update.UserID = userID
+	if err := s.validate(&update); err != nil {
+		return nil, err
+	}
+
+	strategy.Name, strategy.Description, strategy.Rules = update.Name, update.Description, update.Rules
+	strategy.UpdatedAt = time.Now().UTC()
+	_, err = s.strategyCollection.UpdateOne(ctx, bson.M{"_id": strategy.ID}, bson.M{"$set": bson.M{
+		"name":        strategy.Name,
+		"description": strategy.Description,
+		"rules":       strategy.Rules,
+		"updated_at":  strategy.UpdatedAt,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return strategy, nil
+}
+
+// SetPublished lists the user's strategy in the marketplace or withdraws it
+func (s *StrategyService) SetPublished(ctx context.Context, id, userID string, published bool) (*models.Strategy, error) {
+	strategy, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	strategy.Published = published
+	strategy.UpdatedAt = time.Now().UTC()
+	_, err = s.strategyCollection.UpdateOne(ctx, bson.M{"_id": strategy.ID}, bson.M{"$set": bson.M{
+		"published":  published,
+		"updated_at": strategy.UpdatedAt,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return strategy, nil
+}
+
+// Delete removes the user's strategy with its runs and their logs. Clones,
+// and fills its live runs made, are kept.
+func (s *StrategyService) Delete(ctx context.Context, id, userID string) error {
+	strategy, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.strategyCollection.DeleteOne(ctx, bson.M{"_id": strategy.ID}); err != nil {
+		return err
+	}
+	if _, err := s.runCollection.DeleteMany(ctx, bson.M{"strategy_id": id}); err != nil {
+		return err
+	}
+	_, err = s.logCollection.DeleteMany(ctx, bson.M{"strategy_id": id})
+	return err
+}
+
+// Get returns a strategy the user owns, or a published one in their tenant
+func (s *StrategyService) Get(ctx context.Context, id, userID, tenantID string) (*models.Strategy, error) {
+	strategy, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if strategy.UserID != userID && (!strategy.Published || strategy.TenantID != tenantID) {
+		return nil, ErrStrategyNotFound
+	}
+	return strategy, nil
+}
+
+// ListOwn returns the user's strategies, newest first
+func (s *StrategyService) ListOwn(ctx context.Context, userID string) ([]models.Strategy, error) {
+	return s.list(ctx, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: -1}}, 0)
+}
+
+// Marketplace returns the published strategies in the tenant. sortBy is
+// "return" (average paper-run return, the default), "runs", "clones" or
+// "newest".
+func (s *StrategyService) Marketplace(ctx context.Context, tenantID, sortBy string, limit int64) ([]models.Strategy, error) {
+	filter := bson.M{"published": true}
+	if tenantID != "" {
+		filter["tenant_id"] = tenantID
+	} else {
+		filter["tenant_id"] = bson.M{"$exists": false}
+	}
+	sortField := map[string]string{
+		"runs":   "stats.runs",
+		"clones": "clones",
+		"newest": "created_at",
+	}[sortBy]
+	if sortField == "" {
+		sortField = "stats.avg_return_percent"
+	}
+	return s.list(ctx, filter, bson.D{{Key: sortField, Value: -1}, {Key: "_id", Value: -1}}, limit)
+}
+
+// Clone copies a published strategy, or one of the user's own, into a new
+// unpublished strategy owned by the user
+func (s *StrategyService) Clone(ctx context.Context, id, userID, tenantID string) (*models.Strategy, error) {
+	source, err := s.Get(ctx, id, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	clone, err := s.Create(ctx, models.Strategy{
+		UserID:      userID,
+		Name:        source.Name,
+		Description: source.Description,
+		Rules:       source.Rules,
+		ClonedFrom:  source.ID.Hex(),
+		TenantID:    tenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if source.UserID != userID {
+		if _, err := s.strategyCollection.UpdateOne(ctx, bson.M{"_id": source.ID}, bson.M{"$inc": bson.M{"clones": 1}}); err != nil {
+			log.Printf("Error counting clone of strategy %s: %v", id, err)
+		}
+	}
+	return clone, nil
+}
+
+// PaperRun simulates the strategy over the last days of its symbol's daily
+// candles, trading on closes from the user's current buying power. No orders
+// are placed; the run is stored and the strategy's statistics updated.
+func (s *StrategyService) PaperRun(ctx context.Context, id, userID, tenantID string, days int) (*models.StrategyRun, error) {
+	strategy, err := s.Get(ctx, id, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = defaultPaperRunDays
+	}
+	candles, err := s.candleService.GetCandles(ctx, strategy.Rules.Symbol, days)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) < 2 {
+		return nil, ErrNotEnoughHistory
+	}
+	cash := s.orderService.GetBuyingPower(ctx, userID)
+	if cash <= 0 {
+		return nil, fmt.Errorf("no buying power to paper-run with")
+	}
+
+	run := paperRun(strategy.Rules, candles, cash)
+	run.StrategyID = id
+	run.UserID = userID
+	run.CreatedAt = time.Now().UTC()
+	result, err := s.runCollection.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+
+	if err := s.refreshStats(ctx, strategy.ID); err != nil {
+		log.Printf("Error updating stats of strategy %s: %v", id, err)
+	}
+	return &run, nil
+}
+
+// Runs returns the user's runs of a strategy, newest first
+func (s *StrategyService) Runs(ctx context.Context, id, userID string, limit int64) ([]models.StrategyRun, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.runCollection.Find(ctx, bson.M{"strategy_id": id, "user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.StrategyRun{}
+	err = cursor.All(ctx, &runs)
+	return runs, err
+}
+
+// paperRun trades the rules on each candle's close. Each entry spends the
+// allocation share of cash on whole lots of 0.0001; a position still open
+// after the last candle is closed at its close.
+func paperRun(rules models.StrategyRules, candles []models.Candle, startingCash float64) models.StrategyRun {
+	prices := make([]float64, len(candles))
+	for i, candle := range candles {
+		prices[i] = candle.Close
+	}
+
+	run := models.StrategyRun{
+		Mode:         "paper",
+		Symbol:       rules.Symbol,
+		From:         candles[0].Time,
+		To:           candles[len(candles)-1].Time,
+		StartingCash: startingCash,
+		Trades:       []models.StrategyTrade{},
+	}
+	cash, peak := startingCash, startingCash
+	var open *models.StrategyTrade
+	closePosition := func(i int, reason string) {
+		open.ExitAt, open.ExitPrice, open.Reason = candles[i].Time, prices[i], reason
+		open.ProfitLoss = round2((open.ExitPrice - open.EntryPrice) * open.Quantity)
+		cash += open.Quantity * open.ExitPrice
+		run.Trades = append(run.Trades, *open)
+		open = nil
+	}
+
+	for i, price := range prices {
+		if open == nil {
+			if conditionsHold(rules.Entry, prices, i) {
+				quantity := math.Floor(cash*rules.AllocationPercent/100/price*quantityPrecision) / quantityPrecision
+				if quantity > 0 {
+					cash -= quantity * price
+					open = &models.StrategyTrade{EntryAt: candles[i].Time, EntryPrice: price, Quantity: quantity}
+				}
+			}
+		} else {
+			if reason := exitReason(rules, open.EntryPrice, prices, i); reason != "" {
+				closePosition(i, reason)
+			}
+		}
+
+		equity := cash
+		if open != nil {
+			equity += open.Quantity * price
+		}
+		peak = math.Max(peak, equity)
+		if drawdown := (peak - equity) / peak * 100; drawdown > run.MaxDrawdownPercent {
+			run.MaxDrawdownPercent = round2(drawdown)
+		}
+	}
+	if open != nil {
+		closePosition(len(prices)-1, "end")
+	}
+
+	wins := 0
+	for _, trade := range run.Trades {
+		if trade.ProfitLoss > 0 {
+			wins++
+		}
+	}
+	if len(run.Trades) > 0 {
+		run.WinRatePercent = round2(float64(wins) / float64(len(run.Trades)) * 100)
+	}
+	run.FinalEquity = round2(cash)
+	run.ReturnPercent = round2((cash - startingCash) / startingCash * 100)
+	return run
+}
+
+// refreshStats recomputes a strategy's statistics from all of its paper runs
+func (s *StrategyService) refreshStats(ctx context.Context, id primitive.ObjectID) error {
+	cursor, err := s.runCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"strategy_id": id.Hex(), "mode": "paper"}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                  nil,
+			"runs":                 bson.M{"$sum": 1},
+			"avg_return_percent":   bson.M{"$avg": "$return_percent"},
+			"best_return_percent":  bson.M{"$max": "$return_percent"},
+			"worst_return_percent": bson.M{"$min": "$return_percent"},
+			"avg_win_rate_percent": bson.M{"$avg": "$win_rate_percent"},
+			"last_run_at":          bson.M{"$max": "$created_at"},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stats models.StrategyStats
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&stats); err != nil {
+			return err
+		}
+	}
+	stats.AvgReturnPercent = round2(stats.AvgReturnPercent)
+	stats.AvgWinRatePercent = round2(stats.AvgWinRatePercent)
+	_, err = s.strategyCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"stats": stats}})
+	return err
+}
+
+func (s *StrategyService) validate(strategy *models.Strategy) error {
+	strategy.Name = strings.TrimSpace(strategy.Name)
+	if len(strategy.Name) < 3 || len(strategy.Name) > 60 {
+		return fmt.Errorf("name must be 3-60 characters")
+	}
+	return s.validateStrategyRules(&strategy.Rules)
+}
+
+func (s *StrategyService) checkLimit(ctx context.Context, userID string) error {
+	if s.perUser <= 0 {
+		return nil
+	}
+	owned, err := s.strategyCollection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+	if owned >= int64(s.perUser) {
+		return ErrStrategyLimit
+	}
+	return nil
+}
+
+func (s *StrategyService) owned(ctx context.Context, id, userID string) (*models.Strategy, error) {
+	strategy, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if strategy.UserID != userID {
+		if strategy.Published {
+			return nil, ErrNotStrategyOwner
+		}
+		return nil, ErrStrategyNotFound
+	}
+	return strategy, nil
+}
+
+func (s *StrategyService) find(ctx context.Context, id string) (*models.Strategy, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrStrategyNotFound
+	}
+	var strategy models.Strategy
+	if err := s.strategyCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&strategy); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrStrategyNotFound
+		}
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+func (s *StrategyService) list(ctx context.Context, filter bson.M, sort bson.D, limit int64) ([]models.Strategy, error) {
+	opts := options.Find().SetSort(sort)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.strategyCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	strategies := []models.Strategy{}
+	err = cursor.All(ctx, &strategies)
+	return strategies, err
+}
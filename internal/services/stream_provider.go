@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// QuoteStreamProvider adapts one market data provider's streaming protocol.
+// MarketStream owns the connection and calls it to speak the protocol.
+type QuoteStreamProvider interface {
+	Name() string
+	URL() string
+	// Subscribe sends what the provider needs on a new connection:
+	// authentication, then a subscription to the symbols' trades
+	Subscribe(conn *websocket.Conn, symbols []string) error
+	// Parse decodes one frame into trades. Control frames yield none; a
+	// frame reporting that the connection is unusable yields an error.
+	Parse(data []byte) ([]StreamTrade, error)
+	// Snapshot fetches a symbol's last trade over REST, used to fill the
+	// gap a reconnect leaves
+	Snapshot(ctx context.Context, symbol string) (*StreamTrade, error)
+}
+
+// StreamTrade is one trade from a streaming provider
+type StreamTrade struct {
+	Symbol    string
+	Price     float64
+	Volume    int64
+	PrevClose float64 // Set on snapshots when the provider has it
+	At        time.Time
+}
+
+// NewQuoteStreamProvider returns the provider MARKET_STREAM_PROVIDER names
+func NewQuoteStreamProvider(name, apiKey string) (QuoteStreamProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("MARKET_STREAM_API_KEY is required for %s", name)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch strings.ToLower(name) {
+	case "finnhub":
+		return &FinnhubStreamProvider{apiKey: apiKey, client: client}, nil
+	case "polygon":
+		return &PolygonStreamProvider{apiKey: apiKey, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown market stream provider %q, expected finnhub or polygon", name)
+}
+
+// getJSON fetches a REST endpoint into out
+func getJSON(ctx context.Context, client *http.Client, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return nil
+}
+
+// FinnhubStreamProvider streams trades from wss://ws.finnhub.io
+type FinnhubStreamProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+func (p *FinnhubStreamProvider) Name() string {
+	return "finnhub"
+}
+
+func (p *FinnhubStreamProvider) URL() string {
+	return "wss://ws.finnhub.io?token=" + url.QueryEscape(p.apiKey)
+}
+
+func (p *FinnhubStreamProvider) Subscribe(conn *websocket.Conn, symbols []string) error {
+	for _, symbol := range symbols {
+		if err := conn.WriteJSON(map[string]string{"type": "subscribe", "symbol": symbol}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *FinnhubStreamProvider) Parse(data []byte) ([]StreamTrade, error) {
+	var frame struct {
+		Type string `json:"type"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Symbol string  `json:"s"`
+			Price  float64 `json:"p"`
+			Volume float64 `json:"v"`
+			Time   int64   `json:"t"` // Milliseconds
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return nil, err
+	}
+	switch frame.Type {
+	case "trade":
+	case "error":
+		return nil, fmt.Errorf("finnhub: %s", frame.Msg)
+	default:
+		return nil, nil // Pings
+	}
+
+	trades := make([]StreamTrade, 0, len(frame.Data))
+	for _, t := range frame.Data {
+		trades = append(trades, StreamTrade{
+			Symbol: t.Symbol,
+			Price:  t.Price,
+			Volume: int64(t.Volume),
+			At:     time.UnixMilli(t.Time).UTC(),
+		})
+	}
+	return trades, nil
+}
+
+func (p *FinnhubStreamProvider) Snapshot(ctx context.Context, symbol string) (*StreamTrade, error) {
+	var quote struct {
+		Current   float64 `json:"c"`
+		PrevClose float64 `json:"pc"`
+		Time      int64   `json:"t"` // Seconds
+	}
+	endpoint := fmt.Sprintf("https://finnhub.io/api/v1/quote?symbol=%s&token=%s", url.QueryEscape(symbol), url.QueryEscape(p.apiKey))
+	if err := getJSON(ctx, p.client, endpoint, &quote); err != nil {
+		return nil, err
+	}
+	if quote.Current <= 0 {
+		return nil, fmt.Errorf("no quote returned for symbol %s", symbol)
+	}
+	return &StreamTrade{
+		Symbol:    symbol,
+		Price:     quote.Current,
+		PrevClose: quote.PrevClose,
+		At:        time.Unix(quote.Time, 0).UTC(),
+	}, nil
+}
+
+// PolygonStreamProvider streams trades from wss://socket.polygon.io/stocks
+type PolygonStreamProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+func (p *PolygonStreamProvider) Name() string {
+	return "polygon"
+}
+
+func (p *PolygonStreamProvider) URL() string {
+	return "wss://socket.polygon.io/stocks"
+}
+
+// Subscribe authenticates and subscribes in one go; Polygon handles the
+// messages in order and reports a failed login in a status frame
+func (p *PolygonStreamProvider) Subscribe(conn *websocket.Conn, symbols []string) error {
+	if err := conn.WriteJSON(map[string]string{"action": "auth", "params": p.apiKey}); err != nil {
+		return err
+	}
+	params := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		params[i] = "T." + symbol
+	}
+	return conn.WriteJSON(map[string]string{"action": "subscribe", "params": strings.Join(params, ",")})
+}
+
+func (p *PolygonStreamProvider) Parse(data []byte) ([]StreamTrade, error) {
+	var events []struct {
+		Event   string  `json:"ev"`
+		Status  string  `json:"status"`
+		Message string  `json:"message"`
+		Symbol  string  `json:"sym"`
+		Price   float64 `json:"p"`
+		Size    float64 `json:"s"`
+		Time    int64   `json:"t"` // Milliseconds
+	}
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+
+	var trades []StreamTrade
+	for _, e := range events {
+		switch e.Event {
+		case "T":
+			trades = append(trades, StreamTrade{
+				Symbol: e.Symbol,
+				Price:  e.Price,
+				Volume: int64(e.Size),
+				At:     time.UnixMilli(e.Time).UTC(),
+			})
+		case "status":
+			if e.Status == "auth_failed" || e.Status == "max_connections" {
+				return nil, fmt.Errorf("polygon: %s", e.Message)
+			}
+		}
+	}
+	return trades, nil
+}
+
+func (p *PolygonStreamProvider) Snapshot(ctx context.Context, symbol string) (*StreamTrade, error) {
+	var snapshot struct {
+		Ticker struct {
+			LastTrade struct {
+				Price float64 `json:"p"`
+				Time  int64   `json:"t"` // Nanoseconds
+			} `json:"lastTrade"`
+			PrevDay struct {
+				Close float64 `json:"c"`
+			} `json:"prevDay"`
+		} `json:"ticker"`
+	}
+	endpoint := fmt.Sprintf("https://api.polygon.io/v2/snapshot/locale/us/markets/stocks/tickers/%s?apiKey=%s", url.PathEscape(symbol), url.QueryEscape(p.apiKey))
+	if err := getJSON(ctx, p.client, endpoint, &snapshot); err != nil {
+		return nil, err
+	}
+	last := snapshot.Ticker.LastTrade
+	if last.Price <= 0 {
+		return nil, fmt.Errorf("no snapshot returned for symbol %s", symbol)
+	}
+	return &StreamTrade{
+		Symbol:    symbol,
+		Price:     last.Price,
+		PrevClose: snapshot.Ticker.PrevDay.Close,
+		At:        time.Unix(0, last.Time).UTC(),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"trading-simulator/config"
+)
+
+// StreamPublisher sends a message to an external streaming platform
+type StreamPublisher interface {
+	Publish(topic string, payload []byte) error
+	Close() error
+}
+
+type streamMessage struct {
+	topic   string
+	payload []byte
+}
+
+// streamEnvelope is the JSON written to the external topic
+type streamEnvelope struct {
+	Topic     string      `json:"topic"`
+	UserID    string      `json:"userId,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// StreamService forwards tick and fill events from the event bus to Kafka
+// or NATS for external consumers. Publishing happens on a background
+// worker so a slow broker never holds up trading; when the buffer is full
+// events are dropped and counted.
+type StreamService struct {
+	publisher StreamPublisher
+	prefix    string
+	queue     chan streamMessage
+	dropped   atomic.Int64
+}
+
+// NewStreamService builds the integration configured by STREAM_BACKEND
+// ("nats" or "kafka"). It returns nil when streaming is not configured.
+func NewStreamService(events *EventBus) (*StreamService, error) {
+	var publisher StreamPublisher
+	var err error
+	switch backend := strings.ToLower(os.Getenv("STREAM_BACKEND")); backend {
+	case "":
+		return nil, nil
+	case "nats":
+		publisher, err = NewNATSPublisher(config.GetEnv("NATS_URL", "nats://localhost:4222"))
+	case "kafka":
+		publisher, err = NewKafkaRESTPublisher(config.GetEnv("KAFKA_REST_URL", "http://localhost:8082"))
+	default:
+		return nil, fmt.Errorf("unknown STREAM_BACKEND %q", backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &StreamService{
+		publisher: publisher,
+		prefix:    config.GetEnv("STREAM_TOPIC_PREFIX", "simulator."),
+		queue:     make(chan streamMessage, config.GetEnvInt("STREAM_BUFFER_SIZE", 10000)),
+	}
+	events.Subscribe(EventPriceTick, s.enqueue)
+	events.Subscribe(EventOrderFilled, s.enqueue)
+	return s, nil
+}
+
+func (s *StreamService) enqueue(event Event) {
+	payload, err := json.Marshal(streamEnvelope{
+		Topic:     event.Topic,
+		UserID:    event.UserID,
+		Timestamp: event.Timestamp,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		log.Printf("Error encoding %s for streaming: %v", event.Topic, err)
+		return
+	}
+
+	select {
+	case s.queue <- streamMessage{topic: s.prefix + event.Topic, payload: payload}:
+	default:
+		if s.dropped.Add(1)%1000 == 1 {
+			log.Printf("⚠️ Stream buffer full, %d events dropped so far", s.dropped.Load())
+		}
+	}
+}
+
+// Run publishes queued events until the process exits
+func (s *StreamService) Run() {
+	defer s.publisher.Close()
+	for msg := range s.queue {
+		if err := s.publisher.Publish(msg.topic, msg.payload); err != nil {
+			log.Printf("Error streaming to %s: %v", msg.topic, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+)
+
+// Annual borrow fee, in percent, of symbols an admin has not configured
+const defaultBorrowFeeRate = 0.3
+
+type SymbolService struct {
+	mu      sync.RWMutex
+	symbols map[string]models.SymbolInfo
+}
+
+func NewSymbolService() *SymbolService {
+	s := &SymbolService{symbols: make(map[string]models.SymbolInfo)}
+
+	// Stocks trade in fractional shares down to 0.0001 with cent ticks
+	sectors := map[string]string{
+		"AAPL":  "Technology",
+		"GOOGL": "Communication Services",
+		"MSFT":  "Technology",
+		"TSLA":  "Consumer Cyclical",
+		"AMZN":  "Consumer Cyclical",
+		"NVDA":  "Technology",
+		"META":  "Communication Services",
+		"JPM":   "Financial Services",
+	}
+	for symbol, sector := range sectors {
+		info := defaultStockRules(symbol)
+		info.Sector = sector
+		s.symbols[symbol] = info
+	}
+
+	// Crypto trades in small fractional lots
+	s.symbols["BTC-USD"] = models.SymbolInfo{
+		Symbol:         "BTC-USD",
+		Name:           getStockName("BTC-USD"),
+		AssetClass:     "crypto",
+		Sector:         "Crypto",
+		TickSize:       0.01,
+		PricePrecision: 2,
+		LotSize:        0.0001,
+		MinQuantity:    0.0001,
+		BorrowFeeRate:  defaultBorrowFeeRate,
+	}
+	s.symbols["ETH-USD"] = models.SymbolInfo{
+		Symbol:         "ETH-USD",
+		Name:           getStockName("ETH-USD"),
+		AssetClass:     "crypto",
+		Sector:         "Crypto",
+		TickSize:       0.01,
+		PricePrecision: 2,
+		LotSize:        0.001,
+		MinQuantity:    0.001,
+		BorrowFeeRate:  defaultBorrowFeeRate,
+	}
+
+	return s
+}
+
+func defaultStockRules(symbol string) models.SymbolInfo {
+	return models.SymbolInfo{
+		Symbol:         symbol,
+		Name:           getStockName(symbol),
+		AssetClass:     "stock",
+		Sector:         "Other",
+		TickSize:       0.01,
+		PricePrecision: 2,
+		LotSize:        quantityStep,
+		MinQuantity:    quantityStep,
+		BorrowFeeRate:  defaultBorrowFeeRate,
+	}
+}
+
+// AddSymbol registers the rules for a new symbol
+func (s *SymbolService) AddSymbol(info models.SymbolInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols[info.Symbol] = info
+}
+
+// RemoveSymbol forgets a symbol's rules
+func (s *SymbolService) RemoveSymbol(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.symbols, strings.ToUpper(symbol))
+}
+
+// SetBorrow applies short-sale settings to a known symbol and reports
+// whether the symbol exists
+func (s *SymbolService) SetBorrow(settings models.BorrowSettings) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.symbols[settings.Symbol]
+	if !ok {
+		return false
+	}
+	info.HardToBorrow = settings.HardToBorrow
+	info.BorrowFeeRate = settings.BorrowFeeRate
+	info.BorrowLimit = settings.BorrowLimit
+	s.symbols[settings.Symbol] = info
+	return true
+}
+
+// HasSymbol reports whether the symbol is known
+func (s *SymbolService) HasSymbol(symbol string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.symbols[strings.ToUpper(symbol)]
+	return ok
+}
+
+// ListSymbols returns the rules for every known symbol, sorted by symbol
+func (s *SymbolService) ListSymbols() []models.SymbolInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]models.SymbolInfo, 0, len(s.symbols))
+	for _, info := range s.symbols {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	return list
+}
+
+// GetSymbol returns the rules for a symbol, falling back to stock defaults
+func (s *SymbolService) GetSymbol(symbol string) models.SymbolInfo {
+	symbol = strings.ToUpper(symbol)
+
+	s.mu.RLock()
+	info, exists := s.symbols[symbol]
+	s.mu.RUnlock()
+
+	if !exists {
+		return defaultStockRules(symbol)
+	}
+	return info
+}
+
+// ApplyRules rounds the order's prices to the symbol tick size, turns a
+// notional amount into a quantity at the order price and checks that the
+// quantity respects the lot size
+func (s *SymbolService) ApplyRules(order *models.Order) error {
+	order.Symbol = strings.ToUpper(order.Symbol)
+	info := s.GetSymbol(order.Symbol)
+
+	order.Price = roundPrice(order.Price, info)
+	order.StopPrice = roundPrice(order.StopPrice, info)
+	order.LimitPrice = roundPrice(order.LimitPrice, info)
+
+	if order.Price <= 0 {
+		return i18n.NewError("order.below_tick", info.TickSize, info.Symbol)
+	}
+
+	// Notional orders buy or sell as many lots as the amount covers
+	if order.Notional > 0 {
+		step := math.Max(info.LotSize, quantityStep)
+		order.Quantity = roundQuantity(math.Floor(order.Notional/order.Price/step+1e-9) * step)
+	}
+
+	quantity := order.Quantity
+	if quantity < info.MinQuantity {
+		return i18n.NewError("order.below_min_quantity", quantity, info.MinQuantity, info.Symbol)
+	}
+	if !isMultipleOf(quantity, info.LotSize) {
+		return i18n.NewError("order.lot_size", quantity, info.LotSize, info.Symbol)
+	}
+	return nil
+}
+
+// Smallest quantity any symbol trades in; quantities are kept to 4 decimals
+const (
+	quantityStep      = 0.0001
+	quantityPrecision = 1e4
+)
+
+// roundQuantity trims float noise from a quantity or share count
+func roundQuantity(quantity float64) float64 {
+	return math.Round(quantity*quantityPrecision) / quantityPrecision
+}
+
+// roundPrice snaps a price to the nearest tick and trims float noise
+// beyond the symbol's precision
+func roundPrice(price float64, info models.SymbolInfo) float64 {
+	if info.TickSize <= 0 || price == 0 {
+		return price
+	}
+	ticks := math.Round(price / info.TickSize)
+	scale := math.Pow(10, float64(info.PricePrecision))
+	return math.Round(ticks*info.TickSize*scale) / scale
+}
+
+func isMultipleOf(value, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	ratio := value / step
+	return math.Abs(ratio-math.Round(ratio)) < 1e-9
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+const (
+	// Simulated days kept per symbol, one trading year
+	statsHistoryDays = 252
+	// Days averaged for average daily volume
+	advDays = 20
+	// Days averaged for average true range
+	atrDays = 14
+)
+
+// simDay is one completed or in-progress simulated trading day
+type simDay struct {
+	open, high, low, close float64
+	volume                 int64
+}
+
+// symbolStatsState is the running state of one symbol
+type symbolStatsState struct {
+	today     simDay
+	ticks     int
+	days      []simDay // Completed days, oldest first
+	completed int
+	updatedAt time.Time
+}
+
+// SymbolStatsService keeps rolling per-symbol statistics from simulator
+// ticks. A simulated day ends every SIM_TICKS_PER_DAY ticks.
+type SymbolStatsService struct {
+	candleService *CandleService
+	ticksPerDay   int
+
+	mu      sync.RWMutex
+	symbols map[string]*symbolStatsState
+}
+
+func NewSymbolStatsService(candleService *CandleService) *SymbolStatsService {
+	return &SymbolStatsService{
+		candleService: candleService,
+		ticksPerDay:   max(config.GetEnvInt("SIM_TICKS_PER_DAY", 100), 1),
+		symbols:       make(map[string]*symbolStatsState),
+	}
+}
+
+// LoadHistory seeds the completed days of each symbol from stored daily
+// candles so averages and ranges are meaningful right after a restart.
+// Symbols that have already completed a simulated day are left alone.
+func (s *SymbolStatsService) LoadHistory(ctx context.Context, symbols []string) {
+	for _, symbol := range symbols {
+		candles, err := s.candleService.GetCandles(ctx, symbol, statsHistoryDays)
+		if err != nil {
+			log.Printf("Error loading candles for %s stats: %v", symbol, err)
+			continue
+		}
+		if len(candles) == 0 {
+			continue
+		}
+		days := make([]simDay, 0, len(candles))
+		for _, candle := range candles {
+			days = append(days, simDay{open: candle.Open, high: candle.High, low: candle.Low, close: candle.Close, volume: candle.Volume})
+		}
+
+		s.mu.Lock()
+		state := s.state(strings.ToUpper(symbol))
+		if state.completed == 0 {
+			state.days = days
+			state.completed = len(days)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// RecordTick folds a simulator tick into the symbol's current day, rolling
+// the day over once it has seen SIM_TICKS_PER_DAY ticks
+func (s *SymbolStatsService) RecordTick(stock models.Stock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state(stock.Symbol)
+	if state.ticks == 0 {
+		state.today = simDay{open: stock.Price, high: stock.Price, low: stock.Price}
+	}
+	state.today.high = math.Max(state.today.high, stock.Price)
+	state.today.low = math.Min(state.today.low, stock.Price)
+	state.today.close = stock.Price
+	state.today.volume += tickVolume(stock, s.ticksPerDay)
+	state.ticks++
+	state.updatedAt = stock.Timestamp
+
+	if state.ticks >= s.ticksPerDay {
+		state.days = append(state.days, state.today)
+		if len(state.days) > statsHistoryDays {
+			state.days = state.days[len(state.days)-statsHistoryDays:]
+		}
+		state.completed++
+		state.ticks = 0
+	}
+}
+
+// tickVolume is the share of a day's volume a tick adds. Streamed ticks
+// carry what traded since the last one; simulated quotes carry a whole
+// day's volume, the same unit as the candles days are seeded from, so
+// each adds its share of a day of ticksPerDay ticks.
+func tickVolume(stock models.Stock, ticksPerDay int) int64 {
+	if stock.Source == PriceSourceStreamed {
+		return stock.Volume
+	}
+	return stock.Volume / int64(ticksPerDay)
+}
+
+// GetStats returns the statistics for a symbol, or nil before its first tick
+func (s *SymbolStatsService) GetStats(symbol string) *models.SymbolStats {
+	symbol = strings.ToUpper(symbol)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.symbols[symbol]
+	if !ok || (state.ticks == 0 && len(state.days) == 0) {
+		return nil
+	}
+
+	// Between days the last completed day stands in for today
+	today := state.today
+	if state.ticks == 0 {
+		today = state.days[len(state.days)-1]
+	}
+	stats := &models.SymbolStats{
+		Symbol:     symbol,
+		SimDay:     state.completed,
+		DayOpen:    today.open,
+		DayHigh:    today.high,
+		DayLow:     today.low,
+		DayVolume:  today.volume,
+		Week52High: today.high,
+		Week52Low:  today.low,
+		UpdatedAt:  state.updatedAt,
+	}
+
+	days := state.days
+	for _, day := range days {
+		stats.Week52High = math.Max(stats.Week52High, day.high)
+		stats.Week52Low = math.Min(stats.Week52Low, day.low)
+	}
+
+	if len(days) == 0 {
+		stats.AverageDailyVolume = float64(today.volume)
+		stats.AverageTrueRange = today.high - today.low
+		return stats
+	}
+
+	recent := days[max(len(days)-advDays, 0):]
+	var volume int64
+	for _, day := range recent {
+		volume += day.volume
+	}
+	stats.AverageDailyVolume = float64(volume) / float64(len(recent))
+
+	recent = days[max(len(days)-atrDays, 0):]
+	var trueRange float64
+	for i, day := range recent {
+		tr := day.high - day.low
+		// The first day of the window still has a previous close unless it
+		// is the oldest day kept
+		if prev := len(days) - len(recent) + i - 1; prev >= 0 {
+			prevClose := days[prev].close
+			tr = math.Max(tr, math.Max(math.Abs(day.high-prevClose), math.Abs(day.low-prevClose)))
+		}
+		trueRange += tr
+	}
+	stats.AverageTrueRange = trueRange / float64(len(recent))
+	return stats
+}
+
+// state returns the symbol's state, creating it. Callers hold mu.
+func (s *SymbolStatsService) state(symbol string) *symbolStatsState {
+	state, ok := s.symbols[symbol]
+	if !ok {
+		state = &symbolStatsState{}
+		s.symbols[symbol] = state
+	}
+	return state
+}
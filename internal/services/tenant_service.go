@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Starting cash for new accounts when tenancy is off or a tenant sets none
+const defaultStartingBalance = 10000.0
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,39}$`)
+
+// ErrUnknownTenant is returned when a request cannot be matched to a tenant
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantService resolves requests to tenants when MULTI_TENANT is on. With
+// tenancy off every lookup returns no tenant and nothing is scoped.
+type TenantService struct {
+	tenantCollection *mongo.Collection
+	enabled          bool
+	defaultTenant    string
+	cacheTTL         time.Duration
+
+	mu       sync.RWMutex
+	tenants  map[string]models.Tenant
+	hosts    map[string]string // host -> tenant ID
+	loadedAt time.Time
+}
+
+func NewTenantService() *TenantService {
+	return &TenantService{
+		tenantCollection: config.GetCollection("tenants"),
+		enabled:          config.GetEnv("MULTI_TENANT", "false") == "true",
+		defaultTenant:    config.GetEnv("DEFAULT_TENANT", ""),
+		cacheTTL:         time.Duration(config.GetEnvInt("TENANT_CACHE_SECONDS", 30)) * time.Second,
+	}
+}
+
+// Enabled reports whether multi-tenant mode is on
+func (s *TenantService) Enabled() bool {
+	return s != nil && s.enabled
+}
+
+// Resolve picks the tenant for a request from the X-Tenant-ID header, then
+// the request host, then DEFAULT_TENANT. It returns nil with tenancy off.
+func (s *TenantService) Resolve(header, host string) (*models.Tenant, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	tenants, hosts := s.current()
+
+	id := strings.ToLower(strings.TrimSpace(header))
+	if id == "" {
+		hostname, _, _ := strings.Cut(strings.ToLower(host), ":")
+		id = hosts[hostname]
+	}
+	if id == "" {
+		id = s.defaultTenant
+	}
+	tenant, ok := tenants[id]
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+	return &tenant, nil
+}
+
+// GetTenant returns a tenant by ID. It returns nil for an empty ID or with
+// tenancy off.
+func (s *TenantService) GetTenant(id string) (*models.Tenant, error) {
+	if !s.Enabled() || id == "" {
+		return nil, nil
+	}
+	tenants, _ := s.current()
+	tenant, ok := tenants[id]
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+	return &tenant, nil
+}
+
+// StartingBalance returns the cash a new account in the tenant starts with
+func (s *TenantService) StartingBalance(tenantID string) float64 {
+	if tenant, err := s.GetTenant(tenantID); err == nil && tenant != nil && tenant.StartingBalance > 0 {
+		return tenant.StartingBalance
+	}
+	return defaultStartingBalance
+}
+
+// OpeningBalance returns the cash the user's account opened with or was last
+// reset to. Accounts from before that was recorded fall back to the tenant's
+// starting balance.
+func (s *TenantService) OpeningBalance(user models.User) float64 {
+	if user.StartingBalance == 0 && user.AccountResetAt.IsZero() {
+		return s.StartingBalance(user.TenantID)
+	}
+	return user.StartingBalance
+}
+
+// CheckSymbol rejects symbols outside the tenant's universe
+func (s *TenantService) CheckSymbol(tenantID, symbol string) error {
+	tenant, err := s.GetTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant != nil && !tenant.AllowsSymbol(symbol) {
+		return i18n.NewError("order.symbol_not_available", symbol)
+	}
+	return nil
+}
+
+// ListTenants returns every tenant sorted by ID
+func (s *TenantService) ListTenants() []models.Tenant {
+	tenants, _ := s.current()
+	list := make([]models.Tenant, 0, len(tenants))
+	for _, tenant := range tenants {
+		list = append(list, tenant)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// SaveTenant creates or replaces a tenant and refreshes the cache
+func (s *TenantService) SaveTenant(ctx context.Context, tenant models.Tenant) (*models.Tenant, error) {
+	tenant.ID = strings.ToLower(tenant.ID)
+	if !tenantIDPattern.MatchString(tenant.ID) {
+		return nil, fmt.Errorf("tenant id must be 2-40 lowercase letters, digits or dashes")
+	}
+	if tenant.StartingBalance < 0 {
+		return nil, fmt.Errorf("starting balance cannot be negative")
+	}
+	tenants, hosts := s.current()
+	for i, host := range tenant.Hosts {
+		host = strings.ToLower(host)
+		if owner, ok := hosts[host]; ok && owner != tenant.ID {
+			return nil, fmt.Errorf("host %s already belongs to tenant %s", host, owner)
+		}
+		tenant.Hosts[i] = host
+	}
+	for i, symbol := range tenant.Symbols {
+		tenant.Symbols[i] = strings.ToUpper(symbol)
+	}
+
+	now := time.Now().UTC()
+	tenant.CreatedAt = now
+	if existing, ok := tenants[tenant.ID]; ok {
+		tenant.CreatedAt = existing.CreatedAt
+		tenant.UpdatedAt = now
+	}
+
+	_, err := s.tenantCollection.ReplaceOne(ctx, bson.M{"_id": tenant.ID}, tenant, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+	log.Printf("🏫 Tenant %s saved", tenant.ID)
+	return &tenant, nil
+}
+
+// current returns the cached tenants and host index, reloading them once the
+// cache expires. If Mongo is unreachable the last known tenants stay in effect.
+func (s *TenantService) current() (map[string]models.Tenant, map[string]string) {
+	s.mu.RLock()
+	if s.tenants != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		tenants, hosts := s.tenants, s.hosts
+		s.mu.RUnlock()
+		return tenants, hosts
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tenants != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		return s.tenants, s.hosts
+	}
+
+	cursor, err := s.tenantCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error loading tenants: %v", err)
+		if s.tenants == nil {
+			s.tenants, s.hosts = map[string]models.Tenant{}, map[string]string{}
+		}
+		return s.tenants, s.hosts
+	}
+	defer cursor.Close(context.Background())
+
+	var stored []models.Tenant
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		log.Printf("Error decoding tenants: %v", err)
+	}
+	tenants := make(map[string]models.Tenant, len(stored))
+	hosts := make(map[string]string)
+	for _, tenant := range stored {
+		tenants[tenant.ID] = tenant
+		for _, host := range tenant.Hosts {
+			hosts[host] = tenant.ID
+		}
+	}
+
+	s.tenants, s.hosts = tenants, hosts
+	s.loadedAt = time.Now()
+	return tenants, hosts
+}
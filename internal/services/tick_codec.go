@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/binary"
+	"math"
+
+	"trading-simulator/internal/models"
+)
+
+// Binary tick frame layout (big-endian):
+//
+//	offset  size  field
+//	0       1     frame type (tickFrameType)
+//	1       8     sequence number
+//	9       1     symbol length N
+//	10      N     symbol (ASCII)
+//	10+N    8     price (float64)
+//	18+N    8     change (float64)
+//	26+N    8     change percent (float64)
+//	34+N    8     volume (int64)
+//	42+N    8     timestamp (unix milliseconds)
+const tickFrameType byte = 1
+
+func encodeTickFrame(seq uint64, stock models.Stock) []byte {
+	symbol := stock.Symbol
+	if len(symbol) > math.MaxUint8 {
+		symbol = symbol[:math.MaxUint8]
+	}
+
+	frame := make([]byte, 0, 50+len(symbol))
+	frame = append(frame, tickFrameType)
+	frame = binary.BigEndian.AppendUint64(frame, seq)
+	frame = append(frame, byte(len(symbol)))
+	frame = append(frame, symbol...)
+	frame = binary.BigEndian.AppendUint64(frame, math.Float64bits(stock.Price))
+	frame = binary.BigEndian.AppendUint64(frame, math.Float64bits(stock.Change))
+	frame = binary.BigEndian.AppendUint64(frame, math.Float64bits(stock.ChangePercent))
+	frame = binary.BigEndian.AppendUint64(frame, uint64(stock.Volume))
+	frame = binary.BigEndian.AppendUint64(frame, uint64(stock.Timestamp.UnixMilli()))
+	return frame
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+)
+
+const (
+	// Most ticks returned by one query
+	maxTickLogLimit = 10000
+	// Name of the TTL index that expires ticks
+	tickLogTTLIndex = "recorded_at_ttl"
+)
+
+// ErrTickRange is returned for a reversed period
+var ErrTickRange = errors.New("from must not be after to")
+
+// TickLogService keeps every broadcast tick in the tick_log collection,
+// unsampled, so price paths can be studied offline against fills: slippage
+// against the tick before an order, and the lag between a price's own time
+// and its broadcast. Ticks are written in batches and expired by a TTL
+// index after TICK_LOG_RETENTION_HOURS (default 48, 0 keeps them);
+// TICK_LOG=false stops recording.
+type TickLogService struct {
+	tickCollection *mongo.Collection
+	writer         *BatchWriter
+	enabled        bool
+	retention      time.Duration
+}
+
+func NewTickLogService() *TickLogService {
+	tickCollection := config.GetCollection("tick_log")
+	return &TickLogService{
+		tickCollection: tickCollection,
+		writer:         NewBatchWriter(tickCollection),
+		enabled:        config.GetEnv("TICK_LOG", "true") == "true",
+		retention:      time.Duration(config.GetEnvInt("TICK_LOG_RETENTION_HOURS", 48)) * time.Hour,
+	}
+}
+
+// RecordTick queues the tick for writing
+func (s *TickLogService) RecordTick(stock models.Stock) {
+	if !s.enabled {
+		return
+	}
+	now := time.Now().UTC()
+	at := stock.Timestamp
+	if at.IsZero() {
+		at = now
+	}
+	s.writer.Write(models.TickRecord{
+		Symbol:     strings.ToUpper(stock.Symbol),
+		Price:      stock.Price,
+		Volume:     stock.Volume,
+		Source:     stock.Source,
+		Timestamp:  at.UTC(),
+		RecordedAt: now,
+	})
+}
+
+// Ticks returns up to limit of the symbol's ticks between from and to,
+// inclusive and oldest first, and whether more remain in the period
+func (s *TickLogService) Ticks(ctx context.Context, symbol string, from, to time.Time, limit int64) ([]models.TickRecord, bool, error) {
+	if from.After(to) {
+		return nil, false, ErrTickRange
+	}
+	if limit <= 0 || limit > maxTickLogLimit {
+		limit = maxTickLogLimit
+	}
+
+	cursor, err := s.tickCollection.Find(ctx, bson.M{
+		"symbol":    strings.ToUpper(symbol),
+		"timestamp": bson.M{"$gte": from, "$lte": to},
+	}, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	ticks := []models.TickRecord{}
+	if err := cursor.All(ctx, &ticks); err != nil {
+		return nil, false, err
+	}
+	if int64(len(ticks)) > limit {
+		return ticks[:limit], true, nil
+	}
+	return ticks, false, nil
+}
+
+// Dropped returns how many ticks were not recorded because the database
+// fell behind
+func (s *TickLogService) Dropped() int64 {
+	return s.writer.Dropped()
+}
+
+// Run writes the recorded ticks until the process exits
+func (s *TickLogService) Run() {
+	s.writer.Run()
+}
+
+// EnsureIndexes indexes ticks for range queries and sets the TTL index to
+// TICK_LOG_RETENTION_HOURS, dropping it when the retention is zero
+func (s *TickLogService) EnsureIndexes(ctx context.Context) error {
+	indexes := s.tickCollection.Indexes()
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "timestamp", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	if s.retention <= 0 {
+		if _, err := indexes.DropOne(ctx, tickLogTTLIndex); err != nil && !isMongoCode(err, 27) { // IndexNotFound
+			return err
+		}
+		return nil
+	}
+	seconds := int32(s.retention / time.Second)
+	_, err = indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recorded_at", Value: 1}},
+		Options: options.Index().SetName(tickLogTTLIndex).SetExpireAfterSeconds(seconds),
+	})
+	if isMongoCode(err, 85) { // IndexOptionsConflict: the retention changed
+		return s.tickCollection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: s.tickCollection.Name()},
+			{Key: "index", Value: bson.M{"name": tickLogTTLIndex, "expireAfterSeconds": seconds}},
+		}).Err()
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Tier of accounts that registered without choosing one
+const defaultTier = "beginner"
+
+var tierNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,19}$`)
+
+// Tiers that exist before an operator has stored anything
+var defaultAccountTiers = []models.AccountTier{
+	{Name: defaultTier, StartingBalance: 0, Features: []string{}, Description: "The tenant's starting balance ($10,000 by default) and the standard features"},
+	{Name: "pro", StartingBalance: 100000, Features: []string{FeatureMargin, FeatureOptions}, Description: "$100,000 with margin and options"},
+}
+
+var (
+	ErrUnknownTier     = errors.New("unknown account tier")
+	ErrTierUserMissing = errors.New("user not found")
+)
+
+// TierService manages account tiers. A tier is chosen at registration or
+// granted by an admin; it sets the starting balance of new accounts and
+// turns features on for its users.
+type TierService struct {
+	tierCollection *mongo.Collection
+	userCollection *mongo.Collection
+	cacheTTL       time.Duration
+
+	mu       sync.RWMutex
+	tiers    map[string]models.AccountTier
+	loadedAt time.Time
+}
+
+func NewTierService() *TierService {
+	return &TierService{
+		tierCollection: config.GetCollection("account_tiers"),
+		userCollection: config.GetCollection("users"),
+		cacheTTL:       time.Duration(config.GetEnvInt("ACCOUNT_TIER_CACHE_SECONDS", 30)) * time.Second,
+	}
+}
+
+// ListTiers returns every tier, cheapest first
+func (s *TierService) ListTiers() []models.AccountTier {
+	tiers := s.current()
+	list := make([]models.AccountTier, 0, len(tiers))
+	for _, tier := range tiers {
+		list = append(list, tier)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartingBalance != list[j].StartingBalance {
+			return list[i].StartingBalance < list[j].StartingBalance
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// GetTier returns a tier by name; an empty name is the default tier
+func (s *TierService) GetTier(name string) (models.AccountTier, error) {
+	if name == "" {
+		name = defaultTier
+	}
+	tier, ok := s.current()[name]
+	if !ok {
+		return models.AccountTier{}, ErrUnknownTier
+	}
+	return tier, nil
+}
+
+// SetTier stores a tier and refreshes the cache
+func (s *TierService) SetTier(ctx context.Context, tier models.AccountTier) (*models.AccountTier, error) {
+	if !tierNamePattern.MatchString(tier.Name) {
+		return nil, fmt.Errorf("tier names are 2-20 lowercase letters, digits or dashes")
+	}
+	if tier.StartingBalance < 0 {
+		return nil, fmt.Errorf("starting balance cannot be negative")
+	}
+	if tier.Features == nil {
+		tier.Features = []string{}
+	}
+	if existing, ok := s.current()[tier.Name]; ok && tier.Description == "" {
+		tier.Description = existing.Description
+	}
+	tier.UpdatedAt = time.Now().UTC()
+
+	_, err := s.tierCollection.UpdateOne(ctx,
+		bson.M{"name": tier.Name},
+		bson.M{"$set": tier},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+	log.Printf("🎚️ Account tier %s set to $%.2f with %v by %s", tier.Name, tier.StartingBalance, tier.Features, tier.UpdatedBy)
+	return &tier, nil
+}
+
+// Grants reports whether the user's tier turns a feature on
+func (s *TierService) Grants(userID, feature string) bool {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false
+	}
+	var user models.User
+	err = s.userCollection.FindOne(context.Background(), bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"tier": 1})).Decode(&user)
+	if err != nil {
+		return false
+	}
+	tier, err := s.GetTier(user.Tier)
+	return err == nil && slices.Contains(tier.Features, feature)
+}
+
+// Assign moves a user to a tier. The balance is left alone; the tier's
+// starting balance only applies to new accounts. Admins of a tenant can
+// only change their own tenant's users.
+func (s *TierService) Assign(ctx context.Context, admin *models.User, userID, name string) error {
+	if _, err := s.GetTier(name); err != nil {
+		return err
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrTierUserMissing
+	}
+	filter := bson.M{"_id": objID}
+	if admin.TenantID != "" {
+		filter["tenant_id"] = admin.TenantID
+	}
+	result, err := s.userCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"tier": name}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTierUserMissing
+	}
+	log.Printf("🎚️ User %s moved to the %s tier by %s", userID, name, admin.Username)
+	return nil
+}
+
+// current returns the cached tiers, reloading them once the cache expires.
+// If Mongo is unreachable the last known tiers stay in effect.
+func (s *TierService) current() map[string]models.AccountTier {
+	s.mu.RLock()
+	if s.tiers != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		tiers := s.tiers
+		s.mu.RUnlock()
+		return tiers
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tiers != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		return s.tiers
+	}
+
+	tiers := make(map[string]models.AccountTier, len(defaultAccountTiers))
+	for _, tier := range defaultAccountTiers {
+		tiers[tier.Name] = tier
+	}
+
+	cursor, err := s.tierCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error loading account tiers: %v", err)
+		if s.tiers == nil {
+			s.tiers = tiers
+		}
+		return s.tiers
+	}
+	defer cursor.Close(context.Background())
+
+	var stored []models.AccountTier
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		log.Printf("Error decoding account tiers: %v", err)
+	}
+	for _, tier := range stored {
+		tiers[tier.Name] = tier
+	}
+
+	s.tiers = tiers
+	s.loadedAt = time.Now()
+	return tiers
+}
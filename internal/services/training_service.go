@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"trading-simulator/config"
+	"trading-simulator/internal/i18n"
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// A delay has to finish well inside a request's timeout
+const maxTrainingDelayMs = 10000
+
+// trainingRejections are the broker errors training mode picks from.
+// Routing failures are worth retrying; a lack of liquidity is not.
+var trainingRejections = []string{
+	"order.routing_failed",
+	"order.venue_timeout",
+	"order.liquidity_unavailable",
+}
+
+// IsRoutingFailure reports whether an order was rejected on its way to the
+// venue, so the same order may succeed if sent again
+func IsRoutingFailure(err error) bool {
+	var msgErr *i18n.Error
+	return errors.As(err, &msgErr) && (msgErr.Code == "order.routing_failed" || msgErr.Code == "order.venue_timeout")
+}
+
+// TrainingService holds each group's training mode settings and applies
+// them to orders. Settings are cached like the simulation settings, so
+// other instances pick up a change within seconds.
+type TrainingService struct {
+	settingsCollection *mongo.Collection
+
+	mu       sync.Mutex
+	settings map[string]models.TrainingSettings
+	loadedAt map[string]time.Time
+}
+
+func NewTrainingService() *TrainingService {
+	return &TrainingService{
+		settingsCollection: config.GetCollection("training_settings"),
+		settings:           make(map[string]models.TrainingSettings),
+		loadedAt:           make(map[string]time.Time),
+	}
+}
+
+// GetSettings returns the group's training settings; training mode is off
+// until an admin turns it on
+func (s *TrainingService) GetSettings(tenantID string) models.TrainingSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.loadedAt[tenantID]) < simulationCacheTTL {
+		return s.settings[tenantID]
+	}
+
+	settings := models.TrainingSettings{TenantID: tenantID}
+	err := s.settingsCollection.FindOne(context.Background(), bson.M{"_id": tenantID}).Decode(&settings)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error loading training settings: %v", err)
+		return s.settings[tenantID]
+	}
+	s.settings[tenantID] = settings
+	s.loadedAt[tenantID] = time.Now()
+	return settings
+}
+
+// SetSettings validates and stores a group's training settings
+func (s *TrainingService) SetSettings(ctx context.Context, settings models.TrainingSettings) (models.TrainingSettings, error) {
+	if settings.RejectProbability < 0 || settings.RejectProbability > 1 {
+		return s.GetSettings(settings.TenantID), errors.New("reject probability must be between 0 and 1")
+	}
+	if settings.DelayProbability < 0 || settings.DelayProbability > 1 {
+		return s.GetSettings(settings.TenantID), errors.New("delay probability must be between 0 and 1")
+	}
+	if settings.MaxDelayMs < 0 || settings.MaxDelayMs > maxTrainingDelayMs {
+		return s.GetSettings(settings.TenantID), fmt.Errorf("max delay must be between 0 and %d ms", maxTrainingDelayMs)
+	}
+	settings.UpdatedAt = time.Now().UTC()
+
+	_, err := s.settingsCollection.ReplaceOne(ctx, bson.M{"_id": settings.TenantID}, settings, options.Replace().SetUpsert(true))
+	if err != nil {
+		return s.GetSettings(settings.TenantID), err
+	}
+
+	s.mu.Lock()
+	s.settings[settings.TenantID] = settings
+	s.loadedAt[settings.TenantID] = time.Now()
+	s.mu.Unlock()
+
+	log.Printf("🎓 Training mode for group %q changed by %s: enabled %v, reject %.0f%%, delay %.0f%% up to %dms",
+		settings.TenantID, settings.UpdatedBy, settings.Enabled, settings.RejectProbability*100, settings.DelayProbability*100, settings.MaxDelayMs)
+	return settings, nil
+}
+
+// Apply holds or rejects an order as its group's training settings say.
+// A held order waits a random time up to the maximum delay and then carries
+// on; a rejected one fails with one of the trainingRejections.
+func (s *TrainingService) Apply(ctx context.Context, order *models.Order) error {
+	settings := s.GetSettings(order.TenantID)
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.MaxDelayMs > 0 && rand.Float64() < settings.DelayProbability {
+		delay := time.Duration(rand.Intn(settings.MaxDelayMs)+1) * time.Millisecond
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if rand.Float64() < settings.RejectProbability {
+		code := trainingRejections[rand.Intn(len(trainingRejections))]
+		log.Printf("🎓 Training mode rejected %s %s %g %s with %s", order.UserID, order.Type, order.Quantity, order.Symbol, code)
+		return i18n.NewError(code, order.Symbol)
+	}
+	return nil
+}
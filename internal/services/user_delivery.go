@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"trading-simulator/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// userConnections indexes authenticated clients by user ID; a user may
+// have a socket open in several tabs and devices. The hub goroutine writes
+// it and any goroutine may read it.
+type userConnections struct {
+	mu      sync.RWMutex
+	clients map[string][]*WebSocketClient
+}
+
+func newUserConnections() *userConnections {
+	return &userConnections{clients: make(map[string][]*WebSocketClient)}
+}
+
+func (u *userConnections) add(client *WebSocketClient) {
+	if client.userID == "" {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.clients[client.userID] = append(u.clients[client.userID], client)
+}
+
+func (u *userConnections) remove(client *WebSocketClient) {
+	if client.userID == "" {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	clients := u.clients[client.userID]
+	for i, c := range clients {
+		if c == client {
+			clients = append(clients[:i:i], clients[i+1:]...)
+			break
+		}
+	}
+	if len(clients) == 0 {
+		delete(u.clients, client.userID)
+		return
+	}
+	u.clients[client.userID] = clients
+}
+
+// of returns the user's open sockets
+func (u *userConnections) of(userID string) []*WebSocketClient {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.clients[userID]
+}
+
+// deliveryCounters back DeliveryStats
+type deliveryCounters struct {
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	offline   atomic.Uint64
+	inboxed   atomic.Uint64
+}
+
+// SetInbox keeps messages for users who are offline, or whose sockets are
+// all backed up, in the inbox. It must be called before Run.
+func (h *WebSocketHub) SetInbox(inbox *InboxService) {
+	h.inbox = inbox
+}
+
+// SendToUser delivers a JSON message to every socket the user has open,
+// without waiting for the hub goroutine. A message no socket took is kept
+// in the inbox, when there is one, and sent when the user next connects.
+// It reports whether any socket took the message.
+func (h *WebSocketHub) SendToUser(userID string, payload interface{}) bool {
+	return h.sendToUser(userID, payload, true)
+}
+
+// SendLive delivers a JSON message to the user's open sockets like
+// SendToUser, but drops it rather than keeping it in the inbox, for updates
+// that are stale by the time the user reconnects
+func (h *WebSocketHub) SendLive(userID string, payload interface{}) bool {
+	return h.sendToUser(userID, payload, false)
+}
+
+func (h *WebSocketHub) sendToUser(userID string, payload interface{}, keep bool) bool {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshaling message for %s: %v", userID, err)
+		return false
+	}
+
+	clients := h.byUser.of(userID)
+	delivered := false
+	for _, client := range clients {
+		if client.trySend(outboundMessage{text: message}) {
+			delivered = true
+		}
+	}
+	switch {
+	case delivered:
+		h.delivery.delivered.Add(1)
+		return true
+	case len(clients) > 0:
+		h.delivery.dropped.Add(1)
+	default:
+		h.delivery.offline.Add(1)
+	}
+
+	if keep && h.inbox != nil {
+		h.keep(userID, message)
+	}
+	return false
+}
+
+// keep stores an undelivered message in the user's inbox
+func (h *WebSocketHub) keep(userID string, message []byte) {
+	var stored map[string]interface{}
+	if err := json.Unmarshal(message, &stored); err != nil {
+		return // Only objects are kept
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.inbox.Store(ctx, userID, stored); err != nil {
+		log.Printf("Error keeping message for %s in the inbox: %v", userID, err)
+		return
+	}
+	h.delivery.inboxed.Add(1)
+}
+
+// DeliveryStats returns how messages sent to individual users have fared
+// since startup
+func (h *WebSocketHub) DeliveryStats() models.DeliveryStats {
+	return models.DeliveryStats{
+		Delivered: h.delivery.delivered.Load(),
+		Dropped:   h.delivery.dropped.Load(),
+		Offline:   h.delivery.offline.Load(),
+		Inboxed:   h.delivery.inboxed.Load(),
+	}
+}
+
+// sendInbox sends a connecting client the messages kept while the user was
+// away, each marked with inbox and the time it was kept. Only the messages
+// the client's queue took are marked delivered; the rest wait for the next
+// connection. Runs on the hub goroutine.
+func (h *WebSocketHub) sendInbox(client *WebSocketClient) {
+	var queued []primitive.ObjectID
+	for _, kept := range client.inbox {
+		kept.Message["inbox"] = true
+		kept.Message["keptAt"] = kept.CreatedAt
+		message, err := json.Marshal(kept.Message)
+		if err != nil {
+			continue
+		}
+		if client.trySend(outboundMessage{text: message}) {
+			queued = append(queued, kept.ID)
+		}
+	}
+	client.inbox = nil
+	if len(queued) > 0 && h.inbox != nil {
+		go h.markDelivered(client.userID, queued)
+	}
+}
+
+// markDelivered records that inbox messages were queued on a socket, off the
+// hub goroutine
+func (h *WebSocketHub) markDelivered(userID string, ids []primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.inbox.MarkDelivered(ctx, ids); err != nil {
+		log.Printf("Error marking inbox messages delivered for %s: %v", userID, err)
+	}
+}
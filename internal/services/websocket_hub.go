@@ -1,10 +1,18 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"trading-simulator/config"
+	"trading-simulator/internal/chaos"
 	"trading-simulator/internal/models"
 	"github.com/gorilla/websocket"
 )
@@ -14,74 +22,750 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+
+	// PriceChannel carries stock ticks
+	PriceChannel = "prices"
+	// Number of recent messages kept per channel for resume
+	resumeBufferSize = 256
+	// How long a client may keep a backlog of coalesced updates before it is dropped
+	slowClientTimeout = 15 * time.Second
+	// Ticks and direct messages queued for the hub goroutine before new ones are dropped
+	broadcastBufferSize = 1024
 )
 
+// StockMessage is a stock tick tagged with its channel sequence number
+type StockMessage struct {
+	Channel string `json:"channel"`
+	Seq     uint64 `json:"seq"`
+	models.Stock
+}
+
+// clientCommand is a message sent by the client over the socket. Replies
+// echo ID so clients can match them to their commands.
+type clientCommand struct {
+	Action  string   `json:"action"` // "resume", "ping", "subscribe", "unsubscribe", "place_order" or "cancel_order"
+	ID      string   `json:"id,omitempty"`
+	Channel string   `json:"channel"`
+	Since   uint64   `json:"since"`
+	Symbols []string `json:"symbols,omitempty"` // For subscribe and unsubscribe
+
+	CompetitionID string `json:"competitionId,omitempty"` // For the leaderboard channel
+
+	Order   *SocketOrder `json:"order,omitempty"`   // For place_order
+	OrderID string       `json:"orderId,omitempty"` // For cancel_order
+}
+
+// outboundMessage holds the encodings of one message. Control messages only
+// have a text form; ticks carry a binary frame for clients that opted in.
+type outboundMessage struct {
+	text   []byte
+	binary []byte
+}
+
+// directMessage is a control message for every client
+type directMessage struct {
+	payload []byte
+}
+
+type resumeRequest struct {
+	client  *WebSocketClient
+	channel string
+	since   uint64
+}
+
+type heartbeatRequest struct {
+	client *WebSocketClient
+	id     string
+}
+
+type subscriptionChange struct {
+	client    *WebSocketClient
+	id        string
+	channel   string // PriceChannel, PresenceChannel or LeaderboardChannel
+	symbols   []string
+	subscribe bool
+
+	competitionID string // For LeaderboardChannel
+}
+
+// removal is a client the hub closes once it has finished the current event
+type removal struct {
+	client *WebSocketClient
+	code   int
+	reason string
+}
+
+// disconnectRequest asks the hub to close a client that broke a limit
+type disconnectRequest struct {
+	client *WebSocketClient
+	reason string
+	detail string
+}
+
+// ClientLimits protect the hub from misbehaving connections
+type ClientLimits struct {
+	MaxSubscriptions  int           // Symbols one client may subscribe to
+	MaxMessageRate    int           // Inbound messages per second before the client is dropped
+	AnonymousIdleTime time.Duration // Unauthenticated clients that send nothing for this long are dropped; zero to keep them
+}
+
+// kickRequest asks the hub to close a client by ID from outside Run
+type kickRequest struct {
+	id     uint64
+	reason string
+	found  chan bool
+}
+
+// clientReply is a response to a command handled off the hub goroutine
+type clientReply struct {
+	client  *WebSocketClient
+	payload map[string]interface{}
+}
+
 type WebSocketHub struct {
 	clients    map[*WebSocketClient]bool
 	broadcast  chan models.Stock
 	register   chan *WebSocketClient
 	unregister chan *WebSocketClient
+	resume     chan resumeRequest
+	heartbeat  chan heartbeatRequest
+	subscribe  chan subscriptionChange
+	replies    chan clientReply
+	disconnect chan disconnectRequest
+	kick       chan kickRequest
+	inspect    chan chan []models.WSConnection
+	nextID     uint64 // Owned by Run
+	limits     ClientLimits
+	direct     chan directMessage
+	sequences  map[string]uint64
+	history    map[string]*messageRing
+	latest     map[string]models.Stock // Last tick per symbol, for connect snapshots
+	removals   []removal               // Clients to close after the current event, owned by Run
+	byUser     *userConnections        // Authenticated clients by user ID, for SendToUser
+	delivery   deliveryCounters
+	inbox      *InboxService // Keeps messages for offline users; nil drops them
+
+	trading  *SocketTrading // Handles order commands; nil disables them
+	presence *presenceTracker
+
+	leaderboard  chan leaderboardUpdate
+	leaderboards *LeaderboardFeed // Serves leaderboard snapshots; nil disables the channel
+
+	clientCount  atomic.Int64  // Mirrors len(clients) for readers outside Run
+	greeting     atomic.Value  // []byte sent to each new client, empty for none
+	ticksDropped atomic.Uint64 // Ticks discarded because the broadcast buffer was full
 }
 
 type WebSocketClient struct {
 	hub      *WebSocketHub
 	conn     *websocket.Conn
-	send     chan []byte
+	send     chan outboundMessage
 	username string
+	userID   string // Set for sockets authenticated with a token
+	tenantID string
+	binary   bool
+	symbols  map[string]bool // Symbols whose ticks are sent; nil for all
+
+	hidePresence bool // Not listed in presence events
+	presence     bool // Subscribed to presence events
+
+	leaderboards map[string]bool // Competitions whose live leaderboard is sent
+
+	id          uint64
+	remoteAddr  string
+	connectedAt time.Time
+	sent        atomic.Uint64 // Frames written by WritePump
+	dropped     atomic.Uint64 // Messages skipped or coalesced because the buffer was full
+
+	// Guards closing send, so any goroutine can offer a message without
+	// racing the hub closing the client
+	sendMu sync.Mutex
+	closed bool
+
+	// Sent in the connect snapshot, then dropped
+	openOrders []models.Order
+	// Kept while the user was offline, sent after the snapshot, then dropped
+	inbox []models.InboxMessage
+
+	// Latest undelivered tick per symbol while the send buffer is full.
+	// Owned by the hub goroutine.
+	pending   map[string]outboundMessage
+	slowSince time.Time
+
+	// Close frame sent by WritePump once send is closed. Set under sendMu.
+	closeCode   int
+	closeReason string
 }
 
 func NewWebSocketHub() *WebSocketHub {
 	return &WebSocketHub{
 		clients:    make(map[*WebSocketClient]bool),
-		broadcast:  make(chan models.Stock),
+		broadcast:  make(chan models.Stock, broadcastBufferSize),
 		register:   make(chan *WebSocketClient),
 		unregister: make(chan *WebSocketClient),
+		resume:     make(chan resumeRequest),
+		heartbeat:  make(chan heartbeatRequest),
+		subscribe:  make(chan subscriptionChange),
+		replies:    make(chan clientReply),
+		disconnect: make(chan disconnectRequest),
+		kick:       make(chan kickRequest),
+		inspect:    make(chan chan []models.WSConnection),
+		limits: ClientLimits{
+			MaxSubscriptions:  config.GetEnvInt("WS_MAX_SUBSCRIPTIONS", 50),
+			MaxMessageRate:    config.GetEnvInt("WS_MAX_MESSAGES_PER_SECOND", 10),
+			AnonymousIdleTime: time.Duration(config.GetEnvInt("WS_ANONYMOUS_IDLE_SECONDS", 600)) * time.Second,
+		},
+		direct:     make(chan directMessage, broadcastBufferSize),
+		sequences:  make(map[string]uint64),
+		history:    make(map[string]*messageRing),
+		latest:     make(map[string]models.Stock),
+		byUser:     newUserConnections(),
+		presence:   newPresenceTracker(),
+		leaderboard: make(chan leaderboardUpdate),
 	}
 }
 
+// ClientOptions configure a new WebSocket connection
+type ClientOptions struct {
+	Username string
+	UserID   string // Authenticated user, who may trade over the socket
+	TenantID string
+	// Keep the user out of presence events; they are still counted online
+	HidePresence bool
+	Binary   bool           // Send price ticks as compact binary frames instead of JSON
+	Symbols  []string       // Only send these symbols; empty for all
+	Orders   []models.Order // Open orders of an authenticated user, sent with the connect snapshot
+	Inbox    []models.InboxMessage // Messages kept while the user was offline, sent after the snapshot
+}
+
+// Run owns the client set. Each event is handled in full before the clients
+// it removed are closed, so no handler changes the set while ranging over it.
 func (h *WebSocketHub) Run() {
 	for {
-		select {
-		case client := <-h.register:
-			h.clients[client] = true
-			log.Printf("Client connected. Total clients: %d", len(h.clients))
-		
-		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				log.Printf("Client disconnected. Total clients: %d", len(h.clients))
-			}
-		
-		case stock := <-h.broadcast:
-			message, err := json.Marshal(stock)
-			if err != nil {
-				log.Printf("Error marshaling stock data: %v", err)
-				continue
-			}
+		h.handleNext()
+		h.flushRemovals()
+	}
+}
+
+func (h *WebSocketHub) handleNext() {
+	select {
+	case client := <-h.register:
+		h.nextID++
+		client.id = h.nextID
+		h.clients[client] = true
+		h.clientCount.Store(int64(len(h.clients)))
+		h.byUser.add(client)
+		if greeting, _ := h.greeting.Load().([]byte); len(greeting) > 0 {
+			client.trySend(outboundMessage{text: greeting})
+		}
+		h.sendSnapshot(client)
+		h.sendInbox(client)
+		h.updatePresence(client, true)
+		log.Printf("Client connected. Total clients: %d", len(h.clients))
+
+	case client := <-h.unregister:
+		if _, ok := h.clients[client]; ok {
+			h.removeClient(client, websocket.CloseNormalClosure, "")
+		}
+
+	case req := <-h.resume:
+		h.replay(req)
+
+	case req := <-h.heartbeat:
+		h.sendPong(req)
+
+	case change := <-h.subscribe:
+		h.changeSubscription(change)
 
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+	case req := <-h.disconnect:
+		h.dropClient(req)
+
+	case req := <-h.kick:
+		req.found <- h.kickClient(req)
+
+	case reply := <-h.inspect:
+		reply <- h.connections()
+
+	case reply := <-h.replies:
+		if _, ok := h.clients[reply.client]; ok {
+			h.sendControl(reply.client, reply.payload)
+		}
+
+	case msg := <-h.direct:
+		for client := range h.clients {
+			client.trySend(outboundMessage{text: msg.payload})
+		}
+
+	case stock := <-h.broadcast:
+		h.publishTick(stock)
+
+	case update := <-h.leaderboard:
+		h.publishLeaderboard(update)
+	}
+}
+
+// publishTick sequences a tick, records it for resume and fans it out to
+// every client, dropping those that have lagged for too long
+func (h *WebSocketHub) publishTick(stock models.Stock) {
+	seq := h.sequences[PriceChannel] + 1
+	text, err := json.Marshal(StockMessage{Channel: PriceChannel, Seq: seq, Stock: stock})
+	if err != nil {
+		log.Printf("Error marshaling stock data: %v", err)
+		return
+	}
+	message := outboundMessage{text: text, binary: encodeTickFrame(seq, stock)}
+	h.sequences[PriceChannel] = seq
+	h.channelHistory(PriceChannel).push(seq, message)
+	h.latest[stock.Symbol] = stock
+
+	for client := range h.clients {
+		if !client.wants(stock.Symbol) {
+			continue
+		}
+		if !client.deliver(stock.Symbol, message) {
+			log.Printf("Dropping slow WebSocket client %s (backlog of %d symbols)", client.username, len(client.pending))
+			h.removeClient(client, websocket.ClosePolicyViolation, "slow consumer")
+		}
+	}
+}
+
+// sendSnapshot sends a new client the latest price of each of its symbols,
+// and its open orders when authenticated, before any ticks. The snapshot
+// carries the current sequence so the client can resume from it.
+func (h *WebSocketHub) sendSnapshot(client *WebSocketClient) {
+	stocks := make([]models.Stock, 0, len(h.latest))
+	for symbol, stock := range h.latest {
+		if client.wants(symbol) {
+			stocks = append(stocks, stock)
+		}
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i].Symbol < stocks[j].Symbol })
+
+	snapshot := map[string]interface{}{
+		"type":    "snapshot",
+		"channel": PriceChannel,
+		"seq":     h.sequences[PriceChannel],
+		"stocks":  stocks,
+	}
+	if client.openOrders != nil {
+		snapshot["orders"] = client.openOrders
+		client.openOrders = nil
+	}
+	h.sendControl(client, snapshot)
+}
+
+// wants reports whether the client receives ticks for the symbol
+func (c *WebSocketClient) wants(symbol string) bool {
+	return c.symbols == nil || c.symbols[symbol]
+}
+
+// Limits returns the per-connection limits
+func (h *WebSocketHub) Limits() ClientLimits {
+	return h.limits
+}
+
+// Connections lists the connected clients for the admin introspection
+// endpoint. It is answered by the hub goroutine, so the list is consistent.
+func (h *WebSocketHub) Connections() []models.WSConnection {
+	reply := make(chan []models.WSConnection, 1)
+	h.inspect <- reply
+	return <-reply
+}
+
+// Disconnect closes a client by ID, telling it an admin closed it. It
+// reports false if no such client is connected.
+func (h *WebSocketHub) Disconnect(id uint64, reason string) bool {
+	found := make(chan bool, 1)
+	h.kick <- kickRequest{id: id, reason: reason, found: found}
+	return <-found
+}
+
+func (h *WebSocketHub) connections() []models.WSConnection {
+	list := make([]models.WSConnection, 0, len(h.clients))
+	for client := range h.clients {
+		conn := models.WSConnection{
+			ID:              client.id,
+			Username:        client.username,
+			UserID:          client.userID,
+			TenantID:        client.tenantID,
+			RemoteAddr:      client.remoteAddr,
+			Binary:          client.binary,
+			AllSymbols:      client.symbols == nil,
+			Subscriptions:   []string{},
+			ConnectedAt:     client.connectedAt,
+			MessagesSent:    client.sent.Load(),
+			MessagesDropped: client.dropped.Load(),
+			Backlog:         len(client.send) + len(client.pending),
+			Leaderboards:    client.leaderboardCompetitions(),
+		}
+		for symbol := range client.symbols {
+			conn.Subscriptions = append(conn.Subscriptions, symbol)
+		}
+		sort.Strings(conn.Subscriptions)
+		list = append(list, conn)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (h *WebSocketHub) kickClient(req kickRequest) bool {
+	for client := range h.clients {
+		if client.id == req.id {
+			detail := "disconnected by an administrator"
+			if req.reason != "" {
+				detail += ": " + req.reason
 			}
+			h.dropClient(disconnectRequest{client: client, reason: "admin_disconnect", detail: detail})
+			return true
+		}
+	}
+	return false
+}
+
+// dropClient tells a client which limit it broke, then closes it with a
+// policy violation whose close reason is the same machine-readable code
+func (h *WebSocketHub) dropClient(req disconnectRequest) {
+	if _, ok := h.clients[req.client]; !ok {
+		return
+	}
+	h.sendControl(req.client, map[string]interface{}{
+		"type":   "disconnect",
+		"reason": req.reason,
+		"detail": req.detail,
+	})
+	log.Printf("Disconnecting WebSocket client %s: %s", req.client.username, req.detail)
+	h.removeClient(req.client, websocket.ClosePolicyViolation, req.reason)
+}
+
+// removeClient queues a client to be unregistered once the hub has finished
+// the current event, which may still be ranging over the clients
+func (h *WebSocketHub) removeClient(client *WebSocketClient, code int, reason string) {
+	h.removals = append(h.removals, removal{client: client, code: code, reason: reason})
+}
+
+// flushRemovals unregisters the clients queued by removeClient and closes
+// their send channels so WritePump sends a close frame with the given code
+// and reason
+func (h *WebSocketHub) flushRemovals() {
+	if len(h.removals) == 0 {
+		return
+	}
+	removals := h.removals
+	h.removals = nil
+	for _, r := range removals {
+		if _, ok := h.clients[r.client]; !ok {
+			continue // Queued twice in one event
+		}
+		delete(h.clients, r.client)
+		h.clientCount.Store(int64(len(h.clients)))
+		h.byUser.remove(r.client)
+		h.updatePresence(r.client, false)
+		r.client.closeSend(r.code, r.reason)
+		log.Printf("Client disconnected. Total clients: %d", len(h.clients))
+	}
+}
+
+// offer queues a message without blocking and reports whether there was
+// room. Any goroutine may call it; once the client is closed it reports
+// false.
+func (c *WebSocketClient) offer(message outboundMessage) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// trySend offers a message, counting it as dropped when the buffer is full
+func (c *WebSocketClient) trySend(message outboundMessage) bool {
+	if c.offer(message) {
+		return true
+	}
+	c.dropped.Add(1)
+	return false
+}
+
+// closeSend closes the send channel once
+func (c *WebSocketClient) closeSend(code int, reason string) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.closeCode = code
+	c.closeReason = reason
+	close(c.send)
+}
+
+// deliver queues a tick for the client. When the send buffer is full the tick
+// replaces any older undelivered tick for the same symbol, so a lagging client
+// only ever receives the latest price. It returns false once the client has
+// been lagging for longer than slowClientTimeout.
+func (c *WebSocketClient) deliver(symbol string, message outboundMessage) bool {
+	c.flushPending()
+
+	if len(c.pending) == 0 {
+		if c.offer(message) {
+			return true
 		}
+		c.slowSince = time.Now()
+	}
+
+	if c.pending == nil {
+		c.pending = make(map[string]outboundMessage)
 	}
+	if _, ok := c.pending[symbol]; ok {
+		c.dropped.Add(1) // Superseded before it was sent
+	}
+	c.pending[symbol] = message
+	return time.Since(c.slowSince) < slowClientTimeout
+}
+
+// flushPending moves coalesced ticks into the send buffer while there is room
+func (c *WebSocketClient) flushPending() {
+	for symbol, message := range c.pending {
+		if !c.offer(message) {
+			return
+		}
+		delete(c.pending, symbol)
+	}
+}
+
+// ClientCount returns the number of connected WebSocket clients
+func (h *WebSocketHub) ClientCount() int {
+	return int(h.clientCount.Load())
 }
 
+// TicksDropped returns how many ticks were discarded because the hub fell a
+// full buffer behind
+func (h *WebSocketHub) TicksDropped() uint64 {
+	return h.ticksDropped.Load()
+}
+
+// BroadcastStock queues a tick for every client without waiting for the
+// hub. When the hub is a full buffer behind the tick is dropped; the next
+// tick for the symbol carries its latest price anyway.
 func (h *WebSocketHub) BroadcastStock(stock models.Stock) {
-	h.broadcast <- stock
+	select {
+	case h.broadcast <- stock:
+	default:
+		if h.ticksDropped.Add(1)%1000 == 1 {
+			log.Printf("⚠️ WebSocket broadcast buffer full, %d ticks dropped so far", h.ticksDropped.Load())
+		}
+	}
 }
 
-func (h *WebSocketHub) RegisterClient(conn *websocket.Conn, username string) *WebSocketClient {
+// Broadcast delivers a JSON message to every connected client
+func (h *WebSocketHub) Broadcast(payload interface{}) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshaling broadcast: %v", err)
+		return
+	}
+	h.direct <- directMessage{payload: message}
+}
+
+// SetGreeting sets a JSON message sent to each client as it connects.
+// A nil payload clears it.
+func (h *WebSocketHub) SetGreeting(payload interface{}) {
+	if payload == nil {
+		h.greeting.Store([]byte(nil))
+		return
+	}
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshaling greeting: %v", err)
+		return
+	}
+	h.greeting.Store(message)
+}
+
+func (h *WebSocketHub) channelHistory(channel string) *messageRing {
+	ring, ok := h.history[channel]
+	if !ok {
+		ring = newMessageRing(resumeBufferSize)
+		h.history[channel] = ring
+	}
+	return ring
+}
+
+// replay resends buffered messages newer than the client's last seen sequence.
+// If the requested sequence has already fallen out of the buffer the client is
+// told about the gap so it can refetch state over REST.
+func (h *WebSocketHub) replay(req resumeRequest) {
+	if _, ok := h.clients[req.client]; !ok {
+		return
+	}
+	// Leaderboards are only replayed to their subscribers, who were checked
+	// to be in the competition's tenant
+	if !req.client.follows(req.channel) {
+		return
+	}
+
+	ring := h.channelHistory(req.channel)
+	if oldest := ring.oldest(); oldest > 0 && req.since+1 < oldest {
+		h.sendControl(req.client, map[string]interface{}{
+			"type":      "resume_gap",
+			"channel":   req.channel,
+			"since":     req.since,
+			"oldestSeq": oldest,
+		})
+	}
+
+	replayed := 0
+	for _, msg := range ring.after(req.since) {
+		if req.client.trySend(msg) {
+			replayed++
+		}
+	}
+	log.Printf("Resumed %s for %s from seq %d (%d messages)", req.channel, req.client.username, req.since, replayed)
+}
+
+// sendPong answers a client heartbeat with the latest sequence per channel
+func (h *WebSocketHub) sendPong(req heartbeatRequest) {
+	if _, ok := h.clients[req.client]; !ok {
+		return
+	}
+
+	seqs := make(map[string]uint64, len(h.sequences))
+	for channel, seq := range h.sequences {
+		if req.client.follows(channel) {
+			seqs[channel] = seq
+		}
+	}
+	pong := map[string]interface{}{
+		"type":       "pong",
+		"serverTime": time.Now().UTC(),
+		"sequences":  seqs,
+	}
+	if req.id != "" {
+		pong["id"] = req.id
+	}
+	h.sendControl(req.client, pong)
+}
+
+// changeSubscription adds or removes symbols from a client's tick filter.
+// Subscribing to symbols sends their latest ticks right away; unsubscribing
+// from every symbol leaves the client with no ticks rather than all of them.
+func (h *WebSocketHub) changeSubscription(change subscriptionChange) {
+	client := change.client
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	if change.channel == PresenceChannel {
+		h.changePresenceSubscription(change)
+		return
+	}
+	if change.channel == LeaderboardChannel {
+		h.changeLeaderboardSubscription(change)
+		return
+	}
+
+	if client.symbols == nil {
+		if !change.subscribe {
+			// Narrowing the full feed starts from every symbol seen so far
+			client.symbols = make(map[string]bool, len(h.latest))
+			for symbol := range h.latest {
+				client.symbols[symbol] = true
+			}
+		} else {
+			client.symbols = make(map[string]bool)
+		}
+	}
+
+	var added []models.Stock
+	var rejected []string
+	for _, symbol := range change.symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		if !change.subscribe {
+			delete(client.symbols, symbol)
+			continue
+		}
+		if !client.symbols[symbol] && len(client.symbols) >= h.limits.MaxSubscriptions {
+			rejected = append(rejected, symbol)
+			continue
+		}
+		if stock, ok := h.latest[symbol]; ok && !client.symbols[symbol] {
+			added = append(added, stock)
+		}
+		client.symbols[symbol] = true
+	}
+
+	symbols := make([]string, 0, len(client.symbols))
+	for symbol := range client.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	action := "unsubscribe"
+	if change.subscribe {
+		action = "subscribe"
+	}
+	reply := map[string]interface{}{
+		"type":    "response",
+		"action":  action,
+		"ok":      true,
+		"symbols": symbols,
+	}
+	if change.id != "" {
+		reply["id"] = change.id
+	}
+	if len(added) > 0 {
+		reply["stocks"] = added
+	}
+	if len(rejected) > 0 {
+		// The symbols that fit are still subscribed
+		reply["type"] = "error"
+		reply["ok"] = false
+		reply["code"] = "subscription_limit"
+		reply["error"] = fmt.Sprintf("at most %d symbols per connection", h.limits.MaxSubscriptions)
+		reply["rejected"] = rejected
+	}
+	h.sendControl(client, reply)
+}
+
+func (h *WebSocketHub) sendControl(client *WebSocketClient, payload map[string]interface{}) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshaling control message: %v", err)
+		return
+	}
+	client.trySend(outboundMessage{text: message})
+}
+
+// RegisterClient adds a connection to the hub. The client first receives a
+// snapshot of current prices, then streams ticks.
+func (h *WebSocketHub) RegisterClient(conn *websocket.Conn, opts ClientOptions) *WebSocketClient {
 	client := &WebSocketClient{
-		hub:      h,
-		conn:     conn,
-		send:     make(chan []byte, 256),
-		username: username,
+		hub:         h,
+		conn:        conn,
+		send:        make(chan outboundMessage, 256),
+		remoteAddr:  conn.RemoteAddr().String(),
+		connectedAt: time.Now().UTC(),
+		username:    opts.Username,
+		userID:      opts.UserID,
+		tenantID:    opts.TenantID,
+		binary:      opts.Binary,
+		openOrders:  opts.Orders,
+		inbox:       opts.Inbox,
+
+		hidePresence: opts.HidePresence,
+	}
+	for _, symbol := range opts.Symbols {
+		if client.symbols == nil {
+			client.symbols = make(map[string]bool)
+		}
+		client.symbols[strings.ToUpper(symbol)] = true
 	}
 	h.register <- client
 	return client
@@ -100,17 +784,91 @@ func (c *WebSocketClient) ReadPump() {
 		return nil
 	})
 
+	limits := c.hub.limits
+	var idle *time.Timer
+	if c.userID == "" && limits.AnonymousIdleTime > 0 {
+		idle = time.AfterFunc(limits.AnonymousIdleTime, func() {
+			c.hub.disconnect <- disconnectRequest{
+				client: c,
+				reason: "idle_timeout",
+				detail: fmt.Sprintf("no messages for %v on an unauthenticated connection", limits.AnonymousIdleTime),
+			}
+		})
+		defer idle.Stop()
+	}
+
+	// Messages in the current one-second window
+	windowStart, count := time.Now(), 0
+	dropped := false
+
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.ClosePolicyViolation) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+		// Once dropped, keep reading until WritePump's close frame ends the connection
+		if dropped {
+			continue
+		}
+		if idle != nil {
+			idle.Reset(limits.AnonymousIdleTime)
+		}
+
+		if now := time.Now(); now.Sub(windowStart) >= time.Second {
+			windowStart, count = now, 0
+		}
+		count++
+		if limits.MaxMessageRate > 0 && count > limits.MaxMessageRate {
+			dropped = true
+			c.hub.disconnect <- disconnectRequest{
+				client: c,
+				reason: "rate_limited",
+				detail: fmt.Sprintf("more than %d messages per second", limits.MaxMessageRate),
+			}
+			continue
+		}
+		c.handleCommand(data)
+	}
+}
+
+func (c *WebSocketClient) handleCommand(data []byte) {
+	var cmd clientCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return
+	}
+
+	switch cmd.Action {
+	case "resume":
+		channel := cmd.Channel
+		if channel == "" {
+			channel = PriceChannel
+		}
+		c.hub.resume <- resumeRequest{client: c, channel: channel, since: cmd.Since}
+	case "ping":
+		c.hub.heartbeat <- heartbeatRequest{client: c, id: cmd.ID}
+	case "subscribe", "unsubscribe":
+		c.hub.subscribe <- subscriptionChange{client: c, id: cmd.ID, channel: cmd.Channel, symbols: cmd.Symbols, subscribe: cmd.Action == "subscribe", competitionID: cmd.CompetitionID}
+	case "place_order", "cancel_order":
+		c.reply(c.hub.trading.Handle(c, cmd))
+	default:
+		c.reply(commandError(cmd, errUnknownCommand))
 	}
 }
 
+// reply queues a response to one of the client's commands
+func (c *WebSocketClient) reply(payload map[string]interface{}) {
+	c.hub.replies <- clientReply{client: c, payload: payload}
+}
+
+// SetTrading enables order commands over the socket. It must be called
+// before Run.
+func (h *WebSocketHub) SetTrading(trading *SocketTrading) {
+	h.trading = trading
+}
+
 func (c *WebSocketClient) WritePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
@@ -121,21 +879,31 @@ func (c *WebSocketClient) WritePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
+			// An injected failure drops the connection, as a network fault would
+			if ok && chaos.Inject(context.Background(), chaos.TargetWebSocket) != nil {
+				return
+			}
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeReason))
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
+			messageType, data := websocket.TextMessage, message.text
+			if c.binary && message.binary != nil {
+				messageType, data = websocket.BinaryMessage, message.binary
+			}
+
+			w, err := c.conn.NextWriter(messageType)
 			if err != nil {
 				return
 			}
-			w.Write(message)
+			w.Write(data)
 
 			if err := w.Close(); err != nil {
 				return
 			}
+			c.sent.Add(1)
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"trading-simulator/internal/models"
+)
+
+// BenchmarkHubBroadcast measures sequencing, encoding and fanning out one
+// tick to 100 subscribers. Clients have no connection; their send buffers
+// are drained after each tick the way WritePump would.
+func BenchmarkHubBroadcast(b *testing.B) {
+	hub := NewWebSocketHub()
+	for i := 0; i < 100; i++ {
+		hub.clients[&WebSocketClient{hub: hub, send: make(chan outboundMessage, 256)}] = true
+	}
+	stock := models.Stock{Symbol: "AAPL", Name: "Apple Inc.", Price: 189.25, Change: 1.1, ChangePercent: 0.58, Volume: 1000000}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stock.Timestamp = time.Now()
+		hub.publishTick(stock)
+		for client := range hub.clients {
+			<-client.send
+		}
+	}
+}
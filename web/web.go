@@ -0,0 +1,21 @@
+// Package web embeds the built frontend so the server can ship as a single
+// binary. Copy the SPA build output into web/dist before running go build;
+// the committed placeholder page is served until then.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist returns the embedded frontend build, rooted at its index.html
+func Dist() fs.FS {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err) // The dist directory is always embedded
+	}
+	return files
+}